@@ -1,16 +1,23 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"os"
-	"strings"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/nzws/flux-encoder/internal/controlplane/api"
 	"github.com/nzws/flux-encoder/internal/controlplane/auth"
 	"github.com/nzws/flux-encoder/internal/controlplane/balancer"
+	"github.com/nzws/flux-encoder/internal/shared/grpckeepalive"
+	"github.com/nzws/flux-encoder/internal/shared/grpctls"
 	"github.com/nzws/flux-encoder/internal/shared/logger"
+	"github.com/nzws/flux-encoder/internal/worker/uploader"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
@@ -21,6 +28,9 @@ import (
 
 const version = "0.1.0"
 
+// shutdownTimeout はシャットダウン時に処理中のリクエストの完了を待つ時間
+const shutdownTimeout = 10 * time.Second
+
 // @title Flux Encoder API
 // @version 0.1.0
 
@@ -48,40 +58,183 @@ func main() {
 		logger.Fatal("WORKER_NODES environment variable is required")
 	}
 
-	workerNodes := strings.Split(workerNodesStr, ",")
-	for i := range workerNodes {
-		workerNodes[i] = strings.TrimSpace(workerNodes[i])
+	workerDefaultPort := os.Getenv("WORKER_DEFAULT_PORT")
+	workerNodes, err := balancer.ParseWorkerAddresses(workerNodesStr, workerDefaultPort)
+	if err != nil {
+		logger.Fatal("Invalid WORKER_NODES", zap.Error(err))
 	}
 
 	workerTimeout := time.Duration(getEnvInt("WORKER_STARTUP_TIMEOUT", 60)) * time.Second
+	statusConcurrency := getEnvInt("WORKER_STATUS_CONCURRENCY", 8)
+	statusTimeout := time.Duration(getEnvInt("WORKER_STATUS_TIMEOUT", 5)) * time.Second
+	inputDir := getEnvOrDefault("INPUT_DIR", "/tmp/flux-inputs")
+	maxInputSizeMB := getEnvInt("MAX_INPUT_SIZE_MB", 5120)
+	maxInputTotalSizeMB := getEnvInt("MAX_INPUT_TOTAL_SIZE_MB", 20480)
+	inputTTL := time.Duration(getEnvInt("INPUT_TTL", int(api.DefaultInputTTL/time.Second))) * time.Second
+	publicBaseURL := getEnvOrDefault("PUBLIC_BASE_URL", "http://localhost:"+port)
+	grpcCompression := os.Getenv("GRPC_COMPRESSION")
+	maxOutputHeight := getEnvInt("MAX_OUTPUT_HEIGHT", 2160)
+	jobStatusTTL := time.Duration(getEnvInt("JOB_STATUS_TTL", 3600)) * time.Second
+	maxActiveDispatches := getEnvInt("MAX_ACTIVE_DISPATCHES", 0)
+	jobStateDir := os.Getenv("JOB_STATE_DIR")
+	workerMaxCPUPercent := getEnvInt("WORKER_MAX_CPU_PERCENT", 0)
+	rateLimitRPS := getEnvFloat("RATE_LIMIT_RPS", 0)
+	rateLimitBurst := getEnvInt("RATE_LIMIT_BURST", 0)
+	// X-Forwarded-For などからクライアント IP を取得してよいリバースプロキシ（指定しない場合は接続元のアドレスを使う）
+	trustedProxies := auth.ParseTrustedProxies(os.Getenv("TRUSTED_PROXIES"))
+	presetUsageMaxLabels := getEnvInt("PRESET_USAGE_MAX_LABELS", api.DefaultPresetUsageMaxLabels)
+	// output.metadata の件数と合計サイズ（バイト）の上限（Worker と同じ値にする。0 の場合は制限しない）
+	metadataLimits := uploader.MetadataLimits{
+		MaxEntries: getEnvInt("METADATA_MAX_ENTRIES", uploader.DefaultMaxMetadataEntries),
+		MaxBytes:   getEnvInt("METADATA_MAX_BYTES", uploader.DefaultMaxMetadataBytes),
+	}
+	// 管理者用のエンドポイント（失敗したジョブの一覧・再投入など）を利用できる API Key の名前
+	adminKeys := auth.ParseAdminKeys(os.Getenv("ADMIN_API_KEYS"))
+	presetAllowlist, err := auth.ParsePresetAllowlist(os.Getenv("API_KEY_PRESETS"))
+	if err != nil {
+		logger.Fatal("Invalid API_KEY_PRESETS", zap.Error(err))
+	}
+	// Worker との接続を維持する keepalive ping の間隔と応答の待ち時間（秒、未設定の場合はデフォルト値）
+	keepaliveConfig, err := grpckeepalive.ParseConfig(os.Getenv("GRPC_KEEPALIVE_INTERVAL"), os.Getenv("GRPC_KEEPALIVE_TIMEOUT"))
+	if err != nil {
+		logger.Fatal("Invalid gRPC keepalive configuration", zap.Error(err))
+	}
+	workerTLS := grpctls.ClientConfig{
+		CAFile:     os.Getenv("WORKER_TLS_CA"),
+		CertFile:   os.Getenv("WORKER_CLIENT_CERT"),
+		KeyFile:    os.Getenv("WORKER_CLIENT_KEY"),
+		ServerName: os.Getenv("WORKER_TLS_SERVER_NAME"),
+	}
 
 	logger.Info("Control plane configuration",
 		zap.String("port", port),
 		zap.Strings("workers", workerNodes),
+		zap.String("worker_default_port", workerDefaultPort),
 		zap.Duration("worker_timeout", workerTimeout),
+		zap.Int("worker_status_concurrency", statusConcurrency),
+		zap.Duration("worker_status_timeout", statusTimeout),
+		zap.String("input_dir", inputDir),
+		zap.Int("max_input_size_mb", maxInputSizeMB),
+		zap.Int("max_input_total_size_mb", maxInputTotalSizeMB),
+		zap.Duration("input_ttl", inputTTL),
+		zap.String("public_base_url", publicBaseURL),
+		zap.String("grpc_compression", grpcCompression),
+		zap.Duration("grpc_keepalive_interval", keepaliveConfig.Interval),
+		zap.Duration("grpc_keepalive_timeout", keepaliveConfig.Timeout),
+		zap.Int("max_output_height", maxOutputHeight),
+		zap.Duration("job_status_ttl", jobStatusTTL),
+		zap.Int("max_active_dispatches", maxActiveDispatches),
+		zap.String("job_state_dir", jobStateDir),
+		zap.Int("worker_max_cpu_percent", workerMaxCPUPercent),
+		zap.Float64("rate_limit_rps", rateLimitRPS),
+		zap.Int("rate_limit_burst", rateLimitBurst),
+		zap.Strings("trusted_proxies", trustedProxies),
+		zap.Int("preset_restricted_keys", len(presetAllowlist)),
+		zap.Int("admin_keys", len(adminKeys)),
+		zap.Int("preset_usage_max_labels", presetUsageMaxLabels),
+		zap.Int("metadata_max_entries", metadataLimits.MaxEntries),
+		zap.Int("metadata_max_bytes", metadataLimits.MaxBytes),
+		zap.Bool("worker_tls", workerTLS.Enabled()),
+		zap.Bool("worker_mtls", workerTLS.CertFile != ""),
 	)
 
 	// Balancer 作成
 	bal := balancer.New(workerNodes, workerTimeout)
+	bal.SetStatusFanOut(statusConcurrency, statusTimeout)
+	bal.SetMaxCPUPercent(float64(workerMaxCPUPercent))
+	if err := bal.SetCompression(grpcCompression); err != nil {
+		logger.Fatal("Invalid GRPC_COMPRESSION", zap.Error(err))
+	}
+	if err := bal.SetTLS(workerTLS); err != nil {
+		logger.Fatal("Invalid worker TLS configuration", zap.Error(err))
+	}
+	bal.SetKeepalive(keepaliveConfig)
 
 	// API ハンドラー作成
 	handler := api.NewHandler(bal)
+	handler.SetMaxOutputHeight(maxOutputHeight)
+	handler.SetJobStatusTTL(jobStatusTTL)
+	handler.SetMaxActiveDispatches(maxActiveDispatches)
+	handler.SetPresetAllowlist(presetAllowlist)
+	handler.SetPresetUsageMaxLabels(presetUsageMaxLabels)
+	handler.SetMetadataLimits(metadataLimits)
+
+	// ジョブの状態の永続化（未設定の場合は再起動で失われる）
+	if jobStateDir != "" {
+		jobStore, err := api.NewFileJobStore(jobStateDir)
+		if err != nil {
+			logger.Fatal("Failed to create job state store",
+				zap.String("dir", jobStateDir),
+				zap.Error(err),
+			)
+		}
+		// 保持期間を過ぎた状態は参照されないため起動時に削除する
+		removed, err := jobStore.Prune(time.Now().Add(-jobStatusTTL))
+		if err != nil {
+			logger.Warn("Failed to prune job states", zap.Error(err))
+		}
+		logger.Info("Job state persistence enabled",
+			zap.String("dir", jobStateDir),
+			zap.Int("pruned", removed),
+		)
+		handler.SetJobStore(jobStore)
+	}
+
+	// 入力アップロードの保存先作成
+	inputStore, err := api.NewInputStore(inputDir, int64(maxInputSizeMB)*1024*1024, publicBaseURL)
+	if err != nil {
+		logger.Fatal("Failed to create input store",
+			zap.String("dir", inputDir),
+			zap.Error(err),
+		)
+	}
+	inputStore.SetMaxTotalSize(int64(maxInputTotalSizeMB) * 1024 * 1024)
+	inputStore.SetTTL(inputTTL)
+	handler.SetInputStore(inputStore)
 
 	// Gin セットアップ
 	if !isDev {
 		gin.SetMode(gin.ReleaseMode)
 	}
 	r := gin.Default()
+	// gin は既定ですべてのプロキシを信頼するため、ヘッダーの偽装でクライアント IP ごとのレート制限を回避されないよう明示的に設定する
+	if err := r.SetTrustedProxies(trustedProxies); err != nil {
+		logger.Fatal("Invalid TRUSTED_PROXIES", zap.Error(err))
+	}
 
 	// 認証ミドルウェアを適用
 	r.Use(auth.APIKeyMiddleware())
 
+	// API Key ごとのレート制限（認証で設定されたキーの名前を使うため認証の後に適用する）
+	if rateLimitRPS > 0 {
+		r.Use(auth.NewRateLimiter(rateLimitRPS, rateLimitBurst).Middleware())
+	}
+
 	// ルート設定
 	v1 := r.Group("/api/v1")
 	{
 		v1.POST("/jobs", handler.CreateJob)
+		v1.POST("/jobs/dryrun", handler.DryRunJob)
+		v1.GET("/jobs/:id", handler.GetJob)
+		v1.DELETE("/jobs/:id", handler.CancelJob)
 		v1.GET("/jobs/:id/stream", handler.StreamJobProgress)
+		v1.GET("/jobs/:id/outputs", handler.GetJobOutputs)
+		v1.POST("/job-groups", handler.CreateJobGroup)
+		v1.GET("/job-groups/:id", handler.GetJobGroup)
+		v1.GET("/job-groups/:id/stream", handler.StreamJobGroupProgress)
 		v1.GET("/workers/status", handler.GetWorkerStatus)
+		v1.GET("/presets", handler.ListPresets)
+		v1.GET("/presets/usage", handler.GetPresetUsage)
+		v1.POST("/inputs", handler.CreateInput)
+		v1.GET("/inputs/:id", handler.GetInput)
+		v1.PATCH("/inputs/:id", handler.UploadInputChunk)
+		v1.GET("/inputs/:id/content", handler.DownloadInput)
+		v1.POST("/validate", handler.ValidateOutput)
+
+		// 管理者用（ADMIN_API_KEYS に含まれるキーのみ）
+		admin := v1.Group("", auth.RequireAdmin(adminKeys))
+		admin.GET("/jobs/failed", handler.ListFailedJobs)
+		admin.POST("/jobs/failed/:id/replay", handler.ReplayFailedJob)
 	}
 
 	// ヘルスチェック
@@ -95,11 +248,36 @@ func main() {
 	// Swagger UI
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
-	// サーバー起動
+	// シグナルハンドリング
+	srv := &http.Server{Addr: ":" + port, Handler: r}
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+
+	// shutdownDone は処理中のリクエストの完了を待ち終えると閉じる
+	shutdownDone := make(chan struct{})
+	go func() {
+		defer close(shutdownDone)
+		<-sigChan
+		logger.Info("Received shutdown signal, gracefully stopping...")
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := srv.Shutdown(ctx); err != nil {
+			logger.Warn("Failed to shut down server gracefully", zap.Error(err))
+		}
+	}()
+
+	// サーバー起動（シグナルで Shutdown されると ListenAndServe が戻る）
 	logger.Info("Control plane started", zap.String("addr", ":"+port))
-	if err := r.Run(":" + port); err != nil {
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 		logger.Fatal("Failed to start server", zap.Error(err))
 	}
+	<-shutdownDone
+
+	// 処理中のリクエストが終わってから Worker への接続を閉じる
+	if err := bal.Close(); err != nil {
+		logger.Warn("Failed to close worker connections", zap.Error(err))
+	}
+	logger.Info("Control plane stopped")
 }
 
 func getEnvOrDefault(key, defaultValue string) string {
@@ -118,3 +296,13 @@ func getEnvInt(key string, defaultValue int) int {
 	}
 	return defaultValue
 }
+
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		var f float64
+		if _, err := fmt.Sscanf(value, "%g", &f); err == nil {
+			return f
+		}
+	}
+	return defaultValue
+}
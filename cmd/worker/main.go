@@ -7,20 +7,33 @@ import (
 	"os"
 	"os/signal"
 	"strconv"
+	"strings"
 	"syscall"
+	"time"
 
+	"github.com/nzws/flux-encoder/internal/shared/execlimit"
+	"github.com/nzws/flux-encoder/internal/shared/grpckeepalive"
+	"github.com/nzws/flux-encoder/internal/shared/grpctls"
 	"github.com/nzws/flux-encoder/internal/shared/logger"
 	"github.com/nzws/flux-encoder/internal/worker/encoder"
 	workergrpc "github.com/nzws/flux-encoder/internal/worker/grpc"
+	"github.com/nzws/flux-encoder/internal/worker/preset"
+	"github.com/nzws/flux-encoder/internal/worker/sysload"
 	"github.com/nzws/flux-encoder/internal/worker/uploader"
+	"github.com/nzws/flux-encoder/internal/worker/validator"
 	workerv1 "github.com/nzws/flux-encoder/proto/worker/v1"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection"
 )
 
 const version = "0.1.0"
 
+// capabilityProbeTimeout は起動時に ffmpeg のフィルター・エンコーダーやハードウェアエンコードを調べる際のタイムアウト
+const capabilityProbeTimeout = 10 * time.Second
+
 func main() {
 	// ロガー初期化
 	isDev := os.Getenv("ENV") == "development"
@@ -35,18 +48,111 @@ func main() {
 	// 環境変数から設定を取得
 	port := getEnvOrDefault("GRPC_PORT", "50051")
 	maxConcurrent := getEnvInt("MAX_CONCURRENT_JOBS", 2)
+	jobQueueSize := getEnvInt("JOB_QUEUE_SIZE", 0)
 	workDir := getEnvOrDefault("WORK_DIR", "/tmp/ffmpeg-jobs")
 	storageType := getEnvOrDefault("STORAGE_TYPE", "s3")
 	workerID := getEnvOrDefault("WORKER_ID", "worker-1")
+	startupSelfTest := os.Getenv("STARTUP_SELFTEST") == "true"
+	selfTestPreset := getEnvOrDefault("SELFTEST_PRESET", encoder.DefaultSelfTestPreset)
+	capabilityProbe := os.Getenv("CAPABILITY_PROBE") != "false"
+	presetsFile := os.Getenv("PRESETS_FILE")
+	grpcCompression := os.Getenv("GRPC_COMPRESSION")
+	retryAfter := time.Duration(getEnvInt("BUSY_RETRY_AFTER", int(workergrpc.DefaultRetryAfter/time.Second))) * time.Second
+	reattachGrace := time.Duration(getEnvInt("STREAM_REATTACH_GRACE", int(workergrpc.DefaultReattachGrace/time.Second))) * time.Second
+	incrementalUpload := os.Getenv("INCREMENTAL_UPLOAD") == "true"
+	maxProbeOutputMB := getEnvInt("MAX_PROBE_OUTPUT_MB", execlimit.DefaultMaxOutputBytes>>20)
+	serverTLS := grpctls.ServerConfig{
+		CertFile:     os.Getenv("GRPC_TLS_CERT"),
+		KeyFile:      os.Getenv("GRPC_TLS_KEY"),
+		ClientCAFile: os.Getenv("GRPC_TLS_CLIENT_CA"),
+	}
+	incrementalUploadInterval := time.Duration(getEnvInt("INCREMENTAL_UPLOAD_INTERVAL", int(uploader.DefaultIncrementalUploadInterval/time.Second))) * time.Second
+	loadSampleInterval := time.Duration(getEnvInt("LOAD_SAMPLE_INTERVAL", int(sysload.DefaultInterval/time.Second))) * time.Second
+	gpuLoadSampling := os.Getenv("GPU_LOAD_SAMPLING") == "true"
+	allowRawArgs := os.Getenv("WORKER_ALLOW_RAW_ARGS") == "true"
+	// 管理者用 RPC（GetJobLogs）の認証トークン（未設定の場合は管理者用 RPC を拒否する）
+	adminToken := os.Getenv("WORKER_ADMIN_TOKEN")
+	verifyUpload := os.Getenv("VERIFY_UPLOAD") == "true"
+	hwAccel := os.Getenv("HW_ACCEL")
+	// 空文字列を指定した場合はグローバル引数を付けない（未設定の場合はデフォルト値）
+	ffmpegGlobalArgs := encoder.DefaultGlobalArgs()
+	if value, ok := os.LookupEnv("FFMPEG_GLOBAL_ARGS"); ok {
+		ffmpegGlobalArgs = strings.Fields(value)
+	}
+	// カスタムビルドなど PATH 以外にある ffmpeg・ffprobe を使用する場合に指定する（未設定の場合は PATH から探す）
+	ffmpegPath := getEnvOrDefault("FFMPEG_PATH", validator.DefaultFFmpegPath)
+	ffprobePath := getEnvOrDefault("FFPROBE_PATH", validator.DefaultFFprobePath)
+	idleTimeout := time.Duration(getEnvInt("WORKER_IDLE_TIMEOUT", 0)) * time.Second
+	// 開発用に自動停止を無効化する（WORKER_IDLE_TIMEOUT を設定していても停止しない）
+	disableAutoShutdown := os.Getenv("DISABLE_AUTO_SHUTDOWN") == "true" || os.Getenv("DISABLE_AUTO_SHUTDOWN") == "1"
+	progressHeartbeat := time.Duration(getEnvInt("PROGRESS_HEARTBEAT_INTERVAL", int(encoder.DefaultProgressHeartbeat/time.Second))) * time.Second
+	// 出力の検証のデフォルト設定（ジョブごとの validation で上書きできる）。未設定の項目は組み込みの値を使う
+	validationSettings, err := encoder.ValidationSettingsFromEnv(os.Getenv)
+	if err != nil {
+		logger.Fatal("Invalid validation configuration", zap.Error(err))
+	}
+	// Control Plane が送信する keepalive ping の間隔（秒、未設定の場合はデフォルト値）。この半分より短い間隔の ping は拒否する
+	keepaliveConfig, err := grpckeepalive.ParseConfig(os.Getenv("GRPC_KEEPALIVE_INTERVAL"), os.Getenv("GRPC_KEEPALIVE_TIMEOUT"))
+	if err != nil {
+		logger.Fatal("Invalid gRPC keepalive configuration", zap.Error(err))
+	}
+	// 検証の警告がこの件数を超えた出力を失敗にする（0 の場合は警告で失敗しない）
+	maxValidationWarnings := getEnvInt("VALIDATION_MAX_WARNINGS", 0)
+	// 受け付ける入力の長さ（秒）とサイズ（バイト）の上限（0 の場合は制限しない）
+	inputLimits := encoder.InputLimits{
+		MaxDuration: time.Duration(getEnvInt("MAX_INPUT_DURATION_SECONDS", 0)) * time.Second,
+		MaxSize:     int64(getEnvInt("MAX_INPUT_SIZE_BYTES", 0)),
+	}
+	// ジョブの出力のメタデータの件数と合計サイズ（バイト）の上限（0 の場合は制限しない）
+	metadataLimits := uploader.MetadataLimits{
+		MaxEntries: getEnvInt("METADATA_MAX_ENTRIES", uploader.DefaultMaxMetadataEntries),
+		MaxBytes:   getEnvInt("METADATA_MAX_BYTES", uploader.DefaultMaxMetadataBytes),
+	}
 
 	logger.Info("Worker configuration",
 		zap.String("port", port),
 		zap.Int("max_concurrent", maxConcurrent),
+		zap.Int("job_queue_size", jobQueueSize),
 		zap.String("work_dir", workDir),
 		zap.String("storage_type", storageType),
 		zap.String("worker_id", workerID),
+		zap.Bool("startup_selftest", startupSelfTest),
+		zap.Bool("capability_probe", capabilityProbe),
+		zap.String("grpc_compression", grpcCompression),
+		zap.Duration("grpc_keepalive_interval", keepaliveConfig.Interval),
+		zap.Duration("busy_retry_after", retryAfter),
+		zap.Duration("stream_reattach_grace", reattachGrace),
+		zap.Duration("idle_timeout", idleTimeout),
+		zap.Bool("auto_shutdown", !disableAutoShutdown),
+		zap.Bool("incremental_upload", incrementalUpload),
+		zap.Duration("incremental_upload_interval", incrementalUploadInterval),
+		zap.Int("max_probe_output_mb", maxProbeOutputMB),
+		zap.Bool("grpc_tls", serverTLS.Enabled()),
+		zap.Bool("grpc_mtls", serverTLS.ClientCAFile != ""),
+		zap.Duration("load_sample_interval", loadSampleInterval),
+		zap.Bool("gpu_load_sampling", gpuLoadSampling),
+		zap.Bool("allow_raw_args", allowRawArgs),
+		zap.Bool("admin_token", adminToken != ""),
+		zap.Duration("progress_heartbeat_interval", progressHeartbeat),
+		zap.Bool("verify_upload", verifyUpload),
+		zap.Strings("ffmpeg_global_args", ffmpegGlobalArgs),
+		zap.String("ffmpeg_path", ffmpegPath),
+		zap.String("ffprobe_path", ffprobePath),
+		zap.String("hw_accel", hwAccel),
+		zap.String("validation_level", validationSettings.Level),
+		zap.Duration("validation_timeout", validationSettings.Timeout),
+		zap.Bool("validation_skip_decode", validationSettings.SkipDecodeTest != nil && *validationSettings.SkipDecodeTest),
+		zap.String("hls_validation_depth", validationSettings.HLSValidationDepth),
+		zap.Int("validation_max_warnings", maxValidationWarnings),
+		zap.Duration("max_input_duration", inputLimits.MaxDuration),
+		zap.Int64("max_input_size_bytes", inputLimits.MaxSize),
+		zap.Int("metadata_max_entries", metadataLimits.MaxEntries),
+		zap.Int("metadata_max_bytes", metadataLimits.MaxBytes),
 	)
 
+	// ffprobe/ffmpeg の出力として読み込む上限（細工された入力による巨大な出力でメモリを使い果たさないようにする）
+	execlimit.SetMaxOutputBytes(int64(maxProbeOutputMB) << 20)
+
 	// 作業ディレクトリ作成
 	if err := os.MkdirAll(workDir, 0755); err != nil {
 		logger.Fatal("Failed to create work directory",
@@ -55,11 +161,70 @@ func main() {
 		)
 	}
 
+	// カスタムプリセット読み込み
+	if presetsFile != "" {
+		if err := preset.LoadFromFile(presetsFile); err != nil {
+			logger.Fatal("Failed to load presets file",
+				zap.String("path", presetsFile),
+				zap.Error(err),
+			)
+		}
+		logger.Info("Loaded custom presets",
+			zap.String("path", presetsFile),
+			zap.Int("loaded_presets", preset.CustomCount()),
+			zap.Int("total_presets", len(preset.List())),
+		)
+	}
+
 	// エンコーダー初期化
 	enc := encoder.New(workDir)
+	enc.SetBinaryPaths(ffmpegPath, ffprobePath)
+	enc.SetProgressHeartbeat(progressHeartbeat)
+	if err := enc.SetValidationDefaults(validationSettings); err != nil {
+		logger.Fatal("Invalid validation configuration", zap.Error(err))
+	}
+	enc.SetMaxValidationWarnings(maxValidationWarnings)
+	enc.SetInputLimits(inputLimits)
+	if err := enc.SetGlobalArgs(ffmpegGlobalArgs); err != nil {
+		logger.Fatal("Invalid FFMPEG_GLOBAL_ARGS", zap.Error(err))
+	}
 
-	// アップローダー初期化
 	ctx := context.Background()
+
+	// ハードウェアエンコード（利用できない場合は CPU のプリセットでエンコードする）
+	if hwAccel != "" {
+		if !preset.IsValidHardwareAccel(hwAccel) {
+			logger.Fatal("Invalid HW_ACCEL", zap.String("hw_accel", hwAccel))
+		}
+		probeCtx, cancelProbe := context.WithTimeout(ctx, capabilityProbeTimeout)
+		err := encoder.ProbeHardwareAccel(probeCtx, ffmpegPath, hwAccel)
+		cancelProbe()
+		if err != nil {
+			logger.Warn("Hardware acceleration is not available, falling back to CPU presets",
+				zap.String("hw_accel", hwAccel),
+				zap.Error(err),
+			)
+			hwAccel = ""
+		} else {
+			logger.Info("Hardware acceleration is available", zap.String("hw_accel", hwAccel))
+		}
+		if err := enc.SetHardwareAccel(hwAccel); err != nil {
+			logger.Fatal("Invalid HW_ACCEL", zap.Error(err))
+		}
+	}
+
+	// 起動時セルフテスト（ffmpeg やプリセットの不備をトラフィック受付前に検出する）
+	if startupSelfTest {
+		logger.Info("Running startup self-test", zap.String("preset", selfTestPreset))
+		if err := enc.SelfTest(ctx, selfTestPreset); err != nil {
+			logger.Fatal("Startup self-test failed",
+				zap.String("preset", selfTestPreset),
+				zap.Error(err),
+			)
+		}
+	}
+
+	// アップローダー初期化
 	upl, err := uploader.NewUploader(ctx, storageType)
 	if err != nil {
 		logger.Fatal("Failed to create uploader",
@@ -68,13 +233,72 @@ func main() {
 		)
 	}
 
-	// gRPC サーバー作成
-	grpcServer := grpc.NewServer()
+	// S3 ストレージの場合は s3:// の入力をアップロードと同じ AWS 設定でダウンロードする
+	if s3Uploader, ok := upl.(*uploader.S3Uploader); ok {
+		enc.SetInputDownloader(s3Uploader)
+	}
+
+	// gRPC サーバー作成（TLS 証明書が設定されている場合は TLS、クライアント CA が設定されている場合は mTLS）
+	serverOpts, err := grpctls.ServerOptions(serverTLS)
+	if err != nil {
+		logger.Fatal("Invalid gRPC TLS configuration", zap.Error(err))
+	}
+	// Control Plane が接続の維持に送信する keepalive ping を受け付ける
+	serverOpts = append(serverOpts, grpckeepalive.ServerOptions(keepaliveConfig)...)
+	grpcServer := grpc.NewServer(serverOpts...)
 	workerServer := workergrpc.NewServer(enc, upl, int32(maxConcurrent), workerID, version)
 	workerServer.SetGRPCServer(grpcServer)
+	workerServer.SetRetryAfter(retryAfter)
+	workerServer.SetQueueSize(jobQueueSize)
+	workerServer.SetReattachGrace(reattachGrace)
+	workerServer.SetIdleTimeout(idleTimeout)
+	workerServer.SetAutoShutdown(!disableAutoShutdown)
+	workerServer.SetIncrementalUpload(incrementalUpload, incrementalUploadInterval)
+	workerServer.SetAllowRawArgs(allowRawArgs)
+	workerServer.SetAdminToken(adminToken)
+	workerServer.SetMetadataLimits(metadataLimits)
+	if verifyUpload {
+		workerServer.SetUploadVerifier(uploader.NewUploadVerifier(nil))
+	}
+	if allowRawArgs {
+		logger.Warn("Raw ffmpeg args are allowed: jobs can pass arbitrary arguments to ffmpeg")
+	}
+	if err := workerServer.SetCompression(grpcCompression); err != nil {
+		logger.Fatal("Invalid GRPC_COMPRESSION", zap.Error(err))
+	}
+
+	// ffmpeg のフィルター・エンコーダーを調べて GetStatus で報告する（失敗しても起動は続け、報告しない）
+	if capabilityProbe {
+		probeCtx, cancelProbe := context.WithTimeout(ctx, capabilityProbeTimeout)
+		capabilities, err := encoder.ProbeCapabilities(probeCtx, ffmpegPath)
+		cancelProbe()
+		if err != nil {
+			logger.Warn("Failed to probe ffmpeg capabilities", zap.Error(err))
+		} else {
+			// 確認できなかった種類のハードウェアエンコーダーは報告しない
+			capabilities = capabilities.WithoutUnavailableHardwareEncoders(hwAccel)
+			workerServer.SetCapabilities(capabilities)
+			logger.Info("Probed ffmpeg capabilities",
+				zap.Int("filters", len(capabilities.Filters)),
+				zap.Int("encoders", len(capabilities.Encoders)),
+			)
+		}
+	}
+
+	// ホストの CPU・メモリ（・GPU）の負荷をバックグラウンドで定期的に取得して GetStatus で報告する（0 の場合は報告しない）
+	if loadSampleInterval > 0 {
+		sampler := sysload.NewSampler(sysload.NewHostSource(gpuLoadSampling), loadSampleInterval)
+		go sampler.Run(ctx)
+		workerServer.SetLoadSampler(sampler)
+	}
 
 	workerv1.RegisterWorkerServiceServer(grpcServer, workerServer)
 
+	// 標準の gRPC ヘルスチェック（Kubernetes の liveness/readiness probe 用）
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
+	workerServer.SetHealthServer(healthServer)
+
 	// リフレクション有効化（開発用）
 	if isDev {
 		reflection.Register(grpcServer)
@@ -93,14 +317,15 @@ func main() {
 	go func() {
 		<-sigChan
 		logger.Info("Received shutdown signal, gracefully stopping...")
-		grpcServer.GracefulStop()
+		workerServer.Stop()
 	}()
 
-	// サーバー起動
+	// サーバー起動（シグナルまたは自動停止で Stop されると Serve が戻り、プロセスが終了する）
 	logger.Info("Worker started", zap.String("addr", ":"+port))
 	if err := grpcServer.Serve(lis); err != nil {
 		logger.Fatal("Failed to serve", zap.Error(err))
 	}
+	logger.Info("Worker stopped")
 }
 
 func getEnvOrDefault(key, defaultValue string) string {
@@ -15,14 +15,14 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/jobs": {
+        "/inputs": {
             "post": {
                 "security": [
                     {
                         "bearerAuth": []
                     }
                 ],
-                "description": "Submit a new video encoding job to the Control Plane. The job will be distributed to an available Worker.",
+                "description": "Start a resumable upload of a source video. Send the data with PATCH /inputs/{id} and use input_url from the completed upload as a job's input_url. Uploads not updated for INPUT_TTL are deleted, and uploads are rejected with 507 while the declared sizes of stored uploads exceed MAX_INPUT_TOTAL_SIZE_MB.",
                 "consumes": [
                     "application/json"
                 ],
@@ -30,25 +30,25 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "jobs"
+                    "inputs"
                 ],
-                "summary": "Create encoding job",
+                "summary": "Create input upload",
                 "parameters": [
                     {
-                        "description": "Job parameters",
-                        "name": "job",
+                        "description": "Upload parameters",
+                        "name": "input",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/internal_controlplane_api.JobRequest"
+                            "$ref": "#/definitions/internal_controlplane_api.CreateInputRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "202": {
-                        "description": "Job accepted",
+                    "201": {
+                        "description": "Upload created",
                         "schema": {
-                            "$ref": "#/definitions/internal_controlplane_api.JobResponse"
+                            "$ref": "#/definitions/internal_controlplane_api.InputResponse"
                         }
                     },
                     "400": {
@@ -57,8 +57,20 @@ const docTemplate = `{
                             "$ref": "#/definitions/internal_controlplane_api.ErrorResponse"
                         }
                     },
-                    "503": {
-                        "description": "No available workers",
+                    "413": {
+                        "description": "Input too large",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controlplane_api.ErrorResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported content type",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controlplane_api.ErrorResponse"
+                        }
+                    },
+                    "507": {
+                        "description": "Input storage quota exceeded",
                         "schema": {
                             "$ref": "#/definitions/internal_controlplane_api.ErrorResponse"
                         }
@@ -66,25 +78,25 @@ const docTemplate = `{
                 }
             }
         },
-        "/jobs/{id}/stream": {
+        "/inputs/{id}": {
             "get": {
                 "security": [
                     {
                         "bearerAuth": []
                     }
                 ],
-                "description": "Get real-time job progress updates via Server-Sent Events (SSE)",
+                "description": "Get the current offset of an upload. Clients resume an interrupted upload from this offset.",
                 "produces": [
-                    "text/event-stream"
+                    "application/json"
                 ],
                 "tags": [
-                    "jobs"
+                    "inputs"
                 ],
-                "summary": "Stream job progress",
+                "summary": "Get input upload status",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Job ID",
+                        "description": "Input ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -92,127 +104,1781 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "SSE stream of job progress",
+                        "description": "OK",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/internal_controlplane_api.InputResponse"
                         }
                     },
                     "404": {
-                        "description": "Job not found",
+                        "description": "Input not found",
                         "schema": {
                             "$ref": "#/definitions/internal_controlplane_api.ErrorResponse"
                         }
                     }
                 }
-            }
-        },
-        "/workers/status": {
-            "get": {
+            },
+            "patch": {
                 "security": [
                     {
                         "bearerAuth": []
                     }
                 ],
-                "description": "Get status of all registered Workers (not implemented yet)",
+                "description": "Append a chunk at the offset given by the Upload-Offset header. The offset must match the current offset of the upload.",
+                "consumes": [
+                    "application/offset+octet-stream"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "workers"
+                    "inputs"
+                ],
+                "summary": "Upload input chunk",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Input ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Byte offset of this chunk",
+                        "name": "Upload-Offset",
+                        "in": "header",
+                        "required": true
+                    }
                 ],
-                "summary": "Get worker status",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/internal_controlplane_api.WorkerStatusResponse"
+                            "$ref": "#/definitions/internal_controlplane_api.InputResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controlplane_api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Input not found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controlplane_api.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Offset mismatch or concurrent upload",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controlplane_api.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Chunk exceeds declared size",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controlplane_api.ErrorResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported content type",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controlplane_api.ErrorResponse"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "internal_controlplane_api.ErrorResponse": {
-            "type": "object",
-            "properties": {
-                "error": {
-                    "type": "string",
-                    "example": "error message"
-                }
-            }
         },
-        "internal_controlplane_api.JobRequest": {
-            "type": "object",
-            "required": [
-                "input_url",
-                "output",
-                "preset"
-            ],
-            "properties": {
-                "input_url": {
-                    "type": "string",
-                    "example": "https://example.com/video.mp4"
-                },
-                "output": {
-                    "$ref": "#/definitions/internal_controlplane_api.OutputConfig"
-                },
-                "preset": {
-                    "type": "string",
-                    "example": "720p_h264"
+        "/inputs/{id}/content": {
+            "get": {
+                "description": "Serve a completed upload. This URL is returned as input_url and does not require authentication.",
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "inputs"
+                ],
+                "summary": "Download uploaded input",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Input ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "404": {
+                        "description": "Input not found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controlplane_api.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Upload not completed",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controlplane_api.ErrorResponse"
+                        }
+                    }
                 }
             }
         },
-        "internal_controlplane_api.JobResponse": {
-            "type": "object",
-            "properties": {
-                "job_id": {
-                    "type": "string",
-                    "example": "550e8400-e29b-41d4-a716-446655440000"
-                },
-                "status": {
-                    "type": "string",
-                    "example": "accepted"
-                },
-                "stream_url": {
-                    "type": "string",
-                    "example": "/api/v1/jobs/550e8400-e29b-41d4-a716-446655440000/stream"
+        "/job-groups": {
+            "post": {
+                "security": [
+                    {
+                        "bearerAuth": []
+                    }
+                ],
+                "description": "Encode one input with several presets in parallel. Each preset becomes its own job dispatched to an available Worker. If any job cannot be dispatched, the already dispatched jobs are cancelled and the group is rejected.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "job-groups"
+                ],
+                "summary": "Create job group",
+                "parameters": [
+                    {
+                        "description": "Job group parameters",
+                        "name": "group",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_controlplane_api.JobGroupRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Job group accepted",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controlplane_api.JobGroupResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controlplane_api.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "A preset is not allowed for the API key",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controlplane_api.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "No worker has the ffmpeg filters/encoders required by a preset",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controlplane_api.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "No available workers or worker is busy",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controlplane_api.ErrorResponse"
+                        },
+                        "headers": {
+                            "Retry-After": {
+                                "type": "string",
+                                "description": "Seconds to wait before retrying when the worker is busy"
+                            }
+                        }
+                    }
                 }
             }
         },
-        "internal_controlplane_api.OutputConfig": {
-            "type": "object",
-            "required": [
-                "path",
-                "storage"
-            ],
-            "properties": {
-                "metadata": {
-                    "type": "object",
-                    "additionalProperties": {
-                        "type": "string"
+        "/job-groups/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "bearerAuth": []
+                    }
+                ],
+                "description": "Get the aggregated progress of a job group and the status and output URL of each job. The final status is retained for the same period as job statuses.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "job-groups"
+                ],
+                "summary": "Get job group status",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Job group ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controlplane_api.JobGroupProgress"
+                        }
                     },
-                    "example": {
-                        "key1": "value1",
-                        "key2": "value2"
+                    "404": {
+                        "description": "Job group not found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controlplane_api.ErrorResponse"
+                        }
                     }
-                },
-                "path": {
-                    "type": "string",
-                    "example": "output/video.mp4"
-                },
-                "storage": {
-                    "type": "string",
-                    "example": "s3"
                 }
             }
         },
-        "internal_controlplane_api.WorkerStatusResponse": {
-            "type": "object",
-            "properties": {
-                "message": {
+        "/job-groups/{id}/stream": {
+            "get": {
+                "security": [
+                    {
+                        "bearerAuth": []
+                    }
+                ],
+                "description": "Stream the aggregated progress of a job group using Server-Sent Events. The stream ends once every job has finished; the last event contains all output URLs.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "job-groups"
+                ],
+                "summary": "Stream job group progress",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Job group ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "SSE stream of job group progress",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Job group not found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controlplane_api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/jobs": {
+            "post": {
+                "security": [
+                    {
+                        "bearerAuth": []
+                    }
+                ],
+                "description": "Submit a new video encoding job to the Control Plane. The job will be distributed to an available Worker.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "jobs"
+                ],
+                "summary": "Create encoding job",
+                "parameters": [
+                    {
+                        "description": "Job parameters",
+                        "name": "job",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_controlplane_api.JobRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Job accepted",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controlplane_api.JobResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controlplane_api.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Preset is not allowed for the API key, or raw ffmpeg args are not allowed",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controlplane_api.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "No worker has the ffmpeg filters/encoders required by the preset",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controlplane_api.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "No available workers or worker is busy",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controlplane_api.ErrorResponse"
+                        },
+                        "headers": {
+                            "Retry-After": {
+                                "type": "string",
+                                "description": "Seconds to wait before retrying when the worker is busy"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/jobs/dryrun": {
+            "post": {
+                "security": [
+                    {
+                        "bearerAuth": []
+                    }
+                ],
+                "description": "Build the ffmpeg command a Worker would run for the job without downloading the input or running ffmpeg. Useful for debugging presets and overrides.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "jobs"
+                ],
+                "summary": "Dry-run encoding job",
+                "parameters": [
+                    {
+                        "description": "Job parameters",
+                        "name": "job",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_controlplane_api.JobRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ffmpeg command",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controlplane_api.DryRunResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controlplane_api.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Preset is not allowed for the API key, or raw ffmpeg args are not allowed",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controlplane_api.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Worker failed to build the command",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controlplane_api.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "No available workers",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controlplane_api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/jobs/failed": {
+            "get": {
+                "security": [
+                    {
+                        "bearerAuth": []
+                    }
+                ],
+                "description": "List jobs that ended in failure (dead letters), newest first. Each entry contains the original request for replay, with URL query strings, URL credentials and the KMS key redacted. Cancelled jobs are not recorded. Requires an API key listed in ADMIN_API_KEYS.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "jobs"
+                ],
+                "summary": "List failed jobs",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/internal_controlplane_api.DeadLetter"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Admin API key required",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controlplane_api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to read dead letters",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controlplane_api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/jobs/failed/{id}/replay": {
+            "post": {
+                "security": [
+                    {
+                        "bearerAuth": []
+                    }
+                ],
+                "description": "Resubmit a failed job with its original request as a new job. The request is validated again with the current settings. The dead letter is removed once the new job is accepted. Requires an API key listed in ADMIN_API_KEYS.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "jobs"
+                ],
+                "summary": "Replay a failed job",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Failed job ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Job accepted",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controlplane_api.JobResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Original request is no longer valid",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controlplane_api.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Admin API key required, or preset is not allowed for the API key",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controlplane_api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Failed job not found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controlplane_api.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "No worker has the ffmpeg filters/encoders required by the preset",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controlplane_api.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "No available workers or worker is busy",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controlplane_api.ErrorResponse"
+                        },
+                        "headers": {
+                            "Retry-After": {
+                                "type": "string",
+                                "description": "Seconds to wait before retrying when the worker is busy"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/jobs/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "bearerAuth": []
+                    }
+                ],
+                "description": "Get the last known status of a job. The final status is retained for a limited time after the job finishes.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "jobs"
+                ],
+                "summary": "Get job status",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Job ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controlplane_api.JobStatusResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Job not found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controlplane_api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "bearerAuth": []
+                    }
+                ],
+                "description": "Cancel a running job. The request is forwarded to the Worker the job was dispatched to, which stops ffmpeg. The job then finishes with JOB_STATUS_FAILED.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "jobs"
+                ],
+                "summary": "Cancel job",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Job ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controlplane_api.CancelJobResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Job not found or not running",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controlplane_api.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Failed to cancel the job on the worker",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controlplane_api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/jobs/{id}/outputs": {
+            "get": {
+                "security": [
+                    {
+                        "bearerAuth": []
+                    }
+                ],
+                "description": "List every file the job uploaded (HLS/DASH segments, playlists, thumbnails) with its URL. Available once the job has completed, for the same period as the job status.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "jobs"
+                ],
+                "summary": "List job outputs",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Job ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controlplane_api.JobOutputsResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Job not found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controlplane_api.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Job has not completed",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controlplane_api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/jobs/{id}/stream": {
+            "get": {
+                "security": [
+                    {
+                        "bearerAuth": []
+                    }
+                ],
+                "description": "Get real-time job progress updates via Server-Sent Events (SSE)",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "jobs"
+                ],
+                "summary": "Stream job progress",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Job ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "SSE stream of job progress",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Job not found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controlplane_api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/presets": {
+            "get": {
+                "security": [
+                    {
+                        "bearerAuth": []
+                    }
+                ],
+                "description": "List the built-in encoding presets, sorted by name. Custom presets loaded only on Workers (PRESETS_FILE) are not included. API keys restricted by API_KEY_PRESETS only see the presets allowed for them.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "presets"
+                ],
+                "summary": "List presets",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/internal_controlplane_api.PresetResponse"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/presets/usage": {
+            "get": {
+                "security": [
+                    {
+                        "bearerAuth": []
+                    }
+                ],
+                "description": "Count the jobs dispatched to Workers per preset since the Control Plane started, most used first. Jobs with raw_ffmpeg_args are counted as \"raw\". Non built-in presets beyond PRESET_USAGE_MAX_LABELS distinct names are counted as \"other\". API keys restricted by API_KEY_PRESETS only see the presets allowed for them (not \"raw\" or \"other\").",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "presets"
+                ],
+                "summary": "Get preset usage",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/internal_controlplane_api.PresetUsage"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to read preset usage",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controlplane_api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/validate": {
+            "post": {
+                "security": [
+                    {
+                        "bearerAuth": []
+                    }
+                ],
+                "description": "Validate an output that already exists in a Worker's storage without re-encoding it (e.g. to verify a migrated asset). The path is relative to the storage root, like the output.path of a job. Only Workers with local storage (STORAGE_TYPE=local) can validate outputs, as remote segment validation is not supported. An output that fails validation is returned with 200 and valid=false.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "jobs"
+                ],
+                "summary": "Validate existing output",
+                "parameters": [
+                    {
+                        "description": "Output to validate",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_controlplane_api.ValidateOutputRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Validation result",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controlplane_api.ValidateOutputResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controlplane_api.ErrorResponse"
+                        }
+                    },
+                    "501": {
+                        "description": "Worker storage does not support validating existing outputs",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controlplane_api.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Worker failed to validate the output",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controlplane_api.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "No available workers",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controlplane_api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/workers/status": {
+            "get": {
+                "security": [
+                    {
+                        "bearerAuth": []
+                    }
+                ],
+                "description": "Get status of all registered Workers. Unreachable Workers are reported with available=false.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "workers"
+                ],
+                "summary": "Get worker status",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/internal_controlplane_api.WorkerStatusResponse"
+                            }
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "internal_controlplane_api.CancelJobResponse": {
+            "type": "object",
+            "properties": {
+                "job_id": {
+                    "type": "string",
+                    "example": "550e8400-e29b-41d4-a716-446655440000"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "job cancelled"
+                }
+            }
+        },
+        "internal_controlplane_api.CreateInputRequest": {
+            "type": "object",
+            "required": [
+                "content_type",
+                "size"
+            ],
+            "properties": {
+                "content_type": {
+                    "type": "string",
+                    "example": "video/mp4"
+                },
+                "filename": {
+                    "type": "string",
+                    "example": "video.mp4"
+                },
+                "size": {
+                    "type": "integer",
+                    "example": 10485760
+                }
+            }
+        },
+        "internal_controlplane_api.DeadLetter": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string",
+                    "example": "ffmpeg exited with status 1"
+                },
+                "failed_at": {
+                    "type": "string",
+                    "example": "2025-01-01T00:00:00Z"
+                },
+                "job_id": {
+                    "type": "string",
+                    "example": "550e8400-e29b-41d4-a716-446655440000"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "Encoding failed"
+                },
+                "request": {
+                    "$ref": "#/definitions/internal_controlplane_api.JobRequest"
+                }
+            }
+        },
+        "internal_controlplane_api.DryRunResponse": {
+            "type": "object",
+            "properties": {
+                "command": {
+                    "description": "Command は Worker が実行する ffmpeg のコマンドライン（先頭は実行ファイル名）",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "ffmpeg",
+                        "-nostdin",
+                        "-i",
+                        "input.mp4",
+                        "output.mp4"
+                    ]
+                },
+                "preset": {
+                    "type": "string",
+                    "example": "720p_h264"
+                },
+                "worker": {
+                    "description": "Worker はコマンドを組み立てた Worker のアドレス",
+                    "type": "string",
+                    "example": "worker-1:50051"
+                },
+                "worker_id": {
+                    "type": "string",
+                    "example": "worker-1"
+                },
+                "working_dir": {
+                    "description": "WorkingDir はコマンドを実行する作業ディレクトリ（2 パスエンコードの場合のみ）",
+                    "type": "string",
+                    "example": "/tmp/flux-encoder/550e8400-e29b-41d4-a716-446655440000"
+                }
+            }
+        },
+        "internal_controlplane_api.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string",
+                    "example": "error message"
+                }
+            }
+        },
+        "internal_controlplane_api.InputResponse": {
+            "type": "object",
+            "properties": {
+                "completed": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "content_type": {
+                    "type": "string",
+                    "example": "video/mp4"
+                },
+                "filename": {
+                    "type": "string",
+                    "example": "video.mp4"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "550e8400-e29b-41d4-a716-446655440000"
+                },
+                "input_url": {
+                    "type": "string",
+                    "example": "http://localhost:8080/api/v1/inputs/550e8400-e29b-41d4-a716-446655440000/content"
+                },
+                "offset": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "size": {
+                    "type": "integer",
+                    "example": 10485760
+                },
+                "upload_url": {
+                    "type": "string",
+                    "example": "/api/v1/inputs/550e8400-e29b-41d4-a716-446655440000"
+                }
+            }
+        },
+        "internal_controlplane_api.JobGroupJob": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string",
+                    "example": ""
+                },
+                "job_id": {
+                    "type": "string",
+                    "example": "550e8400-e29b-41d4-a716-446655440000"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "Encoding"
+                },
+                "output_url": {
+                    "type": "string",
+                    "example": "https://example-bucket.s3.amazonaws.com/output/720p.mp4"
+                },
+                "preset": {
+                    "type": "string",
+                    "example": "720p_h264"
+                },
+                "progress": {
+                    "type": "number",
+                    "example": 42.5
+                },
+                "status": {
+                    "type": "string",
+                    "example": "JOB_STATUS_PROCESSING"
+                }
+            }
+        },
+        "internal_controlplane_api.JobGroupJobRequest": {
+            "type": "object",
+            "required": [
+                "output",
+                "preset"
+            ],
+            "properties": {
+                "output": {
+                    "$ref": "#/definitions/internal_controlplane_api.OutputConfig"
+                },
+                "preset": {
+                    "type": "string",
+                    "example": "720p_h264"
+                }
+            }
+        },
+        "internal_controlplane_api.JobGroupProgress": {
+            "type": "object",
+            "properties": {
+                "completed": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "failed": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "group_id": {
+                    "type": "string",
+                    "example": "7c9e6679-7425-40de-944b-e07fc1f90ae7"
+                },
+                "jobs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/internal_controlplane_api.JobGroupJob"
+                    }
+                },
+                "min_progress": {
+                    "description": "MinProgress は最も遅れているジョブの進捗率（全体の完了見込みの目安）",
+                    "type": "number",
+                    "example": 22.4
+                },
+                "progress": {
+                    "description": "Progress は各ジョブの進捗率の平均（終了したジョブは 100 として数える）",
+                    "type": "number",
+                    "example": 61.2
+                },
+                "status": {
+                    "description": "Status は running・completed・partially_completed・failed のいずれか",
+                    "type": "string",
+                    "example": "running"
+                }
+            }
+        },
+        "internal_controlplane_api.JobGroupRequest": {
+            "type": "object",
+            "required": [
+                "input_url",
+                "jobs"
+            ],
+            "properties": {
+                "input_url": {
+                    "type": "string",
+                    "example": "https://example.com/video.mp4"
+                },
+                "jobs": {
+                    "description": "Jobs はプリセットごとの出力先（プリセットごとに1つのジョブとして別々の Worker に送信する）",
+                    "type": "array",
+                    "maxItems": 16,
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/internal_controlplane_api.JobGroupJobRequest"
+                    }
+                },
+                "on_failure": {
+                    "description": "OnFailure は1つのジョブが失敗した場合の扱い（\"continue\" は残りを実行し続ける、\"cancel\" は残りをキャンセルする）",
+                    "type": "string",
+                    "enum": [
+                        "continue",
+                        "cancel"
+                    ],
+                    "example": "continue"
+                },
+                "retry": {
+                    "description": "Retry は各ジョブの Worker 選択とアップロードのリトライ設定。省略時は既定値",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/internal_controlplane_api.RetryPolicy"
+                        }
+                    ]
+                },
+                "speed": {
+                    "description": "Speed はエンコーダーの速度プリセット（x264/x265 の -preset 値）。省略時は各プリセットの既定値",
+                    "type": "string",
+                    "example": "veryfast"
+                }
+            }
+        },
+        "internal_controlplane_api.JobGroupResponse": {
+            "type": "object",
+            "properties": {
+                "group_id": {
+                    "type": "string",
+                    "example": "7c9e6679-7425-40de-944b-e07fc1f90ae7"
+                },
+                "jobs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/internal_controlplane_api.JobResponse"
+                    }
+                },
+                "status": {
+                    "type": "string",
+                    "example": "accepted"
+                },
+                "stream_url": {
+                    "type": "string",
+                    "example": "/api/v1/job-groups/7c9e6679-7425-40de-944b-e07fc1f90ae7/stream"
+                }
+            }
+        },
+        "internal_controlplane_api.JobOutputFile": {
+            "type": "object",
+            "properties": {
+                "path": {
+                    "description": "Path は出力先（output.path）からの相対パス。単一ファイル出力の場合は出力ファイルと同じディレクトリからの相対パス",
+                    "type": "string",
+                    "example": "stream_0/segment_000.ts"
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://example-bucket.s3.amazonaws.com/output/hls/stream_0/segment_000.ts"
+                }
+            }
+        },
+        "internal_controlplane_api.JobOutputsResponse": {
+            "type": "object",
+            "properties": {
+                "files": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/internal_controlplane_api.JobOutputFile"
+                    }
+                },
+                "job_id": {
+                    "type": "string",
+                    "example": "550e8400-e29b-41d4-a716-446655440000"
+                },
+                "output_url": {
+                    "type": "string",
+                    "example": "https://example-bucket.s3.amazonaws.com/output/hls/master.m3u8"
+                }
+            }
+        },
+        "internal_controlplane_api.JobRequest": {
+            "type": "object",
+            "required": [
+                "input_url",
+                "output"
+            ],
+            "properties": {
+                "callback_url": {
+                    "description": "CallbackURL はジョブの完了・失敗時に結果を POST する Webhook の URL（http/https のみ）",
+                    "type": "string",
+                    "example": "https://example.com/webhook"
+                },
+                "duration": {
+                    "description": "Duration は切り出す長さ（start_time と同じ表記）。省略時は入力の最後まで",
+                    "type": "string",
+                    "example": "60"
+                },
+                "fallback_preset": {
+                    "description": "FallbackPreset は preset に必要なフィルター・エンコーダーを持つ Worker が1台もない場合に代わりに使用するプリセット",
+                    "type": "string",
+                    "example": "720p_h264"
+                },
+                "hls_init_path": {
+                    "description": "HLSInitPath は fMP4 の HLS の初期化セグメントの配置先（出力からの相対パス）。プレイリストの #EXT-X-MAP の URI も書き換える",
+                    "type": "string",
+                    "example": "init/init.mp4"
+                },
+                "hls_key_path": {
+                    "description": "HLSKeyPath は暗号化した HLS の暗号化キーの配置先（出力からの相対パス）。プレイリストの #EXT-X-KEY の URI も書き換える",
+                    "type": "string",
+                    "example": "keys/video.key"
+                },
+                "input_url": {
+                    "type": "string",
+                    "example": "https://example.com/video.mp4"
+                },
+                "keep_partial_output": {
+                    "description": "KeepPartialOutput はキャンセル・失敗した場合にアップロード済みの途中までの出力を残すか（既定では削除する）",
+                    "type": "boolean",
+                    "example": false
+                },
+                "output": {
+                    "$ref": "#/definitions/internal_controlplane_api.OutputConfig"
+                },
+                "overrides": {
+                    "description": "Overrides はプリセットの ffmpeg オプションを上書きする値（キーは \"crf\"、\"b:v\"、\"preset\"、\"profile:v\"、\"level\" のみ）",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "preset": {
+                    "type": "string",
+                    "example": "720p_h264"
+                },
+                "priority": {
+                    "description": "Priority は Worker のキューで実行枠を待つ順番を決める優先度（high のジョブから実行する）。省略時は normal",
+                    "type": "string",
+                    "enum": [
+                        "high",
+                        "normal",
+                        "low"
+                    ],
+                    "example": "high"
+                },
+                "raw_ffmpeg_args": {
+                    "description": "RawFFmpegArgs はプリセットの代わりに使用する ffmpeg 引数（指定する場合は preset を省略する）\n-i と出力パスは Worker が付け、出力の拡張子は output.path から決める\nWORKER_ALLOW_RAW_ARGS=true の Worker のみ受け付け、それ以外の Worker の場合は 403 を返す",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "-c:v",
+                        "libx264",
+                        "-crf",
+                        "20",
+                        "-c:a",
+                        "copy"
+                    ]
+                },
+                "retry": {
+                    "description": "Retry はこのジョブの Worker 選択とアップロードのリトライ設定。省略時は既定値",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/internal_controlplane_api.RetryPolicy"
+                        }
+                    ]
+                },
+                "segment_layout": {
+                    "description": "SegmentLayout は単一バリアント HLS のセグメントの配置（\"flat\" は同じディレクトリ、\"segments\" は segments/ サブディレクトリ）",
+                    "type": "string",
+                    "enum": [
+                        "flat",
+                        "segments"
+                    ],
+                    "example": "segments"
+                },
+                "skip_preflight": {
+                    "description": "SkipPreflight はエンコード前の ffprobe による入力の事前チェックを省略するか（ffprobe で判別できない特殊な入力向け）",
+                    "type": "boolean",
+                    "example": false
+                },
+                "speed": {
+                    "description": "Speed はエンコーダーの速度プリセット（x264/x265 の -preset 値）。省略時はプリセットの既定値",
+                    "type": "string",
+                    "example": "veryfast"
+                },
+                "start_time": {
+                    "description": "StartTime は入力から切り出す開始位置（秒数 \"90\" または時刻表記 \"00:01:30.5\"）。省略時は先頭から",
+                    "type": "string",
+                    "example": "00:01:30"
+                },
+                "stream_copy": {
+                    "description": "StreamCopy は再エンコードせずにコピーするストリーム（\"video\" の場合は音声のみ、\"audio\" の場合は映像のみ再エンコード）",
+                    "type": "string",
+                    "enum": [
+                        "video",
+                        "audio"
+                    ],
+                    "example": "video"
+                },
+                "subtitle_path": {
+                    "description": "SubtitlePath は映像に焼き込む字幕（.vtt/.srt）の URL（http/https/s3）または Worker から参照できるローカルパス\n-filter_complex を使う ABR プリセットとは併用できない",
+                    "type": "string",
+                    "example": "https://example.com/subtitles/ja.vtt"
+                },
+                "validation": {
+                    "description": "Validation はこのジョブの出力の検証の設定。省略時は Worker の既定値（VALIDATION_LEVEL など）",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/internal_controlplane_api.ValidationConfig"
+                        }
+                    ]
+                }
+            }
+        },
+        "internal_controlplane_api.JobResponse": {
+            "type": "object",
+            "properties": {
+                "fallback_used": {
+                    "description": "FallbackUsed は preset に対応する Worker がなく fallback_preset を使用したか",
+                    "type": "boolean",
+                    "example": false
+                },
+                "job_id": {
+                    "type": "string",
+                    "example": "550e8400-e29b-41d4-a716-446655440000"
+                },
+                "preset": {
+                    "description": "Preset はジョブに使用したプリセット（フォールバックした場合は fallback_preset）",
+                    "type": "string",
+                    "example": "720p_h264"
+                },
+                "queue_position": {
+                    "description": "QueuePosition は Worker の実行枠が満杯でキューで待つ場合の順番（1 始まり、すぐに開始する場合は省略）",
+                    "type": "integer",
+                    "example": 2
+                },
+                "status": {
+                    "type": "string",
+                    "example": "accepted"
+                },
+                "stream_url": {
+                    "type": "string",
+                    "example": "/api/v1/jobs/550e8400-e29b-41d4-a716-446655440000/stream"
+                },
+                "worker_id": {
+                    "description": "WorkerID はジョブを送信した Worker の識別子（Worker が識別子を返さない場合はアドレス）",
+                    "type": "string",
+                    "example": "worker-1"
+                }
+            }
+        },
+        "internal_controlplane_api.JobStatusResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string",
+                    "example": ""
+                },
+                "error_code": {
+                    "description": "ErrorCode は失敗の分類（INPUT_UNREACHABLE、UNSUPPORTED_CODEC、DISK_FULL、VALIDATION_FAILED、FFMPEG_CRASH）",
+                    "type": "string",
+                    "example": ""
+                },
+                "ffmpeg_command": {
+                    "description": "FFmpegCommand は Worker が実行した ffmpeg のコマンド（先頭は実行ファイル名）。署名付き URL のクエリの値などの認証情報は REDACTED に置き換えられる",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "ffmpeg",
+                        "-nostdin",
+                        "-i",
+                        "https://example.com/input.mp4?X-Amz-Signature=REDACTED",
+                        "output.mp4"
+                    ]
+                },
+                "job_id": {
+                    "type": "string",
+                    "example": "550e8400-e29b-41d4-a716-446655440000"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "Job completed"
+                },
+                "output_url": {
+                    "type": "string",
+                    "example": "https://example-bucket.s3.amazonaws.com/output/video.mp4"
+                },
+                "progress": {
+                    "type": "number",
+                    "example": 100
+                },
+                "status": {
+                    "type": "string",
+                    "example": "JOB_STATUS_COMPLETED"
+                }
+            }
+        },
+        "internal_controlplane_api.OutputConfig": {
+            "type": "object",
+            "required": [
+                "path",
+                "storage"
+            ],
+            "properties": {
+                "kms_key_id": {
+                    "description": "KMSKeyID は S3 の出力を SSE-KMS で暗号化する KMS キー（キーID、キーARN、エイリアス）。省略時は Worker の既定値",
+                    "type": "string",
+                    "example": "arn:aws:kms:ap-northeast-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab"
+                },
+                "metadata": {
+                    "description": "Metadata は出力に付与するメタデータ。キーは S3 のメタデータに使える文字（小文字の英数字・ハイフン・アンダースコア）に変換する\n件数と合計サイズ（キーと値のバイト数）の上限は METADATA_MAX_ENTRIES・METADATA_MAX_BYTES（デフォルト 20 件・2048 バイト）",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    },
+                    "example": {
+                        "key1": "value1",
+                        "key2": "value2"
+                    }
+                },
+                "path": {
+                    "type": "string",
+                    "example": "output/video.mp4"
+                },
+                "storage": {
+                    "type": "string",
+                    "example": "s3"
+                }
+            }
+        },
+        "internal_controlplane_api.PresetResponse": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string",
+                    "example": "HD 720p with H.264 encoding"
+                },
+                "extension": {
+                    "type": "string",
+                    "example": "mp4"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "720p_h264"
+                },
+                "output_type": {
+                    "description": "OutputType は出力タイプ（\"single\"、\"hls\"、\"dash\"）",
+                    "type": "string",
+                    "enum": [
+                        "single",
+                        "hls",
+                        "dash"
+                    ],
+                    "example": "single"
+                }
+            }
+        },
+        "internal_controlplane_api.PresetUsage": {
+            "type": "object",
+            "properties": {
+                "jobs": {
+                    "type": "integer",
+                    "example": 42
+                },
+                "preset": {
+                    "description": "Preset はプリセットの名前（raw_ffmpeg_args のジョブは raw、集計する名前の数の上限を超えたプリセットは other）",
+                    "type": "string",
+                    "example": "720p_h264"
+                }
+            }
+        },
+        "internal_controlplane_api.RetryPolicy": {
+            "type": "object",
+            "properties": {
+                "initial_wait_ms": {
+                    "description": "InitialWaitMs は初回の待機時間（ミリ秒、最大 300000）",
+                    "type": "integer",
+                    "example": 1000
+                },
+                "max_attempts": {
+                    "description": "MaxAttempts は最大試行回数（1〜10）",
+                    "type": "integer",
+                    "example": 5
+                },
+                "max_wait_ms": {
+                    "description": "MaxWaitMs は待機時間の上限（ミリ秒、最大 300000）",
+                    "type": "integer",
+                    "example": 30000
+                }
+            }
+        },
+        "internal_controlplane_api.ValidateOutputRequest": {
+            "type": "object",
+            "required": [
+                "path"
+            ],
+            "properties": {
+                "path": {
+                    "description": "Path は検証する出力のパス（ジョブの output.path と同じく、Worker のストレージのルートからの相対パス）",
+                    "type": "string",
+                    "example": "videos/output.mp4"
+                },
+                "validation": {
+                    "description": "Validation は検証の設定。省略時は Worker の既定値（VALIDATION_LEVEL など）",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/internal_controlplane_api.ValidationConfig"
+                        }
+                    ]
+                }
+            }
+        },
+        "internal_controlplane_api.ValidateOutputResponse": {
+            "type": "object",
+            "properties": {
+                "duration_ms": {
+                    "type": "integer",
+                    "example": 350
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/internal_controlplane_api.ValidationIssue"
+                    }
+                },
+                "media_info": {
+                    "description": "MediaInfo は ffprobe で取得したメディア情報（ファイルが存在しない場合など、取得前に失敗した場合は省略）",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/internal_controlplane_api.ValidatedMediaInfo"
+                        }
+                    ]
+                },
+                "valid": {
+                    "description": "Valid は検証に合格したかどうか",
+                    "type": "boolean",
+                    "example": true
+                },
+                "warnings": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/internal_controlplane_api.ValidationIssue"
+                    }
+                },
+                "worker": {
+                    "description": "Worker は検証した Worker のアドレス",
+                    "type": "string",
+                    "example": "worker-1:50051"
+                },
+                "worker_id": {
+                    "type": "string",
+                    "example": "worker-1"
+                }
+            }
+        },
+        "internal_controlplane_api.ValidatedAudioStream": {
+            "type": "object",
+            "properties": {
+                "bitrate": {
+                    "type": "integer",
+                    "example": 128000
+                },
+                "channel_layout": {
+                    "type": "string",
+                    "example": "stereo"
+                },
+                "channels": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "codec": {
+                    "type": "string",
+                    "example": "aac"
+                },
+                "sample_rate": {
+                    "type": "integer",
+                    "example": 48000
+                }
+            }
+        },
+        "internal_controlplane_api.ValidatedMediaInfo": {
+            "type": "object",
+            "properties": {
+                "audio_streams": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/internal_controlplane_api.ValidatedAudioStream"
+                    }
+                },
+                "bitrate": {
+                    "type": "integer",
+                    "example": 2628000
+                },
+                "duration": {
+                    "type": "number",
+                    "example": 120.5
+                },
+                "format": {
+                    "type": "string",
+                    "example": "mov,mp4,m4a,3gp,3g2,mj2"
+                },
+                "size": {
+                    "description": "Size はファイルサイズ（バイト、単一ファイルの場合のみ）",
+                    "type": "integer",
+                    "example": 37748736
+                },
+                "total_segments": {
+                    "description": "TotalSegments は HLS/DASH のセグメント数（単一ファイルの場合は省略）",
+                    "type": "integer",
+                    "example": 0
+                },
+                "video_streams": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/internal_controlplane_api.ValidatedVideoStream"
+                    }
+                }
+            }
+        },
+        "internal_controlplane_api.ValidatedVideoStream": {
+            "type": "object",
+            "properties": {
+                "bitrate": {
+                    "type": "integer",
+                    "example": 2500000
+                },
+                "codec": {
+                    "type": "string",
+                    "example": "h264"
+                },
+                "frame_rate": {
+                    "type": "number",
+                    "example": 29.97
+                },
+                "height": {
+                    "type": "integer",
+                    "example": 720
+                },
+                "level": {
+                    "type": "string",
+                    "example": "3.1"
+                },
+                "pixel_format": {
+                    "type": "string",
+                    "example": "yuv420p"
+                },
+                "profile": {
+                    "type": "string",
+                    "example": "High"
+                },
+                "width": {
+                    "type": "integer",
+                    "example": 1280
+                }
+            }
+        },
+        "internal_controlplane_api.ValidationConfig": {
+            "type": "object",
+            "properties": {
+                "hls_validation_depth": {
+                    "description": "HLSValidationDepth は HLS の検証の深さ（\"basic\"、\"medium\"、\"full\"）",
+                    "type": "string",
+                    "enum": [
+                        "basic",
+                        "medium",
+                        "full"
+                    ],
+                    "example": "full"
+                },
+                "level": {
+                    "description": "Level は検証のレベル（\"minimal\"、\"standard\"、\"strict\"）",
+                    "type": "string",
+                    "enum": [
+                        "minimal",
+                        "standard",
+                        "strict"
+                    ],
+                    "example": "strict"
+                },
+                "skip_decode_test": {
+                    "description": "SkipDecodeTest は strict レベルのデコードテストを省略するか",
+                    "type": "boolean",
+                    "example": true
+                },
+                "timeout_seconds": {
+                    "description": "TimeoutSeconds は検証のタイムアウト（秒）",
+                    "type": "integer",
+                    "example": 60
+                }
+            }
+        },
+        "internal_controlplane_api.ValidationIssue": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "BITRATE_LOW"
+                },
+                "field": {
+                    "type": "string",
+                    "example": "bitrate"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "bitrate is lower than expected"
+                }
+            }
+        },
+        "internal_controlplane_api.WorkerLoadResponse": {
+            "type": "object",
+            "properties": {
+                "cpu_percent": {
+                    "type": "number",
+                    "example": 72.5
+                },
+                "gpu_percent": {
+                    "description": "GPUPercent は GPU（NVENC）の使用率（Worker が GPU の負荷を報告しない場合は省略）",
+                    "type": "number",
+                    "example": 35
+                },
+                "memory_percent": {
+                    "type": "number",
+                    "example": 41.3
+                },
+                "memory_total_bytes": {
+                    "type": "integer",
+                    "example": 16898535424
+                },
+                "memory_used_bytes": {
+                    "type": "integer",
+                    "example": 6979321856
+                },
+                "sampled_at": {
+                    "type": "string",
+                    "example": "2025-01-01T00:00:00Z"
+                }
+            }
+        },
+        "internal_controlplane_api.WorkerStatusResponse": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string",
+                    "example": "worker-1.internal:50051"
+                },
+                "available": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "current_jobs": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "error": {
+                    "type": "string",
+                    "example": ""
+                },
+                "load": {
+                    "description": "Load は Worker のホストの負荷（Worker が報告しない場合は省略）",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/internal_controlplane_api.WorkerLoadResponse"
+                        }
+                    ]
+                },
+                "max_concurrent_jobs": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "queued_jobs": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "version": {
+                    "type": "string",
+                    "example": "0.1.0"
+                },
+                "worker_id": {
                     "type": "string",
-                    "example": "not implemented yet"
+                    "example": "worker-1"
                 }
             }
         }
@@ -0,0 +1,149 @@
+package api
+
+import (
+	"errors"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+)
+
+// redactedValue は一覧で秘匿した値の代わりに返す文字列
+const redactedValue = "REDACTED"
+
+// DefaultDeadLetterCapacity はメモリ上に保持するデッドレターの最大件数のデフォルト値
+const DefaultDeadLetterCapacity = 1000
+
+// ErrDeadLetterNotFound はデッドレターが存在しない場合のエラー
+var ErrDeadLetterNotFound = errors.New("dead letter not found")
+
+// DeadLetter は最終的に失敗したジョブの記録
+// Request を使って同じ内容のジョブを再投入できる
+type DeadLetter struct {
+	JobID    string     `json:"job_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Request  JobRequest `json:"request"`
+	Message  string     `json:"message" example:"Encoding failed"`
+	Error    string     `json:"error" example:"ffmpeg exited with status 1"`
+	FailedAt time.Time  `json:"failed_at" example:"2025-01-01T00:00:00Z"`
+}
+
+// redacted は一覧で返すために、リクエストの URL のクエリ文字列（署名付き URL の署名など）・認証情報と KMS キーを秘匿したコピーを返す
+// 再投入には保存した元のリクエストを使う
+func (d DeadLetter) redacted() DeadLetter {
+	d.Request.InputURL = redactURL(d.Request.InputURL)
+	d.Request.CallbackURL = redactURL(d.Request.CallbackURL)
+	d.Request.SubtitlePath = redactURL(d.Request.SubtitlePath)
+	if d.Request.Output.KMSKeyID != "" {
+		d.Request.Output.KMSKeyID = redactedValue
+	}
+	return d
+}
+
+// redactURL は URL のクエリ文字列とユーザー情報を秘匿する（URL でない値やどちらも含まない URL はそのまま返す）
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || (u.RawQuery == "" && u.User == nil) {
+		return raw
+	}
+	if u.RawQuery != "" {
+		u.RawQuery = redactedValue
+	}
+	if u.User != nil {
+		u.User = url.User(redactedValue)
+	}
+	return u.String()
+}
+
+// DeadLetterStore は失敗したジョブの保存先
+// Control Plane の複数インスタンスで共有する場合は Redis などの外部ストアで実装する
+type DeadLetterStore interface {
+	// Add はデッドレターを追加する（同じ JobID の場合は上書きする）
+	Add(entry DeadLetter) error
+	// Get は JobID に対応するデッドレターを取得する
+	Get(jobID string) (DeadLetter, error)
+	// List はデッドレターを失敗日時の新しい順に返す
+	List() ([]DeadLetter, error)
+	// Remove はデッドレターを削除する
+	Remove(jobID string) error
+}
+
+// MemoryDeadLetterStore はメモリ上にデッドレターを保持する DeadLetterStore
+// 上限を超えた場合は最も古いものから削除する
+type MemoryDeadLetterStore struct {
+	entries  map[string]DeadLetter
+	capacity int
+	mutex    sync.RWMutex
+}
+
+// NewMemoryDeadLetterStore は新しい MemoryDeadLetterStore を作成する
+func NewMemoryDeadLetterStore(capacity int) *MemoryDeadLetterStore {
+	if capacity <= 0 {
+		capacity = DefaultDeadLetterCapacity
+	}
+
+	return &MemoryDeadLetterStore{
+		entries:  make(map[string]DeadLetter),
+		capacity: capacity,
+	}
+}
+
+// Add はデッドレターを追加する
+func (s *MemoryDeadLetterStore) Add(entry DeadLetter) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.entries[entry.JobID] = entry
+
+	for len(s.entries) > s.capacity {
+		oldestID := ""
+		var oldest time.Time
+		for id, e := range s.entries {
+			if oldestID == "" || e.FailedAt.Before(oldest) {
+				oldestID = id
+				oldest = e.FailedAt
+			}
+		}
+		delete(s.entries, oldestID)
+	}
+
+	return nil
+}
+
+// Get は JobID に対応するデッドレターを取得する
+func (s *MemoryDeadLetterStore) Get(jobID string) (DeadLetter, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	entry, exists := s.entries[jobID]
+	if !exists {
+		return DeadLetter{}, ErrDeadLetterNotFound
+	}
+	return entry, nil
+}
+
+// List はデッドレターを失敗日時の新しい順に返す
+func (s *MemoryDeadLetterStore) List() ([]DeadLetter, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	list := make([]DeadLetter, 0, len(s.entries))
+	for _, entry := range s.entries {
+		list = append(list, entry)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].FailedAt.After(list[j].FailedAt)
+	})
+	return list, nil
+}
+
+// Remove はデッドレターを削除する
+func (s *MemoryDeadLetterStore) Remove(jobID string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, exists := s.entries[jobID]; !exists {
+		return ErrDeadLetterNotFound
+	}
+	delete(s.entries, jobID)
+	return nil
+}
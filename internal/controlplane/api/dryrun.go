@@ -0,0 +1,109 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/nzws/flux-encoder/internal/controlplane/balancer"
+	"github.com/nzws/flux-encoder/internal/shared/logger"
+	workerv1 "github.com/nzws/flux-encoder/proto/worker/v1"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// DryRunResponse はドライランのレスポンス
+type DryRunResponse struct {
+	// Worker はコマンドを組み立てた Worker のアドレス
+	Worker   string `json:"worker" example:"worker-1:50051"`
+	WorkerID string `json:"worker_id,omitempty" example:"worker-1"`
+	Preset   string `json:"preset" example:"720p_h264"`
+	// Command は Worker が実行する ffmpeg のコマンドライン（先頭は実行ファイル名）
+	Command []string `json:"command" example:"ffmpeg,-nostdin,-i,input.mp4,output.mp4"`
+	// WorkingDir はコマンドを実行する作業ディレクトリ（2 パスエンコードの場合のみ）
+	WorkingDir string `json:"working_dir,omitempty" example:"/tmp/flux-encoder/550e8400-e29b-41d4-a716-446655440000"`
+}
+
+// DryRunJob はジョブを実行せずに、Worker が実行する ffmpeg のコマンドを返す
+// @Summary Dry-run encoding job
+// @Description Build the ffmpeg command a Worker would run for the job without downloading the input or running ffmpeg. Useful for debugging presets and overrides.
+// @Tags jobs
+// @Accept json
+// @Produce json
+// @Param job body JobRequest true "Job parameters"
+// @Success 200 {object} DryRunResponse "ffmpeg command"
+// @Failure 400 {object} ErrorResponse "Invalid request"
+// @Failure 403 {object} ErrorResponse "Preset is not allowed for the API key, or raw ffmpeg args are not allowed"
+// @Failure 502 {object} ErrorResponse "Worker failed to build the command"
+// @Failure 503 {object} ErrorResponse "No available workers"
+// @Security bearerAuth
+// @Router /jobs/dryrun [post]
+func (h *Handler) DryRunJob(c *gin.Context) {
+	var req JobRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.validateJobRequest(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.checkPresetAllowed(c, req); err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+
+	// コマンドの組み立てには実行枠を使わないため、空きに関わらず応答した Worker から選ぶ
+	worker, ok := h.dryRunWorker(c, jobCapabilities(req.Preset, req))
+	if !ok {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "no available workers"})
+		return
+	}
+
+	conn, err := h.balancer.Dial(worker.Address)
+	if err != nil {
+		logger.Error("Failed to connect to worker", zap.String("worker", worker.Address), zap.Error(err))
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "no available workers"})
+		return
+	}
+
+	jobID := uuid.New().String()
+	resp, err := workerv1.NewWorkerServiceClient(conn).DryRun(c.Request.Context(), req.toProto(jobID, req.Preset))
+	if err != nil {
+		logger.Warn("Dry run failed", zap.String("worker", worker.Address), zap.Error(err))
+		switch status.Code(err) {
+		case codes.InvalidArgument:
+			c.JSON(http.StatusBadRequest, gin.H{"error": status.Convert(err).Message()})
+		case codes.PermissionDenied:
+			c.JSON(http.StatusForbidden, gin.H{"error": errRawArgsNotAllowed.Error()})
+		default:
+			c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, DryRunResponse{
+		Worker:     worker.Address,
+		WorkerID:   worker.WorkerID,
+		Preset:     resp.Preset,
+		Command:    resp.Command,
+		WorkingDir: resp.WorkingDir,
+	})
+}
+
+// dryRunWorker は required のフィルター・エンコーダーを利用できる、応答した最初の Worker を返す
+func (h *Handler) dryRunWorker(c *gin.Context, required balancer.Capabilities) (balancer.WorkerInfo, bool) {
+	for _, info := range h.balancer.StatusAll(c.Request.Context()) {
+		if !info.Available {
+			continue
+		}
+		if info.Capabilities != nil && len(info.Capabilities.Missing(required)) > 0 {
+			continue
+		}
+		return info, true
+	}
+	return balancer.WorkerInfo{}, false
+}
@@ -0,0 +1,101 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nzws/flux-encoder/internal/controlplane/balancer"
+	workerv1 "github.com/nzws/flux-encoder/proto/worker/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// dryRunMockWorker はジョブを実行せずにコマンドを返すモック Worker
+// 実行枠に空きがなくてもドライランに応答する
+type dryRunMockWorker struct {
+	workerv1.UnimplementedWorkerServiceServer
+
+	err error
+}
+
+func (w *dryRunMockWorker) GetStatus(ctx context.Context, req *workerv1.StatusRequest) (*workerv1.WorkerStatus, error) {
+	return &workerv1.WorkerStatus{CurrentJobs: 1, MaxConcurrentJobs: 1, WorkerId: "dryrun-worker"}, nil
+}
+
+func (w *dryRunMockWorker) DryRun(ctx context.Context, req *workerv1.JobRequest) (*workerv1.DryRunResponse, error) {
+	if w.err != nil {
+		return nil, w.err
+	}
+	return &workerv1.DryRunResponse{
+		Command: []string{"ffmpeg", "-nostdin", "-i", req.InputUrl, "-preset", req.Speed, "/tmp/" + req.JobId + "/output.mp4"},
+		Preset:  req.Preset,
+	}, nil
+}
+
+// postDryRun は worker に接続した Handler の POST /api/v1/jobs/dryrun にリクエストを送信する
+func postDryRun(t *testing.T, worker workerv1.WorkerServiceServer, body string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	gin.SetMode(gin.TestMode)
+	handler := NewHandler(balancer.New([]string{startMockWorker(t, worker)}, time.Second))
+	router := gin.New()
+	router.POST("/api/v1/jobs/dryrun", handler.DryRunJob)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/jobs/dryrun", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestDryRunJobがWorkerの組み立てたコマンドを返す(t *testing.T) {
+	w := postDryRun(t, &dryRunMockWorker{}, `{"input_url":"https://example.com/video.mp4","preset":"720p_h264","speed":"veryfast","output":{"storage":"local","path":"out.mp4"}}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("ステータスコードが一致しない: 期待値 %d, 取得値 %d (%s)", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var resp DryRunResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("レスポンスのパースに失敗: %v", err)
+	}
+	if resp.WorkerID != "dryrun-worker" || resp.Preset != "720p_h264" {
+		t.Errorf("レスポンスが一致しない: %+v", resp)
+	}
+	if len(resp.Command) < 6 || resp.Command[0] != "ffmpeg" || resp.Command[5] != "veryfast" {
+		t.Errorf("コマンドが一致しない: %v", resp.Command)
+	}
+}
+
+func TestDryRunJobがWorkerのエラーをステータスコードに変換する(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{name: "不正な引数", err: status.Error(codes.InvalidArgument, "unknown preset"), want: http.StatusBadRequest},
+		{name: "生の引数が不許可", err: status.Error(codes.PermissionDenied, "raw ffmpeg args are disabled"), want: http.StatusForbidden},
+		{name: "その他のエラー", err: status.Error(codes.Internal, "boom"), want: http.StatusBadGateway},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := postDryRun(t, &dryRunMockWorker{err: tt.err}, `{"input_url":"https://example.com/video.mp4","preset":"720p_h264","output":{"storage":"local","path":"out.mp4"}}`)
+			if w.Code != tt.want {
+				t.Errorf("ステータスコードが一致しない: 期待値 %d, 取得値 %d (%s)", tt.want, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestDryRunJobで不正なリクエストは400が返る(t *testing.T) {
+	w := postDryRun(t, &dryRunMockWorker{}, `{"input_url":"https://example.com/video.mp4","preset":"720p_h264","speed":"warp","output":{"storage":"local","path":"out.mp4"}}`)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("ステータスコードが一致しない: 期待値 %d, 取得値 %d", http.StatusBadRequest, w.Code)
+	}
+}
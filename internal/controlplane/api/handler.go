@@ -3,44 +3,305 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/nzws/flux-encoder/internal/controlplane/auth"
 	"github.com/nzws/flux-encoder/internal/controlplane/balancer"
 	"github.com/nzws/flux-encoder/internal/shared/logger"
+	"github.com/nzws/flux-encoder/internal/shared/retry"
+	"github.com/nzws/flux-encoder/internal/worker/encoder"
+	"github.com/nzws/flux-encoder/internal/worker/preset"
+	"github.com/nzws/flux-encoder/internal/worker/uploader"
 	workerv1 "github.com/nzws/flux-encoder/proto/worker/v1"
 	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 // Handler は REST API のハンドラー
 type Handler struct {
 	balancer   *balancer.Balancer
 	jobManager *JobManager
+	// jobGroups は複数のプリセットを並列に実行するジョブグループ
+	jobGroups  *JobGroupManager
+	inputStore *InputStore
+	// deadLetters は失敗したジョブの保存先
+	deadLetters DeadLetterStore
+	// jobStore はジョブの状態の保存先（再起動後に最終ステータスを返すため）
+	jobStore JobStore
+	// maxOutputHeight は出力解像度（高さ px）の上限。0 の場合は制限しない
+	maxOutputHeight int
+	// metadataLimits は output.metadata の件数・合計サイズの上限
+	metadataLimits uploader.MetadataLimits
+	// presetAllowlist は API Key ごとに利用を許可するプリセット。登録されていないキーは制限しない
+	presetAllowlist auth.PresetAllowlist
+	// presetUsage はプリセットごとのジョブ数の保存先、presetUsageLabels は集計する名前の決定（presetusage.go を参照）
+	presetUsage       PresetUsageStore
+	presetUsageLabels *presetUsageLabeler
+	// dispatchSlots は Worker から進捗を受信中のジョブ数を制限するセマフォ。nil の場合は制限しない
+	dispatchSlots chan struct{}
+	// reattachConfig は進捗ストリームが切断された際に実行中のジョブへ再接続するリトライ設定
+	reattachConfig retry.Config
+	// webhookConfig と webhookTimeout は callback_url への送信のリトライ設定と1回の送信のタイムアウト
+	webhookConfig  retry.Config
+	webhookTimeout time.Duration
 }
 
 // NewHandler は新しい Handler を作成する
 func NewHandler(balancer *balancer.Balancer) *Handler {
 	return &Handler{
-		balancer:   balancer,
-		jobManager: NewJobManager(),
+		balancer:          balancer,
+		jobManager:        NewJobManager(),
+		jobGroups:         NewJobGroupManager(),
+		deadLetters:       NewMemoryDeadLetterStore(DefaultDeadLetterCapacity),
+		jobStore:          NopJobStore{},
+		metadataLimits:    uploader.DefaultMetadataLimits(),
+		presetUsage:       NewMemoryPresetUsageStore(),
+		presetUsageLabels: newPresetUsageLabeler(DefaultPresetUsageMaxLabels),
+		// 再接続は Worker の STREAM_REATTACH_GRACE（デフォルト 30 秒）以内に終える
+		reattachConfig: defaultReattachConfig,
+		webhookConfig:  defaultWebhookConfig,
+		webhookTimeout: defaultWebhookTimeout,
+	}
+}
+
+// SetDeadLetterStore は失敗したジョブの保存先を設定する
+func (h *Handler) SetDeadLetterStore(store DeadLetterStore) {
+	h.deadLetters = store
+}
+
+// SetJobStore はジョブの状態の保存先を設定する
+func (h *Handler) SetJobStore(store JobStore) {
+	h.jobStore = store
+}
+
+// SetInputStore は入力アップロードの保存先を設定する
+func (h *Handler) SetInputStore(store *InputStore) {
+	h.inputStore = store
+}
+
+// SetJobStatusTTL はジョブ終了後に最終ステータスを保持する期間を設定する
+func (h *Handler) SetJobStatusTTL(ttl time.Duration) {
+	h.jobManager.SetStatusTTL(ttl)
+	h.jobGroups.SetStatusTTL(ttl)
+}
+
+// SetMaxOutputHeight は受け付ける出力解像度（高さ px）の上限を設定する
+// 0 以下を指定すると制限しない
+func (h *Handler) SetMaxOutputHeight(height int) {
+	h.maxOutputHeight = height
+}
+
+// SetMetadataLimits は output.metadata の件数・合計サイズの上限を設定する（0 以下の項目は制限しない）
+// Worker の METADATA_MAX_ENTRIES・METADATA_MAX_BYTES と同じ値にする
+func (h *Handler) SetMetadataLimits(limits uploader.MetadataLimits) {
+	h.metadataLimits = limits
+}
+
+// SetPresetAllowlist は API Key ごとに利用を許可するプリセットを設定する
+// 制限されたキーには許可したプリセットのみを一覧に返し、それ以外のプリセットのジョブは 403 で拒否する
+func (h *Handler) SetPresetAllowlist(allowlist auth.PresetAllowlist) {
+	h.presetAllowlist = allowlist
+}
+
+// SetMaxActiveDispatches は同時にディスパッチ中（Worker から進捗を受信中）のジョブ数の上限を設定する
+// 上限に達している間の新しいジョブは、空きができるまでリクエスト中に待機する
+// 0 以下を指定すると制限しない
+func (h *Handler) SetMaxActiveDispatches(n int) {
+	if n <= 0 {
+		h.dispatchSlots = nil
+		return
+	}
+	h.dispatchSlots = make(chan struct{}, n)
+}
+
+// acquireDispatchSlot はディスパッチの枠を確保し、解放する関数を返す
+// 上限に達している場合は空きができるか ctx がキャンセルされるまで待つ
+func (h *Handler) acquireDispatchSlot(ctx context.Context) (func(), error) {
+	slots := h.dispatchSlots
+	if slots == nil {
+		return func() {}, nil
+	}
+
+	select {
+	case slots <- struct{}{}:
+		return func() { <-slots }, nil
+	default:
+	}
+
+	logger.Info("Waiting for a dispatch slot", zap.Int("max_active_dispatches", cap(slots)))
+	select {
+	case slots <- struct{}{}:
+		return func() { <-slots }, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("cancelled while waiting for a dispatch slot: %w", ctx.Err())
 	}
 }
 
 // JobRequest はジョブ作成のリクエスト
 type JobRequest struct {
 	InputURL string       `json:"input_url" binding:"required" example:"https://example.com/video.mp4"`
-	Preset   string       `json:"preset" binding:"required" example:"720p_h264"`
+	Preset   string       `json:"preset" binding:"required_without=RawFFmpegArgs" example:"720p_h264"`
 	Output   OutputConfig `json:"output" binding:"required"`
+	// Speed はエンコーダーの速度プリセット（x264/x265 の -preset 値）。省略時はプリセットの既定値
+	Speed string `json:"speed,omitempty" example:"veryfast"`
+	// StreamCopy は再エンコードせずにコピーするストリーム（"video" の場合は音声のみ、"audio" の場合は映像のみ再エンコード）
+	StreamCopy string `json:"stream_copy,omitempty" binding:"omitempty,oneof=video audio" enums:"video,audio" example:"video"`
+	// Overrides はプリセットの ffmpeg オプションを上書きする値（キーは "crf"、"b:v"、"preset"、"profile:v"、"level" のみ）
+	Overrides map[string]string `json:"overrides,omitempty"`
+	// SegmentLayout は単一バリアント HLS のセグメントの配置（"flat" は同じディレクトリ、"segments" は segments/ サブディレクトリ）
+	SegmentLayout string `json:"segment_layout,omitempty" binding:"omitempty,oneof=flat segments" enums:"flat,segments" example:"segments"`
+	// HLSInitPath は fMP4 の HLS の初期化セグメントの配置先（出力からの相対パス）。プレイリストの #EXT-X-MAP の URI も書き換える
+	HLSInitPath string `json:"hls_init_path,omitempty" example:"init/init.mp4"`
+	// HLSKeyPath は暗号化した HLS の暗号化キーの配置先（出力からの相対パス）。プレイリストの #EXT-X-KEY の URI も書き換える
+	HLSKeyPath string `json:"hls_key_path,omitempty" example:"keys/video.key"`
+	// Retry はこのジョブの Worker 選択とアップロードのリトライ設定。省略時は既定値
+	Retry *RetryPolicy `json:"retry,omitempty"`
+	// CallbackURL はジョブの完了・失敗時に結果を POST する Webhook の URL（http/https のみ）
+	CallbackURL string `json:"callback_url,omitempty" example:"https://example.com/webhook"`
+	// SubtitlePath は映像に焼き込む字幕（.vtt/.srt）の URL（http/https/s3）または Worker から参照できるローカルパス
+	// -filter_complex を使う ABR プリセットとは併用できない
+	SubtitlePath string `json:"subtitle_path,omitempty" example:"https://example.com/subtitles/ja.vtt"`
+	// KeepPartialOutput はキャンセル・失敗した場合にアップロード済みの途中までの出力を残すか（既定では削除する）
+	KeepPartialOutput bool `json:"keep_partial_output,omitempty" example:"false"`
+	// FallbackPreset は preset に必要なフィルター・エンコーダーを持つ Worker が1台もない場合に代わりに使用するプリセット
+	FallbackPreset string `json:"fallback_preset,omitempty" example:"720p_h264"`
+	// RawFFmpegArgs はプリセットの代わりに使用する ffmpeg 引数（指定する場合は preset を省略する）
+	// -i と出力パスは Worker が付け、出力の拡張子は output.path から決める
+	// WORKER_ALLOW_RAW_ARGS=true の Worker のみ受け付け、それ以外の Worker の場合は 403 を返す
+	RawFFmpegArgs []string `json:"raw_ffmpeg_args,omitempty" example:"-c:v,libx264,-crf,20,-c:a,copy"`
+	// StartTime は入力から切り出す開始位置（秒数 "90" または時刻表記 "00:01:30.5"）。省略時は先頭から
+	StartTime string `json:"start_time,omitempty" example:"00:01:30"`
+	// Duration は切り出す長さ（start_time と同じ表記）。省略時は入力の最後まで
+	Duration string `json:"duration,omitempty" example:"60"`
+	// SkipPreflight はエンコード前の ffprobe による入力の事前チェックを省略するか（ffprobe で判別できない特殊な入力向け）
+	SkipPreflight bool `json:"skip_preflight,omitempty" example:"false"`
+	// Validation はこのジョブの出力の検証の設定。省略時は Worker の既定値（VALIDATION_LEVEL など）
+	Validation *ValidationConfig `json:"validation,omitempty"`
+	// Priority は Worker のキューで実行枠を待つ順番を決める優先度（high のジョブから実行する）。省略時は normal
+	Priority string `json:"priority,omitempty" binding:"omitempty,oneof=high normal low" enums:"high,normal,low" example:"high"`
+}
+
+// defaultJobPriority はジョブの優先度を省略した場合に Worker に送信する優先度
+const defaultJobPriority = "normal"
+
+// RetryPolicy はジョブごとのリトライ設定（省略した項目・0 の項目は既定値を使用する）
+type RetryPolicy struct {
+	// MaxAttempts は最大試行回数（1〜10）
+	MaxAttempts int `json:"max_attempts,omitempty" example:"5"`
+	// InitialWaitMs は初回の待機時間（ミリ秒、最大 300000）
+	InitialWaitMs int64 `json:"initial_wait_ms,omitempty" example:"1000"`
+	// MaxWaitMs は待機時間の上限（ミリ秒、最大 300000）
+	MaxWaitMs int64 `json:"max_wait_ms,omitempty" example:"30000"`
+}
+
+// defaultSelectRetryConfig は Worker 選択のリトライ設定の既定値
+// 既定では空き Worker がなければリトライせずに 503 を返す
+var defaultSelectRetryConfig = retry.Config{
+	MaxAttempts: 1,
+	InitialWait: retry.DefaultConfig.InitialWait,
+	MaxWait:     retry.DefaultConfig.MaxWait,
+	Multiplier:  retry.DefaultConfig.Multiplier,
+	Jitter:      retry.DefaultConfig.Jitter,
+}
+
+// defaultReattachConfig は進捗ストリームが切断された際に実行中のジョブへ再接続するリトライ設定
+var defaultReattachConfig = retry.Config{
+	MaxAttempts: 3,
+	InitialWait: time.Second,
+	MaxWait:     5 * time.Second,
+	Multiplier:  2,
+	Jitter:      retry.DefaultConfig.Jitter,
+	IsRetryable: isRecoverableStreamError,
+}
+
+// isRecoverableStreamError は Worker との進捗ストリームのエラーが再接続で回復できる可能性があるかを返す
+// 接続断などの一時的なエラー（Unavailable）のみを対象とし、Worker がジョブを見つけられない場合などは回復しない
+func isRecoverableStreamError(err error) bool {
+	return status.Code(err) == codes.Unavailable
+}
+
+// progressReceiver は Worker からの進捗ストリーム（SubmitJob / AttachJob）
+type progressReceiver interface {
+	Recv() (*workerv1.JobProgress, error)
+}
+
+// retryConfig は base をジョブのリトライ設定で上書きした設定を返す（nil の場合は base）
+func (p *RetryPolicy) retryConfig(base retry.Config) (retry.Config, error) {
+	if p == nil {
+		return base, nil
+	}
+	return base.WithOverrides(p.MaxAttempts, p.InitialWaitMs, p.MaxWaitMs)
+}
+
+// toProto は Worker に送信するリトライ設定に変換する
+func (p *RetryPolicy) toProto() *workerv1.RetryPolicy {
+	if p == nil {
+		return nil
+	}
+	return &workerv1.RetryPolicy{
+		MaxAttempts:   int32(p.MaxAttempts),
+		InitialWaitMs: p.InitialWaitMs,
+		MaxWaitMs:     p.MaxWaitMs,
+	}
+}
+
+// ValidationConfig はジョブごとの出力の検証の設定（省略した項目は Worker の既定値を使用する）
+type ValidationConfig struct {
+	// Level は検証のレベル（"minimal"、"standard"、"strict"）
+	Level string `json:"level,omitempty" enums:"minimal,standard,strict" example:"strict"`
+	// TimeoutSeconds は検証のタイムアウト（秒）
+	TimeoutSeconds int `json:"timeout_seconds,omitempty" example:"60"`
+	// SkipDecodeTest は strict レベルのデコードテストを省略するか
+	SkipDecodeTest *bool `json:"skip_decode_test,omitempty" example:"true"`
+	// HLSValidationDepth は HLS の検証の深さ（"basic"、"medium"、"full"）
+	HLSValidationDepth string `json:"hls_validation_depth,omitempty" enums:"basic,medium,full" example:"full"`
+}
+
+// settings は Worker のエンコーダーと同じ検証の設定に変換する
+func (v *ValidationConfig) settings() encoder.ValidationSettings {
+	if v == nil {
+		return encoder.ValidationSettings{}
+	}
+	return encoder.ValidationSettings{
+		Level:              v.Level,
+		Timeout:            time.Duration(v.TimeoutSeconds) * time.Second,
+		SkipDecodeTest:     v.SkipDecodeTest,
+		HLSValidationDepth: v.HLSValidationDepth,
+	}
+}
+
+// toProto は Worker に送信する検証の設定に変換する
+func (v *ValidationConfig) toProto() *workerv1.ValidationConfig {
+	if v == nil {
+		return nil
+	}
+	return &workerv1.ValidationConfig{
+		Level:              v.Level,
+		TimeoutSeconds:     int32(v.TimeoutSeconds),
+		SkipDecodeTest:     v.SkipDecodeTest,
+		HlsValidationDepth: v.HLSValidationDepth,
+	}
 }
 
 // OutputConfig はアップロード先の設定
 type OutputConfig struct {
-	Storage  string            `json:"storage" binding:"required" example:"s3"`
-	Path     string            `json:"path" binding:"required" example:"output/video.mp4"`
+	Storage string `json:"storage" binding:"required" example:"s3"`
+	Path    string `json:"path" binding:"required" example:"output/video.mp4"`
+	// Metadata は出力に付与するメタデータ。キーは S3 のメタデータに使える文字（小文字の英数字・ハイフン・アンダースコア）に変換する
+	// 件数と合計サイズ（キーと値のバイト数）の上限は METADATA_MAX_ENTRIES・METADATA_MAX_BYTES（デフォルト 20 件・2048 バイト）
 	Metadata map[string]string `json:"metadata" example:"key1:value1,key2:value2"`
+	// KMSKeyID は S3 の出力を SSE-KMS で暗号化する KMS キー（キーID、キーARN、エイリアス）。省略時は Worker の既定値
+	KMSKeyID string `json:"kms_key_id,omitempty" example:"arn:aws:kms:ap-northeast-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab"`
 }
 
 // JobResponse はジョブ作成のレスポンス
@@ -48,6 +309,14 @@ type JobResponse struct {
 	JobID     string `json:"job_id" example:"550e8400-e29b-41d4-a716-446655440000"`
 	Status    string `json:"status" example:"accepted"`
 	StreamURL string `json:"stream_url" example:"/api/v1/jobs/550e8400-e29b-41d4-a716-446655440000/stream"`
+	// WorkerID はジョブを送信した Worker の識別子（Worker が識別子を返さない場合はアドレス）
+	WorkerID string `json:"worker_id,omitempty" example:"worker-1"`
+	// Preset はジョブに使用したプリセット（フォールバックした場合は fallback_preset）
+	Preset string `json:"preset" example:"720p_h264"`
+	// FallbackUsed は preset に対応する Worker がなく fallback_preset を使用したか
+	FallbackUsed bool `json:"fallback_used,omitempty" example:"false"`
+	// QueuePosition は Worker の実行枠が満杯でキューで待つ場合の順番（1 始まり、すぐに開始する場合は省略）
+	QueuePosition int `json:"queue_position,omitempty" example:"2"`
 }
 
 // ErrorResponse はエラーレスポンス
@@ -64,7 +333,10 @@ type ErrorResponse struct {
 // @Param job body JobRequest true "Job parameters"
 // @Success 202 {object} JobResponse "Job accepted"
 // @Failure 400 {object} ErrorResponse "Invalid request"
-// @Failure 503 {object} ErrorResponse "No available workers"
+// @Failure 403 {object} ErrorResponse "Preset is not allowed for the API key, or raw ffmpeg args are not allowed"
+// @Failure 422 {object} ErrorResponse "No worker has the ffmpeg filters/encoders required by the preset"
+// @Failure 503 {object} ErrorResponse "No available workers or worker is busy"
+// @Header 503 {string} Retry-After "Seconds to wait before retrying when the worker is busy"
 // @Security bearerAuth
 // @Router /jobs [post]
 func (h *Handler) CreateJob(c *gin.Context) {
@@ -74,162 +346,815 @@ func (h *Handler) CreateJob(c *gin.Context) {
 		return
 	}
 
-	// ジョブIDを生成
+	if err := h.validateJobRequest(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.checkPresetAllowed(c, req); err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+
 	jobID := uuid.New().String()
+	job, err := h.startJob(c.Request.Context(), jobID, req)
+	if err != nil {
+		respondStartJobError(c, err)
+		return
+	}
+
+	// ジョブ作成レスポンス
+	c.JSON(http.StatusAccepted, newJobResponse(jobID, job))
+}
+
+// validateJobRequest はジョブのリクエストのうち Worker に送信する前に検証できる項目をチェックする
+func (h *Handler) validateJobRequest(req JobRequest) error {
+	if len(req.RawFFmpegArgs) > 0 {
+		if err := checkRawFFmpegArgs(req); err != nil {
+			return err
+		}
+		if err := h.checkRawOutputHeight(req.RawFFmpegArgs); err != nil {
+			return err
+		}
+	}
+
+	if req.Speed != "" && !preset.IsValidSpeed(req.Speed) {
+		return fmt.Errorf("invalid speed: %s", req.Speed)
+	}
+
+	if err := preset.ValidateOverrides(req.Overrides); err != nil {
+		return err
+	}
+	if _, ok := req.Overrides["preset"]; ok && req.Speed != "" {
+		return errors.New("speed and preset override cannot both be set")
+	}
+
+	if err := h.checkOutputHeight(req.Preset); err != nil {
+		return err
+	}
+
+	if _, err := encoder.ParseClip(req.StartTime, req.Duration); err != nil {
+		return err
+	}
+
+	if _, err := req.Retry.retryConfig(retry.DefaultConfig); err != nil {
+		return fmt.Errorf("invalid retry: %w", err)
+	}
+
+	if err := req.Validation.settings().Validate(); err != nil {
+		return fmt.Errorf("invalid validation: %w", err)
+	}
+
+	if req.CallbackURL != "" {
+		if err := validateCallbackURL(req.CallbackURL); err != nil {
+			return err
+		}
+	}
+
+	if err := checkSubtitle(req.Preset, req.SubtitlePath, req.StreamCopy); err != nil {
+		return err
+	}
+
+	// 初期化セグメント・暗号化キーを出力できるかはプリセットの引数で決まるため、Worker でチェックする
+	for _, auxPath := range []string{req.HLSInitPath, req.HLSKeyPath} {
+		if auxPath == "" {
+			continue
+		}
+		if err := encoder.ValidateHLSAuxPath(auxPath); err != nil {
+			return err
+		}
+	}
+
+	if req.FallbackPreset != "" {
+		if err := h.checkFallbackPreset(req); err != nil {
+			return err
+		}
+	}
+
+	if req.Output.KMSKeyID != "" {
+		if err := uploader.ValidateKMSKeyID(req.Output.KMSKeyID); err != nil {
+			return err
+		}
+	}
+
+	if _, err := uploader.SanitizeMetadata(req.Output.Metadata, h.metadataLimits); err != nil {
+		return fmt.Errorf("invalid output metadata: %w", err)
+	}
+	return nil
+}
+
+// checkPresetAllowed は認証した API Key が req のプリセット（fallback_preset を含む）を利用できるかチェックする
+// プリセットを制限されたキーは、プリセットを経由しない生の ffmpeg 引数も利用できない
+func (h *Handler) checkPresetAllowed(c *gin.Context, req JobRequest) error {
+	keyName := c.GetString(auth.APIKeyNameContextKey)
+	if !h.presetAllowlist.Restricted(keyName) {
+		return nil
+	}
+	if len(req.RawFFmpegArgs) > 0 {
+		return errors.New("raw_ffmpeg_args are not allowed for this api key")
+	}
+	for _, name := range []string{req.Preset, req.FallbackPreset} {
+		if name != "" && !h.presetAllowlist.Allows(keyName, name) {
+			logger.Warn("Preset is not allowed for api key",
+				zap.String("api_key_name", keyName),
+				zap.String("preset", name),
+			)
+			return fmt.Errorf("preset is not allowed for this api key: %s", name)
+		}
+	}
+	return nil
+}
+
+// checkRawFFmpegArgs は生の ffmpeg 引数と、併用できないプリセット関連の指定がないかをチェックする
+func checkRawFFmpegArgs(req JobRequest) error {
+	switch {
+	case req.Preset != "":
+		return errors.New("preset and raw_ffmpeg_args cannot both be set")
+	case req.FallbackPreset != "":
+		return errors.New("fallback_preset cannot be combined with raw_ffmpeg_args")
+	case req.Speed != "", req.StreamCopy != "", len(req.Overrides) > 0, req.SegmentLayout != "", req.SubtitlePath != "":
+		return errors.New("speed, stream_copy, overrides, segment_layout and subtitle_path cannot be combined with raw_ffmpeg_args")
+	case req.HLSInitPath != "", req.HLSKeyPath != "":
+		return errors.New("hls_init_path and hls_key_path cannot be combined with raw_ffmpeg_args")
+	}
+	if err := encoder.ValidateRawArgs(req.RawFFmpegArgs); err != nil {
+		return fmt.Errorf("invalid raw_ffmpeg_args: %w", err)
+	}
+	return nil
+}
+
+// checkFallbackPreset はフォールバックのプリセットを指定できるかチェックする
+// 対応する Worker がない場合に選択し直すため、Control Plane が要件を知っているプリセットのみ指定できる
+func (h *Handler) checkFallbackPreset(req JobRequest) error {
+	if req.FallbackPreset == req.Preset {
+		return errors.New("fallback_preset must differ from preset")
+	}
+	if !preset.Exists(req.FallbackPreset) {
+		return fmt.Errorf("unknown fallback_preset: %s", req.FallbackPreset)
+	}
+	if err := h.checkOutputHeight(req.FallbackPreset); err != nil {
+		return fmt.Errorf("fallback_preset: %w", err)
+	}
+	if err := checkSubtitle(req.FallbackPreset, req.SubtitlePath, req.StreamCopy); err != nil {
+		return fmt.Errorf("fallback_preset: %w", err)
+	}
+	return nil
+}
+
+// dispatchedJob は Worker に送信したジョブの送信先と使用したプリセット
+type dispatchedJob struct {
+	worker balancer.WorkerInfo
+	preset string
+	// fallback は preset に対応する Worker がなく、fallback_preset を使用したか
+	fallback bool
+	// queuePosition は Worker のキューで待つ順番（キューで待たない場合は 0）
+	queuePosition int
+}
+
+// newJobResponse はジョブ受付時のレスポンスを作成する
+func newJobResponse(jobID string, job dispatchedJob) JobResponse {
+	workerID := job.worker.WorkerID
+	if workerID == "" {
+		workerID = job.worker.Address
+	}
+	return JobResponse{
+		JobID:         jobID,
+		Status:        "accepted",
+		StreamURL:     fmt.Sprintf("/api/v1/jobs/%s/stream", jobID),
+		WorkerID:      workerID,
+		Preset:        job.preset,
+		FallbackUsed:  job.fallback,
+		QueuePosition: job.queuePosition,
+	}
+}
+
+// workerBusyError は Worker が容量超過でジョブを拒否したことを表す
+type workerBusyError struct {
+	err error
+	// retryAfter は Worker が提示した再試行までの待ち時間（提示がない場合は 0）
+	retryAfter time.Duration
+}
+
+func (e *workerBusyError) Error() string {
+	return fmt.Sprintf("worker is busy: %v", e.err)
+}
+
+func (e *workerBusyError) Unwrap() error {
+	return e.err
+}
+
+// errRawArgsNotAllowed は Worker が生の ffmpeg 引数を許可していないことを表す
+var errRawArgsNotAllowed = errors.New("raw ffmpeg args are not allowed on the selected worker")
+
+// retryDelayFromError は gRPC エラーの詳細に含まれる RetryInfo から待ち時間を取り出す
+func retryDelayFromError(err error) time.Duration {
+	st, ok := status.FromError(err)
+	if !ok {
+		return 0
+	}
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.RetryInfo); ok && info.GetRetryDelay() != nil {
+			return info.GetRetryDelay().AsDuration()
+		}
+	}
+	return 0
+}
+
+// respondStartJobError は startJob のエラーを 503 レスポンスとして返す
+// Worker が容量超過の場合は Retry-After ヘッダーで再試行までの秒数を通知する
+// 必要なフィルター・エンコーダーを持つ Worker がない場合はリトライしても成功しないため 422 を返す
+// Worker が生の ffmpeg 引数を許可していない場合は 403 を返す
+func respondStartJobError(c *gin.Context, err error) {
+	var unsupported *balancer.UnsupportedCapabilityError
+	if errors.As(err, &unsupported) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": unsupported.Error()})
+		return
+	}
+
+	if errors.Is(err, errRawArgsNotAllowed) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+
+	var busy *workerBusyError
+	if errors.As(err, &busy) {
+		if busy.retryAfter > 0 {
+			seconds := int64((busy.retryAfter + time.Second - 1) / time.Second)
+			c.Header("Retry-After", strconv.FormatInt(seconds, 10))
+		}
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "worker is busy"})
+		return
+	}
+	c.JSON(http.StatusServiceUnavailable, gin.H{"error": "no available workers"})
+}
 
+// startJob は Worker を選択して jobID のジョブを送信し、送信先の Worker と使用したプリセットを返す
+// preset に対応する Worker がなく fallback_preset がある場合は、fallback_preset で選択し直して警告の進捗を送信する
+// 最初の進捗以降の受信はゴルーチンで非同期に行う
+func (h *Handler) startJob(ctx context.Context, jobID string, req JobRequest) (dispatchedJob, error) {
 	logger.Info("Creating job",
 		zap.String("job_id", jobID),
 		zap.String("input_url", req.InputURL),
 		zap.String("preset", req.Preset),
+		zap.String("speed", req.Speed),
+		zap.String("stream_copy", req.StreamCopy),
+		zap.Any("overrides", req.Overrides),
+		zap.String("segment_layout", req.SegmentLayout),
+		zap.String("hls_init_path", req.HLSInitPath),
+		zap.String("hls_key_path", req.HLSKeyPath),
+		zap.Any("retry", req.Retry),
+		zap.String("priority", req.Priority),
+		zap.Int("raw_ffmpeg_args", len(req.RawFFmpegArgs)),
 	)
 
-	// Worker を選択
-	_, conn, err := h.balancer.SelectWorker(c.Request.Context())
+	// 同時ディスパッチ数の上限に達している場合は空きができるまで待つ
+	// 枠は Worker から進捗の受信を終えるまで保持する
+	release, err := h.acquireDispatchSlot(ctx)
+	if err != nil {
+		logger.Warn("Failed to acquire dispatch slot", zap.String("job_id", jobID), zap.Error(err))
+		return dispatchedJob{}, err
+	}
+	dispatched := false
+	defer func() {
+		if !dispatched {
+			release()
+		}
+	}()
+
+	// Worker を選択（ジョブのリトライ設定がある場合は空き Worker が見つかるまでリトライする）
+	selectConfig, err := req.Retry.retryConfig(defaultSelectRetryConfig)
+	if err != nil {
+		return dispatchedJob{}, err
+	}
+	presetName := req.Preset
+	worker, conn, err := h.selectWorker(ctx, selectConfig, jobCapabilities(presetName, req))
+	var fallbackWarning string
+	var unsupported *balancer.UnsupportedCapabilityError
+	if err != nil && req.FallbackPreset != "" && errors.As(err, &unsupported) {
+		// 記録するリクエスト（デッドレターなど）は元のプリセットのまま残し、送信するプリセットのみを切り替える
+		fallbackWarning = fmt.Sprintf("Using fallback preset %s: %v (preset %s)", req.FallbackPreset, unsupported, req.Preset)
+		logger.Warn("No worker supports the preset, using fallback preset",
+			zap.String("job_id", jobID),
+			zap.String("preset", req.Preset),
+			zap.String("fallback_preset", req.FallbackPreset),
+			zap.Strings("missing", unsupported.Missing),
+		)
+		presetName = req.FallbackPreset
+		worker, conn, err = h.selectWorker(ctx, selectConfig, jobCapabilities(presetName, req))
+	}
 	if err != nil {
 		logger.Error("Failed to select worker", zap.Error(err))
-		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "no available workers"})
-		return
+		return dispatchedJob{}, err
+	}
+
+	// Worker にジョブを送信し、最初の進捗（QUEUED）を受け取るまでは同期的に待つ
+	// Worker が容量超過で拒否した場合は、再試行までの待ち時間とともにエラーを返す
+	client := workerv1.NewWorkerServiceClient(conn)
+	stream, err := client.SubmitJob(context.Background(), req.toProto(jobID, presetName))
+	var first *workerv1.JobProgress
+	if err == nil {
+		first, err = stream.Recv()
+	}
+	if status.Code(err) == codes.ResourceExhausted {
+		busyErr := &workerBusyError{err: err, retryAfter: retryDelayFromError(err)}
+		logger.Warn("Worker rejected job due to capacity",
+			zap.String("job_id", jobID),
+			zap.Duration("retry_after", busyErr.retryAfter),
+		)
+		return dispatchedJob{}, busyErr
+	}
+	if status.Code(err) == codes.PermissionDenied && len(req.RawFFmpegArgs) > 0 {
+		logger.Warn("Worker rejected raw ffmpeg args", zap.String("job_id", jobID), zap.String("worker", worker.Address))
+		return dispatchedJob{}, fmt.Errorf("%w: %v", errRawArgsNotAllowed, err)
 	}
 
-	// 進捗チャネル作成
+	// 進捗チャネル作成（キャンセルを転送できるよう送信先の Worker も記録する）
 	progressCh := h.jobManager.CreateProgressChannel(jobID)
+	h.jobManager.SetWorker(jobID, worker.Address)
 
-	// Worker にジョブを送信（ゴルーチンで非同期実行）
+	// 進捗を記録してチャネルに送信する（SSE のクライアントがいなくても受信を止めない）
+	// 失敗で終了した場合（キャンセルした場合を除く）はデッドレターに記録し、完了・失敗時は callback_url に通知する
+	sendProgress := func(progress *workerv1.JobProgress) {
+		h.recordProgress(jobID, progress)
+		h.cancelGroupJobs(h.jobGroups.RecordProgress(jobID, progress))
+		if progress.Status == workerv1.JobStatus_JOB_STATUS_FAILED && !h.jobManager.IsCancelled(jobID) {
+			h.recordDeadLetter(jobID, req, progress)
+		}
+		if req.CallbackURL != "" && isWebhookStatus(progress.Status) {
+			go h.sendWebhook(req.CallbackURL, jobID, progress)
+		}
+		publishProgress(progressCh, progress)
+	}
+
+	h.recordPresetUsage(jobID, presetName, req)
+
+	// Worker から進捗が届く前でも GET /jobs/:id で参照できるよう受付状態を記録する
+	h.recordProgress(jobID, &workerv1.JobProgress{
+		JobId:   jobID,
+		Status:  workerv1.JobStatus_JOB_STATUS_QUEUED,
+		Message: "Job accepted",
+	})
+
+	// 以降の進捗はゴルーチンで非同期に受信する
+	dispatched = true
 	go func() {
+		defer release()
+		defer h.jobManager.CloseProgressChannel(jobID)
 		defer func() {
-			if err := conn.Close(); err != nil {
-				logger.Warn("Failed to close worker connection", zap.Error(err))
-			}
+			h.cancelGroupJobs(h.jobGroups.CloseJob(jobID))
 		}()
-		defer h.jobManager.CloseProgressChannel(jobID)
 
-		client := workerv1.NewWorkerServiceClient(conn)
-		stream, err := client.SubmitJob(context.Background(), &workerv1.JobRequest{
-			JobId:    jobID,
-			InputUrl: req.InputURL,
-			Preset:   req.Preset,
-			Output: &workerv1.OutputConfig{
-				Storage:  req.Output.Storage,
-				Path:     req.Output.Path,
-				Metadata: req.Output.Metadata,
-			},
-		})
+		if err == io.EOF {
+			return
+		}
 		if err != nil {
 			logger.Error("Failed to submit job", zap.Error(err))
-			progressCh <- &workerv1.JobProgress{
+			sendProgress(&workerv1.JobProgress{
 				JobId:   jobID,
 				Status:  workerv1.JobStatus_JOB_STATUS_FAILED,
 				Message: "Failed to submit job",
 				Error:   err.Error(),
-			}
+			})
 			return
 		}
+		if fallbackWarning != "" {
+			sendProgress(&workerv1.JobProgress{
+				JobId:   jobID,
+				Status:  workerv1.JobStatus_JOB_STATUS_QUEUED,
+				Message: fallbackWarning,
+			})
+		}
+		sendProgress(first)
 
 		// 進捗を受信してチャネルに送信
+		var receiver progressReceiver = stream
 		for {
-			progress, err := stream.Recv()
+			progress, err := receiver.Recv()
 			if err == io.EOF {
 				break
 			}
+			if err != nil && isRecoverableStreamError(err) {
+				// 一時的な切断の場合は Worker で実行中のジョブに再接続して受信を続ける
+				logger.Warn("Progress stream interrupted, reattaching to job",
+					zap.String("job_id", jobID),
+					zap.String("worker", worker.Address),
+					zap.Error(err),
+				)
+				attached, latest, attachErr := h.reattachJob(jobID, worker.Address)
+				if attachErr == nil {
+					receiver = attached
+					logger.Info("Reattached to job", zap.String("job_id", jobID))
+					sendProgress(latest)
+					continue
+				}
+				logger.Warn("Failed to reattach to job", zap.String("job_id", jobID), zap.Error(attachErr))
+			}
 			if err != nil {
 				logger.Error("Failed to receive progress", zap.Error(err))
-				progressCh <- &workerv1.JobProgress{
+				sendProgress(&workerv1.JobProgress{
 					JobId:   jobID,
 					Status:  workerv1.JobStatus_JOB_STATUS_FAILED,
 					Message: "Failed to receive progress",
 					Error:   err.Error(),
-				}
+				})
 				return
 			}
-			progressCh <- progress
+			sendProgress(progress)
 		}
 	}()
 
-	// ジョブ作成レスポンス
-	c.JSON(http.StatusAccepted, gin.H{
-		"job_id":     jobID,
-		"status":     "accepted",
-		"stream_url": fmt.Sprintf("/api/v1/jobs/%s/stream", jobID),
-	})
+	return dispatchedJob{worker: worker, preset: presetName, fallback: fallbackWarning != "", queuePosition: int(first.GetQueuePosition())}, nil
 }
 
-// StreamJobProgress はジョブの進捗をSSEでストリーム
-// @Summary Stream job progress
-// @Description Get real-time job progress updates via Server-Sent Events (SSE)
-// @Tags jobs
-// @Produce text/event-stream
-// @Param id path string true "Job ID"
-// @Success 200 {string} string "SSE stream of job progress"
-// @Failure 404 {object} ErrorResponse "Job not found"
-// @Security bearerAuth
-// @Router /jobs/{id}/stream [get]
-func (h *Handler) StreamJobProgress(c *gin.Context) {
-	jobID := c.Param("id")
+// toProto は presetName で jobID のジョブとして Worker に送信するリクエストに変換する
+func (req JobRequest) toProto(jobID, presetName string) *workerv1.JobRequest {
+	priority := req.Priority
+	if priority == "" {
+		priority = defaultJobPriority
+	}
+	return &workerv1.JobRequest{
+		JobId:             jobID,
+		InputUrl:          req.InputURL,
+		Preset:            presetName,
+		Speed:             req.Speed,
+		StreamCopy:        req.StreamCopy,
+		Overrides:         req.Overrides,
+		SegmentLayout:     req.SegmentLayout,
+		HlsInitPath:       req.HLSInitPath,
+		HlsKeyPath:        req.HLSKeyPath,
+		SubtitlePath:      req.SubtitlePath,
+		Retry:             req.Retry.toProto(),
+		KeepPartialOutput: req.KeepPartialOutput,
+		RawFfmpegArgs:     req.RawFFmpegArgs,
+		StartTime:         req.StartTime,
+		Duration:          req.Duration,
+		SkipPreflight:     req.SkipPreflight,
+		Validation:        req.Validation.toProto(),
+		Priority:          priority,
+		Output: &workerv1.OutputConfig{
+			Storage:  req.Output.Storage,
+			Path:     req.Output.Path,
+			Metadata: req.Output.Metadata,
+			KmsKeyId: req.Output.KMSKeyID,
+		},
+	}
+}
 
-	logger.Info("Streaming job progress", zap.String("job_id", jobID))
+// jobCapabilities はジョブに必要なフィルター・エンコーダーを返す
+// 生の ffmpeg 引数の場合はその引数から、それ以外はプリセットと字幕の焼き込みから求める
+func jobCapabilities(presetName string, req JobRequest) balancer.Capabilities {
+	if len(req.RawFFmpegArgs) > 0 {
+		p := preset.Preset{FFmpegArgs: req.RawFFmpegArgs}
+		return balancer.Capabilities{Filters: p.RequiredFilters(), Encoders: p.RequiredEncoders()}
+	}
+	required := requiredCapabilities(presetName)
+	if req.SubtitlePath != "" {
+		// 字幕の焼き込みには libass を有効にした ffmpeg の subtitles フィルターが必要
+		required.Filters = append(required.Filters, "subtitles")
+	}
+	return required
+}
 
-	// SSE ヘッダー設定
-	c.Writer.Header().Set("Content-Type", "text/event-stream")
-	c.Writer.Header().Set("Cache-Control", "no-cache")
-	c.Writer.Header().Set("Connection", "keep-alive")
-	c.Writer.Header().Set("Transfer-Encoding", "chunked")
-	c.Writer.Header().Set("X-Accel-Buffering", "no") // Nginxのバッファリング無効化
+// selectWorker は required のフィルター・エンコーダーを持つ Worker を選択する
+// 持つ Worker がない場合（*balancer.UnsupportedCapabilityError）はリトライしない
+func (h *Handler) selectWorker(ctx context.Context, config retry.Config, required balancer.Capabilities) (balancer.WorkerInfo, *grpc.ClientConn, error) {
+	config.IsRetryable = func(err error) bool {
+		var unsupported *balancer.UnsupportedCapabilityError
+		return !errors.As(err, &unsupported)
+	}
 
-	// 進捗チャネル取得
-	progressCh, exists := h.jobManager.GetProgressChannel(jobID)
-	if !exists {
-		logger.Warn("Job not found", zap.String("job_id", jobID))
-		if _, err := fmt.Fprintf(c.Writer, "data: {\"error\":\"job not found\"}\n\n"); err != nil {
-			logger.Warn("Failed to write SSE error", zap.Error(err))
+	var worker balancer.WorkerInfo
+	var conn *grpc.ClientConn
+	err := retry.Do(ctx, config, func() error {
+		var selectErr error
+		worker, conn, selectErr = h.balancer.SelectWorkerFor(ctx, required)
+		return selectErr
+	})
+	return worker, conn, err
+}
+
+// reattachJob は Worker で実行中のジョブの進捗ストリームに再接続し、最初に届いた進捗（最新の進捗）とともに返す
+// 一時的なエラーの間は reattachConfig に従ってリトライする
+func (h *Handler) reattachJob(jobID, workerAddr string) (progressReceiver, *workerv1.JobProgress, error) {
+	var stream progressReceiver
+	var first *workerv1.JobProgress
+	err := retry.Do(context.Background(), h.reattachConfig, func() error {
+		conn, err := h.balancer.Dial(workerAddr)
+		if err != nil {
+			return err
 		}
-		c.Writer.Flush()
-		return
+		s, err := workerv1.NewWorkerServiceClient(conn).AttachJob(context.Background(), &workerv1.AttachRequest{JobId: jobID})
+		if err == nil {
+			first, err = s.Recv()
+		}
+		if err != nil {
+			return err
+		}
+		stream = s
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
 	}
+	return stream, first, nil
+}
 
-	// 進捗を SSE で送信
-	flusher, ok := c.Writer.(http.Flusher)
-	if !ok {
-		logger.Error("Streaming not supported")
-		if _, err := fmt.Fprintf(c.Writer, "data: {\"error\":\"streaming not supported\"}\n\n"); err != nil {
-			logger.Warn("Failed to write SSE error", zap.Error(err))
-		}
+// recordProgress はジョブの最新の進捗を記録し、ステータスが変わった場合は JobStore に保存する
+// 進捗率の更新ごとには保存しない（書き込みを状態遷移の回数に抑える）
+func (h *Handler) recordProgress(jobID string, progress *workerv1.JobProgress) {
+	previous, exists := h.jobManager.GetLastProgress(jobID)
+	h.jobManager.RecordProgress(jobID, progress)
+	if exists && previous.Status == progress.Status {
 		return
 	}
 
-	for {
-		select {
-		case progress, ok := <-progressCh:
-			if !ok {
-				// チャネルが閉じられた
-				logger.Info("Progress channel closed", zap.String("job_id", jobID))
-				return
-			}
-
-			// JSON形式で送信（安全にエスケープ）
-			data := map[string]interface{}{
-				"job_id":   progress.JobId,
-				"status":   progress.Status.String(),
-				"progress": progress.Progress,
-				"message":  progress.Message,
-			}
-			if progress.OutputUrl != "" {
-				data["output_url"] = progress.OutputUrl
-			}
-			if progress.Error != "" {
-				data["error"] = progress.Error
-			}
+	if err := h.jobStore.Save(jobID, newJobState(jobID, progress, time.Now())); err != nil {
+		logger.Warn("Failed to persist job state",
+			zap.String("job_id", jobID),
+			zap.String("status", progress.Status.String()),
+			zap.Error(err),
+		)
+	}
+}
 
-			jsonData, err := json.Marshal(data)
-			if err != nil {
-				logger.Error("Failed to marshal progress", zap.Error(err))
-				continue
-			}
+// lastKnownProgress はジョブの最新の進捗を返す
+// メモリ上にない場合（Control Plane の再起動後など）は JobStore から読み込む
+func (h *Handler) lastKnownProgress(jobID string) (*workerv1.JobProgress, bool) {
+	if progress, exists := h.jobManager.GetLastProgress(jobID); exists {
+		return progress, true
+	}
 
-			if _, err := fmt.Fprintf(c.Writer, "data: %s\n\n", jsonData); err != nil {
-				logger.Warn("Failed to write SSE progress", zap.Error(err))
-				continue
-			}
-			flusher.Flush()
+	state, err := h.jobStore.Load(jobID)
+	if err != nil {
+		if !errors.Is(err, ErrJobStateNotFound) {
+			logger.Warn("Failed to load job state", zap.String("job_id", jobID), zap.Error(err))
+		}
+		return nil, false
+	}
+
+	if !state.isFinished() {
+		// 進捗を受信していた Control Plane が停止したため、Worker とのストリームが切断されジョブは中断されている
+		state.Status = workerv1.JobStatus_JOB_STATUS_FAILED.String()
+		state.Message = "Job interrupted"
+		state.Error = "control plane restarted while the job was running"
+	}
+	if ttl := h.jobManager.StatusTTL(); time.Since(state.UpdatedAt) >= ttl {
+		return nil, false
+	}
+	return state.toProgress(), true
+}
+
+// recordDeadLetter は失敗したジョブをデッドレターに記録する
+func (h *Handler) recordDeadLetter(jobID string, req JobRequest, progress *workerv1.JobProgress) {
+	entry := DeadLetter{
+		JobID:    jobID,
+		Request:  req,
+		Message:  progress.Message,
+		Error:    progress.Error,
+		FailedAt: time.Now(),
+	}
+	if err := h.deadLetters.Add(entry); err != nil {
+		logger.Error("Failed to record dead letter",
+			zap.String("job_id", jobID),
+			zap.Error(err),
+		)
+		return
+	}
+
+	logger.Warn("Job recorded in dead letter store",
+		zap.String("job_id", jobID),
+		zap.String("preset", req.Preset),
+		zap.String("input_url", req.InputURL),
+		zap.String("error", progress.Error),
+	)
+}
+
+// ListFailedJobs は失敗したジョブの一覧を取得する
+// @Summary List failed jobs
+// @Description List jobs that ended in failure (dead letters), newest first. Each entry contains the original request for replay, with URL query strings, URL credentials and the KMS key redacted. Cancelled jobs are not recorded. Requires an API key listed in ADMIN_API_KEYS.
+// @Tags jobs
+// @Produce json
+// @Success 200 {array} DeadLetter
+// @Failure 403 {object} ErrorResponse "Admin API key required"
+// @Failure 500 {object} ErrorResponse "Failed to read dead letters"
+// @Security bearerAuth
+// @Router /jobs/failed [get]
+func (h *Handler) ListFailedJobs(c *gin.Context) {
+	entries, err := h.deadLetters.List()
+	if err != nil {
+		logger.Error("Failed to list dead letters", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list failed jobs"})
+		return
+	}
+
+	redacted := make([]DeadLetter, 0, len(entries))
+	for _, entry := range entries {
+		redacted = append(redacted, entry.redacted())
+	}
+	c.JSON(http.StatusOK, redacted)
+}
+
+// ReplayFailedJob は失敗したジョブを同じリクエスト内容で再投入する
+// @Summary Replay a failed job
+// @Description Resubmit a failed job with its original request as a new job. The request is validated again with the current settings. The dead letter is removed once the new job is accepted. Requires an API key listed in ADMIN_API_KEYS.
+// @Tags jobs
+// @Produce json
+// @Param id path string true "Failed job ID"
+// @Success 202 {object} JobResponse "Job accepted"
+// @Failure 400 {object} ErrorResponse "Original request is no longer valid"
+// @Failure 403 {object} ErrorResponse "Admin API key required, or preset is not allowed for the API key"
+// @Failure 404 {object} ErrorResponse "Failed job not found"
+// @Failure 422 {object} ErrorResponse "No worker has the ffmpeg filters/encoders required by the preset"
+// @Failure 503 {object} ErrorResponse "No available workers or worker is busy"
+// @Header 503 {string} Retry-After "Seconds to wait before retrying when the worker is busy"
+// @Security bearerAuth
+// @Router /jobs/failed/{id}/replay [post]
+func (h *Handler) ReplayFailedJob(c *gin.Context) {
+	failedJobID := c.Param("id")
+
+	entry, err := h.deadLetters.Get(failedJobID)
+	if err != nil {
+		if errors.Is(err, ErrDeadLetterNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		logger.Error("Failed to get dead letter", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get failed job"})
+		return
+	}
+
+	// 記録後に設定（解像度の上限など）が変わっている場合があるため、新しいジョブと同じく検証し直す
+	if err := h.validateJobRequest(entry.Request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 失敗したジョブを投入したキーと再投入するキーは異なる場合があるため、再投入するキーで確認する
+	if err := h.checkPresetAllowed(c, entry.Request); err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+
+	jobID := uuid.New().String()
+	job, err := h.startJob(c.Request.Context(), jobID, entry.Request)
+	if err != nil {
+		respondStartJobError(c, err)
+		return
+	}
+
+	if err := h.deadLetters.Remove(failedJobID); err != nil && !errors.Is(err, ErrDeadLetterNotFound) {
+		logger.Warn("Failed to remove replayed dead letter",
+			zap.String("job_id", failedJobID),
+			zap.Error(err),
+		)
+	}
+
+	logger.Info("Replayed failed job",
+		zap.String("failed_job_id", failedJobID),
+		zap.String("job_id", jobID),
+	)
+
+	c.JSON(http.StatusAccepted, newJobResponse(jobID, job))
+}
+
+// requiredCapabilities はプリセットの ffmpeg 引数から Worker に必要なフィルター・エンコーダーを返す
+// Control Plane が知らないプリセット（Worker 側で追加されたもの）は要件なしとして扱う
+func requiredCapabilities(presetName string) balancer.Capabilities {
+	p, err := preset.Get(presetName)
+	if err != nil {
+		return balancer.Capabilities{}
+	}
+	return balancer.Capabilities{
+		Filters:  p.RequiredFilters(),
+		Encoders: p.RequiredEncoders(),
+	}
+}
+
+// checkSubtitle は字幕の焼き込みを指定できるかチェックする
+// Control Plane が知らないプリセット（Worker 側で追加されたもの）の場合は Worker でチェックする
+func checkSubtitle(presetName, subtitlePath, streamCopy string) error {
+	if subtitlePath == "" {
+		return nil
+	}
+	if err := encoder.ValidateSubtitlePath(subtitlePath); err != nil {
+		return err
+	}
+	if streamCopy == encoder.StreamCopyVideo {
+		return fmt.Errorf("burn-in subtitles cannot be combined with video stream copy")
+	}
+	p, err := preset.Get(presetName)
+	if err != nil {
+		return nil
+	}
+	return encoder.CheckSubtitleBurnIn(p)
+}
+
+// checkOutputHeight はプリセットの出力解像度（短辺）が上限を超えていないかチェックする
+// 縦長のプリセット（1080x1920 など）は幅を短辺として比較する
+// Control Plane が知らないプリセット（Worker 側で追加されたもの）や解像度が未指定のプリセットはチェックしない
+func (h *Handler) checkOutputHeight(presetName string) error {
+	if h.maxOutputHeight <= 0 {
+		return nil
+	}
+
+	p, err := preset.Get(presetName)
+	if err != nil {
+		return nil
+	}
+
+	if edge := p.ShortEdge(); edge > h.maxOutputHeight {
+		return fmt.Errorf("output resolution %dp of preset %s exceeds the maximum %dp", edge, presetName, h.maxOutputHeight)
+	}
+	return nil
+}
+
+// checkRawOutputHeight は生の ffmpeg 引数の出力解像度（短辺）が上限を超えていないかチェックする
+// 上限がある場合、入力と同じ解像度のまま出力されないよう -s か scale で大きさを指定する必要がある
+func (h *Handler) checkRawOutputHeight(args []string) error {
+	if h.maxOutputHeight <= 0 {
+		return nil
+	}
+
+	edge, err := encoder.RawOutputShortEdge(args)
+	if err != nil {
+		return fmt.Errorf("raw_ffmpeg_args: %w (required when the output resolution is limited to %dp)", err, h.maxOutputHeight)
+	}
+	if edge > h.maxOutputHeight {
+		return fmt.Errorf("output resolution %dp of raw_ffmpeg_args exceeds the maximum %dp", edge, h.maxOutputHeight)
+	}
+	return nil
+}
+
+// StreamJobProgress はジョブの進捗をSSEでストリーム
+// @Summary Stream job progress
+// @Description Get real-time job progress updates via Server-Sent Events (SSE)
+// @Tags jobs
+// @Produce text/event-stream
+// @Param id path string true "Job ID"
+// @Success 200 {string} string "SSE stream of job progress"
+// @Failure 404 {object} ErrorResponse "Job not found"
+// @Security bearerAuth
+// @Router /jobs/{id}/stream [get]
+func (h *Handler) StreamJobProgress(c *gin.Context) {
+	jobID := c.Param("id")
+
+	logger.Info("Streaming job progress", zap.String("job_id", jobID))
+
+	// SSE ヘッダー設定
+	c.Writer.Header().Set("Content-Type", "text/event-stream")
+	c.Writer.Header().Set("Cache-Control", "no-cache")
+	c.Writer.Header().Set("Connection", "keep-alive")
+	c.Writer.Header().Set("Transfer-Encoding", "chunked")
+	c.Writer.Header().Set("X-Accel-Buffering", "no") // Nginxのバッファリング無効化
+
+	// 進捗チャネル取得
+	progressCh, exists := h.jobManager.GetProgressChannel(jobID)
+	if !exists {
+		// 終了済みのジョブは最新の状態を1件送信して終了する
+		if progress, ok := h.lastKnownProgress(jobID); ok {
+			writeProgressEvent(c.Writer, progress)
+			c.Writer.Flush()
+			return
+		}
+
+		logger.Warn("Job not found", zap.String("job_id", jobID))
+		if _, err := fmt.Fprintf(c.Writer, "data: {\"error\":\"job not found\"}\n\n"); err != nil {
+			logger.Warn("Failed to write SSE error", zap.Error(err))
+		}
+		c.Writer.Flush()
+		return
+	}
+
+	// 進捗を SSE で送信
+	flusher, ok := c.Writer.(http.Flusher)
+	if !ok {
+		logger.Error("Streaming not supported")
+		if _, err := fmt.Fprintf(c.Writer, "data: {\"error\":\"streaming not supported\"}\n\n"); err != nil {
+			logger.Warn("Failed to write SSE error", zap.Error(err))
+		}
+		return
+	}
+
+	// 接続前に届いた進捗（受付直後の QUEUED など）を先に送信する
+	// 履歴の進捗はチャネルにも残っている場合があるため、同じ進捗が2回届くことがある（at-least-once）
+	for _, progress := range h.jobManager.GetProgressHistory(jobID) {
+		writeProgressEvent(c.Writer, progress)
+	}
+	flusher.Flush()
+
+	for {
+		select {
+		case progress, ok := <-progressCh:
+			if !ok {
+				// チャネルが閉じられた
+				logger.Info("Progress channel closed", zap.String("job_id", jobID))
+				return
+			}
+
+			if !writeProgressEvent(c.Writer, progress) {
+				continue
+			}
+			flusher.Flush()
 
 		case <-c.Request.Context().Done():
 			// クライアント切断
@@ -239,20 +1164,816 @@ func (h *Handler) StreamJobProgress(c *gin.Context) {
 	}
 }
 
+// writeProgressEvent は進捗を SSE のイベントとして書き込み、書き込めたかどうかを返す
+func writeProgressEvent(w io.Writer, progress *workerv1.JobProgress) bool {
+	// JSON形式で送信（安全にエスケープ）
+	data := map[string]interface{}{
+		"job_id":   progress.JobId,
+		"status":   progress.Status.String(),
+		"progress": progress.Progress,
+		"message":  progress.Message,
+	}
+	if progress.OutputUrl != "" {
+		data["output_url"] = progress.OutputUrl
+	}
+	if progress.Error != "" {
+		data["error"] = progress.Error
+	}
+	if progress.ErrorCode != "" {
+		data["error_code"] = progress.ErrorCode
+	}
+	if len(progress.FfmpegCommand) > 0 {
+		data["ffmpeg_command"] = progress.FfmpegCommand
+	}
+
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		logger.Error("Failed to marshal progress", zap.Error(err))
+		return false
+	}
+
+	if _, err := fmt.Fprintf(w, "data: %s\n\n", jsonData); err != nil {
+		logger.Warn("Failed to write SSE progress", zap.Error(err))
+		return false
+	}
+	return true
+}
+
+// JobStatusResponse はジョブの最新ステータスのレスポンス
+type JobStatusResponse struct {
+	JobID     string  `json:"job_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Status    string  `json:"status" example:"JOB_STATUS_COMPLETED"`
+	Progress  float32 `json:"progress" example:"100"`
+	Message   string  `json:"message" example:"Job completed"`
+	OutputURL string  `json:"output_url,omitempty" example:"https://example-bucket.s3.amazonaws.com/output/video.mp4"`
+	Error     string  `json:"error,omitempty" example:""`
+	// ErrorCode は失敗の分類（INPUT_UNREACHABLE、UNSUPPORTED_CODEC、DISK_FULL、VALIDATION_FAILED、FFMPEG_CRASH）
+	ErrorCode string `json:"error_code,omitempty" example:""`
+	// FFmpegCommand は Worker が実行した ffmpeg のコマンド（先頭は実行ファイル名）。署名付き URL のクエリの値などの認証情報は REDACTED に置き換えられる
+	FFmpegCommand []string `json:"ffmpeg_command,omitempty" example:"ffmpeg,-nostdin,-i,https://example.com/input.mp4?X-Amz-Signature=REDACTED,output.mp4"`
+}
+
+// GetJob はジョブの最新ステータスを取得する
+// @Summary Get job status
+// @Description Get the last known status of a job. The final status is retained for a limited time after the job finishes.
+// @Tags jobs
+// @Produce json
+// @Param id path string true "Job ID"
+// @Success 200 {object} JobStatusResponse
+// @Failure 404 {object} ErrorResponse "Job not found"
+// @Security bearerAuth
+// @Router /jobs/{id} [get]
+func (h *Handler) GetJob(c *gin.Context) {
+	jobID := c.Param("id")
+
+	progress, exists := h.lastKnownProgress(jobID)
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "job not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, JobStatusResponse{
+		JobID:         jobID,
+		Status:        progress.Status.String(),
+		Progress:      progress.Progress,
+		Message:       progress.Message,
+		OutputURL:     progress.OutputUrl,
+		Error:         progress.Error,
+		ErrorCode:     progress.ErrorCode,
+		FFmpegCommand: progress.FfmpegCommand,
+	})
+}
+
+// JobOutputFile はジョブがアップロードしたファイル
+type JobOutputFile struct {
+	// Path は出力先（output.path）からの相対パス。単一ファイル出力の場合は出力ファイルと同じディレクトリからの相対パス
+	Path string `json:"path" example:"stream_0/segment_000.ts"`
+	URL  string `json:"url" example:"https://example-bucket.s3.amazonaws.com/output/hls/stream_0/segment_000.ts"`
+}
+
+// JobOutputsResponse はジョブの出力ファイル一覧のレスポンス
+type JobOutputsResponse struct {
+	JobID     string          `json:"job_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	OutputURL string          `json:"output_url" example:"https://example-bucket.s3.amazonaws.com/output/hls/master.m3u8"`
+	Files     []JobOutputFile `json:"files"`
+}
+
+// outputFilesFromProto は Worker から届いた出力ファイルの一覧を変換する
+func outputFilesFromProto(files []*workerv1.OutputFile) []JobOutputFile {
+	if len(files) == 0 {
+		return nil
+	}
+	outputFiles := make([]JobOutputFile, 0, len(files))
+	for _, file := range files {
+		outputFiles = append(outputFiles, JobOutputFile{Path: file.Path, URL: file.Url})
+	}
+	return outputFiles
+}
+
+// outputFilesToProto は出力ファイルの一覧を進捗の形式に戻す
+func outputFilesToProto(files []JobOutputFile) []*workerv1.OutputFile {
+	if len(files) == 0 {
+		return nil
+	}
+	outputFiles := make([]*workerv1.OutputFile, 0, len(files))
+	for _, file := range files {
+		outputFiles = append(outputFiles, &workerv1.OutputFile{Path: file.Path, Url: file.URL})
+	}
+	return outputFiles
+}
+
+// GetJobOutputs は完了したジョブがアップロードしたファイルの一覧を取得する
+// @Summary List job outputs
+// @Description List every file the job uploaded (HLS/DASH segments, playlists, thumbnails) with its URL. Available once the job has completed, for the same period as the job status.
+// @Tags jobs
+// @Produce json
+// @Param id path string true "Job ID"
+// @Success 200 {object} JobOutputsResponse
+// @Failure 404 {object} ErrorResponse "Job not found"
+// @Failure 409 {object} ErrorResponse "Job has not completed"
+// @Security bearerAuth
+// @Router /jobs/{id}/outputs [get]
+func (h *Handler) GetJobOutputs(c *gin.Context) {
+	jobID := c.Param("id")
+
+	progress, exists := h.lastKnownProgress(jobID)
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "job not found"})
+		return
+	}
+	if progress.Status != workerv1.JobStatus_JOB_STATUS_COMPLETED {
+		c.JSON(http.StatusConflict, gin.H{"error": "job not completed"})
+		return
+	}
+
+	files := outputFilesFromProto(progress.OutputFiles)
+	if files == nil {
+		files = []JobOutputFile{}
+	}
+	c.JSON(http.StatusOK, JobOutputsResponse{
+		JobID:     jobID,
+		OutputURL: progress.OutputUrl,
+		Files:     files,
+	})
+}
+
+// cancelJobTimeout は Worker へのキャンセル要求のタイムアウト
+const cancelJobTimeout = 10 * time.Second
+
+// CancelJobResponse はジョブキャンセルのレスポンス
+type CancelJobResponse struct {
+	JobID   string `json:"job_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Message string `json:"message" example:"job cancelled"`
+}
+
+// CancelJob は実行中のジョブをキャンセルする
+// @Summary Cancel job
+// @Description Cancel a running job. The request is forwarded to the Worker the job was dispatched to, which stops ffmpeg. The job then finishes with JOB_STATUS_FAILED.
+// @Tags jobs
+// @Produce json
+// @Param id path string true "Job ID"
+// @Success 200 {object} CancelJobResponse
+// @Failure 404 {object} ErrorResponse "Job not found or not running"
+// @Failure 502 {object} ErrorResponse "Failed to cancel the job on the worker"
+// @Security bearerAuth
+// @Router /jobs/{id} [delete]
+func (h *Handler) CancelJob(c *gin.Context) {
+	jobID := c.Param("id")
+
+	workerAddr, exists := h.jobManager.GetWorker(jobID)
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "job not found or not running"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), cancelJobTimeout)
+	defer cancel()
+
+	// キャンセルによる失敗をデッドレターに記録しないよう、Worker に転送する前に記録する
+	h.jobManager.SetCancelled(jobID, true)
+	resp, err := h.cancelOnWorker(ctx, workerAddr, jobID)
+	if err != nil || !resp.Success {
+		h.jobManager.SetCancelled(jobID, false)
+	}
+	if err != nil {
+		logger.Error("Failed to cancel job on worker",
+			zap.String("job_id", jobID),
+			zap.String("worker", workerAddr),
+			zap.Error(err),
+		)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "failed to cancel job on worker"})
+		return
+	}
+	// Worker 側ですでに終了している場合
+	if !resp.Success {
+		c.JSON(http.StatusNotFound, gin.H{"error": resp.Message})
+		return
+	}
+
+	logger.Info("Cancelled job",
+		zap.String("job_id", jobID),
+		zap.String("worker", workerAddr),
+	)
+
+	c.JSON(http.StatusOK, CancelJobResponse{
+		JobID:   jobID,
+		Message: resp.Message,
+	})
+}
+
+// cancelOnWorker は workerAddr の Worker にジョブのキャンセルを要求する
+func (h *Handler) cancelOnWorker(ctx context.Context, workerAddr, jobID string) (*workerv1.CancelResponse, error) {
+	conn, err := h.balancer.Dial(workerAddr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to worker: %w", err)
+	}
+
+	return workerv1.NewWorkerServiceClient(conn).CancelJob(ctx, &workerv1.CancelRequest{JobId: jobID})
+}
+
+// JobGroupRequest は同じ入力を複数のプリセットでエンコードするジョブグループの作成リクエスト
+type JobGroupRequest struct {
+	InputURL string `json:"input_url" binding:"required" example:"https://example.com/video.mp4"`
+	// Jobs はプリセットごとの出力先（プリセットごとに1つのジョブとして別々の Worker に送信する）
+	Jobs []JobGroupJobRequest `json:"jobs" binding:"required,min=1,max=16,dive"`
+	// OnFailure は1つのジョブが失敗した場合の扱い（"continue" は残りを実行し続ける、"cancel" は残りをキャンセルする）
+	OnFailure string `json:"on_failure,omitempty" binding:"omitempty,oneof=continue cancel" enums:"continue,cancel" example:"continue"`
+	// Speed はエンコーダーの速度プリセット（x264/x265 の -preset 値）。省略時は各プリセットの既定値
+	Speed string `json:"speed,omitempty" example:"veryfast"`
+	// Retry は各ジョブの Worker 選択とアップロードのリトライ設定。省略時は既定値
+	Retry *RetryPolicy `json:"retry,omitempty"`
+}
+
+// JobGroupJobRequest はジョブグループに含めるプリセットと出力先
+type JobGroupJobRequest struct {
+	Preset string       `json:"preset" binding:"required" example:"720p_h264"`
+	Output OutputConfig `json:"output" binding:"required"`
+}
+
+// JobGroupResponse はジョブグループ作成のレスポンス
+type JobGroupResponse struct {
+	GroupID   string        `json:"group_id" example:"7c9e6679-7425-40de-944b-e07fc1f90ae7"`
+	Status    string        `json:"status" example:"accepted"`
+	StreamURL string        `json:"stream_url" example:"/api/v1/job-groups/7c9e6679-7425-40de-944b-e07fc1f90ae7/stream"`
+	Jobs      []JobResponse `json:"jobs"`
+}
+
+// CreateJobGroup は同じ入力を複数のプリセットでエンコードするジョブグループを作成する
+// @Summary Create job group
+// @Description Encode one input with several presets in parallel. Each preset becomes its own job dispatched to an available Worker. If any job cannot be dispatched, the already dispatched jobs are cancelled and the group is rejected.
+// @Tags job-groups
+// @Accept json
+// @Produce json
+// @Param group body JobGroupRequest true "Job group parameters"
+// @Success 202 {object} JobGroupResponse "Job group accepted"
+// @Failure 400 {object} ErrorResponse "Invalid request"
+// @Failure 403 {object} ErrorResponse "A preset is not allowed for the API key"
+// @Failure 422 {object} ErrorResponse "No worker has the ffmpeg filters/encoders required by a preset"
+// @Failure 503 {object} ErrorResponse "No available workers or worker is busy"
+// @Header 503 {string} Retry-After "Seconds to wait before retrying when the worker is busy"
+// @Security bearerAuth
+// @Router /job-groups [post]
+func (h *Handler) CreateJobGroup(c *gin.Context) {
+	var req JobGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	onFailure := req.OnFailure
+	if onFailure == "" {
+		onFailure = JobGroupOnFailureContinue
+	}
+
+	groupID := uuid.New().String()
+	jobReqs := make([]JobRequest, len(req.Jobs))
+	members := make([]JobGroupJob, len(req.Jobs))
+	for i, job := range req.Jobs {
+		jobReqs[i] = JobRequest{
+			InputURL: req.InputURL,
+			Preset:   job.Preset,
+			Output:   job.Output,
+			Speed:    req.Speed,
+			Retry:    req.Retry,
+		}
+		if err := h.validateJobRequest(jobReqs[i]); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("jobs[%d]: %v", i, err)})
+			return
+		}
+		if err := h.checkPresetAllowed(c, jobReqs[i]); err != nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("jobs[%d]: %v", i, err)})
+			return
+		}
+		members[i] = JobGroupJob{JobID: uuid.New().String(), Preset: job.Preset}
+	}
+
+	logger.Info("Creating job group",
+		zap.String("group_id", groupID),
+		zap.String("input_url", req.InputURL),
+		zap.Int("jobs", len(members)),
+		zap.String("on_failure", onFailure),
+	)
+
+	// 進捗を取りこぼさないよう、ジョブを送信する前にグループを登録する
+	h.jobGroups.Create(groupID, onFailure, members)
+
+	jobs := make([]JobResponse, 0, len(members))
+	for i, member := range members {
+		if h.jobGroups.Cancelling(groupID) {
+			// 送信済みのジョブがすでに失敗している場合、残りのジョブは送信しない
+			h.jobGroups.RecordProgress(member.JobID, &workerv1.JobProgress{
+				JobId:   member.JobID,
+				Status:  workerv1.JobStatus_JOB_STATUS_FAILED,
+				Message: "Job cancelled",
+				Error:   "another job in the group failed",
+			})
+			continue
+		}
+		job, err := h.startJob(c.Request.Context(), member.JobID, jobReqs[i])
+		if err != nil {
+			// 一部のプリセットのみ実行されることがないよう、送信済みのジョブをキャンセルしてグループごと拒否する
+			logger.Warn("Failed to dispatch job group",
+				zap.String("group_id", groupID),
+				zap.String("preset", member.Preset),
+				zap.Error(err),
+			)
+			h.jobGroups.Remove(groupID)
+			dispatched := make([]string, 0, len(jobs))
+			for _, job := range jobs {
+				dispatched = append(dispatched, job.JobID)
+			}
+			h.cancelGroupJobs(dispatched)
+			respondStartJobError(c, err)
+			return
+		}
+		jobs = append(jobs, newJobResponse(member.JobID, job))
+		if h.jobGroups.Cancelling(groupID) {
+			// 送信中に他のジョブが失敗した場合は、キャンセル対象に含まれていても Worker が未記録だったため改めてキャンセルする
+			h.cancelGroupJobs([]string{member.JobID})
+		}
+	}
+
+	c.JSON(http.StatusAccepted, JobGroupResponse{
+		GroupID:   groupID,
+		Status:    "accepted",
+		StreamURL: fmt.Sprintf("/api/v1/job-groups/%s/stream", groupID),
+		Jobs:      jobs,
+	})
+}
+
+// GetJobGroup はジョブグループの最新の進捗を取得する
+// @Summary Get job group status
+// @Description Get the aggregated progress of a job group and the status and output URL of each job. The final status is retained for the same period as job statuses.
+// @Tags job-groups
+// @Produce json
+// @Param id path string true "Job group ID"
+// @Success 200 {object} JobGroupProgress
+// @Failure 404 {object} ErrorResponse "Job group not found"
+// @Security bearerAuth
+// @Router /job-groups/{id} [get]
+func (h *Handler) GetJobGroup(c *gin.Context) {
+	progress, exists := h.jobGroups.Get(c.Param("id"))
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "job group not found"})
+		return
+	}
+	c.JSON(http.StatusOK, progress)
+}
+
+// StreamJobGroupProgress はジョブグループの進捗を SSE でストリーミングする
+// @Summary Stream job group progress
+// @Description Stream the aggregated progress of a job group using Server-Sent Events. The stream ends once every job has finished; the last event contains all output URLs.
+// @Tags job-groups
+// @Produce text/event-stream
+// @Param id path string true "Job group ID"
+// @Success 200 {string} string "SSE stream of job group progress"
+// @Failure 404 {object} ErrorResponse "Job group not found"
+// @Security bearerAuth
+// @Router /job-groups/{id}/stream [get]
+func (h *Handler) StreamJobGroupProgress(c *gin.Context) {
+	groupID := c.Param("id")
+
+	logger.Info("Streaming job group progress", zap.String("group_id", groupID))
+
+	// SSE ヘッダー設定
+	c.Writer.Header().Set("Content-Type", "text/event-stream")
+	c.Writer.Header().Set("Cache-Control", "no-cache")
+	c.Writer.Header().Set("Connection", "keep-alive")
+	c.Writer.Header().Set("Transfer-Encoding", "chunked")
+	c.Writer.Header().Set("X-Accel-Buffering", "no") // Nginxのバッファリング無効化
+
+	progressCh, running := h.jobGroups.GetProgressChannel(groupID)
+	if !running {
+		// 終了済みのグループは最新の状態を1件送信して終了する
+		if progress, ok := h.jobGroups.Get(groupID); ok {
+			writeJobGroupEvent(c.Writer, progress)
+			c.Writer.Flush()
+			return
+		}
+
+		logger.Warn("Job group not found", zap.String("group_id", groupID))
+		if _, err := fmt.Fprintf(c.Writer, "data: {\"error\":\"job group not found\"}\n\n"); err != nil {
+			logger.Warn("Failed to write SSE error", zap.Error(err))
+		}
+		c.Writer.Flush()
+		return
+	}
+
+	// 未送信の進捗がない場合（別の接続がすでに受信した場合など）は現在の進捗を最初に送信する
+	if len(progressCh) == 0 {
+		if progress, ok := h.jobGroups.Get(groupID); ok {
+			writeJobGroupEvent(c.Writer, progress)
+			c.Writer.Flush()
+		}
+	}
+
+	for {
+		select {
+		case progress, ok := <-progressCh:
+			if !ok {
+				logger.Info("Job group progress channel closed", zap.String("group_id", groupID))
+				return
+			}
+			if !writeJobGroupEvent(c.Writer, progress) {
+				continue
+			}
+			c.Writer.Flush()
+
+		case <-c.Request.Context().Done():
+			logger.Info("Client disconnected", zap.String("group_id", groupID))
+			return
+		}
+	}
+}
+
+// writeJobGroupEvent はジョブグループの進捗を SSE のイベントとして書き込み、書き込めたかどうかを返す
+func writeJobGroupEvent(w io.Writer, progress JobGroupProgress) bool {
+	jsonData, err := json.Marshal(progress)
+	if err != nil {
+		logger.Error("Failed to marshal job group progress", zap.Error(err))
+		return false
+	}
+
+	if _, err := fmt.Fprintf(w, "data: %s\n\n", jsonData); err != nil {
+		logger.Warn("Failed to write SSE progress", zap.Error(err))
+		return false
+	}
+	return true
+}
+
+// cancelGroupJobs はジョブグループの実行中のジョブを Worker でキャンセルする
+// キャンセルの完了は待たず、失敗してもログに記録するのみ
+func (h *Handler) cancelGroupJobs(jobIDs []string) {
+	for _, jobID := range jobIDs {
+		workerAddr, exists := h.jobManager.GetWorker(jobID)
+		if !exists {
+			continue
+		}
+		h.jobManager.SetCancelled(jobID, true)
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), cancelJobTimeout)
+			defer cancel()
+
+			if _, err := h.cancelOnWorker(ctx, workerAddr, jobID); err != nil {
+				h.jobManager.SetCancelled(jobID, false)
+				logger.Warn("Failed to cancel job in job group",
+					zap.String("job_id", jobID),
+					zap.String("worker", workerAddr),
+					zap.Error(err),
+				)
+				return
+			}
+			logger.Info("Cancelled job in job group", zap.String("job_id", jobID))
+		}()
+	}
+}
+
 // WorkerStatusResponse はWorker状態のレスポンス
 type WorkerStatusResponse struct {
-	Message string `json:"message" example:"not implemented yet"`
+	Address           string `json:"address" example:"worker-1.internal:50051"`
+	WorkerID          string `json:"worker_id,omitempty" example:"worker-1"`
+	Version           string `json:"version,omitempty" example:"0.1.0"`
+	CurrentJobs       int32  `json:"current_jobs" example:"1"`
+	MaxConcurrentJobs int32  `json:"max_concurrent_jobs" example:"2"`
+	QueuedJobs        int32  `json:"queued_jobs" example:"0"`
+	Available         bool   `json:"available" example:"true"`
+	Error             string `json:"error,omitempty" example:""`
+	// Load は Worker のホストの負荷（Worker が報告しない場合は省略）
+	Load *WorkerLoadResponse `json:"load,omitempty"`
+}
+
+// WorkerLoadResponse は Worker のホストの負荷
+type WorkerLoadResponse struct {
+	CPUPercent       float64 `json:"cpu_percent" example:"72.5"`
+	MemoryPercent    float64 `json:"memory_percent" example:"41.3"`
+	MemoryUsedBytes  uint64  `json:"memory_used_bytes" example:"6979321856"`
+	MemoryTotalBytes uint64  `json:"memory_total_bytes" example:"16898535424"`
+	// GPUPercent は GPU（NVENC）の使用率（Worker が GPU の負荷を報告しない場合は省略）
+	GPUPercent *float64  `json:"gpu_percent,omitempty" example:"35"`
+	SampledAt  time.Time `json:"sampled_at" example:"2025-01-01T00:00:00Z"`
+}
+
+// newWorkerLoadResponse は Worker のホストの負荷をレスポンスの形式に変換する（報告がない場合は nil）
+func newWorkerLoadResponse(load *balancer.Load) *WorkerLoadResponse {
+	if load == nil {
+		return nil
+	}
+	resp := &WorkerLoadResponse{
+		CPUPercent:       load.CPUPercent,
+		MemoryPercent:    load.MemoryPercent,
+		MemoryUsedBytes:  load.MemoryUsedBytes,
+		MemoryTotalBytes: load.MemoryTotalBytes,
+		SampledAt:        load.SampledAt,
+	}
+	if load.GPUAvailable {
+		gpuPercent := load.GPUPercent
+		resp.GPUPercent = &gpuPercent
+	}
+	return resp
 }
 
 // GetWorkerStatus はすべての Worker の状態を取得
 // @Summary Get worker status
-// @Description Get status of all registered Workers (not implemented yet)
+// @Description Get status of all registered Workers. Unreachable Workers are reported with available=false.
 // @Tags workers
 // @Produce json
-// @Success 200 {object} WorkerStatusResponse
+// @Success 200 {array} WorkerStatusResponse
 // @Security bearerAuth
 // @Router /workers/status [get]
 func (h *Handler) GetWorkerStatus(c *gin.Context) {
-	// 実装は省略（管理用APIとして将来実装）
-	c.JSON(http.StatusOK, gin.H{"message": "not implemented yet"})
+	infos := h.balancer.StatusAll(c.Request.Context())
+
+	response := make([]WorkerStatusResponse, 0, len(infos))
+	for _, info := range infos {
+		response = append(response, WorkerStatusResponse{
+			Address:           info.Address,
+			WorkerID:          info.WorkerID,
+			Version:           info.Version,
+			CurrentJobs:       info.CurrentJobs,
+			MaxConcurrentJobs: info.MaxConcurrentJobs,
+			QueuedJobs:        info.QueuedJobs,
+			Available:         info.Available,
+			Error:             info.Error,
+			Load:              newWorkerLoadResponse(info.Load),
+		})
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// PresetResponse はプリセット一覧のレスポンス
+type PresetResponse struct {
+	Name        string `json:"name" example:"720p_h264"`
+	Description string `json:"description" example:"HD 720p with H.264 encoding"`
+	Extension   string `json:"extension" example:"mp4"`
+	// OutputType は出力タイプ（"single"、"hls"、"dash"）
+	OutputType string `json:"output_type" enums:"single,hls,dash" example:"single"`
+}
+
+// ListPresets は利用可能なプリセットの一覧を返す
+// @Summary List presets
+// @Description List the built-in encoding presets, sorted by name. Custom presets loaded only on Workers (PRESETS_FILE) are not included. API keys restricted by API_KEY_PRESETS only see the presets allowed for them.
+// @Tags presets
+// @Produce json
+// @Success 200 {array} PresetResponse
+// @Security bearerAuth
+// @Router /presets [get]
+func (h *Handler) ListPresets(c *gin.Context) {
+	presets := preset.List()
+	keyName := c.GetString(auth.APIKeyNameContextKey)
+
+	response := make([]PresetResponse, 0, len(presets))
+	for _, p := range presets {
+		if !h.presetAllowlist.Allows(keyName, p.Name) {
+			continue
+		}
+		outputType := p.OutputType
+		if outputType == "" {
+			outputType = "single"
+		}
+		response = append(response, PresetResponse{
+			Name:        p.Name,
+			Description: p.Description,
+			Extension:   p.Extension,
+			OutputType:  outputType,
+		})
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// uploadOffsetHeader は再開可能アップロードのオフセットを示すヘッダー（tus 互換）
+const uploadOffsetHeader = "Upload-Offset"
+
+// chunkContentType はチャンク送信時に要求する Content-Type（tus 互換）
+const chunkContentType = "application/offset+octet-stream"
+
+// CreateInputRequest は入力アップロード開始のリクエスト
+type CreateInputRequest struct {
+	Size        int64  `json:"size" binding:"required" example:"10485760"`
+	ContentType string `json:"content_type" binding:"required" example:"video/mp4"`
+	Filename    string `json:"filename" example:"video.mp4"`
+}
+
+// InputResponse は入力アップロードの状態
+type InputResponse struct {
+	ID          string `json:"id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Filename    string `json:"filename,omitempty" example:"video.mp4"`
+	ContentType string `json:"content_type" example:"video/mp4"`
+	Size        int64  `json:"size" example:"10485760"`
+	Offset      int64  `json:"offset" example:"0"`
+	Completed   bool   `json:"completed" example:"false"`
+	UploadURL   string `json:"upload_url" example:"/api/v1/inputs/550e8400-e29b-41d4-a716-446655440000"`
+	InputURL    string `json:"input_url,omitempty" example:"http://localhost:8080/api/v1/inputs/550e8400-e29b-41d4-a716-446655440000/content"`
+}
+
+func (h *Handler) inputResponse(upload InputUpload) InputResponse {
+	resp := InputResponse{
+		ID:          upload.ID,
+		Filename:    upload.Filename,
+		ContentType: upload.ContentType,
+		Size:        upload.Size,
+		Offset:      upload.Offset,
+		Completed:   upload.Completed(),
+		UploadURL:   fmt.Sprintf("/api/v1/inputs/%s", upload.ID),
+	}
+	if resp.Completed {
+		resp.InputURL = h.inputStore.InputURL(upload.ID)
+	}
+	return resp
+}
+
+// inputStoreAvailable は入力アップロードが有効か確認し、無効ならエラーレスポンスを返す
+func (h *Handler) inputStoreAvailable(c *gin.Context) bool {
+	if h.inputStore == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "input upload is not configured"})
+		return false
+	}
+	return true
+}
+
+// CreateInput は入力ファイルのアップロードを開始する
+// @Summary Create input upload
+// @Description Start a resumable upload of a source video. Send the data with PATCH /inputs/{id} and use input_url from the completed upload as a job's input_url. Uploads not updated for INPUT_TTL are deleted, and uploads are rejected with 507 while the declared sizes of stored uploads exceed MAX_INPUT_TOTAL_SIZE_MB.
+// @Tags inputs
+// @Accept json
+// @Produce json
+// @Param input body CreateInputRequest true "Upload parameters"
+// @Success 201 {object} InputResponse "Upload created"
+// @Failure 400 {object} ErrorResponse "Invalid request"
+// @Failure 413 {object} ErrorResponse "Input too large"
+// @Failure 415 {object} ErrorResponse "Unsupported content type"
+// @Failure 507 {object} ErrorResponse "Input storage quota exceeded"
+// @Security bearerAuth
+// @Router /inputs [post]
+func (h *Handler) CreateInput(c *gin.Context) {
+	if !h.inputStoreAvailable(c) {
+		return
+	}
+
+	var req CreateInputRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	upload, err := h.inputStore.Create(req.Size, req.ContentType, req.Filename)
+	if err != nil {
+		c.JSON(inputErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	logger.Info("Input upload created",
+		zap.String("input_id", upload.ID),
+		zap.Int64("size", upload.Size),
+		zap.String("content_type", upload.ContentType),
+	)
+
+	c.Header(uploadOffsetHeader, "0")
+	c.JSON(http.StatusCreated, h.inputResponse(upload))
+}
+
+// GetInput は入力アップロードの状態を取得する
+// @Summary Get input upload status
+// @Description Get the current offset of an upload. Clients resume an interrupted upload from this offset.
+// @Tags inputs
+// @Produce json
+// @Param id path string true "Input ID"
+// @Success 200 {object} InputResponse
+// @Failure 404 {object} ErrorResponse "Input not found"
+// @Security bearerAuth
+// @Router /inputs/{id} [get]
+func (h *Handler) GetInput(c *gin.Context) {
+	if !h.inputStoreAvailable(c) {
+		return
+	}
+
+	upload, ok := h.inputStore.Get(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": ErrInputNotFound.Error()})
+		return
+	}
+
+	c.Header(uploadOffsetHeader, strconv.FormatInt(upload.Offset, 10))
+	c.JSON(http.StatusOK, h.inputResponse(upload))
+}
+
+// UploadInputChunk は入力ファイルのチャンクを書き込む
+// @Summary Upload input chunk
+// @Description Append a chunk at the offset given by the Upload-Offset header. The offset must match the current offset of the upload.
+// @Tags inputs
+// @Accept application/offset+octet-stream
+// @Produce json
+// @Param id path string true "Input ID"
+// @Param Upload-Offset header int true "Byte offset of this chunk"
+// @Success 200 {object} InputResponse
+// @Failure 400 {object} ErrorResponse "Invalid request"
+// @Failure 404 {object} ErrorResponse "Input not found"
+// @Failure 409 {object} ErrorResponse "Offset mismatch or concurrent upload"
+// @Failure 413 {object} ErrorResponse "Chunk exceeds declared size"
+// @Failure 415 {object} ErrorResponse "Unsupported content type"
+// @Security bearerAuth
+// @Router /inputs/{id} [patch]
+func (h *Handler) UploadInputChunk(c *gin.Context) {
+	if !h.inputStoreAvailable(c) {
+		return
+	}
+
+	id := c.Param("id")
+
+	if c.ContentType() != chunkContentType {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": fmt.Sprintf("content type must be %s", chunkContentType)})
+		return
+	}
+
+	offset, err := strconv.ParseInt(c.GetHeader(uploadOffsetHeader), 10, 64)
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid Upload-Offset header"})
+		return
+	}
+
+	upload, err := h.inputStore.Append(id, offset, c.Request.Body)
+	if err != nil {
+		logger.Warn("Failed to append input chunk",
+			zap.String("input_id", id),
+			zap.Int64("offset", offset),
+			zap.Error(err),
+		)
+		if upload.ID != "" {
+			c.Header(uploadOffsetHeader, strconv.FormatInt(upload.Offset, 10))
+		}
+		c.JSON(inputErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header(uploadOffsetHeader, strconv.FormatInt(upload.Offset, 10))
+	c.JSON(http.StatusOK, h.inputResponse(upload))
+}
+
+// DownloadInput はアップロード済みの入力ファイルを返す（Worker の ffmpeg が取得する）
+// @Summary Download uploaded input
+// @Description Serve a completed upload. This URL is returned as input_url and does not require authentication.
+// @Tags inputs
+// @Produce octet-stream
+// @Param id path string true "Input ID"
+// @Success 200 {file} file
+// @Failure 404 {object} ErrorResponse "Input not found"
+// @Failure 409 {object} ErrorResponse "Upload not completed"
+// @Router /inputs/{id}/content [get]
+func (h *Handler) DownloadInput(c *gin.Context) {
+	if !h.inputStoreAvailable(c) {
+		return
+	}
+
+	upload, ok := h.inputStore.Get(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": ErrInputNotFound.Error()})
+		return
+	}
+	if !upload.Completed() {
+		c.JSON(http.StatusConflict, gin.H{"error": "upload not completed"})
+		return
+	}
+
+	c.Header("Content-Type", upload.ContentType)
+	c.File(h.inputStore.Path(upload.ID))
+}
+
+// inputErrorStatus は InputStore のエラーを HTTP ステータスに変換する
+func inputErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrInputInvalidSize):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrInputNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrInputOffsetMismatch), errors.Is(err, ErrInputBusy):
+		return http.StatusConflict
+	case errors.Is(err, ErrInputTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, ErrInputUnsupportedType):
+		return http.StatusUnsupportedMediaType
+	case errors.Is(err, ErrInputQuotaExceeded):
+		return http.StatusInsufficientStorage
+	default:
+		return http.StatusInternalServerError
+	}
 }
@@ -0,0 +1,1843 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/nzws/flux-encoder/internal/controlplane/balancer"
+	"github.com/nzws/flux-encoder/internal/worker/preset"
+	"github.com/nzws/flux-encoder/internal/worker/uploader"
+	workerv1 "github.com/nzws/flux-encoder/proto/worker/v1"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+func TestCreateJobで不正なSpeedは400が返る(t *testing.T) {
+	w := postJob(t, NewHandler(nil), `{"input_url":"https://example.com/video.mp4","preset":"720p_h264","output":{"storage":"local","path":"out.mp4"},"speed":"turbo"}`)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("ステータスコードが一致しない: 期待値 %d, 取得値 %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestCreateJobで不正なStreamCopyは400が返る(t *testing.T) {
+	w := postJob(t, NewHandler(nil), `{"input_url":"https://example.com/video.mp4","preset":"720p_h264","output":{"storage":"local","path":"out.mp4"},"stream_copy":"subtitle"}`)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("ステータスコードが一致しない: 期待値 %d, 取得値 %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+// postJob は CreateJob にリクエストを送信してレスポンスを返す
+func postJob(t *testing.T, handler *Handler, body string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/api/v1/jobs", handler.CreateJob)
+	return postJobTo(router, body)
+}
+
+// postJobTo は router の POST /api/v1/jobs にリクエストを送信してレスポンスを返す
+func postJobTo(router *gin.Engine, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/jobs", bytes.NewReader([]byte(body)))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestCreateJobで上限を超える解像度のプリセットは400が返る(t *testing.T) {
+	handler := NewHandler(nil)
+	handler.SetMaxOutputHeight(720)
+
+	w := postJob(t, handler, `{"input_url":"https://example.com/video.mp4","preset":"1080p_h264","output":{"storage":"local","path":"out.mp4"}}`)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("ステータスコードが一致しない: 期待値 %d, 取得値 %d", http.StatusBadRequest, w.Code)
+	}
+	if !strings.Contains(w.Body.String(), "1080p_h264") {
+		t.Errorf("エラーメッセージにプリセット名が含まれていない: %s", w.Body.String())
+	}
+}
+
+func TestCreateJobで上限以内の解像度のプリセットは受け付けられる(t *testing.T) {
+	// 接続できない Worker のみを設定し、解像度チェックを通過して Worker 選択に進むことを確認する
+	handler := NewHandler(balancer.New([]string{"127.0.0.1:1"}, 100*time.Millisecond))
+	handler.SetMaxOutputHeight(720)
+
+	for _, name := range []string{"720p_h264", "480p_h264", "hls_720p_abr", "custom_worker_preset"} {
+		w := postJob(t, handler, `{"input_url":"https://example.com/video.mp4","preset":"`+name+`","output":{"storage":"local","path":"out.mp4"}}`)
+
+		if w.Code != http.StatusServiceUnavailable {
+			t.Errorf("プリセット %s のステータスコードが一致しない: 期待値 %d, 取得値 %d", name, http.StatusServiceUnavailable, w.Code)
+		}
+	}
+}
+
+func TestCreateJobで縦長のプリセットは短辺を解像度の上限と比較する(t *testing.T) {
+	handler := NewHandler(balancer.New([]string{"127.0.0.1:1"}, 100*time.Millisecond))
+
+	tests := []struct {
+		maxHeight int
+		preset    string
+		want      int
+	}{
+		// 1080x1920 の短辺は 1080 のため 1080p の上限に収まる（解像度チェックを通過して Worker 選択に進む）
+		{maxHeight: 1080, preset: "vertical_1080x1920_h264", want: http.StatusServiceUnavailable},
+		{maxHeight: 720, preset: "vertical_720x1280_h264", want: http.StatusServiceUnavailable},
+		{maxHeight: 720, preset: "vertical_1080x1920_h264", want: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		handler.SetMaxOutputHeight(tt.maxHeight)
+		w := postJob(t, handler, `{"input_url":"https://example.com/video.mp4","preset":"`+tt.preset+`","output":{"storage":"local","path":"out.mp4"}}`)
+		if w.Code != tt.want {
+			t.Errorf("上限 %dp のプリセット %s のステータスコードが一致しない: 期待値 %d, 取得値 %d (%s)", tt.maxHeight, tt.preset, tt.want, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestCreateJobで生のffmpeg引数の出力解像度も上限と比較する(t *testing.T) {
+	handler := NewHandler(balancer.New([]string{"127.0.0.1:1"}, 100*time.Millisecond))
+	handler.SetMaxOutputHeight(1080)
+
+	tests := []struct {
+		name string
+		args string
+		want int
+	}{
+		{name: "上限以内のscale", args: `["-vf","scale=-2:720","-c:v","libx264"]`, want: http.StatusServiceUnavailable},
+		{name: "上限を超える-s", args: `["-s","3840x2160","-c:v","libx264"]`, want: http.StatusBadRequest},
+		// 大きさを指定しない場合は入力と同じ解像度になるため、上限がある場合は拒否する
+		{name: "大きさの指定なし", args: `["-c:v","libx264","-crf","20"]`, want: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		w := postJob(t, handler, `{"input_url":"https://example.com/video.mp4","raw_ffmpeg_args":`+tt.args+`,"output":{"storage":"local","path":"out.mp4"}}`)
+		if w.Code != tt.want {
+			t.Errorf("%s のステータスコードが一致しない: 期待値 %d, 取得値 %d (%s)", tt.name, tt.want, w.Code, w.Body.String())
+		}
+	}
+
+	// 上限が 0 の場合は大きさの指定がなくても受け付ける
+	handler.SetMaxOutputHeight(0)
+	w := postJob(t, handler, `{"input_url":"https://example.com/video.mp4","raw_ffmpeg_args":["-c:v","libx264"],"output":{"storage":"local","path":"out.mp4"}}`)
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("ステータスコードが一致しない: 期待値 %d, 取得値 %d (%s)", http.StatusServiceUnavailable, w.Code, w.Body.String())
+	}
+}
+
+func TestCreateJobで解像度の上限が0の場合は制限しない(t *testing.T) {
+	handler := NewHandler(balancer.New([]string{"127.0.0.1:1"}, 100*time.Millisecond))
+	handler.SetMaxOutputHeight(0)
+
+	w := postJob(t, handler, `{"input_url":"https://example.com/video.mp4","preset":"1080p_h264","output":{"storage":"local","path":"out.mp4"}}`)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("ステータスコードが一致しない: 期待値 %d, 取得値 %d", http.StatusServiceUnavailable, w.Code)
+	}
+}
+
+func Testジョブの優先度がWorkerに渡され省略時はnormalになる(t *testing.T) {
+	tests := map[string]string{
+		"":       "normal",
+		"high":   "high",
+		"normal": "normal",
+		"low":    "low",
+	}
+	for priority, want := range tests {
+		req := JobRequest{InputURL: "https://example.com/video.mp4", Preset: "720p_h264", Priority: priority}
+		if got := req.toProto("job-1", req.Preset).Priority; got != want {
+			t.Errorf("%q の優先度が一致しない: 期待値 %q, 取得値 %q", priority, want, got)
+		}
+	}
+}
+
+func TestCreateJobで不正な優先度は400が返る(t *testing.T) {
+	handler := NewHandler(nil)
+
+	w := postJob(t, handler, `{"input_url":"https://example.com/video.mp4","preset":"720p_h264","output":{"storage":"local","path":"out.mp4"},"priority":"urgent"}`)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("ステータスコードが一致しない: 期待値 %d, 取得値 %d (%s)", http.StatusBadRequest, w.Code, w.Body.String())
+	}
+}
+
+func TestCreateJobで上限を超えるメタデータは400が返る(t *testing.T) {
+	handler := NewHandler(nil)
+	handler.SetMetadataLimits(uploader.MetadataLimits{MaxEntries: 2, MaxBytes: 64})
+
+	tests := map[string]string{
+		"件数の上限":      `{"a":"1","b":"2","c":"3"}`,
+		"合計サイズの上限":   `{"description":"` + strings.Repeat("x", 64) + `"}`,
+		"変換後に重複するキー": `{"Owner":"a","owner":"b"}`,
+	}
+	for name, metadata := range tests {
+		w := postJob(t, handler, `{"input_url":"https://example.com/video.mp4","preset":"720p_h264","output":{"storage":"local","path":"out.mp4","metadata":`+metadata+`}}`)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: ステータスコードが一致しない: 期待値 %d, 取得値 %d", name, http.StatusBadRequest, w.Code)
+		}
+		if !strings.Contains(w.Body.String(), "invalid output metadata") {
+			t.Errorf("%s: エラーメッセージが一致しない: %s", name, w.Body.String())
+		}
+	}
+
+	// 上限以内のメタデータは Worker 選択に進む
+	handler = NewHandler(balancer.New([]string{"127.0.0.1:1"}, 100*time.Millisecond))
+	w := postJob(t, handler, `{"input_url":"https://example.com/video.mp4","preset":"720p_h264","output":{"storage":"local","path":"out.mp4","metadata":{"Content ID":"abc"}}}`)
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("ステータスコードが一致しない: 期待値 %d, 取得値 %d (%s)", http.StatusServiceUnavailable, w.Code, w.Body.String())
+	}
+}
+
+func TestGetJobが最新のステータスを返す(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	handler := NewHandler(nil)
+	router := gin.New()
+	router.GET("/api/v1/jobs/:id", handler.GetJob)
+
+	jobID := "job-123"
+	handler.jobManager.CreateProgressChannel(jobID)
+	handler.jobManager.RecordProgress(jobID, &workerv1.JobProgress{
+		JobId:     jobID,
+		Status:    workerv1.JobStatus_JOB_STATUS_COMPLETED,
+		Progress:  100,
+		Message:   "Job completed",
+		OutputUrl: "https://example.com/out.mp4",
+	})
+	handler.jobManager.CloseProgressChannel(jobID)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/jobs/"+jobID, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("ステータスコードが一致しない: 期待値 %d, 取得値 %d", http.StatusOK, w.Code)
+	}
+
+	var resp JobStatusResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("レスポンスのパースに失敗: %v", err)
+	}
+	if resp.JobID != jobID || resp.Status != "JOB_STATUS_COMPLETED" || resp.Progress != 100 || resp.OutputURL != "https://example.com/out.mp4" {
+		t.Errorf("レスポンスが一致しない: %+v", resp)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/jobs/unknown", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("存在しないジョブのステータスコードが一致しない: 期待値 %d, 取得値 %d", http.StatusNotFound, w.Code)
+	}
+}
+
+// failingWorker は常にジョブを失敗させるモック Worker
+type failingWorker struct {
+	workerv1.UnimplementedWorkerServiceServer
+
+	mutex    sync.Mutex
+	requests []*workerv1.JobRequest
+}
+
+func (w *failingWorker) GetStatus(ctx context.Context, req *workerv1.StatusRequest) (*workerv1.WorkerStatus, error) {
+	return &workerv1.WorkerStatus{CurrentJobs: 0, MaxConcurrentJobs: 1, WorkerId: "failing-worker"}, nil
+}
+
+func (w *failingWorker) SubmitJob(req *workerv1.JobRequest, stream grpc.ServerStreamingServer[workerv1.JobProgress]) error {
+	w.mutex.Lock()
+	w.requests = append(w.requests, req)
+	w.mutex.Unlock()
+
+	return stream.Send(&workerv1.JobProgress{
+		JobId:   req.JobId,
+		Status:  workerv1.JobStatus_JOB_STATUS_FAILED,
+		Message: "Encoding failed",
+		Error:   "ffmpeg exited with status 1",
+	})
+}
+
+func (w *failingWorker) submitted() []*workerv1.JobRequest {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	return append([]*workerv1.JobRequest(nil), w.requests...)
+}
+
+// startMockWorker はモック Worker の gRPC サーバーを起動し、接続先アドレスを返す
+func startMockWorker(t *testing.T, worker workerv1.WorkerServiceServer) string {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("リスナーの作成に失敗: %v", err)
+	}
+	server := grpc.NewServer()
+	workerv1.RegisterWorkerServiceServer(server, worker)
+	go func() {
+		if err := server.Serve(lis); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+			t.Logf("mock worker server stopped unexpectedly: %v", err)
+		}
+	}()
+	t.Cleanup(server.Stop)
+
+	return lis.Addr().String()
+}
+
+// newDeadLetterTestRouter は失敗するモック Worker に接続した Handler とルーターを作成する
+func newDeadLetterTestRouter(t *testing.T) (*Handler, *gin.Engine, *failingWorker) {
+	t.Helper()
+
+	worker := &failingWorker{}
+	addr := startMockWorker(t, worker)
+
+	gin.SetMode(gin.TestMode)
+	handler := NewHandler(balancer.New([]string{addr}, time.Second))
+	router := gin.New()
+	router.POST("/api/v1/jobs", handler.CreateJob)
+	router.GET("/api/v1/jobs/failed", handler.ListFailedJobs)
+	router.POST("/api/v1/jobs/failed/:id/replay", handler.ReplayFailedJob)
+	router.GET("/api/v1/jobs/:id", handler.GetJob)
+
+	return handler, router, worker
+}
+
+// waitDeadLetter は指定したジョブがデッドレターに記録されるまで待つ
+func waitDeadLetter(t *testing.T, store DeadLetterStore, jobID string) DeadLetter {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if entry, err := store.Get(jobID); err == nil {
+			return entry
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("ジョブ %s がデッドレターに記録されなかった", jobID)
+	return DeadLetter{}
+}
+
+func Test失敗したジョブがデッドレターに記録され一覧で取得できる(t *testing.T) {
+	handler, router, _ := newDeadLetterTestRouter(t)
+
+	w := postJobTo(router, `{"input_url":"https://example.com/video.mp4?X-Amz-Signature=secret","preset":"720p_h264","output":{"storage":"local","path":"out.mp4"}}`)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("ステータスコードが一致しない: 期待値 %d, 取得値 %d", http.StatusAccepted, w.Code)
+	}
+	var created JobResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatalf("レスポンスのパースに失敗: %v", err)
+	}
+
+	entry := waitDeadLetter(t, handler.deadLetters, created.JobID)
+	if entry.Request.InputURL != "https://example.com/video.mp4?X-Amz-Signature=secret" || entry.Request.Preset != "720p_h264" {
+		t.Errorf("デッドレターのリクエストが一致しない: %+v", entry.Request)
+	}
+	if entry.Error != "ffmpeg exited with status 1" {
+		t.Errorf("デッドレターのエラーが一致しない: %s", entry.Error)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/jobs/failed", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("ステータスコードが一致しない: 期待値 %d, 取得値 %d", http.StatusOK, w.Code)
+	}
+	var list []DeadLetter
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+		t.Fatalf("レスポンスのパースに失敗: %v", err)
+	}
+	if len(list) != 1 || list[0].JobID != created.JobID {
+		t.Fatalf("デッドレター一覧が一致しない: %+v", list)
+	}
+	// 一覧では署名付き URL の署名を返さない
+	if list[0].Request.InputURL != "https://example.com/video.mp4?REDACTED" {
+		t.Errorf("一覧の input_url が秘匿されていない: %s", list[0].Request.InputURL)
+	}
+}
+
+func Test再投入時に現在の設定で検証できないジョブは400が返る(t *testing.T) {
+	handler, router, worker := newDeadLetterTestRouter(t)
+	handler.SetMaxOutputHeight(480)
+	if err := handler.deadLetters.Add(DeadLetter{
+		JobID:    "failed-job",
+		Request:  JobRequest{InputURL: "https://example.com/video.mp4", Preset: "720p_h264", Output: OutputConfig{Storage: "local", Path: "out.mp4"}},
+		FailedAt: time.Now(),
+	}); err != nil {
+		t.Fatalf("デッドレターの追加に失敗: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/jobs/failed/failed-job/replay", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("ステータスコードが一致しない: 期待値 %d, 取得値 %d (%s)", http.StatusBadRequest, w.Code, w.Body.String())
+	}
+	if len(worker.submitted()) != 0 {
+		t.Error("検証できないジョブが Worker に送信された")
+	}
+	if _, err := handler.deadLetters.Get("failed-job"); err != nil {
+		t.Errorf("再投入できなかったデッドレターが削除された: %v", err)
+	}
+}
+
+func Test失敗したジョブを再投入すると新しいジョブが作成される(t *testing.T) {
+	handler, router, worker := newDeadLetterTestRouter(t)
+
+	w := postJobTo(router, `{"input_url":"https://example.com/video.mp4","preset":"720p_h264","output":{"storage":"local","path":"out.mp4"},"speed":"fast"}`)
+	var created JobResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatalf("レスポンスのパースに失敗: %v", err)
+	}
+	waitDeadLetter(t, handler.deadLetters, created.JobID)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/jobs/failed/"+created.JobID+"/replay", nil))
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("ステータスコードが一致しない: 期待値 %d, 取得値 %d", http.StatusAccepted, w.Code)
+	}
+	var replayed JobResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &replayed); err != nil {
+		t.Fatalf("レスポンスのパースに失敗: %v", err)
+	}
+	if replayed.JobID == "" || replayed.JobID == created.JobID {
+		t.Errorf("再投入で新しいジョブIDが発行されていない: %s", replayed.JobID)
+	}
+
+	if _, err := handler.deadLetters.Get(created.JobID); !errors.Is(err, ErrDeadLetterNotFound) {
+		t.Error("再投入したデッドレターが削除されていない")
+	}
+
+	// 再投入したジョブも失敗するため、新しいジョブIDで記録される
+	waitDeadLetter(t, handler.deadLetters, replayed.JobID)
+
+	requests := worker.submitted()
+	if len(requests) != 2 {
+		t.Fatalf("Worker に送信されたジョブ数が一致しない: 期待値 2, 取得値 %d", len(requests))
+	}
+	if requests[1].JobId != replayed.JobID || requests[1].InputUrl != requests[0].InputUrl ||
+		requests[1].Preset != requests[0].Preset || requests[1].Speed != "fast" {
+		t.Errorf("再投入したジョブの内容が一致しない: 元 %+v, 再投入 %+v", requests[0], requests[1])
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/jobs/failed/"+created.JobID+"/replay", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("削除済みのデッドレターのステータスコードが一致しない: 期待値 %d, 取得値 %d", http.StatusNotFound, w.Code)
+	}
+}
+
+// busyWorker は常に容量超過でジョブを拒否するモック Worker
+type busyWorker struct {
+	workerv1.UnimplementedWorkerServiceServer
+
+	retryAfter time.Duration
+}
+
+func (w *busyWorker) GetStatus(ctx context.Context, req *workerv1.StatusRequest) (*workerv1.WorkerStatus, error) {
+	return &workerv1.WorkerStatus{CurrentJobs: 0, MaxConcurrentJobs: 1, WorkerId: "busy-worker"}, nil
+}
+
+func (w *busyWorker) SubmitJob(req *workerv1.JobRequest, stream grpc.ServerStreamingServer[workerv1.JobProgress]) error {
+	st := status.New(codes.ResourceExhausted, "worker is at capacity")
+	if w.retryAfter > 0 {
+		detailed, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(w.retryAfter)})
+		if err != nil {
+			return err
+		}
+		st = detailed
+	}
+	return st.Err()
+}
+
+func TestCreateJobでWorkerが容量超過の場合はRetryAfterが返る(t *testing.T) {
+	addr := startMockWorker(t, &busyWorker{retryAfter: 1500 * time.Millisecond})
+	handler := NewHandler(balancer.New([]string{addr}, time.Second))
+
+	w := postJob(t, handler, `{"input_url":"https://example.com/video.mp4","preset":"720p_h264","output":{"storage":"local","path":"out.mp4"}}`)
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("ステータスコードが一致しない: 期待値 %d, 取得値 %d", http.StatusServiceUnavailable, w.Code)
+	}
+	// 1.5 秒は切り上げて 2 秒として通知される
+	if got := w.Header().Get("Retry-After"); got != "2" {
+		t.Errorf("Retry-After が一致しない: 期待値 %s, 取得値 %s", "2", got)
+	}
+	if !strings.Contains(w.Body.String(), "worker is busy") {
+		t.Errorf("エラーメッセージが一致しない: %s", w.Body.String())
+	}
+}
+
+func TestCreateJobでRetryInfoがない容量超過はRetryAfterを返さない(t *testing.T) {
+	addr := startMockWorker(t, &busyWorker{})
+	handler := NewHandler(balancer.New([]string{addr}, time.Second))
+
+	w := postJob(t, handler, `{"input_url":"https://example.com/video.mp4","preset":"720p_h264","output":{"storage":"local","path":"out.mp4"}}`)
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("ステータスコードが一致しない: 期待値 %d, 取得値 %d", http.StatusServiceUnavailable, w.Code)
+	}
+	if got := w.Header().Get("Retry-After"); got != "" {
+		t.Errorf("Retry-After が設定されている: %s", got)
+	}
+}
+
+func TestCreateJobで不正なOverridesは400が返る(t *testing.T) {
+	bodies := []string{
+		`{"input_url":"https://example.com/video.mp4","preset":"720p_h264","output":{"storage":"local","path":"out.mp4"},"overrides":{"vf":"scale=1:1"}}`,
+		`{"input_url":"https://example.com/video.mp4","preset":"720p_h264","output":{"storage":"local","path":"out.mp4"},"overrides":{"crf":"abc"}}`,
+		`{"input_url":"https://example.com/video.mp4","preset":"720p_h264","output":{"storage":"local","path":"out.mp4"},"overrides":{"b:v":"3M"}}`,
+		`{"input_url":"https://example.com/video.mp4","preset":"720p_h264","output":{"storage":"local","path":"out.mp4"},"speed":"fast","overrides":{"preset":"slow"}}`,
+	}
+
+	for _, body := range bodies {
+		w := postJob(t, NewHandler(nil), body)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("ステータスコードが一致しない: 期待値 %d, 取得値 %d (%s)", http.StatusBadRequest, w.Code, body)
+		}
+	}
+}
+
+func TestCreateJobでOverridesがWorkerに渡される(t *testing.T) {
+	handler, router, worker := newDeadLetterTestRouter(t)
+
+	w := postJobTo(router, `{"input_url":"https://example.com/video.mp4","preset":"720p_h264","output":{"storage":"local","path":"out.mp4"},"overrides":{"crf":"20","b:v":"3000k"}}`)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("ステータスコードが一致しない: 期待値 %d, 取得値 %d", http.StatusAccepted, w.Code)
+	}
+	var created JobResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatalf("レスポンスのパースに失敗: %v", err)
+	}
+	waitDeadLetter(t, handler.deadLetters, created.JobID)
+
+	submitted := worker.submitted()
+	if len(submitted) != 1 {
+		t.Fatalf("送信されたジョブ数が一致しない: 期待値 1, 取得値 %d", len(submitted))
+	}
+	overrides := submitted[0].GetOverrides()
+	if overrides["crf"] != "20" || overrides["b:v"] != "3000k" || len(overrides) != 2 {
+		t.Errorf("Worker に渡された overrides が一致しない: %v", overrides)
+	}
+}
+
+func TestCreateJobで不正な切り出し範囲は400が返る(t *testing.T) {
+	bodies := []string{
+		`{"input_url":"https://example.com/video.mp4","preset":"720p_h264","output":{"storage":"local","path":"out.mp4"},"start_time":"-10"}`,
+		`{"input_url":"https://example.com/video.mp4","preset":"720p_h264","output":{"storage":"local","path":"out.mp4"},"duration":"-5"}`,
+		`{"input_url":"https://example.com/video.mp4","preset":"720p_h264","output":{"storage":"local","path":"out.mp4"},"duration":"0"}`,
+		`{"input_url":"https://example.com/video.mp4","preset":"720p_h264","output":{"storage":"local","path":"out.mp4"},"start_time":"1:2:3:4"}`,
+	}
+
+	for _, body := range bodies {
+		w := postJob(t, NewHandler(nil), body)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("ステータスコードが一致しない: 期待値 %d, 取得値 %d (%s)", http.StatusBadRequest, w.Code, body)
+		}
+	}
+}
+
+func TestCreateJobで切り出し範囲がWorkerに渡される(t *testing.T) {
+	handler, router, worker := newDeadLetterTestRouter(t)
+
+	w := postJobTo(router, `{"input_url":"https://example.com/video.mp4","preset":"720p_h264","output":{"storage":"local","path":"out.mp4"},"start_time":"00:01:30","duration":"60"}`)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("ステータスコードが一致しない: 期待値 %d, 取得値 %d", http.StatusAccepted, w.Code)
+	}
+	var created JobResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatalf("レスポンスのパースに失敗: %v", err)
+	}
+	waitDeadLetter(t, handler.deadLetters, created.JobID)
+
+	submitted := worker.submitted()
+	if len(submitted) != 1 {
+		t.Fatalf("送信されたジョブ数が一致しない: 期待値 1, 取得値 %d", len(submitted))
+	}
+	if submitted[0].GetStartTime() != "00:01:30" || submitted[0].GetDuration() != "60" {
+		t.Errorf("Worker に渡された切り出し範囲が一致しない: start_time=%s, duration=%s", submitted[0].GetStartTime(), submitted[0].GetDuration())
+	}
+}
+
+func TestCreateJobのレスポンスに送信先のWorkerIDが含まれる(t *testing.T) {
+	handler, router, _ := newDeadLetterTestRouter(t)
+
+	w := postJobTo(router, `{"input_url":"https://example.com/video.mp4","preset":"720p_h264","output":{"storage":"local","path":"out.mp4"}}`)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("ステータスコードが一致しない: 期待値 %d, 取得値 %d", http.StatusAccepted, w.Code)
+	}
+	var created JobResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatalf("レスポンスのパースに失敗: %v", err)
+	}
+	if created.WorkerID != "failing-worker" {
+		t.Errorf("worker_id が一致しない: 期待値 %s, 取得値 %s", "failing-worker", created.WorkerID)
+	}
+
+	// 再投入したジョブのレスポンスにも含まれる
+	waitDeadLetter(t, handler.deadLetters, created.JobID)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/jobs/failed/"+created.JobID+"/replay", nil))
+	var replayed JobResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &replayed); err != nil {
+		t.Fatalf("レスポンスのパースに失敗: %v", err)
+	}
+	if replayed.WorkerID != "failing-worker" {
+		t.Errorf("再投入時の worker_id が一致しない: 期待値 %s, 取得値 %s", "failing-worker", replayed.WorkerID)
+	}
+}
+
+// anonymousWorker は識別子を返さないモック Worker
+type anonymousWorker struct {
+	failingWorker
+}
+
+func (w *anonymousWorker) GetStatus(ctx context.Context, req *workerv1.StatusRequest) (*workerv1.WorkerStatus, error) {
+	return &workerv1.WorkerStatus{CurrentJobs: 0, MaxConcurrentJobs: 1}, nil
+}
+
+func TestCreateJobでWorkerIDがない場合はアドレスが返る(t *testing.T) {
+	addr := startMockWorker(t, &anonymousWorker{})
+	handler := NewHandler(balancer.New([]string{addr}, time.Second))
+
+	w := postJob(t, handler, `{"input_url":"https://example.com/video.mp4","preset":"720p_h264","output":{"storage":"local","path":"out.mp4"}}`)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("ステータスコードが一致しない: 期待値 %d, 取得値 %d", http.StatusAccepted, w.Code)
+	}
+	var created JobResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatalf("レスポンスのパースに失敗: %v", err)
+	}
+	if created.WorkerID != addr {
+		t.Errorf("worker_id が一致しない: 期待値 %s, 取得値 %s", addr, created.WorkerID)
+	}
+}
+
+// queuedWorker は実行枠が満杯でジョブをキューの 2 番目で待たせるモック Worker
+type queuedWorker struct {
+	completingWorker
+}
+
+func (w *queuedWorker) SubmitJob(req *workerv1.JobRequest, stream grpc.ServerStreamingServer[workerv1.JobProgress]) error {
+	if err := stream.Send(&workerv1.JobProgress{
+		JobId:         req.JobId,
+		Status:        workerv1.JobStatus_JOB_STATUS_QUEUED,
+		Message:       "Waiting for a free slot",
+		QueuePosition: 2,
+	}); err != nil {
+		return err
+	}
+	return stream.Send(&workerv1.JobProgress{JobId: req.JobId, Status: workerv1.JobStatus_JOB_STATUS_COMPLETED, Progress: 100})
+}
+
+func TestCreateJobでWorkerのキューで待つ場合はキュー内の順番が返る(t *testing.T) {
+	body := `{"input_url":"https://example.com/video.mp4","preset":"720p_h264","output":{"storage":"local","path":"out.mp4"}}`
+	tests := []struct {
+		name   string
+		worker workerv1.WorkerServiceServer
+		want   int
+	}{
+		{name: "キューで待つ", worker: &queuedWorker{}, want: 2},
+		{name: "すぐに開始する", worker: &completingWorker{}, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewHandler(balancer.New([]string{startMockWorker(t, tt.worker)}, time.Second))
+
+			w := postJob(t, handler, body)
+			if w.Code != http.StatusAccepted {
+				t.Fatalf("ステータスコードが一致しない: 期待値 %d, 取得値 %d", http.StatusAccepted, w.Code)
+			}
+			var created JobResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+				t.Fatalf("レスポンスのパースに失敗: %v", err)
+			}
+			if created.QueuePosition != tt.want {
+				t.Errorf("queue_position が一致しない: 期待値 %d, 取得値 %d", tt.want, created.QueuePosition)
+			}
+			if tt.want == 0 && strings.Contains(w.Body.String(), "queue_position") {
+				t.Errorf("キューで待たないジョブに queue_position が含まれている: %s", w.Body.String())
+			}
+		})
+	}
+}
+
+func TestCreateJobで不正なSegmentLayoutは400が返る(t *testing.T) {
+	w := postJob(t, NewHandler(nil), `{"input_url":"https://example.com/video.mp4","preset":"hls_720p","output":{"storage":"local","path":"out"},"segment_layout":"nested"}`)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("ステータスコードが一致しない: 期待値 %d, 取得値 %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestCreateJobで出力の外を指すHLSの配置先は400が返る(t *testing.T) {
+	for _, field := range []string{`"hls_init_path":"../init.mp4"`, `"hls_key_path":"/keys/video.key"`} {
+		w := postJob(t, NewHandler(nil), `{"input_url":"https://example.com/video.mp4","preset":"hls_720p","output":{"storage":"local","path":"out"},`+field+`}`)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s のステータスコードが一致しない: 期待値 %d, 取得値 %d", field, http.StatusBadRequest, w.Code)
+		}
+	}
+}
+
+func TestCreateJobでABRプリセットへの字幕の焼き込みは400が返る(t *testing.T) {
+	w := postJob(t, NewHandler(nil), `{"input_url":"https://example.com/video.mp4","preset":"hls_720p_abr_video_only","output":{"storage":"local","path":"out"},"subtitle_path":"https://example.com/subs/ja.vtt"}`)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("ステータスコードが一致しない: 期待値 %d, 取得値 %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+// cancellableWorker はキャンセルされるまでジョブを実行し続けるモック Worker
+type cancellableWorker struct {
+	workerv1.UnimplementedWorkerServiceServer
+
+	mutex   sync.Mutex
+	running map[string]chan struct{}
+}
+
+func newCancellableWorker() *cancellableWorker {
+	return &cancellableWorker{running: make(map[string]chan struct{})}
+}
+
+func (w *cancellableWorker) GetStatus(ctx context.Context, req *workerv1.StatusRequest) (*workerv1.WorkerStatus, error) {
+	return &workerv1.WorkerStatus{CurrentJobs: 0, MaxConcurrentJobs: 1, WorkerId: "cancellable-worker"}, nil
+}
+
+func (w *cancellableWorker) SubmitJob(req *workerv1.JobRequest, stream grpc.ServerStreamingServer[workerv1.JobProgress]) error {
+	cancelled := make(chan struct{})
+	w.mutex.Lock()
+	w.running[req.JobId] = cancelled
+	w.mutex.Unlock()
+
+	if err := stream.Send(&workerv1.JobProgress{JobId: req.JobId, Status: workerv1.JobStatus_JOB_STATUS_QUEUED}); err != nil {
+		return err
+	}
+
+	select {
+	case <-cancelled:
+		return stream.Send(&workerv1.JobProgress{
+			JobId:   req.JobId,
+			Status:  workerv1.JobStatus_JOB_STATUS_FAILED,
+			Message: "Encoding failed",
+			Error:   "context canceled",
+		})
+	case <-stream.Context().Done():
+		return stream.Context().Err()
+	}
+}
+
+func (w *cancellableWorker) CancelJob(ctx context.Context, req *workerv1.CancelRequest) (*workerv1.CancelResponse, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	cancelled, exists := w.running[req.JobId]
+	if !exists {
+		return &workerv1.CancelResponse{Success: false, Message: "job not found: " + req.JobId}, nil
+	}
+	close(cancelled)
+	delete(w.running, req.JobId)
+	return &workerv1.CancelResponse{Success: true, Message: "job cancelled"}, nil
+}
+
+// newCancelTestRouter はキャンセル可能なモック Worker に接続した Handler とルーターを作成する
+func newCancelTestRouter(t *testing.T) (*Handler, *gin.Engine, string) {
+	t.Helper()
+
+	addr := startMockWorker(t, newCancellableWorker())
+
+	gin.SetMode(gin.TestMode)
+	handler := NewHandler(balancer.New([]string{addr}, time.Second))
+	router := gin.New()
+	router.POST("/api/v1/jobs", handler.CreateJob)
+	router.GET("/api/v1/jobs/:id", handler.GetJob)
+	router.DELETE("/api/v1/jobs/:id", handler.CancelJob)
+
+	return handler, router, addr
+}
+
+// deleteJob は DELETE /api/v1/jobs/:id にリクエストを送信してレスポンスを返す
+func deleteJob(router *gin.Engine, jobID string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/api/v1/jobs/"+jobID, nil))
+	return w
+}
+
+func TestCancelJobで実行中のジョブがWorkerでキャンセルされる(t *testing.T) {
+	handler, router, _ := newCancelTestRouter(t)
+
+	w := postJobTo(router, `{"input_url":"https://example.com/video.mp4","preset":"720p_h264","output":{"storage":"local","path":"out.mp4"}}`)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("ステータスコードが一致しない: 期待値 %d, 取得値 %d", http.StatusAccepted, w.Code)
+	}
+	var created JobResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatalf("レスポンスのパースに失敗: %v", err)
+	}
+
+	w = deleteJob(router, created.JobID)
+	if w.Code != http.StatusOK {
+		t.Fatalf("ステータスコードが一致しない: 期待値 %d, 取得値 %d (%s)", http.StatusOK, w.Code, w.Body.String())
+	}
+	var resp CancelJobResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("レスポンスのパースに失敗: %v", err)
+	}
+	if resp.JobID != created.JobID {
+		t.Errorf("ジョブIDが一致しない: 期待値 %s, 取得値 %s", created.JobID, resp.JobID)
+	}
+
+	// Worker からの終了通知でジョブが終了し、再度のキャンセルは 404 になる
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		progress, ok := handler.jobManager.GetLastProgress(created.JobID)
+		_, running := handler.jobManager.GetWorker(created.JobID)
+		if ok && progress.Status == workerv1.JobStatus_JOB_STATUS_FAILED && !running {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("ジョブがキャンセルで終了しなかった: %+v", progress)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if w := deleteJob(router, created.JobID); w.Code != http.StatusNotFound {
+		t.Errorf("終了したジョブのステータスコードが一致しない: 期待値 %d, 取得値 %d", http.StatusNotFound, w.Code)
+	}
+
+	// キャンセルによる失敗はデッドレターに記録しない
+	if _, err := handler.deadLetters.Get(created.JobID); !errors.Is(err, ErrDeadLetterNotFound) {
+		t.Errorf("キャンセルしたジョブがデッドレターに記録された: %v", err)
+	}
+}
+
+func TestCancelJobで存在しないジョブは404が返る(t *testing.T) {
+	_, router, _ := newCancelTestRouter(t)
+
+	if w := deleteJob(router, "unknown"); w.Code != http.StatusNotFound {
+		t.Errorf("ステータスコードが一致しない: 期待値 %d, 取得値 %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestCancelJobでWorker側にジョブがない場合は404が返る(t *testing.T) {
+	handler, router, addr := newCancelTestRouter(t)
+	handler.jobManager.SetWorker("job-finished-on-worker", addr)
+
+	if w := deleteJob(router, "job-finished-on-worker"); w.Code != http.StatusNotFound {
+		t.Errorf("ステータスコードが一致しない: 期待値 %d, 取得値 %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestCancelJobでWorkerへの要求が失敗した場合は502が返る(t *testing.T) {
+	handler, router, _ := newCancelTestRouter(t)
+	handler.jobManager.SetWorker("job-on-lost-worker", "127.0.0.1:1")
+
+	if w := deleteJob(router, "job-on-lost-worker"); w.Code != http.StatusBadGateway {
+		t.Errorf("ステータスコードが一致しない: 期待値 %d, 取得値 %d", http.StatusBadGateway, w.Code)
+	}
+}
+
+func TestListPresetsがプリセットを名前順で返す(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/api/v1/presets", NewHandler(nil).ListPresets)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/presets", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("ステータスコードが一致しない: 期待値 %d, 取得値 %d", http.StatusOK, w.Code)
+	}
+
+	var presets []PresetResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &presets); err != nil {
+		t.Fatalf("レスポンスのパースに失敗: %v", err)
+	}
+	if len(presets) != len(preset.List()) {
+		t.Fatalf("プリセット数が一致しない: 期待値 %d, 取得値 %d", len(preset.List()), len(presets))
+	}
+	for i := 1; i < len(presets); i++ {
+		if presets[i-1].Name >= presets[i].Name {
+			t.Errorf("プリセットが名前順でない: %s, %s", presets[i-1].Name, presets[i].Name)
+		}
+	}
+
+	byName := make(map[string]PresetResponse)
+	for _, p := range presets {
+		byName[p.Name] = p
+	}
+	if p := byName["720p_h264"]; p.Extension != "mp4" || p.OutputType != "single" || p.Description == "" {
+		t.Errorf("720p_h264 の内容が一致しない: %+v", p)
+	}
+	if p := byName["hls_720p"]; p.OutputType != "hls" {
+		t.Errorf("hls_720p の出力タイプが一致しない: %+v", p)
+	}
+}
+
+func TestCreateJobで範囲外のRetryは400が返る(t *testing.T) {
+	w := postJob(t, NewHandler(nil), `{"input_url":"https://example.com/video.mp4","preset":"720p_h264","output":{"storage":"local","path":"out.mp4"},"retry":{"max_attempts":100}}`)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("ステータスコードが一致しない: 期待値 %d, 取得値 %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestCreateJobでRetryがWorkerに渡される(t *testing.T) {
+	handler, router, worker := newDeadLetterTestRouter(t)
+
+	w := postJobTo(router, `{"input_url":"https://example.com/video.mp4","preset":"720p_h264","output":{"storage":"local","path":"out.mp4"},"retry":{"max_attempts":5,"initial_wait_ms":500,"max_wait_ms":60000}}`)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("ステータスコードが一致しない: 期待値 %d, 取得値 %d", http.StatusAccepted, w.Code)
+	}
+	var created JobResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatalf("レスポンスのパースに失敗: %v", err)
+	}
+	waitDeadLetter(t, handler.deadLetters, created.JobID)
+
+	submitted := worker.submitted()
+	if len(submitted) != 1 {
+		t.Fatalf("送信されたジョブ数が一致しない: 期待値 1, 取得値 %d", len(submitted))
+	}
+	policy := submitted[0].GetRetry()
+	if policy.GetMaxAttempts() != 5 || policy.GetInitialWaitMs() != 500 || policy.GetMaxWaitMs() != 60000 {
+		t.Errorf("Worker に渡された retry が一致しない: %+v", policy)
+	}
+}
+
+func TestCreateJobで不正な検証の設定は400が返る(t *testing.T) {
+	bodies := []string{
+		`{"input_url":"https://example.com/video.mp4","preset":"720p_h264","output":{"storage":"local","path":"out.mp4"},"validation":{"level":"paranoid"}}`,
+		`{"input_url":"https://example.com/video.mp4","preset":"720p_h264","output":{"storage":"local","path":"out.mp4"},"validation":{"hls_validation_depth":"deep"}}`,
+		`{"input_url":"https://example.com/video.mp4","preset":"720p_h264","output":{"storage":"local","path":"out.mp4"},"validation":{"timeout_seconds":-1}}`,
+	}
+
+	for _, body := range bodies {
+		w := postJob(t, NewHandler(nil), body)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("ステータスコードが一致しない: 期待値 %d, 取得値 %d (%s)", http.StatusBadRequest, w.Code, body)
+		}
+	}
+}
+
+func TestCreateJobで検証の設定がWorkerに渡される(t *testing.T) {
+	handler, router, worker := newDeadLetterTestRouter(t)
+
+	w := postJobTo(router, `{"input_url":"https://example.com/video.mp4","preset":"720p_h264","output":{"storage":"local","path":"out.mp4"},"validation":{"level":"strict","timeout_seconds":60,"skip_decode_test":false}}`)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("ステータスコードが一致しない: 期待値 %d, 取得値 %d", http.StatusAccepted, w.Code)
+	}
+	var created JobResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatalf("レスポンスのパースに失敗: %v", err)
+	}
+	waitDeadLetter(t, handler.deadLetters, created.JobID)
+
+	submitted := worker.submitted()
+	if len(submitted) != 1 {
+		t.Fatalf("送信されたジョブ数が一致しない: 期待値 1, 取得値 %d", len(submitted))
+	}
+	config := submitted[0].GetValidation()
+	if config.GetLevel() != "strict" || config.GetTimeoutSeconds() != 60 {
+		t.Errorf("Worker に渡された validation が一致しない: %+v", config)
+	}
+	// false を明示した場合も Worker の既定値と区別して渡す
+	if config.SkipDecodeTest == nil || config.GetSkipDecodeTest() {
+		t.Errorf("Worker に渡された skip_decode_test が一致しない: %v", config.SkipDecodeTest)
+	}
+}
+
+// warmingUpWorker は最初の busyChecks 回の状態取得では空きがないと応答するモック Worker
+type warmingUpWorker struct {
+	failingWorker
+
+	busyChecks int32
+	checks     atomic.Int32
+}
+
+func (w *warmingUpWorker) GetStatus(ctx context.Context, req *workerv1.StatusRequest) (*workerv1.WorkerStatus, error) {
+	if w.checks.Add(1) <= w.busyChecks {
+		return &workerv1.WorkerStatus{CurrentJobs: 1, MaxConcurrentJobs: 1, WorkerId: "warming-up-worker"}, nil
+	}
+	return &workerv1.WorkerStatus{CurrentJobs: 0, MaxConcurrentJobs: 1, WorkerId: "warming-up-worker"}, nil
+}
+
+func TestCreateJobでRetryを指定するとWorker選択がリトライされる(t *testing.T) {
+	body := `{"input_url":"https://example.com/video.mp4","preset":"720p_h264","output":{"storage":"local","path":"out.mp4"}%s}`
+
+	// 既定ではリトライせずに 503 が返る
+	worker := &warmingUpWorker{busyChecks: 1}
+	handler := NewHandler(balancer.New([]string{startMockWorker(t, worker)}, time.Second))
+	if w := postJob(t, handler, fmt.Sprintf(body, "")); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("リトライなしのステータスコードが一致しない: 期待値 %d, 取得値 %d", http.StatusServiceUnavailable, w.Code)
+	}
+
+	// 試行回数を指定すると空きができるまでリトライして受け付けられる
+	worker = &warmingUpWorker{busyChecks: 2}
+	handler = NewHandler(balancer.New([]string{startMockWorker(t, worker)}, time.Second))
+	w := postJob(t, handler, fmt.Sprintf(body, `,"retry":{"max_attempts":3,"initial_wait_ms":1,"max_wait_ms":10}`))
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("リトライありのステータスコードが一致しない: 期待値 %d, 取得値 %d", http.StatusAccepted, w.Code)
+	}
+	if got := worker.checks.Load(); got != 3 {
+		t.Errorf("状態取得の回数が一致しない: 期待値 %d, 取得値 %d", 3, got)
+	}
+}
+
+func TestMaxActiveDispatchesを超えるジョブは空きができるまで待機する(t *testing.T) {
+	handler, router, _ := newCancelTestRouter(t)
+	handler.SetMaxActiveDispatches(1)
+	body := `{"input_url":"https://example.com/video.mp4","preset":"720p_h264","output":{"storage":"local","path":"out.mp4"}}`
+
+	w := postJobTo(router, body)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("ステータスコードが一致しない: 期待値 %d, 取得値 %d", http.StatusAccepted, w.Code)
+	}
+	var first JobResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &first); err != nil {
+		t.Fatalf("レスポンスのパースに失敗: %v", err)
+	}
+
+	// 1件目がディスパッチ中の間、2件目は待機する
+	done := make(chan *httptest.ResponseRecorder, 1)
+	go func() {
+		done <- postJobTo(router, body)
+	}()
+	select {
+	case w := <-done:
+		t.Fatalf("上限を超えたジョブが待機せずに返った: %d", w.Code)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	// 1件目が終了すると枠が空き、2件目がディスパッチされる
+	if w := deleteJob(router, first.JobID); w.Code != http.StatusOK {
+		t.Fatalf("キャンセルのステータスコードが一致しない: 期待値 %d, 取得値 %d", http.StatusOK, w.Code)
+	}
+	select {
+	case w := <-done:
+		if w.Code != http.StatusAccepted {
+			t.Errorf("2件目のステータスコードが一致しない: 期待値 %d, 取得値 %d", http.StatusAccepted, w.Code)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("枠が空いても2件目がディスパッチされない")
+	}
+}
+
+// heartbeatWorker は ffmpeg の出力が止まった間のハートビートを進捗チャネルの容量を超えて送信してから完了するモック Worker
+type heartbeatWorker struct {
+	completingWorker
+}
+
+func (w *heartbeatWorker) SubmitJob(req *workerv1.JobRequest, stream grpc.ServerStreamingServer[workerv1.JobProgress]) error {
+	if err := stream.Send(&workerv1.JobProgress{JobId: req.JobId, Status: workerv1.JobStatus_JOB_STATUS_QUEUED}); err != nil {
+		return err
+	}
+	for i := 0; i < progressChannelSize*3; i++ {
+		if err := stream.Send(&workerv1.JobProgress{
+			JobId:    req.JobId,
+			Status:   workerv1.JobStatus_JOB_STATUS_PROCESSING,
+			Progress: 45,
+			Message:  fmt.Sprintf("Encoding: 45.0%% (no output from ffmpeg for %ds)", (i+1)*10),
+		}); err != nil {
+			return err
+		}
+	}
+	return stream.Send(&workerv1.JobProgress{JobId: req.JobId, Status: workerv1.JobStatus_JOB_STATUS_COMPLETED, Progress: 100})
+}
+
+func TestSSEのクライアントがいなくてもハートビートでディスパッチの枠が解放されなくならない(t *testing.T) {
+	handler := NewHandler(balancer.New([]string{startMockWorker(t, &heartbeatWorker{})}, time.Second))
+	handler.SetMaxActiveDispatches(1)
+	body := `{"input_url":"https://example.com/video.mp4","preset":"720p_h264","output":{"storage":"local","path":"out.mp4"}}`
+
+	// 進捗ストリーム（SSE）を開かずに、ハートビートが続いたジョブの完了を待つ
+	w := postJob(t, handler, body)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("ステータスコードが一致しない: 期待値 %d, 取得値 %d", http.StatusAccepted, w.Code)
+	}
+	var created JobResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatalf("レスポンスのパースに失敗: %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if progress, ok := handler.lastKnownProgress(created.JobID); ok && progress.Status == workerv1.JobStatus_JOB_STATUS_COMPLETED {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("ハートビートの後にジョブが完了しない")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// 完了したジョブの枠が解放され、次のジョブを待たずにディスパッチできる
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	release, err := handler.acquireDispatchSlot(ctx)
+	if err != nil {
+		t.Fatalf("ディスパッチの枠が解放されない: %v", err)
+	}
+	release()
+}
+
+func Testディスパッチの枠を待機中にキャンセルされるとエラーになる(t *testing.T) {
+	handler := NewHandler(nil)
+	handler.SetMaxActiveDispatches(1)
+
+	release, err := handler.acquireDispatchSlot(context.Background())
+	if err != nil {
+		t.Fatalf("枠の確保に失敗: %v", err)
+	}
+	defer release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := handler.acquireDispatchSlot(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("エラーが一致しない: 期待値 %v, 取得値 %v", context.DeadlineExceeded, err)
+	}
+}
+
+func Test再起動後もJobStoreから最終ステータスを取得できる(t *testing.T) {
+	store, err := NewFileJobStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("FileJobStore の作成に失敗: %v", err)
+	}
+
+	// 失敗するジョブを実行し、最終ステータスが保存されるまで待つ
+	handler, router, _ := newDeadLetterTestRouter(t)
+	handler.SetJobStore(store)
+	w := postJobTo(router, `{"input_url":"https://example.com/video.mp4","preset":"720p_h264","output":{"storage":"local","path":"out.mp4"}}`)
+	var created JobResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatalf("レスポンスのパースに失敗: %v", err)
+	}
+	waitDeadLetter(t, handler.deadLetters, created.JobID)
+
+	// 同じ保存先を使う新しい Handler（再起動後の Control Plane）から取得する
+	restarted := NewHandler(nil)
+	restarted.SetJobStore(store)
+	gin.SetMode(gin.TestMode)
+	restartedRouter := gin.New()
+	restartedRouter.GET("/api/v1/jobs/:id", restarted.GetJob)
+	restartedRouter.GET("/api/v1/jobs/:id/stream", restarted.StreamJobProgress)
+
+	w = httptest.NewRecorder()
+	restartedRouter.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/jobs/"+created.JobID, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("ステータスコードが一致しない: 期待値 %d, 取得値 %d", http.StatusOK, w.Code)
+	}
+	var resp JobStatusResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("レスポンスのパースに失敗: %v", err)
+	}
+	if resp.Status != "JOB_STATUS_FAILED" || resp.Error != "ffmpeg exited with status 1" {
+		t.Errorf("レスポンスが一致しない: %+v", resp)
+	}
+
+	// SSE は最終ステータスを1件送信して終了する
+	w = httptest.NewRecorder()
+	restartedRouter.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/jobs/"+created.JobID+"/stream", nil))
+	if body := w.Body.String(); !strings.Contains(body, `"status":"JOB_STATUS_FAILED"`) || strings.Contains(body, "job not found") {
+		t.Errorf("SSE の内容が一致しない: %s", body)
+	}
+}
+
+func Test実行中に再起動したジョブは中断として返される(t *testing.T) {
+	store, err := NewFileJobStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("FileJobStore の作成に失敗: %v", err)
+	}
+	jobID := uuid.New().String()
+	if err := store.Save(jobID, newJobState(jobID, &workerv1.JobProgress{
+		Status:   workerv1.JobStatus_JOB_STATUS_PROCESSING,
+		Progress: 40,
+	}, time.Now())); err != nil {
+		t.Fatalf("状態の保存に失敗: %v", err)
+	}
+
+	handler := NewHandler(nil)
+	handler.SetJobStore(store)
+	progress, ok := handler.lastKnownProgress(jobID)
+	if !ok {
+		t.Fatal("保存した状態が取得できない")
+	}
+	if progress.Status != workerv1.JobStatus_JOB_STATUS_FAILED || progress.Error == "" {
+		t.Errorf("中断したジョブが失敗として返されない: %+v", progress)
+	}
+}
+
+// countingJobStore は保存した状態を記録する JobStore
+type countingJobStore struct {
+	NopJobStore
+
+	mutex  sync.Mutex
+	states []JobState
+}
+
+func (s *countingJobStore) Save(jobID string, state JobState) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.states = append(s.states, state)
+	return nil
+}
+
+func Test進捗率の更新ではJobStoreに保存しない(t *testing.T) {
+	handler := NewHandler(nil)
+	store := &countingJobStore{}
+	handler.SetJobStore(store)
+
+	jobID := "job-123"
+	for _, progress := range []*workerv1.JobProgress{
+		{Status: workerv1.JobStatus_JOB_STATUS_QUEUED},
+		{Status: workerv1.JobStatus_JOB_STATUS_PROCESSING, Progress: 10},
+		{Status: workerv1.JobStatus_JOB_STATUS_PROCESSING, Progress: 50},
+		{Status: workerv1.JobStatus_JOB_STATUS_UPLOADING, Progress: 100},
+		{Status: workerv1.JobStatus_JOB_STATUS_COMPLETED, Progress: 100},
+	} {
+		handler.recordProgress(jobID, progress)
+	}
+
+	if len(store.states) != 4 {
+		t.Fatalf("保存回数が一致しない: 期待値 4, 取得値 %d", len(store.states))
+	}
+	if last := store.states[3]; last.Status != "JOB_STATUS_COMPLETED" || last.JobID != jobID {
+		t.Errorf("最後に保存した状態が一致しない: %+v", last)
+	}
+}
+
+// flakyStreamWorker は進捗ストリームの途中で一時的なエラーを返し、AttachJob で再接続するとジョブを完了させるモック Worker
+type flakyStreamWorker struct {
+	workerv1.UnimplementedWorkerServiceServer
+
+	// streamErr は SubmitJob の途中で返すエラー
+	streamErr error
+	// attachFailures は AttachJob が Unavailable を返す回数（再接続のリトライを確認する）
+	attachFailures int32
+	attaches       int32
+}
+
+func (w *flakyStreamWorker) GetStatus(ctx context.Context, req *workerv1.StatusRequest) (*workerv1.WorkerStatus, error) {
+	return &workerv1.WorkerStatus{CurrentJobs: 0, MaxConcurrentJobs: 1, WorkerId: "flaky-worker"}, nil
+}
+
+func (w *flakyStreamWorker) SubmitJob(req *workerv1.JobRequest, stream grpc.ServerStreamingServer[workerv1.JobProgress]) error {
+	for _, st := range []workerv1.JobStatus{workerv1.JobStatus_JOB_STATUS_QUEUED, workerv1.JobStatus_JOB_STATUS_PROCESSING} {
+		if err := stream.Send(&workerv1.JobProgress{JobId: req.JobId, Status: st}); err != nil {
+			return err
+		}
+	}
+	return w.streamErr
+}
+
+func (w *flakyStreamWorker) AttachJob(req *workerv1.AttachRequest, stream grpc.ServerStreamingServer[workerv1.JobProgress]) error {
+	if atomic.AddInt32(&w.attaches, 1) <= w.attachFailures {
+		return status.Error(codes.Unavailable, "worker restarting stream")
+	}
+	if err := stream.Send(&workerv1.JobProgress{JobId: req.JobId, Status: workerv1.JobStatus_JOB_STATUS_UPLOADING, Progress: 100}); err != nil {
+		return err
+	}
+	return stream.Send(&workerv1.JobProgress{
+		JobId:     req.JobId,
+		Status:    workerv1.JobStatus_JOB_STATUS_COMPLETED,
+		Progress:  100,
+		OutputUrl: "https://example.com/out.mp4",
+	})
+}
+
+// runFlakyStreamJob は flakyStreamWorker にジョブを送信し、進捗の受信を終えた後の最終の進捗を返す
+func runFlakyStreamJob(t *testing.T, worker *flakyStreamWorker) *workerv1.JobProgress {
+	t.Helper()
+
+	addr := startMockWorker(t, worker)
+	handler := NewHandler(balancer.New([]string{addr}, time.Second))
+	handler.reattachConfig.InitialWait = time.Millisecond
+	handler.reattachConfig.MaxWait = time.Millisecond
+
+	jobID := "reattach-job"
+	_, err := handler.startJob(context.Background(), jobID, JobRequest{
+		InputURL: "https://example.com/input.mp4",
+		Preset:   "720p_h264",
+		Output:   OutputConfig{Storage: "s3", Path: "out.mp4"},
+	})
+	if err != nil {
+		t.Fatalf("ジョブの開始に失敗: %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if _, running := handler.jobManager.GetProgressChannel(jobID); !running {
+			progress, _ := handler.jobManager.GetLastProgress(jobID)
+			return progress
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("ジョブ %s の進捗の受信が終わらない", jobID)
+	return nil
+}
+
+func Test進捗ストリームが一時的に切断されても再接続してジョブが完了する(t *testing.T) {
+	worker := &flakyStreamWorker{
+		streamErr:      status.Error(codes.Unavailable, "connection reset"),
+		attachFailures: 1,
+	}
+
+	progress := runFlakyStreamJob(t, worker)
+	if progress == nil || progress.Status != workerv1.JobStatus_JOB_STATUS_COMPLETED {
+		t.Fatalf("再接続後にジョブが完了しない: %+v", progress)
+	}
+	if progress.OutputUrl != "https://example.com/out.mp4" {
+		t.Errorf("出力 URL が一致しない: %s", progress.OutputUrl)
+	}
+	if got := atomic.LoadInt32(&worker.attaches); got != 2 {
+		t.Errorf("再接続の試行回数が一致しない: 期待値 2, 取得値 %d", got)
+	}
+}
+
+func Test回復できない進捗ストリームのエラーは再接続せずに失敗する(t *testing.T) {
+	worker := &flakyStreamWorker{streamErr: status.Error(codes.Internal, "encoder crashed")}
+
+	progress := runFlakyStreamJob(t, worker)
+	if progress == nil || progress.Status != workerv1.JobStatus_JOB_STATUS_FAILED {
+		t.Fatalf("ジョブが失敗しない: %+v", progress)
+	}
+	if got := atomic.LoadInt32(&worker.attaches); got != 0 {
+		t.Errorf("回復できないエラーで再接続した: %d 回", got)
+	}
+}
+
+// completingWorker はアップロードしたファイルの一覧とともにジョブを完了させるモック Worker
+type completingWorker struct {
+	workerv1.UnimplementedWorkerServiceServer
+}
+
+func (w *completingWorker) GetStatus(ctx context.Context, req *workerv1.StatusRequest) (*workerv1.WorkerStatus, error) {
+	return &workerv1.WorkerStatus{CurrentJobs: 0, MaxConcurrentJobs: 1, WorkerId: "completing-worker"}, nil
+}
+
+func (w *completingWorker) SubmitJob(req *workerv1.JobRequest, stream grpc.ServerStreamingServer[workerv1.JobProgress]) error {
+	if err := stream.Send(&workerv1.JobProgress{JobId: req.JobId, Status: workerv1.JobStatus_JOB_STATUS_QUEUED}); err != nil {
+		return err
+	}
+	return stream.Send(&workerv1.JobProgress{
+		JobId:     req.JobId,
+		Status:    workerv1.JobStatus_JOB_STATUS_COMPLETED,
+		Progress:  100,
+		OutputUrl: "https://cdn.example.com/hls/master.m3u8",
+		OutputFiles: []*workerv1.OutputFile{
+			{Path: "stream_0/segment_000.ts", Url: "https://cdn.example.com/hls/stream_0/segment_000.ts"},
+			{Path: "stream_0/playlist.m3u8", Url: "https://cdn.example.com/hls/stream_0/playlist.m3u8"},
+			{Path: "master.m3u8", Url: "https://cdn.example.com/hls/master.m3u8"},
+		},
+	})
+}
+
+// getJobOutputs は GET /api/v1/jobs/:id/outputs を実行する
+func getJobOutputs(router *gin.Engine, jobID string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/jobs/"+jobID+"/outputs", nil))
+	return w
+}
+
+func TestGetJobOutputsで完了したジョブのアップロードしたファイル一覧が返る(t *testing.T) {
+	addr := startMockWorker(t, &completingWorker{})
+
+	gin.SetMode(gin.TestMode)
+	handler := NewHandler(balancer.New([]string{addr}, time.Second))
+	router := gin.New()
+	router.POST("/api/v1/jobs", handler.CreateJob)
+	router.GET("/api/v1/jobs/:id/outputs", handler.GetJobOutputs)
+
+	w := postJobTo(router, `{"input_url":"https://example.com/in.mp4","preset":"720p_h264","output":{"storage":"s3","path":"hls"}}`)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("ステータスコードが一致しない: 期待値 %d, 取得値 %d, body %s", http.StatusAccepted, w.Code, w.Body.String())
+	}
+	var created JobResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatalf("レスポンスのパースに失敗: %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if w = getJobOutputs(router, created.JobID); w.Code != http.StatusConflict {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if w.Code != http.StatusOK {
+		t.Fatalf("ステータスコードが一致しない: 期待値 %d, 取得値 %d", http.StatusOK, w.Code)
+	}
+
+	var resp JobOutputsResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("レスポンスのパースに失敗: %v", err)
+	}
+	want := []JobOutputFile{
+		{Path: "stream_0/segment_000.ts", URL: "https://cdn.example.com/hls/stream_0/segment_000.ts"},
+		{Path: "stream_0/playlist.m3u8", URL: "https://cdn.example.com/hls/stream_0/playlist.m3u8"},
+		{Path: "master.m3u8", URL: "https://cdn.example.com/hls/master.m3u8"},
+	}
+	if resp.JobID != created.JobID || resp.OutputURL != "https://cdn.example.com/hls/master.m3u8" {
+		t.Errorf("レスポンスが一致しない: %+v", resp)
+	}
+	if !reflect.DeepEqual(resp.Files, want) {
+		t.Errorf("ファイル一覧が一致しない: 期待値 %v, 取得値 %v", want, resp.Files)
+	}
+}
+
+func TestGetJobOutputsで未完了のジョブは409が返る(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	handler := NewHandler(nil)
+	router := gin.New()
+	router.GET("/api/v1/jobs/:id/outputs", handler.GetJobOutputs)
+
+	handler.jobManager.RecordProgress("running", &workerv1.JobProgress{Status: workerv1.JobStatus_JOB_STATUS_PROCESSING})
+	handler.jobManager.RecordProgress("failed", &workerv1.JobProgress{Status: workerv1.JobStatus_JOB_STATUS_FAILED})
+
+	for _, jobID := range []string{"running", "failed"} {
+		if w := getJobOutputs(router, jobID); w.Code != http.StatusConflict {
+			t.Errorf("%s のステータスコードが一致しない: 期待値 %d, 取得値 %d", jobID, http.StatusConflict, w.Code)
+		}
+	}
+	if w := getJobOutputs(router, "unknown"); w.Code != http.StatusNotFound {
+		t.Errorf("存在しないジョブのステータスコードが一致しない: 期待値 %d, 取得値 %d", http.StatusNotFound, w.Code)
+	}
+}
+
+// limitedWorker は指定したフィルター・エンコーダーのみを報告するモック Worker
+type limitedWorker struct {
+	workerv1.UnimplementedWorkerServiceServer
+
+	capabilities *workerv1.WorkerCapabilities
+	submits      atomic.Int32
+}
+
+func (w *limitedWorker) GetStatus(ctx context.Context, req *workerv1.StatusRequest) (*workerv1.WorkerStatus, error) {
+	return &workerv1.WorkerStatus{CurrentJobs: 0, MaxConcurrentJobs: 1, WorkerId: "limited-worker", Capabilities: w.capabilities}, nil
+}
+
+func (w *limitedWorker) SubmitJob(req *workerv1.JobRequest, stream grpc.ServerStreamingServer[workerv1.JobProgress]) error {
+	w.submits.Add(1)
+	return status.Error(codes.Unavailable, "unexpected submit")
+}
+
+func TestCreateJobで必要なエンコーダーを持つWorkerがない場合は422が返る(t *testing.T) {
+	// 720p_h264 は scale フィルターと libx264 / aac エンコーダーを必要とする
+	noX264 := &limitedWorker{capabilities: &workerv1.WorkerCapabilities{
+		Filters:  []string{"scale", "tonemap"},
+		Encoders: []string{"aac", "libx265"},
+	}}
+	noScale := &limitedWorker{capabilities: &workerv1.WorkerCapabilities{
+		Filters:  []string{"tonemap"},
+		Encoders: []string{"aac", "libx264"},
+	}}
+	handler := NewHandler(balancer.New([]string{startMockWorker(t, noX264), startMockWorker(t, noScale)}, time.Second))
+
+	// リトライ設定があっても、対応する Worker がなければ待たずに失敗する
+	start := time.Now()
+	w := postJob(t, handler, `{"input_url":"https://example.com/video.mp4","preset":"720p_h264","output":{"storage":"local","path":"out.mp4"},"retry":{"max_attempts":5,"initial_wait_ms":1000}}`)
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("ステータスコードが一致しない: 期待値 %d, 取得値 %d (%s)", http.StatusUnprocessableEntity, w.Code, w.Body.String())
+	}
+	if elapsed := time.Since(start); elapsed > 900*time.Millisecond {
+		t.Errorf("Worker の選択がリトライされた: %v", elapsed)
+	}
+	for _, missing := range []string{"encoder:libx264", "filter:scale"} {
+		if !strings.Contains(w.Body.String(), missing) {
+			t.Errorf("エラーメッセージに %s が含まれない: %s", missing, w.Body.String())
+		}
+	}
+	if noX264.submits.Load() != 0 || noScale.submits.Load() != 0 {
+		t.Error("対応していない Worker にジョブが送信された")
+	}
+}
+
+// fallbackWorker は指定したフィルター・エンコーダーのみを報告し、受け取ったプリセットを記録するモック Worker
+// release が閉じられるまでジョブを完了しない
+type fallbackWorker struct {
+	workerv1.UnimplementedWorkerServiceServer
+
+	capabilities *workerv1.WorkerCapabilities
+	presets      chan string
+	release      chan struct{}
+}
+
+func (w *fallbackWorker) GetStatus(ctx context.Context, req *workerv1.StatusRequest) (*workerv1.WorkerStatus, error) {
+	return &workerv1.WorkerStatus{CurrentJobs: 0, MaxConcurrentJobs: 1, WorkerId: "fallback-worker", Capabilities: w.capabilities}, nil
+}
+
+func (w *fallbackWorker) SubmitJob(req *workerv1.JobRequest, stream grpc.ServerStreamingServer[workerv1.JobProgress]) error {
+	w.presets <- req.Preset
+	if err := stream.Send(&workerv1.JobProgress{JobId: req.JobId, Status: workerv1.JobStatus_JOB_STATUS_QUEUED, Message: "Job queued"}); err != nil {
+		return err
+	}
+	<-w.release
+	return stream.Send(&workerv1.JobProgress{JobId: req.JobId, Status: workerv1.JobStatus_JOB_STATUS_COMPLETED, Progress: 100})
+}
+
+func TestCreateJobでプリセットに対応するWorkerがない場合はフォールバックのプリセットが使われる(t *testing.T) {
+	// 1080p_av1 は libsvtav1 を必要とするが、Worker は libx264 のみを持つ
+	worker := &fallbackWorker{
+		capabilities: &workerv1.WorkerCapabilities{
+			Filters:  []string{"scale"},
+			Encoders: []string{"aac", "libx264"},
+		},
+		presets: make(chan string, 1),
+		release: make(chan struct{}),
+	}
+	defer close(worker.release)
+	handler := NewHandler(balancer.New([]string{startMockWorker(t, worker)}, time.Second))
+
+	w := postJob(t, handler, `{"input_url":"https://example.com/video.mp4","preset":"1080p_av1","fallback_preset":"1080p_h264","output":{"storage":"local","path":"out.mp4"}}`)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("ステータスコードが一致しない: 期待値 %d, 取得値 %d (%s)", http.StatusAccepted, w.Code, w.Body.String())
+	}
+	var resp JobResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("レスポンスのパースに失敗: %v", err)
+	}
+	if resp.Preset != "1080p_h264" || !resp.FallbackUsed {
+		t.Errorf("フォールバックがレスポンスに反映されていない: %+v", resp)
+	}
+	if got := <-worker.presets; got != "1080p_h264" {
+		t.Errorf("Worker に送信されたプリセットが一致しない: 期待値 %s, 取得値 %s", "1080p_h264", got)
+	}
+
+	// 最初の進捗としてフォールバックの警告が送信される
+	progressCh, ok := handler.jobManager.GetProgressChannel(resp.JobID)
+	if !ok {
+		t.Fatal("進捗チャネルが見つからない")
+	}
+	select {
+	case progress := <-progressCh:
+		for _, want := range []string{"fallback preset 1080p_h264", "encoder:libsvtav1", "1080p_av1"} {
+			if !strings.Contains(progress.Message, want) {
+				t.Errorf("警告のメッセージに %s が含まれない: %s", want, progress.Message)
+			}
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("フォールバックの警告が送信されなかった")
+	}
+}
+
+func TestCreateJobで不正なフォールバックのプリセットは400が返る(t *testing.T) {
+	handler := NewHandler(nil)
+
+	for _, fallback := range []string{"unknown_preset", "720p_h264"} {
+		w := postJob(t, handler, `{"input_url":"https://example.com/video.mp4","preset":"720p_h264","fallback_preset":"`+fallback+`","output":{"storage":"local","path":"out.mp4"}}`)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: ステータスコードが一致しない: 期待値 %d, 取得値 %d", fallback, http.StatusBadRequest, w.Code)
+		}
+		if !strings.Contains(w.Body.String(), "fallback_preset") {
+			t.Errorf("%s: エラーメッセージに fallback_preset が含まれない: %s", fallback, w.Body.String())
+		}
+	}
+}
+
+// rawArgsDenyingWorker は生の ffmpeg 引数のジョブを PermissionDenied で拒否するモック Worker
+type rawArgsDenyingWorker struct {
+	workerv1.UnimplementedWorkerServiceServer
+
+	rawArgs chan []string
+}
+
+func (w *rawArgsDenyingWorker) GetStatus(ctx context.Context, req *workerv1.StatusRequest) (*workerv1.WorkerStatus, error) {
+	return &workerv1.WorkerStatus{CurrentJobs: 0, MaxConcurrentJobs: 1, WorkerId: "raw-args-denying-worker"}, nil
+}
+
+func (w *rawArgsDenyingWorker) SubmitJob(req *workerv1.JobRequest, stream grpc.ServerStreamingServer[workerv1.JobProgress]) error {
+	w.rawArgs <- req.RawFfmpegArgs
+	return status.Error(codes.PermissionDenied, "raw ffmpeg args are not allowed on this worker")
+}
+
+func TestCreateJobで生のffmpeg引数を許可していないWorkerの場合は403が返る(t *testing.T) {
+	worker := &rawArgsDenyingWorker{rawArgs: make(chan []string, 1)}
+	handler := NewHandler(balancer.New([]string{startMockWorker(t, worker)}, time.Second))
+
+	w := postJob(t, handler, `{"input_url":"https://example.com/video.mp4","raw_ffmpeg_args":["-c:v","libx264","-crf","20"],"output":{"storage":"local","path":"out.mkv"}}`)
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("ステータスコードが一致しない: 期待値 %d, 取得値 %d (%s)", http.StatusForbidden, w.Code, w.Body.String())
+	}
+	if got, want := <-worker.rawArgs, []string{"-c:v", "libx264", "-crf", "20"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Worker に送信された引数が一致しない: 期待値 %v, 取得値 %v", want, got)
+	}
+}
+
+func TestCreateJobで生のffmpeg引数とプリセットの併用や不正な引数は400が返る(t *testing.T) {
+	handler := NewHandler(nil)
+
+	for _, body := range []string{
+		`{"input_url":"https://example.com/video.mp4","preset":"720p_h264","raw_ffmpeg_args":["-c:v","libx264"],"output":{"storage":"local","path":"out.mp4"}}`,
+		`{"input_url":"https://example.com/video.mp4","raw_ffmpeg_args":["-c:v","libx264"],"speed":"veryfast","output":{"storage":"local","path":"out.mp4"}}`,
+		`{"input_url":"https://example.com/video.mp4","raw_ffmpeg_args":["-i","/etc/passwd"],"output":{"storage":"local","path":"out.mp4"}}`,
+		`{"input_url":"https://example.com/video.mp4","output":{"storage":"local","path":"out.mp4"}}`,
+	} {
+		if w := postJob(t, handler, body); w.Code != http.StatusBadRequest {
+			t.Errorf("ステータスコードが一致しない: 期待値 %d, 取得値 %d (%s)", http.StatusBadRequest, w.Code, body)
+		}
+	}
+}
+
+// postJobGroup は CreateJobGroup にリクエストを送信してレスポンスをパースする
+func postJobGroup(t *testing.T, handler *Handler, body string) JobGroupResponse {
+	t.Helper()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/api/v1/job-groups", handler.CreateJobGroup)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/job-groups", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("ステータスコードが一致しない: 期待値 %d, 取得値 %d (%s)", http.StatusAccepted, w.Code, w.Body.String())
+	}
+
+	var resp JobGroupResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("レスポンスのパースに失敗: %v", err)
+	}
+	return resp
+}
+
+// waitJobGroup はジョブグループが終了するまで待ち、最終の進捗を返す
+func waitJobGroup(t *testing.T, handler *Handler, groupID string) JobGroupProgress {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if progress, ok := handler.jobGroups.Get(groupID); ok && progress.Status != JobGroupStatusRunning {
+			return progress
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("ジョブグループ %s が終了しなかった", groupID)
+	return JobGroupProgress{}
+}
+
+func TestCreateJobGroupでプリセットごとのジョブが別々のWorkerに送信される(t *testing.T) {
+	failing := &failingWorker{}
+	handler := NewHandler(balancer.New([]string{startMockWorker(t, &completingWorker{}), startMockWorker(t, failing)}, time.Second))
+
+	resp := postJobGroup(t, handler, `{"input_url":"https://example.com/video.mp4","jobs":[{"preset":"360p_h264","output":{"storage":"local","path":"360p.mp4"}},{"preset":"720p_h264","output":{"storage":"local","path":"720p.mp4"}}]}`)
+	if len(resp.Jobs) != 2 || resp.Jobs[0].WorkerID == resp.Jobs[1].WorkerID {
+		t.Fatalf("ジョブが別々の Worker に送信されていない: %+v", resp.Jobs)
+	}
+
+	// 1つのジョブが失敗しても、もう1つのジョブの結果とともに部分的な完了になる
+	progress := waitJobGroup(t, handler, resp.GroupID)
+	if progress.Status != JobGroupStatusPartiallyCompleted || progress.Completed != 1 || progress.Failed != 1 {
+		t.Errorf("グループの進捗が一致しない: %+v", progress)
+	}
+	for _, job := range progress.Jobs {
+		if job.Status == "JOB_STATUS_COMPLETED" && job.OutputURL == "" {
+			t.Errorf("完了したジョブの出力URLがない: %+v", job)
+		}
+	}
+	if len(failing.submitted()) != 1 {
+		t.Errorf("失敗する Worker に送信されたジョブ数が一致しない: 期待値 1, 取得値 %d", len(failing.submitted()))
+	}
+}
+
+func TestCreateJobGroupでcancelの場合は失敗時に残りのジョブがキャンセルされる(t *testing.T) {
+	handler := NewHandler(balancer.New([]string{startMockWorker(t, newCancellableWorker()), startMockWorker(t, &failingWorker{})}, time.Second))
+
+	resp := postJobGroup(t, handler, `{"input_url":"https://example.com/video.mp4","on_failure":"cancel","jobs":[{"preset":"360p_h264","output":{"storage":"local","path":"360p.mp4"}},{"preset":"720p_h264","output":{"storage":"local","path":"720p.mp4"}}]}`)
+
+	// キャンセルされなければ cancellableWorker のジョブは終了しない
+	progress := waitJobGroup(t, handler, resp.GroupID)
+	if progress.Status != JobGroupStatusFailed || progress.Failed != 2 {
+		t.Errorf("グループの進捗が一致しない: %+v", progress)
+	}
+}
+
+// readJobGroupEvents は SSE の本文からジョブグループの進捗イベントを取り出す
+func readJobGroupEvents(t *testing.T, body string) []JobGroupProgress {
+	t.Helper()
+
+	var events []JobGroupProgress
+	for _, line := range strings.Split(body, "\n") {
+		data, ok := strings.CutPrefix(line, "data: ")
+		if !ok {
+			continue
+		}
+		var event JobGroupProgress
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			t.Fatalf("イベントのパースに失敗: %v (%s)", err, data)
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+func TestStreamJobGroupProgressがレンディションごとと全体の進捗を送信する(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	handler := NewHandler(nil)
+	router := gin.New()
+	router.GET("/api/v1/job-groups/:id/stream", handler.StreamJobGroupProgress)
+
+	handler.jobGroups.Create("group-1", JobGroupOnFailureContinue, []JobGroupJob{
+		{JobID: "job-360p", Preset: "360p_h264"},
+		{JobID: "job-1080p", Preset: "1080p_h264"},
+	})
+	handler.jobGroups.RecordProgress("job-360p", &workerv1.JobProgress{Status: workerv1.JobStatus_JOB_STATUS_PROCESSING, Progress: 80, Message: "Encoding"})
+	handler.jobGroups.RecordProgress("job-1080p", &workerv1.JobProgress{Status: workerv1.JobStatus_JOB_STATUS_PROCESSING, Progress: 20, Message: "Encoding"})
+
+	w := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/job-groups/group-1/stream", nil))
+	}()
+
+	// ストリームが受信を始めてから残りの進捗を送る
+	ch, _ := handler.jobGroups.GetProgressChannel("group-1")
+	deadline := time.Now().Add(5 * time.Second)
+	for len(ch) > 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	handler.jobGroups.RecordProgress("job-360p", &workerv1.JobProgress{Status: workerv1.JobStatus_JOB_STATUS_COMPLETED, Progress: 100, OutputUrl: "https://cdn.example.com/360p.mp4"})
+	handler.jobGroups.RecordProgress("job-1080p", &workerv1.JobProgress{Status: workerv1.JobStatus_JOB_STATUS_COMPLETED, Progress: 100, OutputUrl: "https://cdn.example.com/1080p.mp4"})
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("グループの終了後もストリームが終了しない")
+	}
+
+	events := readJobGroupEvents(t, w.Body.String())
+	if len(events) != 4 {
+		t.Fatalf("イベント数が一致しない: 期待値 4, 取得値 %d (%s)", len(events), w.Body.String())
+	}
+
+	second := events[1]
+	if second.Progress != 50 || second.MinProgress != 20 || second.Status != JobGroupStatusRunning {
+		t.Errorf("全体の進捗が一致しない: %+v", second)
+	}
+	if second.Jobs[0].Progress != 80 || second.Jobs[1].Progress != 20 || second.Jobs[1].Preset != "1080p_h264" {
+		t.Errorf("レンディションごとの進捗が一致しない: %+v", second.Jobs)
+	}
+
+	third := events[2]
+	if third.Progress != 60 || third.MinProgress != 20 || third.Completed != 1 {
+		t.Errorf("1件完了時の全体の進捗が一致しない: %+v", third)
+	}
+
+	last := events[3]
+	if last.Status != JobGroupStatusCompleted || last.Progress != 100 || last.MinProgress != 100 {
+		t.Errorf("最終の進捗が一致しない: %+v", last)
+	}
+	if last.Jobs[0].OutputURL != "https://cdn.example.com/360p.mp4" || last.Jobs[1].OutputURL != "https://cdn.example.com/1080p.mp4" {
+		t.Errorf("出力URLが一致しない: %+v", last.Jobs)
+	}
+}
+
+func TestGetWorkerStatusがすべてのWorkerの状態を返し到達できないWorkerは利用不可になる(t *testing.T) {
+	addr := startMockWorker(t, &failingWorker{})
+	b := balancer.New([]string{addr, "127.0.0.1:1"}, time.Second)
+	b.SetStatusFanOut(0, 500*time.Millisecond)
+
+	gin.SetMode(gin.TestMode)
+	handler := NewHandler(b)
+	router := gin.New()
+	router.GET("/api/v1/workers/status", handler.GetWorkerStatus)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/workers/status", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("ステータスコードが一致しない: 期待値 %d, 取得値 %d (%s)", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var statuses []WorkerStatusResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &statuses); err != nil {
+		t.Fatalf("レスポンスの解析に失敗: %v", err)
+	}
+	if len(statuses) != 2 {
+		t.Fatalf("Worker 数が一致しない: 期待値 2, 取得値 %d", len(statuses))
+	}
+
+	reachable := statuses[0]
+	if reachable.Address != addr || !reachable.Available || reachable.WorkerID != "failing-worker" || reachable.MaxConcurrentJobs != 1 {
+		t.Errorf("到達できる Worker の状態が一致しない: %+v", reachable)
+	}
+	unreachable := statuses[1]
+	if unreachable.Address != "127.0.0.1:1" || unreachable.Available || unreachable.Error == "" {
+		t.Errorf("到達できない Worker が利用不可として返されない: %+v", unreachable)
+	}
+}
+
+func Test失敗の進捗のSSEイベントにエラーの分類が含まれる(t *testing.T) {
+	var b strings.Builder
+	ok := writeProgressEvent(&b, &workerv1.JobProgress{
+		JobId:     "job-1",
+		Status:    workerv1.JobStatus_JOB_STATUS_FAILED,
+		Message:   "Encoding failed",
+		Error:     "ffmpeg failed: exit status 1",
+		ErrorCode: "UNSUPPORTED_CODEC",
+	})
+	if !ok {
+		t.Fatal("イベントを書き込めなかった")
+	}
+
+	data, found := strings.CutPrefix(strings.TrimSpace(b.String()), "data: ")
+	if !found {
+		t.Fatalf("SSE のイベントになっていない: %s", b.String())
+	}
+	var event map[string]any
+	if err := json.Unmarshal([]byte(data), &event); err != nil {
+		t.Fatalf("イベントのパースに失敗: %v", err)
+	}
+	if event["error_code"] != "UNSUPPORTED_CODEC" || event["error"] != "ffmpeg failed: exit status 1" {
+		t.Errorf("イベントの内容が一致しない: %v", event)
+	}
+
+	// 分類がない場合は error_code を含めない
+	b.Reset()
+	writeProgressEvent(&b, &workerv1.JobProgress{JobId: "job-1", Status: workerv1.JobStatus_JOB_STATUS_PROCESSING})
+	if strings.Contains(b.String(), "error_code") {
+		t.Errorf("分類がない場合に error_code が含まれている: %s", b.String())
+	}
+}
+
+func TestStreamJobProgressが接続前に届いた進捗を先に送信する(t *testing.T) {
+	handler := NewHandler(nil)
+	jobID := "test-job-replay"
+
+	// クライアントが接続する前に Worker から QUEUED と PROCESSING が届いている
+	progressCh := handler.jobManager.CreateProgressChannel(jobID)
+	handler.jobManager.RecordProgress(jobID, &workerv1.JobProgress{JobId: jobID, Status: workerv1.JobStatus_JOB_STATUS_QUEUED, Message: "Job accepted"})
+	handler.jobManager.RecordProgress(jobID, &workerv1.JobProgress{JobId: jobID, Status: workerv1.JobStatus_JOB_STATUS_PROCESSING, Progress: 10, Message: "Encoding"})
+	close(progressCh)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/api/v1/jobs/:id/stream", handler.StreamJobProgress)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/jobs/"+jobID+"/stream", nil))
+
+	var statuses []string
+	for _, line := range strings.Split(w.Body.String(), "\n") {
+		data, ok := strings.CutPrefix(line, "data: ")
+		if !ok {
+			continue
+		}
+		var event map[string]any
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			t.Fatalf("イベントのパースに失敗: %v (%s)", err, data)
+		}
+		statuses = append(statuses, event["status"].(string))
+	}
+	want := []string{"JOB_STATUS_QUEUED", "JOB_STATUS_PROCESSING"}
+	if strings.Join(statuses, ",") != strings.Join(want, ",") {
+		t.Errorf("送信された進捗が一致しない: 期待値 %v, 取得値 %v", want, statuses)
+	}
+}
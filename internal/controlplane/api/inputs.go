@@ -0,0 +1,331 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nzws/flux-encoder/internal/shared/logger"
+	"go.uber.org/zap"
+)
+
+var (
+	// ErrInputNotFound はアップロードが存在しない場合のエラー
+	ErrInputNotFound = errors.New("input upload not found")
+	// ErrInputOffsetMismatch は指定されたオフセットが現在のオフセットと一致しない場合のエラー
+	ErrInputOffsetMismatch = errors.New("upload offset mismatch")
+	// ErrInputInvalidSize はアップロードサイズが不正な場合のエラー
+	ErrInputInvalidSize = errors.New("invalid upload size")
+	// ErrInputTooLarge はサイズ上限または宣言サイズを超えた場合のエラー
+	ErrInputTooLarge = errors.New("input exceeds allowed size")
+	// ErrInputBusy は同じアップロードに対して別のチャンクを書き込み中の場合のエラー
+	ErrInputBusy = errors.New("another chunk is being written")
+	// ErrInputUnsupportedType は許可されていない Content-Type の場合のエラー
+	ErrInputUnsupportedType = errors.New("unsupported content type")
+	// ErrInputQuotaExceeded は保持中のアップロードの合計サイズが上限を超える場合のエラー
+	ErrInputQuotaExceeded = errors.New("input storage quota exceeded")
+)
+
+// DefaultInputTTL はアップロードを最後に更新してから削除するまでのデフォルトの期間
+const DefaultInputTTL = 24 * time.Hour
+
+// InputUpload はアップロード中または完了済みの入力ファイルの状態
+type InputUpload struct {
+	ID          string
+	Filename    string
+	ContentType string
+	Size        int64
+	Offset      int64
+	CreatedAt   time.Time
+	// UpdatedAt は最後にチャンクを受信した時刻（保持期限の起点）
+	UpdatedAt time.Time
+
+	busy bool
+}
+
+// Completed はすべてのバイトを受信済みかを返す
+func (u InputUpload) Completed() bool {
+	return u.Offset == u.Size
+}
+
+// InputStore は再開可能な入力アップロードを管理する
+// 受信したデータは dir 配下に ID ごとのファイルとして保存する
+type InputStore struct {
+	dir     string
+	maxSize int64
+	// maxTotalSize は保持中のアップロードの宣言サイズの合計の上限（0 以下の場合は制限しない）
+	maxTotalSize int64
+	// ttl は最後に更新してからアップロードを保持する期間（0 以下の場合は削除しない）
+	ttl     time.Duration
+	baseURL string
+	uploads map[string]*InputUpload
+	now     func() time.Time
+	mutex   sync.Mutex
+}
+
+// NewInputStore は新しい InputStore を作成する
+// baseURL は Worker から入力ファイルを取得するための Control Plane の公開URL
+// アップロードの状態はメモリにのみ保持するため、再起動前に dir に残った入力ファイルは削除する
+func NewInputStore(dir string, maxSize int64, baseURL string) (*InputStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create input directory: %w", err)
+	}
+	removed, err := purgeInputDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	if removed > 0 {
+		logger.Info("Removed orphaned input files", zap.String("dir", dir), zap.Int("removed", removed))
+	}
+
+	return &InputStore{
+		dir:     dir,
+		maxSize: maxSize,
+		ttl:     DefaultInputTTL,
+		baseURL: strings.TrimRight(baseURL, "/"),
+		uploads: make(map[string]*InputUpload),
+		now:     time.Now,
+	}, nil
+}
+
+// purgeInputDir は dir にある入力ファイル（ID をファイル名とする通常のファイル）を削除し、削除した件数を返す
+// INPUT_DIR に別のファイルが置かれていても消さないよう、ID の形式でないファイルは残す
+func purgeInputDir(dir string) (int, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read input directory: %w", err)
+	}
+
+	removed := 0
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		if _, err := uuid.Parse(entry.Name()); err != nil {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, entry.Name())); err != nil {
+			return removed, fmt.Errorf("failed to remove orphaned input file: %w", err)
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// SetTTL は最後に更新してからアップロードを保持する期間を設定する（0 以下の場合は削除しない）
+func (s *InputStore) SetTTL(ttl time.Duration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.ttl = ttl
+}
+
+// SetMaxTotalSize は保持中のアップロードの宣言サイズの合計の上限を設定する（0 以下の場合は制限しない）
+// 完了前のアップロードも宣言サイズ分を確保済みとして数える
+func (s *InputStore) SetMaxTotalSize(size int64) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.maxTotalSize = size
+}
+
+// Prune は保持期限を過ぎたアップロードとそのファイルを削除し、削除した件数を返す
+// チャンクを書き込み中のアップロードは削除しない
+func (s *InputStore) Prune() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.pruneLocked(s.now())
+}
+
+// pruneLocked は now の時点で保持期限を過ぎたアップロードを削除する（mutex を保持して呼び出す）
+func (s *InputStore) pruneLocked(now time.Time) int {
+	removed := 0
+	for id, upload := range s.uploads {
+		if upload.busy || !s.isExpired(upload, now) {
+			continue
+		}
+		if err := os.Remove(s.Path(id)); err != nil && !os.IsNotExist(err) {
+			logger.Warn("Failed to remove expired input file", zap.String("input_id", id), zap.Error(err))
+			continue
+		}
+		delete(s.uploads, id)
+		removed++
+	}
+	if removed > 0 {
+		logger.Info("Pruned expired input uploads", zap.Int("removed", removed))
+	}
+	return removed
+}
+
+// isExpired はアップロードが保持期限を過ぎているかを返す
+func (s *InputStore) isExpired(upload *InputUpload, now time.Time) bool {
+	return s.ttl > 0 && now.Sub(upload.UpdatedAt) > s.ttl
+}
+
+// isAllowedInputType は入力として受け付ける Content-Type かを判定する
+func isAllowedInputType(contentType string) bool {
+	mediaType := strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	return strings.HasPrefix(mediaType, "video/") || mediaType == "application/octet-stream"
+}
+
+// Create は新しいアップロードを開始する
+func (s *InputStore) Create(size int64, contentType, filename string) (InputUpload, error) {
+	if size <= 0 {
+		return InputUpload{}, fmt.Errorf("%w: %d", ErrInputInvalidSize, size)
+	}
+	if size > s.maxSize {
+		return InputUpload{}, fmt.Errorf("%w: %d bytes (max %d bytes)", ErrInputTooLarge, size, s.maxSize)
+	}
+	if !isAllowedInputType(contentType) {
+		return InputUpload{}, fmt.Errorf("%w: %s", ErrInputUnsupportedType, contentType)
+	}
+
+	s.mutex.Lock()
+	now := s.now()
+	s.pruneLocked(now)
+	if s.maxTotalSize > 0 {
+		total := size
+		for _, upload := range s.uploads {
+			total += upload.Size
+		}
+		if total > s.maxTotalSize {
+			s.mutex.Unlock()
+			return InputUpload{}, fmt.Errorf("%w: %d bytes would be stored (max %d bytes)", ErrInputQuotaExceeded, total, s.maxTotalSize)
+		}
+	}
+	upload := &InputUpload{
+		ID:          uuid.New().String(),
+		Filename:    filepath.Base(filename),
+		ContentType: contentType,
+		Size:        size,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		// ファイルを作成するまで他のアップロードの合計サイズに数えつつ、Prune の対象から外す
+		busy: true,
+	}
+	s.uploads[upload.ID] = upload
+	s.mutex.Unlock()
+
+	file, err := os.Create(s.Path(upload.ID))
+	if err == nil {
+		err = file.Close()
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if err != nil {
+		delete(s.uploads, upload.ID)
+		return InputUpload{}, fmt.Errorf("failed to create input file: %w", err)
+	}
+	upload.busy = false
+	return *upload, nil
+}
+
+// Get はアップロードの状態を返す
+func (s *InputStore) Get(id string) (InputUpload, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	upload, ok := s.uploads[id]
+	if !ok || s.isExpired(upload, s.now()) {
+		return InputUpload{}, false
+	}
+	return *upload, true
+}
+
+// Append は offset の位置からチャンクを書き込む
+// offset は現在のオフセットと一致している必要がある。書き込み途中で読み取りエラーが発生した場合も
+// 書き込めた分だけオフセットを進めるため、クライアントは Get で確認したオフセットから再開できる
+func (s *InputStore) Append(id string, offset int64, r io.Reader) (InputUpload, error) {
+	s.mutex.Lock()
+	upload, ok := s.uploads[id]
+	if !ok || s.isExpired(upload, s.now()) {
+		s.mutex.Unlock()
+		return InputUpload{}, ErrInputNotFound
+	}
+	if upload.busy {
+		s.mutex.Unlock()
+		return InputUpload{}, ErrInputBusy
+	}
+	if offset != upload.Offset {
+		current := upload.Offset
+		s.mutex.Unlock()
+		return InputUpload{}, fmt.Errorf("%w: expected %d, got %d", ErrInputOffsetMismatch, current, offset)
+	}
+	upload.busy = true
+	remaining := upload.Size - upload.Offset
+	s.mutex.Unlock()
+
+	written, writeErr := s.writeChunk(id, offset, remaining, r)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	upload.busy = false
+	upload.Offset += written
+	upload.UpdatedAt = s.now()
+
+	if writeErr != nil {
+		return *upload, writeErr
+	}
+
+	if upload.Completed() {
+		logger.Info("Input upload completed",
+			zap.String("input_id", id),
+			zap.Int64("size", upload.Size),
+		)
+	}
+
+	return *upload, nil
+}
+
+// writeChunk はファイルの offset 位置に最大 remaining バイトを書き込み、書き込んだバイト数を返す
+// remaining を超えるデータが送られた場合は書き込みを取り消して ErrInputTooLarge を返す
+func (s *InputStore) writeChunk(id string, offset, remaining int64, r io.Reader) (int64, error) {
+	file, err := os.OpenFile(s.Path(id), os.O_WRONLY, 0644)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open input file: %w", err)
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			logger.Warn("Failed to close input file", zap.String("input_id", id), zap.Error(err))
+		}
+	}()
+
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return 0, fmt.Errorf("failed to seek input file: %w", err)
+	}
+
+	written, err := io.Copy(file, io.LimitReader(r, remaining))
+	if err != nil {
+		return written, fmt.Errorf("failed to write chunk: %w", err)
+	}
+
+	// 宣言サイズを超えるデータが残っていないか確認
+	if n, _ := r.Read(make([]byte, 1)); n > 0 {
+		if err := file.Truncate(offset); err != nil {
+			return written, fmt.Errorf("failed to rollback oversized chunk: %w", err)
+		}
+		return 0, fmt.Errorf("%w: chunk exceeds declared upload size", ErrInputTooLarge)
+	}
+
+	return written, nil
+}
+
+// Path は入力ファイルの保存先パスを返す
+func (s *InputStore) Path(id string) string {
+	return filepath.Join(s.dir, id)
+}
+
+// InputURL はジョブの input_url として使用できるURLを返す
+func (s *InputStore) InputURL(id string) string {
+	return fmt.Sprintf("%s/api/v1/inputs/%s/content", s.baseURL, id)
+}
@@ -0,0 +1,305 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func newInputTestRouter(t *testing.T, maxSize int64) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	store, err := NewInputStore(t.TempDir(), maxSize, "http://controlplane:8080/")
+	if err != nil {
+		t.Fatalf("InputStore の作成に失敗: %v", err)
+	}
+
+	handler := NewHandler(nil)
+	handler.SetInputStore(store)
+
+	router := gin.New()
+	router.POST("/api/v1/inputs", handler.CreateInput)
+	router.GET("/api/v1/inputs/:id", handler.GetInput)
+	router.PATCH("/api/v1/inputs/:id", handler.UploadInputChunk)
+	router.GET("/api/v1/inputs/:id/content", handler.DownloadInput)
+	return router
+}
+
+func createInput(t *testing.T, router *gin.Engine, size int64, contentType string) (*httptest.ResponseRecorder, InputResponse) {
+	t.Helper()
+
+	body, err := json.Marshal(CreateInputRequest{Size: size, ContentType: contentType, Filename: "video.mp4"})
+	if err != nil {
+		t.Fatalf("リクエストの作成に失敗: %v", err)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/inputs", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var resp InputResponse
+	if w.Code == http.StatusCreated {
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("レスポンスのパースに失敗: %v", err)
+		}
+	}
+	return w, resp
+}
+
+func patchInput(t *testing.T, router *gin.Engine, id string, offset int64, chunk []byte) (*httptest.ResponseRecorder, InputResponse) {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodPatch, "/api/v1/inputs/"+id, bytes.NewReader(chunk))
+	req.Header.Set("Content-Type", chunkContentType)
+	req.Header.Set(uploadOffsetHeader, strconv.FormatInt(offset, 10))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var resp InputResponse
+	if w.Code == http.StatusOK {
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("レスポンスのパースに失敗: %v", err)
+		}
+	}
+	return w, resp
+}
+
+func TestInputを一度のリクエストでアップロードして取得できる(t *testing.T) {
+	router := newInputTestRouter(t, 1024)
+	data := bytes.Repeat([]byte("flux"), 64)
+
+	w, created := createInput(t, router, int64(len(data)), "video/mp4")
+	if w.Code != http.StatusCreated {
+		t.Fatalf("ステータスコードが一致しない: 期待値 %d, 取得値 %d (%s)", http.StatusCreated, w.Code, w.Body.String())
+	}
+	if created.Offset != 0 || created.Completed || created.InputURL != "" {
+		t.Errorf("作成直後の状態が正しくない: %+v", created)
+	}
+
+	w, uploaded := patchInput(t, router, created.ID, 0, data)
+	if w.Code != http.StatusOK {
+		t.Fatalf("ステータスコードが一致しない: 期待値 %d, 取得値 %d (%s)", http.StatusOK, w.Code, w.Body.String())
+	}
+	if !uploaded.Completed || uploaded.Offset != int64(len(data)) {
+		t.Errorf("アップロードが完了していない: %+v", uploaded)
+	}
+
+	expectedURL := "http://controlplane:8080/api/v1/inputs/" + created.ID + "/content"
+	if uploaded.InputURL != expectedURL {
+		t.Errorf("InputURL が一致しない: 期待値 %s, 取得値 %s", expectedURL, uploaded.InputURL)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/inputs/"+created.ID+"/content", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("ステータスコードが一致しない: 期待値 %d, 取得値 %d", http.StatusOK, w.Code)
+	}
+	if !bytes.Equal(w.Body.Bytes(), data) {
+		t.Error("取得した内容がアップロードした内容と一致しない")
+	}
+}
+
+func TestInputのアップロードを中断したオフセットから再開できる(t *testing.T) {
+	router := newInputTestRouter(t, 1024)
+	data := []byte("0123456789abcdefghij")
+
+	_, created := createInput(t, router, int64(len(data)), "video/quicktime")
+
+	// 最初のチャンク
+	w, first := patchInput(t, router, created.ID, 0, data[:8])
+	if w.Code != http.StatusOK {
+		t.Fatalf("ステータスコードが一致しない: 期待値 %d, 取得値 %d", http.StatusOK, w.Code)
+	}
+	if first.Completed || first.Offset != 8 {
+		t.Errorf("途中の状態が正しくない: %+v", first)
+	}
+
+	// 完了前のダウンロードは拒否される
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/inputs/"+created.ID+"/content", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusConflict {
+		t.Errorf("完了前のダウンロードのステータスコードが一致しない: 期待値 %d, 取得値 %d", http.StatusConflict, w.Code)
+	}
+
+	// 誤ったオフセットは 409
+	w, _ = patchInput(t, router, created.ID, 4, data[4:])
+	if w.Code != http.StatusConflict {
+		t.Errorf("オフセット不一致のステータスコードが一致しない: 期待値 %d, 取得値 %d", http.StatusConflict, w.Code)
+	}
+
+	// 現在のオフセットを確認して再開
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/inputs/"+created.ID, nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if got := w.Header().Get(uploadOffsetHeader); got != "8" {
+		t.Fatalf("Upload-Offset が一致しない: 期待値 %s, 取得値 %s", "8", got)
+	}
+
+	w, resumed := patchInput(t, router, created.ID, 8, data[8:])
+	if w.Code != http.StatusOK {
+		t.Fatalf("ステータスコードが一致しない: 期待値 %d, 取得値 %d", http.StatusOK, w.Code)
+	}
+	if !resumed.Completed {
+		t.Errorf("再開後にアップロードが完了していない: %+v", resumed)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/inputs/"+created.ID+"/content", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if !bytes.Equal(w.Body.Bytes(), data) {
+		t.Errorf("取得した内容が一致しない: 期待値 %q, 取得値 %q", data, w.Body.Bytes())
+	}
+}
+
+func TestInputのサイズとContentTypeが検証される(t *testing.T) {
+	router := newInputTestRouter(t, 16)
+
+	testCases := []struct {
+		name           string
+		size           int64
+		contentType    string
+		expectedStatus int
+	}{
+		{"上限以内", 16, "video/mp4", http.StatusCreated},
+		{"上限超過", 17, "video/mp4", http.StatusRequestEntityTooLarge},
+		{"負のサイズ", -1, "video/mp4", http.StatusBadRequest},
+		{"許可されていない ContentType", 8, "text/plain", http.StatusUnsupportedMediaType},
+		{"octet-stream は許可", 8, "application/octet-stream", http.StatusCreated},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w, _ := createInput(t, router, tc.size, tc.contentType)
+			if w.Code != tc.expectedStatus {
+				t.Errorf("ステータスコードが一致しない: 期待値 %d, 取得値 %d", tc.expectedStatus, w.Code)
+			}
+		})
+	}
+}
+
+func TestInputの宣言サイズを超えるチャンクは拒否される(t *testing.T) {
+	router := newInputTestRouter(t, 1024)
+
+	_, created := createInput(t, router, 4, "video/mp4")
+
+	w, _ := patchInput(t, router, created.ID, 0, []byte("too long"))
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("ステータスコードが一致しない: 期待値 %d, 取得値 %d", http.StatusRequestEntityTooLarge, w.Code)
+	}
+
+	// 超過したチャンクは書き込まれず、最初からやり直せる
+	w, uploaded := patchInput(t, router, created.ID, 0, []byte("okay"))
+	if w.Code != http.StatusOK || !uploaded.Completed {
+		t.Errorf("再送信が成功しない: %d %+v", w.Code, uploaded)
+	}
+}
+
+func TestInputストアが未設定の場合は503が返る(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	handler := NewHandler(nil)
+
+	router := gin.New()
+	router.POST("/api/v1/inputs", handler.CreateInput)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/inputs", bytes.NewReader([]byte(`{}`)))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("ステータスコードが一致しない: 期待値 %d, 取得値 %d", http.StatusServiceUnavailable, w.Code)
+	}
+}
+
+func TestInputは保持期限を過ぎると削除される(t *testing.T) {
+	store, err := NewInputStore(t.TempDir(), 1024, "http://controlplane:8080")
+	if err != nil {
+		t.Fatalf("InputStore の作成に失敗: %v", err)
+	}
+	now := time.Now()
+	store.now = func() time.Time { return now }
+	store.SetTTL(time.Hour)
+
+	upload, err := store.Create(4, "video/mp4", "video.mp4")
+	if err != nil {
+		t.Fatalf("アップロードの作成に失敗: %v", err)
+	}
+
+	// チャンクを受信すると保持期限が延びる
+	now = now.Add(50 * time.Minute)
+	if _, err := store.Append(upload.ID, 0, bytes.NewReader([]byte("data"))); err != nil {
+		t.Fatalf("チャンクの書き込みに失敗: %v", err)
+	}
+	now = now.Add(50 * time.Minute)
+	if _, ok := store.Get(upload.ID); !ok {
+		t.Fatal("保持期限内のアップロードが取得できない")
+	}
+
+	now = now.Add(11 * time.Minute)
+	if _, ok := store.Get(upload.ID); ok {
+		t.Error("保持期限を過ぎたアップロードが取得できる")
+	}
+	if removed := store.Prune(); removed != 1 {
+		t.Errorf("削除した件数が一致しない: 期待値 1, 取得値 %d", removed)
+	}
+	if _, err := os.Stat(store.Path(upload.ID)); !os.IsNotExist(err) {
+		t.Errorf("保持期限を過ぎた入力ファイルが削除されない: %v", err)
+	}
+}
+
+func TestInputの合計サイズが上限を超えるアップロードは507が返る(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store, err := NewInputStore(t.TempDir(), 1024, "http://controlplane:8080")
+	if err != nil {
+		t.Fatalf("InputStore の作成に失敗: %v", err)
+	}
+	store.SetMaxTotalSize(100)
+	handler := NewHandler(nil)
+	handler.SetInputStore(store)
+	router := gin.New()
+	router.POST("/api/v1/inputs", handler.CreateInput)
+
+	if w, _ := createInput(t, router, 60, "video/mp4"); w.Code != http.StatusCreated {
+		t.Fatalf("ステータスコードが一致しない: 期待値 %d, 取得値 %d", http.StatusCreated, w.Code)
+	}
+	// 完了前のアップロードも宣言サイズ分を数える
+	if w, _ := createInput(t, router, 50, "video/mp4"); w.Code != http.StatusInsufficientStorage {
+		t.Errorf("ステータスコードが一致しない: 期待値 %d, 取得値 %d (%s)", http.StatusInsufficientStorage, w.Code, w.Body.String())
+	}
+	if w, _ := createInput(t, router, 40, "video/mp4"); w.Code != http.StatusCreated {
+		t.Errorf("ステータスコードが一致しない: 期待値 %d, 取得値 %d", http.StatusCreated, w.Code)
+	}
+}
+
+func TestInputStoreの作成時に前回の起動で残った入力ファイルが削除される(t *testing.T) {
+	dir := t.TempDir()
+	orphan := filepath.Join(dir, uuid.New().String())
+	other := filepath.Join(dir, "keep.txt")
+	for _, path := range []string{orphan, other} {
+		if err := os.WriteFile(path, []byte("data"), 0644); err != nil {
+			t.Fatalf("ファイルの作成に失敗: %v", err)
+		}
+	}
+
+	if _, err := NewInputStore(dir, 1024, "http://controlplane:8080"); err != nil {
+		t.Fatalf("InputStore の作成に失敗: %v", err)
+	}
+
+	if _, err := os.Stat(orphan); !os.IsNotExist(err) {
+		t.Errorf("残った入力ファイルが削除されない: %v", err)
+	}
+	if _, err := os.Stat(other); err != nil {
+		t.Errorf("入力ファイル以外のファイルが削除された: %v", err)
+	}
+}
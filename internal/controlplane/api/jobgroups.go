@@ -0,0 +1,326 @@
+package api
+
+import (
+	"sync"
+	"time"
+
+	workerv1 "github.com/nzws/flux-encoder/proto/worker/v1"
+)
+
+// ジョブグループで1つのジョブが失敗した場合の扱い
+const (
+	// JobGroupOnFailureContinue は失敗したジョブ以外のジョブを最後まで実行する
+	JobGroupOnFailureContinue = "continue"
+	// JobGroupOnFailureCancel は実行中の残りのジョブをキャンセルする
+	JobGroupOnFailureCancel = "cancel"
+)
+
+// ジョブグループのステータス
+const (
+	// JobGroupStatusRunning は終了していないジョブがある状態
+	JobGroupStatusRunning = "running"
+	// JobGroupStatusCompleted はすべてのジョブが完了した状態
+	JobGroupStatusCompleted = "completed"
+	// JobGroupStatusPartiallyCompleted は一部のジョブが完了し、残りが失敗した状態
+	JobGroupStatusPartiallyCompleted = "partially_completed"
+	// JobGroupStatusFailed はすべてのジョブが失敗した状態
+	JobGroupStatusFailed = "failed"
+)
+
+// JobGroupJob はジョブグループに含まれるジョブの最新の状態
+type JobGroupJob struct {
+	JobID     string  `json:"job_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Preset    string  `json:"preset" example:"720p_h264"`
+	Status    string  `json:"status" example:"JOB_STATUS_PROCESSING"`
+	Progress  float32 `json:"progress" example:"42.5"`
+	Message   string  `json:"message,omitempty" example:"Encoding"`
+	OutputURL string  `json:"output_url,omitempty" example:"https://example-bucket.s3.amazonaws.com/output/720p.mp4"`
+	Error     string  `json:"error,omitempty" example:""`
+}
+
+// JobGroupProgress はジョブグループ全体の進捗
+type JobGroupProgress struct {
+	GroupID string `json:"group_id" example:"7c9e6679-7425-40de-944b-e07fc1f90ae7"`
+	// Status は running・completed・partially_completed・failed のいずれか
+	Status string `json:"status" example:"running"`
+	// Progress は各ジョブの進捗率の平均（終了したジョブは 100 として数える）
+	Progress float32 `json:"progress" example:"61.2"`
+	// MinProgress は最も遅れているジョブの進捗率（全体の完了見込みの目安）
+	MinProgress float32       `json:"min_progress" example:"22.4"`
+	Completed   int           `json:"completed" example:"1"`
+	Failed      int           `json:"failed" example:"0"`
+	Jobs        []JobGroupJob `json:"jobs"`
+}
+
+// jobGroup は複数のプリセットを並列に実行するジョブのまとまり
+type jobGroup struct {
+	id        string
+	onFailure string
+	jobs      []JobGroupJob
+	// cancelling は失敗時に残りのジョブのキャンセルを開始したか
+	cancelling bool
+	ch         chan JobGroupProgress
+	// expiresAt は保持期限。ゼロ値の場合は実行中で期限なし
+	expiresAt time.Time
+}
+
+// JobGroupManager はジョブグループと、各ジョブの進捗を集約したグループの進捗を管理する
+type JobGroupManager struct {
+	groups map[string]*jobGroup
+	// byJob はジョブIDから所属するグループIDを引く
+	byJob map[string]string
+	ttl   time.Duration
+	now   func() time.Time
+	mutex sync.RWMutex
+}
+
+// NewJobGroupManager は新しい JobGroupManager を作成する
+func NewJobGroupManager() *JobGroupManager {
+	return &JobGroupManager{
+		groups: make(map[string]*jobGroup),
+		byJob:  make(map[string]string),
+		ttl:    DefaultJobStatusTTL,
+		now:    time.Now,
+	}
+}
+
+// SetStatusTTL はグループの終了後に最終ステータスを保持する期間を設定する
+func (gm *JobGroupManager) SetStatusTTL(ttl time.Duration) {
+	gm.mutex.Lock()
+	defer gm.mutex.Unlock()
+
+	gm.ttl = ttl
+}
+
+// Create はジョブグループを登録する
+// jobs にはジョブIDとプリセットを指定し、ジョブの送信前に登録しておく
+func (gm *JobGroupManager) Create(groupID, onFailure string, jobs []JobGroupJob) {
+	gm.mutex.Lock()
+	defer gm.mutex.Unlock()
+
+	gm.pruneLocked(gm.now())
+
+	group := &jobGroup{
+		id:        groupID,
+		onFailure: onFailure,
+		jobs:      make([]JobGroupJob, len(jobs)),
+		ch:        make(chan JobGroupProgress, 100),
+	}
+	for i, job := range jobs {
+		group.jobs[i] = JobGroupJob{
+			JobID:  job.JobID,
+			Preset: job.Preset,
+			Status: workerv1.JobStatus_JOB_STATUS_QUEUED.String(),
+		}
+		gm.byJob[job.JobID] = groupID
+	}
+	gm.groups[groupID] = group
+}
+
+// Remove はジョブグループを削除する（ジョブの送信に失敗してグループを受け付けなかった場合）
+func (gm *JobGroupManager) Remove(groupID string) {
+	gm.mutex.Lock()
+	defer gm.mutex.Unlock()
+
+	gm.removeLocked(groupID)
+}
+
+// RecordProgress はジョブの進捗をグループに反映する
+// グループに属さないジョブの場合は何もしない
+// on_failure が cancel のグループでジョブが初めて失敗した場合は、キャンセルすべき実行中のジョブIDを返す
+func (gm *JobGroupManager) RecordProgress(jobID string, progress *workerv1.JobProgress) []string {
+	gm.mutex.Lock()
+	defer gm.mutex.Unlock()
+
+	group, ok := gm.groupOfLocked(jobID)
+	if !ok {
+		return nil
+	}
+	for i := range group.jobs {
+		job := &group.jobs[i]
+		if job.JobID != jobID {
+			continue
+		}
+		if isFinishedStatus(job.Status) {
+			// 終了後に届いた進捗（再接続時の重複など）は反映しない
+			return nil
+		}
+		job.Status = progress.Status.String()
+		job.Progress = progress.Progress
+		job.Message = progress.Message
+		job.OutputURL = progress.OutputUrl
+		job.Error = progress.Error
+	}
+
+	var toCancel []string
+	if progress.Status == workerv1.JobStatus_JOB_STATUS_FAILED &&
+		group.onFailure == JobGroupOnFailureCancel && !group.cancelling {
+		group.cancelling = true
+		for _, job := range group.jobs {
+			if !isFinishedStatus(job.Status) {
+				toCancel = append(toCancel, job.JobID)
+			}
+		}
+	}
+
+	gm.publishLocked(group)
+	return toCancel
+}
+
+// CloseJob はジョブの進捗の受信が終わったことをグループに反映する
+// 終了ステータスを受信せずにストリームが閉じた場合は失敗として扱う
+func (gm *JobGroupManager) CloseJob(jobID string) []string {
+	// 終了済みのジョブには RecordProgress が反映しない
+	return gm.RecordProgress(jobID, &workerv1.JobProgress{
+		JobId:  jobID,
+		Status: workerv1.JobStatus_JOB_STATUS_FAILED,
+		Error:  "progress stream closed before the job finished",
+	})
+}
+
+// Cancelling はジョブの失敗によって残りのジョブのキャンセルを開始したグループかを返す
+func (gm *JobGroupManager) Cancelling(groupID string) bool {
+	gm.mutex.RLock()
+	defer gm.mutex.RUnlock()
+
+	group, exists := gm.groups[groupID]
+	return exists && group.cancelling
+}
+
+// Get はジョブグループの最新の進捗を取得する
+// 登録がない場合や保持期限を過ぎた場合は false を返す
+func (gm *JobGroupManager) Get(groupID string) (JobGroupProgress, bool) {
+	gm.mutex.RLock()
+	defer gm.mutex.RUnlock()
+
+	group, exists := gm.groups[groupID]
+	if !exists || gm.isExpired(group, gm.now()) {
+		return JobGroupProgress{}, false
+	}
+	return group.snapshot(), true
+}
+
+// GetProgressChannel は実行中のジョブグループの進捗チャネルを取得する
+// グループが終了するとチャネルは閉じられる
+func (gm *JobGroupManager) GetProgressChannel(groupID string) (<-chan JobGroupProgress, bool) {
+	gm.mutex.RLock()
+	defer gm.mutex.RUnlock()
+
+	group, exists := gm.groups[groupID]
+	if !exists || !group.expiresAt.IsZero() {
+		return nil, false
+	}
+	return group.ch, true
+}
+
+// groupOfLocked はジョブが属するグループを返す（mutex を保持した状態で呼ぶ）
+func (gm *JobGroupManager) groupOfLocked(jobID string) (*jobGroup, bool) {
+	groupID, ok := gm.byJob[jobID]
+	if !ok {
+		return nil, false
+	}
+	group, ok := gm.groups[groupID]
+	return group, ok
+}
+
+// publishLocked はグループの進捗をチャネルに送信し、すべてのジョブが終了していればチャネルを閉じる
+// チャネルが満杯の場合は最も古い進捗を捨てる（進捗は累積なので最新のものが届けばよい）
+func (gm *JobGroupManager) publishLocked(group *jobGroup) {
+	if !group.expiresAt.IsZero() {
+		return
+	}
+
+	snapshot := group.snapshot()
+	for {
+		select {
+		case group.ch <- snapshot:
+		default:
+			select {
+			case <-group.ch:
+			default:
+			}
+			continue
+		}
+		break
+	}
+
+	if snapshot.Status != JobGroupStatusRunning {
+		close(group.ch)
+		group.expiresAt = gm.now().Add(gm.ttl)
+	}
+}
+
+// removeLocked はグループとジョブの対応を削除する（mutex を保持した状態で呼ぶ）
+func (gm *JobGroupManager) removeLocked(groupID string) {
+	group, exists := gm.groups[groupID]
+	if !exists {
+		return
+	}
+	for _, job := range group.jobs {
+		delete(gm.byJob, job.JobID)
+	}
+	delete(gm.groups, groupID)
+}
+
+// isExpired は保持期限を過ぎているかどうかを返す
+func (gm *JobGroupManager) isExpired(group *jobGroup, now time.Time) bool {
+	return !group.expiresAt.IsZero() && !now.Before(group.expiresAt)
+}
+
+// pruneLocked は保持期限を過ぎたグループを削除する（mutex を保持した状態で呼ぶ）
+func (gm *JobGroupManager) pruneLocked(now time.Time) {
+	for groupID, group := range gm.groups {
+		if gm.isExpired(group, now) {
+			gm.removeLocked(groupID)
+		}
+	}
+}
+
+// snapshot は各ジョブの状態からグループ全体の進捗を集計する
+func (g *jobGroup) snapshot() JobGroupProgress {
+	progress := JobGroupProgress{
+		GroupID: g.id,
+		Jobs:    append([]JobGroupJob(nil), g.jobs...),
+	}
+
+	var total float32
+	minProgress := float32(100)
+	finished := 0
+	for _, job := range g.jobs {
+		switch job.Status {
+		case workerv1.JobStatus_JOB_STATUS_COMPLETED.String():
+			progress.Completed++
+		case workerv1.JobStatus_JOB_STATUS_FAILED.String():
+			progress.Failed++
+		}
+		jobProgress := job.Progress
+		if isFinishedStatus(job.Status) {
+			finished++
+			jobProgress = 100
+		}
+		total += jobProgress
+		minProgress = min(minProgress, jobProgress)
+	}
+	if len(g.jobs) > 0 {
+		progress.Progress = total / float32(len(g.jobs))
+		progress.MinProgress = minProgress
+	}
+
+	switch {
+	case finished < len(g.jobs):
+		progress.Status = JobGroupStatusRunning
+	case progress.Failed == 0:
+		progress.Status = JobGroupStatusCompleted
+	case progress.Completed > 0:
+		progress.Status = JobGroupStatusPartiallyCompleted
+	default:
+		progress.Status = JobGroupStatusFailed
+	}
+	return progress
+}
+
+// isFinishedStatus はジョブのステータス（JobStatus の文字列）が終了状態かを返す
+func isFinishedStatus(status string) bool {
+	return status == workerv1.JobStatus_JOB_STATUS_COMPLETED.String() ||
+		status == workerv1.JobStatus_JOB_STATUS_FAILED.String()
+}
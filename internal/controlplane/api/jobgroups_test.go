@@ -0,0 +1,126 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+
+	workerv1 "github.com/nzws/flux-encoder/proto/worker/v1"
+)
+
+// newTestJobGroup は2つのジョブ（job-360p、job-720p）を持つグループを登録する
+func newTestJobGroup(onFailure string) *JobGroupManager {
+	gm := NewJobGroupManager()
+	gm.Create("group-1", onFailure, []JobGroupJob{
+		{JobID: "job-360p", Preset: "360p_h264"},
+		{JobID: "job-720p", Preset: "720p_h264"},
+	})
+	return gm
+}
+
+func Testジョブグループの進捗が各ジョブの平均に集約される(t *testing.T) {
+	gm := newTestJobGroup(JobGroupOnFailureContinue)
+	ch, ok := gm.GetProgressChannel("group-1")
+	if !ok {
+		t.Fatal("進捗チャネルが取得できない")
+	}
+
+	gm.RecordProgress("job-360p", &workerv1.JobProgress{Status: workerv1.JobStatus_JOB_STATUS_PROCESSING, Progress: 50})
+	gm.RecordProgress("job-720p", &workerv1.JobProgress{Status: workerv1.JobStatus_JOB_STATUS_PROCESSING, Progress: 10})
+
+	progress, _ := gm.Get("group-1")
+	if progress.Status != JobGroupStatusRunning || progress.Progress != 30 {
+		t.Errorf("実行中の進捗が一致しない: %+v", progress)
+	}
+
+	gm.RecordProgress("job-360p", &workerv1.JobProgress{
+		Status:    workerv1.JobStatus_JOB_STATUS_COMPLETED,
+		Progress:  100,
+		OutputUrl: "https://cdn.example.com/360p.mp4",
+	})
+	gm.RecordProgress("job-720p", &workerv1.JobProgress{
+		Status:    workerv1.JobStatus_JOB_STATUS_COMPLETED,
+		Progress:  100,
+		OutputUrl: "https://cdn.example.com/720p.mp4",
+	})
+
+	var events []JobGroupProgress
+	for event := range ch {
+		events = append(events, event)
+	}
+	if len(events) != 4 {
+		t.Fatalf("進捗イベント数が一致しない: 期待値 4, 取得値 %d", len(events))
+	}
+	last := events[len(events)-1]
+	if last.Status != JobGroupStatusCompleted || last.Progress != 100 || last.Completed != 2 {
+		t.Errorf("最終の進捗が一致しない: %+v", last)
+	}
+	var urls []string
+	for _, job := range last.Jobs {
+		urls = append(urls, job.OutputURL)
+	}
+	if want := []string{"https://cdn.example.com/360p.mp4", "https://cdn.example.com/720p.mp4"}; !reflect.DeepEqual(urls, want) {
+		t.Errorf("出力URLが一致しない: 期待値 %v, 取得値 %v", want, urls)
+	}
+
+	if _, running := gm.GetProgressChannel("group-1"); running {
+		t.Error("終了したグループの進捗チャネルが取得できる")
+	}
+	if progress, ok := gm.Get("group-1"); !ok || progress.Status != JobGroupStatusCompleted {
+		t.Errorf("終了したグループの最終ステータスが取得できない: %+v", progress)
+	}
+}
+
+func Testジョブグループで一部のジョブが失敗すると部分的な完了になる(t *testing.T) {
+	gm := newTestJobGroup(JobGroupOnFailureContinue)
+
+	toCancel := gm.RecordProgress("job-360p", &workerv1.JobProgress{Status: workerv1.JobStatus_JOB_STATUS_FAILED, Error: "ffmpeg exited with status 1"})
+	if len(toCancel) != 0 {
+		t.Errorf("continue のグループでキャンセル対象が返された: %v", toCancel)
+	}
+	if progress, _ := gm.Get("group-1"); progress.Status != JobGroupStatusRunning {
+		t.Errorf("残りのジョブの実行中にステータスが変わった: %s", progress.Status)
+	}
+
+	gm.RecordProgress("job-720p", &workerv1.JobProgress{Status: workerv1.JobStatus_JOB_STATUS_COMPLETED, Progress: 100})
+	progress, _ := gm.Get("group-1")
+	if progress.Status != JobGroupStatusPartiallyCompleted || progress.Completed != 1 || progress.Failed != 1 {
+		t.Errorf("部分的な完了の進捗が一致しない: %+v", progress)
+	}
+	if progress.Jobs[0].Error != "ffmpeg exited with status 1" {
+		t.Errorf("失敗したジョブのエラーが一致しない: %s", progress.Jobs[0].Error)
+	}
+}
+
+func Testキャンセルのグループで失敗すると実行中のジョブがキャンセル対象になる(t *testing.T) {
+	gm := newTestJobGroup(JobGroupOnFailureCancel)
+
+	toCancel := gm.RecordProgress("job-360p", &workerv1.JobProgress{Status: workerv1.JobStatus_JOB_STATUS_FAILED})
+	if want := []string{"job-720p"}; !reflect.DeepEqual(toCancel, want) {
+		t.Errorf("キャンセル対象が一致しない: 期待値 %v, 取得値 %v", want, toCancel)
+	}
+
+	// キャンセルされたジョブの失敗では再度キャンセルしない
+	toCancel = gm.RecordProgress("job-720p", &workerv1.JobProgress{Status: workerv1.JobStatus_JOB_STATUS_FAILED, Error: "context canceled"})
+	if len(toCancel) != 0 {
+		t.Errorf("2回目の失敗でキャンセル対象が返された: %v", toCancel)
+	}
+	if progress, _ := gm.Get("group-1"); progress.Status != JobGroupStatusFailed {
+		t.Errorf("ステータスが一致しない: 期待値 %s, 取得値 %s", JobGroupStatusFailed, progress.Status)
+	}
+}
+
+func Test終了ステータスなしでストリームが閉じたジョブは失敗として扱われる(t *testing.T) {
+	gm := newTestJobGroup(JobGroupOnFailureContinue)
+
+	gm.RecordProgress("job-360p", &workerv1.JobProgress{Status: workerv1.JobStatus_JOB_STATUS_COMPLETED, Progress: 100})
+	gm.CloseJob("job-360p")
+	gm.CloseJob("job-720p")
+
+	progress, _ := gm.Get("group-1")
+	if progress.Status != JobGroupStatusPartiallyCompleted {
+		t.Errorf("ステータスが一致しない: 期待値 %s, 取得値 %s", JobGroupStatusPartiallyCompleted, progress.Status)
+	}
+	if progress.Jobs[0].Status != "JOB_STATUS_COMPLETED" || progress.Jobs[1].Status != "JOB_STATUS_FAILED" {
+		t.Errorf("ジョブのステータスが一致しない: %+v", progress.Jobs)
+	}
+}
@@ -2,33 +2,99 @@ package api
 
 import (
 	"sync"
+	"time"
 
 	workerv1 "github.com/nzws/flux-encoder/proto/worker/v1"
 )
 
+// DefaultJobStatusTTL はジョブ終了後に最終ステータスを保持するデフォルトの期間
+const DefaultJobStatusTTL = time.Hour
+
+// ProgressHistorySize はジョブごとに保持する直近の進捗の件数
+// SSE の接続時に再送するため、接続が遅れたクライアントも受付直後の QUEUED・PROCESSING を受け取れる
+const ProgressHistorySize = 32
+
+// progressChannelSize はジョブごとの進捗チャネルに SSE のクライアントが読み出すまで溜めておける件数
+const progressChannelSize = 100
+
+// jobStatus はジョブの最新の進捗と保持期限
+type jobStatus struct {
+	progress *workerv1.JobProgress
+	// history は直近の進捗（古い順、最大 ProgressHistorySize 件）
+	history []*workerv1.JobProgress
+	// expiresAt は保持期限。ゼロ値の場合はジョブ実行中で期限なし
+	expiresAt time.Time
+}
+
 // JobManager はジョブの進捗を管理する
 type JobManager struct {
-	jobs  map[string]chan *workerv1.JobProgress
-	mutex sync.RWMutex
+	jobs     map[string]chan *workerv1.JobProgress
+	statuses map[string]*jobStatus
+	// workers は実行中のジョブを送信した Worker のアドレス（キャンセルの転送先）
+	workers map[string]string
+	// cancelled はキャンセルを Worker に転送した実行中のジョブ
+	cancelled map[string]bool
+	ttl       time.Duration
+	now       func() time.Time
+	mutex     sync.RWMutex
 }
 
 // NewJobManager は新しい JobManager を作成する
 func NewJobManager() *JobManager {
 	return &JobManager{
-		jobs: make(map[string]chan *workerv1.JobProgress),
+		jobs:      make(map[string]chan *workerv1.JobProgress),
+		statuses:  make(map[string]*jobStatus),
+		workers:   make(map[string]string),
+		cancelled: make(map[string]bool),
+		ttl:       DefaultJobStatusTTL,
+		now:       time.Now,
 	}
 }
 
+// SetStatusTTL はジョブ終了後に最終ステータスを保持する期間を設定する
+func (jm *JobManager) SetStatusTTL(ttl time.Duration) {
+	jm.mutex.Lock()
+	defer jm.mutex.Unlock()
+
+	jm.ttl = ttl
+}
+
+// StatusTTL はジョブ終了後に最終ステータスを保持する期間を返す
+func (jm *JobManager) StatusTTL() time.Duration {
+	jm.mutex.RLock()
+	defer jm.mutex.RUnlock()
+
+	return jm.ttl
+}
+
 // CreateProgressChannel は新しい進捗チャネルを作成する
 func (jm *JobManager) CreateProgressChannel(jobID string) chan *workerv1.JobProgress {
 	jm.mutex.Lock()
 	defer jm.mutex.Unlock()
 
-	ch := make(chan *workerv1.JobProgress, 100)
+	ch := make(chan *workerv1.JobProgress, progressChannelSize)
 	jm.jobs[jobID] = ch
 	return ch
 }
 
+// publishProgress は進捗をチャネルに送信する
+// SSE のクライアントが接続していない・読み出しが遅いためにチャネルが満杯の場合は、最も古い進捗を捨てて送信する
+// （Worker からの受信を止めると Worker 側の送信と ffmpeg も止まり、callback_url への通知も届かなくなるため）
+// 送信するのはジョブごとに1つのゴルーチンのみのため、古い進捗を捨てれば必ず送信できる
+func publishProgress(ch chan *workerv1.JobProgress, progress *workerv1.JobProgress) {
+	for {
+		select {
+		case ch <- progress:
+			return
+		default:
+			select {
+			case <-ch:
+			default:
+			}
+		}
+	}
+}
+
 // GetProgressChannel は進捗チャネルを取得する
 func (jm *JobManager) GetProgressChannel(jobID string) (chan *workerv1.JobProgress, bool) {
 	jm.mutex.RLock()
@@ -39,6 +105,7 @@ func (jm *JobManager) GetProgressChannel(jobID string) (chan *workerv1.JobProgre
 }
 
 // CloseProgressChannel は進捗チャネルを閉じて削除する
+// 最新の進捗は TTL の間保持される
 func (jm *JobManager) CloseProgressChannel(jobID string) {
 	jm.mutex.Lock()
 	defer jm.mutex.Unlock()
@@ -47,4 +114,111 @@ func (jm *JobManager) CloseProgressChannel(jobID string) {
 		close(ch)
 		delete(jm.jobs, jobID)
 	}
+	delete(jm.workers, jobID)
+	delete(jm.cancelled, jobID)
+
+	now := jm.now()
+	if status, exists := jm.statuses[jobID]; exists {
+		status.expiresAt = now.Add(jm.ttl)
+	}
+	jm.pruneStatusesLocked(now)
+}
+
+// SetWorker はジョブを送信した Worker のアドレスを記録する
+// 記録は CloseProgressChannel で削除される
+func (jm *JobManager) SetWorker(jobID, workerAddr string) {
+	jm.mutex.Lock()
+	defer jm.mutex.Unlock()
+
+	jm.workers[jobID] = workerAddr
+}
+
+// GetWorker は実行中のジョブを送信した Worker のアドレスを取得する
+func (jm *JobManager) GetWorker(jobID string) (string, bool) {
+	jm.mutex.RLock()
+	defer jm.mutex.RUnlock()
+
+	addr, exists := jm.workers[jobID]
+	return addr, exists
+}
+
+// SetCancelled は実行中のジョブをキャンセル済みとして記録する（false の場合は記録を取り消す）
+// 記録は CloseProgressChannel で削除される
+func (jm *JobManager) SetCancelled(jobID string, cancelled bool) {
+	jm.mutex.Lock()
+	defer jm.mutex.Unlock()
+
+	if cancelled {
+		jm.cancelled[jobID] = true
+		return
+	}
+	delete(jm.cancelled, jobID)
+}
+
+// IsCancelled はジョブのキャンセルを Worker に転送したかを返す
+func (jm *JobManager) IsCancelled(jobID string) bool {
+	jm.mutex.RLock()
+	defer jm.mutex.RUnlock()
+
+	return jm.cancelled[jobID]
+}
+
+// RecordProgress はジョブの最新の進捗を記録し、直近の進捗の履歴に追加する
+// 履歴が ProgressHistorySize 件を超えた場合は古いものから削除する
+func (jm *JobManager) RecordProgress(jobID string, progress *workerv1.JobProgress) {
+	jm.mutex.Lock()
+	defer jm.mutex.Unlock()
+
+	status, exists := jm.statuses[jobID]
+	if !exists || jm.isExpired(status, jm.now()) {
+		status = &jobStatus{}
+		jm.statuses[jobID] = status
+	}
+	status.progress = progress
+	status.expiresAt = time.Time{}
+	if len(status.history) >= ProgressHistorySize {
+		status.history = append(status.history[:0], status.history[len(status.history)-ProgressHistorySize+1:]...)
+	}
+	status.history = append(status.history, progress)
+}
+
+// GetProgressHistory はジョブの直近の進捗を古い順に返す（記録がない場合や保持期限を過ぎた場合は nil）
+func (jm *JobManager) GetProgressHistory(jobID string) []*workerv1.JobProgress {
+	jm.mutex.RLock()
+	defer jm.mutex.RUnlock()
+
+	status, exists := jm.statuses[jobID]
+	if !exists || jm.isExpired(status, jm.now()) {
+		return nil
+	}
+	history := make([]*workerv1.JobProgress, len(status.history))
+	copy(history, status.history)
+	return history
+}
+
+// GetLastProgress はジョブの最新の進捗を取得する
+// 記録がない場合や保持期限を過ぎた場合は false を返す
+func (jm *JobManager) GetLastProgress(jobID string) (*workerv1.JobProgress, bool) {
+	jm.mutex.RLock()
+	defer jm.mutex.RUnlock()
+
+	status, exists := jm.statuses[jobID]
+	if !exists || jm.isExpired(status, jm.now()) {
+		return nil, false
+	}
+	return status.progress, true
+}
+
+// isExpired は保持期限を過ぎているかどうかを返す
+func (jm *JobManager) isExpired(status *jobStatus, now time.Time) bool {
+	return !status.expiresAt.IsZero() && !now.Before(status.expiresAt)
+}
+
+// pruneStatusesLocked は保持期限を過ぎたステータスを削除する（mutex を保持した状態で呼ぶ）
+func (jm *JobManager) pruneStatusesLocked(now time.Time) {
+	for jobID, status := range jm.statuses {
+		if jm.isExpired(status, now) {
+			delete(jm.statuses, jobID)
+		}
+	}
 }
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"sync"
 	"testing"
+	"time"
 
 	workerv1 "github.com/nzws/flux-encoder/proto/worker/v1"
 )
@@ -102,6 +103,23 @@ func Testチャネルのバッファ容量が100である(t *testing.T) {
 	}
 }
 
+func Testチャネルが満杯の場合は最も古い進捗を捨てて送信する(t *testing.T) {
+	jm := NewJobManager()
+	ch := jm.CreateProgressChannel("test-job-publish")
+
+	// 読み出すクライアントがいなくても容量を超えて送信できる
+	for i := 0; i < progressChannelSize+10; i++ {
+		publishProgress(ch, &workerv1.JobProgress{Progress: float32(i)})
+	}
+
+	if len(ch) != progressChannelSize {
+		t.Fatalf("チャネル内の件数が一致しない: 期待値 %d, 取得値 %d", progressChannelSize, len(ch))
+	}
+	if first := <-ch; first.Progress != 10 {
+		t.Errorf("最も古い進捗が一致しない: 期待値 10, 取得値 %v", first.Progress)
+	}
+}
+
 func Test並行処理で複数のジョブを作成できる(t *testing.T) {
 	jm := NewJobManager()
 	numJobs := 100
@@ -316,3 +334,89 @@ func Test特殊文字を含むメッセージがJSON化できる(t *testing.T) {
 		})
 	}
 }
+
+func Test最新の進捗がチャネルのクローズ後も取得できる(t *testing.T) {
+	jm := NewJobManager()
+	jobID := "test-job-status"
+
+	jm.CreateProgressChannel(jobID)
+	jm.RecordProgress(jobID, &workerv1.JobProgress{JobId: jobID, Status: workerv1.JobStatus_JOB_STATUS_PROCESSING, Progress: 50})
+	jm.RecordProgress(jobID, &workerv1.JobProgress{JobId: jobID, Status: workerv1.JobStatus_JOB_STATUS_COMPLETED, Progress: 100, OutputUrl: "https://example.com/out.mp4"})
+	jm.CloseProgressChannel(jobID)
+
+	progress, exists := jm.GetLastProgress(jobID)
+	if !exists {
+		t.Fatal("クローズ後に最新の進捗が取得できない")
+	}
+	if progress.Status != workerv1.JobStatus_JOB_STATUS_COMPLETED {
+		t.Errorf("ステータスが一致しない: 期待値 %v, 取得値 %v", workerv1.JobStatus_JOB_STATUS_COMPLETED, progress.Status)
+	}
+	if progress.OutputUrl != "https://example.com/out.mp4" {
+		t.Errorf("OutputUrl が一致しない: 取得値 %s", progress.OutputUrl)
+	}
+}
+
+func Test保持期限を過ぎた進捗は取得できない(t *testing.T) {
+	jm := NewJobManager()
+	jm.SetStatusTTL(time.Minute)
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	jm.now = func() time.Time { return now }
+
+	jobID := "test-job-expired"
+	jm.CreateProgressChannel(jobID)
+	jm.RecordProgress(jobID, &workerv1.JobProgress{JobId: jobID, Status: workerv1.JobStatus_JOB_STATUS_FAILED})
+
+	// 実行中は期限切れにならない
+	now = now.Add(time.Hour)
+	if _, exists := jm.GetLastProgress(jobID); !exists {
+		t.Fatal("実行中のジョブの進捗が取得できない")
+	}
+
+	jm.CloseProgressChannel(jobID)
+	now = now.Add(59 * time.Second)
+	if _, exists := jm.GetLastProgress(jobID); !exists {
+		t.Error("保持期限内の進捗が取得できない")
+	}
+
+	now = now.Add(time.Second)
+	if _, exists := jm.GetLastProgress(jobID); exists {
+		t.Error("保持期限を過ぎた進捗が取得できた")
+	}
+
+	// 別のジョブのクローズ時に期限切れのステータスが削除される
+	jm.CreateProgressChannel("other-job")
+	jm.CloseProgressChannel("other-job")
+	if _, exists := jm.statuses[jobID]; exists {
+		t.Error("期限切れのステータスが削除されていない")
+	}
+}
+
+func Test記録のないジョブの進捗は取得できない(t *testing.T) {
+	jm := NewJobManager()
+
+	if _, exists := jm.GetLastProgress("存在しないジョブID"); exists {
+		t.Error("記録のないジョブで exists が true になった")
+	}
+}
+
+func Test直近の進捗の履歴が古い順に上限件数まで保持される(t *testing.T) {
+	jm := NewJobManager()
+	jobID := "test-job-history"
+
+	jm.CreateProgressChannel(jobID)
+	for i := 0; i < ProgressHistorySize+5; i++ {
+		jm.RecordProgress(jobID, &workerv1.JobProgress{JobId: jobID, Status: workerv1.JobStatus_JOB_STATUS_PROCESSING, Progress: float32(i)})
+	}
+
+	history := jm.GetProgressHistory(jobID)
+	if len(history) != ProgressHistorySize {
+		t.Fatalf("履歴の件数が一致しない: 期待値 %d, 取得値 %d", ProgressHistorySize, len(history))
+	}
+	if history[0].Progress != 5 || history[len(history)-1].Progress != float32(ProgressHistorySize+4) {
+		t.Errorf("履歴が古いものから削除されていない: 先頭 %v, 末尾 %v", history[0].Progress, history[len(history)-1].Progress)
+	}
+
+	if history := jm.GetProgressHistory("存在しないジョブID"); history != nil {
+		t.Errorf("記録のないジョブで履歴が返された: %v", history)
+	}
+}
@@ -0,0 +1,200 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	workerv1 "github.com/nzws/flux-encoder/proto/worker/v1"
+)
+
+// ErrJobStateNotFound はジョブの状態が保存されていない場合のエラー
+var ErrJobStateNotFound = errors.New("job state not found")
+
+// jobStateFileSuffix は FileJobStore が保存するファイルの拡張子
+const jobStateFileSuffix = ".json"
+
+// JobState は永続化するジョブの状態
+// 再起動後も GET /jobs/:id/outputs で返せるよう、完了時にアップロードしたファイルの一覧も保存する
+type JobState struct {
+	JobID       string          `json:"job_id"`
+	Status      string          `json:"status"`
+	Progress    float32         `json:"progress"`
+	Message     string          `json:"message"`
+	OutputURL   string          `json:"output_url,omitempty"`
+	Error       string          `json:"error,omitempty"`
+	ErrorCode   string          `json:"error_code,omitempty"`
+	OutputFiles []JobOutputFile `json:"output_files,omitempty"`
+	// FFmpegCommand は Worker が実行した ffmpeg のコマンド（認証情報は取り除かれている）
+	FFmpegCommand []string  `json:"ffmpeg_command,omitempty"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// newJobState は進捗から永続化する状態を作成する
+func newJobState(jobID string, progress *workerv1.JobProgress, now time.Time) JobState {
+	return JobState{
+		JobID:         jobID,
+		Status:        progress.Status.String(),
+		Progress:      progress.Progress,
+		Message:       progress.Message,
+		OutputURL:     progress.OutputUrl,
+		Error:         progress.Error,
+		ErrorCode:     progress.ErrorCode,
+		OutputFiles:   outputFilesFromProto(progress.OutputFiles),
+		FFmpegCommand: progress.FfmpegCommand,
+		UpdatedAt:     now,
+	}
+}
+
+// toProgress は永続化した状態を進捗に戻す
+func (s JobState) toProgress() *workerv1.JobProgress {
+	return &workerv1.JobProgress{
+		JobId:         s.JobID,
+		Status:        workerv1.JobStatus(workerv1.JobStatus_value[s.Status]),
+		Progress:      s.Progress,
+		Message:       s.Message,
+		OutputUrl:     s.OutputURL,
+		Error:         s.Error,
+		ErrorCode:     s.ErrorCode,
+		OutputFiles:   outputFilesToProto(s.OutputFiles),
+		FfmpegCommand: s.FFmpegCommand,
+		Timestamp:     s.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+// isFinished はジョブが終了した状態（完了または失敗）かどうかを返す
+func (s JobState) isFinished() bool {
+	return s.Status == workerv1.JobStatus_JOB_STATUS_COMPLETED.String() ||
+		s.Status == workerv1.JobStatus_JOB_STATUS_FAILED.String()
+}
+
+// JobStore はジョブの状態の保存先
+// Control Plane の再起動後も GET /jobs/:id と SSE で最終ステータスを返せるようにする
+type JobStore interface {
+	// Save はジョブの状態を保存する（同じ JobID の場合は上書きする）
+	Save(jobID string, state JobState) error
+	// Load は JobID に対応するジョブの状態を取得する
+	Load(jobID string) (JobState, error)
+}
+
+// NopJobStore は何も保存しない JobStore（永続化を無効にする場合に使用する）
+type NopJobStore struct{}
+
+// Save は何もしない
+func (NopJobStore) Save(jobID string, state JobState) error {
+	return nil
+}
+
+// Load は常に ErrJobStateNotFound を返す
+func (NopJobStore) Load(jobID string) (JobState, error) {
+	return JobState{}, ErrJobStateNotFound
+}
+
+// FileJobStore はジョブごとに JSON ファイルとして状態を保存する JobStore
+type FileJobStore struct {
+	dir string
+}
+
+// NewFileJobStore は新しい FileJobStore を作成する
+func NewFileJobStore(dir string) (*FileJobStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create job state directory: %w", err)
+	}
+
+	return &FileJobStore{dir: dir}, nil
+}
+
+// Save はジョブの状態をファイルに書き込む
+// 書き込み途中で停止しても壊れたファイルが残らないよう、一時ファイルに書き込んでから置き換える
+func (s *FileJobStore) Save(jobID string, state JobState) error {
+	path, err := s.path(jobID)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to marshal job state: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(s.dir, jobID+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create job state file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("failed to write job state: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("failed to close job state file: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("failed to save job state: %w", err)
+	}
+	return nil
+}
+
+// Load はファイルからジョブの状態を読み込む
+func (s *FileJobStore) Load(jobID string) (JobState, error) {
+	path, err := s.path(jobID)
+	if err != nil {
+		return JobState{}, err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return JobState{}, ErrJobStateNotFound
+	}
+	if err != nil {
+		return JobState{}, fmt.Errorf("failed to read job state: %w", err)
+	}
+
+	var state JobState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return JobState{}, fmt.Errorf("failed to parse job state: %w", err)
+	}
+	return state, nil
+}
+
+// Prune は before より前に更新されたジョブの状態を削除し、削除した件数を返す
+func (s *FileJobStore) Prune(before time.Time) (int, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read job state directory: %w", err)
+	}
+
+	removed := 0
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, jobStateFileSuffix) {
+			continue
+		}
+		state, err := s.Load(strings.TrimSuffix(name, jobStateFileSuffix))
+		if err != nil || !state.UpdatedAt.Before(before) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(s.dir, name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return removed, fmt.Errorf("failed to remove job state: %w", err)
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// path は JobID に対応するファイルのパスを返す
+// JobID は Control Plane が生成する UUID のみを受け付ける（パスの組み立てに任意の文字列を使わない）
+func (s *FileJobStore) path(jobID string) (string, error) {
+	if _, err := uuid.Parse(jobID); err != nil {
+		return "", ErrJobStateNotFound
+	}
+	return filepath.Join(s.dir, jobID+jobStateFileSuffix), nil
+}
@@ -0,0 +1,114 @@
+package api
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	workerv1 "github.com/nzws/flux-encoder/proto/worker/v1"
+)
+
+func newTestFileJobStore(t *testing.T) *FileJobStore {
+	t.Helper()
+
+	store, err := NewFileJobStore(filepath.Join(t.TempDir(), "jobs"))
+	if err != nil {
+		t.Fatalf("FileJobStore の作成に失敗: %v", err)
+	}
+	return store
+}
+
+func TestFileJobStoreで保存した状態を読み込める(t *testing.T) {
+	store := newTestFileJobStore(t)
+	jobID := uuid.New().String()
+	updatedAt := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	state := newJobState(jobID, &workerv1.JobProgress{
+		Status:    workerv1.JobStatus_JOB_STATUS_COMPLETED,
+		Progress:  100,
+		Message:   "Job completed",
+		OutputUrl: "https://example.com/out.mp4",
+		OutputFiles: []*workerv1.OutputFile{
+			{Path: "out.mp4", Url: "https://example.com/out.mp4"},
+			{Path: "thumbnail.jpg", Url: "https://example.com/thumbnail.jpg"},
+		},
+	}, updatedAt)
+	if err := store.Save(jobID, state); err != nil {
+		t.Fatalf("状態の保存に失敗: %v", err)
+	}
+
+	loaded, err := store.Load(jobID)
+	if err != nil {
+		t.Fatalf("状態の読み込みに失敗: %v", err)
+	}
+	if !reflect.DeepEqual(loaded, state) {
+		t.Errorf("状態が一致しない: 期待値 %+v, 取得値 %+v", state, loaded)
+	}
+
+	progress := loaded.toProgress()
+	if progress.Status != workerv1.JobStatus_JOB_STATUS_COMPLETED || progress.OutputUrl != "https://example.com/out.mp4" || len(progress.OutputFiles) != 2 {
+		t.Errorf("進捗に戻した値が一致しない: %+v", progress)
+	}
+
+	// 上書きできる
+	state.Status = workerv1.JobStatus_JOB_STATUS_FAILED.String()
+	if err := store.Save(jobID, state); err != nil {
+		t.Fatalf("状態の上書きに失敗: %v", err)
+	}
+	if loaded, _ := store.Load(jobID); loaded.Status != state.Status {
+		t.Errorf("上書きした状態が一致しない: 期待値 %s, 取得値 %s", state.Status, loaded.Status)
+	}
+}
+
+func TestFileJobStoreで保存されていないジョブはErrJobStateNotFoundを返す(t *testing.T) {
+	store := newTestFileJobStore(t)
+
+	for _, jobID := range []string{uuid.New().String(), "../../etc/passwd", ""} {
+		if _, err := store.Load(jobID); !errors.Is(err, ErrJobStateNotFound) {
+			t.Errorf("%q で ErrJobStateNotFound が返されない: %v", jobID, err)
+		}
+	}
+	if err := store.Save("../escape", JobState{}); !errors.Is(err, ErrJobStateNotFound) {
+		t.Errorf("不正な JobID で保存できてしまう: %v", err)
+	}
+}
+
+func TestFileJobStoreのPruneで古い状態が削除される(t *testing.T) {
+	store := newTestFileJobStore(t)
+	now := time.Now()
+	oldID, newID := uuid.New().String(), uuid.New().String()
+
+	if err := store.Save(oldID, JobState{JobID: oldID, UpdatedAt: now.Add(-2 * time.Hour)}); err != nil {
+		t.Fatalf("状態の保存に失敗: %v", err)
+	}
+	if err := store.Save(newID, JobState{JobID: newID, UpdatedAt: now}); err != nil {
+		t.Fatalf("状態の保存に失敗: %v", err)
+	}
+
+	removed, err := store.Prune(now.Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("Prune に失敗: %v", err)
+	}
+	if removed != 1 {
+		t.Errorf("削除した件数が一致しない: 期待値 1, 取得値 %d", removed)
+	}
+	if _, err := store.Load(oldID); !errors.Is(err, ErrJobStateNotFound) {
+		t.Errorf("古い状態が削除されていない: %v", err)
+	}
+	if _, err := store.Load(newID); err != nil {
+		t.Errorf("新しい状態が削除された: %v", err)
+	}
+
+	// 一時ファイルが残っていない
+	entries, err := os.ReadDir(store.dir)
+	if err != nil {
+		t.Fatalf("ディレクトリの読み込みに失敗: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("ファイル数が一致しない: 期待値 1, 取得値 %d", len(entries))
+	}
+}
@@ -0,0 +1,154 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nzws/flux-encoder/internal/controlplane/auth"
+	"github.com/nzws/flux-encoder/internal/controlplane/balancer"
+)
+
+// newPresetAllowlistRouter は API_KEYS で認証し、restricted キーのプリセットを制限したルーターを作成する
+func newPresetAllowlistRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	t.Setenv("API_KEYS", "restricted:key-restricted,admin:key-admin")
+
+	allowlist, err := auth.ParsePresetAllowlist("restricted:720p_h264|hls_720p")
+	if err != nil {
+		t.Fatalf("API_KEY_PRESETS の解析に失敗: %v", err)
+	}
+	handler := NewHandler(balancer.New([]string{startMockWorker(t, &failingWorker{})}, time.Second))
+	handler.SetPresetAllowlist(allowlist)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(auth.APIKeyMiddleware())
+	router.POST("/api/v1/jobs", handler.CreateJob)
+	router.GET("/api/v1/presets", handler.ListPresets)
+	router.GET("/api/v1/presets/usage", handler.GetPresetUsage)
+	return router
+}
+
+func requestWithKey(router *gin.Engine, method, path, apiKey, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+apiKey)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func Test制限されたキーは許可されていないプリセットのジョブを作成できない(t *testing.T) {
+	router := newPresetAllowlistRouter(t)
+
+	tests := []struct {
+		name   string
+		apiKey string
+		body   string
+		want   int
+	}{
+		{
+			name:   "制限されたキーで許可されたプリセット",
+			apiKey: "key-restricted",
+			body:   `{"input_url":"https://example.com/video.mp4","preset":"720p_h264","output":{"storage":"local","path":"out.mp4"}}`,
+			want:   http.StatusAccepted,
+		},
+		{
+			name:   "制限されたキーで許可されていないプリセット",
+			apiKey: "key-restricted",
+			body:   `{"input_url":"https://example.com/video.mp4","preset":"1080p_h264","output":{"storage":"local","path":"out.mp4"}}`,
+			want:   http.StatusForbidden,
+		},
+		{
+			name:   "制限されたキーで許可されていないフォールバック",
+			apiKey: "key-restricted",
+			body:   `{"input_url":"https://example.com/video.mp4","preset":"720p_h264","fallback_preset":"1080p_h264","output":{"storage":"local","path":"out.mp4"}}`,
+			want:   http.StatusForbidden,
+		},
+		{
+			name:   "管理者のキーで許可されていないプリセット",
+			apiKey: "key-admin",
+			body:   `{"input_url":"https://example.com/video.mp4","preset":"1080p_h264","output":{"storage":"local","path":"out.mp4"}}`,
+			want:   http.StatusAccepted,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := requestWithKey(router, http.MethodPost, "/api/v1/jobs", tt.apiKey, tt.body)
+			if w.Code != tt.want {
+				t.Errorf("ステータスコードが一致しない: 期待値 %d, 取得値 %d (%s)", tt.want, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func Test制限されたキーにはプリセット一覧で許可されたプリセットのみ返る(t *testing.T) {
+	router := newPresetAllowlistRouter(t)
+
+	names := func(apiKey string) []string {
+		w := requestWithKey(router, http.MethodGet, "/api/v1/presets", apiKey, "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("ステータスコードが一致しない: 期待値 %d, 取得値 %d", http.StatusOK, w.Code)
+		}
+		var presets []PresetResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &presets); err != nil {
+			t.Fatalf("レスポンスのパースに失敗: %v", err)
+		}
+		result := make([]string, len(presets))
+		for i, p := range presets {
+			result[i] = p.Name
+		}
+		return result
+	}
+
+	if got := strings.Join(names("key-restricted"), ","); got != "720p_h264,hls_720p" {
+		t.Errorf("制限されたキーのプリセットが一致しない: 期待値 720p_h264,hls_720p, 取得値 %s", got)
+	}
+	if got := names("key-admin"); len(got) <= 2 {
+		t.Errorf("管理者のキーにすべてのプリセットが返らない: %v", got)
+	}
+}
+
+func Test制限されたキーにはプリセットの利用数で許可されたプリセットのみ返る(t *testing.T) {
+	router := newPresetAllowlistRouter(t)
+
+	bodies := []string{
+		`{"input_url":"https://example.com/a.mp4","preset":"720p_h264","output":{"storage":"local","path":"a.mp4"}}`,
+		`{"input_url":"https://example.com/b.mp4","preset":"1080p_h264","output":{"storage":"local","path":"b.mp4"}}`,
+		`{"input_url":"https://example.com/c.mp4","raw_ffmpeg_args":["-c:v","libx264"],"output":{"storage":"local","path":"c.mp4"}}`,
+	}
+	for _, body := range bodies {
+		if w := requestWithKey(router, http.MethodPost, "/api/v1/jobs", "key-admin", body); w.Code != http.StatusAccepted {
+			t.Fatalf("ステータスコードが一致しない: 期待値 %d, 取得値 %d (%s)", http.StatusAccepted, w.Code, w.Body.String())
+		}
+	}
+
+	presets := func(apiKey string) string {
+		w := requestWithKey(router, http.MethodGet, "/api/v1/presets/usage", apiKey, "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("ステータスコードが一致しない: 期待値 %d, 取得値 %d", http.StatusOK, w.Code)
+		}
+		var usage []PresetUsage
+		if err := json.Unmarshal(w.Body.Bytes(), &usage); err != nil {
+			t.Fatalf("レスポンスのパースに失敗: %v", err)
+		}
+		names := make([]string, len(usage))
+		for i, u := range usage {
+			names[i] = u.Preset
+		}
+		return strings.Join(names, ",")
+	}
+
+	if got := presets("key-restricted"); got != "720p_h264" {
+		t.Errorf("制限されたキーのプリセットが一致しない: 期待値 720p_h264, 取得値 %s", got)
+	}
+	if got := presets("key-admin"); got != "1080p_h264,720p_h264,raw" {
+		t.Errorf("管理者のキーのプリセットが一致しない: 期待値 1080p_h264,720p_h264,raw, 取得値 %s", got)
+	}
+}
@@ -0,0 +1,168 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nzws/flux-encoder/internal/controlplane/auth"
+	"github.com/nzws/flux-encoder/internal/shared/logger"
+	"github.com/nzws/flux-encoder/internal/shared/metrics"
+	"github.com/nzws/flux-encoder/internal/worker/preset"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultPresetUsageMaxLabels は組み込み以外のプリセットとして個別に集計する名前の数の上限のデフォルト値
+	DefaultPresetUsageMaxLabels = 50
+
+	// presetUsageOtherLabel は上限を超えた組み込み以外のプリセットをまとめて集計する名前
+	presetUsageOtherLabel = "other"
+	// presetUsageRawLabel はプリセットの代わりに raw_ffmpeg_args を指定したジョブを集計する名前
+	presetUsageRawLabel = "raw"
+)
+
+// PresetUsageStore はプリセットごとのジョブ数の保存先
+// Control Plane の複数インスタンスで集計を共有する場合は Redis などの外部ストアで実装する
+type PresetUsageStore interface {
+	// Increment は preset のジョブ数を 1 増やす
+	Increment(preset string) error
+	// Counts はプリセットごとのジョブ数を返す
+	Counts() (map[string]int64, error)
+}
+
+// MemoryPresetUsageStore はメモリ上にプリセットごとのジョブ数を保持する PresetUsageStore
+// Control Plane を再起動すると集計はリセットされる
+type MemoryPresetUsageStore struct {
+	counts map[string]int64
+	mutex  sync.Mutex
+}
+
+// NewMemoryPresetUsageStore は新しい MemoryPresetUsageStore を作成する
+func NewMemoryPresetUsageStore() *MemoryPresetUsageStore {
+	return &MemoryPresetUsageStore{counts: make(map[string]int64)}
+}
+
+// Increment は preset のジョブ数を 1 増やす
+func (s *MemoryPresetUsageStore) Increment(preset string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.counts[preset]++
+	return nil
+}
+
+// Counts はプリセットごとのジョブ数のコピーを返す
+func (s *MemoryPresetUsageStore) Counts() (map[string]int64, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	counts := make(map[string]int64, len(s.counts))
+	for name, count := range s.counts {
+		counts[name] = count
+	}
+	return counts, nil
+}
+
+// presetUsageLabeler は集計に使うプリセットの名前を決める
+// Control Plane はカスタムプリセット（Worker の PRESETS_FILE）を知らないため、任意の名前をそのまま集計すると
+// Prometheus のラベルの種類が際限なく増える。組み込みのプリセット以外は最初の maxLabels 種類のみ個別に集計し、以降は other にまとめる
+type presetUsageLabeler struct {
+	maxLabels int
+	seen      map[string]bool
+	mutex     sync.Mutex
+}
+
+func newPresetUsageLabeler(maxLabels int) *presetUsageLabeler {
+	return &presetUsageLabeler{maxLabels: maxLabels, seen: make(map[string]bool)}
+}
+
+// label は name を集計する名前を返す
+func (l *presetUsageLabeler) label(name string) string {
+	if preset.Exists(name) {
+		return name
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.seen[name] {
+		return name
+	}
+	if len(l.seen) >= l.maxLabels {
+		return presetUsageOtherLabel
+	}
+	l.seen[name] = true
+	return name
+}
+
+// SetPresetUsageStore はプリセットごとのジョブ数の保存先を設定する
+func (h *Handler) SetPresetUsageStore(store PresetUsageStore) {
+	h.presetUsage = store
+}
+
+// SetPresetUsageMaxLabels は組み込み以外のプリセットとして個別に集計する名前の数の上限を設定する（0 以下の場合は組み込みのプリセット以外をすべて other にまとめる）
+func (h *Handler) SetPresetUsageMaxLabels(maxLabels int) {
+	h.presetUsageLabels = newPresetUsageLabeler(max(maxLabels, 0))
+}
+
+// recordPresetUsage は Worker に送信したジョブのプリセットを集計する
+// フォールバックした場合は実際に送信したプリセット、raw_ffmpeg_args を指定した場合は raw として集計する
+func (h *Handler) recordPresetUsage(jobID, presetName string, req JobRequest) {
+	name := presetUsageRawLabel
+	if len(req.RawFFmpegArgs) == 0 {
+		name = h.presetUsageLabels.label(presetName)
+	}
+
+	metrics.PresetUsage.WithLabelValues(name).Inc()
+	if err := h.presetUsage.Increment(name); err != nil {
+		logger.Warn("Failed to record preset usage",
+			zap.String("job_id", jobID),
+			zap.String("preset", name),
+			zap.Error(err),
+		)
+	}
+}
+
+// PresetUsage はプリセットのジョブ数
+type PresetUsage struct {
+	// Preset はプリセットの名前（raw_ffmpeg_args のジョブは raw、集計する名前の数の上限を超えたプリセットは other）
+	Preset string `json:"preset" example:"720p_h264"`
+	Jobs   int64  `json:"jobs" example:"42"`
+}
+
+// GetPresetUsage はプリセットごとのジョブ数を返す
+// @Summary Get preset usage
+// @Description Count the jobs dispatched to Workers per preset since the Control Plane started, most used first. Jobs with raw_ffmpeg_args are counted as "raw". Non built-in presets beyond PRESET_USAGE_MAX_LABELS distinct names are counted as "other". API keys restricted by API_KEY_PRESETS only see the presets allowed for them (not "raw" or "other").
+// @Tags presets
+// @Produce json
+// @Success 200 {array} PresetUsage
+// @Failure 500 {object} ErrorResponse "Failed to read preset usage"
+// @Security bearerAuth
+// @Router /presets/usage [get]
+func (h *Handler) GetPresetUsage(c *gin.Context) {
+	counts, err := h.presetUsage.Counts()
+	if err != nil {
+		logger.Error("Failed to read preset usage", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to read preset usage"})
+		return
+	}
+
+	// 制限されたキーには許可されたプリセットの集計のみ返す（raw と other は許可されたプリセットではないため含めない）
+	keyName := c.GetString(auth.APIKeyNameContextKey)
+	usage := make([]PresetUsage, 0, len(counts))
+	for name, jobs := range counts {
+		if !h.presetAllowlist.Allows(keyName, name) {
+			continue
+		}
+		usage = append(usage, PresetUsage{Preset: name, Jobs: jobs})
+	}
+	sort.Slice(usage, func(i, j int) bool {
+		if usage[i].Jobs != usage[j].Jobs {
+			return usage[i].Jobs > usage[j].Jobs
+		}
+		return usage[i].Preset < usage[j].Preset
+	})
+	c.JSON(http.StatusOK, usage)
+}
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nzws/flux-encoder/internal/controlplane/balancer"
+	"github.com/nzws/flux-encoder/internal/shared/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func getPresetUsage(t *testing.T, handler *Handler) []PresetUsage {
+	t.Helper()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/api/v1/presets/usage", handler.GetPresetUsage)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/presets/usage", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("ステータスコードが一致しない: 期待値 %d, 取得値 %d", http.StatusOK, w.Code)
+	}
+	var usage []PresetUsage
+	if err := json.Unmarshal(w.Body.Bytes(), &usage); err != nil {
+		t.Fatalf("レスポンスのパースに失敗: %v", err)
+	}
+	return usage
+}
+
+func Testジョブごとにプリセットの利用数が集計され取得できる(t *testing.T) {
+	handler := NewHandler(balancer.New([]string{startMockWorker(t, &failingWorker{})}, time.Second))
+	metricBefore := testutil.ToFloat64(metrics.PresetUsage.WithLabelValues("hls_720p"))
+
+	bodies := []string{
+		`{"input_url":"https://example.com/a.mp4","preset":"hls_720p","output":{"storage":"local","path":"a"}}`,
+		`{"input_url":"https://example.com/b.mp4","preset":"720p_h264","output":{"storage":"local","path":"b.mp4"}}`,
+		`{"input_url":"https://example.com/c.mp4","preset":"hls_720p","output":{"storage":"local","path":"c"}}`,
+		`{"input_url":"https://example.com/d.mp4","raw_ffmpeg_args":["-c:v","libx264"],"output":{"storage":"local","path":"d.mp4"}}`,
+	}
+	for _, body := range bodies {
+		if w := postJob(t, handler, body); w.Code != http.StatusAccepted {
+			t.Fatalf("ステータスコードが一致しない: 期待値 %d, 取得値 %d: %s", http.StatusAccepted, w.Code, w.Body.String())
+		}
+	}
+
+	expected := []PresetUsage{{Preset: "hls_720p", Jobs: 2}, {Preset: "720p_h264", Jobs: 1}, {Preset: "raw", Jobs: 1}}
+	if usage := getPresetUsage(t, handler); !reflect.DeepEqual(usage, expected) {
+		t.Errorf("プリセットの利用数が一致しない:\n期待値 %+v\n取得値 %+v", expected, usage)
+	}
+	if got := testutil.ToFloat64(metrics.PresetUsage.WithLabelValues("hls_720p")) - metricBefore; got != 2 {
+		t.Errorf("メトリクスの増分が一致しない: 期待値 2, 取得値 %v", got)
+	}
+}
+
+func Test組み込み以外のプリセットは上限を超えるとotherにまとめられる(t *testing.T) {
+	labeler := newPresetUsageLabeler(2)
+
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"custom_a", "custom_a"},
+		{"custom_b", "custom_b"},
+		{"custom_c", "other"},
+		{"custom_a", "custom_a"},
+		// 組み込みのプリセットは上限に関わらず個別に集計する
+		{"720p_h264", "720p_h264"},
+	}
+	for _, tt := range tests {
+		if got := labeler.label(tt.name); got != tt.expected {
+			t.Errorf("%s の集計名が一致しない: 期待値 %s, 取得値 %s", tt.name, tt.expected, got)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nzws/flux-encoder/internal/controlplane/balancer"
+	"github.com/nzws/flux-encoder/internal/shared/logger"
+	workerv1 "github.com/nzws/flux-encoder/proto/worker/v1"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ValidateOutputRequest は既存の出力の検証のリクエスト
+type ValidateOutputRequest struct {
+	// Path は検証する出力のパス（ジョブの output.path と同じく、Worker のストレージのルートからの相対パス）
+	Path string `json:"path" binding:"required" example:"videos/output.mp4"`
+	// Validation は検証の設定。省略時は Worker の既定値（VALIDATION_LEVEL など）
+	Validation *ValidationConfig `json:"validation,omitempty"`
+}
+
+// ValidationIssue は検証エラー・警告
+type ValidationIssue struct {
+	Code    string `json:"code" example:"BITRATE_LOW"`
+	Message string `json:"message" example:"bitrate is lower than expected"`
+	Field   string `json:"field,omitempty" example:"bitrate"`
+}
+
+// ValidatedVideoStream は検証した出力の映像ストリーム
+type ValidatedVideoStream struct {
+	Codec       string  `json:"codec" example:"h264"`
+	Profile     string  `json:"profile,omitempty" example:"High"`
+	Level       string  `json:"level,omitempty" example:"3.1"`
+	Width       int     `json:"width" example:"1280"`
+	Height      int     `json:"height" example:"720"`
+	FrameRate   float64 `json:"frame_rate,omitempty" example:"29.97"`
+	PixelFormat string  `json:"pixel_format,omitempty" example:"yuv420p"`
+	Bitrate     int64   `json:"bitrate,omitempty" example:"2500000"`
+}
+
+// ValidatedAudioStream は検証した出力の音声ストリーム
+type ValidatedAudioStream struct {
+	Codec         string `json:"codec" example:"aac"`
+	SampleRate    int    `json:"sample_rate,omitempty" example:"48000"`
+	Channels      int    `json:"channels,omitempty" example:"2"`
+	ChannelLayout string `json:"channel_layout,omitempty" example:"stereo"`
+	Bitrate       int64  `json:"bitrate,omitempty" example:"128000"`
+}
+
+// ValidatedMediaInfo は検証した出力のメディア情報
+type ValidatedMediaInfo struct {
+	Format   string  `json:"format" example:"mov,mp4,m4a,3gp,3g2,mj2"`
+	Duration float64 `json:"duration" example:"120.5"`
+	// Size はファイルサイズ（バイト、単一ファイルの場合のみ）
+	Size         int64                  `json:"size,omitempty" example:"37748736"`
+	Bitrate      int64                  `json:"bitrate,omitempty" example:"2628000"`
+	VideoStreams []ValidatedVideoStream `json:"video_streams"`
+	AudioStreams []ValidatedAudioStream `json:"audio_streams"`
+	// TotalSegments は HLS/DASH のセグメント数（単一ファイルの場合は省略）
+	TotalSegments int `json:"total_segments,omitempty" example:"0"`
+}
+
+// ValidateOutputResponse は既存の出力の検証結果
+type ValidateOutputResponse struct {
+	// Worker は検証した Worker のアドレス
+	Worker   string `json:"worker" example:"worker-1:50051"`
+	WorkerID string `json:"worker_id,omitempty" example:"worker-1"`
+	// Valid は検証に合格したかどうか
+	Valid    bool              `json:"valid" example:"true"`
+	Errors   []ValidationIssue `json:"errors"`
+	Warnings []ValidationIssue `json:"warnings"`
+	// MediaInfo は ffprobe で取得したメディア情報（ファイルが存在しない場合など、取得前に失敗した場合は省略）
+	MediaInfo  *ValidatedMediaInfo `json:"media_info,omitempty"`
+	DurationMs int64               `json:"duration_ms" example:"350"`
+}
+
+// ValidateOutput は Worker のストレージにある既存の出力を再エンコードせずに検証する
+// @Summary Validate existing output
+// @Description Validate an output that already exists in a Worker's storage without re-encoding it (e.g. to verify a migrated asset). The path is relative to the storage root, like the output.path of a job. Only Workers with local storage (STORAGE_TYPE=local) can validate outputs, as remote segment validation is not supported. An output that fails validation is returned with 200 and valid=false.
+// @Tags jobs
+// @Accept json
+// @Produce json
+// @Param request body ValidateOutputRequest true "Output to validate"
+// @Success 200 {object} ValidateOutputResponse "Validation result"
+// @Failure 400 {object} ErrorResponse "Invalid request"
+// @Failure 501 {object} ErrorResponse "Worker storage does not support validating existing outputs"
+// @Failure 502 {object} ErrorResponse "Worker failed to validate the output"
+// @Failure 503 {object} ErrorResponse "No available workers"
+// @Security bearerAuth
+// @Router /validate [post]
+func (h *Handler) ValidateOutput(c *gin.Context) {
+	var req ValidateOutputRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if strings.Contains(req.Path, "://") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "path must be relative to the storage root, not a URL"})
+		return
+	}
+	if err := req.Validation.settings().Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid validation: %v", err)})
+		return
+	}
+
+	// 検証には実行枠を使わないため、空きに関わらず応答した Worker から選ぶ
+	worker, ok := h.dryRunWorker(c, balancer.Capabilities{})
+	if !ok {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "no available workers"})
+		return
+	}
+
+	conn, err := h.balancer.Dial(worker.Address)
+	if err != nil {
+		logger.Error("Failed to connect to worker", zap.String("worker", worker.Address), zap.Error(err))
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "no available workers"})
+		return
+	}
+
+	resp, err := workerv1.NewWorkerServiceClient(conn).ValidateOutput(c.Request.Context(), &workerv1.ValidateOutputRequest{
+		Path:       req.Path,
+		Validation: req.Validation.toProto(),
+	})
+	if err != nil {
+		logger.Warn("Output validation failed", zap.String("worker", worker.Address), zap.Error(err))
+		switch status.Code(err) {
+		case codes.InvalidArgument:
+			c.JSON(http.StatusBadRequest, gin.H{"error": status.Convert(err).Message()})
+		case codes.FailedPrecondition, codes.Unimplemented:
+			c.JSON(http.StatusNotImplemented, gin.H{"error": status.Convert(err).Message()})
+		default:
+			c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, validateOutputResponse(worker, resp))
+}
+
+// validateOutputResponse は Worker の検証結果を ValidateOutputResponse に変換する
+func validateOutputResponse(worker balancer.WorkerInfo, resp *workerv1.ValidateOutputResponse) ValidateOutputResponse {
+	result := ValidateOutputResponse{
+		Worker:     worker.Address,
+		WorkerID:   worker.WorkerID,
+		Valid:      resp.Valid,
+		Errors:     validationIssues(resp.Errors),
+		Warnings:   validationIssues(resp.Warnings),
+		DurationMs: resp.DurationMs,
+	}
+
+	info := resp.MediaInfo
+	if info == nil {
+		return result
+	}
+	result.MediaInfo = &ValidatedMediaInfo{
+		Format:        info.Format,
+		Duration:      info.Duration,
+		Size:          info.Size,
+		Bitrate:       info.Bitrate,
+		VideoStreams:  make([]ValidatedVideoStream, 0, len(info.VideoStreams)),
+		AudioStreams:  make([]ValidatedAudioStream, 0, len(info.AudioStreams)),
+		TotalSegments: int(info.TotalSegments),
+	}
+	for _, v := range info.VideoStreams {
+		result.MediaInfo.VideoStreams = append(result.MediaInfo.VideoStreams, ValidatedVideoStream{
+			Codec:       v.Codec,
+			Profile:     v.Profile,
+			Level:       v.Level,
+			Width:       int(v.Width),
+			Height:      int(v.Height),
+			FrameRate:   v.FrameRate,
+			PixelFormat: v.PixelFormat,
+			Bitrate:     v.Bitrate,
+		})
+	}
+	for _, a := range info.AudioStreams {
+		result.MediaInfo.AudioStreams = append(result.MediaInfo.AudioStreams, ValidatedAudioStream{
+			Codec:         a.Codec,
+			SampleRate:    int(a.SampleRate),
+			Channels:      int(a.Channels),
+			ChannelLayout: a.ChannelLayout,
+			Bitrate:       a.Bitrate,
+		})
+	}
+	return result
+}
+
+// validationIssues は検証エラー・警告を変換する（ない場合は空のスライス）
+func validationIssues(issues []*workerv1.ValidationIssue) []ValidationIssue {
+	result := make([]ValidationIssue, 0, len(issues))
+	for _, issue := range issues {
+		result = append(result, ValidationIssue{Code: issue.Code, Message: issue.Message, Field: issue.Field})
+	}
+	return result
+}
@@ -0,0 +1,144 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nzws/flux-encoder/internal/controlplane/balancer"
+	workerv1 "github.com/nzws/flux-encoder/proto/worker/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// validateMockWorker は valid.mp4 のみ検証に合格させるモック Worker
+type validateMockWorker struct {
+	workerv1.UnimplementedWorkerServiceServer
+
+	err     error
+	lastReq *workerv1.ValidateOutputRequest
+}
+
+func (w *validateMockWorker) GetStatus(ctx context.Context, req *workerv1.StatusRequest) (*workerv1.WorkerStatus, error) {
+	return &workerv1.WorkerStatus{CurrentJobs: 1, MaxConcurrentJobs: 1, WorkerId: "validate-worker"}, nil
+}
+
+func (w *validateMockWorker) ValidateOutput(ctx context.Context, req *workerv1.ValidateOutputRequest) (*workerv1.ValidateOutputResponse, error) {
+	w.lastReq = req
+	if w.err != nil {
+		return nil, w.err
+	}
+	if req.Path != "valid.mp4" {
+		return &workerv1.ValidateOutputResponse{
+			Errors: []*workerv1.ValidationIssue{{Code: "FILE_NOT_FOUND", Message: "output file does not exist: " + req.Path}},
+		}, nil
+	}
+	return &workerv1.ValidateOutputResponse{
+		Valid:    true,
+		Warnings: []*workerv1.ValidationIssue{{Code: "BITRATE_LOW", Message: "bitrate is lower than expected", Field: "bitrate"}},
+		MediaInfo: &workerv1.ValidatedMediaInfo{
+			Format:       "mov,mp4,m4a,3gp,3g2,mj2",
+			Duration:     10,
+			VideoStreams: []*workerv1.ValidatedVideoStream{{Codec: "h264", Width: 1280, Height: 720}},
+		},
+		DurationMs: 12,
+	}, nil
+}
+
+// postValidate は worker に接続した Handler の POST /api/v1/validate にリクエストを送信する
+func postValidate(t *testing.T, worker workerv1.WorkerServiceServer, body string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	gin.SetMode(gin.TestMode)
+	handler := NewHandler(balancer.New([]string{startMockWorker(t, worker)}, time.Second))
+	router := gin.New()
+	router.POST("/api/v1/validate", handler.ValidateOutput)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/validate", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestValidateOutputがWorkerの検証結果を返す(t *testing.T) {
+	worker := &validateMockWorker{}
+	w := postValidate(t, worker, `{"path":"valid.mp4","validation":{"level":"strict","skip_decode_test":true}}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("ステータスコードが一致しない: 期待値 %d, 取得値 %d (%s)", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var resp ValidateOutputResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("レスポンスのパースに失敗: %v", err)
+	}
+	if !resp.Valid || resp.WorkerID != "validate-worker" || len(resp.Errors) != 0 {
+		t.Errorf("検証結果が一致しない: %+v", resp)
+	}
+	if len(resp.Warnings) != 1 || resp.Warnings[0].Code != "BITRATE_LOW" {
+		t.Errorf("警告が一致しない: %+v", resp.Warnings)
+	}
+	if resp.MediaInfo == nil || len(resp.MediaInfo.VideoStreams) != 1 || resp.MediaInfo.VideoStreams[0].Width != 1280 {
+		t.Errorf("メディア情報が一致しない: %+v", resp.MediaInfo)
+	}
+	if worker.lastReq.Validation.GetLevel() != "strict" || !worker.lastReq.Validation.GetSkipDecodeTest() {
+		t.Errorf("検証の設定が Worker に渡されない: %+v", worker.lastReq.Validation)
+	}
+}
+
+func TestValidateOutputが不正な出力を200とエラーで返す(t *testing.T) {
+	w := postValidate(t, &validateMockWorker{}, `{"path":"broken.mp4"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("ステータスコードが一致しない: 期待値 %d, 取得値 %d (%s)", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var resp ValidateOutputResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("レスポンスのパースに失敗: %v", err)
+	}
+	if resp.Valid || len(resp.Errors) != 1 || resp.Errors[0].Code != "FILE_NOT_FOUND" {
+		t.Errorf("検証結果が一致しない: %+v", resp)
+	}
+	if resp.MediaInfo != nil {
+		t.Errorf("メディア情報が返された: %+v", resp.MediaInfo)
+	}
+}
+
+func TestValidateOutputのエラーのステータスコード(t *testing.T) {
+	tests := []struct {
+		name   string
+		worker *validateMockWorker
+		body   string
+		status int
+	}{
+		{name: "パスなし", worker: &validateMockWorker{}, body: `{}`, status: http.StatusBadRequest},
+		{name: "URL を指定", worker: &validateMockWorker{}, body: `{"path":"s3://bucket/out.mp4"}`, status: http.StatusBadRequest},
+		{name: "不正な検証の設定", worker: &validateMockWorker{}, body: `{"path":"out.mp4","validation":{"level":"paranoid"}}`, status: http.StatusBadRequest},
+		{
+			name:   "ローカル以外のストレージ",
+			worker: &validateMockWorker{err: status.Error(codes.FailedPrecondition, "validating existing outputs is not supported with s3 storage")},
+			body:   `{"path":"out.mp4"}`,
+			status: http.StatusNotImplemented,
+		},
+		{
+			name:   "Worker のエラー",
+			worker: &validateMockWorker{err: status.Error(codes.Internal, "boom")},
+			body:   `{"path":"out.mp4"}`,
+			status: http.StatusBadGateway,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := postValidate(t, tt.worker, tt.body)
+			if w.Code != tt.status {
+				t.Errorf("ステータスコードが一致しない: 期待値 %d, 取得値 %d (%s)", tt.status, w.Code, w.Body.String())
+			}
+		})
+	}
+}
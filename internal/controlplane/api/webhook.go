@@ -0,0 +1,142 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/nzws/flux-encoder/internal/shared/logger"
+	"github.com/nzws/flux-encoder/internal/shared/retry"
+	workerv1 "github.com/nzws/flux-encoder/proto/worker/v1"
+	"go.uber.org/zap"
+)
+
+// defaultWebhookTimeout は Webhook の1回の送信のタイムアウト
+const defaultWebhookTimeout = 10 * time.Second
+
+// defaultWebhookConfig は Webhook の送信のリトライ設定
+var defaultWebhookConfig = retry.Config{
+	MaxAttempts: 3,
+	InitialWait: time.Second,
+	MaxWait:     10 * time.Second,
+	Multiplier:  2.0,
+	Jitter:      retry.DefaultConfig.Jitter,
+	IsRetryable: isRetryableWebhookError,
+}
+
+// WebhookPayload はジョブの完了・失敗時に callback_url へ POST する内容
+type WebhookPayload struct {
+	JobID     string `json:"job_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Status    string `json:"status" example:"JOB_STATUS_COMPLETED"`
+	OutputURL string `json:"output_url,omitempty" example:"https://example-bucket.s3.amazonaws.com/output/video.mp4"`
+	Error     string `json:"error,omitempty" example:""`
+}
+
+// webhookStatusError は Webhook の送信先が成功以外のステータスコードを返したことを表す
+type webhookStatusError struct {
+	code int
+}
+
+func (e *webhookStatusError) Error() string {
+	return fmt.Sprintf("webhook returned status %d", e.code)
+}
+
+// isRetryableWebhookError は Webhook の送信エラーをリトライするかを判定する
+// 4xx（送信先の設定誤りなど）はリトライしても成功しないため false を返す。ただし 408 と 429 はリトライする
+func isRetryableWebhookError(err error) bool {
+	var statusErr *webhookStatusError
+	if !errors.As(err, &statusErr) {
+		return true
+	}
+	if statusErr.code == http.StatusRequestTimeout || statusErr.code == http.StatusTooManyRequests {
+		return true
+	}
+	return statusErr.code < 400 || statusErr.code >= 500
+}
+
+// validateCallbackURL は callback_url が http(s) の URL かを検証する
+func validateCallbackURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("invalid callback_url: %w", err)
+	}
+	if scheme := strings.ToLower(u.Scheme); scheme != "http" && scheme != "https" {
+		return fmt.Errorf("invalid callback_url: scheme must be http or https: %s", raw)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("invalid callback_url: host is required: %s", raw)
+	}
+	return nil
+}
+
+// isWebhookStatus は Webhook を送信する終了ステータス（完了・失敗）かを返す
+func isWebhookStatus(status workerv1.JobStatus) bool {
+	return status == workerv1.JobStatus_JOB_STATUS_COMPLETED || status == workerv1.JobStatus_JOB_STATUS_FAILED
+}
+
+// sendWebhook はジョブの終了を callbackURL に POST する
+// 一時的なエラーは webhookConfig に従ってリトライし、最終的に失敗した場合はログに記録する
+func (h *Handler) sendWebhook(callbackURL, jobID string, progress *workerv1.JobProgress) {
+	body, err := json.Marshal(WebhookPayload{
+		JobID:     jobID,
+		Status:    progress.Status.String(),
+		OutputURL: progress.OutputUrl,
+		Error:     progress.Error,
+	})
+	if err != nil {
+		logger.Error("Failed to marshal webhook payload", zap.String("job_id", jobID), zap.Error(err))
+		return
+	}
+
+	err = retry.Do(context.Background(), h.webhookConfig, func() error {
+		return h.postWebhook(callbackURL, body)
+	})
+	if err != nil {
+		logger.Error("Failed to send webhook",
+			zap.String("job_id", jobID),
+			zap.String("callback_url", callbackURL),
+			zap.Error(err),
+		)
+		return
+	}
+
+	logger.Info("Webhook sent",
+		zap.String("job_id", jobID),
+		zap.String("callback_url", callbackURL),
+	)
+}
+
+// postWebhook は Webhook を1回送信する（2xx 以外はエラー）
+func (h *Handler) postWebhook(callbackURL string, body []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), h.webhookTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, callbackURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		if err := resp.Body.Close(); err != nil {
+			logger.Warn("Failed to close webhook response body", zap.Error(err))
+		}
+	}()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &webhookStatusError{code: resp.StatusCode}
+	}
+	return nil
+}
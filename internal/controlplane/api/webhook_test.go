@@ -0,0 +1,147 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nzws/flux-encoder/internal/controlplane/balancer"
+	"github.com/nzws/flux-encoder/internal/shared/retry"
+	workerv1 "github.com/nzws/flux-encoder/proto/worker/v1"
+	"google.golang.org/grpc"
+)
+
+func Testジョブの完了時にcallback_urlへ結果がPOSTされる(t *testing.T) {
+	var attempts atomic.Int32
+	payloads := make(chan WebhookPayload, 1)
+	callback := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// 最初の送信は一時的なエラーとしてリトライさせる
+		if attempts.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("リクエストが一致しない: method %s, content-type %s", r.Method, r.Header.Get("Content-Type"))
+		}
+		var payload WebhookPayload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("ペイロードのパースに失敗: %v", err)
+		}
+		payloads <- payload
+	}))
+	defer callback.Close()
+
+	handler := NewHandler(balancer.New([]string{startMockWorker(t, &completingWorker{})}, time.Second))
+	handler.webhookConfig = retry.Config{
+		MaxAttempts: 3,
+		InitialWait: time.Millisecond,
+		MaxWait:     time.Millisecond,
+		Multiplier:  1,
+		IsRetryable: isRetryableWebhookError,
+	}
+
+	w := postJob(t, handler, `{"input_url":"https://example.com/in.mp4","preset":"720p_h264","output":{"storage":"s3","path":"hls"},"callback_url":"`+callback.URL+`/hooks/encode"}`)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("ステータスコードが一致しない: 期待値 %d, 取得値 %d, body %s", http.StatusAccepted, w.Code, w.Body.String())
+	}
+	var created JobResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatalf("レスポンスのパースに失敗: %v", err)
+	}
+
+	select {
+	case payload := <-payloads:
+		want := WebhookPayload{
+			JobID:     created.JobID,
+			Status:    "JOB_STATUS_COMPLETED",
+			OutputURL: "https://cdn.example.com/hls/master.m3u8",
+		}
+		if payload != want {
+			t.Errorf("ペイロードが一致しない: 期待値 %+v, 取得値 %+v", want, payload)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Webhook が送信されない")
+	}
+	if got := attempts.Load(); got != 2 {
+		t.Errorf("送信回数が一致しない: 期待値 2, 取得値 %d", got)
+	}
+}
+
+func TestCreateJobでhttp以外のcallback_urlは400が返る(t *testing.T) {
+	for _, callbackURL := range []string{"ftp://example.com/hook", "file:///tmp/hook", "example.com/hook", "https://"} {
+		body := `{"input_url":"https://example.com/video.mp4","preset":"720p_h264","output":{"storage":"local","path":"out.mp4"},"callback_url":"` + callbackURL + `"}`
+		if w := postJob(t, NewHandler(nil), body); w.Code != http.StatusBadRequest {
+			t.Errorf("ステータスコードが一致しない: 期待値 %d, 取得値 %d (%s)", http.StatusBadRequest, w.Code, callbackURL)
+		}
+	}
+}
+
+func TestWebhookの4xxエラーはリトライしない(t *testing.T) {
+	if isRetryableWebhookError(&webhookStatusError{code: http.StatusNotFound}) {
+		t.Error("404 がリトライ対象になっている")
+	}
+	for _, code := range []int{http.StatusRequestTimeout, http.StatusTooManyRequests, http.StatusBadGateway} {
+		if !isRetryableWebhookError(&webhookStatusError{code: code}) {
+			t.Errorf("%d がリトライ対象になっていない", code)
+		}
+	}
+}
+
+// chattyWorker は進捗チャネルの容量を超える件数の進捗を送信してから完了するモック Worker
+type chattyWorker struct {
+	workerv1.UnimplementedWorkerServiceServer
+}
+
+func (w *chattyWorker) GetStatus(ctx context.Context, req *workerv1.StatusRequest) (*workerv1.WorkerStatus, error) {
+	return &workerv1.WorkerStatus{CurrentJobs: 0, MaxConcurrentJobs: 1, WorkerId: "chatty-worker"}, nil
+}
+
+func (w *chattyWorker) SubmitJob(req *workerv1.JobRequest, stream grpc.ServerStreamingServer[workerv1.JobProgress]) error {
+	if err := stream.Send(&workerv1.JobProgress{JobId: req.JobId, Status: workerv1.JobStatus_JOB_STATUS_QUEUED}); err != nil {
+		return err
+	}
+	for i := 0; i < progressChannelSize*3; i++ {
+		if err := stream.Send(&workerv1.JobProgress{
+			JobId:    req.JobId,
+			Status:   workerv1.JobStatus_JOB_STATUS_PROCESSING,
+			Progress: float32(i) / float32(progressChannelSize*3) * 100,
+			Message:  "Encoding",
+		}); err != nil {
+			return err
+		}
+	}
+	return stream.Send(&workerv1.JobProgress{JobId: req.JobId, Status: workerv1.JobStatus_JOB_STATUS_COMPLETED, Progress: 100})
+}
+
+func TestSSEのクライアントがいなくても多数の進捗の後にcallback_urlへ結果がPOSTされる(t *testing.T) {
+	payloads := make(chan WebhookPayload, 1)
+	callback := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload WebhookPayload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("ペイロードのパースに失敗: %v", err)
+		}
+		payloads <- payload
+	}))
+	defer callback.Close()
+
+	handler := NewHandler(balancer.New([]string{startMockWorker(t, &chattyWorker{})}, time.Second))
+
+	// 進捗ストリーム（SSE）を開かずに完了を待つ
+	w := postJob(t, handler, `{"input_url":"https://example.com/in.mp4","preset":"720p_h264","output":{"storage":"local","path":"out.mp4"},"callback_url":"`+callback.URL+`"}`)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("ステータスコードが一致しない: 期待値 %d, 取得値 %d, body %s", http.StatusAccepted, w.Code, w.Body.String())
+	}
+
+	select {
+	case payload := <-payloads:
+		if payload.Status != "JOB_STATUS_COMPLETED" {
+			t.Errorf("ステータスが一致しない: 期待値 JOB_STATUS_COMPLETED, 取得値 %s", payload.Status)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Webhook が送信されない")
+	}
+}
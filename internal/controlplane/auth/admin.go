@@ -0,0 +1,49 @@
+package auth
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nzws/flux-encoder/internal/shared/logger"
+	"go.uber.org/zap"
+)
+
+// AdminKeys は管理者用のエンドポイントを利用できる API Key の名前
+type AdminKeys map[string]bool
+
+// ParseAdminKeys はカンマ区切りの API Key の名前のリスト（ADMIN_API_KEYS）を AdminKeys に変換する
+// 例: "ops,default"
+func ParseAdminKeys(value string) AdminKeys {
+	admins := make(AdminKeys)
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			admins[name] = true
+		}
+	}
+	return admins
+}
+
+// RequireAdmin は admins に含まれる API Key のみを通すミドルウェア（APIKeyMiddleware の後に適用する）
+// それ以外のキーは 403 で拒否する。認証が無効な場合（キーの名前がない場合）はすべてのエンドポイントと同じく制限しない
+func RequireAdmin(admins AdminKeys) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		value, authenticated := c.Get(APIKeyNameContextKey)
+		if !authenticated {
+			c.Next()
+			return
+		}
+
+		name, _ := value.(string)
+		if !admins[name] {
+			logger.Warn("Rejected non-admin API key",
+				zap.String("api_key_name", name),
+				zap.String("path", c.Request.URL.Path),
+			)
+			c.JSON(http.StatusForbidden, gin.H{"error": "admin api key required"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
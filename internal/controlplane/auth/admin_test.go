@@ -0,0 +1,52 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestADMIN_API_KEYSの形式を解析できる(t *testing.T) {
+	admins := ParseAdminKeys(" ops , default,,")
+	if len(admins) != 2 || !admins["ops"] || !admins["default"] {
+		t.Errorf("管理者のキーが一致しない: %v", admins)
+	}
+}
+
+func Test管理者用のエンドポイントは管理者のキーのみ利用できる(t *testing.T) {
+	mustSetenv(t, "API_KEYS", "ops:ops-key,app:app-key")
+	defer func() {
+		mustUnsetenv(t, "API_KEYS")
+	}()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(APIKeyMiddleware())
+	router.GET("/admin", RequireAdmin(ParseAdminKeys("ops")), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	})
+
+	testCases := []struct {
+		token          string
+		expectedStatus int
+	}{
+		{"ops-key", http.StatusOK},
+		{"app-key", http.StatusForbidden},
+		{"", http.StatusUnauthorized},
+	}
+
+	for _, tc := range testCases {
+		req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+		if tc.token != "" {
+			req.Header.Set("Authorization", "Bearer "+tc.token)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != tc.expectedStatus {
+			t.Errorf("%q: ステータスコードが一致しない: 期待値 %d, 取得値 %d", tc.token, tc.expectedStatus, w.Code)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"fmt"
 	"net/http"
 	"os"
 	"strings"
@@ -10,11 +11,24 @@ import (
 	"go.uber.org/zap"
 )
 
+const (
+	// APIKeyNameContextKey は認証に成功した API Key の名前を格納する gin コンテキストのキー
+	APIKeyNameContextKey = "api_key_name"
+
+	// DefaultAPIKeyName は API_KEY で指定した API Key の名前
+	DefaultAPIKeyName = "default"
+)
+
 // APIKeyMiddleware はAPI Key認証を行うミドルウェア
+// API_KEY（単一のキー）と API_KEYS（カンマ区切りの name:key）の両方を受け付け、
+// 認証に成功したキーの名前を APIKeyNameContextKey に格納する
 func APIKeyMiddleware() gin.HandlerFunc {
-	apiKey := os.Getenv("API_KEY")
-	if apiKey == "" {
-		logger.Warn("API_KEY is not set, authentication is disabled")
+	apiKeys, err := loadAPIKeys(os.Getenv("API_KEY"), os.Getenv("API_KEYS"))
+	if err != nil {
+		logger.Fatal("Invalid API key configuration", zap.Error(err))
+	}
+	if len(apiKeys) == 0 {
+		logger.Warn("API_KEY and API_KEYS are not set, authentication is disabled")
 		return func(c *gin.Context) {
 			c.Next()
 		}
@@ -27,6 +41,12 @@ func APIKeyMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		// アップロード済み入力の取得は Worker の ffmpeg が行うため認証不要（推測困難なIDで保護）
+		if isInputContentPath(c.Request.Method, c.Request.URL.Path) {
+			c.Next()
+			return
+		}
+
 		// Authorization ヘッダーから API Key を取得
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -54,7 +74,8 @@ func APIKeyMiddleware() gin.HandlerFunc {
 		token := parts[1]
 
 		// API Key を検証
-		if token != apiKey {
+		name, ok := apiKeys[token]
+		if !ok {
 			logger.Warn("Invalid API key",
 				zap.String("path", c.Request.URL.Path),
 				zap.String("client_ip", c.ClientIP()),
@@ -64,7 +85,71 @@ func APIKeyMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		// 認証成功
+		// 認証成功（ハンドラーやログでリクエスト元を識別できるようキーの名前を保持する）
+		c.Set(APIKeyNameContextKey, name)
+		logger.Debug("Authenticated request",
+			zap.String("api_key_name", name),
+			zap.String("path", c.Request.URL.Path),
+		)
 		c.Next()
 	}
 }
+
+// loadAPIKeys は API_KEY と API_KEYS の値から API Key と名前のマップを作成する
+// API_KEY は DefaultAPIKeyName という名前で登録する
+func loadAPIKeys(apiKey, apiKeys string) (map[string]string, error) {
+	keys, err := parseAPIKeys(apiKeys)
+	if err != nil {
+		return nil, err
+	}
+	if apiKey != "" {
+		if name, ok := keys[apiKey]; ok {
+			return nil, fmt.Errorf("API_KEY is also registered in API_KEYS as %q", name)
+		}
+		keys[apiKey] = DefaultAPIKeyName
+	}
+	return keys, nil
+}
+
+// parseAPIKeys はカンマ区切りの name:key のリストを API Key から名前へのマップに変換する
+// キーにはコロンを含めてもよい（最初のコロンで名前と分割する）
+func parseAPIKeys(value string) (map[string]string, error) {
+	keys := make(map[string]string)
+	names := make(map[string]bool)
+	for i, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		name, key, ok := strings.Cut(entry, ":")
+		name = strings.TrimSpace(name)
+		key = strings.TrimSpace(key)
+		if !ok || name == "" || key == "" {
+			// キーを含む可能性があるため値ではなく位置を示す
+			return nil, fmt.Errorf("invalid API_KEYS entry at position %d (must be name:key)", i+1)
+		}
+		if names[name] {
+			return nil, fmt.Errorf("duplicate API key name: %s", name)
+		}
+		if _, exists := keys[key]; exists {
+			return nil, fmt.Errorf("duplicate API key for name: %s", name)
+		}
+		names[name] = true
+		keys[key] = name
+	}
+	return keys, nil
+}
+
+// isInputContentPath はアップロード済み入力の取得リクエストかを判定する
+func isInputContentPath(method, path string) bool {
+	if method != http.MethodGet {
+		return false
+	}
+	id, ok := strings.CutPrefix(path, "/api/v1/inputs/")
+	if !ok {
+		return false
+	}
+	id, ok = strings.CutSuffix(id, "/content")
+	return ok && id != "" && !strings.Contains(id, "/")
+}
@@ -143,6 +143,50 @@ func TestHealthエンドポイントは認証不要(t *testing.T) {
 	}
 }
 
+func Testアップロード済み入力の取得は認証不要でそれ以外の入力APIは認証が必要(t *testing.T) {
+	mustSetenv(t, "API_KEY", "test-api-key-123")
+	defer func() {
+		mustUnsetenv(t, "API_KEY")
+	}()
+
+	router := gin.New()
+	router.Use(APIKeyMiddleware())
+	router.GET("/api/v1/inputs/:id", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	})
+	router.PATCH("/api/v1/inputs/:id", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	})
+	router.GET("/api/v1/inputs/:id/content", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	})
+
+	testCases := []struct {
+		method         string
+		path           string
+		expectedStatus int
+	}{
+		{"GET", "/api/v1/inputs/abc/content", http.StatusOK},
+		{"HEAD", "/api/v1/inputs/abc/content", http.StatusUnauthorized},
+		{"GET", "/api/v1/inputs/abc", http.StatusUnauthorized},
+		{"PATCH", "/api/v1/inputs/abc", http.StatusUnauthorized},
+		{"PATCH", "/api/v1/inputs/abc/content", http.StatusUnauthorized},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.method+" "+tc.path, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.path, nil)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			if w.Code != tc.expectedStatus {
+				t.Errorf("ステータスコードが一致しない: 期待値 %d, 取得値 %d", tc.expectedStatus, w.Code)
+			}
+		})
+	}
+}
+
 func TestAPIキーが設定されていない場合は認証が無効化される(t *testing.T) {
 	// API_KEY 環境変数を設定しない（またはクリア）
 	mustUnsetenv(t, "API_KEY")
@@ -233,6 +277,118 @@ func Test複数のエンドポイントで認証が機能する(t *testing.T) {
 	}
 }
 
+func Test複数のAPIキーのいずれでもリクエストが通過し名前が設定される(t *testing.T) {
+	mustSetenv(t, "API_KEY", "legacy-key")
+	mustSetenv(t, "API_KEYS", "encoder-app:key-aaa, batch:key-bbb")
+	defer func() {
+		mustUnsetenv(t, "API_KEY")
+		mustUnsetenv(t, "API_KEYS")
+	}()
+
+	router := gin.New()
+	router.Use(APIKeyMiddleware())
+	router.GET("/test", func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString(APIKeyNameContextKey))
+	})
+
+	testCases := []struct {
+		name         string
+		token        string
+		expectedName string
+	}{
+		{"API_KEYS の1つ目", "key-aaa", "encoder-app"},
+		{"API_KEYS の2つ目", "key-bbb", "batch"},
+		{"API_KEY", "legacy-key", DefaultAPIKeyName},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/test", nil)
+			req.Header.Set("Authorization", "Bearer "+tc.token)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("ステータスコードが一致しない: 期待値 %d, 取得値 %d", http.StatusOK, w.Code)
+			}
+			if w.Body.String() != tc.expectedName {
+				t.Errorf("API キーの名前が一致しない: 期待値 %s, 取得値 %s", tc.expectedName, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestAPI_KEYSに登録されていないキーで401が返る(t *testing.T) {
+	mustSetenv(t, "API_KEYS", "encoder-app:key-aaa,batch:key-bbb")
+	defer func() {
+		mustUnsetenv(t, "API_KEYS")
+	}()
+
+	router := gin.New()
+	router.Use(APIKeyMiddleware())
+	router.GET("/test", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"message": "success"})
+	})
+
+	// 名前をキーとして送っても通過しない
+	for _, token := range []string{"key-ccc", "encoder-app", "encoder-app:key-aaa"} {
+		req := httptest.NewRequest("GET", "/test", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("%s: ステータスコードが一致しない: 期待値 %d, 取得値 %d", token, http.StatusUnauthorized, w.Code)
+		}
+	}
+}
+
+func TestAPI_KEYSの形式が解析される(t *testing.T) {
+	testCases := []struct {
+		name     string
+		value    string
+		expected map[string]string
+		wantErr  bool
+	}{
+		{"空", "", map[string]string{}, false},
+		{"複数", "a:key1,b:key2", map[string]string{"key1": "a", "key2": "b"}, false},
+		{"空白と空要素", " a : key1 ,, ", map[string]string{"key1": "a"}, false},
+		{"キーにコロンを含む", "a:key:with:colon", map[string]string{"key:with:colon": "a"}, false},
+		{"名前がない", "key1", nil, true},
+		{"キーが空", "a:", nil, true},
+		{"名前の重複", "a:key1,a:key2", nil, true},
+		{"キーの重複", "a:key1,b:key1", nil, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			keys, err := parseAPIKeys(tc.value)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("エラーの有無が一致しない: 期待値 %v, 取得値 %v", tc.wantErr, err)
+			}
+			if tc.wantErr {
+				return
+			}
+			if len(keys) != len(tc.expected) {
+				t.Fatalf("キーの数が一致しない: 期待値 %v, 取得値 %v", tc.expected, keys)
+			}
+			for key, name := range tc.expected {
+				if keys[key] != name {
+					t.Errorf("%s の名前が一致しない: 期待値 %s, 取得値 %s", key, name, keys[key])
+				}
+			}
+		})
+	}
+}
+
+func TestAPI_KEYとAPI_KEYSで同じキーを指定するとエラーになる(t *testing.T) {
+	if _, err := loadAPIKeys("key1", "a:key1"); err == nil {
+		t.Error("エラーが返されない")
+	}
+}
+
 func mustSetenv(t *testing.T, key, value string) {
 	t.Helper()
 	if err := os.Setenv(key, value); err != nil {
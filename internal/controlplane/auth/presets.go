@@ -0,0 +1,55 @@
+package auth
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PresetAllowlist は API Key の名前ごとに利用を許可するプリセット
+// 登録されていないキー（管理者用のキーや認証が無効な場合）はすべてのプリセットを利用できる
+type PresetAllowlist map[string]map[string]bool
+
+// ParsePresetAllowlist はカンマ区切りの name:preset|preset のリストを PresetAllowlist に変換する
+// 例: "encoder-app:720p_h264|hls_720p,batch:480p_h264"
+func ParsePresetAllowlist(value string) (PresetAllowlist, error) {
+	allowlist := make(PresetAllowlist)
+	for i, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		name, list, ok := strings.Cut(entry, ":")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid API_KEY_PRESETS entry at position %d (must be name:preset|preset)", i+1)
+		}
+		if _, exists := allowlist[name]; exists {
+			return nil, fmt.Errorf("duplicate API_KEY_PRESETS entry for name: %s", name)
+		}
+
+		presets := make(map[string]bool)
+		for _, preset := range strings.Split(list, "|") {
+			if preset = strings.TrimSpace(preset); preset != "" {
+				presets[preset] = true
+			}
+		}
+		if len(presets) == 0 {
+			return nil, fmt.Errorf("API_KEY_PRESETS entry for %s has no presets", name)
+		}
+		allowlist[name] = presets
+	}
+	return allowlist, nil
+}
+
+// Restricted は keyName の API Key が利用できるプリセットを制限されているかを返す
+func (a PresetAllowlist) Restricted(keyName string) bool {
+	_, ok := a[keyName]
+	return ok
+}
+
+// Allows は keyName の API Key が preset を利用できるかを返す
+func (a PresetAllowlist) Allows(keyName, preset string) bool {
+	presets, ok := a[keyName]
+	return !ok || presets[preset]
+}
@@ -0,0 +1,45 @@
+package auth
+
+import "testing"
+
+func TestAPI_KEY_PRESETSの形式を解析できる(t *testing.T) {
+	allowlist, err := ParsePresetAllowlist(" encoder-app: 720p_h264 | hls_720p ,batch:480p_h264,")
+	if err != nil {
+		t.Fatalf("解析に失敗: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		preset string
+		want   bool
+	}{
+		{name: "encoder-app", preset: "720p_h264", want: true},
+		{name: "encoder-app", preset: "hls_720p", want: true},
+		{name: "encoder-app", preset: "2160p_h265", want: false},
+		{name: "batch", preset: "720p_h264", want: false},
+		// 登録されていないキー（管理者）は制限しない
+		{name: "admin", preset: "2160p_h265", want: true},
+		{name: "", preset: "2160p_h265", want: true},
+	}
+	for _, tt := range tests {
+		if got := allowlist.Allows(tt.name, tt.preset); got != tt.want {
+			t.Errorf("%s の %s の許可が一致しない: 期待値 %v, 取得値 %v", tt.name, tt.preset, tt.want, got)
+		}
+	}
+	if !allowlist.Restricted("batch") || allowlist.Restricted("admin") {
+		t.Errorf("制限の有無が一致しない: %v", allowlist)
+	}
+}
+
+func Test不正なAPI_KEY_PRESETSはエラーになる(t *testing.T) {
+	for _, value := range []string{
+		"encoder-app",
+		":720p_h264",
+		"encoder-app:",
+		"encoder-app:720p_h264,encoder-app:hls_720p",
+	} {
+		if _, err := ParsePresetAllowlist(value); err == nil {
+			t.Errorf("%q でエラーが返らない", value)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package auth
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nzws/flux-encoder/internal/shared/logger"
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+)
+
+// limiterSweepInterval は使われていないバケットを破棄する間隔
+const limiterSweepInterval = time.Minute
+
+// RateLimiter は API Key ごとのトークンバケットでリクエスト数を制限する
+type RateLimiter struct {
+	rps   rate.Limit
+	burst int
+
+	mu        sync.Mutex
+	limiters  map[string]*rate.Limiter
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// NewRateLimiter は 1 秒あたり rps 件、最大 burst 件まで連続して受け付ける RateLimiter を作成する
+// burst が 0 以下の場合は rps を切り上げた値（最小 1）を使う
+func NewRateLimiter(rps float64, burst int) *RateLimiter {
+	if burst <= 0 {
+		burst = max(1, int(math.Ceil(rps)))
+	}
+	return &RateLimiter{
+		rps:      rate.Limit(rps),
+		burst:    burst,
+		limiters: make(map[string]*rate.Limiter),
+		now:      time.Now,
+	}
+}
+
+// ParseTrustedProxies はカンマ区切りの信頼するリバースプロキシの IP アドレス・CIDR（TRUSTED_PROXIES）を変換する
+// 空の場合は nil を返す（どのプロキシも信頼せず、X-Forwarded-For などのヘッダーを無視して接続元のアドレスを使う）
+// 例: "10.0.0.0/8,192.168.1.10"
+func ParseTrustedProxies(value string) []string {
+	var proxies []string
+	for _, proxy := range strings.Split(value, ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return proxies
+}
+
+// Middleware はレート制限を行うミドルウェアを返す
+// APIKeyMiddleware の後に適用し、認証済みのキーの名前ごとに独立したバケットを使う
+// 認証が無効な場合はクライアントの IP アドレス（c.ClientIP()）ごとに制限する
+// ヘッダーの偽装で制限を回避されないよう、Engine の SetTrustedProxies には信頼するプロキシのみを設定する（ParseTrustedProxies）
+func (rl *RateLimiter) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// ヘルスチェックとメトリクスは制限しない
+		if c.Request.URL.Path == "/health" || c.Request.URL.Path == "/metrics" {
+			c.Next()
+			return
+		}
+
+		key := "ip:" + c.ClientIP()
+		if name := c.GetString(APIKeyNameContextKey); name != "" {
+			key = "key:" + name
+		}
+
+		if wait := rl.reserve(key); wait > 0 {
+			retryAfter := int(math.Ceil(wait.Seconds()))
+			logger.Warn("Rate limit exceeded",
+				zap.String("limit_key", key),
+				zap.String("path", c.Request.URL.Path),
+				zap.Int("retry_after", retryAfter),
+			)
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": "rate limit exceeded"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// reserve は key のバケットからトークンを 1 つ取得する
+// 取得できない場合はトークンを消費せず、次に取得できるまでの待ち時間を返す
+func (rl *RateLimiter) reserve(key string) time.Duration {
+	now := rl.now()
+
+	rl.mu.Lock()
+	if now.Sub(rl.lastSweep) >= limiterSweepInterval {
+		rl.sweepLocked(now)
+	}
+	limiter, ok := rl.limiters[key]
+	if !ok {
+		limiter = rate.NewLimiter(rl.rps, rl.burst)
+		rl.limiters[key] = limiter
+	}
+	rl.mu.Unlock()
+
+	r := limiter.ReserveN(now, 1)
+	if !r.OK() {
+		return time.Second
+	}
+	wait := r.DelayFrom(now)
+	if wait > 0 {
+		r.CancelAt(now)
+	}
+	return wait
+}
+
+// sweepLocked はトークンが満タンまで補充されたバケットを破棄する
+// 満タンのバケットは新しく作成したものと同じため、破棄しても制限は変わらない（IP アドレスごとのバケットが増え続けないようにする）
+// mu を保持して呼び出す
+func (rl *RateLimiter) sweepLocked(now time.Time) {
+	rl.lastSweep = now
+	for key, limiter := range rl.limiters {
+		if limiter.TokensAt(now) >= float64(rl.burst) {
+			delete(rl.limiters, key)
+		}
+	}
+}
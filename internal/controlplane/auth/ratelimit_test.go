@@ -0,0 +1,232 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newRateLimitedRouter は API_KEYS で認証した後にレート制限を行うルーターを作成する
+func newRateLimitedRouter(t *testing.T, rl *RateLimiter) *gin.Engine {
+	t.Helper()
+	t.Setenv("API_KEYS", "encoder-app:key-aaa,batch:key-bbb")
+
+	router := gin.New()
+	router.Use(APIKeyMiddleware())
+	router.Use(rl.Middleware())
+	router.GET("/test", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"message": "success"})
+	})
+	router.GET("/health", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	})
+	return router
+}
+
+func doRequest(router *gin.Engine, path, apiKey string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", path, nil)
+	if apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// frozenRateLimiter は時刻を固定した RateLimiter を作成する（テスト中にトークンが補充されないようにする）
+func frozenRateLimiter(rps float64, burst int) *RateLimiter {
+	rl := NewRateLimiter(rps, burst)
+	now := time.Now()
+	rl.now = func() time.Time { return now }
+	return rl
+}
+
+func Test上限を超えたリクエストに429とRetry_Afterが返る(t *testing.T) {
+	router := newRateLimitedRouter(t, frozenRateLimiter(0.5, 3))
+
+	for i := 0; i < 3; i++ {
+		if w := doRequest(router, "/test", "key-aaa"); w.Code != http.StatusOK {
+			t.Fatalf("%d 件目のステータスコードが一致しない: 期待値 %d, 取得値 %d", i+1, http.StatusOK, w.Code)
+		}
+	}
+
+	w := doRequest(router, "/test", "key-aaa")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("ステータスコードが一致しない: 期待値 %d, 取得値 %d", http.StatusTooManyRequests, w.Code)
+	}
+	// 0.5 件/秒のため次のトークンまで 2 秒かかる
+	if got := w.Header().Get("Retry-After"); got != "2" {
+		t.Errorf("Retry-After が一致しない: 期待値 2, 取得値 %q", got)
+	}
+}
+
+func Test拒否されたリクエストはトークンを消費しない(t *testing.T) {
+	rl := frozenRateLimiter(1, 1)
+	if wait := rl.reserve("key:a"); wait != 0 {
+		t.Fatalf("最初のリクエストは待ち時間なしで通過するべき: %v", wait)
+	}
+	for i := 0; i < 3; i++ {
+		if wait := rl.reserve("key:a"); wait != time.Second {
+			t.Errorf("待ち時間が一致しない: 期待値 %v, 取得値 %v", time.Second, wait)
+		}
+	}
+}
+
+func Test時間が経過するとトークンが補充される(t *testing.T) {
+	rl := NewRateLimiter(1, 1)
+	now := time.Now()
+	rl.now = func() time.Time { return now }
+	router := newRateLimitedRouter(t, rl)
+
+	if w := doRequest(router, "/test", "key-aaa"); w.Code != http.StatusOK {
+		t.Fatalf("ステータスコードが一致しない: 期待値 %d, 取得値 %d", http.StatusOK, w.Code)
+	}
+	if w := doRequest(router, "/test", "key-aaa"); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("ステータスコードが一致しない: 期待値 %d, 取得値 %d", http.StatusTooManyRequests, w.Code)
+	}
+
+	now = now.Add(time.Second)
+	if w := doRequest(router, "/test", "key-aaa"); w.Code != http.StatusOK {
+		t.Errorf("補充後のステータスコードが一致しない: 期待値 %d, 取得値 %d", http.StatusOK, w.Code)
+	}
+}
+
+func TestAPIキーごとに独立したバケットが使われる(t *testing.T) {
+	router := newRateLimitedRouter(t, frozenRateLimiter(1, 2))
+
+	for i := 0; i < 2; i++ {
+		if w := doRequest(router, "/test", "key-aaa"); w.Code != http.StatusOK {
+			t.Fatalf("ステータスコードが一致しない: 期待値 %d, 取得値 %d", http.StatusOK, w.Code)
+		}
+	}
+	if w := doRequest(router, "/test", "key-aaa"); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("encoder-app のステータスコードが一致しない: 期待値 %d, 取得値 %d", http.StatusTooManyRequests, w.Code)
+	}
+
+	// encoder-app が上限に達していても batch は影響を受けない
+	for i := 0; i < 2; i++ {
+		if w := doRequest(router, "/test", "key-bbb"); w.Code != http.StatusOK {
+			t.Errorf("batch のステータスコードが一致しない: 期待値 %d, 取得値 %d", http.StatusOK, w.Code)
+		}
+	}
+	if w := doRequest(router, "/test", "key-bbb"); w.Code != http.StatusTooManyRequests {
+		t.Errorf("batch のステータスコードが一致しない: 期待値 %d, 取得値 %d", http.StatusTooManyRequests, w.Code)
+	}
+}
+
+func Testヘルスチェックはレート制限されない(t *testing.T) {
+	router := newRateLimitedRouter(t, frozenRateLimiter(1, 1))
+
+	for i := 0; i < 5; i++ {
+		if w := doRequest(router, "/health", ""); w.Code != http.StatusOK {
+			t.Fatalf("ステータスコードが一致しない: 期待値 %d, 取得値 %d", http.StatusOK, w.Code)
+		}
+	}
+}
+
+func Test認証が無効な場合はクライアントIPごとに制限される(t *testing.T) {
+	rl := frozenRateLimiter(1, 1)
+	router := gin.New()
+	router.Use(rl.Middleware())
+	router.GET("/test", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"message": "success"})
+	})
+
+	request := func(remoteAddr string) int {
+		req := httptest.NewRequest("GET", "/test", nil)
+		req.RemoteAddr = remoteAddr
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := request("192.0.2.1:1234"); code != http.StatusOK {
+		t.Fatalf("ステータスコードが一致しない: 期待値 %d, 取得値 %d", http.StatusOK, code)
+	}
+	if code := request("192.0.2.1:5678"); code != http.StatusTooManyRequests {
+		t.Errorf("同じ IP のステータスコードが一致しない: 期待値 %d, 取得値 %d", http.StatusTooManyRequests, code)
+	}
+	if code := request("192.0.2.2:1234"); code != http.StatusOK {
+		t.Errorf("別の IP のステータスコードが一致しない: 期待値 %d, 取得値 %d", http.StatusOK, code)
+	}
+}
+
+func TestX_Forwarded_Forを偽装してもIPごとの制限を回避できない(t *testing.T) {
+	tests := []struct {
+		name           string
+		trustedProxies string
+		// wantSecond は別の X-Forwarded-For を付けた同じ接続元からの 2 件目のステータスコード
+		wantSecond int
+	}{
+		{name: "プロキシを信頼しない", trustedProxies: "", wantSecond: http.StatusTooManyRequests},
+		{name: "接続元が信頼するプロキシ", trustedProxies: "192.0.2.1", wantSecond: http.StatusOK},
+		{name: "接続元が信頼しないプロキシ", trustedProxies: "10.0.0.0/8", wantSecond: http.StatusTooManyRequests},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			if err := router.SetTrustedProxies(ParseTrustedProxies(tt.trustedProxies)); err != nil {
+				t.Fatalf("信頼するプロキシの設定に失敗: %v", err)
+			}
+			router.Use(frozenRateLimiter(1, 1).Middleware())
+			router.GET("/test", func(c *gin.Context) {
+				c.JSON(http.StatusOK, gin.H{"message": "success"})
+			})
+
+			request := func(forwardedFor string) int {
+				req := httptest.NewRequest("GET", "/test", nil)
+				req.RemoteAddr = "192.0.2.1:1234"
+				req.Header.Set("X-Forwarded-For", forwardedFor)
+				w := httptest.NewRecorder()
+				router.ServeHTTP(w, req)
+				return w.Code
+			}
+
+			if code := request("198.51.100.1"); code != http.StatusOK {
+				t.Fatalf("ステータスコードが一致しない: 期待値 %d, 取得値 %d", http.StatusOK, code)
+			}
+			if code := request("198.51.100.2"); code != tt.wantSecond {
+				t.Errorf("ステータスコードが一致しない: 期待値 %d, 取得値 %d", tt.wantSecond, code)
+			}
+		})
+	}
+}
+
+func Test信頼するプロキシの指定をパースできる(t *testing.T) {
+	got := ParseTrustedProxies(" 10.0.0.0/8, ,192.168.1.10 ")
+	want := []string{"10.0.0.0/8", "192.168.1.10"}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("信頼するプロキシが一致しない: 期待値 %v, 取得値 %v", want, got)
+	}
+	if got := ParseTrustedProxies(""); got != nil {
+		t.Errorf("空の場合は nil を返すべき: 取得値 %v", got)
+	}
+}
+
+func Test使われていないバケットは補充後に破棄される(t *testing.T) {
+	rl := NewRateLimiter(1, 2)
+	now := time.Now()
+	rl.now = func() time.Time { return now }
+
+	rl.reserve("ip:192.0.2.1")
+	rl.reserve("ip:192.0.2.2")
+	rl.reserve("ip:192.0.2.2")
+
+	// 192.0.2.1 は満タンまで補充され、192.0.2.2 はまだ補充中
+	now = now.Add(limiterSweepInterval)
+	rl.limiters["ip:192.0.2.2"].ReserveN(now, 2)
+	rl.reserve("ip:192.0.2.3")
+
+	if _, ok := rl.limiters["ip:192.0.2.1"]; ok {
+		t.Error("満タンのバケットが破棄されていない")
+	}
+	if _, ok := rl.limiters["ip:192.0.2.2"]; !ok {
+		t.Error("補充中のバケットが破棄されている")
+	}
+	if len(rl.limiters) != 2 {
+		t.Errorf("バケット数が一致しない: 期待値 2, 取得値 %d", len(rl.limiters))
+	}
+}
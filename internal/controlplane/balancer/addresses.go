@@ -0,0 +1,83 @@
+package balancer
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// ParseWorkerAddresses はカンマ区切りの Worker アドレス（WORKER_NODES）を検証して正規化する
+// 各アドレスの前後の空白を取り除き、空の要素と重複は除く
+// ポートがないアドレスは defaultPort を付けて補い、defaultPort が空の場合はエラーを返す
+func ParseWorkerAddresses(raw, defaultPort string) ([]string, error) {
+	if defaultPort != "" && !isValidPort(defaultPort) {
+		return nil, fmt.Errorf("invalid default worker port: %q", defaultPort)
+	}
+
+	var addresses []string
+	seen := make(map[string]bool)
+	var invalid []string
+	for _, entry := range strings.Split(raw, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		address, err := normalizeWorkerAddress(entry, defaultPort)
+		if err != nil {
+			invalid = append(invalid, err.Error())
+			continue
+		}
+		if seen[address] {
+			continue
+		}
+		seen[address] = true
+		addresses = append(addresses, address)
+	}
+
+	if len(invalid) > 0 {
+		return nil, fmt.Errorf("invalid worker addresses: %s", strings.Join(invalid, "; "))
+	}
+	if len(addresses) == 0 {
+		return nil, fmt.Errorf("no worker addresses")
+	}
+	return addresses, nil
+}
+
+// normalizeWorkerAddress は1つの Worker アドレスを host:port の形式にする
+// IPv6 アドレスは [::1]:50051 の形式で指定する（ポートを補う場合は ::1 のみでもよい）
+func normalizeWorkerAddress(entry, defaultPort string) (string, error) {
+	if strings.Contains(entry, "://") {
+		return "", fmt.Errorf("%s: must be host:port, not a URL", entry)
+	}
+
+	host, port, err := net.SplitHostPort(entry)
+	if err != nil {
+		// ポートがない（ホスト名・IPv4・角括弧のない IPv6 アドレス）
+		host = strings.TrimSuffix(strings.TrimPrefix(entry, "["), "]")
+		if strings.Contains(host, ":") && net.ParseIP(host) == nil {
+			return "", fmt.Errorf("%s: %v", entry, err)
+		}
+		if defaultPort == "" {
+			return "", fmt.Errorf("%s: missing port (use host:port or set WORKER_DEFAULT_PORT)", entry)
+		}
+		port = defaultPort
+	}
+
+	if host == "" {
+		return "", fmt.Errorf("%s: missing host", entry)
+	}
+	if strings.ContainsAny(host, " /") {
+		return "", fmt.Errorf("%s: invalid host", entry)
+	}
+	if !isValidPort(port) {
+		return "", fmt.Errorf("%s: invalid port %q", entry, port)
+	}
+	return net.JoinHostPort(host, port), nil
+}
+
+// isValidPort はポート番号（1〜65535）として正しいかを返す
+func isValidPort(port string) bool {
+	n, err := strconv.Atoi(port)
+	return err == nil && n >= 1 && n <= 65535
+}
@@ -0,0 +1,66 @@
+package balancer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWorkerアドレスの空白と空の要素と重複が除かれる(t *testing.T) {
+	addresses, err := ParseWorkerAddresses(" worker-1:50051 , worker-2:50051,,worker-1:50051 ,[::1]:50052", "")
+	if err != nil {
+		t.Fatalf("アドレスの解析に失敗: %v", err)
+	}
+
+	want := []string{"worker-1:50051", "worker-2:50051", "[::1]:50052"}
+	if !reflect.DeepEqual(addresses, want) {
+		t.Errorf("アドレスが一致しない: 期待値 %v, 取得値 %v", want, addresses)
+	}
+}
+
+func Testポートのないアドレスにデフォルトのポートが補われる(t *testing.T) {
+	addresses, err := ParseWorkerAddresses("worker-1,worker-2:50052,10.0.0.5,::1,worker-1:50051", "50051")
+	if err != nil {
+		t.Fatalf("アドレスの解析に失敗: %v", err)
+	}
+
+	// ポートを補った worker-1 と明示した worker-1:50051 は同じアドレスとして扱う
+	want := []string{"worker-1:50051", "worker-2:50052", "10.0.0.5:50051", "[::1]:50051"}
+	if !reflect.DeepEqual(addresses, want) {
+		t.Errorf("アドレスが一致しない: 期待値 %v, 取得値 %v", want, addresses)
+	}
+}
+
+func Testポートのないアドレスはデフォルトのポートがなければエラーになる(t *testing.T) {
+	_, err := ParseWorkerAddresses("worker-1:50051,worker-2", "")
+	if err == nil {
+		t.Fatal("ポートのないアドレスでエラーが返されなかった")
+	}
+	if !strings.Contains(err.Error(), "worker-2: missing port") || strings.Contains(err.Error(), "worker-1") {
+		t.Errorf("エラーメッセージが一致しない: %v", err)
+	}
+}
+
+func Test不正なWorkerアドレスはエラーになる(t *testing.T) {
+	testCases := []struct {
+		name        string
+		raw         string
+		defaultPort string
+	}{
+		{"ポートが数値でない", "worker-1:grpc", ""},
+		{"ポートが範囲外", "worker-1:70000", ""},
+		{"ポートが空", "worker-1:", "50051"},
+		{"ホストが空", ":50051", ""},
+		{"URL", "http://worker-1:50051", ""},
+		{"アドレスがない", " , ", "50051"},
+		{"デフォルトのポートが不正", "worker-1", "0"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if addresses, err := ParseWorkerAddresses(tc.raw, tc.defaultPort); err == nil {
+				t.Errorf("エラーが返されなかった: %v", addresses)
+			}
+		})
+	}
+}
@@ -2,39 +2,188 @@ package balancer
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
 
+	"github.com/nzws/flux-encoder/internal/shared/grpccompress"
+	"github.com/nzws/flux-encoder/internal/shared/grpckeepalive"
+	"github.com/nzws/flux-encoder/internal/shared/grpctls"
 	"github.com/nzws/flux-encoder/internal/shared/logger"
 	workerv1 "github.com/nzws/flux-encoder/proto/worker/v1"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 )
 
+const (
+	// defaultStatusConcurrency は状態取得の同時実行数のデフォルト値
+	defaultStatusConcurrency = 8
+	// defaultStatusTimeout は Worker ごとの状態取得タイムアウトのデフォルト値
+	defaultStatusTimeout = 5 * time.Second
+)
+
 // Balancer は Worker の負荷分散を行う
 type Balancer struct {
 	workers         []string
 	lastWorkerIndex int
 	mutex           sync.Mutex
 	timeout         time.Duration
+
+	statusConcurrency int
+	statusTimeout     time.Duration
+
+	compression string
+	// keepalive は Worker への接続に送信する keepalive ping の設定
+	keepalive grpckeepalive.Config
+	// maxCPUPercent は選択を後回しにする Worker の CPU 使用率（0 の場合は CPU 使用率を考慮しない）
+	maxCPUPercent float64
+	// creds は Worker への接続の認証情報（TLS が未設定の場合は insecure）
+	creds credentials.TransportCredentials
+
+	// connMutex は conns と closed を保護する
+	connMutex sync.Mutex
+	// conns は Worker のアドレスごとの接続（最初に使う際に作成し、Close まで使い回す）
+	conns map[string]*grpc.ClientConn
+	// closed は Close が呼ばれたか（以降は接続を作成しない）
+	closed bool
+}
+
+// errBalancerClosed は Close した Balancer で接続しようとした場合のエラー
+var errBalancerClosed = errors.New("balancer is closed")
+
+// WorkerInfo は Worker の状態取得結果
+type WorkerInfo struct {
+	Address           string
+	WorkerID          string
+	Version           string
+	CurrentJobs       int32
+	MaxConcurrentJobs int32
+	QueuedJobs        int32 // 実行枠が空くのを待っているジョブ数
+	Available         bool
+	Error             string
+	// Capabilities は Worker の ffmpeg で利用できるフィルター・エンコーダー（報告がない場合は nil）
+	Capabilities *Capabilities
+	// Load は Worker が報告したホストの負荷（報告がない場合は nil）
+	Load *Load
+}
+
+// Load は Worker のホストの負荷
+type Load struct {
+	CPUPercent       float64
+	MemoryPercent    float64
+	MemoryUsedBytes  uint64
+	MemoryTotalBytes uint64
+	// GPUAvailable が false の場合、GPUPercent は報告されていない
+	GPUAvailable bool
+	GPUPercent   float64
+	SampledAt    time.Time
+}
+
+// loadFromProto は Worker が報告したホストの負荷を変換する（報告がない場合は nil）
+func loadFromProto(load *workerv1.HostLoad) *Load {
+	if load == nil {
+		return nil
+	}
+	return &Load{
+		CPUPercent:       load.CpuPercent,
+		MemoryPercent:    load.MemoryPercent,
+		MemoryUsedBytes:  load.MemoryUsedBytes,
+		MemoryTotalBytes: load.MemoryTotalBytes,
+		GPUAvailable:     load.GpuAvailable,
+		GPUPercent:       load.GpuPercent,
+		SampledAt:        time.UnixMilli(load.SampledAtUnixMs),
+	}
 }
 
 // New は新しい Balancer を作成する
 func New(workers []string, timeout time.Duration) *Balancer {
 	return &Balancer{
-		workers:         workers,
-		lastWorkerIndex: -1,
-		timeout:         timeout,
+		workers:           workers,
+		lastWorkerIndex:   -1,
+		timeout:           timeout,
+		statusConcurrency: defaultStatusConcurrency,
+		statusTimeout:     defaultStatusTimeout,
+		creds:             insecure.NewCredentials(),
+		keepalive:         grpckeepalive.DefaultConfig(),
+		conns:             make(map[string]*grpc.ClientConn),
+	}
+}
+
+// SetStatusFanOut は StatusAll の同時実行数と Worker ごとのタイムアウトを設定する
+// 0 以下の値はデフォルト値として扱う
+func (b *Balancer) SetStatusFanOut(concurrency int, timeout time.Duration) {
+	if concurrency <= 0 {
+		concurrency = defaultStatusConcurrency
+	}
+	if timeout <= 0 {
+		timeout = defaultStatusTimeout
 	}
+	b.statusConcurrency = concurrency
+	b.statusTimeout = timeout
 }
 
-// SelectWorker は空いている Worker を選択する
-func (b *Balancer) SelectWorker(ctx context.Context) (string, *grpc.ClientConn, error) {
+// SetCompression は Worker との gRPC 通信で使用する圧縮方式を設定する
+// 空文字・"none" の場合は圧縮しない
+func (b *Balancer) SetCompression(name string) error {
+	normalized, err := grpccompress.Normalize(name)
+	if err != nil {
+		return err
+	}
+	b.compression = normalized
+	return nil
+}
+
+// SetKeepalive は Worker への接続に送信する keepalive ping の間隔と応答の待ち時間を設定する
+// 設定後に作成する接続から適用する
+func (b *Balancer) SetKeepalive(cfg grpckeepalive.Config) {
+	b.keepalive = cfg
+}
+
+// SetTLS は Worker との gRPC 通信で使用する TLS を設定する
+// クライアント証明書を指定すると mTLS になる。設定がない場合は暗号化しない
+func (b *Balancer) SetTLS(cfg grpctls.ClientConfig) error {
+	creds, err := grpctls.ClientCredentials(cfg)
+	if err != nil {
+		return err
+	}
+	b.creds = creds
+	return nil
+}
+
+// SetMaxCPUPercent は Worker 選択で CPU 使用率を考慮する閾値を設定する
+// 空きがあっても CPU 使用率が閾値以上の Worker は、閾値未満の空き Worker がない場合にのみ選択する
+// 0 以下を指定すると CPU 使用率を考慮しない（ジョブ数のみで選択する）
+func (b *Balancer) SetMaxCPUPercent(percent float64) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.maxCPUPercent = max(percent, 0)
+}
+
+// SelectWorker は空いている Worker を選択し、その状態と接続を返す
+// 接続は Balancer が管理するため、呼び出し側で Close しない
+func (b *Balancer) SelectWorker(ctx context.Context) (WorkerInfo, *grpc.ClientConn, error) {
+	return b.SelectWorkerFor(ctx, Capabilities{})
+}
+
+// SelectWorkerFor は required のフィルター・エンコーダーをすべて利用できる空き Worker を選択する
+// フィルター・エンコーダーを報告しない Worker（プローブが無効・失敗）は利用できるものとして扱う
+// 応答したすべての Worker が required を満たさない場合は *UnsupportedCapabilityError を返す
+// CPU 使用率の閾値が設定されている場合、閾値以上の Worker は他に空き Worker がないときに CPU 使用率が最も低いものを選択する
+func (b *Balancer) SelectWorkerFor(ctx context.Context, required Capabilities) (WorkerInfo, *grpc.ClientConn, error) {
 	b.mutex.Lock()
 	defer b.mutex.Unlock()
 
+	// responded は状態を返した Worker 数、incapable はそのうち必要なフィルター・エンコーダーを持たない Worker 数
+	responded, incapable := 0, 0
+	missing := make(map[string]bool)
+	// fallback は空きがあるが CPU が閾値以上の Worker のうち、CPU 使用率が最も低いもの
+	var fallback *candidate
+
 	startIdx := (b.lastWorkerIndex + 1) % len(b.workers)
 
 	for i := 0; i < len(b.workers); i++ {
@@ -55,49 +204,206 @@ func (b *Balancer) SelectWorker(ctx context.Context) (string, *grpc.ClientConn,
 			)
 			continue
 		}
+		responded++
+
+		// 必要なフィルター・エンコーダーを持たない Worker は空きに関わらず対象外
+		capabilities := capabilitiesFromProto(status.Capabilities)
+		if capabilities != nil && !required.IsEmpty() {
+			if names := capabilities.Missing(required); len(names) > 0 {
+				logger.Debug("Worker lacks required ffmpeg features",
+					zap.String("worker", worker),
+					zap.Strings("missing", names),
+				)
+				incapable++
+				for _, name := range names {
+					missing[name] = true
+				}
+				continue
+			}
+		}
 
 		// 空きがあるかチェック
 		if status.CurrentJobs < status.MaxConcurrentJobs {
-			b.lastWorkerIndex = idx
-			logger.Info("Selected worker",
-				zap.String("worker", worker),
-				zap.Int32("current_jobs", status.CurrentJobs),
-				zap.Int32("max_jobs", status.MaxConcurrentJobs),
-			)
-			return worker, conn, nil
+			info := workerInfoFromStatus(worker, status)
+
+			// CPU が閾値以上の Worker は候補として残し、閾値未満の Worker を探し続ける
+			if b.isCPUSaturated(info.Load) {
+				logger.Debug("Worker CPU is saturated, looking for another worker",
+					zap.String("worker", worker),
+					zap.Float64("cpu_percent", info.Load.CPUPercent),
+				)
+				if fallback == nil || info.Load.CPUPercent < fallback.info.Load.CPUPercent {
+					fallback = &candidate{idx: idx, info: info, conn: conn}
+				}
+				continue
+			}
+
+			return b.selected(idx, info, conn)
 		}
+	}
+
+	// 空き Worker がすべて CPU の閾値以上の場合は、CPU 使用率が最も低い Worker を選択する
+	if fallback != nil {
+		return b.selected(fallback.idx, fallback.info, fallback.conn)
+	}
+
+	// 応答した Worker がすべて必要なフィルター・エンコーダーを持たない場合は、空きを待っても送信先がない
+	if responded > 0 && incapable == responded {
+		return WorkerInfo{}, nil, newUnsupportedCapabilityError(missing)
+	}
+
+	return WorkerInfo{}, nil, fmt.Errorf("no available workers (all %d workers are busy)", len(b.workers))
+}
+
+// candidate は選択の候補として保持している Worker
+type candidate struct {
+	idx  int
+	info WorkerInfo
+	conn *grpc.ClientConn
+}
+
+// isCPUSaturated は Worker の CPU 使用率が閾値以上かを返す（閾値が未設定、または負荷の報告がない場合は false）
+func (b *Balancer) isCPUSaturated(load *Load) bool {
+	return b.maxCPUPercent > 0 && load != nil && load.CPUPercent >= b.maxCPUPercent
+}
+
+// selected は選択した Worker を記録して返す（mutex を保持した状態で呼ぶ）
+func (b *Balancer) selected(idx int, info WorkerInfo, conn *grpc.ClientConn) (WorkerInfo, *grpc.ClientConn, error) {
+	b.lastWorkerIndex = idx
+	fields := []zap.Field{
+		zap.String("worker", info.Address),
+		zap.Int32("current_jobs", info.CurrentJobs),
+		zap.Int32("max_jobs", info.MaxConcurrentJobs),
+	}
+	if info.Load != nil {
+		fields = append(fields, zap.Float64("cpu_percent", info.Load.CPUPercent))
+	}
+	logger.Info("Selected worker", fields...)
+	return info, conn, nil
+}
+
+// workerInfoFromStatus は Worker が返した状態を WorkerInfo に変換する
+func workerInfoFromStatus(addr string, status *workerv1.WorkerStatus) WorkerInfo {
+	return WorkerInfo{
+		Address:           addr,
+		WorkerID:          status.WorkerId,
+		Version:           status.Version,
+		CurrentJobs:       status.CurrentJobs,
+		MaxConcurrentJobs: status.MaxConcurrentJobs,
+		QueuedJobs:        status.QueuedJobs,
+		Available:         true,
+		Capabilities:      capabilitiesFromProto(status.Capabilities),
+		Load:              loadFromProto(status.HostLoad),
+	}
+}
+
+// StatusAll はすべての Worker の状態を並行して取得する
+// 同時接続数は statusConcurrency で制限され、各 Worker は statusTimeout でタイムアウトする
+// 応答しない Worker は Available=false として結果に含まれる（結果は登録順）
+func (b *Balancer) StatusAll(ctx context.Context) []WorkerInfo {
+	results := make([]WorkerInfo, len(b.workers))
+	sem := make(chan struct{}, b.statusConcurrency)
+	var wg sync.WaitGroup
+
+	for i, worker := range b.workers {
+		wg.Add(1)
+		go func(idx int, addr string) {
+			defer wg.Done()
 
-		// 空きがない場合は接続を閉じる
+			info := WorkerInfo{Address: addr}
+
+			// 同時実行数を制限
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				info.Error = ctx.Err().Error()
+				results[idx] = info
+				return
+			}
+
+			_, status, err := b.getWorkerStatusWithTimeout(ctx, addr, b.statusTimeout)
+			if err != nil {
+				logger.Warn("Failed to get worker status",
+					zap.String("worker", addr),
+					zap.Error(err),
+				)
+				info.Error = err.Error()
+				results[idx] = info
+				return
+			}
+
+			results[idx] = workerInfoFromStatus(addr, status)
+		}(i, worker)
+	}
+
+	wg.Wait()
+	return results
+}
+
+// Dial は指定した Worker への gRPC 接続を返す
+// 接続は Worker ごとに最初に使う際に作成し、keepalive ping で維持しながら Close まで使い回す（呼び出し側で Close しない）
+func (b *Balancer) Dial(workerAddr string) (*grpc.ClientConn, error) {
+	b.connMutex.Lock()
+	defer b.connMutex.Unlock()
+
+	if b.closed {
+		return nil, errBalancerClosed
+	}
+	// 誤って Close された接続は使えないため作成し直す
+	if conn, ok := b.conns[workerAddr]; ok && conn.GetState() != connectivity.Shutdown {
+		return conn, nil
+	}
+
+	opts := []grpc.DialOption{grpc.WithTransportCredentials(b.creds)}
+	opts = append(opts, grpccompress.DialOptions(b.compression)...)
+	opts = append(opts, grpckeepalive.DialOptions(b.keepalive)...)
+	conn, err := grpc.NewClient(workerAddr, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect: %w", err)
+	}
+	b.conns[workerAddr] = conn
+	return conn, nil
+}
+
+// Close はすべての Worker への接続を閉じる（以降の Dial・SelectWorker はエラーを返す）
+// 接続を使用中のストリームは中断されるため、シャットダウン時に呼ぶ
+func (b *Balancer) Close() error {
+	b.connMutex.Lock()
+	defer b.connMutex.Unlock()
+
+	b.closed = true
+	var errs []error
+	for addr, conn := range b.conns {
 		if err := conn.Close(); err != nil {
-			logger.Warn("Failed to close worker connection", zap.Error(err))
+			errs = append(errs, fmt.Errorf("failed to close connection to %s: %w", addr, err))
 		}
+		delete(b.conns, addr)
 	}
-
-	return "", nil, fmt.Errorf("no available workers (all %d workers are busy)", len(b.workers))
+	return errors.Join(errs...)
 }
 
 // getWorkerStatus は Worker の状態を取得する
 func (b *Balancer) getWorkerStatus(ctx context.Context, workerAddr string) (*grpc.ClientConn, *workerv1.WorkerStatus, error) {
+	return b.getWorkerStatusWithTimeout(ctx, workerAddr, b.timeout)
+}
+
+// getWorkerStatusWithTimeout は指定したタイムアウトで Worker の状態を取得する
+func (b *Balancer) getWorkerStatusWithTimeout(ctx context.Context, workerAddr string, timeout time.Duration) (*grpc.ClientConn, *workerv1.WorkerStatus, error) {
 	// タイムアウト付きコンテキスト
-	ctx, cancel := context.WithTimeout(ctx, b.timeout)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
-	// Worker に接続
-	conn, err := grpc.NewClient(
-		workerAddr,
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-	)
+	// Worker への接続を取得
+	conn, err := b.Dial(workerAddr)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to connect: %w", err)
+		return nil, nil, err
 	}
 
 	// 状態取得
 	client := workerv1.NewWorkerServiceClient(conn)
 	status, err := client.GetStatus(ctx, &workerv1.StatusRequest{})
 	if err != nil {
-		if closeErr := conn.Close(); closeErr != nil {
-			logger.Warn("Failed to close worker connection", zap.Error(closeErr))
-		}
 		return nil, nil, fmt.Errorf("failed to get status: %w", err)
 	}
 
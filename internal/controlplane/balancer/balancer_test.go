@@ -3,11 +3,14 @@ package balancer
 import (
 	"context"
 	"errors"
+	"net"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	workerv1 "github.com/nzws/flux-encoder/proto/worker/v1"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
 	"google.golang.org/grpc/test/bufconn"
 )
 
@@ -17,9 +20,35 @@ type mockWorkerServer struct {
 	currentJobs       int32
 	maxConcurrentJobs int32
 	shouldFail        bool
+	delay             time.Duration
+	capabilities      *workerv1.WorkerCapabilities
+	hostLoad          *workerv1.HostLoad
+
+	// 同時実行数の計測用（nil の場合は計測しない）
+	inflight    *int32
+	maxInflight *int32
 }
 
 func (m *mockWorkerServer) GetStatus(ctx context.Context, req *workerv1.StatusRequest) (*workerv1.WorkerStatus, error) {
+	if m.inflight != nil {
+		current := atomic.AddInt32(m.inflight, 1)
+		defer atomic.AddInt32(m.inflight, -1)
+		for {
+			prev := atomic.LoadInt32(m.maxInflight)
+			if current <= prev || atomic.CompareAndSwapInt32(m.maxInflight, prev, current) {
+				break
+			}
+		}
+	}
+
+	if m.delay > 0 {
+		select {
+		case <-time.After(m.delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
 	if m.shouldFail {
 		return nil, grpc.ErrServerStopped
 	}
@@ -29,6 +58,8 @@ func (m *mockWorkerServer) GetStatus(ctx context.Context, req *workerv1.StatusRe
 		MaxConcurrentJobs: m.maxConcurrentJobs,
 		WorkerId:          "test-worker",
 		Version:           "1.0.0",
+		Capabilities:      m.capabilities,
+		HostLoad:          m.hostLoad,
 	}, nil
 }
 
@@ -56,6 +87,28 @@ func startMockWorkerServer(t *testing.T, currentJobs, maxJobs int32, shouldFail
 	return server, lis, addr
 }
 
+// startTCPMockWorker は TCP のローカルポートでモック Worker を起動し、アドレスを返す
+func startTCPMockWorker(t *testing.T, mock *mockWorkerServer) string {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("リスナーの作成に失敗: %v", err)
+	}
+
+	server := grpc.NewServer()
+	workerv1.RegisterWorkerServiceServer(server, mock)
+
+	go func() {
+		if err := server.Serve(lis); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+			t.Logf("mock worker server stopped unexpectedly: %v", err)
+		}
+	}()
+	t.Cleanup(server.Stop)
+
+	return lis.Addr().String()
+}
+
 func Test空いているWorkerを選択できる(t *testing.T) {
 	// モック Worker を起動（空きあり）
 	server, lis, addr := startMockWorkerServer(t, 1, 5, false)
@@ -195,6 +248,108 @@ func Test複数のWorkerが登録される(t *testing.T) {
 	}
 }
 
+func TestStatusAllが遅いWorkerと失敗するWorkerを含めてタイムアウト内に部分結果を返す(t *testing.T) {
+	var inflight, maxInflight int32
+
+	var workers []string
+	healthy := 0
+	for i := 0; i < 12; i++ {
+		mock := &mockWorkerServer{
+			currentJobs:       1,
+			maxConcurrentJobs: 2,
+			inflight:          &inflight,
+			maxInflight:       &maxInflight,
+		}
+		switch {
+		case i%6 == 1:
+			// 応答が遅い Worker（タイムアウトする）
+			mock.delay = 5 * time.Second
+		case i%6 == 2:
+			// エラーを返す Worker
+			mock.shouldFail = true
+		default:
+			healthy++
+		}
+		workers = append(workers, startTCPMockWorker(t, mock))
+	}
+
+	b := New(workers, 60*time.Second)
+	b.SetStatusFanOut(4, 300*time.Millisecond)
+
+	start := time.Now()
+	results := b.StatusAll(context.Background())
+	elapsed := time.Since(start)
+
+	// 遅い Worker 2台分のタイムアウトを考慮しても十分短時間で完了するはず
+	if elapsed > 2*time.Second {
+		t.Errorf("StatusAll の完了に時間がかかりすぎている: %v", elapsed)
+	}
+
+	if len(results) != len(workers) {
+		t.Fatalf("結果数が一致しない: 期待値 %d, 取得値 %d", len(workers), len(results))
+	}
+
+	available := 0
+	for i, r := range results {
+		if r.Address != workers[i] {
+			t.Errorf("results[%d] のアドレスが登録順と一致しない: 期待値 %s, 取得値 %s", i, workers[i], r.Address)
+		}
+		if r.Available {
+			available++
+			if r.WorkerID != "test-worker" || r.MaxConcurrentJobs != 2 {
+				t.Errorf("results[%d] の状態が正しくない: %+v", i, r)
+			}
+		} else if r.Error == "" {
+			t.Errorf("results[%d] が利用不可なのにエラーが設定されていない", i)
+		}
+	}
+
+	if available != healthy {
+		t.Errorf("利用可能な Worker 数が一致しない: 期待値 %d, 取得値 %d", healthy, available)
+	}
+
+	if got := atomic.LoadInt32(&maxInflight); got > 4 {
+		t.Errorf("同時実行数が上限を超えた: 上限 4, 最大 %d", got)
+	}
+}
+
+func TestSetStatusFanOutが0以下の値でデフォルトを使用する(t *testing.T) {
+	b := New([]string{"localhost:50051"}, 5*time.Second)
+	b.SetStatusFanOut(0, 0)
+
+	if b.statusConcurrency != defaultStatusConcurrency {
+		t.Errorf("statusConcurrency が一致しない: 期待値 %d, 取得値 %d", defaultStatusConcurrency, b.statusConcurrency)
+	}
+	if b.statusTimeout != defaultStatusTimeout {
+		t.Errorf("statusTimeout が一致しない: 期待値 %v, 取得値 %v", defaultStatusTimeout, b.statusTimeout)
+	}
+}
+
+func TestStatusAllが到達不能なWorkerを利用不可として返す(t *testing.T) {
+	addr := startTCPMockWorker(t, &mockWorkerServer{currentJobs: 0, maxConcurrentJobs: 1})
+
+	// 使用されていないポートを確保して閉じる
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("リスナーの作成に失敗: %v", err)
+	}
+	unreachable := lis.Addr().String()
+	if err := lis.Close(); err != nil {
+		t.Fatalf("リスナーのクローズに失敗: %v", err)
+	}
+
+	b := New([]string{addr, unreachable}, 60*time.Second)
+	b.SetStatusFanOut(2, 500*time.Millisecond)
+
+	results := b.StatusAll(context.Background())
+	if !results[0].Available {
+		t.Errorf("稼働中の Worker が利用不可になっている: %+v", results[0])
+	}
+	if results[1].Available {
+		t.Errorf("到達不能な Worker が利用可能になっている: %+v", results[1])
+	}
+}
+
 // 統合テスト：実際の gRPC サーバーを使用したテスト
 // Note: これらのテストは実際のネットワーク接続を必要とするため、
 // CI/CD 環境では実行できない場合がある
@@ -215,3 +370,214 @@ func Test複数のWorkerが登録される(t *testing.T) {
 // }
 //
 // これにより、テストで statusGetter をモックに置き換えることができる
+
+func TestSelectWorkerForが必要なフィルターを持つWorkerのみを選択する(t *testing.T) {
+	required := Capabilities{Filters: []string{"tonemap"}, Encoders: []string{"libx264"}}
+	withoutTonemap := startTCPMockWorker(t, &mockWorkerServer{
+		maxConcurrentJobs: 2,
+		capabilities:      &workerv1.WorkerCapabilities{Filters: []string{"scale"}, Encoders: []string{"libx264"}},
+	})
+	withTonemap := startTCPMockWorker(t, &mockWorkerServer{
+		maxConcurrentJobs: 2,
+		capabilities:      &workerv1.WorkerCapabilities{Filters: []string{"scale", "tonemap"}, Encoders: []string{"libx264"}},
+	})
+	b := New([]string{withoutTonemap, withTonemap}, time.Second)
+
+	for i := 0; i < 2; i++ {
+		info, _, err := b.SelectWorkerFor(context.Background(), required)
+		if err != nil {
+			t.Fatalf("Worker の選択に失敗: %v", err)
+		}
+		if info.Address != withTonemap {
+			t.Errorf("選択された Worker が一致しない: 期待値 %s, 取得値 %s", withTonemap, info.Address)
+		}
+	}
+}
+
+func TestSelectWorkerForが対応するWorkerがない場合にUnsupportedCapabilityErrorを返す(t *testing.T) {
+	required := Capabilities{Filters: []string{"tonemap"}, Encoders: []string{"libfdk_aac"}}
+	b := New([]string{
+		startTCPMockWorker(t, &mockWorkerServer{
+			maxConcurrentJobs: 2,
+			capabilities:      &workerv1.WorkerCapabilities{Filters: []string{"scale"}, Encoders: []string{"libfdk_aac"}},
+		}),
+		startTCPMockWorker(t, &mockWorkerServer{
+			maxConcurrentJobs: 2,
+			capabilities:      &workerv1.WorkerCapabilities{Filters: []string{"scale"}, Encoders: []string{"aac"}},
+		}),
+	}, time.Second)
+
+	_, _, err := b.SelectWorkerFor(context.Background(), required)
+	var unsupported *UnsupportedCapabilityError
+	if !errors.As(err, &unsupported) {
+		t.Fatalf("UnsupportedCapabilityError が返されない: %v", err)
+	}
+	want := []string{"encoder:libfdk_aac", "filter:tonemap"}
+	if len(unsupported.Missing) != len(want) || unsupported.Missing[0] != want[0] || unsupported.Missing[1] != want[1] {
+		t.Errorf("足りないフィルター・エンコーダーが一致しない: 期待値 %v, 取得値 %v", want, unsupported.Missing)
+	}
+
+	// 要件がなければ同じ Worker を選択できる
+	_, _, err = b.SelectWorker(context.Background())
+	if err != nil {
+		t.Fatalf("要件なしでの Worker の選択に失敗: %v", err)
+	}
+}
+
+func TestSelectWorkerForが対応するWorkerが満杯の場合は通常のエラーを返す(t *testing.T) {
+	required := Capabilities{Filters: []string{"tonemap"}}
+	b := New([]string{
+		startTCPMockWorker(t, &mockWorkerServer{
+			maxConcurrentJobs: 2,
+			capabilities:      &workerv1.WorkerCapabilities{Filters: []string{"scale"}},
+		}),
+		startTCPMockWorker(t, &mockWorkerServer{
+			currentJobs:       2,
+			maxConcurrentJobs: 2,
+			capabilities:      &workerv1.WorkerCapabilities{Filters: []string{"tonemap"}},
+		}),
+	}, time.Second)
+
+	_, _, err := b.SelectWorkerFor(context.Background(), required)
+	var unsupported *UnsupportedCapabilityError
+	if err == nil || errors.As(err, &unsupported) {
+		t.Errorf("空き待ちのエラーが返されない: %v", err)
+	}
+}
+
+func TestSelectWorkerForが能力を報告しないWorkerを対応済みとして扱う(t *testing.T) {
+	addr := startTCPMockWorker(t, &mockWorkerServer{maxConcurrentJobs: 1})
+	b := New([]string{addr}, time.Second)
+
+	info, _, err := b.SelectWorkerFor(context.Background(), Capabilities{Filters: []string{"tonemap"}})
+	if err != nil {
+		t.Fatalf("Worker の選択に失敗: %v", err)
+	}
+	if info.Address != addr || info.Capabilities != nil {
+		t.Errorf("選択結果が一致しない: %+v", info)
+	}
+}
+
+func TestSelectWorkerForがCPUの閾値以上のWorkerを後回しにする(t *testing.T) {
+	busyCPU := startTCPMockWorker(t, &mockWorkerServer{maxConcurrentJobs: 2, hostLoad: &workerv1.HostLoad{CpuPercent: 97}})
+	idleCPU := startTCPMockWorker(t, &mockWorkerServer{maxConcurrentJobs: 2, hostLoad: &workerv1.HostLoad{CpuPercent: 20}})
+	b := New([]string{busyCPU, idleCPU}, time.Second)
+	b.SetMaxCPUPercent(90)
+
+	// ラウンドロビンの順番に関わらず CPU に余裕のある Worker を選択する
+	for i := 0; i < 3; i++ {
+		info, _, err := b.SelectWorker(context.Background())
+		if err != nil {
+			t.Fatalf("Worker の選択に失敗: %v", err)
+		}
+		if info.Address != idleCPU {
+			t.Errorf("選択された Worker が一致しない: 期待値 %s, 取得値 %s", idleCPU, info.Address)
+		}
+		if info.Load == nil || info.Load.CPUPercent != 20 {
+			t.Errorf("負荷が一致しない: %+v", info.Load)
+		}
+	}
+}
+
+func TestSelectWorkerForがすべてCPUの閾値以上の場合は最もCPUの低いWorkerを選択する(t *testing.T) {
+	hotter := startTCPMockWorker(t, &mockWorkerServer{maxConcurrentJobs: 2, hostLoad: &workerv1.HostLoad{CpuPercent: 99}})
+	hot := startTCPMockWorker(t, &mockWorkerServer{maxConcurrentJobs: 2, hostLoad: &workerv1.HostLoad{CpuPercent: 92}})
+	full := startTCPMockWorker(t, &mockWorkerServer{currentJobs: 2, maxConcurrentJobs: 2, hostLoad: &workerv1.HostLoad{CpuPercent: 10}})
+	b := New([]string{hotter, hot, full}, time.Second)
+	b.SetMaxCPUPercent(90)
+
+	info, _, err := b.SelectWorker(context.Background())
+	if err != nil {
+		t.Fatalf("Worker の選択に失敗: %v", err)
+	}
+	if info.Address != hot {
+		t.Errorf("選択された Worker が一致しない: 期待値 %s, 取得値 %s", hot, info.Address)
+	}
+}
+
+func TestSelectWorkerForがCPUの閾値が未設定の場合は負荷を考慮しない(t *testing.T) {
+	busyCPU := startTCPMockWorker(t, &mockWorkerServer{maxConcurrentJobs: 2, hostLoad: &workerv1.HostLoad{CpuPercent: 97}})
+	noLoad := startTCPMockWorker(t, &mockWorkerServer{maxConcurrentJobs: 2})
+	b := New([]string{busyCPU, noLoad}, time.Second)
+
+	selected := make(map[string]bool)
+	for i := 0; i < 2; i++ {
+		info, _, err := b.SelectWorker(context.Background())
+		if err != nil {
+			t.Fatalf("Worker の選択に失敗: %v", err)
+		}
+		selected[info.Address] = true
+	}
+	if !selected[busyCPU] || !selected[noLoad] {
+		t.Errorf("ラウンドロビンで両方の Worker が選択されない: %v", selected)
+	}
+}
+
+func TestSelectWorkerが同じWorkerへの接続を使い回す(t *testing.T) {
+	addr := startTCPMockWorker(t, &mockWorkerServer{maxConcurrentJobs: 2})
+	b := New([]string{addr}, time.Second)
+	t.Cleanup(func() { _ = b.Close() })
+
+	_, first, err := b.SelectWorker(context.Background())
+	if err != nil {
+		t.Fatalf("Worker の選択に失敗: %v", err)
+	}
+	_, second, err := b.SelectWorker(context.Background())
+	if err != nil {
+		t.Fatalf("Worker の選択に失敗: %v", err)
+	}
+	if first != second {
+		t.Error("同じ Worker への接続が使い回されない")
+	}
+
+	dialed, err := b.Dial(addr)
+	if err != nil {
+		t.Fatalf("接続の取得に失敗: %v", err)
+	}
+	if dialed != first {
+		t.Error("Dial が Worker 選択と同じ接続を返さない")
+	}
+}
+
+func TestCloseがすべての接続を閉じ以降の接続を拒否する(t *testing.T) {
+	addr := startTCPMockWorker(t, &mockWorkerServer{maxConcurrentJobs: 2})
+	b := New([]string{addr}, time.Second)
+
+	_, conn, err := b.SelectWorker(context.Background())
+	if err != nil {
+		t.Fatalf("Worker の選択に失敗: %v", err)
+	}
+
+	if err := b.Close(); err != nil {
+		t.Fatalf("Close に失敗: %v", err)
+	}
+	if state := conn.GetState(); state != connectivity.Shutdown {
+		t.Errorf("接続が閉じられていない: %v", state)
+	}
+	if _, err := b.Dial(addr); !errors.Is(err, errBalancerClosed) {
+		t.Errorf("Close 後の接続でエラーが返されない: %v", err)
+	}
+	if _, _, err := b.SelectWorker(context.Background()); err == nil {
+		t.Error("Close 後に Worker を選択できた")
+	}
+}
+
+func TestDialが閉じられた接続を作成し直す(t *testing.T) {
+	addr := startTCPMockWorker(t, &mockWorkerServer{maxConcurrentJobs: 2})
+	b := New([]string{addr}, time.Second)
+	t.Cleanup(func() { _ = b.Close() })
+
+	conn, err := b.Dial(addr)
+	if err != nil {
+		t.Fatalf("接続の取得に失敗: %v", err)
+	}
+	_ = conn.Close()
+
+	info, reopened, err := b.SelectWorker(context.Background())
+	if err != nil {
+		t.Fatalf("閉じられた接続の作成し直しに失敗: %v", err)
+	}
+	if reopened == conn || !info.Available {
+		t.Errorf("閉じられた接続が使われた: %+v", info)
+	}
+}
@@ -0,0 +1,74 @@
+package balancer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	workerv1 "github.com/nzws/flux-encoder/proto/worker/v1"
+)
+
+// Capabilities は ffmpeg のフィルター・エンコーダーの一覧
+// Worker が利用できるもの、またはジョブに必要なものを表す
+type Capabilities struct {
+	Filters  []string
+	Encoders []string
+}
+
+// IsEmpty は必要なフィルター・エンコーダーが1つもないかを返す
+func (c Capabilities) IsEmpty() bool {
+	return len(c.Filters) == 0 && len(c.Encoders) == 0
+}
+
+// Missing は required のうち c に含まれないものを "filter:tonemap"、"encoder:libfdk_aac" の形式で返す
+func (c Capabilities) Missing(required Capabilities) []string {
+	var missing []string
+	missing = appendMissing(missing, "filter", c.Filters, required.Filters)
+	missing = appendMissing(missing, "encoder", c.Encoders, required.Encoders)
+	return missing
+}
+
+func appendMissing(missing []string, kind string, available, required []string) []string {
+	set := make(map[string]bool, len(available))
+	for _, name := range available {
+		set[name] = true
+	}
+	for _, name := range required {
+		if !set[name] {
+			missing = append(missing, kind+":"+name)
+		}
+	}
+	return missing
+}
+
+// capabilitiesFromProto は Worker が報告したフィルター・エンコーダーを変換する（報告がない場合は nil）
+func capabilitiesFromProto(capabilities *workerv1.WorkerCapabilities) *Capabilities {
+	if capabilities == nil {
+		return nil
+	}
+	return &Capabilities{
+		Filters:  capabilities.Filters,
+		Encoders: capabilities.Encoders,
+	}
+}
+
+// UnsupportedCapabilityError は応答したすべての Worker がジョブに必要なフィルター・エンコーダーを持たないことを表す
+// 空き状況に関わらず送信先がないため、リトライしても成功しない
+type UnsupportedCapabilityError struct {
+	// Missing はいずれかの Worker で利用できなかったフィルター・エンコーダー
+	Missing []string
+}
+
+func (e *UnsupportedCapabilityError) Error() string {
+	return fmt.Sprintf("no worker supports the required ffmpeg features: %s", strings.Join(e.Missing, ", "))
+}
+
+// newUnsupportedCapabilityError は Worker ごとに足りなかったものをまとめたエラーを作成する
+func newUnsupportedCapabilityError(missing map[string]bool) *UnsupportedCapabilityError {
+	names := make([]string, 0, len(missing))
+	for name := range missing {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return &UnsupportedCapabilityError{Missing: names}
+}
@@ -0,0 +1,152 @@
+package execlimit
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	// DefaultMaxOutputBytes は外部コマンドの出力として読み込む最大サイズのデフォルト値
+	DefaultMaxOutputBytes = 10 << 20
+
+	// maxStderrBytes は Output で ExitError に含める stderr の最大サイズ
+	maxStderrBytes = 64 << 10
+)
+
+// ErrOutputTooLarge は外部コマンドの出力が上限を超えたことを示す
+var ErrOutputTooLarge = errors.New("command output too large")
+
+// maxOutputBytes は Output / CombinedOutput で読み込む出力の上限
+var maxOutputBytes atomic.Int64
+
+func init() {
+	maxOutputBytes.Store(DefaultMaxOutputBytes)
+}
+
+// SetMaxOutputBytes は外部コマンドの出力として読み込む最大サイズを設定する
+// 0 以下を指定するとデフォルト値に戻す
+func SetMaxOutputBytes(n int64) {
+	if n <= 0 {
+		n = DefaultMaxOutputBytes
+	}
+	maxOutputBytes.Store(n)
+}
+
+// MaxOutputBytes は現在の出力の上限を返す
+func MaxOutputBytes() int64 {
+	return maxOutputBytes.Load()
+}
+
+// Output は cmd.Output と同様にコマンドを実行して標準出力を返す
+// 標準出力が上限を超えた場合はプロセスを停止して ErrOutputTooLarge を返す
+// （細工された入力で ffprobe が巨大な出力を返してもメモリを使い果たさないようにする）
+func Output(cmd *exec.Cmd) ([]byte, error) {
+	if cmd.Stdout != nil {
+		return nil, errors.New("execlimit: Stdout already set")
+	}
+
+	stdout := &limitedBuffer{cmd: cmd, limit: MaxOutputBytes()}
+	cmd.Stdout = stdout
+
+	// stderr は ExitError に含めるため上限付きで保持する（超過分は捨てる）
+	var stderr *truncatingBuffer
+	if cmd.Stderr == nil {
+		stderr = &truncatingBuffer{limit: maxStderrBytes}
+		cmd.Stderr = stderr
+	}
+
+	err := cmd.Run()
+	if stdout.exceeded() {
+		return nil, fmt.Errorf("%w (limit %d bytes)", ErrOutputTooLarge, stdout.limit)
+	}
+	var exitErr *exec.ExitError
+	if stderr != nil && errors.As(err, &exitErr) {
+		exitErr.Stderr = stderr.Bytes()
+	}
+	return stdout.Bytes(), err
+}
+
+// CombinedOutput は cmd.CombinedOutput と同様にコマンドを実行して標準出力と標準エラー出力を返す
+// 出力が上限を超えた場合はプロセスを停止して ErrOutputTooLarge を返す
+func CombinedOutput(cmd *exec.Cmd) ([]byte, error) {
+	if cmd.Stdout != nil || cmd.Stderr != nil {
+		return nil, errors.New("execlimit: Stdout or Stderr already set")
+	}
+
+	output := &limitedBuffer{cmd: cmd, limit: MaxOutputBytes()}
+	cmd.Stdout = output
+	cmd.Stderr = output
+
+	err := cmd.Run()
+	if output.exceeded() {
+		return nil, fmt.Errorf("%w (limit %d bytes)", ErrOutputTooLarge, output.limit)
+	}
+	return output.Bytes(), err
+}
+
+// limitedBuffer は上限までの出力を保持し、超過した時点でプロセスを停止する io.Writer
+type limitedBuffer struct {
+	cmd   *exec.Cmd
+	limit int64
+
+	mu   sync.Mutex
+	buf  bytes.Buffer
+	over bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.over {
+		return 0, ErrOutputTooLarge
+	}
+	if int64(b.buf.Len())+int64(len(p)) > b.limit {
+		b.over = true
+		// パイプへの書き込みで停止したままにならないようプロセスを終了させる
+		if b.cmd.Process != nil {
+			_ = b.cmd.Process.Kill()
+		}
+		return 0, ErrOutputTooLarge
+	}
+	return b.buf.Write(p)
+}
+
+func (b *limitedBuffer) Bytes() []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.Bytes()
+}
+
+func (b *limitedBuffer) exceeded() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.over
+}
+
+// truncatingBuffer は上限までの出力を保持し、超過分を捨てる io.Writer
+type truncatingBuffer struct {
+	limit int
+	buf   bytes.Buffer
+}
+
+func (b *truncatingBuffer) Write(p []byte) (int, error) {
+	if remaining := b.limit - b.buf.Len(); remaining > 0 {
+		if len(p) > remaining {
+			b.buf.Write(p[:remaining])
+		} else {
+			b.buf.Write(p)
+		}
+	}
+	return len(p), nil
+}
+
+func (b *truncatingBuffer) Bytes() []byte {
+	return b.buf.Bytes()
+}
@@ -0,0 +1,87 @@
+package execlimit
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+// setMaxOutputBytesForTest はテスト中のみ出力の上限を変更する
+func setMaxOutputBytesForTest(t *testing.T, n int64) {
+	t.Helper()
+	prev := MaxOutputBytes()
+	SetMaxOutputBytes(n)
+	t.Cleanup(func() { SetMaxOutputBytes(prev) })
+}
+
+func Test上限以内の出力はそのまま返る(t *testing.T) {
+	setMaxOutputBytesForTest(t, 1024)
+
+	output, err := Output(exec.Command("sh", "-c", "echo hello"))
+	if err != nil {
+		t.Fatalf("予期しないエラー: %v", err)
+	}
+	if string(output) != "hello\n" {
+		t.Errorf("出力が一致しない: 期待値 %q, 取得値 %q", "hello\n", output)
+	}
+}
+
+func Test上限を超える出力を続けるコマンドは停止されてエラーになる(t *testing.T) {
+	setMaxOutputBytesForTest(t, 64*1024)
+
+	// 終了しない巨大な出力（細工された入力に対する ffprobe を想定）
+	done := make(chan error, 1)
+	go func() {
+		_, err := Output(exec.Command("yes", strings.Repeat("x", 1000)))
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, ErrOutputTooLarge) {
+			t.Errorf("ErrOutputTooLarge が返されない: %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("上限を超えてもコマンドが停止されない")
+	}
+}
+
+func TestCombinedOutputでstderrも上限に含まれる(t *testing.T) {
+	setMaxOutputBytesForTest(t, 1024)
+
+	_, err := CombinedOutput(exec.Command("sh", "-c", "head -c 4096 /dev/zero >&2"))
+	if !errors.Is(err, ErrOutputTooLarge) {
+		t.Errorf("ErrOutputTooLarge が返されない: %v", err)
+	}
+
+	output, err := CombinedOutput(exec.Command("sh", "-c", "echo out; echo err >&2"))
+	if err != nil {
+		t.Fatalf("予期しないエラー: %v", err)
+	}
+	if !strings.Contains(string(output), "out") || !strings.Contains(string(output), "err") {
+		t.Errorf("stdout と stderr の両方が含まれていない: %q", output)
+	}
+}
+
+func Test失敗したコマンドのstderrがExitErrorに含まれる(t *testing.T) {
+	_, err := Output(exec.Command("sh", "-c", "echo 'invalid data' >&2; exit 1"))
+
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		t.Fatalf("ExitError が返されない: %v", err)
+	}
+	if strings.TrimSpace(string(exitErr.Stderr)) != "invalid data" {
+		t.Errorf("stderr が一致しない: 期待値 %q, 取得値 %q", "invalid data", exitErr.Stderr)
+	}
+}
+
+func TestSetMaxOutputBytesに0以下を指定するとデフォルト値に戻る(t *testing.T) {
+	setMaxOutputBytesForTest(t, 1)
+
+	SetMaxOutputBytes(0)
+	if got := MaxOutputBytes(); got != DefaultMaxOutputBytes {
+		t.Errorf("上限が一致しない: 期待値 %d, 取得値 %d", DefaultMaxOutputBytes, got)
+	}
+}
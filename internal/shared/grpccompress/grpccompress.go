@@ -0,0 +1,37 @@
+package grpccompress
+
+import (
+	"fmt"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/encoding"
+	// gzip コンプレッサーを登録する
+	_ "google.golang.org/grpc/encoding/gzip"
+)
+
+// Normalize は GRPC_COMPRESSION の値を検証し、コンプレッサー名を返す
+// 空文字・"none"・"identity" は圧縮無効として空文字を返す
+func Normalize(name string) (string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	switch name {
+	case "", "none", "identity":
+		return "", nil
+	}
+
+	if encoding.GetCompressor(name) == nil {
+		return "", fmt.Errorf("unsupported grpc compression: %s", name)
+	}
+	return name, nil
+}
+
+// DialOptions は圧縮設定に応じたクライアント接続オプションを返す
+// name が空の場合は何も返さない（圧縮なし）
+func DialOptions(name string) []grpc.DialOption {
+	if name == "" {
+		return nil
+	}
+	return []grpc.DialOption{
+		grpc.WithDefaultCallOptions(grpc.UseCompressor(name)),
+	}
+}
@@ -0,0 +1,87 @@
+package grpckeepalive
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
+)
+
+const (
+	// DefaultPingInterval は Control Plane が Worker への接続に keepalive ping を送信する間隔のデフォルト値
+	// 長時間進捗が届かないストリームや待機中の接続が、途中のロードバランサーなどに切断されないようにする
+	DefaultPingInterval = 30 * time.Second
+	// DefaultPingTimeout は ping の応答を待つ時間のデフォルト値（応答がない場合は接続を切断し、次の呼び出しで再接続する）
+	DefaultPingTimeout = 10 * time.Second
+)
+
+// Config は keepalive ping の設定
+type Config struct {
+	// Interval は ping を送信する間隔（Worker は Interval / 2 より短い間隔の ping を送るクライアントを切断する）
+	Interval time.Duration
+	// Timeout は ping の応答を待つ時間
+	Timeout time.Duration
+}
+
+// DefaultConfig はデフォルトの keepalive ping の設定を返す
+func DefaultConfig() Config {
+	return Config{
+		Interval: DefaultPingInterval,
+		Timeout:  DefaultPingTimeout,
+	}
+}
+
+// ParseConfig は GRPC_KEEPALIVE_INTERVAL・GRPC_KEEPALIVE_TIMEOUT の値（秒）から設定を作成する
+// 空文字の項目はデフォルト値を使用する。正の整数でない場合はエラーを返す
+func ParseConfig(interval, timeout string) (Config, error) {
+	cfg := DefaultConfig()
+
+	var err error
+	if cfg.Interval, err = parseSeconds("GRPC_KEEPALIVE_INTERVAL", interval, cfg.Interval); err != nil {
+		return Config{}, err
+	}
+	if cfg.Timeout, err = parseSeconds("GRPC_KEEPALIVE_TIMEOUT", timeout, cfg.Timeout); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+// parseSeconds は秒数の文字列を time.Duration に変換する（空文字の場合は defaultValue）
+func parseSeconds(name, value string, defaultValue time.Duration) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return defaultValue, nil
+	}
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds <= 0 {
+		return 0, fmt.Errorf("invalid %s: %q (must be a positive number of seconds)", name, value)
+	}
+	return time.Duration(seconds) * time.Second, nil
+}
+
+// DialOptions は keepalive ping を送信するクライアント接続オプションを返す
+// ストリームがない待機中の接続にも ping を送信する
+func DialOptions(cfg Config) []grpc.DialOption {
+	return []grpc.DialOption{
+		grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:                cfg.Interval,
+			Timeout:             cfg.Timeout,
+			PermitWithoutStream: true,
+		}),
+	}
+}
+
+// ServerOptions は DialOptions の ping を受け付けるサーバーオプションを返す
+// gRPC のデフォルト（5 分より短い間隔の ping を拒否する）では、DialOptions を使うクライアントが切断される
+// 送信側と多少設定が異なっても切断しないよう、cfg.Interval の半分以上の間隔の ping を受け付ける
+func ServerOptions(cfg Config) []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             cfg.Interval / 2,
+			PermitWithoutStream: true,
+		}),
+	}
+}
@@ -0,0 +1,58 @@
+package grpckeepalive
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test未指定の項目はデフォルト値になる(t *testing.T) {
+	cfg, err := ParseConfig("", " ")
+	if err != nil {
+		t.Fatalf("設定の解析に失敗: %v", err)
+	}
+	if cfg != DefaultConfig() {
+		t.Errorf("設定が一致しない: 期待値 %+v, 取得値 %+v", DefaultConfig(), cfg)
+	}
+	if cfg.Interval != 30*time.Second || cfg.Timeout != 10*time.Second {
+		t.Errorf("デフォルト値が一致しない: %+v", cfg)
+	}
+}
+
+func Test秒数で指定した間隔とタイムアウトを解析できる(t *testing.T) {
+	cfg, err := ParseConfig("60", "5")
+	if err != nil {
+		t.Fatalf("設定の解析に失敗: %v", err)
+	}
+	if cfg.Interval != time.Minute {
+		t.Errorf("間隔が一致しない: 期待値 %v, 取得値 %v", time.Minute, cfg.Interval)
+	}
+	if cfg.Timeout != 5*time.Second {
+		t.Errorf("タイムアウトが一致しない: 期待値 %v, 取得値 %v", 5*time.Second, cfg.Timeout)
+	}
+}
+
+func Test不正な値はエラーになる(t *testing.T) {
+	testCases := []struct {
+		name     string
+		interval string
+		timeout  string
+		message  string
+	}{
+		{name: "数値でない間隔", interval: "30s", message: "GRPC_KEEPALIVE_INTERVAL"},
+		{name: "0 の間隔", interval: "0", message: "GRPC_KEEPALIVE_INTERVAL"},
+		{name: "負のタイムアウト", timeout: "-1", message: "GRPC_KEEPALIVE_TIMEOUT"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParseConfig(tc.interval, tc.timeout)
+			if err == nil {
+				t.Fatal("不正な値でエラーが返されなかった")
+			}
+			if !strings.Contains(err.Error(), tc.message) {
+				t.Errorf("エラーメッセージに環境変数名が含まれていない: %v", err)
+			}
+		})
+	}
+}
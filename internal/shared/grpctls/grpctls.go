@@ -0,0 +1,121 @@
+package grpctls
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// ClientConfig は Control Plane から Worker への gRPC 接続の TLS 設定
+type ClientConfig struct {
+	// CAFile は Worker のサーバー証明書を検証する CA 証明書（空の場合はシステムの CA を使用する）
+	CAFile string
+	// CertFile と KeyFile は Worker に提示するクライアント証明書と秘密鍵（mTLS）
+	CertFile string
+	KeyFile  string
+	// ServerName はサーバー証明書の検証に使用するホスト名（空の場合は接続先のホスト名）
+	ServerName string
+}
+
+// Enabled は TLS の設定があるかを返す
+func (c ClientConfig) Enabled() bool {
+	return c.CAFile != "" || c.CertFile != "" || c.KeyFile != ""
+}
+
+// ServerConfig は Worker の gRPC サーバーの TLS 設定
+type ServerConfig struct {
+	// CertFile と KeyFile はサーバー証明書と秘密鍵
+	CertFile string
+	KeyFile  string
+	// ClientCAFile はクライアント証明書を検証する CA 証明書（指定した場合はクライアント証明書を必須にする）
+	ClientCAFile string
+}
+
+// Enabled は TLS の設定があるかを返す
+func (c ServerConfig) Enabled() bool {
+	return c.CertFile != "" || c.KeyFile != "" || c.ClientCAFile != ""
+}
+
+// ClientCredentials は Worker への接続に使用する認証情報を返す
+// TLS の設定がない場合は暗号化しない（insecure）
+func ClientCredentials(cfg ClientConfig) (credentials.TransportCredentials, error) {
+	if !cfg.Enabled() {
+		return insecure.NewCredentials(), nil
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: cfg.ServerName,
+	}
+	if cfg.CAFile != "" {
+		pool, err := loadCertPool(cfg.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if cfg.CertFile != "" || cfg.KeyFile != "" {
+		cert, err := loadKeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return credentials.NewTLS(tlsConfig), nil
+}
+
+// ServerOptions は TLS の設定に応じたサーバーオプションを返す
+// TLS の設定がない場合は何も返さない（暗号化しない）
+func ServerOptions(cfg ServerConfig) ([]grpc.ServerOption, error) {
+	if !cfg.Enabled() {
+		return nil, nil
+	}
+
+	cert, err := loadKeyPair(cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+	}
+	if cfg.ClientCAFile != "" {
+		pool, err := loadCertPool(cfg.ClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return []grpc.ServerOption{grpc.Creds(credentials.NewTLS(tlsConfig))}, nil
+}
+
+// loadKeyPair は証明書と秘密鍵を読み込む（片方のみの指定はエラー）
+func loadKeyPair(certFile, keyFile string) (tls.Certificate, error) {
+	if certFile == "" || keyFile == "" {
+		return tls.Certificate{}, fmt.Errorf("both certificate and key must be set (cert: %q, key: %q)", certFile, keyFile)
+	}
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("failed to load certificate: %w", err)
+	}
+	return cert, nil
+}
+
+// loadCertPool は PEM 形式の CA 証明書を読み込む
+func loadCertPool(caFile string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA certificate: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no valid certificates found in %s", caFile)
+	}
+	return pool, nil
+}
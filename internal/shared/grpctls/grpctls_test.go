@@ -0,0 +1,182 @@
+package grpctls
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// testCerts はテスト用の CA と、その CA で署名したサーバー・クライアント証明書のファイルパス
+type testCerts struct {
+	caFile     string
+	serverCert string
+	serverKey  string
+	clientCert string
+	clientKey  string
+}
+
+// writeTestCerts はテスト用の CA・サーバー証明書（localhost）・クライアント証明書を作成する
+func writeTestCerts(t *testing.T) testCerts {
+	t.Helper()
+
+	dir := t.TempDir()
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("CA の鍵の作成に失敗: %v", err)
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "flux-encoder test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("CA 証明書の作成に失敗: %v", err)
+	}
+	caCert, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		t.Fatalf("CA 証明書のパースに失敗: %v", err)
+	}
+
+	certs := testCerts{caFile: filepath.Join(dir, "ca.pem")}
+	writePEM(t, certs.caFile, "CERTIFICATE", caDER)
+
+	issue := func(serial int64, name string, usage x509.ExtKeyUsage, dnsNames []string) (string, string) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatalf("鍵の作成に失敗: %v", err)
+		}
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(serial),
+			Subject:      pkix.Name{CommonName: name},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+			DNSNames:     dnsNames,
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+		if err != nil {
+			t.Fatalf("証明書の作成に失敗: %v", err)
+		}
+		keyDER, err := x509.MarshalECPrivateKey(key)
+		if err != nil {
+			t.Fatalf("鍵のエンコードに失敗: %v", err)
+		}
+		certFile := filepath.Join(dir, name+".pem")
+		keyFile := filepath.Join(dir, name+"-key.pem")
+		writePEM(t, certFile, "CERTIFICATE", der)
+		writePEM(t, keyFile, "EC PRIVATE KEY", keyDER)
+		return certFile, keyFile
+	}
+	certs.serverCert, certs.serverKey = issue(2, "worker", x509.ExtKeyUsageServerAuth, []string{"localhost"})
+	certs.clientCert, certs.clientKey = issue(3, "controlplane", x509.ExtKeyUsageClientAuth, nil)
+	return certs
+}
+
+func writePEM(t *testing.T, path, blockType string, der []byte) {
+	t.Helper()
+
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600); err != nil {
+		t.Fatalf("PEM の書き込みに失敗: %v", err)
+	}
+}
+
+// checkHealth は bufconn 上で TLS の gRPC サーバーを起動し、クライアント設定で接続してヘルスチェックを呼び出す
+func checkHealth(t *testing.T, serverCfg ServerConfig, clientCfg ClientConfig) error {
+	t.Helper()
+
+	serverOpts, err := ServerOptions(serverCfg)
+	if err != nil {
+		t.Fatalf("サーバーオプションの作成に失敗: %v", err)
+	}
+	lis := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer(serverOpts...)
+	healthpb.RegisterHealthServer(server, health.NewServer())
+	go func() {
+		_ = server.Serve(lis)
+	}()
+	t.Cleanup(server.Stop)
+
+	creds, err := ClientCredentials(clientCfg)
+	if err != nil {
+		t.Fatalf("クライアントの認証情報の作成に失敗: %v", err)
+	}
+	conn, err := grpc.NewClient("passthrough:///localhost",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(creds),
+	)
+	if err != nil {
+		t.Fatalf("接続の作成に失敗: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err = healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+	return err
+}
+
+func TestMTLSで接続できる(t *testing.T) {
+	certs := writeTestCerts(t)
+
+	err := checkHealth(t,
+		ServerConfig{CertFile: certs.serverCert, KeyFile: certs.serverKey, ClientCAFile: certs.caFile},
+		ClientConfig{CAFile: certs.caFile, CertFile: certs.clientCert, KeyFile: certs.clientKey},
+	)
+	if err != nil {
+		t.Fatalf("mTLS での呼び出しに失敗: %v", err)
+	}
+}
+
+func Testクライアント証明書がない接続はmTLSのサーバーに拒否される(t *testing.T) {
+	certs := writeTestCerts(t)
+
+	err := checkHealth(t,
+		ServerConfig{CertFile: certs.serverCert, KeyFile: certs.serverKey, ClientCAFile: certs.caFile},
+		ClientConfig{CAFile: certs.caFile},
+	)
+	if err == nil {
+		t.Fatal("クライアント証明書なしの呼び出しが成功した")
+	}
+}
+
+func TestTLSが未設定の場合は暗号化せずに接続する(t *testing.T) {
+	if err := checkHealth(t, ServerConfig{}, ClientConfig{}); err != nil {
+		t.Fatalf("暗号化なしでの呼び出しに失敗: %v", err)
+	}
+}
+
+func Test証明書と秘密鍵の片方のみの指定はエラーになる(t *testing.T) {
+	certs := writeTestCerts(t)
+
+	if _, err := ClientCredentials(ClientConfig{CAFile: certs.caFile, CertFile: certs.clientCert}); err == nil {
+		t.Error("クライアントの秘密鍵がなくてもエラーにならない")
+	}
+	if _, err := ServerOptions(ServerConfig{ClientCAFile: certs.caFile}); err == nil {
+		t.Error("サーバー証明書がなくてもエラーにならない")
+	}
+}
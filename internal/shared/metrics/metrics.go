@@ -24,6 +24,16 @@ var (
 		[]string{"preset"},
 	)
 
+	// PresetUsage は Worker に送信したジョブ数をプリセットごとに数える
+	// ラベルの種類が増えすぎないよう、組み込み以外のプリセットは上限を超えると other にまとめる（PRESET_USAGE_MAX_LABELS）
+	PresetUsage = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "flyencoder_preset_usage_total",
+			Help: "Total number of jobs dispatched to workers per preset",
+		},
+		[]string{"preset"},
+	)
+
 	// Worker メトリクス
 	ActiveJobs = promauto.NewGaugeVec(
 		prometheus.GaugeOpts{
@@ -33,6 +43,25 @@ var (
 		[]string{"worker_id"},
 	)
 
+	// QueuedJobs は実行枠が空くのを待っている Worker のジョブ数（JOB_QUEUE_SIZE のキュー）を優先度ごとに数える
+	QueuedJobs = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "flyencoder_worker_queued_jobs",
+			Help: "Number of jobs waiting for a free slot on worker",
+		},
+		[]string{"priority", "worker_id"}, // high, normal, low
+	)
+
+	// QueueWaitDuration はキューで待ったジョブが実行枠を得るまでの時間（キャンセルされたジョブは含めない）
+	QueueWaitDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "flyencoder_worker_queue_wait_seconds",
+			Help:    "Time jobs waited in the worker queue before getting a slot, in seconds",
+			Buckets: []float64{1, 5, 10, 30, 60, 120, 300, 600, 1800, 3600},
+		},
+		[]string{"priority", "worker_id"},
+	)
+
 	EncodingDuration = promauto.NewHistogramVec(
 		prometheus.HistogramOpts{
 			Name:    "flyencoder_encoding_duration_seconds",
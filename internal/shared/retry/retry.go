@@ -3,6 +3,7 @@ package retry
 import (
 	"context"
 	"fmt"
+	"math/rand/v2"
 	"time"
 
 	"github.com/nzws/flux-encoder/internal/shared/logger"
@@ -15,6 +16,11 @@ type Config struct {
 	InitialWait time.Duration // 初回待機時間
 	MaxWait     time.Duration // 最大待機時間
 	Multiplier  float64       // 待機時間の倍率
+	Jitter      float64       // 待機時間のゆらぎの割合（0.0〜1.0）。各待機時間を wait * (1 ± rand*Jitter) にする
+
+	// IsRetryable はエラーをリトライするかを判定する（nil の場合はすべてのエラーをリトライする）
+	// false を返したエラーは残りの試行を行わずに即座に返す
+	IsRetryable func(error) bool
 }
 
 // DefaultConfig はデフォルトのリトライ設定
@@ -23,6 +29,60 @@ var DefaultConfig = Config{
 	InitialWait: 1 * time.Second,
 	MaxWait:     30 * time.Second,
 	Multiplier:  2.0,
+	Jitter:      0.2,
+}
+
+// ジョブごとに指定できるリトライ設定の上限
+const (
+	// MaxAttemptsLimit は最大試行回数の上限
+	MaxAttemptsLimit = 10
+	// MaxWaitLimit は待機時間の上限
+	MaxWaitLimit = 5 * time.Minute
+)
+
+// WithOverrides は 0 以外の値で上書きしたリトライ設定を返す（待機時間はミリ秒で指定する）
+// 上限を超える値や負の値、初回待機時間が最大待機時間を超える組み合わせはエラーになる
+func (c Config) WithOverrides(maxAttempts int, initialWaitMs, maxWaitMs int64) (Config, error) {
+	limitMs := MaxWaitLimit.Milliseconds()
+	if maxAttempts < 0 || maxAttempts > MaxAttemptsLimit {
+		return Config{}, fmt.Errorf("max attempts must be between 1 and %d: %d", MaxAttemptsLimit, maxAttempts)
+	}
+	if initialWaitMs < 0 || initialWaitMs > limitMs {
+		return Config{}, fmt.Errorf("initial wait must be between 0 and %d ms: %d", limitMs, initialWaitMs)
+	}
+	if maxWaitMs < 0 || maxWaitMs > limitMs {
+		return Config{}, fmt.Errorf("max wait must be between 0 and %d ms: %d", limitMs, maxWaitMs)
+	}
+
+	if maxAttempts > 0 {
+		c.MaxAttempts = maxAttempts
+	}
+	if initialWaitMs > 0 {
+		c.InitialWait = time.Duration(initialWaitMs) * time.Millisecond
+	}
+	if maxWaitMs > 0 {
+		c.MaxWait = time.Duration(maxWaitMs) * time.Millisecond
+	}
+	if c.InitialWait > c.MaxWait {
+		return Config{}, fmt.Errorf("initial wait (%s) must not exceed max wait (%s)", c.InitialWait, c.MaxWait)
+	}
+	return c, nil
+}
+
+// configContextKey はコンテキストにリトライ設定を格納するキー
+type configContextKey struct{}
+
+// WithConfig はジョブごとのリトライ設定を付与したコンテキストを返す
+func WithConfig(ctx context.Context, config Config) context.Context {
+	return context.WithValue(ctx, configContextKey{}, config)
+}
+
+// FromContext はコンテキストに付与されたリトライ設定を返す（付与されていない場合は fallback）
+func FromContext(ctx context.Context, fallback Config) Config {
+	if config, ok := ctx.Value(configContextKey{}).(Config); ok {
+		return config
+	}
+	return fallback
 }
 
 // Do はexponential backoffでリトライを実行する
@@ -38,15 +98,23 @@ func Do(ctx context.Context, config Config, fn func() error) error {
 
 		lastErr = err
 
+		// リトライしても成功しないエラー（権限不足など）は即座に返す
+		if config.IsRetryable != nil && !config.IsRetryable(err) {
+			return fmt.Errorf("non-retryable error (attempt %d): %w", attempt, err)
+		}
+
 		// 最後の試行ならリトライしない
 		if attempt == config.MaxAttempts {
 			break
 		}
 
+		// 複数の Worker が同時にリトライしないよう待機時間をずらす
+		jittered := jitteredWait(wait, config, rand.Float64)
+
 		logger.Warn("Operation failed, retrying",
 			zap.Int("attempt", attempt),
 			zap.Int("max_attempts", config.MaxAttempts),
-			zap.Duration("wait", wait),
+			zap.Duration("wait", jittered),
 			zap.Error(err),
 		)
 
@@ -54,7 +122,7 @@ func Do(ctx context.Context, config Config, fn func() error) error {
 		select {
 		case <-ctx.Done():
 			return fmt.Errorf("retry cancelled: %w", ctx.Err())
-		case <-time.After(wait):
+		case <-time.After(jittered):
 		}
 
 		// 次回の待機時間を計算（exponential backoff）
@@ -66,3 +134,16 @@ func Do(ctx context.Context, config Config, fn func() error) error {
 
 	return fmt.Errorf("max retry attempts reached (%d): %w", config.MaxAttempts, lastErr)
 }
+
+// jitteredWait は待機時間に Jitter の割合のゆらぎを加える（MaxWait を超えない）
+// random は [0.0, 1.0) の乱数を返す関数
+func jitteredWait(wait time.Duration, config Config, random func() float64) time.Duration {
+	jitter := min(max(config.Jitter, 0), 1)
+	if jitter > 0 {
+		wait = time.Duration(float64(wait) * (1 + (2*random()-1)*jitter))
+	}
+	if config.MaxWait > 0 && wait > config.MaxWait {
+		wait = config.MaxWait
+	}
+	return wait
+}
@@ -3,6 +3,7 @@ package retry
 import (
 	"context"
 	"errors"
+	"math/rand/v2"
 	"testing"
 	"time"
 )
@@ -221,6 +222,9 @@ func TestDefaultConfigが適切に設定されている(t *testing.T) {
 	if DefaultConfig.Multiplier != 2.0 {
 		t.Errorf("DefaultConfig.Multiplier = %f, 期待値: 2.0", DefaultConfig.Multiplier)
 	}
+	if DefaultConfig.Jitter != 0.2 {
+		t.Errorf("DefaultConfig.Jitter = %f, 期待値: 0.2", DefaultConfig.Jitter)
+	}
 }
 
 func Test最大試行回数が1の場合はリトライしない(t *testing.T) {
@@ -245,3 +249,146 @@ func Test最大試行回数が1の場合はリトライしない(t *testing.T) {
 		t.Errorf("MaxAttempts=1 なのに関数が %d 回呼ばれた", callCount)
 	}
 }
+
+func Testジッター付きの待機時間が範囲内に収まる(t *testing.T) {
+	config := Config{
+		InitialWait: 1 * time.Second,
+		MaxWait:     5 * time.Second,
+		Multiplier:  2.0,
+		Jitter:      0.2,
+	}
+
+	for _, wait := range []time.Duration{1 * time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second} {
+		lower := time.Duration(float64(wait) * (1 - config.Jitter))
+		upper := min(time.Duration(float64(wait)*(1+config.Jitter)), config.MaxWait)
+
+		seen := make(map[time.Duration]bool)
+		for range 1000 {
+			got := jitteredWait(wait, config, rand.Float64)
+			if got < lower || got > upper {
+				t.Fatalf("待機時間 %v のジッター適用後が範囲外: 期待値 [%v, %v], 取得値 %v", wait, lower, upper, got)
+			}
+			seen[got] = true
+		}
+		if len(seen) < 2 {
+			t.Errorf("待機時間 %v にジッターが適用されていない", wait)
+		}
+	}
+}
+
+func Testジッター付きの待機時間がMaxWaitを超えない(t *testing.T) {
+	config := Config{MaxWait: 5 * time.Second, Jitter: 1.0}
+
+	// 乱数の最大値でも MaxWait で頭打ちになる
+	got := jitteredWait(5*time.Second, config, func() float64 { return 0.999 })
+	if got != config.MaxWait {
+		t.Errorf("待機時間が一致しない: 期待値 %v, 取得値 %v", config.MaxWait, got)
+	}
+}
+
+func TestJitterが0の場合は待機時間を変えない(t *testing.T) {
+	config := Config{MaxWait: 5 * time.Second}
+
+	got := jitteredWait(2*time.Second, config, func() float64 { return 0.9 })
+	if got != 2*time.Second {
+		t.Errorf("待機時間が一致しない: 期待値 %v, 取得値 %v", 2*time.Second, got)
+	}
+}
+
+func TestリトライできないエラーはIsRetryableで即座に返す(t *testing.T) {
+	callCount := 0
+	permanentErr := errors.New("access denied")
+
+	config := Config{
+		MaxAttempts: 3,
+		InitialWait: 1 * time.Millisecond,
+		MaxWait:     100 * time.Millisecond,
+		Multiplier:  2.0,
+		IsRetryable: func(err error) bool { return !errors.Is(err, permanentErr) },
+	}
+
+	err := Do(context.Background(), config, func() error {
+		callCount++
+		return permanentErr
+	})
+	if !errors.Is(err, permanentErr) {
+		t.Fatalf("エラーが一致しない: 期待値 %v, 取得値 %v", permanentErr, err)
+	}
+	if callCount != 1 {
+		t.Errorf("リトライできないエラーなのに関数が %d 回呼ばれた（期待値: 1）", callCount)
+	}
+}
+
+func TestリトライできるエラーはIsRetryableを指定してもリトライする(t *testing.T) {
+	callCount := 0
+	permanentErr := errors.New("access denied")
+
+	config := Config{
+		MaxAttempts: 3,
+		InitialWait: 1 * time.Millisecond,
+		MaxWait:     100 * time.Millisecond,
+		Multiplier:  2.0,
+		IsRetryable: func(err error) bool { return !errors.Is(err, permanentErr) },
+	}
+
+	err := Do(context.Background(), config, func() error {
+		callCount++
+		if callCount == 1 {
+			return errors.New("service unavailable")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Errorf("2回目で成功するはずがエラーが返された: %v", err)
+	}
+	if callCount != 2 {
+		t.Errorf("関数が %d 回呼ばれた（期待値: 2）", callCount)
+	}
+}
+
+func TestWithOverridesで0以外の値が上書きされる(t *testing.T) {
+	config, err := DefaultConfig.WithOverrides(5, 200, 0)
+	if err != nil {
+		t.Fatalf("エラーが返された: %v", err)
+	}
+	if config.MaxAttempts != 5 || config.InitialWait != 200*time.Millisecond {
+		t.Errorf("上書きした値が一致しない: %+v", config)
+	}
+	if config.MaxWait != DefaultConfig.MaxWait || config.Multiplier != DefaultConfig.Multiplier || config.Jitter != DefaultConfig.Jitter {
+		t.Errorf("指定していない値が既定値でない: %+v", config)
+	}
+}
+
+func TestWithOverridesで範囲外の値はエラーになる(t *testing.T) {
+	tests := []struct {
+		name          string
+		maxAttempts   int
+		initialWaitMs int64
+		maxWaitMs     int64
+	}{
+		{name: "試行回数が上限を超える", maxAttempts: MaxAttemptsLimit + 1},
+		{name: "試行回数が負", maxAttempts: -1},
+		{name: "初回待機時間が上限を超える", initialWaitMs: MaxWaitLimit.Milliseconds() + 1, maxWaitMs: MaxWaitLimit.Milliseconds()},
+		{name: "最大待機時間が負", maxWaitMs: -1},
+		{name: "初回待機時間が最大待機時間を超える", initialWaitMs: 2000, maxWaitMs: 1000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DefaultConfig.WithOverrides(tt.maxAttempts, tt.initialWaitMs, tt.maxWaitMs); err == nil {
+				t.Error("エラーが返されるべきだが nil だった")
+			}
+		})
+	}
+}
+
+func TestFromContextでコンテキストのリトライ設定が取得できる(t *testing.T) {
+	if got := FromContext(context.Background(), DefaultConfig); got.MaxAttempts != DefaultConfig.MaxAttempts {
+		t.Errorf("設定がない場合の MaxAttempts が一致しない: 期待値 %d, 取得値 %d", DefaultConfig.MaxAttempts, got.MaxAttempts)
+	}
+
+	ctx := WithConfig(context.Background(), Config{MaxAttempts: 7})
+	if got := FromContext(ctx, DefaultConfig); got.MaxAttempts != 7 {
+		t.Errorf("MaxAttempts が一致しない: 期待値 %d, 取得値 %d", 7, got.MaxAttempts)
+	}
+}
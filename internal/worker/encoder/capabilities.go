@@ -0,0 +1,76 @@
+package encoder
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/nzws/flux-encoder/internal/shared/execlimit"
+)
+
+// Capabilities は ffmpeg で利用できるフィルター・エンコーダー
+type Capabilities struct {
+	Filters  []string
+	Encoders []string
+}
+
+var (
+	// filterFlagsPattern は ffmpeg -filters の各行の先頭にあるフラグ（タイムライン・スライス・コマンド対応）
+	filterFlagsPattern = regexp.MustCompile(`^[T.][S.][C.]$`)
+	// encoderFlagsPattern は ffmpeg -encoders の各行の先頭にあるフラグ（種類と機能の 6 文字）
+	encoderFlagsPattern = regexp.MustCompile(`^[VAS.][F.][S.][X.][B.][D.]$`)
+)
+
+// ProbeCapabilities は ffmpegPath の ffmpeg で -filters / -encoders を実行し、利用できるフィルター・エンコーダーを返す
+// Control Plane がジョブの送信前に必要なフィルター・エンコーダーを持つ Worker か判定するために使用する
+func ProbeCapabilities(ctx context.Context, ffmpegPath string) (Capabilities, error) {
+	filters, err := runCapabilityProbe(ctx, ffmpegPath, "-filters")
+	if err != nil {
+		return Capabilities{}, err
+	}
+	encoders, err := runCapabilityProbe(ctx, ffmpegPath, "-encoders")
+	if err != nil {
+		return Capabilities{}, err
+	}
+
+	return Capabilities{
+		Filters:  parseCapabilityList(filters, filterFlagsPattern),
+		Encoders: parseCapabilityList(encoders, encoderFlagsPattern),
+	}, nil
+}
+
+// runCapabilityProbe は ffmpeg に一覧表示のオプションを渡して実行し、出力を返す
+func runCapabilityProbe(ctx context.Context, ffmpegPath, option string) (string, error) {
+	cmd := newFFmpegCommand(ctx, ffmpegPath, "-hide_banner", option)
+	output, err := execlimit.CombinedOutput(cmd)
+	if err != nil {
+		return "", fmt.Errorf("failed to run ffmpeg %s: %w: %s", option, err, strings.TrimSpace(string(output)))
+	}
+	return string(output), nil
+}
+
+// parseCapabilityList は ffmpeg -filters / -encoders の出力から名前の一覧を取り出す（重複を除いてソートする）
+// 各行は「フラグ 名前 説明」の形式で、フラグが flags に一致する行のみを対象にする
+// 凡例の行（"V..... = Video" など）は 2 列目が "=" のため除外する
+func parseCapabilityList(output string, flags *regexp.Regexp) []string {
+	seen := make(map[string]bool)
+	var names []string
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || !flags.MatchString(fields[0]) || fields[1] == "=" {
+			continue
+		}
+		if !seen[fields[1]] {
+			seen[fields[1]] = true
+			names = append(names, fields[1])
+		}
+	}
+
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,52 @@
+package encoder
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFFmpegのフィルター一覧からフィルター名が取り出される(t *testing.T) {
+	output := `Filters:
+  T.. = Timeline support
+  .S. = Slice threading
+  ..C = Command support
+  A = Audio input/output
+  V = Video input/output
+  N = Dynamic number and/or type of input/output
+  | = Source or sink filter
+ ... abench            A->A       Benchmark part of a filtergraph.
+ TSC scale             V->V       Scale the input video size and/or convert the image format.
+ ... split             V->N       Pass on the input to N video outputs.
+ .S. tonemap           V->V       Conversion to/from different dynamic ranges.
+ ... subtitles         V->V       Render text subtitles onto input video using the libass library.
+`
+	got := parseCapabilityList(output, filterFlagsPattern)
+	want := []string{"abench", "scale", "split", "subtitles", "tonemap"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("フィルター一覧が一致しない: 期待値 %v, 取得値 %v", want, got)
+	}
+}
+
+func TestFFmpegのエンコーダー一覧からエンコーダー名が取り出される(t *testing.T) {
+	output := `Encoders:
+ V..... = Video
+ A..... = Audio
+ S..... = Subtitle
+ .F.... = Frame-level multithreading
+ ..S... = Slice-level multithreading
+ ...X.. = Codec is experimental
+ ....B. = Supports draw_horiz_band
+ .....D = Supports direct rendering method 1
+ ------
+ V....D libx264              libx264 H.264 / AVC / MPEG-4 AVC / MPEG-4 part 10 (codec h264)
+ V....D libsvtav1            SVT-AV1(Scalable Video Technology for AV1) encoder (codec av1)
+ A....D aac                  AAC (Advanced Audio Coding)
+ A....D libfdk_aac           Fraunhofer FDK AAC (codec aac)
+ S..... mov_text             3GPP Timed Text subtitle
+`
+	got := parseCapabilityList(output, encoderFlagsPattern)
+	want := []string{"aac", "libfdk_aac", "libsvtav1", "libx264", "mov_text"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("エンコーダー一覧が一致しない: 期待値 %v, 取得値 %v", want, got)
+	}
+}
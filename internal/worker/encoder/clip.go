@@ -0,0 +1,125 @@
+package encoder
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Clip は入力から切り出してエンコードする範囲
+type Clip struct {
+	// Start は切り出しの開始位置（秒、0 の場合は先頭から）
+	Start float64
+	// Duration は切り出す長さ（秒、0 の場合は入力の最後まで）
+	Duration float64
+}
+
+// ParseClip は開始位置と長さの文字列から Clip を作成する（空の場合は指定なし）
+// 値は秒数（"90"、"12.5"）または時刻表記（"01:30"、"00:01:30.5"）で指定し、負の値と長さ 0 はエラーになる
+func ParseClip(start, duration string) (Clip, error) {
+	var clip Clip
+	if start != "" {
+		value, err := ParseClipTime(start)
+		if err != nil {
+			return Clip{}, fmt.Errorf("invalid start_time: %w", err)
+		}
+		clip.Start = value
+	}
+	if duration != "" {
+		value, err := ParseClipTime(duration)
+		if err != nil {
+			return Clip{}, fmt.Errorf("invalid duration: %w", err)
+		}
+		if value == 0 {
+			return Clip{}, fmt.Errorf("invalid duration: must be greater than 0")
+		}
+		clip.Duration = value
+	}
+	return clip, nil
+}
+
+// ParseClipTime は秒数（"90"、"12.5"）または時刻表記（"MM:SS"、"HH:MM:SS.ms"）を秒に変換する
+func ParseClipTime(value string) (float64, error) {
+	parts := strings.Split(strings.TrimSpace(value), ":")
+	if len(parts) > 3 {
+		return 0, fmt.Errorf("invalid time %q (must be seconds or HH:MM:SS)", value)
+	}
+
+	var seconds float64
+	for i, part := range parts {
+		last := i == len(parts)-1
+		number, err := strconv.ParseFloat(part, 64)
+		if err != nil || math.IsNaN(number) || math.IsInf(number, 0) || strings.ContainsAny(part, "eE+") {
+			return 0, fmt.Errorf("invalid time %q (must be seconds or HH:MM:SS)", value)
+		}
+		if number < 0 || strings.HasPrefix(part, "-") {
+			return 0, fmt.Errorf("invalid time %q: must not be negative", value)
+		}
+		// 時刻表記の場合、時・分は整数で、分・秒は 60 未満
+		if len(parts) > 1 {
+			if !last && number != math.Trunc(number) {
+				return 0, fmt.Errorf("invalid time %q (must be seconds or HH:MM:SS)", value)
+			}
+			if i > 0 && number >= 60 {
+				return 0, fmt.Errorf("invalid time %q: minutes and seconds must be less than 60", value)
+			}
+		}
+		seconds = seconds*60 + number
+	}
+	return seconds, nil
+}
+
+// IsZero は切り出しの指定がないかを返す
+func (c Clip) IsZero() bool {
+	return c.Start == 0 && c.Duration == 0
+}
+
+// inputArgs は入力を指定する ffmpeg 引数を返す
+// -ss は -i の前に置いて入力をシークし（デコードせずに読み飛ばすため速い）、-t は -i の後、プリセットの引数より前に置いて出力の長さを制限する
+func (c Clip) inputArgs(inputURL string) []string {
+	var args []string
+	if c.Start > 0 {
+		args = append(args, "-ss", formatSeconds(c.Start))
+	}
+	args = append(args, "-i", inputURL)
+	if c.Duration > 0 {
+		args = append(args, "-t", formatSeconds(c.Duration))
+	}
+	return args
+}
+
+// encodedDuration は入力の長さ（秒、0 は不明）から切り出した後の長さを返す（進捗の計算に使用する）
+func (c Clip) encodedDuration(sourceDuration float64) float64 {
+	if sourceDuration <= 0 {
+		return c.Duration
+	}
+	remaining := math.Max(sourceDuration-c.Start, 0)
+	if c.Duration > 0 && c.Duration < remaining {
+		return c.Duration
+	}
+	return remaining
+}
+
+// exceedsSource は切り出す範囲が入力の長さ（秒、0 は不明）を超えているかを返す
+func (c Clip) exceedsSource(sourceDuration float64) bool {
+	return sourceDuration > 0 && c.Start+c.Duration > sourceDuration
+}
+
+// thumbnailTimestamp はプリセットのサムネイルの時刻（出力の先頭からの時刻）を入力の時刻に変換する
+// 時刻を解析できない場合はそのまま返す
+func (c Clip) thumbnailTimestamp(timestamp string) string {
+	if c.Start <= 0 {
+		return timestamp
+	}
+	seconds, err := ParseClipTime(timestamp)
+	if err != nil {
+		return timestamp
+	}
+	return formatSeconds(c.Start + seconds)
+}
+
+// formatSeconds は秒数を ffmpeg の時間表記（"90"、"12.5"）にする
+func formatSeconds(seconds float64) string {
+	return strconv.FormatFloat(seconds, 'f', -1, 64)
+}
@@ -0,0 +1,128 @@
+package encoder
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/nzws/flux-encoder/internal/worker/preset"
+)
+
+func Test切り出しの時刻を秒数と時刻表記から解析できる(t *testing.T) {
+	testCases := []struct {
+		name  string
+		value string
+		want  float64
+	}{
+		{"整数の秒数", "90", 90},
+		{"小数の秒数", "12.5", 12.5},
+		{"0秒", "0", 0},
+		{"分と秒", "01:30", 90},
+		{"時分秒", "01:02:03", 3723},
+		{"ミリ秒付き", "00:00:05.25", 5.25},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ParseClipTime(tc.value)
+			if err != nil {
+				t.Fatalf("解析に失敗した: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("秒数が一致しない: 期待値 %v, 取得値 %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func Test不正な切り出しの時刻はエラーになる(t *testing.T) {
+	for _, value := range []string{"", "-5", "-00:10", "abc", "1:2:3:4", "00:60", "01:-30", "1.5:00", "NaN", "Inf", "1e3"} {
+		if _, err := ParseClipTime(value); err == nil {
+			t.Errorf("%q でエラーが返されなかった", value)
+		}
+	}
+}
+
+func Test切り出しの長さが0の場合はエラーになる(t *testing.T) {
+	if _, err := ParseClip("", "0"); err == nil {
+		t.Fatal("長さ 0 でエラーが返されなかった")
+	}
+	if _, err := ParseClip("-1", ""); err == nil {
+		t.Fatal("負の開始位置でエラーが返されなかった")
+	}
+
+	clip, err := ParseClip("", "")
+	if err != nil || !clip.IsZero() {
+		t.Fatalf("指定なしの場合はゼロ値になる必要がある: %+v, %v", clip, err)
+	}
+}
+
+func Test切り出す場合はssを入力の前にtを入力の後に置く(t *testing.T) {
+	clip, err := ParseClip("00:01:30", "12.5")
+	if err != nil {
+		t.Fatalf("解析に失敗した: %v", err)
+	}
+	p := preset.Preset{FFmpegArgs: []string{"-c:v", "libx264"}}
+
+	args := buildFFmpegArgs(nil, clip, "input.mp4", "output.mp4", p)
+	want := []string{"-ss", "90", "-i", "input.mp4", "-t", "12.5", "-progress", "pipe:2", "-y", "-c:v", "libx264", "output.mp4"}
+	if !slices.Equal(args, want) {
+		t.Errorf("引数が一致しない:\n期待値 %v\n取得値 %v", want, args)
+	}
+
+	pass1 := strings.Join(buildTwoPassArgs(nil, clip, "input.mp4", "output.mp4", "ffmpeg2pass", p, 1), " ")
+	if !strings.HasPrefix(pass1, "-ss 90 -i input.mp4 -t 12.5 ") {
+		t.Errorf("2パスエンコードの引数に切り出し範囲が含まれていない: %s", pass1)
+	}
+
+	measure := strings.Join(buildLoudnessMeasureArgs(nil, clip, "input.mp4", preset.LoudnessTarget(preset.Preset{})), " ")
+	if !strings.HasPrefix(measure, "-ss 90 -i input.mp4 -t 12.5 ") {
+		t.Errorf("ラウドネス測定の引数に切り出し範囲が含まれていない: %s", measure)
+	}
+}
+
+func Test切り出さない場合は引数が変わらない(t *testing.T) {
+	args := buildFFmpegArgs(nil, Clip{}, "input.mp4", "output.mp4", preset.Preset{})
+	want := []string{"-i", "input.mp4", "-progress", "pipe:2", "-y", "output.mp4"}
+	if !slices.Equal(args, want) {
+		t.Errorf("引数が一致しない:\n期待値 %v\n取得値 %v", want, args)
+	}
+}
+
+func Test進捗の計算に切り出した範囲の長さを使う(t *testing.T) {
+	testCases := []struct {
+		name   string
+		clip   Clip
+		source float64
+		want   float64
+		exceed bool
+	}{
+		{"開始位置のみ", Clip{Start: 30}, 100, 70, false},
+		{"長さのみ", Clip{Duration: 20}, 100, 20, false},
+		{"開始位置と長さ", Clip{Start: 30, Duration: 20}, 100, 20, false},
+		{"入力の長さを超える", Clip{Start: 90, Duration: 20}, 100, 10, true},
+		{"開始位置が入力の長さを超える", Clip{Start: 120}, 100, 0, true},
+		{"入力の長さが不明", Clip{Start: 30, Duration: 20}, 0, 20, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.clip.encodedDuration(tc.source); got != tc.want {
+				t.Errorf("長さが一致しない: 期待値 %v, 取得値 %v", tc.want, got)
+			}
+			if got := tc.clip.exceedsSource(tc.source); got != tc.exceed {
+				t.Errorf("範囲の超過の判定が一致しない: 期待値 %v, 取得値 %v", tc.exceed, got)
+			}
+		})
+	}
+}
+
+func Test切り出す場合はサムネイルの時刻を開始位置からの時刻にする(t *testing.T) {
+	clip := Clip{Start: 30}
+	if got := clip.thumbnailTimestamp("00:00:05"); got != "35" {
+		t.Errorf("サムネイルの時刻が一致しない: %s", got)
+	}
+	if got := (Clip{}).thumbnailTimestamp("00:00:05"); got != "00:00:05" {
+		t.Errorf("切り出さない場合はプリセットの時刻をそのまま使う必要がある: %s", got)
+	}
+}
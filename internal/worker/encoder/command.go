@@ -0,0 +1,76 @@
+package encoder
+
+import (
+	"net/url"
+	"strings"
+)
+
+// redactedValue は RedactCommand が秘匿した値の代わりに入れる文字列
+const redactedValue = "REDACTED"
+
+// sensitiveValueFlags は値に認証情報や暗号化キーを指定する ffmpeg のオプション
+// RedactCommand はこれらのオプションの値を丸ごと秘匿する
+var sensitiveValueFlags = map[string]bool{
+	"-headers":             true,
+	"-cookies":             true,
+	"-decryption_key":      true,
+	"-encryption_key":      true,
+	"-hls_enc_key":         true,
+	"-hls_enc_iv":          true,
+	"-cenc_decryption_key": true,
+	"-cenc_encryption_key": true,
+}
+
+// commandLine は ffmpeg 引数から実行するコマンド（先頭は実行ファイル名）を返す（newFFmpegCommand と同じく -nostdin を付ける）
+func (e *Encoder) commandLine(args []string) []string {
+	return append([]string{e.ffmpegPath}, withNoStdin(args)...)
+}
+
+// notifyCommand は onCommand が nil でなければ、args で実行するコマンドを渡す
+func (e *Encoder) notifyCommand(onCommand func(command []string), args []string) {
+	if onCommand != nil {
+		onCommand(e.commandLine(args))
+	}
+}
+
+// RedactCommand はコマンドから認証情報を取り除いたコピーを返す
+// URL のユーザー情報とクエリの値（署名付き URL の署名など）、認証情報や暗号化キーを指定するオプションの値を REDACTED に置き換える
+// クエリのキーは残すため、署名付き URL かどうかなどは判別できる
+func RedactCommand(command []string) []string {
+	redacted := make([]string, len(command))
+	for i, arg := range command {
+		if i > 0 && sensitiveValueFlags[command[i-1]] {
+			redacted[i] = redactedValue
+			continue
+		}
+		redacted[i] = redactURL(arg)
+	}
+	return redacted
+}
+
+// redactURL は arg が URL の場合、ユーザー情報とクエリの値を REDACTED に置き換えた URL を返す
+// URL でない場合（ローカルのパスやオプション）はそのまま返す
+func redactURL(arg string) string {
+	if !strings.Contains(arg, "://") {
+		return arg
+	}
+	u, err := url.Parse(arg)
+	if err != nil || u.Scheme == "" {
+		return arg
+	}
+	if u.User == nil && u.RawQuery == "" {
+		return arg
+	}
+
+	if u.User != nil {
+		u.User = url.User(redactedValue)
+	}
+	if u.RawQuery != "" {
+		query := u.Query()
+		for key := range query {
+			query[key] = []string{redactedValue}
+		}
+		u.RawQuery = query.Encode()
+	}
+	return u.String()
+}
@@ -0,0 +1,93 @@
+package encoder
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+
+	"github.com/nzws/flux-encoder/internal/worker/preset"
+)
+
+// Command はジョブで実行する ffmpeg のコマンド
+type Command struct {
+	// Args は ffmpeg 自体を先頭に含むコマンドの引数（argv）
+	Args []string
+	// Dir は ffmpeg を実行するディレクトリ（空の場合は Worker のカレントディレクトリ）
+	// HLS/DASH の場合は出力ディレクトリで実行し、出力ファイルはこのディレクトリからの相対パスになる
+	Dir string
+	// Preset は使用するプリセットの名前（ハードウェアエンコード版に切り替えた場合はその名前）
+	Preset string
+}
+
+// BuildCommand は jobID のジョブで実行する ffmpeg のコマンド（argv）を実行せずに返す
+func (e *Encoder) BuildCommand(jobID, inputURL, presetName string) ([]string, error) {
+	cmd, err := e.BuildCommandWithOptions(jobID, inputURL, presetName, Options{})
+	if err != nil {
+		return nil, err
+	}
+	return cmd.Args, nil
+}
+
+// BuildCommandWithOptions はオプションを指定して、ジョブで実行する ffmpeg のコマンドを実行せずに返す
+// パスの計算のみを行い、ファイルシステムへの書き込み・入力の取得・ffprobe の実行は行わない
+// そのため入力に依存する以下の処理は実際のエンコードと異なる
+//   - s3:// の入力・URL の字幕はダウンロード先のパスになる（ダウンロードはしない）
+//   - 2パスのラウドネス正規化は測定を行わず、1パスの正規化のフィルターになる
+//   - stream_copy の入力のコーデックと出力コンテナの互換性は確認しない
+//
+// 2パスエンコードの場合は出力を書き込む2パス目のコマンドを返す
+func (e *Encoder) BuildCommandWithOptions(jobID, inputURL, presetName string, opts Options) (Command, error) {
+	p, presetName, err := e.jobPreset(jobID, presetName, opts)
+	if err != nil {
+		return Command{}, err
+	}
+	if p.TwoPass && (p.OutputType == outputTypeHLS || p.OutputType == outputTypeDASH) {
+		return Command{}, fmt.Errorf("two-pass encoding is not supported for %s output", p.OutputType)
+	}
+
+	jobDir := filepath.Join(e.workDir, jobID)
+	outputPath, outputFile, err := outputPaths(jobDir, p)
+	if err != nil {
+		return Command{}, err
+	}
+
+	_, _, localInput, isS3, err := s3Input(jobDir, inputURL)
+	if err != nil {
+		return Command{}, err
+	}
+	if isS3 {
+		inputURL = localInput
+	}
+
+	if opts.SubtitlePath != "" {
+		if err := ValidateSubtitlePath(opts.SubtitlePath); err != nil {
+			return Command{}, err
+		}
+		subtitlePath := opts.SubtitlePath
+		if u, err := url.Parse(subtitlePath); err == nil && u.Scheme != "" {
+			subtitlePath = subtitleDownloadPath(jobDir, u)
+		}
+		p.FFmpegArgs = applySubtitles(p.FFmpegArgs, subtitlePath)
+	}
+
+	if p.LoudnessNorm {
+		if err := preset.ValidateLoudness(p); err != nil {
+			return Command{}, err
+		}
+		p.FFmpegArgs = loudnessArgs(p.FFmpegArgs, preset.LoudnessTarget(p), nil)
+	}
+
+	var args []string
+	dir := ffmpegWorkingDir(p, outputPath)
+	if p.TwoPass {
+		args = buildTwoPassArgs(e.globalArgs, opts.Clip, inputURL, outputFile, filepath.Join(jobDir, passLogPrefix), p, 2)
+		dir = jobDir
+	} else {
+		args = buildFFmpegArgs(e.globalArgs, opts.Clip, inputURL, outputFile, p)
+	}
+	return Command{
+		Args:   e.commandLine(args),
+		Dir:    dir,
+		Preset: presetName,
+	}, nil
+}
@@ -0,0 +1,66 @@
+package encoder
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestBuildCommandがファイルに触れずに出力パスを含むコマンドを返す(t *testing.T) {
+	workDir := t.TempDir()
+	e := New(workDir)
+
+	args, err := e.BuildCommand("dryrun-job", "https://example.com/input.mp4", "720p_h264")
+	if err != nil {
+		t.Fatalf("コマンドの構築に失敗: %v", err)
+	}
+
+	if args[0] != "ffmpeg" || !slices.Contains(args, "-nostdin") {
+		t.Errorf("ffmpeg のコマンドになっていない: %v", args)
+	}
+	if i := slices.Index(args, "-i"); i < 0 || args[i+1] != "https://example.com/input.mp4" {
+		t.Errorf("入力が含まれない: %v", args)
+	}
+	if want := filepath.Join(workDir, "dryrun-job", "output.mp4"); args[len(args)-1] != want {
+		t.Errorf("出力パスが一致しない: 期待値 %s, 取得値 %s", want, args[len(args)-1])
+	}
+	if _, err := os.Stat(filepath.Join(workDir, "dryrun-job")); !os.IsNotExist(err) {
+		t.Errorf("ジョブディレクトリが作成された: %v", err)
+	}
+}
+
+func TestBuildCommandWithOptionsがHLSの出力ディレクトリで実行するコマンドを返す(t *testing.T) {
+	workDir := t.TempDir()
+	e := New(workDir)
+
+	cmd, err := e.BuildCommandWithOptions("hls-job", "s3://bucket/videos/source.mov", "hls_720p", Options{Clip: Clip{Start: 5, Duration: 10}})
+	if err != nil {
+		t.Fatalf("コマンドの構築に失敗: %v", err)
+	}
+
+	jobDir := filepath.Join(workDir, "hls-job")
+	if cmd.Dir != filepath.Join(jobDir, outputDirName) {
+		t.Errorf("実行するディレクトリが一致しない: %s", cmd.Dir)
+	}
+	if cmd.Args[len(cmd.Args)-1] != "playlist.m3u8" {
+		t.Errorf("出力がプレイリストになっていない: %v", cmd.Args)
+	}
+	// s3:// の入力はダウンロード先のパスになる
+	if i := slices.Index(cmd.Args, "-i"); i < 0 || cmd.Args[i+1] != filepath.Join(jobDir, inputDirName, "source.mov") {
+		t.Errorf("入力がダウンロード先のパスになっていない: %v", cmd.Args)
+	}
+	if !slices.Contains(cmd.Args, "-ss") || !slices.Contains(cmd.Args, "-t") {
+		t.Errorf("切り出し範囲が含まれない: %v", cmd.Args)
+	}
+	if _, err := os.Stat(jobDir); !os.IsNotExist(err) {
+		t.Errorf("ジョブディレクトリが作成された: %v", err)
+	}
+}
+
+func TestBuildCommandが存在しないプリセットでエラーを返す(t *testing.T) {
+	e := New(t.TempDir())
+	if _, err := e.BuildCommand("dryrun-job", "input.mp4", "nonexistent_preset"); err == nil {
+		t.Error("存在しないプリセットでエラーが返されない")
+	}
+}
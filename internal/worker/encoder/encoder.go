@@ -11,8 +11,10 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/nzws/flux-encoder/internal/shared/execlimit"
 	"github.com/nzws/flux-encoder/internal/shared/logger"
 	"github.com/nzws/flux-encoder/internal/worker/preset"
 	"github.com/nzws/flux-encoder/internal/worker/validator"
@@ -23,11 +25,40 @@ import (
 type Encoder struct {
 	workDir   string
 	validator validator.Validator
+	// ffprobe はエンコード前の入力の事前チェックに使用する（preflight.go を参照）
+	ffprobe *validator.FFProbe
+	// validationDefaults はすべてのジョブに適用する出力の検証の設定（validation.go を参照）
+	validationDefaults validator.ValidationOptions
+	// maxValidationWarnings は検証の警告がこの件数を超えた場合に失敗とする上限（0 の場合は警告で失敗しない）
+	maxValidationWarnings int
+	// inputDownloader は s3:// の入力をダウンロードする。nil の場合は s3:// の入力を受け付けない
+	inputDownloader InputDownloader
+	// progressHeartbeat は ffmpeg の出力が途絶えている間に最後の進捗を再通知する間隔。0 の場合は通知しない
+	progressHeartbeat time.Duration
+	// globalArgs はすべての ffmpeg の実行の先頭に付けるグローバル引数（global_args.go を参照）
+	globalArgs []string
+	// ffmpegPath と ffprobePath は実行する ffmpeg・ffprobe の実行ファイル（SetBinaryPaths を参照）
+	ffmpegPath  string
+	ffprobePath string
+	// hardwareAccel はハードウェアエンコード版のプリセットを優先して使用する種類（空の場合は CPU のプリセット）
+	hardwareAccel string
+	// inputLimits は受け付ける入力の長さとサイズの上限（input_limits.go を参照）
+	inputLimits InputLimits
+
+	// jobLogs はエンコード中のジョブの ffmpeg の stderr（joblog.go を参照）
+	jobLogs   map[string]*jobLog
+	jobLogsMu sync.Mutex
 }
 
 const (
 	outputTypeHLS  = "hls"
 	outputTypeDASH = "dash"
+
+	// passLogPrefix は2パスエンコードのログファイル名のプレフィックス（ジョブディレクトリ内に作成）
+	passLogPrefix = "ffmpeg2pass"
+
+	// DefaultProgressHeartbeat は進捗のハートビートの間隔のデフォルト値
+	DefaultProgressHeartbeat = 10 * time.Second
 )
 
 // ProgressCallback は進捗通知のコールバック関数
@@ -36,11 +67,27 @@ type ProgressCallback func(progress float32, message string)
 // New は新しい Encoder を作成する
 func New(workDir string) *Encoder {
 	return &Encoder{
-		workDir:   workDir,
-		validator: validator.New(),
+		workDir:            workDir,
+		validator:          validator.New(),
+		ffprobe:            validator.NewFFProbe(validator.DefaultFFprobePath),
+		validationDefaults: validator.DefaultValidationOptions(),
+		jobLogs:            make(map[string]*jobLog),
+		progressHeartbeat:  DefaultProgressHeartbeat,
+		globalArgs:         DefaultGlobalArgs(),
+		ffmpegPath:         validator.DefaultFFmpegPath,
+		ffprobePath:        validator.DefaultFFprobePath,
 	}
 }
 
+// SetProgressHeartbeat は ffmpeg の出力が途絶えている間に最後の進捗を再通知する間隔を設定する
+// 入力の取得が止まった場合などに、クライアントが接続が生きていることを判別できるようにする（0 以下で無効）
+func (e *Encoder) SetProgressHeartbeat(interval time.Duration) {
+	if interval < 0 {
+		interval = 0
+	}
+	e.progressHeartbeat = interval
+}
+
 // Encode はエンコード処理を実行する
 func (e *Encoder) Encode(
 	ctx context.Context,
@@ -49,12 +96,27 @@ func (e *Encoder) Encode(
 	presetName string,
 	callback ProgressCallback,
 ) (string, error) {
-	// プリセット取得
-	preset, err := preset.Get(presetName)
+	return e.EncodeWithOptions(ctx, jobID, inputURL, presetName, Options{}, callback)
+}
+
+// EncodeWithOptions はジョブごとのオプションを適用してエンコード処理を実行する
+func (e *Encoder) EncodeWithOptions(
+	ctx context.Context,
+	jobID string,
+	inputURL string,
+	presetName string,
+	opts Options,
+	callback ProgressCallback,
+) (string, error) {
+	preset, presetName, err := e.jobPreset(jobID, presetName, opts)
 	if err != nil {
-		return "", fmt.Errorf("failed to get preset: %w", err)
+		return "", err
 	}
 
+	// エンコード中は ffmpeg の stderr を GetJobLogs で購読できるよう保持する
+	endJobLog := e.beginJobLog(jobID)
+	defer endJobLog()
+
 	// 作業ディレクトリ作成
 	jobDir := filepath.Join(e.workDir, jobID)
 	if err := os.MkdirAll(jobDir, 0755); err != nil {
@@ -67,77 +129,231 @@ func (e *Encoder) Encode(
 		return "", err
 	}
 
-	// ffmpeg コマンド構築
-	args := buildFFmpegArgs(inputURL, outputFile, preset)
+	if preset.TwoPass && (preset.OutputType == outputTypeHLS || preset.OutputType == outputTypeDASH) {
+		return "", fmt.Errorf("two-pass encoding is not supported for %s output", preset.OutputType)
+	}
+
+	// ffmpeg が直接取得できない入力（s3://）はジョブディレクトリにダウンロードする
+	source := inputURL
+	inputURL, err = e.resolveInput(ctx, jobDir, inputURL)
+	if err != nil {
+		return "", err
+	}
+
+	// 字幕を焼き込む場合は、字幕も入力と同じくジョブディレクトリにダウンロードしてから -vf に追加する
+	if opts.SubtitlePath != "" {
+		subtitlePath, err := e.resolveSubtitle(ctx, jobDir, opts.SubtitlePath)
+		if err != nil {
+			return "", err
+		}
+		preset.FFmpegArgs = applySubtitles(preset.FFmpegArgs, subtitlePath)
+	}
+
+	// 動画の総時間（秒）を取得するため、最初にffprobeで調べる
+	// 事前チェックを行う場合は、エンコードできない入力をここで失敗させる（プリセットまたはジョブの指定で省略できる）
+	var duration float64
+	var inputSize int64
+	if preset.SkipPreflight || opts.SkipPreflight {
+		duration, err = e.getDuration(ctx, inputURL)
+		if err != nil {
+			logger.Warn("Failed to get input duration", zap.String("job_id", jobID), zap.Error(err))
+			duration = 0
+		}
+	} else {
+		info, err := e.preflightInput(ctx, inputURL)
+		if err != nil {
+			return "", err
+		}
+		duration, inputSize = info.Duration, info.Size
+	}
+
+	// 切り出す場合は進捗を切り出した範囲の長さで計算する
+	// 範囲が入力の長さを超えている場合も ffmpeg は入力の最後までをエンコードするため、警告のみとする
+	if !opts.Clip.IsZero() {
+		if opts.Clip.exceedsSource(duration) {
+			logger.Warn("Clip range exceeds input duration",
+				zap.String("job_id", jobID),
+				zap.Float64("start", opts.Clip.Start),
+				zap.Float64("clip_duration", opts.Clip.Duration),
+				zap.Float64("input_duration", duration),
+			)
+		}
+		duration = opts.Clip.encodedDuration(duration)
+	}
+
+	// エンコードする長さ（切り出す場合はその範囲）や入力のサイズが上限を超える場合は、ffmpeg を起動せずに失敗させる
+	// 事前チェックを省略した場合も、取得できた長さとサイズで確認する
+	if err := e.checkInputLimits(ctx, jobID, inputURL, duration, inputSize); err != nil {
+		return "", err
+	}
+
+	// ラウドネス正規化は2パスの場合に入力の測定が必要なため、入力の取得後に -af を追加する
+	if preset.LoudnessNorm {
+		preset.FFmpegArgs, err = e.applyLoudness(ctx, jobID, inputURL, preset, opts.Clip, duration, callback)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	// 検証時の期待値（コピーするストリームは入力のコーデックがそのまま出力される）
+	expected := e.getExpectedInfoFromPreset(preset)
+	if preset.OutputType == outputTypeHLS {
+		// 入力（切り出す場合はその範囲）の長さからセグメント数の下限を見積もり、途中で打ち切られた出力を検出する
+		expected.HLS = expectedHLSInfo(preset.FFmpegArgs, duration)
+	}
+	if opts.StreamCopy != "" {
+		if err := e.prepareStreamCopy(ctx, inputURL, preset, opts.StreamCopy, expected); err != nil {
+			return "", err
+		}
+	}
 
 	logger.Info("Starting ffmpeg",
 		zap.String("job_id", jobID),
-		zap.String("input", inputURL),
+		zap.String("input", source),
 		zap.String("preset", presetName),
 		zap.String("output", outputFile),
+		zap.Bool("two_pass", preset.TwoPass),
+		zap.String("speed", opts.Speed),
+		zap.String("stream_copy", opts.StreamCopy),
+		zap.String("segment_layout", opts.SegmentLayout),
+		zap.String("hls_init_path", opts.HLSInitPath),
+		zap.String("hls_key_path", opts.HLSKeyPath),
+		zap.String("subtitle_path", opts.SubtitlePath),
+		zap.Bool("loudness_norm", preset.LoudnessNorm),
+		zap.Float64("clip_start", opts.Clip.Start),
+		zap.Float64("clip_duration", opts.Clip.Duration),
 	)
 
-	// ffmpeg コマンド実行
-	cmd := exec.CommandContext(ctx, "ffmpeg", args...)
+	if preset.TwoPass {
+		if err := e.runTwoPass(ctx, jobID, jobDir, inputURL, outputFile, preset, opts.Clip, duration, opts.OnCommand, callback); err != nil {
+			return "", err
+		}
+	} else {
+		// HLS/DASHの場合は出力ディレクトリをカレントディレクトリに設定
+		args := buildFFmpegArgs(e.globalArgs, opts.Clip, inputURL, outputFile, preset)
+		e.notifyCommand(opts.OnCommand, args)
+		if err := e.runFFmpeg(ctx, jobID, args, ffmpegWorkingDir(preset, outputPath), duration, callback); err != nil {
+			return "", err
+		}
+	}
 
-	// HLS/DASHの場合は出力ディレクトリをカレントディレクトリに設定
-	setFFmpegWorkingDir(cmd, preset, outputPath)
+	logger.Info("Encoding completed",
+		zap.String("job_id", jobID),
+		zap.String("output", outputPath),
+	)
 
-	// stderr をパイプ
-	stderr, err := cmd.StderrPipe()
-	if err != nil {
-		return "", fmt.Errorf("failed to create stderr pipe: %w", err)
+	// プリセットで HLS のバージョンが指定されている場合はプレイリストに反映する
+	if preset.OutputType == outputTypeHLS && preset.HLSVersion > 0 {
+		if err := setHLSVersion(outputPath, preset.HLSVersion); err != nil {
+			return "", err
+		}
 	}
 
-	// コマンド開始
-	if err := cmd.Start(); err != nil {
-		return "", fmt.Errorf("failed to start ffmpeg: %w", err)
+	// セグメントをサブディレクトリに配置する場合はプレイリストの URI を書き換える
+	if preset.OutputType == outputTypeHLS {
+		if err := applySegmentLayout(outputPath, outputFile, opts.SegmentLayout); err != nil {
+			return "", err
+		}
+		// 初期化セグメント・暗号化キーの配置先が指定されている場合は移動して URI を書き換える（セグメントの配置の後に行う）
+		if err := applyHLSAuxPaths(outputPath, outputFile, opts.HLSInitPath, opts.HLSKeyPath); err != nil {
+			return "", err
+		}
 	}
 
-	// 動画の総時間（マイクロ秒）を取得するため、最初にffprobeで調べる
-	duration, err := e.getDuration(ctx, inputURL)
+	// エンコード完了後に検証を実行（ジョブごとの指定を Worker のデフォルト設定に適用する）
+	validationOpts, err := opts.Validation.apply(e.validationDefaults)
 	if err != nil {
-		logger.Warn("Failed to get input duration", zap.String("job_id", jobID), zap.Error(err))
-		duration = 0
+		return "", fmt.Errorf("invalid validation settings: %w", err)
 	}
-
-	stderrLines, err := readFFmpegProgress(jobID, stderr, duration, callback)
-	if err != nil {
-		logger.Error("Failed to read ffmpeg progress",
-			zap.String("job_id", jobID),
-			zap.Error(err),
-		)
+	validationOpts.Expected = expected
+	if err := e.validateOutput(ctx, jobID, outputPath, validationOpts); err != nil {
+		return "", withErrorCode(ErrorCodeValidationFailed, fmt.Errorf("output validation failed: %w", err))
 	}
 
-	// コマンド完了を待つ
-	if err := cmd.Wait(); err != nil {
-		// エラー時はffmpegの出力をログに記録
-		logger.Error("ffmpeg stderr output",
+	// プリセットで指定されている場合はサムネイルを生成する
+	if preset.Thumbnail != nil {
+		thumbnail := thumbnailPath(preset, outputPath)
+		timestamp := opts.Clip.thumbnailTimestamp(preset.Thumbnail.Timestamp)
+		if err := e.generateThumbnail(ctx, inputURL, timestamp, preset.Thumbnail.Width, thumbnail); err != nil {
+			return "", err
+		}
+		logger.Info("Thumbnail generated",
 			zap.String("job_id", jobID),
-			zap.Strings("stderr", stderrLines[max(0, len(stderrLines)-50):]), // 最後の50行
+			zap.String("thumbnail", thumbnail),
 		)
-		return "", fmt.Errorf("ffmpeg failed: %w", err)
 	}
 
-	logger.Info("Encoding completed",
-		zap.String("job_id", jobID),
-		zap.String("output", outputPath),
-	)
+	return outputPath, nil
+}
 
-	// エンコード完了後に検証を実行
-	if err := e.validateOutput(ctx, jobID, outputPath, preset); err != nil {
-		return "", fmt.Errorf("output validation failed: %w", err)
+// IncrementalUploadDir はエンコード中に逐次アップロードできるジョブの出力ディレクトリを返す
+// 対象はセグメントを出力ディレクトリ直下に配置する HLS 出力のみ
+// サブフォルダ配置や初期化セグメント・暗号化キーの配置先の指定はエンコード後にファイルを移動するため対象外
+// プリセットは EncodeWithOptions と同じく、ハードウェアエンコード版とオプションを適用したもので判定する
+func (e *Encoder) IncrementalUploadDir(jobID, presetName string, opts Options) (string, bool) {
+	p, _, err := e.jobPreset(jobID, presetName, opts)
+	if err != nil || p.OutputType != outputTypeHLS || opts.SegmentLayout == SegmentLayoutSubfolder || opts.HLSInitPath != "" || opts.HLSKeyPath != "" {
+		return "", false
 	}
+	return filepath.Join(e.workDir, jobID, outputDirName), true
+}
 
-	return outputPath, nil
+// jobPreset はジョブで使用するプリセット（オプション適用済み）とその名前を返す
+// 生の ffmpeg 引数が指定された場合はプリセットを使用せず、ハードウェアエンコードが有効な場合はハードウェアエンコード版のプリセットがあればそちらを使用する
+func (e *Encoder) jobPreset(jobID, presetName string, opts Options) (preset.Preset, string, error) {
+	var basePreset preset.Preset
+	var err error
+	if len(opts.RawArgs) > 0 {
+		basePreset, err = rawPreset(opts.RawArgs, opts.RawExtension)
+		if err != nil {
+			return preset.Preset{}, "", fmt.Errorf("invalid raw ffmpeg args: %w", err)
+		}
+		presetName = basePreset.Name
+	} else {
+		basePreset, err = preset.GetForHardware(presetName, e.hardwareAccel)
+		if err != nil {
+			return preset.Preset{}, "", fmt.Errorf("failed to get preset: %w", err)
+		}
+		if basePreset.Name != presetName {
+			logger.Info("Using hardware-accelerated preset",
+				zap.String("job_id", jobID),
+				zap.String("preset", presetName),
+				zap.String("hardware_preset", basePreset.Name),
+			)
+			presetName = basePreset.Name
+		}
+	}
+
+	// オプション適用（プリセットのコピーに対して行う）
+	p, err := applyOptions(basePreset, opts)
+	if err != nil {
+		return preset.Preset{}, "", fmt.Errorf("invalid encode options: %w", err)
+	}
+	return p, presetName, nil
 }
 
+// outputDirName は HLS/DASH の出力ディレクトリ名（ジョブディレクトリからの相対パス）
+const outputDirName = "output"
+
+// resolveOutputPaths は出力パスを求め、HLS/DASH の場合は出力ディレクトリを作成する
 func resolveOutputPaths(jobDir string, preset preset.Preset) (string, string, error) {
+	outputPath, outputFile, err := outputPaths(jobDir, preset)
+	if err != nil {
+		return "", "", err
+	}
 	if preset.OutputType == outputTypeHLS || preset.OutputType == outputTypeDASH {
-		outputPath := filepath.Join(jobDir, "output")
 		if err := os.MkdirAll(outputPath, 0755); err != nil {
 			return "", "", fmt.Errorf("failed to create output directory: %w", err)
 		}
+	}
+	return outputPath, outputFile, nil
+}
+
+// outputPaths は出力パス（ファイルまたはディレクトリ）と ffmpeg に渡す出力ファイルを返す（ファイルシステムには触れない）
+// HLS/DASH の場合、ffmpeg は出力ディレクトリで実行するため出力ファイルはディレクトリからの相対パスになる
+func outputPaths(jobDir string, preset preset.Preset) (string, string, error) {
+	if preset.OutputType == outputTypeHLS || preset.OutputType == outputTypeDASH {
+		outputPath := filepath.Join(jobDir, outputDirName)
 		outputFileName := preset.OutputFileName
 		if outputFileName == "" {
 			outputFileName = defaultOutputFileName(preset.OutputType)
@@ -163,64 +379,210 @@ func defaultOutputFileName(outputType string) string {
 	}
 }
 
-func buildFFmpegArgs(inputURL, outputFile string, preset preset.Preset) []string {
-	args := []string{
-		"-i", inputURL, // 入力URL
+func buildFFmpegArgs(globalArgs []string, clip Clip, inputURL, outputFile string, preset preset.Preset) []string {
+	args := withGlobalArgs(globalArgs, append(clip.inputArgs(inputURL), // 入力URL（切り出し範囲を含む）
 		"-progress", "pipe:2", // 進捗をstderrに出力
 		"-y", // 上書き
-	}
+	))
 	args = append(args, preset.FFmpegArgs...)
 	args = append(args, outputFile)
 	return args
 }
 
-func setFFmpegWorkingDir(cmd *exec.Cmd, preset preset.Preset, outputPath string) {
+// buildTwoPassArgs は2パスエンコードの各パスの ffmpeg 引数を構築する
+// 1パス目は解析のみを行い、音声を無効化して結果を破棄する
+func buildTwoPassArgs(globalArgs []string, clip Clip, inputURL, outputFile, passLogFile string, preset preset.Preset, pass int) []string {
+	args := withGlobalArgs(globalArgs, append(clip.inputArgs(inputURL), // 入力URL（切り出し範囲を含む）
+		"-progress", "pipe:2", // 進捗をstderrに出力
+		"-y", // 上書き
+	))
+	args = append(args, preset.FFmpegArgs...)
+	args = append(args, "-pass", strconv.Itoa(pass), "-passlogfile", passLogFile)
+	if pass == 1 {
+		return append(args, "-an", "-f", "null", os.DevNull)
+	}
+	return append(args, outputFile)
+}
+
+func ffmpegWorkingDir(preset preset.Preset, outputPath string) string {
 	if preset.OutputType == outputTypeHLS || preset.OutputType == outputTypeDASH {
-		cmd.Dir = outputPath
+		return outputPath
 	}
+	return ""
 }
 
-func readFFmpegProgress(jobID string, stderr io.Reader, duration float64, callback ProgressCallback) ([]string, error) {
-	frameRe := regexp.MustCompile(`frame=\s*(\d+)`)
-	timeRe := regexp.MustCompile(`out_time_ms=(\d+)`)
+// runTwoPass は2パスエンコードを実行する
+// 進捗は1パス目を 0〜50%、2パス目を 50〜100% として通知する
+func (e *Encoder) runTwoPass(
+	ctx context.Context,
+	jobID, jobDir, inputURL, outputFile string,
+	preset preset.Preset,
+	clip Clip,
+	duration float64,
+	onCommand func(command []string),
+	callback ProgressCallback,
+) error {
+	passLogFile := filepath.Join(jobDir, passLogPrefix)
 
-	var stderrLines []string
-	lastLoggedProgress := float32(-10)
-	scanner := bufio.NewScanner(stderr)
-	for scanner.Scan() {
-		line := scanner.Text()
-		stderrLines = append(stderrLines, line)
+	for pass := 1; pass <= 2; pass++ {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("encoding cancelled before pass %d: %w", pass, err)
+		}
 
-		logger.Debug("ffmpeg output",
+		logger.Info("Starting ffmpeg pass",
 			zap.String("job_id", jobID),
-			zap.String("line", line),
+			zap.Int("pass", pass),
 		)
 
-		if matches := frameRe.FindStringSubmatch(line); len(matches) > 1 {
-			callback(0, fmt.Sprintf("Encoding frame %s", matches[1]))
+		args := buildTwoPassArgs(e.globalArgs, clip, inputURL, outputFile, passLogFile, preset, pass)
+		e.notifyCommand(onCommand, args)
+		// x265 などが出力する統計ファイルもジョブディレクトリに残すため作業ディレクトリを設定する
+		if err := e.runFFmpeg(ctx, jobID, args, jobDir, duration, passProgressCallback(pass, callback)); err != nil {
+			return fmt.Errorf("pass %d: %w", pass, err)
 		}
+	}
+
+	return nil
+}
+
+// passProgressCallback は各パスの進捗（0〜100%）を全体の進捗に変換するコールバックを返す
+func passProgressCallback(pass int, callback ProgressCallback) ProgressCallback {
+	offset := float32(pass-1) * 50
+	return func(progress float32, message string) {
+		callback(offset+progress/2, fmt.Sprintf("Pass %d/2: %s", pass, message))
+	}
+}
+
+// runFFmpeg は ffmpeg を実行し、完了するまで進捗を読み取る
+// ctx がキャンセルされると実行中の ffmpeg は強制終了される
+func (e *Encoder) runFFmpeg(ctx context.Context, jobID string, args []string, dir string, duration float64, callback ProgressCallback) error {
+	_, err := e.runFFmpegWithOutput(ctx, jobID, args, dir, duration, callback)
+	return err
+}
+
+// runFFmpegWithOutput は runFFmpeg と同様に ffmpeg を実行し、stderr の末尾 stderrTailLines 行を返す
+func (e *Encoder) runFFmpegWithOutput(ctx context.Context, jobID string, args []string, dir string, duration float64, callback ProgressCallback) ([]string, error) {
+	cmd := newFFmpegCommand(ctx, e.ffmpegPath, args...)
+	cmd.Dir = dir
+
+	// stderr をパイプ
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stderr pipe: %w", err)
+	}
+
+	// コマンド開始
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start ffmpeg: %w", err)
+	}
+
+	publish := func(line string) { e.publishJobLog(jobID, line) }
+	stderrLines, err := readFFmpegProgress(jobID, stderr, duration, e.progressHeartbeat, publish, callback)
+	if err != nil {
+		logger.Error("Failed to read ffmpeg progress",
+			zap.String("job_id", jobID),
+			zap.Error(err),
+		)
+	}
+
+	// コマンド完了を待つ
+	if err := cmd.Wait(); err != nil {
+		// エラー時はffmpegの出力をログに記録
+		logger.Error("ffmpeg stderr output",
+			zap.String("job_id", jobID),
+			zap.Strings("stderr", stderrLines), // 最後の stderrTailLines 行
+		)
+		return stderrLines, withErrorCode(classifyFFmpegError(strings.Join(stderrLines, "\n")), fmt.Errorf("ffmpeg failed: %w", err))
+	}
+
+	return stderrLines, nil
+}
+
+// stderrTailLines はエラー時のログ用に保持する ffmpeg の stderr の行数
+// 入力によっては大量の警告が出力されるため、全行ではなく末尾のみを保持する
+const stderrTailLines = 50
+
+// readFFmpegProgress は ffmpeg の stderr を読み取り、進捗をコールバックに通知する
+// heartbeat が 0 より大きい場合、出力が heartbeat 以上途絶えている間は最後の進捗を heartbeat ごとに再通知する
+// publish が nil でない場合は読み取った各行を渡す（GetJobLogs の購読者への配信に使う）
+// コールバックはこの関数を呼び出したゴルーチンからのみ呼び出す
+func readFFmpegProgress(jobID string, stderr io.Reader, duration float64, heartbeat time.Duration, publish func(line string), callback ProgressCallback) ([]string, error) {
+	frameRe := regexp.MustCompile(`frame=\s*(\d+)`)
+	timeRe := regexp.MustCompile(`out_time_ms=(\d+)`)
 
-		progress, ok := parseProgress(timeRe, line, duration)
-		if !ok {
-			continue
+	// 出力が途絶えている間もハートビートを送れるよう、読み取りは別のゴルーチンで行う
+	lines := make(chan string)
+	scanErr := make(chan error, 1)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(stderr)
+		for scanner.Scan() {
+			lines <- scanner.Text()
 		}
+		scanErr <- scanner.Err()
+	}()
+
+	// ticker は読み取りの終了時（ffmpeg の終了時）に止める
+	var heartbeatC <-chan time.Time
+	if heartbeat > 0 {
+		ticker := time.NewTicker(heartbeat)
+		defer ticker.Stop()
+		heartbeatC = ticker.C
+	}
+
+	var stderrLines []string
+	lastLoggedProgress := float32(-10)
+	lastProgress := float32(0)
+	lastOutput := time.Now()
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				return stderrLines, <-scanErr
+			}
+			lastOutput = time.Now()
+
+			if len(stderrLines) == stderrTailLines {
+				stderrLines = append(stderrLines[:0], stderrLines[1:]...)
+			}
+			stderrLines = append(stderrLines, line)
+			if publish != nil {
+				publish(line)
+			}
 
-		if progress-lastLoggedProgress >= 10 || progress >= 100 {
-			logger.Info("Encoding progress",
+			logger.Debug("ffmpeg output",
 				zap.String("job_id", jobID),
-				zap.Float32("progress", progress),
-				zap.String("status", fmt.Sprintf("%.1f%%", progress)),
+				zap.String("line", line),
 			)
-			lastLoggedProgress = progress
-		}
 
-		callback(progress, fmt.Sprintf("Encoding: %.1f%%", progress))
-	}
+			if matches := frameRe.FindStringSubmatch(line); len(matches) > 1 {
+				callback(0, fmt.Sprintf("Encoding frame %s", matches[1]))
+			}
+
+			progress, ok := parseProgress(timeRe, line, duration)
+			if !ok {
+				continue
+			}
+			lastProgress = progress
+
+			if progress-lastLoggedProgress >= 10 || progress >= 100 {
+				logger.Info("Encoding progress",
+					zap.String("job_id", jobID),
+					zap.Float32("progress", progress),
+					zap.String("status", fmt.Sprintf("%.1f%%", progress)),
+				)
+				lastLoggedProgress = progress
+			}
 
-	if err := scanner.Err(); err != nil {
-		return stderrLines, err
+			callback(progress, fmt.Sprintf("Encoding: %.1f%%", progress))
+		case <-heartbeatC:
+			silent := time.Since(lastOutput)
+			if silent < heartbeat {
+				continue
+			}
+			callback(lastProgress, fmt.Sprintf("Encoding: %.1f%% (no output from ffmpeg for %s)", lastProgress, silent.Truncate(time.Second)))
+		}
 	}
-	return stderrLines, nil
 }
 
 func parseProgress(timeRe *regexp.Regexp, line string, duration float64) (float32, bool) {
@@ -245,23 +607,14 @@ func parseProgress(timeRe *regexp.Regexp, line string, duration float64) (float3
 }
 
 // validateOutput はエンコード出力を検証する
-func (e *Encoder) validateOutput(ctx context.Context, jobID, outputPath string, preset preset.Preset) error {
+func (e *Encoder) validateOutput(ctx context.Context, jobID, outputPath string, validationOpts validator.ValidationOptions) error {
 	logger.Info("Starting output validation",
 		zap.String("job_id", jobID),
 		zap.String("output", outputPath),
 	)
 
-	// 検証オプションを設定
-	validationOpts := &validator.ValidationOptions{
-		Level:              validator.ValidationLevelStandard,
-		Timeout:            30 * time.Second,
-		SkipDecodeTest:     false,
-		HLSValidationDepth: validator.HLSValidationDepthMedium,
-		Expected:           e.getExpectedInfoFromPreset(preset),
-	}
-
 	// 検証実行
-	result, err := e.validator.Validate(ctx, outputPath, validationOpts)
+	result, err := e.validator.Validate(ctx, outputPath, &validationOpts)
 	if err != nil {
 		return fmt.Errorf("validation error: %w", err)
 	}
@@ -283,6 +636,16 @@ func (e *Encoder) validateOutput(ctx context.Context, jobID, outputPath string,
 		)
 	}
 
+	// 警告が多すぎる出力は、検証に合格していても品質が不十分とみなして失敗にする
+	if e.maxValidationWarnings > 0 && len(result.Warnings) > e.maxValidationWarnings {
+		logger.Error("Output validation produced too many warnings",
+			zap.String("job_id", jobID),
+			zap.Int("warnings", len(result.Warnings)),
+			zap.Int("max_warnings", e.maxValidationWarnings),
+		)
+		return fmt.Errorf("validation produced %d warnings (max %d): %s", len(result.Warnings), e.maxValidationWarnings, result.GetWarningMessages()[0])
+	}
+
 	logger.Info("Output validation succeeded",
 		zap.String("job_id", jobID),
 		zap.Duration("duration", result.ValidationDuration),
@@ -300,32 +663,50 @@ func (e *Encoder) getExpectedInfoFromPreset(preset preset.Preset) *validator.Exp
 		switch arg {
 		case "-c:v":
 			if i+1 < len(preset.FFmpegArgs) {
-				codec := preset.FFmpegArgs[i+1]
-				// libx264 -> h264
-				if codec == "libx264" {
-					expected.VideoCodec = "h264"
-				} else if codec == "libx265" {
-					expected.VideoCodec = "hevc"
-				}
+				// ffmpeg のエンコーダー名 -> ffprobe のコーデック名
+				expected.VideoCodec = videoCodecFromEncoder(preset.FFmpegArgs[i+1])
 			}
 		case "-c:a":
 			if i+1 < len(preset.FFmpegArgs) {
 				expected.AudioCodec = preset.FFmpegArgs[i+1]
 			}
-		case "scale":
-			// -vf scale=-2:720 のような形式から解像度を抽出
+		case "-ac":
 			if i+1 < len(preset.FFmpegArgs) {
-				scaleArg := preset.FFmpegArgs[i+1]
-				if strings.Contains(scaleArg, ":") {
-					parts := strings.Split(scaleArg, ":")
-					if len(parts) >= 2 {
-						// 高さを取得
-						if height, err := strconv.Atoi(parts[len(parts)-1]); err == nil {
-							expected.Height = height
-						}
-					}
+				if channels, err := strconv.Atoi(preset.FFmpegArgs[i+1]); err == nil {
+					expected.AudioChannels = channels
 				}
 			}
+		case "-ar":
+			if i+1 < len(preset.FFmpegArgs) {
+				if sampleRate, err := strconv.Atoi(preset.FFmpegArgs[i+1]); err == nil {
+					expected.AudioSampleRate = sampleRate
+				}
+			}
+		case "-profile:v":
+			// 対象デバイスとの互換性のために指定したプロファイル（ジョブの overrides で指定した値を含む）
+			if i+1 < len(preset.FFmpegArgs) {
+				expected.Profile = preset.FFmpegArgs[i+1]
+			}
+		case "-r":
+			// 出力のフレームレート（"30" や "30000/1001"）
+			if i+1 < len(preset.FFmpegArgs) {
+				expected.FrameRate = validator.ParseFrameRate(preset.FFmpegArgs[i+1])
+			}
+		case "-movflags":
+			// +faststart 指定時は moov の配置を検証する
+			if i+1 < len(preset.FFmpegArgs) && strings.Contains(preset.FFmpegArgs[i+1], "faststart") {
+				expected.FastStart = true
+			}
+		case "-vf":
+			// -vf scale=-2:720 や縦長の scale=720:1280 のような形式から解像度を抽出（resolution.go を参照）
+			if i+1 < len(preset.FFmpegArgs) {
+				expected.Width, expected.Height = expectedSizeFromFilters(preset.FFmpegArgs[i+1])
+			}
+		case "-filter_complex":
+			// ABR のプリセットは最も大きいバリアントの解像度を期待値とする（HLS/DASH の検証ではバリアントの最大の解像度と比較する）
+			if i+1 < len(preset.FFmpegArgs) {
+				expected.Width, expected.Height = expectedSizeFromFilterComplex(preset.FFmpegArgs[i+1])
+			}
 		}
 	}
 
@@ -340,16 +721,61 @@ func (e *Encoder) getExpectedInfoFromPreset(preset preset.Preset) *validator.Exp
 	return expected
 }
 
+// expectedHLSInfo は -hls_time とエンコードする長さ（秒）から HLS のセグメントの期待値を返す
+// ffmpeg はキーフレームでのみ分割するため、セグメントは -hls_time より長くなる場合がある
+// そのため下限は -hls_time から計算したセグメント数の半分とし、目標時間は -hls_time の値まで長くなることを許容する
+func expectedHLSInfo(args []string, duration float64) *validator.ExpectedHLSInfo {
+	hlsTime := 0.0
+	for i, arg := range args {
+		if arg == "-hls_time" && i+1 < len(args) {
+			if value, err := strconv.ParseFloat(args[i+1], 64); err == nil && value > 0 {
+				hlsTime = value
+			}
+		}
+	}
+	// 長さが分からない場合や1セグメントに収まる場合は、セグメント数と目標時間を予測できない
+	if hlsTime == 0 || duration <= hlsTime {
+		return nil
+	}
+	return &validator.ExpectedHLSInfo{
+		MinSegments:             max(1, int(duration/hlsTime/2)),
+		TargetDuration:          hlsTime,
+		TargetDurationTolerance: hlsTime,
+	}
+}
+
+// videoCodecFromEncoder は ffmpeg のエンコーダー名を ffprobe が返すコーデック名に変換する
+// 未知のエンコーダーの場合は空文字を返す（コーデック検証をスキップ）
+func videoCodecFromEncoder(encoderName string) string {
+	switch encoderName {
+	case "libx264":
+		return "h264"
+	case "libx265":
+		return "hevc"
+	case "libsvtav1", "libaom-av1":
+		return "av1"
+	}
+	// ハードウェアエンコーダー（h264_nvenc、hevc_qsv、av1_vaapi など）はコーデック名が先頭に付く
+	if _, ok := preset.HardwareAccelOfEncoder(encoderName); ok {
+		codec, _, _ := strings.Cut(encoderName, "_")
+		switch codec {
+		case "h264", "hevc", "av1", "vp9":
+			return codec
+		}
+	}
+	return ""
+}
+
 // getDuration は動画の総時間（秒）を取得する
 func (e *Encoder) getDuration(ctx context.Context, inputURL string) (float64, error) {
-	cmd := exec.CommandContext(ctx, "ffprobe",
+	cmd := exec.CommandContext(ctx, e.ffprobePath,
 		"-v", "error",
 		"-show_entries", "format=duration",
 		"-of", "default=noprint_wrappers=1:nokey=1",
 		inputURL,
 	)
 
-	output, err := cmd.Output()
+	output, err := execlimit.Output(cmd)
 	if err != nil {
 		return 0, err
 	}
@@ -362,7 +788,7 @@ func (e *Encoder) getDuration(ctx context.Context, inputURL string) (float64, er
 	return duration, nil
 }
 
-// Cleanup はジョブのディレクトリを削除する
+// Cleanup はジョブのディレクトリ（ダウンロードした入力ファイルを含む）を削除する
 func (e *Encoder) Cleanup(jobID string) error {
 	jobDir := filepath.Join(e.workDir, jobID)
 	return os.RemoveAll(jobDir)
@@ -2,10 +2,15 @@ package encoder
 
 import (
 	"context"
+	"errors"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/nzws/flux-encoder/internal/worker/preset"
 )
@@ -320,6 +325,7 @@ func Test出力ファイル名がプリセットのOutputFileNameを使用する
 	}{
 		{"hls_720p", "playlist.m3u8"},
 		{"hls_720p_abr", "stream_%v.m3u8"},
+		{"dash_720p_abr", "manifest.mpd"},
 	}
 
 	for _, tc := range testCases {
@@ -343,3 +349,359 @@ func Test出力ファイル名がプリセットのOutputFileNameを使用する
 // 3. CI環境でffmpegをインストールし、実際のエンコードテストを実行
 // 4. getDuration や outputPath 決定などのロジックを別メソッドに分離し、
 //    個別にテスト可能にする
+
+func TestFaststart指定のプリセットでFastStartが期待値に設定される(t *testing.T) {
+	encoder := New(t.TempDir())
+
+	testCases := []struct {
+		presetName string
+		expected   bool
+	}{
+		{"720p_h264", true},
+		{"hls_720p", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.presetName, func(t *testing.T) {
+			p, err := preset.Get(tc.presetName)
+			if err != nil {
+				t.Fatalf("プリセットの取得に失敗: %v", err)
+			}
+
+			expected := encoder.getExpectedInfoFromPreset(p)
+			if expected.FastStart != tc.expected {
+				t.Errorf("FastStart が一致しない: 期待値 %v, 取得値 %v", tc.expected, expected.FastStart)
+			}
+		})
+	}
+}
+
+func Testエンコーダー名がffprobeのコーデック名に変換される(t *testing.T) {
+	testCases := []struct {
+		encoder  string
+		expected string
+	}{
+		{"libx264", "h264"},
+		{"libx265", "hevc"},
+		{"libsvtav1", "av1"},
+		{"libaom-av1", "av1"},
+		{"h264_nvenc", "h264"},
+		{"hevc_qsv", "hevc"},
+		{"av1_vaapi", "av1"},
+		{"copy", ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.encoder, func(t *testing.T) {
+			if got := videoCodecFromEncoder(tc.encoder); got != tc.expected {
+				t.Errorf("コーデック名が一致しない: 期待値 %q, 取得値 %q", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestAV1プリセットで期待コーデックがav1に設定される(t *testing.T) {
+	encoder := New(t.TempDir())
+
+	p, err := preset.Get("1080p_av1")
+	if err != nil {
+		t.Fatalf("プリセットの取得に失敗: %v", err)
+	}
+
+	expected := encoder.getExpectedInfoFromPreset(p)
+	if expected.VideoCodec != "av1" {
+		t.Errorf("VideoCodec が一致しない: 期待値 %s, 取得値 %s", "av1", expected.VideoCodec)
+	}
+}
+
+func Test音声のチャンネル数とサンプリングレートが期待値に設定される(t *testing.T) {
+	encoder := New(t.TempDir())
+	p := preset.Preset{
+		Name:       "stereo_test",
+		FFmpegArgs: []string{"-c:v", "libx264", "-c:a", "aac", "-ac", "2", "-ar", "48000"},
+		Extension:  "mp4",
+	}
+
+	expected := encoder.getExpectedInfoFromPreset(p)
+	if expected.AudioChannels != 2 {
+		t.Errorf("AudioChannels が一致しない: 期待値 %d, 取得値 %d", 2, expected.AudioChannels)
+	}
+	if expected.AudioSampleRate != 48000 {
+		t.Errorf("AudioSampleRate が一致しない: 期待値 %d, 取得値 %d", 48000, expected.AudioSampleRate)
+	}
+
+	// 指定がない場合は検証しない
+	builtin, err := preset.Get("720p_h264")
+	if err != nil {
+		t.Fatalf("プリセットの取得に失敗: %v", err)
+	}
+	expected = encoder.getExpectedInfoFromPreset(builtin)
+	if expected.AudioChannels != 0 || expected.AudioSampleRate != 0 {
+		t.Errorf("指定のない音声設定が期待値に含まれている: %+v", expected)
+	}
+}
+
+func Testフレームレートの指定が期待値に設定される(t *testing.T) {
+	encoder := New(t.TempDir())
+
+	tests := []struct {
+		rate     string
+		expected float64
+	}{
+		{rate: "30", expected: 30},
+		{rate: "30000/1001", expected: 30000.0 / 1001},
+		{rate: "ntsc", expected: 0}, // 略称は検証しない
+	}
+	for _, tt := range tests {
+		p := preset.Preset{FFmpegArgs: []string{"-c:v", "libx264", "-r", tt.rate}}
+		if got := encoder.getExpectedInfoFromPreset(p).FrameRate; got != tt.expected {
+			t.Errorf("-r %s の FrameRate が一致しない: 期待値 %v, 取得値 %v", tt.rate, tt.expected, got)
+		}
+	}
+}
+
+func TestHLSのセグメント数の下限がhls_timeと長さから見積もられる(t *testing.T) {
+	args := []string{"-c:v", "libx264", "-f", "hls", "-hls_time", "6"}
+
+	// 10分の入力は6秒のセグメントで100個になるため、その半分を下限とする
+	got := expectedHLSInfo(args, 600)
+	if got == nil {
+		t.Fatal("期待値が設定されない")
+	}
+	if got.MinSegments != 50 {
+		t.Errorf("MinSegments が一致しない: 期待値 50, 取得値 %d", got.MinSegments)
+	}
+	if got.TargetDuration != 6 || got.TargetDurationTolerance != 6 {
+		t.Errorf("目標時間が一致しない: %+v", got)
+	}
+
+	// 短い入力でも下限は1以上
+	if got := expectedHLSInfo(args, 8); got == nil || got.MinSegments != 1 {
+		t.Errorf("短い入力の MinSegments が一致しない: %+v", got)
+	}
+
+	// 長さが分からない場合・1セグメントに収まる場合・-hls_time がない場合は検証しない
+	if got := expectedHLSInfo(args, 0); got != nil {
+		t.Errorf("長さが分からないのに期待値が設定された: %+v", got)
+	}
+	if got := expectedHLSInfo(args, 5); got != nil {
+		t.Errorf("1セグメントに収まるのに期待値が設定された: %+v", got)
+	}
+	if got := expectedHLSInfo([]string{"-f", "hls"}, 600); got != nil {
+		t.Errorf("-hls_time がないのに期待値が設定された: %+v", got)
+	}
+}
+
+func Test2パスエンコードの引数が正しく構築される(t *testing.T) {
+	p := preset.Preset{
+		Name:       "two_pass_test",
+		FFmpegArgs: []string{"-c:v", "libx264", "-b:v", "2M", "-c:a", "aac"},
+		Extension:  "mp4",
+		OutputType: "single",
+		TwoPass:    true,
+	}
+
+	pass1 := buildTwoPassArgs(nil, Clip{}, "input.mp4", "/job/output.mp4", "/job/ffmpeg2pass", p, 1)
+	expectedPass1 := []string{
+		"-i", "input.mp4", "-progress", "pipe:2", "-y",
+		"-c:v", "libx264", "-b:v", "2M", "-c:a", "aac",
+		"-pass", "1", "-passlogfile", "/job/ffmpeg2pass",
+		"-an", "-f", "null", os.DevNull,
+	}
+	if !reflect.DeepEqual(pass1, expectedPass1) {
+		t.Errorf("1パス目の引数が一致しない:\n期待値 %v\n取得値 %v", expectedPass1, pass1)
+	}
+
+	pass2 := buildTwoPassArgs(nil, Clip{}, "input.mp4", "/job/output.mp4", "/job/ffmpeg2pass", p, 2)
+	expectedPass2 := []string{
+		"-i", "input.mp4", "-progress", "pipe:2", "-y",
+		"-c:v", "libx264", "-b:v", "2M", "-c:a", "aac",
+		"-pass", "2", "-passlogfile", "/job/ffmpeg2pass",
+		"/job/output.mp4",
+	}
+	if !reflect.DeepEqual(pass2, expectedPass2) {
+		t.Errorf("2パス目の引数が一致しない:\n期待値 %v\n取得値 %v", expectedPass2, pass2)
+	}
+}
+
+func Test2パスエンコードの進捗が前半と後半に割り当てられる(t *testing.T) {
+	testCases := []struct {
+		pass     int
+		progress float32
+		expected float32
+	}{
+		{1, 0, 0},
+		{1, 50, 25},
+		{1, 100, 50},
+		{2, 0, 50},
+		{2, 50, 75},
+		{2, 100, 100},
+	}
+
+	for _, tc := range testCases {
+		var got float32
+		var message string
+		callback := passProgressCallback(tc.pass, func(progress float32, msg string) {
+			got = progress
+			message = msg
+		})
+		callback(tc.progress, "Encoding")
+
+		if got != tc.expected {
+			t.Errorf("pass %d, progress %.0f: 進捗が一致しない: 期待値 %.1f, 取得値 %.1f", tc.pass, tc.progress, tc.expected, got)
+		}
+		if !strings.HasPrefix(message, "Pass ") {
+			t.Errorf("メッセージにパス番号が含まれていない: %s", message)
+		}
+	}
+}
+
+func Test2パスエンコードのキャンセル時に次のパスが実行されない(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	p := preset.Preset{FFmpegArgs: []string{"-c:v", "libx264"}, TwoPass: true}
+	called := false
+	err := New(t.TempDir()).runTwoPass(ctx, "test-job", t.TempDir(), "input.mp4", "output.mp4", p, Clip{}, 0, nil, func(float32, string) {
+		called = true
+	})
+
+	if err == nil {
+		t.Fatal("キャンセル済みのコンテキストでエラーが返されなかった")
+	}
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("context.Canceled が返されなかった: %v", err)
+	}
+	if called {
+		t.Error("キャンセル後に進捗コールバックが呼ばれた")
+	}
+}
+
+func TestFFmpegの出力が途絶えている間は最後の進捗がハートビートとして通知される(t *testing.T) {
+	reader, writer := io.Pipe()
+	progresses := make(chan float32, 100)
+	messages := make(chan string, 100)
+	done := make(chan error, 1)
+	go func() {
+		_, err := readFFmpegProgress("test-job", reader, 10, 20*time.Millisecond, nil, func(progress float32, message string) {
+			progresses <- progress
+			messages <- message
+		})
+		done <- err
+	}()
+
+	if _, err := io.WriteString(writer, "out_time_ms=5000000\n"); err != nil {
+		t.Fatalf("進捗の書き込みに失敗: %v", err)
+	}
+	if progress := <-progresses; progress != 50 {
+		t.Fatalf("進捗が一致しない: 期待値 %v, 取得値 %v", float32(50), progress)
+	}
+	<-messages
+
+	// 出力がない間は同じ進捗が再通知される
+	select {
+	case progress := <-progresses:
+		if progress != 50 {
+			t.Errorf("ハートビートの進捗が一致しない: 期待値 %v, 取得値 %v", float32(50), progress)
+		}
+		if message := <-messages; !strings.Contains(message, "no output from ffmpeg") {
+			t.Errorf("ハートビートのメッセージが一致しない: %q", message)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("ハートビートが通知されなかった")
+	}
+
+	writer.Close()
+	if err := <-done; err != nil {
+		t.Fatalf("進捗の読み取りに失敗: %v", err)
+	}
+
+	// 読み取りの終了後はハートビートが通知されない
+	for len(progresses) > 0 {
+		<-progresses
+	}
+	time.Sleep(60 * time.Millisecond)
+	if len(progresses) > 0 {
+		t.Error("読み取りの終了後にハートビートが通知された")
+	}
+}
+
+func Testハートビートを無効にするとffmpegの出力がない間は通知されない(t *testing.T) {
+	reader, writer := io.Pipe()
+	called := make(chan struct{}, 10)
+	done := make(chan error, 1)
+	go func() {
+		_, err := readFFmpegProgress("test-job", reader, 10, 0, nil, func(float32, string) {
+			called <- struct{}{}
+		})
+		done <- err
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	writer.Close()
+	if err := <-done; err != nil {
+		t.Fatalf("進捗の読み取りに失敗: %v", err)
+	}
+	if len(called) > 0 {
+		t.Error("ハートビートが無効なのに進捗が通知された")
+	}
+}
+
+func TestCleanupが2パスエンコードのログファイルを削除する(t *testing.T) {
+	workDir := t.TempDir()
+	encoder := New(workDir)
+
+	jobID := "test-job-two-pass"
+	jobDir := filepath.Join(workDir, jobID)
+	if err := os.MkdirAll(jobDir, 0755); err != nil {
+		t.Fatalf("ジョブディレクトリの作成に失敗: %v", err)
+	}
+
+	passLogs := []string{passLogPrefix + "-0.log", passLogPrefix + "-0.log.mbtree", "x265_2pass.log"}
+	for _, name := range passLogs {
+		if err := os.WriteFile(filepath.Join(jobDir, name), []byte("stats"), 0644); err != nil {
+			t.Fatalf("パスログの作成に失敗: %v", err)
+		}
+	}
+
+	if err := encoder.Cleanup(jobID); err != nil {
+		t.Fatalf("Cleanup が失敗: %v", err)
+	}
+
+	for _, name := range passLogs {
+		if _, err := os.Stat(filepath.Join(jobDir, name)); !os.IsNotExist(err) {
+			t.Errorf("パスログ %s が削除されていない", name)
+		}
+	}
+}
+
+func TestDASHのプリセットは出力ディレクトリにマニフェストを出力する(t *testing.T) {
+	p, err := preset.Get("dash_720p_abr")
+	if err != nil {
+		t.Fatalf("プリセットの取得に失敗: %v", err)
+	}
+
+	jobDir := t.TempDir()
+	outputPath, outputFile, err := resolveOutputPaths(jobDir, p)
+	if err != nil {
+		t.Fatalf("出力パスの解決に失敗: %v", err)
+	}
+	if outputPath != filepath.Join(jobDir, outputDirName) {
+		t.Errorf("出力パスが一致しない: %s", outputPath)
+	}
+	if info, err := os.Stat(outputPath); err != nil || !info.IsDir() {
+		t.Errorf("出力ディレクトリが作成されていない: %v", err)
+	}
+	if outputFile != "manifest.mpd" {
+		t.Errorf("出力ファイル名が一致しない: %s", outputFile)
+	}
+
+	// セグメントが出力ディレクトリに書き込まれるよう、ffmpeg は出力ディレクトリで実行する
+	if dir := ffmpegWorkingDir(p, outputPath); dir != outputPath {
+		t.Errorf("ffmpeg の作業ディレクトリが一致しない: %s", dir)
+	}
+	args := buildFFmpegArgs(nil, Clip{}, "input.mp4", outputFile, p)
+	if args[len(args)-1] != "manifest.mpd" {
+		t.Errorf("ffmpeg の出力がマニフェストになっていない: %v", args)
+	}
+}
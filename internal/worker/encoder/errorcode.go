@@ -0,0 +1,110 @@
+package encoder
+
+import (
+	"errors"
+	"strings"
+	"syscall"
+)
+
+// ErrorCode はエンコードの失敗の分類（JobProgress.error_code としてクライアントに返す）
+type ErrorCode string
+
+const (
+	// ErrorCodeInputUnreachable は入力を取得できない（存在しない URL・接続エラー・ダウンロードの失敗など）
+	ErrorCodeInputUnreachable ErrorCode = "INPUT_UNREACHABLE"
+	// ErrorCodeInvalidInput は入力が壊れている・映像がない・長さが 0 など、エンコードできない入力である（事前チェックで検出）
+	ErrorCodeInvalidInput ErrorCode = "INVALID_INPUT"
+	// ErrorCodeInputTooLarge は入力の長さまたはサイズが Worker の上限（MAX_INPUT_DURATION_SECONDS・MAX_INPUT_SIZE_BYTES）を超えている
+	ErrorCodeInputTooLarge ErrorCode = "INPUT_TOO_LARGE"
+	// ErrorCodeUnsupportedCodec は入力のコーデックのデコード、または出力のエンコード・格納に対応していない
+	ErrorCodeUnsupportedCodec ErrorCode = "UNSUPPORTED_CODEC"
+	// ErrorCodeDiskFull は作業ディレクトリの空き容量が足りない
+	ErrorCodeDiskFull ErrorCode = "DISK_FULL"
+	// ErrorCodeValidationFailed はエンコード後の出力の検証に失敗した
+	ErrorCodeValidationFailed ErrorCode = "VALIDATION_FAILED"
+	// ErrorCodeFFmpegCrash は上記以外の理由で ffmpeg が異常終了した
+	ErrorCodeFFmpegCrash ErrorCode = "FFMPEG_CRASH"
+)
+
+// codedError は失敗の分類を付けたエラー
+type codedError struct {
+	code ErrorCode
+	err  error
+}
+
+func (e *codedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *codedError) Unwrap() error {
+	return e.err
+}
+
+// withErrorCode は err に失敗の分類を付ける
+func withErrorCode(code ErrorCode, err error) error {
+	return &codedError{code: code, err: err}
+}
+
+// ErrorCodeOf はエンコードのエラーの分類を返す（分類できない場合は空文字列）
+// 空き容量不足は、ffmpeg 以外（ディレクトリの作成や入力のダウンロードなど）で発生した場合も DISK_FULL とする
+func ErrorCodeOf(err error) ErrorCode {
+	if err == nil {
+		return ""
+	}
+	if errors.Is(err, syscall.ENOSPC) {
+		return ErrorCodeDiskFull
+	}
+	var coded *codedError
+	if errors.As(err, &coded) {
+		return coded.code
+	}
+	return ""
+}
+
+// ffmpegErrorPatterns は ffmpeg の stderr に含まれるメッセージと失敗の分類（上から順に照合する）
+var ffmpegErrorPatterns = []struct {
+	code     ErrorCode
+	messages []string
+}{
+	{ErrorCodeDiskFull, []string{
+		"no space left on device",
+		"disk quota exceeded",
+	}},
+	{ErrorCodeInputUnreachable, []string{
+		"server returned 4",
+		"server returned 5",
+		"http error",
+		"connection refused",
+		"connection timed out",
+		"connection reset by peer",
+		"failed to resolve hostname",
+		"name or service not known",
+		"network is unreachable",
+		"no such file or directory",
+		"protocol not found",
+	}},
+	{ErrorCodeUnsupportedCodec, []string{
+		"unknown encoder",
+		"unknown decoder",
+		"not found for output stream",
+		"not found for input stream",
+		"error selecting an encoder",
+		"codec not currently supported",
+		"could not find tag for codec",
+		"unsupported codec",
+	}},
+}
+
+// classifyFFmpegError は異常終了した ffmpeg の stderr から失敗の分類を判定する
+// 該当するメッセージがない場合は FFMPEG_CRASH を返す
+func classifyFFmpegError(stderr string) ErrorCode {
+	stderr = strings.ToLower(stderr)
+	for _, pattern := range ffmpegErrorPatterns {
+		for _, message := range pattern.messages {
+			if strings.Contains(stderr, message) {
+				return pattern.code
+			}
+		}
+	}
+	return ErrorCodeFFmpegCrash
+}
@@ -0,0 +1,56 @@
+package encoder
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+	"testing"
+)
+
+func TestFFmpegのstderrから失敗の分類が判定される(t *testing.T) {
+	testCases := []struct {
+		name   string
+		stderr string
+		want   ErrorCode
+	}{
+		{"HTTPの404", "[https @ 0x55d5c8a2e5c0] HTTP error 404 Not Found\nhttps://example.com/video.mp4: Server returned 404 Not Found", ErrorCodeInputUnreachable},
+		{"接続の拒否", "[tcp @ 0x5600] Connection to tcp://127.0.0.1:8080 failed: Connection refused\nhttp://127.0.0.1:8080/in.mp4: Connection refused", ErrorCodeInputUnreachable},
+		{"名前解決の失敗", "[tcp @ 0x5600] Failed to resolve hostname example.invalid: Name or service not known", ErrorCodeInputUnreachable},
+		{"存在しないローカルファイル", "/data/missing.mp4: No such file or directory", ErrorCodeInputUnreachable},
+		{"存在しないエンコーダー", "Unknown encoder 'libfdk_aac'", ErrorCodeUnsupportedCodec},
+		{"デコーダーがない", "Decoder (codec av1) not found for input stream #0:0", ErrorCodeUnsupportedCodec},
+		{"コンテナに格納できないコーデック", "[mp4 @ 0x5600] Could not find tag for codec pcm_s16le in stream #1, codec not currently supported in container", ErrorCodeUnsupportedCodec},
+		{"空き容量不足", "[hls @ 0x5600] Failed to open file 'segment_003.ts'\nav_interleaved_write_frame(): No space left on device\nError writing trailer of playlist.m3u8: No space left on device", ErrorCodeDiskFull},
+		{"その他の異常終了", "Error while decoding stream #0:0: Invalid data found when processing input\nConversion failed!", ErrorCodeFFmpegCrash},
+		{"出力なし", "", ErrorCodeFFmpegCrash},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := classifyFFmpegError(tc.stderr); got != tc.want {
+				t.Errorf("分類が一致しない: 期待値 %s, 取得値 %s", tc.want, got)
+			}
+		})
+	}
+}
+
+func Testラップされたエラーから失敗の分類を取得できる(t *testing.T) {
+	err := fmt.Errorf("pass 2: %w", withErrorCode(ErrorCodeUnsupportedCodec, errors.New("ffmpeg failed: exit status 1")))
+	if got := ErrorCodeOf(err); got != ErrorCodeUnsupportedCodec {
+		t.Errorf("分類が一致しない: 期待値 %s, 取得値 %s", ErrorCodeUnsupportedCodec, got)
+	}
+	if err.Error() != "pass 2: ffmpeg failed: exit status 1" {
+		t.Errorf("エラーメッセージが変わっている: %s", err.Error())
+	}
+
+	// ffmpeg 以外で発生した空き容量不足も DISK_FULL になる
+	enospc := fmt.Errorf("failed to create job directory: %w", &os.PathError{Op: "mkdir", Path: "/tmp/job", Err: syscall.ENOSPC})
+	if got := ErrorCodeOf(enospc); got != ErrorCodeDiskFull {
+		t.Errorf("分類が一致しない: 期待値 %s, 取得値 %s", ErrorCodeDiskFull, got)
+	}
+
+	if got := ErrorCodeOf(errors.New("failed to get preset: preset not found")); got != "" {
+		t.Errorf("分類できないエラーは空になる必要がある: %s", got)
+	}
+}
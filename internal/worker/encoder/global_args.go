@@ -0,0 +1,89 @@
+package encoder
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"slices"
+
+	"github.com/nzws/flux-encoder/internal/worker/validator"
+)
+
+// defaultGlobalArgs はすべての ffmpeg の実行の先頭に付けるグローバル引数のデフォルト値
+// -nostdin は stdin が端末の場合に ffmpeg が入力を待って止まるのを防ぎ、-hide_banner はログの不要な出力を減らす
+var defaultGlobalArgs = []string{"-nostdin", "-hide_banner"}
+
+// managedGlobalOptions は Worker が付けるため、グローバル引数で指定できないオプション
+// -n は Worker が付ける -y（上書き）と衝突する
+var managedGlobalOptions = map[string]bool{
+	"-i":        true,
+	"-progress": true,
+	"-y":        true,
+	"-n":        true,
+}
+
+// DefaultGlobalArgs はグローバル引数のデフォルト値のコピーを返す
+func DefaultGlobalArgs() []string {
+	return slices.Clone(defaultGlobalArgs)
+}
+
+// ValidateGlobalArgs はグローバル引数が Worker の管理する引数と衝突しないかをチェックする
+func ValidateGlobalArgs(args []string) error {
+	for _, arg := range args {
+		if arg == "" {
+			return fmt.Errorf("ffmpeg global args must not contain empty args")
+		}
+		if managedGlobalOptions[arg] {
+			return fmt.Errorf("ffmpeg global args must not contain %s (managed by the worker)", arg)
+		}
+	}
+	return nil
+}
+
+// SetGlobalArgs はすべての ffmpeg の実行の先頭に付けるグローバル引数を設定する（空の場合は付けない）
+func (e *Encoder) SetGlobalArgs(args []string) error {
+	if err := ValidateGlobalArgs(args); err != nil {
+		return err
+	}
+	e.globalArgs = slices.Clone(args)
+	return nil
+}
+
+// withGlobalArgs は ffmpeg 引数の先頭にグローバル引数を付けた新しいスライスを返す
+func withGlobalArgs(globalArgs, args []string) []string {
+	return append(slices.Clone(globalArgs), args...)
+}
+
+// withNoStdin は -nostdin がなければ先頭に追加した引数を返す
+func withNoStdin(args []string) []string {
+	if slices.Contains(args, "-nostdin") {
+		return args
+	}
+	return append([]string{"-nostdin"}, args...)
+}
+
+// newFFmpegCommand は ffmpegPath の ffmpeg を実行するコマンドを作成する
+// ffmpeg は stdin から対話的な入力（上書きの確認や q キーなど）を読むため、Worker の stdin を読んで止まらないよう
+// グローバル引数の設定に関わらず -nostdin を付け、stdin も明示的に null デバイスにする（Stdin が nil の場合、os/exec は null デバイスを使う）
+// キャンセル時は ffmpeg の子プロセスも含めて終了させる（procgroup_linux.go を参照）
+func newFFmpegCommand(ctx context.Context, ffmpegPath string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, ffmpegPath, withNoStdin(args)...)
+	cmd.Stdin = nil
+	setProcessGroup(cmd)
+	return cmd
+}
+
+// SetBinaryPaths は実行する ffmpeg・ffprobe の実行ファイルを設定する（空の場合は PATH の ffmpeg・ffprobe）
+// エンコード・入力の事前チェック・出力の検証のすべてで使用する
+func (e *Encoder) SetBinaryPaths(ffmpegPath, ffprobePath string) {
+	if ffmpegPath == "" {
+		ffmpegPath = validator.DefaultFFmpegPath
+	}
+	if ffprobePath == "" {
+		ffprobePath = validator.DefaultFFprobePath
+	}
+	e.ffmpegPath = ffmpegPath
+	e.ffprobePath = ffprobePath
+	e.ffprobe = validator.NewFFProbe(ffprobePath)
+	e.validator = validator.NewWithPaths(ffmpegPath, ffprobePath)
+}
@@ -0,0 +1,146 @@
+package encoder
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/nzws/flux-encoder/internal/worker/preset"
+)
+
+func Testデフォルトのグローバル引数がffmpeg引数の先頭に付けられる(t *testing.T) {
+	e := New(t.TempDir())
+	p := preset.Preset{FFmpegArgs: []string{"-c:v", "libx264"}}
+
+	args := buildFFmpegArgs(e.globalArgs, Clip{}, "input.mp4", "output.mp4", p)
+	expected := []string{
+		"-nostdin", "-hide_banner",
+		"-i", "input.mp4", "-progress", "pipe:2", "-y",
+		"-c:v", "libx264",
+		"output.mp4",
+	}
+	if !reflect.DeepEqual(args, expected) {
+		t.Errorf("引数が一致しない:\n期待値 %v\n取得値 %v", expected, args)
+	}
+}
+
+func Test設定したグローバル引数が2パスエンコードとサムネイル生成の先頭に付けられる(t *testing.T) {
+	e := New(t.TempDir())
+	if err := e.SetGlobalArgs([]string{"-nostdin", "-threads", "4"}); err != nil {
+		t.Fatalf("グローバル引数の設定に失敗: %v", err)
+	}
+	p := preset.Preset{FFmpegArgs: []string{"-c:v", "libx264"}, TwoPass: true}
+
+	pass1 := buildTwoPassArgs(e.globalArgs, Clip{}, "input.mp4", "output.mp4", "ffmpeg2pass", p, 1)
+	if !reflect.DeepEqual(pass1[:5], []string{"-nostdin", "-threads", "4", "-i", "input.mp4"}) {
+		t.Errorf("1パス目の先頭の引数が一致しない: %v", pass1)
+	}
+
+	thumbnail := buildThumbnailArgs(e.globalArgs, "input.mp4", "5", 0, "thumbnail.jpg")
+	if !reflect.DeepEqual(thumbnail[:5], []string{"-nostdin", "-threads", "4", "-ss", "5"}) {
+		t.Errorf("サムネイル生成の先頭の引数が一致しない: %v", thumbnail)
+	}
+
+	// 設定後に元のスライスを変更しても影響しない
+	global := []string{"-nostdin"}
+	if err := e.SetGlobalArgs(global); err != nil {
+		t.Fatalf("グローバル引数の設定に失敗: %v", err)
+	}
+	global[0] = "-hide_banner"
+	if args := buildFFmpegArgs(e.globalArgs, Clip{}, "input.mp4", "output.mp4", p); args[0] != "-nostdin" {
+		t.Errorf("設定したグローバル引数が変更された: %v", args)
+	}
+}
+
+func Test空のグローバル引数を設定すると何も付けられない(t *testing.T) {
+	e := New(t.TempDir())
+	if err := e.SetGlobalArgs(nil); err != nil {
+		t.Fatalf("グローバル引数の設定に失敗: %v", err)
+	}
+
+	args := buildFFmpegArgs(e.globalArgs, Clip{}, "input.mp4", "output.mp4", preset.Preset{})
+	expected := []string{"-i", "input.mp4", "-progress", "pipe:2", "-y", "output.mp4"}
+	if !reflect.DeepEqual(args, expected) {
+		t.Errorf("引数が一致しない:\n期待値 %v\n取得値 %v", expected, args)
+	}
+}
+
+func TestWorkerが管理する引数はグローバル引数に指定できない(t *testing.T) {
+	for _, args := range [][]string{
+		{"-i", "other.mp4"},
+		{"-progress", "pipe:1"},
+		{"-y"},
+		{"-nostdin", "-n"},
+		{"-nostdin", ""},
+	} {
+		if err := New(t.TempDir()).SetGlobalArgs(args); err == nil {
+			t.Errorf("エラーが返されなかった: %v", args)
+		}
+	}
+}
+
+func TestFFmpegのコマンドには常にnostdinが付けられる(t *testing.T) {
+	e := New(t.TempDir())
+	if err := e.SetGlobalArgs(nil); err != nil {
+		t.Fatalf("グローバル引数の設定に失敗: %v", err)
+	}
+
+	// グローバル引数がない場合も -nostdin を先頭に付ける
+	cmd := newFFmpegCommand(context.Background(), "ffmpeg", buildFFmpegArgs(e.globalArgs, Clip{}, "input.mp4", "output.mp4", preset.Preset{})...)
+	expected := []string{"ffmpeg", "-nostdin", "-i", "input.mp4", "-progress", "pipe:2", "-y", "output.mp4"}
+	if !reflect.DeepEqual(cmd.Args, expected) {
+		t.Errorf("コマンドの引数が一致しない:\n期待値 %v\n取得値 %v", expected, cmd.Args)
+	}
+	if cmd.Stdin != nil {
+		t.Error("stdin が null デバイス以外に設定されている")
+	}
+
+	// デフォルトのグローバル引数にある場合は重複して付けない
+	cmd = newFFmpegCommand(context.Background(), "ffmpeg", buildFFmpegArgs(DefaultGlobalArgs(), Clip{}, "input.mp4", "output.mp4", preset.Preset{})...)
+	if !reflect.DeepEqual(cmd.Args[:3], []string{"ffmpeg", "-nostdin", "-hide_banner"}) {
+		t.Errorf("コマンドの先頭の引数が一致しない: %v", cmd.Args)
+	}
+}
+
+func Test設定したffmpegとffprobeの実行ファイルがコマンドに使われる(t *testing.T) {
+	// PATH にない実行ファイルを指定しても、設定したパスで実行する
+	binDir := t.TempDir()
+	ffmpegPath := filepath.Join(binDir, "custom-ffmpeg")
+	ffprobePath := filepath.Join(binDir, "custom-ffprobe")
+	if err := os.WriteFile(ffprobePath, []byte("#!/bin/sh\necho 12.5\n"), 0755); err != nil {
+		t.Fatalf("ffprobe の作成に失敗: %v", err)
+	}
+	t.Setenv("PATH", t.TempDir())
+
+	e := New(t.TempDir())
+	e.SetBinaryPaths(ffmpegPath, ffprobePath)
+
+	args, err := e.BuildCommand("path-job", "input.mp4", "720p_h264")
+	if err != nil {
+		t.Fatalf("コマンドの組み立てに失敗: %v", err)
+	}
+	if args[0] != ffmpegPath {
+		t.Errorf("実行ファイルが一致しない: 期待値 %s, 取得値 %s", ffmpegPath, args[0])
+	}
+
+	cmd := newFFmpegCommand(context.Background(), e.ffmpegPath, "-version")
+	if cmd.Path != ffmpegPath {
+		t.Errorf("ffmpeg のパスが一致しない: 期待値 %s, 取得値 %s", ffmpegPath, cmd.Path)
+	}
+
+	duration, err := e.getDuration(context.Background(), "input.mp4")
+	if err != nil {
+		t.Fatalf("設定した ffprobe で長さを取得できない: %v", err)
+	}
+	if duration != 12.5 {
+		t.Errorf("長さが一致しない: 期待値 12.5, 取得値 %v", duration)
+	}
+
+	// 空の場合は PATH の ffmpeg・ffprobe を使う
+	e.SetBinaryPaths("", "")
+	if e.ffmpegPath != "ffmpeg" || e.ffprobePath != "ffprobe" {
+		t.Errorf("デフォルトの実行ファイルが一致しない: %s, %s", e.ffmpegPath, e.ffprobePath)
+	}
+}
@@ -0,0 +1,66 @@
+package encoder
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/nzws/flux-encoder/internal/shared/execlimit"
+	"github.com/nzws/flux-encoder/internal/worker/preset"
+)
+
+// hardwareProbeArgs はハードウェアエンコーダーが利用できるか確認するための、種類ごとのエンコーダーと ffmpeg 引数
+// ffmpeg -encoders はビルドに含まれるエンコーダーを列挙するだけで GPU の有無は分からないため、実際に数フレームをエンコードする
+var hardwareProbeArgs = map[string][]string{
+	preset.HardwareAccelNVENC: {"-c:v", "h264_nvenc"},
+	preset.HardwareAccelQSV:   {"-c:v", "h264_qsv"},
+	preset.HardwareAccelVAAPI: {"-vaapi_device", "/dev/dri/renderD128", "-vf", "format=nv12,hwupload", "-c:v", "h264_vaapi"},
+}
+
+// ProbeHardwareAccel は ffmpegPath の ffmpeg と hwType のハードウェアエンコーダーで短いテスト映像をエンコードできるか確認する
+// GPU・ドライバーがない場合や ffmpeg がエンコーダーを含まない場合はエラーを返す
+func ProbeHardwareAccel(ctx context.Context, ffmpegPath, hwType string) error {
+	encoderArgs, ok := hardwareProbeArgs[hwType]
+	if !ok {
+		return fmt.Errorf("unsupported hardware acceleration: %q (must be nvenc, qsv or vaapi)", hwType)
+	}
+
+	args := []string{"-hide_banner", "-f", "lavfi", "-i", "testsrc=duration=1:size=320x240:rate=25", "-frames:v", "5"}
+	args = append(args, encoderArgs...)
+	args = append(args, "-f", "null", "-")
+	output, err := execlimit.CombinedOutput(newFFmpegCommand(ctx, ffmpegPath, args...))
+	if err != nil {
+		return fmt.Errorf("hardware acceleration %s is not available: %w: %s", hwType, err, lastOutputLine(string(output)))
+	}
+	return nil
+}
+
+// SetHardwareAccel はハードウェアエンコード版のプリセット（<プリセット名>_<種類>）を優先して使用する種類を設定する
+// 呼び出し側で ProbeHardwareAccel により利用できることを確認してから設定する（空の場合は CPU のプリセットを使用する）
+func (e *Encoder) SetHardwareAccel(hwType string) error {
+	if hwType != "" && !preset.IsValidHardwareAccel(hwType) {
+		return fmt.Errorf("unsupported hardware acceleration: %q (must be nvenc, qsv or vaapi)", hwType)
+	}
+	e.hardwareAccel = hwType
+	return nil
+}
+
+// WithoutUnavailableHardwareEncoders は available 以外の種類のハードウェアエンコーダーを除いた Capabilities を返す
+// GPU がない Worker でも ffmpeg -encoders は h264_nvenc などを列挙するため、Control Plane が
+// ハードウェアエンコードのプリセットを利用できない Worker に送らないよう、確認できた種類のみ報告する
+func (c Capabilities) WithoutUnavailableHardwareEncoders(available string) Capabilities {
+	encoders := make([]string, 0, len(c.Encoders))
+	for _, name := range c.Encoders {
+		if hwType, ok := preset.HardwareAccelOfEncoder(name); ok && hwType != available {
+			continue
+		}
+		encoders = append(encoders, name)
+	}
+	return Capabilities{Filters: c.Filters, Encoders: encoders}
+}
+
+// lastOutputLine は ffmpeg の出力の最後の空でない行を返す（エラーメッセージ用）
+func lastOutputLine(output string) string {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
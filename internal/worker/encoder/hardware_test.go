@@ -0,0 +1,50 @@
+package encoder
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/nzws/flux-encoder/internal/worker/preset"
+)
+
+func Test利用できないハードウェアエンコーダーはCapabilitiesから除かれる(t *testing.T) {
+	capabilities := Capabilities{
+		Filters:  []string{"scale"},
+		Encoders: []string{"aac", "h264_nvenc", "h264_qsv", "hevc_nvenc", "libx264"},
+	}
+
+	testCases := []struct {
+		name      string
+		available string
+		expected  []string
+	}{
+		{"NVENCが利用できる", preset.HardwareAccelNVENC, []string{"aac", "h264_nvenc", "hevc_nvenc", "libx264"}},
+		{"ハードウェアエンコードが利用できない", "", []string{"aac", "libx264"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := capabilities.WithoutUnavailableHardwareEncoders(tc.available)
+			if !reflect.DeepEqual(got.Encoders, tc.expected) {
+				t.Errorf("エンコーダー一覧が一致しない: 期待値 %v, 取得値 %v", tc.expected, got.Encoders)
+			}
+			if !reflect.DeepEqual(got.Filters, capabilities.Filters) {
+				t.Errorf("フィルター一覧が変更された: %v", got.Filters)
+			}
+		})
+	}
+}
+
+func Test未対応のハードウェアエンコードの種類はエラーになる(t *testing.T) {
+	encoder := New(t.TempDir())
+	if err := encoder.SetHardwareAccel("amf"); err == nil {
+		t.Error("SetHardwareAccel でエラーが返されなかった")
+	}
+	if err := ProbeHardwareAccel(context.Background(), "ffmpeg", "amf"); err == nil {
+		t.Error("ProbeHardwareAccel でエラーが返されなかった")
+	}
+	if err := encoder.SetHardwareAccel(""); err != nil {
+		t.Errorf("空の種類でエラーが返された: %v", err)
+	}
+}
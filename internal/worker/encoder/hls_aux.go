@@ -0,0 +1,165 @@
+package encoder
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/nzws/flux-encoder/internal/worker/preset"
+)
+
+// ValidateHLSAuxPath は HLS の初期化セグメント・暗号化キーの配置先を検証する
+// 出力ディレクトリからの相対パス（"/" 区切り、".." や絶対パスを含まない正規化済みのもの）のみ受け付ける
+func ValidateHLSAuxPath(p string) error {
+	if !isOutputRelativePath(p) {
+		return fmt.Errorf("invalid hls auxiliary file path (must be a clean relative path inside the output): %q", p)
+	}
+	if strings.HasSuffix(p, ".m3u8") {
+		return fmt.Errorf("hls auxiliary file path must not be a playlist: %q", p)
+	}
+	return nil
+}
+
+// checkHLSAuxPaths はプリセットに対して初期化セグメント・暗号化キーの配置先を指定できるかチェックする
+// 初期化セグメントは fMP4 のセグメント、暗号化キーは暗号化した HLS の場合のみ出力されるため、プリセットの引数から判定する
+// 複数バリアントの HLS はバリアントごとに初期化セグメントがあるため対象外
+func checkHLSAuxPaths(p preset.Preset, opts Options) error {
+	if opts.HLSInitPath == "" && opts.HLSKeyPath == "" {
+		return nil
+	}
+
+	if p.OutputType != outputTypeHLS {
+		return fmt.Errorf("hls init/key paths are only supported for hls output")
+	}
+	if _, ok := argValue(p.FFmpegArgs, "-var_stream_map"); ok {
+		return fmt.Errorf("hls init/key paths are only supported for single-variant hls output")
+	}
+
+	if opts.HLSInitPath != "" {
+		if err := ValidateHLSAuxPath(opts.HLSInitPath); err != nil {
+			return err
+		}
+		if segmentType, _ := argValue(p.FFmpegArgs, "-hls_segment_type"); segmentType != "fmp4" {
+			return fmt.Errorf("hls init path requires fmp4 segments (-hls_segment_type fmp4): %s", p.Name)
+		}
+	}
+	if opts.HLSKeyPath != "" {
+		if err := ValidateHLSAuxPath(opts.HLSKeyPath); err != nil {
+			return err
+		}
+		_, keyInfo := argValue(p.FFmpegArgs, "-hls_key_info_file")
+		if enc, _ := argValue(p.FFmpegArgs, "-hls_enc"); !keyInfo && enc != "1" {
+			return fmt.Errorf("hls key path requires encrypted hls (-hls_key_info_file or -hls_enc 1): %s", p.Name)
+		}
+	}
+	if opts.HLSInitPath == opts.HLSKeyPath {
+		return fmt.Errorf("hls init path and key path must differ: %s", opts.HLSInitPath)
+	}
+	return nil
+}
+
+// applyHLSAuxPaths はプレイリストが参照する初期化セグメント（#EXT-X-MAP）と暗号化キー（#EXT-X-KEY）を指定された配置先に移動し、プレイリストの URI を書き換える
+// 配置先は出力ディレクトリからの相対パスで、プレイリストは出力ディレクトリ直下にあるためそのまま URI になる
+func applyHLSAuxPaths(outputDir, playlistName, initPath, keyPath string) error {
+	if initPath == "" && keyPath == "" {
+		return nil
+	}
+
+	playlistPath := filepath.Join(outputDir, playlistName)
+	content, err := os.ReadFile(playlistPath)
+	if err != nil {
+		return fmt.Errorf("failed to read playlist: %w", err)
+	}
+
+	rewritten := string(content)
+	if initPath != "" {
+		rewritten, err = relocateTagURI(outputDir, rewritten, "#EXT-X-MAP", initPath)
+		if err != nil {
+			return fmt.Errorf("failed to relocate init segment: %w", err)
+		}
+	}
+	if keyPath != "" {
+		rewritten, err = relocateTagURI(outputDir, rewritten, "#EXT-X-KEY", keyPath)
+		if err != nil {
+			return fmt.Errorf("failed to relocate key file: %w", err)
+		}
+	}
+
+	if err := os.WriteFile(playlistPath, []byte(rewritten), 0644); err != nil {
+		return fmt.Errorf("failed to write playlist: %w", err)
+	}
+	return nil
+}
+
+// relocateTagURI は tag の URI 属性が参照するファイルを dest に移動し、URI を dest に書き換えたプレイリストを返す
+// 参照するファイルは1つのみで、出力ディレクトリ内にある必要がある（キーサーバーの URL などは移動できない）
+func relocateTagURI(outputDir, content, tag, dest string) (string, error) {
+	rewritten, uris := rewriteTagURIs(content, tag, dest)
+	switch {
+	case len(uris) == 0:
+		return "", fmt.Errorf("playlist has no %s URI", tag)
+	case len(uris) > 1:
+		return "", fmt.Errorf("playlist references %d different %s URIs", len(uris), tag)
+	}
+
+	uri := uris[0]
+	if !isOutputRelativePath(uri) {
+		return "", fmt.Errorf("%s URI %q is not a file in the output directory", tag, uri)
+	}
+	if uri == dest {
+		return rewritten, nil
+	}
+
+	destPath := filepath.Join(outputDir, filepath.FromSlash(dest))
+	if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
+		return "", fmt.Errorf("failed to create directory for %s: %w", dest, err)
+	}
+	if err := os.Rename(filepath.Join(outputDir, filepath.FromSlash(uri)), destPath); err != nil {
+		return "", fmt.Errorf("failed to move %s to %s: %w", uri, dest, err)
+	}
+	return rewritten, nil
+}
+
+// rewriteTagURIs はプレイリストの tag 行の URI 属性を uri に書き換える
+// 書き換えたプレイリストと、書き換える前の URI（重複なし）を返す。URI 属性のない行（METHOD=NONE など）は変更しない
+func rewriteTagURIs(content, tag, uri string) (string, []string) {
+	var uris []string
+	seen := make(map[string]bool)
+	rewrite := func(old string) string {
+		if !seen[old] {
+			seen[old] = true
+			uris = append(uris, old)
+		}
+		return uri
+	}
+
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), tag+":") {
+			lines[i] = rewriteURIAttribute(line, rewrite)
+		}
+	}
+
+	return strings.Join(lines, "\n"), uris
+}
+
+// isOutputRelativePath は出力ディレクトリ内のファイルを指す相対パスかどうかを返す
+func isOutputRelativePath(p string) bool {
+	if p == "" || strings.ContainsAny(p, `:\`) || path.IsAbs(p) || path.Clean(p) != p {
+		return false
+	}
+	return p != "." && p != ".." && !strings.HasPrefix(p, "../")
+}
+
+// argValue は flag の値を返す（複数ある場合は最後の値）。flag がない場合は false を返す
+func argValue(args []string, flag string) (string, bool) {
+	value, found := "", false
+	for i, arg := range args {
+		if arg == flag && i+1 < len(args) {
+			value, found = args[i+1], true
+		}
+	}
+	return value, found
+}
@@ -0,0 +1,165 @@
+package encoder
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nzws/flux-encoder/internal/worker/preset"
+)
+
+// encryptedFMP4Preset は fMP4 で暗号化した単一バリアント HLS のプリセット
+var encryptedFMP4Preset = preset.Preset{
+	Name:       "hls_fmp4_encrypted",
+	OutputType: outputTypeHLS,
+	Extension:  "m3u8",
+	FFmpegArgs: []string{
+		"-c:v", "libx264", "-f", "hls", "-hls_time", "6",
+		"-hls_segment_type", "fmp4", "-hls_enc", "1",
+	},
+}
+
+// writeHLSOutput は出力ディレクトリにプレイリストと参照されるファイルを作成する
+func writeHLSOutput(t *testing.T, dir, playlist string, files ...string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, "playlist.m3u8"), []byte(playlist), 0644); err != nil {
+		t.Fatalf("プレイリストの作成に失敗: %v", err)
+	}
+	for _, name := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("ディレクトリの作成に失敗: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatalf("ファイルの作成に失敗: %v", err)
+		}
+	}
+}
+
+func Test初期化セグメントと暗号化キーが指定した配置先に移動される(t *testing.T) {
+	dir := t.TempDir()
+	playlist := "#EXTM3U\n#EXT-X-VERSION:7\n#EXT-X-TARGETDURATION:6\n" +
+		"#EXT-X-KEY:METHOD=AES-128,URI=\"playlist.m3u8.key\",IV=0x00000000000000000000000000000001\n" +
+		"#EXT-X-MAP:URI=\"init.mp4\"\n#EXTINF:6.000000,\nsegment_000.m4s\n#EXT-X-ENDLIST\n"
+	writeHLSOutput(t, dir, playlist, "init.mp4", "playlist.m3u8.key", "segment_000.m4s")
+
+	if err := applyHLSAuxPaths(dir, "playlist.m3u8", "init/init.mp4", "keys/video.key"); err != nil {
+		t.Fatalf("配置先の適用に失敗: %v", err)
+	}
+
+	for old, moved := range map[string]string{"init.mp4": "init/init.mp4", "playlist.m3u8.key": "keys/video.key"} {
+		if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(moved))); err != nil {
+			t.Errorf("%s が %s に移動されていない: %v", old, moved, err)
+		}
+		if _, err := os.Stat(filepath.Join(dir, old)); !os.IsNotExist(err) {
+			t.Errorf("%s が元の場所に残っている", old)
+		}
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, "playlist.m3u8"))
+	if err != nil {
+		t.Fatalf("プレイリストの読み込みに失敗: %v", err)
+	}
+	expected := "#EXTM3U\n#EXT-X-VERSION:7\n#EXT-X-TARGETDURATION:6\n" +
+		"#EXT-X-KEY:METHOD=AES-128,URI=\"keys/video.key\",IV=0x00000000000000000000000000000001\n" +
+		"#EXT-X-MAP:URI=\"init/init.mp4\"\n#EXTINF:6.000000,\nsegment_000.m4s\n#EXT-X-ENDLIST\n"
+	if string(content) != expected {
+		t.Errorf("プレイリストが一致しない:\n期待値 %q\n取得値 %q", expected, string(content))
+	}
+}
+
+func TestSegmentsレイアウトの後に初期化セグメントを移動できる(t *testing.T) {
+	dir := t.TempDir()
+	playlist := "#EXTM3U\n#EXT-X-VERSION:7\n#EXT-X-TARGETDURATION:6\n#EXT-X-MAP:URI=\"init.mp4\"\n" +
+		"#EXTINF:6.000000,\nsegment_000.m4s\n#EXT-X-ENDLIST\n"
+	writeHLSOutput(t, dir, playlist, "init.mp4", "segment_000.m4s")
+
+	if err := applySegmentLayout(dir, "playlist.m3u8", SegmentLayoutSubfolder); err != nil {
+		t.Fatalf("レイアウトの適用に失敗: %v", err)
+	}
+	if err := applyHLSAuxPaths(dir, "playlist.m3u8", "init.mp4", ""); err != nil {
+		t.Fatalf("配置先の適用に失敗: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "init.mp4")); err != nil {
+		t.Errorf("初期化セグメントが出力ディレクトリ直下に戻されていない: %v", err)
+	}
+	content, _ := os.ReadFile(filepath.Join(dir, "playlist.m3u8"))
+	if !strings.Contains(string(content), "#EXT-X-MAP:URI=\"init.mp4\"\n") || !strings.Contains(string(content), "\nsegments/segment_000.m4s\n") {
+		t.Errorf("プレイリストの URI が正しく書き換えられていない: %q", string(content))
+	}
+}
+
+func Test移動できない初期化セグメントと暗号化キーはエラーになる(t *testing.T) {
+	tests := []struct {
+		name     string
+		playlist string
+		initPath string
+		keyPath  string
+		wantErr  string
+	}{
+		{
+			name:     "EXT-X-MAP がない",
+			playlist: "#EXTM3U\n#EXTINF:6.000000,\nsegment_000.ts\n#EXT-X-ENDLIST\n",
+			initPath: "init/init.mp4",
+			wantErr:  "no #EXT-X-MAP URI",
+		},
+		{
+			name:     "キーサーバーの URL",
+			playlist: "#EXTM3U\n#EXT-X-KEY:METHOD=AES-128,URI=\"https://keys.example.com/video.key\"\n#EXTINF:6.000000,\nsegment_000.ts\n#EXT-X-ENDLIST\n",
+			keyPath:  "keys/video.key",
+			wantErr:  "is not a file in the output directory",
+		},
+		{
+			name: "キーのローテーション",
+			playlist: "#EXTM3U\n#EXT-X-KEY:METHOD=AES-128,URI=\"key0.key\"\n#EXTINF:6.000000,\nsegment_000.ts\n" +
+				"#EXT-X-KEY:METHOD=AES-128,URI=\"key1.key\"\n#EXTINF:6.000000,\nsegment_001.ts\n#EXT-X-ENDLIST\n",
+			keyPath: "keys/video.key",
+			wantErr: "2 different #EXT-X-KEY URIs",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeHLSOutput(t, dir, tt.playlist)
+
+			err := applyHLSAuxPaths(dir, "playlist.m3u8", tt.initPath, tt.keyPath)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("エラーが一致しない: 期待値 %q を含む, 取得値 %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func Test初期化セグメントと暗号化キーの配置先を指定できるプリセットをチェックする(t *testing.T) {
+	hlsTS, err := preset.Get("hls_720p")
+	if err != nil {
+		t.Fatalf("プリセットの取得に失敗: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		preset  preset.Preset
+		opts    Options
+		wantErr bool
+	}{
+		{name: "fMP4 の初期化セグメント", preset: encryptedFMP4Preset, opts: Options{HLSInitPath: "init/init.mp4"}},
+		{name: "暗号化キー", preset: encryptedFMP4Preset, opts: Options{HLSKeyPath: "keys/video.key"}},
+		{name: "MPEG-TS のセグメント", preset: hlsTS, opts: Options{HLSInitPath: "init/init.mp4"}, wantErr: true},
+		{name: "暗号化しない HLS", preset: hlsTS, opts: Options{HLSKeyPath: "keys/video.key"}, wantErr: true},
+		{name: "出力の外を指すパス", preset: encryptedFMP4Preset, opts: Options{HLSInitPath: "../init.mp4"}, wantErr: true},
+		{name: "絶対パス", preset: encryptedFMP4Preset, opts: Options{HLSKeyPath: "/keys/video.key"}, wantErr: true},
+		{name: "同じ配置先", preset: encryptedFMP4Preset, opts: Options{HLSInitPath: "aux/file", HLSKeyPath: "aux/file"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkHLSAuxPaths(tt.preset, tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("エラーの有無が一致しない: 期待値 %v, 取得値 %v", tt.wantErr, err)
+			}
+		})
+	}
+}
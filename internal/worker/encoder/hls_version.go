@@ -0,0 +1,57 @@
+package encoder
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// hlsVersionTag は HLS プレイリストのバージョンを宣言するタグ
+const hlsVersionTag = "#EXT-X-VERSION"
+
+// setHLSVersion は出力ディレクトリ内のすべてのプレイリストの #EXT-X-VERSION を version に書き換える
+// ffmpeg にはバージョンを指定するオプションがないため、エンコード後にプレイリストを直接書き換える
+func setHLSVersion(outputDir string, version int) error {
+	playlists, err := filepath.Glob(filepath.Join(outputDir, "*.m3u8"))
+	if err != nil {
+		return fmt.Errorf("failed to find HLS playlists: %w", err)
+	}
+	if len(playlists) == 0 {
+		return fmt.Errorf("no HLS playlist found in directory: %s", outputDir)
+	}
+
+	for _, playlist := range playlists {
+		content, err := os.ReadFile(playlist)
+		if err != nil {
+			return fmt.Errorf("failed to read playlist: %w", err)
+		}
+		if err := os.WriteFile(playlist, []byte(replaceHLSVersion(string(content), version)), 0644); err != nil {
+			return fmt.Errorf("failed to write playlist: %w", err)
+		}
+	}
+	return nil
+}
+
+// replaceHLSVersion はプレイリストの #EXT-X-VERSION を置き換える
+// タグがない場合は #EXTM3U の直後に追加する
+func replaceHLSVersion(content string, version int) string {
+	tag := hlsVersionTag + ":" + strconv.Itoa(version)
+	lines := strings.Split(content, "\n")
+
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), hlsVersionTag) {
+			lines[i] = tag
+			return strings.Join(lines, "\n")
+		}
+	}
+
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "#EXTM3U") {
+			lines = append(lines[:i+1], append([]string{tag}, lines[i+1:]...)...)
+			return strings.Join(lines, "\n")
+		}
+	}
+	return tag + "\n" + content
+}
@@ -0,0 +1,68 @@
+package encoder
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestプレイリストのEXTXVERSIONが置き換えられる(t *testing.T) {
+	content := "#EXTM3U\n#EXT-X-VERSION:3\n#EXT-X-TARGETDURATION:6\n#EXTINF:6.000000,\nsegment_000.ts\n"
+	expected := "#EXTM3U\n#EXT-X-VERSION:4\n#EXT-X-TARGETDURATION:6\n#EXTINF:6.000000,\nsegment_000.ts\n"
+
+	if got := replaceHLSVersion(content, 4); got != expected {
+		t.Errorf("プレイリストが一致しない:\n期待値 %q\n取得値 %q", expected, got)
+	}
+}
+
+func TestEXTXVERSIONがないプレイリストにはEXTM3Uの直後に追加される(t *testing.T) {
+	content := "#EXTM3U\n#EXT-X-STREAM-INF:BANDWIDTH=2800000\nstream_0.m3u8\n"
+	expected := "#EXTM3U\n#EXT-X-VERSION:7\n#EXT-X-STREAM-INF:BANDWIDTH=2800000\nstream_0.m3u8\n"
+
+	if got := replaceHLSVersion(content, 7); got != expected {
+		t.Errorf("プレイリストが一致しない:\n期待値 %q\n取得値 %q", expected, got)
+	}
+}
+
+func TestSetHLSVersionが出力ディレクトリのすべてのプレイリストを書き換える(t *testing.T) {
+	dir := t.TempDir()
+	playlists := map[string]string{
+		"master.m3u8":   "#EXTM3U\n#EXT-X-STREAM-INF:BANDWIDTH=2800000\nstream_0.m3u8\n",
+		"stream_0.m3u8": "#EXTM3U\n#EXT-X-VERSION:3\n#EXTINF:6.000000,\nsegment_0_000.ts\n",
+	}
+	for name, content := range playlists {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("プレイリストの作成に失敗: %v", err)
+		}
+	}
+
+	if err := setHLSVersion(dir, 5); err != nil {
+		t.Fatalf("バージョンの設定に失敗: %v", err)
+	}
+
+	for name := range playlists {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("プレイリストの読み取りに失敗: %v", err)
+		}
+		if versionLine(string(data)) != "#EXT-X-VERSION:5" {
+			t.Errorf("%s のバージョンが書き換えられていない:\n%s", name, data)
+		}
+	}
+}
+
+func TestSetHLSVersionでプレイリストがない場合はエラーになる(t *testing.T) {
+	if err := setHLSVersion(t.TempDir(), 5); err == nil {
+		t.Error("プレイリストがなくてもエラーが返されなかった")
+	}
+}
+
+// versionLine はプレイリストの2行目（#EXTM3U の次の行）を返す
+func versionLine(content string) string {
+	lines := strings.Split(content, "\n")
+	if len(lines) < 2 {
+		return ""
+	}
+	return lines[1]
+}
@@ -0,0 +1,68 @@
+package encoder
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// inputDirName はダウンロードした入力ファイルを置くディレクトリ名（ジョブディレクトリからの相対パス）
+// ジョブディレクトリ内に置くため、Cleanup で出力と一緒に削除される
+const inputDirName = "input"
+
+// InputDownloader はオブジェクトストレージの入力をローカルファイルにダウンロードする
+type InputDownloader interface {
+	Download(ctx context.Context, bucket, key, localPath string) error
+}
+
+// SetInputDownloader は s3:// の入力をダウンロードする InputDownloader を設定する
+// 設定しない場合、s3:// の入力はエラーになる
+func (e *Encoder) SetInputDownloader(downloader InputDownloader) {
+	e.inputDownloader = downloader
+}
+
+// resolveInput は入力URLを ffmpeg に渡す入力に解決する
+// http(s) の URL とローカルパスはそのまま返し、s3://bucket/key はジョブディレクトリにダウンロードしたファイルのパスを返す
+func (e *Encoder) resolveInput(ctx context.Context, jobDir, inputURL string) (string, error) {
+	bucket, key, localPath, isS3, err := s3Input(jobDir, inputURL)
+	if err != nil {
+		return "", err
+	}
+	if !isS3 {
+		return inputURL, nil
+	}
+	if e.inputDownloader == nil {
+		return "", fmt.Errorf("s3 input is not supported by this worker (STORAGE_TYPE=s3 is required)")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(localPath), 0755); err != nil {
+		return "", fmt.Errorf("failed to create input directory: %w", err)
+	}
+	if err := e.inputDownloader.Download(ctx, bucket, key, localPath); err != nil {
+		return "", withErrorCode(ErrorCodeInputUnreachable, fmt.Errorf("failed to download input: %w", err))
+	}
+	return localPath, nil
+}
+
+// s3Input は s3://bucket/key の入力のバケット・キーと、ジョブディレクトリ内のダウンロード先のパスを返す
+// s3:// 以外の入力の場合は isS3 が false になる
+func s3Input(jobDir, inputURL string) (bucket, key, localPath string, isS3 bool, err error) {
+	u, err := url.Parse(inputURL)
+	if err != nil || !strings.EqualFold(u.Scheme, "s3") {
+		return "", "", "", false, nil
+	}
+
+	bucket = u.Host
+	key = strings.TrimPrefix(u.Path, "/")
+	if bucket == "" || key == "" || strings.HasSuffix(key, "/") {
+		return "", "", "", true, fmt.Errorf("invalid s3 input url: %s", inputURL)
+	}
+
+	// キーのファイル名は使わない（ffmpeg がフォーマットを推測できるよう拡張子のみ引き継ぐ）
+	localPath = filepath.Join(jobDir, inputDirName, "source"+path.Ext(key))
+	return bucket, key, localPath, true, nil
+}
@@ -0,0 +1,100 @@
+package encoder
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/nzws/flux-encoder/internal/shared/logger"
+	"go.uber.org/zap"
+)
+
+// inputHeadTimeout は入力のサイズを調べる HEAD リクエストのタイムアウト
+const inputHeadTimeout = 10 * time.Second
+
+// InputLimits は Worker が受け付ける入力の上限
+// 長時間・高解像度の入力で実行枠を何時間も占有しないよう、エンコードを始める前に確認する
+type InputLimits struct {
+	// MaxDuration はエンコードする長さ（切り出す場合はその範囲）の上限。0 の場合は制限しない
+	MaxDuration time.Duration
+	// MaxSize は入力のサイズ（バイト）の上限。0 の場合は制限しない
+	MaxSize int64
+}
+
+// SetInputLimits は受け付ける入力の長さとサイズの上限を設定する（負の値は 0 として扱う）
+func (e *Encoder) SetInputLimits(limits InputLimits) {
+	e.inputLimits = InputLimits{
+		MaxDuration: max(limits.MaxDuration, 0),
+		MaxSize:     max(limits.MaxSize, 0),
+	}
+}
+
+// checkInputLimits はエンコードする長さ（秒）と入力のサイズが上限を超えていないかを確認する
+// size が 0（ffprobe で取得できなかった）の場合は、http(s) の入力は HEAD リクエストの Content-Length、ローカルのファイルはファイルのサイズを使う
+// 長さやサイズが分からない場合は確認しない
+func (e *Encoder) checkInputLimits(ctx context.Context, jobID, inputURL string, duration float64, size int64) error {
+	limits := e.inputLimits
+	if limits.MaxDuration > 0 && duration > limits.MaxDuration.Seconds() {
+		return withErrorCode(ErrorCodeInputTooLarge,
+			fmt.Errorf("input duration %.1fs exceeds the maximum of %s", duration, limits.MaxDuration))
+	}
+
+	if limits.MaxSize > 0 {
+		if size <= 0 {
+			size = inputSize(ctx, inputURL)
+		}
+		if size <= 0 {
+			logger.Warn("Input size is unknown, skipping size limit", zap.String("job_id", jobID))
+			return nil
+		}
+		if size > limits.MaxSize {
+			return withErrorCode(ErrorCodeInputTooLarge,
+				fmt.Errorf("input size %d bytes exceeds the maximum of %d bytes", size, limits.MaxSize))
+		}
+	}
+	return nil
+}
+
+// inputSize は入力のサイズ（バイト）を返す。取得できない場合は 0 を返す
+func inputSize(ctx context.Context, inputURL string) int64 {
+	u, err := url.Parse(inputURL)
+	if err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+		return headContentLength(ctx, inputURL)
+	}
+	if err == nil && u.Scheme != "" && u.Scheme != "file" {
+		return 0
+	}
+
+	path := inputURL
+	if err == nil && u.Scheme == "file" {
+		path = u.Path
+	}
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return 0
+	}
+	return info.Size()
+}
+
+// headContentLength は HEAD リクエストで取得した Content-Length を返す。取得できない場合は 0 を返す
+func headContentLength(ctx context.Context, inputURL string) int64 {
+	ctx, cancel := context.WithTimeout(ctx, inputHeadTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, inputURL, nil)
+	if err != nil {
+		return 0
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0
+	}
+	return max(resp.ContentLength, 0)
+}
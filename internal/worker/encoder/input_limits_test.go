@@ -0,0 +1,117 @@
+package encoder
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// installFakeProbeInfo は指定した長さ（秒）とサイズ（バイト）の映像として応答する ffprobe を PATH に配置する（ffmpeg は配置しない）
+func installFakeProbeInfo(t *testing.T, duration string, size int64) {
+	t.Helper()
+
+	probe := `{"format":{"format_name":"mov,mp4,m4a,3gp,3g2,mj2","duration":"` + duration + `","size":"` + strconv.FormatInt(size, 10) + `"},` +
+		`"streams":[{"codec_type":"video","codec_name":"h264","width":3840,"height":2160}]}`
+	binDir := t.TempDir()
+	script := "#!/bin/sh\necho '" + probe + "'\n"
+	if err := os.WriteFile(filepath.Join(binDir, "ffprobe"), []byte(script), 0755); err != nil {
+		t.Fatalf("偽の ffprobe の作成に失敗: %v", err)
+	}
+	t.Setenv("PATH", binDir)
+}
+
+func Test長さの上限を超える入力は事前チェックでINPUT_TOO_LARGEになる(t *testing.T) {
+	installFakeProbeInfo(t, "28800.0", 1<<20)
+
+	e := New(t.TempDir())
+	e.SetInputLimits(InputLimits{MaxDuration: time.Hour})
+	_, err := e.EncodeWithOptions(context.Background(), "long-job", "input.mp4", "720p_h264", Options{}, nil)
+	if err == nil {
+		t.Fatal("上限を超える長さの入力でエラーが返されない")
+	}
+	if code := ErrorCodeOf(err); code != ErrorCodeInputTooLarge {
+		t.Errorf("エラーの分類が一致しない: 期待値 %s, 取得値 %s (%v)", ErrorCodeInputTooLarge, code, err)
+	}
+	if !strings.Contains(err.Error(), "input duration 28800.0s exceeds the maximum of 1h0m0s") {
+		t.Errorf("エラーメッセージが一致しない: %v", err)
+	}
+}
+
+func Testサイズの上限を超える入力は事前チェックでINPUT_TOO_LARGEになる(t *testing.T) {
+	installFakeProbeInfo(t, "10.0", 5<<30)
+
+	e := New(t.TempDir())
+	e.SetInputLimits(InputLimits{MaxDuration: time.Hour, MaxSize: 1 << 30})
+	_, err := e.EncodeWithOptions(context.Background(), "large-job", "input.mp4", "720p_h264", Options{}, nil)
+	if code := ErrorCodeOf(err); code != ErrorCodeInputTooLarge {
+		t.Fatalf("エラーの分類が一致しない: 期待値 %s, 取得値 %s (%v)", ErrorCodeInputTooLarge, code, err)
+	}
+	if !strings.Contains(err.Error(), "input size 5368709120 bytes exceeds the maximum of 1073741824 bytes") {
+		t.Errorf("エラーメッセージが一致しない: %v", err)
+	}
+}
+
+func Test上限以内の入力は事前チェックを通過する(t *testing.T) {
+	installFakeProbeInfo(t, "28800.0", 5<<30)
+
+	// 8 時間の入力から 10 分だけ切り出す場合は、切り出す長さで判定する
+	clip, err := ParseClip("60", "600")
+	if err != nil {
+		t.Fatalf("切り出し範囲の作成に失敗: %v", err)
+	}
+	e := New(t.TempDir())
+	e.SetInputLimits(InputLimits{MaxDuration: time.Hour, MaxSize: 10 << 30})
+	_, err = e.EncodeWithOptions(context.Background(), "clip-job", "input.mp4", "720p_h264", Options{Clip: clip}, nil)
+	if err == nil {
+		t.Fatal("ffmpeg がないのにエラーが返されない")
+	}
+	// 上限の確認を通過し、ffmpeg の実行で失敗する
+	if code := ErrorCodeOf(err); code == ErrorCodeInputTooLarge {
+		t.Errorf("上限以内の入力が拒否された: %v", err)
+	}
+}
+
+func Test入力のサイズが不明な場合はHEADリクエストのContent_Lengthで判定する(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodHead {
+			t.Errorf("HEAD 以外のリクエストが送信された: %s", r.Method)
+		}
+		w.Header().Set("Content-Length", "2048")
+	}))
+	defer server.Close()
+
+	e := New(t.TempDir())
+	e.SetInputLimits(InputLimits{MaxSize: 1024})
+	err := e.checkInputLimits(context.Background(), "head-job", server.URL+"/input.mp4?token=secret", 10, 0)
+	if code := ErrorCodeOf(err); code != ErrorCodeInputTooLarge {
+		t.Errorf("エラーの分類が一致しない: 期待値 %s, 取得値 %s (%v)", ErrorCodeInputTooLarge, code, err)
+	}
+
+	e.SetInputLimits(InputLimits{MaxSize: 4096})
+	if err := e.checkInputLimits(context.Background(), "head-job", server.URL+"/input.mp4", 10, 0); err != nil {
+		t.Errorf("上限以内の入力が拒否された: %v", err)
+	}
+}
+
+func Test入力のサイズが不明なローカルファイルはファイルサイズで判定する(t *testing.T) {
+	inputPath := filepath.Join(t.TempDir(), "input.mp4")
+	if err := os.WriteFile(inputPath, make([]byte, 2048), 0644); err != nil {
+		t.Fatalf("入力ファイルの作成に失敗: %v", err)
+	}
+
+	e := New(t.TempDir())
+	e.SetInputLimits(InputLimits{MaxSize: 1024})
+	if code := ErrorCodeOf(e.checkInputLimits(context.Background(), "local-job", inputPath, 10, 0)); code != ErrorCodeInputTooLarge {
+		t.Errorf("エラーの分類が一致しない: 期待値 %s, 取得値 %s", ErrorCodeInputTooLarge, code)
+	}
+	// サイズが取得できない入力は判定しない
+	if err := e.checkInputLimits(context.Background(), "unknown-job", "rtmp://example.com/live", 10, 0); err != nil {
+		t.Errorf("サイズが不明な入力が拒否された: %v", err)
+	}
+}
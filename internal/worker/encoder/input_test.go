@@ -0,0 +1,89 @@
+package encoder
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeInputDownloader はダウンロードしたオブジェクトを記録し、固定の内容を書き込む InputDownloader
+type fakeInputDownloader struct {
+	bucket string
+	key    string
+}
+
+func (d *fakeInputDownloader) Download(ctx context.Context, bucket, key, localPath string) error {
+	d.bucket, d.key = bucket, key
+	return os.WriteFile(localPath, []byte("video"), 0644)
+}
+
+func TestHTTPの入力とローカルパスはそのまま返される(t *testing.T) {
+	encoder := New(t.TempDir())
+	encoder.SetInputDownloader(&fakeInputDownloader{})
+
+	for _, input := range []string{
+		"https://example.com/video.mp4",
+		"http://example.com/video.mp4?token=abc",
+		"/data/videos/video.mp4",
+		"video.mp4",
+	} {
+		resolved, err := encoder.resolveInput(context.Background(), t.TempDir(), input)
+		if err != nil {
+			t.Errorf("%s の解決に失敗: %v", input, err)
+			continue
+		}
+		if resolved != input {
+			t.Errorf("入力が変更された: 期待値 %s, 取得値 %s", input, resolved)
+		}
+	}
+}
+
+func TestS3の入力はジョブディレクトリにダウンロードされCleanupで削除される(t *testing.T) {
+	workDir := t.TempDir()
+	encoder := New(workDir)
+	downloader := &fakeInputDownloader{}
+	encoder.SetInputDownloader(downloader)
+
+	jobID := "s3-input-job"
+	jobDir := filepath.Join(workDir, jobID)
+	resolved, err := encoder.resolveInput(context.Background(), jobDir, "s3://private-bucket/sources/2025/video.mov")
+	if err != nil {
+		t.Fatalf("s3 の入力の解決に失敗: %v", err)
+	}
+
+	if downloader.bucket != "private-bucket" || downloader.key != "sources/2025/video.mov" {
+		t.Errorf("ダウンロードしたオブジェクトが一致しない: bucket %s, key %s", downloader.bucket, downloader.key)
+	}
+	if expected := filepath.Join(jobDir, inputDirName, "source.mov"); resolved != expected {
+		t.Errorf("ダウンロード先が一致しない: 期待値 %s, 取得値 %s", expected, resolved)
+	}
+	if _, err := os.Stat(resolved); err != nil {
+		t.Fatalf("ダウンロードしたファイルが存在しない: %v", err)
+	}
+
+	if err := encoder.Cleanup(jobID); err != nil {
+		t.Fatalf("Cleanup に失敗: %v", err)
+	}
+	if _, err := os.Stat(resolved); !os.IsNotExist(err) {
+		t.Error("ダウンロードしたファイルが削除されていない")
+	}
+}
+
+func TestS3の入力が解決できない場合はエラーが返る(t *testing.T) {
+	withDownloader := New(t.TempDir())
+	withDownloader.SetInputDownloader(&fakeInputDownloader{})
+
+	for _, input := range []string{"s3://bucket-only", "s3://bucket/", "s3:///key.mp4", "s3://bucket/dir/"} {
+		if _, err := withDownloader.resolveInput(context.Background(), t.TempDir(), input); err == nil {
+			t.Errorf("%s でエラーが返されない", input)
+		}
+	}
+
+	// ダウンロード先が設定されていない Worker では s3 の入力を受け付けない
+	_, err := New(t.TempDir()).resolveInput(context.Background(), t.TempDir(), "s3://bucket/video.mp4")
+	if err == nil || !strings.Contains(err.Error(), "not supported") {
+		t.Errorf("s3 の入力が未対応であるエラーが返されない: %v", err)
+	}
+}
@@ -0,0 +1,99 @@
+package encoder
+
+import "sync"
+
+const (
+	// JobLogRetainLines は購読の開始時に送信するため、ジョブごとに保持する ffmpeg の stderr の行数
+	JobLogRetainLines = 200
+
+	// jobLogSubscriberBuffer は購読者ごとにバッファする行数
+	// 購読者の受信が遅れてバッファが一杯になった場合、ffmpeg の読み取りを止めないよう行を破棄する
+	jobLogSubscriberBuffer = 256
+)
+
+// jobLog はエンコード中のジョブの ffmpeg の stderr の直近の行と購読者
+type jobLog struct {
+	mu          sync.Mutex
+	lines       []string
+	subscribers map[chan string]struct{}
+}
+
+// beginJobLog はジョブの ffmpeg の stderr の保持を開始し、終了時に呼び出す関数を返す
+// 終了すると購読者のチャネルを閉じる
+func (e *Encoder) beginJobLog(jobID string) func() {
+	log := &jobLog{subscribers: make(map[chan string]struct{})}
+
+	e.jobLogsMu.Lock()
+	e.jobLogs[jobID] = log
+	e.jobLogsMu.Unlock()
+
+	return func() {
+		e.jobLogsMu.Lock()
+		if e.jobLogs[jobID] == log {
+			delete(e.jobLogs, jobID)
+		}
+		e.jobLogsMu.Unlock()
+
+		log.mu.Lock()
+		defer log.mu.Unlock()
+		for ch := range log.subscribers {
+			close(ch)
+		}
+		log.subscribers = nil
+	}
+}
+
+// publishJobLog はジョブの ffmpeg の stderr の1行を保持し、購読者に送信する
+func (e *Encoder) publishJobLog(jobID, line string) {
+	e.jobLogsMu.Lock()
+	log, ok := e.jobLogs[jobID]
+	e.jobLogsMu.Unlock()
+	if !ok {
+		return
+	}
+
+	log.mu.Lock()
+	defer log.mu.Unlock()
+	if len(log.lines) == JobLogRetainLines {
+		log.lines = append(log.lines[:0], log.lines[1:]...)
+	}
+	log.lines = append(log.lines, line)
+	for ch := range log.subscribers {
+		select {
+		case ch <- line:
+		default:
+		}
+	}
+}
+
+// SubscribeJobLogs はエンコード中のジョブの ffmpeg の stderr を購読する
+// 保持している直近の行と、以降の行を受信するチャネルを返す（チャネルはジョブのエンコードが終了すると閉じられる）
+// 購読をやめる場合は unsubscribe を呼び出す。エンコード中でない場合は ok が false になる
+func (e *Encoder) SubscribeJobLogs(jobID string) (backlog []string, lines <-chan string, unsubscribe func(), ok bool) {
+	e.jobLogsMu.Lock()
+	log, ok := e.jobLogs[jobID]
+	e.jobLogsMu.Unlock()
+	if !ok {
+		return nil, nil, nil, false
+	}
+
+	log.mu.Lock()
+	defer log.mu.Unlock()
+	if log.subscribers == nil {
+		// 購読の直前にエンコードが終了した
+		return nil, nil, nil, false
+	}
+	ch := make(chan string, jobLogSubscriberBuffer)
+	log.subscribers[ch] = struct{}{}
+	backlog = append([]string(nil), log.lines...)
+
+	unsubscribe = func() {
+		log.mu.Lock()
+		defer log.mu.Unlock()
+		if _, exists := log.subscribers[ch]; exists {
+			delete(log.subscribers, ch)
+			close(ch)
+		}
+	}
+	return backlog, ch, unsubscribe, true
+}
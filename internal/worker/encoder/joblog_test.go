@@ -0,0 +1,49 @@
+package encoder
+
+import (
+	"fmt"
+	"testing"
+)
+
+func Test購読者は保持している直近の行と以降の行を受信する(t *testing.T) {
+	e := New(t.TempDir())
+	end := e.beginJobLog("log-job")
+
+	for i := 0; i < JobLogRetainLines+5; i++ {
+		e.publishJobLog("log-job", fmt.Sprintf("line %d", i))
+	}
+
+	backlog, lines, unsubscribe, ok := e.SubscribeJobLogs("log-job")
+	if !ok {
+		t.Fatal("エンコード中のジョブを購読できない")
+	}
+	defer unsubscribe()
+
+	if len(backlog) != JobLogRetainLines {
+		t.Fatalf("保持している行数が一致しない: 期待値 %d, 取得値 %d", JobLogRetainLines, len(backlog))
+	}
+	if backlog[0] != "line 5" || backlog[len(backlog)-1] != fmt.Sprintf("line %d", JobLogRetainLines+4) {
+		t.Errorf("古い行から破棄されていない: 先頭 %q, 末尾 %q", backlog[0], backlog[len(backlog)-1])
+	}
+
+	e.publishJobLog("log-job", "frame=  10 fps=25")
+	if got := <-lines; got != "frame=  10 fps=25" {
+		t.Errorf("購読後の行が一致しない: %q", got)
+	}
+
+	// エンコードが終了するとチャネルが閉じられ、以降は購読できない
+	end()
+	if _, open := <-lines; open {
+		t.Error("エンコードの終了後もチャネルが閉じられない")
+	}
+	if _, _, _, ok := e.SubscribeJobLogs("log-job"); ok {
+		t.Error("終了したジョブを購読できた")
+	}
+}
+
+func Testエンコード中でないジョブは購読できない(t *testing.T) {
+	e := New(t.TempDir())
+	if _, _, _, ok := e.SubscribeJobLogs("unknown-job"); ok {
+		t.Error("存在しないジョブを購読できた")
+	}
+}
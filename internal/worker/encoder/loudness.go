@@ -0,0 +1,143 @@
+package encoder
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/nzws/flux-encoder/internal/shared/logger"
+	"github.com/nzws/flux-encoder/internal/worker/preset"
+	"go.uber.org/zap"
+)
+
+// loudnormSampleRate は -ar の指定がない場合に loudnorm の出力をリサンプリングするサンプリングレート
+// loudnorm は 192kHz で出力するため、指定がないとエンコーダーの対応する最大のレートで出力されてしまう
+const loudnormSampleRate = 48000
+
+// loudnessMeasurement は loudnorm の測定パス（print_format=json）の測定値
+type loudnessMeasurement struct {
+	InputI       string `json:"input_i"`
+	InputTP      string `json:"input_tp"`
+	InputLRA     string `json:"input_lra"`
+	InputThresh  string `json:"input_thresh"`
+	TargetOffset string `json:"target_offset"`
+}
+
+// applyLoudness はプリセットの設定に従ってラウドネス正規化の -af を ffmpeg 引数に追加する
+// 2パスの場合は先に入力のラウドネスを測定し、測定値を loudnorm に渡す（測定できない無音の入力などは1パスで正規化する）
+func (e *Encoder) applyLoudness(ctx context.Context, jobID, inputURL string, p preset.Preset, clip Clip, duration float64, callback ProgressCallback) ([]string, error) {
+	if err := preset.ValidateLoudness(p); err != nil {
+		return nil, err
+	}
+
+	target := preset.LoudnessTarget(p)
+	var measured *loudnessMeasurement
+	if target.TwoPass {
+		logger.Info("Measuring loudness", zap.String("job_id", jobID))
+		m, err := e.measureLoudness(ctx, jobID, inputURL, clip, target, duration, callback)
+		if err != nil {
+			return nil, err
+		}
+		if m.valid() {
+			measured = m
+		} else {
+			logger.Warn("Loudness could not be measured, falling back to single-pass normalization",
+				zap.String("job_id", jobID),
+				zap.String("input_i", m.InputI),
+			)
+		}
+	}
+
+	return loudnessArgs(p.FFmpegArgs, target, measured), nil
+}
+
+// loudnessArgs は ffmpegArgs に正規化の -af を追加した引数を返す（measured が nil の場合は1パスの正規化）
+func loudnessArgs(ffmpegArgs []string, target preset.LoudnessSpec, measured *loudnessMeasurement) []string {
+	filter := loudnormFilter(target, measured)
+	if !slices.Contains(ffmpegArgs, "-ar") {
+		filter += ",aresample=" + strconv.Itoa(loudnormSampleRate)
+	}
+
+	args := make([]string, len(ffmpegArgs), len(ffmpegArgs)+2)
+	copy(args, ffmpegArgs)
+	return append(args, "-af", filter)
+}
+
+// measureLoudness は loudnorm の測定パスを実行して入力のラウドネスを測定する
+// 測定中の進捗は全体の進捗を進めずにメッセージとして通知する
+func (e *Encoder) measureLoudness(ctx context.Context, jobID, inputURL string, clip Clip, target preset.LoudnessSpec, duration float64, callback ProgressCallback) (*loudnessMeasurement, error) {
+	args := buildLoudnessMeasureArgs(e.globalArgs, clip, inputURL, target)
+	stderrLines, err := e.runFFmpegWithOutput(ctx, jobID, args, "", duration, func(_ float32, message string) {
+		callback(0, "Measuring loudness: "+message)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to measure loudness: %w", err)
+	}
+	return parseLoudnessMeasurement(stderrLines)
+}
+
+// buildLoudnessMeasureArgs は loudnorm の測定パスの ffmpeg 引数を構築する（映像・字幕は処理せずに結果を破棄する）
+// 切り出す場合はエンコードする範囲と同じ範囲を測定する
+func buildLoudnessMeasureArgs(globalArgs []string, clip Clip, inputURL string, target preset.LoudnessSpec) []string {
+	return withGlobalArgs(globalArgs, append(clip.inputArgs(inputURL),
+		"-progress", "pipe:2",
+		"-vn", "-sn", "-dn",
+		"-af", loudnormFilter(target, nil)+":print_format=json",
+		"-f", "null", os.DevNull,
+	))
+}
+
+// parseLoudnessMeasurement は測定パスの stderr の末尾から loudnorm が出力した JSON を取り出す
+func parseLoudnessMeasurement(stderrLines []string) (*loudnessMeasurement, error) {
+	output := strings.Join(stderrLines, "\n")
+	end := strings.LastIndex(output, "}")
+	if end < 0 {
+		return nil, fmt.Errorf("loudness measurement not found in ffmpeg output")
+	}
+	start := strings.LastIndex(output[:end], "{")
+	if start < 0 {
+		return nil, fmt.Errorf("loudness measurement not found in ffmpeg output")
+	}
+
+	var m loudnessMeasurement
+	if err := json.Unmarshal([]byte(output[start:end+1]), &m); err != nil {
+		return nil, fmt.Errorf("failed to parse loudness measurement: %w", err)
+	}
+	return &m, nil
+}
+
+// valid は測定値がすべて有限の数値か（無音の入力では -inf になる）を返す
+func (m *loudnessMeasurement) valid() bool {
+	for _, value := range []string{m.InputI, m.InputTP, m.InputLRA, m.InputThresh, m.TargetOffset} {
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil || math.IsInf(f, 0) || math.IsNaN(f) {
+			return false
+		}
+	}
+	return true
+}
+
+// loudnormFilter は loudnorm フィルターの指定を構築する
+// measured を指定した場合は測定値を渡し、線形の正規化（2パスの2回目）を行う
+func loudnormFilter(target preset.LoudnessSpec, measured *loudnessMeasurement) string {
+	filter := fmt.Sprintf("loudnorm=I=%s:TP=%s:LRA=%s",
+		formatLoudness(target.IntegratedLUFS),
+		formatLoudness(target.TruePeak),
+		formatLoudness(target.LRA),
+	)
+	if measured == nil {
+		return filter
+	}
+	return filter + fmt.Sprintf(":measured_I=%s:measured_TP=%s:measured_LRA=%s:measured_thresh=%s:offset=%s:linear=true",
+		measured.InputI, measured.InputTP, measured.InputLRA, measured.InputThresh, measured.TargetOffset)
+}
+
+// formatLoudness は目標値を loudnorm のオプション値として出力する（-16 や -1.5 のように不要な0を付けない）
+func formatLoudness(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
@@ -0,0 +1,114 @@
+package encoder
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/nzws/flux-encoder/internal/worker/preset"
+)
+
+func Test1パスのラウドネス正規化で目標値のloudnormがafに追加される(t *testing.T) {
+	p := preset.Preset{
+		Name:         "loudnorm_test",
+		FFmpegArgs:   []string{"-c:v", "libx264", "-c:a", "aac"},
+		LoudnessNorm: true,
+	}
+
+	args, err := New(t.TempDir()).applyLoudness(context.Background(), "test-job", "input.mp4", p, Clip{}, 0, func(float32, string) {})
+	if err != nil {
+		t.Fatalf("ラウドネス正規化の適用に失敗: %v", err)
+	}
+	want := []string{"-c:v", "libx264", "-c:a", "aac", "-af", "loudnorm=I=-16:TP=-1.5:LRA=11,aresample=48000"}
+	if !reflect.DeepEqual(args, want) {
+		t.Errorf("引数が一致しない:\n期待値 %v\n取得値 %v", want, args)
+	}
+
+	// 目標値を指定し、-ar がある場合はリサンプリングを追加しない
+	p.FFmpegArgs = []string{"-c:a", "aac", "-ar", "44100"}
+	p.Loudness = &preset.LoudnessSpec{IntegratedLUFS: -23, TruePeak: -2, LRA: 7}
+	args, err = New(t.TempDir()).applyLoudness(context.Background(), "test-job", "input.mp4", p, Clip{}, 0, func(float32, string) {})
+	if err != nil {
+		t.Fatalf("ラウドネス正規化の適用に失敗: %v", err)
+	}
+	want = []string{"-c:a", "aac", "-ar", "44100", "-af", "loudnorm=I=-23:TP=-2:LRA=7"}
+	if !reflect.DeepEqual(args, want) {
+		t.Errorf("目標値を指定した引数が一致しない:\n期待値 %v\n取得値 %v", want, args)
+	}
+}
+
+func Testラウドネス正規化はafを指定したプリセットではエラーになる(t *testing.T) {
+	p := preset.Preset{
+		Name:         "loudnorm_with_af",
+		FFmpegArgs:   []string{"-c:a", "aac", "-af", "volume=2"},
+		LoudnessNorm: true,
+	}
+
+	if _, err := New(t.TempDir()).applyLoudness(context.Background(), "test-job", "input.mp4", p, Clip{}, 0, func(float32, string) {}); err == nil {
+		t.Error("-af を指定したプリセットでエラーが返されなかった")
+	}
+	if _, err := applyOptions(p, Options{}); err == nil {
+		t.Error("applyOptions で -af を指定したプリセットのエラーが返されなかった")
+	}
+
+	p.FFmpegArgs = []string{"-c:a", "aac"}
+	if _, err := applyOptions(p, Options{StreamCopy: StreamCopyAudio}); err == nil {
+		t.Error("音声のコピーとラウドネス正規化の併用でエラーが返されなかった")
+	}
+}
+
+func Testラウドネスの測定値がffmpegの出力から取り出される(t *testing.T) {
+	lines := []string{
+		"progress=end",
+		"[Parsed_loudnorm_0 @ 0x5581c0a3e2c0] ",
+		"{",
+		`	"input_i" : "-27.61",`,
+		`	"input_tp" : "-4.47",`,
+		`	"input_lra" : "18.06",`,
+		`	"input_thresh" : "-39.20",`,
+		`	"output_i" : "-16.58",`,
+		`	"output_tp" : "-1.50",`,
+		`	"output_lra" : "14.78",`,
+		`	"output_thresh" : "-27.71",`,
+		`	"normalization_type" : "dynamic",`,
+		`	"target_offset" : "0.58"`,
+		"}",
+	}
+
+	m, err := parseLoudnessMeasurement(lines)
+	if err != nil {
+		t.Fatalf("測定値の取り出しに失敗: %v", err)
+	}
+	if !m.valid() {
+		t.Errorf("有効な測定値が無効と判定された: %+v", m)
+	}
+
+	target := preset.LoudnessTarget(preset.Preset{LoudnessNorm: true})
+	want := "loudnorm=I=-16:TP=-1.5:LRA=11:measured_I=-27.61:measured_TP=-4.47:measured_LRA=18.06:measured_thresh=-39.20:offset=0.58:linear=true"
+	if got := loudnormFilter(target, m); got != want {
+		t.Errorf("2回目の loudnorm が一致しない:\n期待値 %s\n取得値 %s", want, got)
+	}
+
+	measureArgs := strings.Join(buildLoudnessMeasureArgs(nil, Clip{}, "input.mp4", target), " ")
+	if !strings.Contains(measureArgs, "-af loudnorm=I=-16:TP=-1.5:LRA=11:print_format=json") {
+		t.Errorf("測定パスの引数に print_format=json の loudnorm が含まれていない: %s", measureArgs)
+	}
+}
+
+func Test無音の入力の測定値は無効と判定され測定値がない出力はエラーになる(t *testing.T) {
+	silent := &loudnessMeasurement{
+		InputI:       "-inf",
+		InputTP:      "-inf",
+		InputLRA:     "0.00",
+		InputThresh:  "-70.00",
+		TargetOffset: "inf",
+	}
+	if silent.valid() {
+		t.Error("無音の入力の測定値が有効と判定された")
+	}
+
+	if _, err := parseLoudnessMeasurement([]string{"frame=10", "progress=end"}); err == nil {
+		t.Error("測定値がない出力でエラーが返されなかった")
+	}
+}
@@ -0,0 +1,208 @@
+package encoder
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/nzws/flux-encoder/internal/worker/preset"
+)
+
+// Options はジョブごとにプリセットの設定を上書きするオプション
+type Options struct {
+	// Speed はエンコーダーの -preset 値（例: "veryfast"）。空の場合はプリセットの値を使用する
+	Speed string
+	// StreamCopy は再エンコードせずにコピーするストリーム（StreamCopyVideo / StreamCopyAudio）。空の場合は両方を再エンコードする
+	StreamCopy string
+	// Overrides は上書きする ffmpeg オプション（先頭の "-" を除いた名前と値、例: {"crf": "20"}）
+	// 使用できるキーと値は preset.ValidateOverrides でチェックされる
+	Overrides map[string]string
+	// SegmentLayout は HLS のセグメントの配置（SegmentLayoutFlat / SegmentLayoutSubfolder）。空の場合は flat
+	SegmentLayout string
+	// HLSInitPath は fMP4 の HLS の初期化セグメントの配置先（出力ディレクトリからの相対パス）。空の場合は ffmpeg の出力のまま
+	HLSInitPath string
+	// HLSKeyPath は暗号化した HLS の暗号化キーの配置先（出力ディレクトリからの相対パス）。空の場合は ffmpeg の出力のまま
+	HLSKeyPath string
+	// SubtitlePath は映像に焼き込む字幕（WebVTT/SRT）のパスまたは URL。空の場合は焼き込まない
+	SubtitlePath string
+	// RawArgs はプリセットの代わりに使用する ffmpeg 引数（入力と出力パスは付けずに指定する）。空の場合はプリセットを使用する
+	// 呼び出し側で利用を許可した場合のみ指定する（raw.go を参照）
+	RawArgs []string
+	// RawExtension は RawArgs を指定した場合の出力ファイルの拡張子。空の場合は mp4
+	RawExtension string
+	// Clip は入力から切り出してエンコードする範囲（ParseClip で作成する）。ゼロ値の場合は入力全体をエンコードする
+	Clip Clip
+	// SkipPreflight はエンコード前の入力の事前チェック（preflight.go を参照）を省略するか
+	// ffprobe では長さや映像を判別できない特殊な入力の場合に指定する
+	SkipPreflight bool
+	// Validation はエンコード後の出力の検証の設定（Worker のデフォルト設定を上書きする）。ゼロ値の場合はデフォルト設定で検証する
+	Validation ValidationSettings
+	// OnCommand はエンコードの ffmpeg を実行する直前に、実行するコマンド（先頭は実行ファイル名）を受け取る。nil の場合は呼び出さない
+	// 2パスエンコードの場合はパスごとに呼び出す。コマンドには署名付き URL などがそのまま含まれるため、公開する場合は RedactCommand を使う
+	OnCommand func(command []string)
+}
+
+// applyOptions はオプションを適用したプリセットのコピーを返す
+// FFmpegArgs は新しいスライスにコピーしてから変更するため、登録済みのプリセットには影響しない
+func applyOptions(p preset.Preset, opts Options) (preset.Preset, error) {
+	if len(opts.RawArgs) > 0 {
+		if err := checkRawOptions(opts); err != nil {
+			return preset.Preset{}, err
+		}
+	}
+
+	args := make([]string, len(p.FFmpegArgs))
+	copy(args, p.FFmpegArgs)
+
+	if opts.Speed != "" {
+		if opts.StreamCopy == StreamCopyVideo {
+			return preset.Preset{}, fmt.Errorf("speed cannot be combined with video stream copy")
+		}
+		var err error
+		args, err = applySpeed(args, opts.Speed)
+		if err != nil {
+			return preset.Preset{}, err
+		}
+	}
+
+	if len(opts.Overrides) > 0 {
+		if opts.StreamCopy == StreamCopyVideo {
+			return preset.Preset{}, fmt.Errorf("overrides cannot be combined with video stream copy")
+		}
+		if _, ok := opts.Overrides["preset"]; ok && opts.Speed != "" {
+			return preset.Preset{}, fmt.Errorf("speed and preset override cannot both be set")
+		}
+		// ハードウェアエンコーダーは -crf に対応していない（品質は -cq や -qp などエンコーダー固有のオプションで指定する）
+		if _, ok := opts.Overrides["crf"]; ok && p.HardwareAccel != "" {
+			return preset.Preset{}, fmt.Errorf("crf override is not supported for hardware_accel preset: %s", p.Name)
+		}
+		var err error
+		args, err = applyOverrides(args, opts.Overrides)
+		if err != nil {
+			return preset.Preset{}, err
+		}
+	}
+
+	if opts.StreamCopy != "" {
+		var err error
+		args, err = applyStreamCopy(p, args, opts.StreamCopy)
+		if err != nil {
+			return preset.Preset{}, err
+		}
+	}
+
+	// ラウドネス正規化のフィルターは入力の測定が必要な場合があるため、ここでは設定のみチェックする
+	if p.LoudnessNorm {
+		if opts.StreamCopy == StreamCopyAudio {
+			return preset.Preset{}, fmt.Errorf("loudness normalization cannot be combined with audio stream copy")
+		}
+		if err := preset.ValidateLoudness(p); err != nil {
+			return preset.Preset{}, err
+		}
+	}
+
+	if err := checkSegmentLayout(p, opts.SegmentLayout); err != nil {
+		return preset.Preset{}, err
+	}
+	if err := checkHLSAuxPaths(p, opts); err != nil {
+		return preset.Preset{}, err
+	}
+
+	// 検証の設定はエンコード後に使用するが、不正な場合はエンコードの前に失敗させる
+	if err := opts.Validation.Validate(); err != nil {
+		return preset.Preset{}, err
+	}
+
+	// 字幕のフィルターはダウンロード後のパスが必要なため、ここでは焼き込めるかのみチェックする
+	if opts.SubtitlePath != "" {
+		if opts.StreamCopy == StreamCopyVideo {
+			return preset.Preset{}, fmt.Errorf("burn-in subtitles cannot be combined with video stream copy")
+		}
+		if err := ValidateSubtitlePath(opts.SubtitlePath); err != nil {
+			return preset.Preset{}, err
+		}
+		if err := CheckSubtitleBurnIn(p); err != nil {
+			return preset.Preset{}, err
+		}
+	}
+
+	p.FFmpegArgs = args
+	return p, nil
+}
+
+// applySpeed は -preset の値を speed に置き換える。-preset がない場合は追加する
+// x264/x265 以外のエンコーダーでは -preset の意味が異なるためエラーを返す
+func applySpeed(args []string, speed string) ([]string, error) {
+	if !preset.IsValidSpeed(speed) {
+		return nil, fmt.Errorf("invalid speed: %s", speed)
+	}
+
+	for i, arg := range args {
+		if arg == "-c:v" && i+1 < len(args) && args[i+1] != "libx264" && args[i+1] != "libx265" {
+			return nil, fmt.Errorf("speed is not supported for video encoder: %s", args[i+1])
+		}
+	}
+
+	replaced := false
+	for i, arg := range args {
+		if arg == "-preset" && i+1 < len(args) {
+			args[i+1] = speed
+			replaced = true
+		}
+	}
+	if !replaced {
+		args = append(args, "-preset", speed)
+	}
+
+	return args, nil
+}
+
+// applyOverrides は上書き指定の ffmpeg オプションの値を置き換える。オプションがない場合は追加する
+// -filter_complex を使うプリセット（ABR など）はバリアントごとに値が異なるため対象外
+func applyOverrides(args []string, overrides map[string]string) ([]string, error) {
+	if err := preset.ValidateOverrides(overrides); err != nil {
+		return nil, err
+	}
+	for _, arg := range args {
+		if arg == "-filter_complex" {
+			return nil, fmt.Errorf("overrides are not supported for presets using %s", arg)
+		}
+	}
+
+	// 追加される引数の順序を固定するためキーをソートする
+	keys := make([]string, 0, len(overrides))
+	for key := range overrides {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := overrides[key]
+		if key == "preset" {
+			// -preset はエンコーダーの対応確認が必要なため speed と同じ処理で置き換える
+			var err error
+			args, err = applySpeed(args, value)
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+		args = setArg(args, "-"+key, value)
+	}
+
+	return args, nil
+}
+
+// setArg は flag の値を value に置き換える。flag がない場合は末尾に追加する
+func setArg(args []string, flag, value string) []string {
+	replaced := false
+	for i, arg := range args {
+		if arg == flag && i+1 < len(args) {
+			args[i+1] = value
+			replaced = true
+		}
+	}
+	if !replaced {
+		args = append(args, flag, value)
+	}
+	return args
+}
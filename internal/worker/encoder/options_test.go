@@ -0,0 +1,196 @@
+package encoder
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/nzws/flux-encoder/internal/worker/preset"
+)
+
+// presetValue は引数列から -preset の値を取り出す
+func presetValue(args []string) []string {
+	var values []string
+	for i, arg := range args {
+		if arg == "-preset" && i+1 < len(args) {
+			values = append(values, args[i+1])
+		}
+	}
+	return values
+}
+
+func TestSpeed指定でプリセット既定のpresetが置き換えられる(t *testing.T) {
+	base, err := preset.Get("720p_h264")
+	if err != nil {
+		t.Fatalf("プリセットの取得に失敗: %v", err)
+	}
+
+	applied, err := applyOptions(base, Options{Speed: "veryfast"})
+	if err != nil {
+		t.Fatalf("オプションの適用に失敗: %v", err)
+	}
+
+	values := presetValue(applied.FFmpegArgs)
+	if len(values) != 1 || values[0] != "veryfast" {
+		t.Errorf("-preset の値が一致しない: 期待値 [veryfast], 取得値 %v", values)
+	}
+	if len(applied.FFmpegArgs) != len(base.FFmpegArgs) {
+		t.Errorf("引数の数が変わった: 期待値 %d, 取得値 %d", len(base.FFmpegArgs), len(applied.FFmpegArgs))
+	}
+
+	// 登録済みのプリセットが変更されていないことを確認
+	original, err := preset.Get("720p_h264")
+	if err != nil {
+		t.Fatalf("プリセットの取得に失敗: %v", err)
+	}
+	if values := presetValue(original.FFmpegArgs); len(values) != 1 || values[0] != "medium" {
+		t.Errorf("登録済みのプリセットが変更された: 取得値 %v", values)
+	}
+}
+
+func TestSpeed未指定ではプリセットの引数がそのまま使われる(t *testing.T) {
+	base, err := preset.Get("720p_h264")
+	if err != nil {
+		t.Fatalf("プリセットの取得に失敗: %v", err)
+	}
+
+	applied, err := applyOptions(base, Options{})
+	if err != nil {
+		t.Fatalf("オプションの適用に失敗: %v", err)
+	}
+
+	if len(applied.FFmpegArgs) != len(base.FFmpegArgs) {
+		t.Fatalf("引数の数が変わった: 期待値 %d, 取得値 %d", len(base.FFmpegArgs), len(applied.FFmpegArgs))
+	}
+	for i := range base.FFmpegArgs {
+		if applied.FFmpegArgs[i] != base.FFmpegArgs[i] {
+			t.Errorf("引数[%d] が一致しない: 期待値 %s, 取得値 %s", i, base.FFmpegArgs[i], applied.FFmpegArgs[i])
+		}
+	}
+}
+
+func Testプリセット引数を持たないプリセットにspeedが追加される(t *testing.T) {
+	base := preset.Preset{
+		Name:       "custom",
+		FFmpegArgs: []string{"-c:v", "libx265", "-crf", "28"},
+	}
+
+	applied, err := applyOptions(base, Options{Speed: "slow"})
+	if err != nil {
+		t.Fatalf("オプションの適用に失敗: %v", err)
+	}
+
+	if values := presetValue(applied.FFmpegArgs); len(values) != 1 || values[0] != "slow" {
+		t.Errorf("-preset の値が一致しない: 期待値 [slow], 取得値 %v", values)
+	}
+	if len(base.FFmpegArgs) != 4 {
+		t.Errorf("元の引数が変更された: %v", base.FFmpegArgs)
+	}
+}
+
+func Test不正なSpeedと非対応エンコーダーでエラーが返る(t *testing.T) {
+	h264, err := preset.Get("720p_h264")
+	if err != nil {
+		t.Fatalf("プリセットの取得に失敗: %v", err)
+	}
+	if _, err := applyOptions(h264, Options{Speed: "turbo"}); err == nil {
+		t.Error("不正な speed でエラーが返されなかった")
+	}
+
+	av1, err := preset.Get("1080p_av1")
+	if err != nil {
+		t.Fatalf("プリセットの取得に失敗: %v", err)
+	}
+	if _, err := applyOptions(av1, Options{Speed: "veryfast"}); err == nil {
+		t.Error("x264/x265 以外のエンコーダーでエラーが返されなかった")
+	}
+}
+
+func TestOverrides指定でプリセットの引数が置き換えられ不足分は追加される(t *testing.T) {
+	base, err := preset.Get("720p_h264")
+	if err != nil {
+		t.Fatalf("プリセットの取得に失敗: %v", err)
+	}
+
+	applied, err := applyOptions(base, Options{Overrides: map[string]string{"crf": "20", "b:v": "3000k", "preset": "slow"}})
+	if err != nil {
+		t.Fatalf("オプションの適用に失敗: %v", err)
+	}
+
+	expected := []string{
+		"-vf", "scale=-2:720",
+		"-c:v", "libx264",
+		"-preset", "slow",
+		"-crf", "20",
+		"-c:a", "aac",
+		"-b:a", "128k",
+		"-movflags", "+faststart",
+		"-b:v", "3000k",
+	}
+	if !reflect.DeepEqual(applied.FFmpegArgs, expected) {
+		t.Errorf("引数が一致しない:\n期待値 %v\n取得値 %v", expected, applied.FFmpegArgs)
+	}
+
+	// 登録済みのプリセットが変更されていないことを確認
+	original, err := preset.Get("720p_h264")
+	if err != nil {
+		t.Fatalf("プリセットの取得に失敗: %v", err)
+	}
+	if !reflect.DeepEqual(original.FFmpegArgs, base.FFmpegArgs) {
+		t.Errorf("登録済みのプリセットが変更された: %v", original.FFmpegArgs)
+	}
+}
+
+func TestOverridesでプロファイルとレベルが引数に追加され期待値に設定される(t *testing.T) {
+	base, err := preset.Get("720p_h264")
+	if err != nil {
+		t.Fatalf("プリセットの取得に失敗: %v", err)
+	}
+
+	applied, err := applyOptions(base, Options{Overrides: map[string]string{"profile:v": "baseline", "level": "3.0"}})
+	if err != nil {
+		t.Fatalf("オプションの適用に失敗: %v", err)
+	}
+
+	expected := append(append([]string{}, base.FFmpegArgs...), "-level", "3.0", "-profile:v", "baseline")
+	if !reflect.DeepEqual(applied.FFmpegArgs, expected) {
+		t.Errorf("引数が一致しない:\n期待値 %v\n取得値 %v", expected, applied.FFmpegArgs)
+	}
+
+	if got := New(t.TempDir()).getExpectedInfoFromPreset(applied).Profile; got != "baseline" {
+		t.Errorf("期待するプロファイルが一致しない: 期待値 baseline, 取得値 %q", got)
+	}
+	// プロファイルを指定しない場合は検証しない
+	if got := New(t.TempDir()).getExpectedInfoFromPreset(base).Profile; got != "" {
+		t.Errorf("指定のないプロファイルが期待値に含まれている: %q", got)
+	}
+}
+
+func TestOverridesの不正な指定はエラーになる(t *testing.T) {
+	tests := []struct {
+		name   string
+		preset string
+		opts   Options
+	}{
+		{"未知のキー", "720p_h264", Options{Overrides: map[string]string{"vf": "scale=1:1"}}},
+		{"数値でないCRF", "720p_h264", Options{Overrides: map[string]string{"crf": "high"}}},
+		{"単位のないビットレート", "720p_h264", Options{Overrides: map[string]string{"b:v": "3000"}}},
+		{"Speedとpresetの同時指定", "720p_h264", Options{Speed: "fast", Overrides: map[string]string{"preset": "slow"}}},
+		{"映像コピーとの併用", "720p_h264", Options{StreamCopy: StreamCopyVideo, Overrides: map[string]string{"crf": "20"}}},
+		{"filter_complexを使うプリセット", "hls_720p_abr", Options{Overrides: map[string]string{"crf": "20"}}},
+		{"x264以外のエンコーダーでのpreset", "1080p_av1", Options{Overrides: map[string]string{"preset": "slow"}}},
+		{"ハードウェアエンコードのプリセットでのCRF", "1080p_h264_nvenc", Options{Overrides: map[string]string{"crf": "20"}}},
+		{"ハードウェアエンコードのプリセットでのSpeed", "1080p_h264_nvenc", Options{Speed: "fast"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base, err := preset.Get(tt.preset)
+			if err != nil {
+				t.Fatalf("プリセットの取得に失敗: %v", err)
+			}
+			if _, err := applyOptions(base, tt.opts); err == nil {
+				t.Error("エラーが返されなかった")
+			}
+		})
+	}
+}
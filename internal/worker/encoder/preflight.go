@@ -0,0 +1,39 @@
+package encoder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/nzws/flux-encoder/internal/worker/validator"
+)
+
+// preflightInput はエンコードを始める前に ffprobe で入力を調べ、エンコードできない入力の場合はエラーを返す
+// 壊れた入力や映像のない入力で ffmpeg を起動し、数分後に失敗するまで実行枠を占有しないようにする
+// 成功した場合は ffprobe で取得した入力の情報を返す
+func (e *Encoder) preflightInput(ctx context.Context, inputURL string) (*validator.MediaInfo, error) {
+	info, err := e.ffprobe.GetMediaInfo(ctx, inputURL)
+	if err != nil {
+		// 入力を取得できない場合はその分類を使い、それ以外は ffprobe が読めない入力として扱う
+		code := classifyFFmpegError(err.Error())
+		if code != ErrorCodeInputUnreachable {
+			code = ErrorCodeInvalidInput
+		}
+		return nil, withErrorCode(code, fmt.Errorf("pre-flight check failed: cannot read input: %w", err))
+	}
+	if err := checkPreflight(info); err != nil {
+		return nil, withErrorCode(ErrorCodeInvalidInput, fmt.Errorf("pre-flight check failed: %w", err))
+	}
+	return info, nil
+}
+
+// checkPreflight は ffprobe で取得した入力の情報がエンコードできるものかをチェックする
+func checkPreflight(info *validator.MediaInfo) error {
+	if len(info.VideoStreams) == 0 {
+		return errors.New("input has no video stream")
+	}
+	if info.Duration <= 0 {
+		return errors.New("input has zero duration")
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package encoder
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nzws/flux-encoder/internal/worker/validator"
+)
+
+func Testメディアではないファイルは事前チェックで失敗する(t *testing.T) {
+	inputPath := filepath.Join(t.TempDir(), "not-a-video.mp4")
+	if err := os.WriteFile(inputPath, []byte("this is not a media file"), 0644); err != nil {
+		t.Fatalf("入力ファイルの作成に失敗: %v", err)
+	}
+
+	e := New(t.TempDir())
+	_, err := e.EncodeWithOptions(context.Background(), "preflight-job", inputPath, "720p_h264", Options{}, nil)
+	if err == nil {
+		t.Fatal("メディアではない入力でエラーが返されない")
+	}
+	if !strings.Contains(err.Error(), "pre-flight check failed") {
+		t.Errorf("事前チェックのエラーではない: %v", err)
+	}
+	if code := ErrorCodeOf(err); code != ErrorCodeInvalidInput {
+		t.Errorf("エラーの分類が一致しない: 期待値 %s, 取得値 %s", ErrorCodeInvalidInput, code)
+	}
+}
+
+func Test事前チェックは映像がない入力と長さが0の入力を拒否する(t *testing.T) {
+	video := []validator.VideoStreamInfo{{Codec: "h264", Width: 1280, Height: 720}}
+
+	tests := []struct {
+		name    string
+		info    validator.MediaInfo
+		wantErr string
+	}{
+		{
+			name: "映像と長さがある",
+			info: validator.MediaInfo{Duration: 10, VideoStreams: video},
+		},
+		{
+			name:    "映像がない",
+			info:    validator.MediaInfo{Duration: 10, AudioStreams: []validator.AudioStreamInfo{{Codec: "aac"}}},
+			wantErr: "no video stream",
+		},
+		{
+			name:    "長さが0",
+			info:    validator.MediaInfo{VideoStreams: video},
+			wantErr: "zero duration",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkPreflight(&tt.info)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("エラーが返された: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("エラーが一致しない: 期待値 %q を含む, 取得値 %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,25 @@
+//go:build linux
+
+package encoder
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup は ffmpeg を新しいプロセスグループで起動し、コンテキストのキャンセル時にグループ全体を終了させる
+// exec.CommandContext はキャンセル時に ffmpeg 本体にしか SIGKILL を送らないため、ffmpeg が起動した子プロセス
+// （一部のフィルターなど）が孤児となって CPU を使い続けたり、出力のパイプを開いたまま Wait を止めたりするのを防ぐ
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		// Setpgid によりプロセスグループ ID は ffmpeg の PID と同じになる
+		err := syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+		if errors.Is(err, syscall.ESRCH) {
+			return os.ErrProcessDone
+		}
+		return err
+	}
+}
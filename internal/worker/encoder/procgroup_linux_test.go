@@ -0,0 +1,80 @@
+//go:build linux
+
+package encoder
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// processAlive はプロセスが実行中か（終了済みのゾンビでないか）を返す
+func processAlive(pid int) bool {
+	stat, err := os.ReadFile("/proc/" + strconv.Itoa(pid) + "/stat")
+	if err != nil {
+		return false
+	}
+	// /proc/<pid>/stat は "<pid> (<comm>) <state> ..." の形式
+	fields := strings.Fields(string(stat[strings.LastIndexByte(string(stat), ')')+1:]))
+	return len(fields) > 0 && fields[0] != "Z"
+}
+
+func Testキャンセル時に子プロセスも終了する(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh がインストールされていません")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// 子プロセスを起動して PID を出力し、終了を待つ
+	cmd := exec.CommandContext(ctx, "sh", "-c", "sleep 60 & echo $!; wait")
+	setProcessGroup(cmd)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatalf("StdoutPipe に失敗: %v", err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("プロセスの起動に失敗: %v", err)
+	}
+
+	line, err := bufio.NewReader(stdout).ReadString('\n')
+	if err != nil {
+		t.Fatalf("子プロセスの PID の読み込みに失敗: %v", err)
+	}
+	childPID, err := strconv.Atoi(strings.TrimSpace(line))
+	if err != nil {
+		t.Fatalf("子プロセスの PID が不正: %q", line)
+	}
+	if !processAlive(childPID) {
+		t.Fatal("子プロセスが起動していない")
+	}
+
+	cancel()
+	if err := cmd.Wait(); err == nil {
+		t.Error("キャンセルしたプロセスがエラーなしで終了した")
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for processAlive(childPID) {
+		if time.Now().After(deadline) {
+			t.Fatalf("キャンセル後も子プロセス %d が実行中", childPID)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestFFmpegのコマンドは新しいプロセスグループで起動する(t *testing.T) {
+	cmd := newFFmpegCommand(context.Background(), "-version")
+	if cmd.SysProcAttr == nil || !cmd.SysProcAttr.Setpgid {
+		t.Error("Setpgid が設定されていない")
+	}
+	if cmd.Cancel == nil {
+		t.Error("キャンセル時の処理が設定されていない")
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !linux
+
+package encoder
+
+import "os/exec"
+
+// setProcessGroup は Linux 以外では何もしない（キャンセル時は exec.CommandContext の既定の動作で ffmpeg 本体のみ終了させる）
+func setProcessGroup(cmd *exec.Cmd) {}
@@ -0,0 +1,196 @@
+package encoder
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nzws/flux-encoder/internal/worker/preset"
+)
+
+// ジョブが直接指定する ffmpeg 引数（raw_ffmpeg_args）の扱い
+//
+// 生の引数は ffmpeg にそのまま渡すため、指定できる利用者はプリセットと違って
+// ffmpeg が読み書きできる範囲（Worker のファイルシステム上の任意のファイルなど）を操作できる。
+// そのため Worker の WORKER_ALLOW_RAW_ARGS=true で明示的に有効にした場合のみ受け付け、
+// ジョブを投入できる利用者を信頼できる環境でのみ有効にすることを前提とする。
+//
+// ffmpeg はシェルを介さずに exec で起動するため、引数に含まれるシェルの構文は解釈されない。
+// ValidateRawArgs はそれでもシェルへの埋め込みを意図したとみられる引数や、
+// Worker が付ける入力・進捗出力と衝突する引数、追加のファイルを読み込む引数を拒否するが、
+// 任意のファイルへの出力（位置引数）などを防ぐものではなくサンドボックスではない。
+const (
+	// maxRawArgs は生の ffmpeg 引数の最大数
+	maxRawArgs = 256
+	// maxRawArgLength は生の ffmpeg 引数1つあたりの最大長
+	maxRawArgLength = 4096
+
+	// rawPresetName は生の ffmpeg 引数のジョブのプリセット名（ログ・メトリクス用）
+	rawPresetName = "raw"
+	// defaultRawExtension は出力パスに拡張子がない場合の出力ファイルの拡張子
+	defaultRawExtension = "mp4"
+)
+
+// shellMetaSequences はシェルへの埋め込みを意図したとみられる文字列
+// フィルターグラフは ; や , を使うため、それらの単独の文字は対象にしない
+var shellMetaSequences = []string{"`", "$(", "${", "&&", "||", "\x00", "\n", "\r"}
+
+// shellOperators は単独の引数として現れるとシェルの演算子とみられる引数
+var shellOperators = map[string]bool{
+	";": true, "|": true, "&": true, ">": true, ">>": true, "<": true, "2>": true, "2>&1": true,
+}
+
+// disallowedRawOptions は生の ffmpeg 引数で指定できないオプション
+// 入力と進捗出力は Worker が付けるため、その他は Worker のファイルを読み込む・書き出すため拒否する
+var disallowedRawOptions = map[string]bool{
+	"-i":                     true,
+	"-progress":              true,
+	"-filter_script":         true,
+	"-filter_complex_script": true,
+	"-attach":                true,
+	"-dump_attachment":       true,
+	"-passlogfile":           true,
+	"-vstats_file":           true,
+	"-stats_enc_pre":         true,
+	"-stats_enc_post":        true,
+	"-stats_mux_pre":         true,
+}
+
+// ValidateRawArgs は生の ffmpeg 引数を検証する
+// exec でシェルを介さずに起動するため安全性を保証するものではなく、明らかに不正な指定を早期に拒否する
+func ValidateRawArgs(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("raw ffmpeg args are empty")
+	}
+	if len(args) > maxRawArgs {
+		return fmt.Errorf("too many raw ffmpeg args: %d (max %d)", len(args), maxRawArgs)
+	}
+
+	for _, arg := range args {
+		if len(arg) > maxRawArgLength {
+			return fmt.Errorf("raw ffmpeg arg exceeds %d bytes", maxRawArgLength)
+		}
+		if shellOperators[arg] {
+			return fmt.Errorf("raw ffmpeg args must not contain shell operators: %q", arg)
+		}
+		for _, seq := range shellMetaSequences {
+			if strings.Contains(arg, seq) {
+				return fmt.Errorf("raw ffmpeg args must not contain shell syntax: %q", arg)
+			}
+		}
+		option := rawOptionName(arg)
+		if disallowedRawOptions[option] || strings.HasPrefix(option, "-/") {
+			return fmt.Errorf("raw ffmpeg args must not contain %s", option)
+		}
+	}
+	return nil
+}
+
+// rawOptionName はストリーム指定子（-filter_script:v など）を除いたオプション名を返す
+func rawOptionName(arg string) string {
+	if !strings.HasPrefix(arg, "-") {
+		return ""
+	}
+	if name, _, ok := strings.Cut(arg, ":"); ok {
+		return name
+	}
+	return arg
+}
+
+// checkRawOptions は生の ffmpeg 引数と併用できないオプション（プリセットの引数を変更するもの）がないかチェックする
+func checkRawOptions(opts Options) error {
+	switch {
+	case opts.Speed != "":
+		return fmt.Errorf("speed cannot be combined with raw ffmpeg args")
+	case opts.StreamCopy != "":
+		return fmt.Errorf("stream copy cannot be combined with raw ffmpeg args")
+	case len(opts.Overrides) > 0:
+		return fmt.Errorf("overrides cannot be combined with raw ffmpeg args")
+	case opts.SegmentLayout != "":
+		return fmt.Errorf("segment layout cannot be combined with raw ffmpeg args")
+	case opts.HLSInitPath != "", opts.HLSKeyPath != "":
+		return fmt.Errorf("hls init/key paths cannot be combined with raw ffmpeg args")
+	case opts.SubtitlePath != "":
+		return fmt.Errorf("burn-in subtitles cannot be combined with raw ffmpeg args")
+	}
+	return nil
+}
+
+// rawPreset は生の ffmpeg 引数から単一ファイル出力のプリセットを作成する
+// extension は出力ファイルの拡張子（空の場合は defaultRawExtension、英数字のみ）
+func rawPreset(args []string, extension string) (preset.Preset, error) {
+	if err := ValidateRawArgs(args); err != nil {
+		return preset.Preset{}, err
+	}
+
+	extension = strings.ToLower(strings.TrimPrefix(extension, "."))
+	if extension == "" {
+		extension = defaultRawExtension
+	}
+	if len(extension) > 8 || strings.IndexFunc(extension, func(r rune) bool {
+		return (r < 'a' || r > 'z') && (r < '0' || r > '9')
+	}) >= 0 {
+		return preset.Preset{}, fmt.Errorf("invalid output extension for raw ffmpeg args: %q", extension)
+	}
+
+	return preset.Preset{
+		Name:       rawPresetName,
+		FFmpegArgs: append([]string(nil), args...),
+		Extension:  extension,
+		OutputType: "single",
+	}, nil
+}
+
+// RawOutputShortEdge は生の ffmpeg 引数が指定する出力の解像度から、短辺（px）の上限を返す
+// -s（"1280x720"）と -vf・-filter:v・-filter_complex の scale・pad の大きさを対象とし、複数ある場合は最も大きいものを返す
+// 片方の辺が自動（-2 など）の場合も短辺は指定した辺以下になるため、指定した辺を上限とする
+// 大きさの指定がない場合（入力と同じ解像度になる）や、式などで大きさを求められない指定がある場合はエラーを返す
+func RawOutputShortEdge(args []string) (int, error) {
+	shortEdge := 0
+	found := false
+	for i := 0; i+1 < len(args); i++ {
+		var sizes [][2]int
+		switch rawOptionName(args[i]) {
+		case "-s":
+			width, height, ok := strings.Cut(args[i+1], "x")
+			if !ok {
+				return 0, fmt.Errorf("unsupported output size: %q (use WIDTHxHEIGHT)", args[i+1])
+			}
+			sizes = append(sizes, [2]int{positiveSize(width), positiveSize(height)})
+		case "-vf", "-filter", "-filter_complex", "-lavfi":
+			for _, filter := range strings.FieldsFunc(filterLinkLabel.ReplaceAllString(args[i+1], ""), func(r rune) bool {
+				return r == ';' || r == ','
+			}) {
+				name, params, _ := strings.Cut(strings.TrimSpace(filter), "=")
+				if scaleFilters[name] || name == "pad" {
+					width, height, _ := parseSizeParams(params)
+					sizes = append(sizes, [2]int{width, height})
+				}
+			}
+		}
+
+		for _, size := range sizes {
+			edge := shortEdgeBound(size[0], size[1])
+			if edge == 0 {
+				return 0, fmt.Errorf("cannot determine the output size of %s %q", args[i], args[i+1])
+			}
+			found = true
+			shortEdge = max(shortEdge, edge)
+		}
+	}
+	if !found {
+		return 0, fmt.Errorf("raw ffmpeg args do not set the output size with -s or a scale filter")
+	}
+	return shortEdge, nil
+}
+
+// shortEdgeBound は幅と高さ（自動・不明な辺は 0）から短辺の上限を返す（どちらも不明な場合は 0）
+func shortEdgeBound(width, height int) int {
+	switch {
+	case width > 0 && height > 0:
+		return min(width, height)
+	case width > 0:
+		return width
+	default:
+		return height
+	}
+}
@@ -0,0 +1,120 @@
+package encoder
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test生のffmpeg引数でフィルターグラフを含む引数は受け付けられる(t *testing.T) {
+	args := []string{
+		"-filter_complex", "[0:v]split=2[a][b];[a]scale=-2:720[v1];[b]scale=-2:360[v2]",
+		"-map", "[v1]", "-c:v", "libx264", "-crf", "-1",
+		"-af", "pan=stereo|c0=c0|c1=c1",
+	}
+	if err := ValidateRawArgs(args); err != nil {
+		t.Errorf("正しい引数でエラーが返された: %v", err)
+	}
+}
+
+func Test生のffmpeg引数でシェルの構文と入力やファイルを読み込むオプションはエラーになる(t *testing.T) {
+	testCases := [][]string{
+		nil,
+		{"-c:v", "libx264", ";", "rm", "-rf", "/"},
+		{"-metadata", "title=$(id)"},
+		{"-metadata", "title=`id`"},
+		{"-c:v", "libx264", "&&", "curl", "example.com"},
+		{"-metadata", "title=a\nb"},
+		{"-i", "/etc/passwd"},
+		{"-progress", "/tmp/progress"},
+		{"-filter_complex_script", "/tmp/graph.txt"},
+		{"-/filter:v", "/tmp/graph.txt"},
+		{"-passlogfile", "/tmp/log"},
+		{strings.Repeat("a", maxRawArgLength+1)},
+		make([]string, maxRawArgs+1),
+	}
+
+	for _, args := range testCases {
+		if err := ValidateRawArgs(args); err == nil {
+			t.Errorf("不正な引数でエラーが返されなかった: %q", args)
+		}
+	}
+}
+
+func Test生のffmpeg引数のプリセットは出力の拡張子を使う単一ファイル出力になる(t *testing.T) {
+	p, err := rawPreset([]string{"-c:v", "libvpx-vp9", "-c:a", "libopus"}, ".WebM")
+	if err != nil {
+		t.Fatalf("プリセットの作成に失敗: %v", err)
+	}
+	if p.Name != rawPresetName || p.Extension != "webm" || p.OutputType != "single" {
+		t.Errorf("プリセットのフィールドが一致しない: %+v", p)
+	}
+
+	if p, err := rawPreset([]string{"-c:v", "libx264"}, ""); err != nil || p.Extension != defaultRawExtension {
+		t.Errorf("拡張子がない場合に %s にならない: %+v, %v", defaultRawExtension, p, err)
+	}
+	if _, err := rawPreset([]string{"-c:v", "libx264"}, "./../x"); err == nil {
+		t.Error("不正な拡張子でエラーが返されなかった")
+	}
+}
+
+func Test生のffmpeg引数とプリセットを変更するオプションの併用はエラーになる(t *testing.T) {
+	p, err := rawPreset([]string{"-c:v", "libx264"}, "mp4")
+	if err != nil {
+		t.Fatalf("プリセットの作成に失敗: %v", err)
+	}
+
+	for _, opts := range []Options{
+		{RawArgs: p.FFmpegArgs, Speed: "veryfast"},
+		{RawArgs: p.FFmpegArgs, StreamCopy: StreamCopyAudio},
+		{RawArgs: p.FFmpegArgs, Overrides: map[string]string{"crf": "20"}},
+		{RawArgs: p.FFmpegArgs, SubtitlePath: "/tmp/subs.vtt"},
+	} {
+		if _, err := applyOptions(p, opts); err == nil {
+			t.Errorf("併用できないオプションでエラーが返されなかった: %+v", opts)
+		}
+	}
+	if _, err := applyOptions(p, Options{RawArgs: p.FFmpegArgs}); err != nil {
+		t.Errorf("オプションなしでエラーが返された: %v", err)
+	}
+}
+
+func Test生のffmpeg引数の出力の短辺の上限を求められる(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want int
+	}{
+		{name: "-s", args: []string{"-s", "1920x1080", "-c:v", "libx264"}, want: 1080},
+		{name: "縦長の-s", args: []string{"-s:v", "1080x1920"}, want: 1080},
+		{name: "高さを指定したscale", args: []string{"-vf", "scale=-2:720"}, want: 720},
+		{name: "幅を指定したscale", args: []string{"-filter:v", "scale=w=1280:h=-1"}, want: 1280},
+		{name: "縦長に収めるscaleとpad", args: []string{"-vf", "scale=720:1280:force_original_aspect_ratio=decrease,pad=720:1280:(ow-iw)/2:(oh-ih)/2"}, want: 720},
+		{name: "ABRのfilter_complex", args: []string{"-filter_complex", "[0:v]split=2[a][b];[a]scale=-2:1080[v1];[b]scale=-2:360[v2]"}, want: 1080},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RawOutputShortEdge(tt.args)
+			if err != nil {
+				t.Fatalf("短辺の取得に失敗: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("短辺が一致しない: 期待値 %d, 取得値 %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func Test生のffmpeg引数で出力の大きさを求められない場合はエラーになる(t *testing.T) {
+	testCases := [][]string{
+		{"-c:v", "libx264", "-crf", "20"},
+		{"-vf", "scale=iw*2:ih*2"},
+		{"-vf", "scale=-2:-2"},
+		{"-s", "hd1080"},
+		{"-filter_complex", "[0:v]split=2[a][b];[a]scale=-2:720[v1];[b]scale=iw:ih[v2]"},
+	}
+	for _, args := range testCases {
+		if _, err := RawOutputShortEdge(args); err == nil {
+			t.Errorf("大きさを求められない引数でエラーが返されなかった: %q", args)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package encoder
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// sizeUnknownFilters は出力の解像度を変えるが、ffmpeg 引数からは解像度を求められないフィルター
+// これらのフィルターより前の scale・pad から求めた解像度は検証しない
+var sizeUnknownFilters = map[string]bool{
+	"crop":      true,
+	"transpose": true,
+	"rotate":    true,
+}
+
+// scaleFilters は scale と同じ形式（"W:H" または "w=W:h=H"）で出力の解像度を指定するフィルター（GPU 上でスケールするものを含む）
+var scaleFilters = map[string]bool{
+	"scale":       true,
+	"scale_cuda":  true,
+	"scale_npp":   true,
+	"scale_qsv":   true,
+	"scale_vaapi": true,
+	"zscale":      true,
+}
+
+// expectedSizeFromFilters は -vf のフィルターチェーンから出力の解像度（幅・高さ、不明な場合は 0）を求める
+// scale は "scale=-2:720"・"scale=720:-2"・"scale=720:1280"・"scale=w=1280:h=720" の形式に対応し、
+// 負の値（-1・-2 はアスペクト比を保つ）や式の場合はその辺を検証しない。縦長（幅 < 高さ）の解像度もそのまま扱う
+// force_original_aspect_ratio を指定した scale は指定した大きさ以下になるため、後の pad で大きさを揃える場合のみ検証する
+func expectedSizeFromFilters(vf string) (width, height int) {
+	for _, filter := range strings.Split(vf, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(filter), "=")
+		switch {
+		case scaleFilters[name] || name == "pad":
+			w, h, bounded := parseSizeParams(params)
+			if name != "pad" && bounded {
+				w, h = 0, 0
+			}
+			width, height = w, h
+		case sizeUnknownFilters[name]:
+			width, height = 0, 0
+		}
+	}
+	return width, height
+}
+
+// parseSizeParams は scale・pad の引数（"720:-2" や "w=720:h=1280"）から幅と高さを取り出す
+// bounded は force_original_aspect_ratio により指定した大きさが上限として扱われるかを返す
+func parseSizeParams(params string) (width, height int, bounded bool) {
+	position := 0
+	for _, param := range strings.Split(params, ":") {
+		key, value, named := strings.Cut(param, "=")
+		if !named {
+			// 位置指定の引数は幅・高さの順
+			value = key
+			switch position {
+			case 0:
+				key = "w"
+			case 1:
+				key = "h"
+			default:
+				key = ""
+			}
+			position++
+		}
+		switch key {
+		case "w", "width":
+			width = positiveSize(value)
+		case "h", "height":
+			height = positiveSize(value)
+		case "force_original_aspect_ratio":
+			bounded = value != "disable" && value != "0"
+		}
+	}
+	return width, height, bounded
+}
+
+// positiveSize は正の整数の解像度を返す（負の値や式の場合は 0）
+func positiveSize(value string) int {
+	size, err := strconv.Atoi(value)
+	if err != nil || size <= 0 {
+		return 0
+	}
+	return size
+}
+
+// filterLinkLabel はフィルターグラフのリンクラベル（"[0:v]"、"[v1out]" など）
+var filterLinkLabel = regexp.MustCompile(`\[[^\]]*\]`)
+
+// expectedSizeFromFilterComplex は -filter_complex のフィルターグラフから、最も大きいバリアントの解像度（幅・高さ、不明な場合は 0）を求める
+// ABR のプリセットは "[0:v]split=3[v1][v2][v3];[v1]scale=w=1280:h=720[v1out];..." のようにチェーンごとにバリアントをスケールするため、
+// チェーンごとに expectedSizeFromFilters で解像度を求め、高さ（同じ場合は幅）が最大のものを返す
+func expectedSizeFromFilterComplex(graph string) (width, height int) {
+	for _, chain := range strings.Split(graph, ";") {
+		w, h := expectedSizeFromFilters(filterLinkLabel.ReplaceAllString(chain, ""))
+		if h > height || (h == height && w > width) {
+			width, height = w, h
+		}
+	}
+	return width, height
+}
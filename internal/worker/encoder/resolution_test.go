@@ -0,0 +1,141 @@
+package encoder
+
+import (
+	"testing"
+
+	"github.com/nzws/flux-encoder/internal/worker/preset"
+)
+
+func Testフィルターチェーンから出力の解像度が求められる(t *testing.T) {
+	testCases := []struct {
+		name   string
+		vf     string
+		width  int
+		height int
+	}{
+		{"横長で高さのみ指定", "scale=-2:720", 0, 720},
+		{"縦長で幅のみ指定", "scale=720:-2", 720, 0},
+		{"縦長で幅と高さを指定", "scale=720:1280", 720, 1280},
+		{"名前付きの引数", "scale=w=1080:h=1920", 1080, 1920},
+		{"-1によるアスペクト比の維持", "scale=-1:480", 0, 480},
+		{"幅のみ指定で-1", "scale=1280:-1", 1280, 0},
+		{"GPU上のスケール", "scale_cuda=-2:1080", 0, 1080},
+		{"VAAPIのスケール", "format=nv12,hwupload,scale_vaapi=w=1280:h=720", 1280, 720},
+		{"zscale", "zscale=width=1920:height=1080", 1920, 1080},
+		{"スケールのオプション付き", "scale=-2:1080:flags=lanczos", 0, 1080},
+		{"後続のフィルター", "scale=-2:1080,format=nv12,hwupload", 0, 1080},
+		{"字幕の焼き込み", "scale=720:1280,subtitles=/tmp/sub.srt", 720, 1280},
+		{"アスペクト比を保つ縮小のみ", "scale=720:1280:force_original_aspect_ratio=decrease", 0, 0},
+		{"アスペクト比を保つ縮小と黒帯", "scale=720:1280:force_original_aspect_ratio=decrease,pad=720:1280:(ow-iw)/2:(oh-ih)/2,setsar=1", 720, 1280},
+		{"式による指定", "scale=iw/2:ih/2", 0, 0},
+		{"回転", "scale=1280:720,transpose=1", 0, 0},
+		{"スケールなし", "yadif", 0, 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			width, height := expectedSizeFromFilters(tc.vf)
+			if width != tc.width || height != tc.height {
+				t.Errorf("解像度が一致しない: 期待値 %dx%d, 取得値 %dx%d", tc.width, tc.height, width, height)
+			}
+		})
+	}
+}
+
+func Test縦長のプリセットで期待する解像度が縦長になる(t *testing.T) {
+	encoder := New(t.TempDir())
+
+	testCases := []struct {
+		preset string
+		width  int
+		height int
+	}{
+		{"vertical_720x1280_h264", 720, 1280},
+		{"vertical_1080x1920_h264", 1080, 1920},
+		{"720p_h264", 0, 720},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.preset, func(t *testing.T) {
+			p, err := preset.Get(tc.preset)
+			if err != nil {
+				t.Fatalf("プリセットの取得に失敗: %v", err)
+			}
+			expected := encoder.getExpectedInfoFromPreset(p)
+			if expected.Width != tc.width || expected.Height != tc.height {
+				t.Errorf("期待する解像度が一致しない: 期待値 %dx%d, 取得値 %dx%d", tc.width, tc.height, expected.Width, expected.Height)
+			}
+		})
+	}
+}
+
+func Test登録済みのプリセットのvfから期待する解像度がプリセットの高さと一致する(t *testing.T) {
+	encoder := New(t.TempDir())
+
+	checked := 0
+	for _, p := range preset.List() {
+		vf := ""
+		for i, arg := range p.FFmpegArgs {
+			if arg == "-vf" && i+1 < len(p.FFmpegArgs) {
+				vf = p.FFmpegArgs[i+1]
+			}
+		}
+		if vf == "" || p.Height == 0 {
+			continue
+		}
+
+		t.Run(p.Name, func(t *testing.T) {
+			expected := encoder.getExpectedInfoFromPreset(p)
+			if expected.Height != p.Height {
+				t.Errorf("期待する高さがプリセットの高さと一致しない: -vf %q, 期待値 %d, 取得値 %d", vf, p.Height, expected.Height)
+			}
+		})
+		checked++
+	}
+	if checked == 0 {
+		t.Fatal("-vf でスケールするプリセットがない")
+	}
+}
+
+func TestFilterComplexから最も大きいバリアントの解像度が求められる(t *testing.T) {
+	testCases := []struct {
+		name   string
+		graph  string
+		width  int
+		height int
+	}{
+		{"ABR", "[0:v]split=3[v1][v2][v3];[v1]scale=w=1280:h=720[v1out];[v2]scale=w=854:h=480[v2out];[v3]scale=w=640:h=360[v3out]", 1280, 720},
+		{"大きいバリアントが後", "[0:v]split=2[a][b];[a]scale=640:360[aout];[b]scale=1920:1080[bout]", 1920, 1080},
+		{"高さのみ指定", "[0:v]split=2[a][b];[a]scale=-2:720[aout];[b]scale=-2:360[bout]", 0, 720},
+		{"スケールなし", "[0:v]yadif[out]", 0, 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			width, height := expectedSizeFromFilterComplex(tc.graph)
+			if width != tc.width || height != tc.height {
+				t.Errorf("解像度が一致しない: 期待値 %dx%d, 取得値 %dx%d", tc.width, tc.height, width, height)
+			}
+		})
+	}
+}
+
+func TestABRのプリセットで最も大きいバリアントの解像度を期待値にする(t *testing.T) {
+	encoder := New(t.TempDir())
+
+	for _, name := range []string{"hls_720p_abr", "hls_720p_abr_video_only", "dash_720p_abr"} {
+		t.Run(name, func(t *testing.T) {
+			p, err := preset.Get(name)
+			if err != nil {
+				t.Fatalf("プリセットの取得に失敗: %v", err)
+			}
+			expected := encoder.getExpectedInfoFromPreset(p)
+			if expected.Width != 1280 || expected.Height != 720 {
+				t.Errorf("期待する解像度が一致しない: 期待値 1280x720, 取得値 %dx%d", expected.Width, expected.Height)
+			}
+			if expected.Height != p.Height {
+				t.Errorf("期待する高さがプリセットの高さと一致しない: %d, %d", expected.Height, p.Height)
+			}
+		})
+	}
+}
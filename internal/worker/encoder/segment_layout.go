@@ -0,0 +1,126 @@
+package encoder
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/nzws/flux-encoder/internal/worker/preset"
+)
+
+const (
+	// SegmentLayoutFlat はプレイリストとセグメントを同じディレクトリに配置する（デフォルト）
+	SegmentLayoutFlat = "flat"
+	// SegmentLayoutSubfolder はセグメントを segmentsDirName サブディレクトリに配置する
+	SegmentLayoutSubfolder = "segments"
+
+	// segmentsDirName は SegmentLayoutSubfolder でセグメントを配置するディレクトリ名
+	segmentsDirName = "segments"
+)
+
+// checkSegmentLayout はプリセットに対してセグメント配置を指定できるかチェックする
+// 複数バリアントの HLS はバリアントごとのプレイリストがあるため対象外
+func checkSegmentLayout(p preset.Preset, layout string) error {
+	switch layout {
+	case "", SegmentLayoutFlat:
+		return nil
+	case SegmentLayoutSubfolder:
+	default:
+		return fmt.Errorf("invalid segment layout: %s (must be flat or segments)", layout)
+	}
+
+	if p.OutputType != outputTypeHLS {
+		return fmt.Errorf("segment layout is only supported for hls output")
+	}
+	for _, arg := range p.FFmpegArgs {
+		if arg == "-var_stream_map" {
+			return fmt.Errorf("segment layout is only supported for single-variant hls output")
+		}
+	}
+	return nil
+}
+
+// applySegmentLayout はプレイリストが参照するセグメントをレイアウトに従って移動し、プレイリストの URI を書き換える
+func applySegmentLayout(outputDir, playlistName, layout string) error {
+	if layout != SegmentLayoutSubfolder {
+		return nil
+	}
+
+	playlistPath := filepath.Join(outputDir, playlistName)
+	content, err := os.ReadFile(playlistPath)
+	if err != nil {
+		return fmt.Errorf("failed to read playlist: %w", err)
+	}
+
+	rewritten, uris := rewriteSegmentURIs(string(content), segmentsDirName)
+
+	segmentsDir := filepath.Join(outputDir, segmentsDirName)
+	if err := os.MkdirAll(segmentsDir, 0755); err != nil {
+		return fmt.Errorf("failed to create segments directory: %w", err)
+	}
+	for _, uri := range uris {
+		if err := os.Rename(filepath.Join(outputDir, uri), filepath.Join(segmentsDir, uri)); err != nil {
+			return fmt.Errorf("failed to move segment %s: %w", uri, err)
+		}
+	}
+
+	if err := os.WriteFile(playlistPath, []byte(rewritten), 0644); err != nil {
+		return fmt.Errorf("failed to write playlist: %w", err)
+	}
+	return nil
+}
+
+// rewriteSegmentURIs はメディアプレイリストのセグメント URI（#EXT-X-MAP の URI 属性を含む）の前に dir を付ける
+// 書き換えたプレイリストと、書き換えた URI（重複なし）を返す
+// 絶対 URL やサブディレクトリを含む URI は ffmpeg の出力ではないため変更しない
+func rewriteSegmentURIs(content, dir string) (string, []string) {
+	var uris []string
+	seen := make(map[string]bool)
+	rewrite := func(uri string) string {
+		if !isLocalSegmentURI(uri) {
+			return uri
+		}
+		if !seen[uri] {
+			seen[uri] = true
+			uris = append(uris, uri)
+		}
+		return dir + "/" + uri
+	}
+
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "":
+		case strings.HasPrefix(trimmed, "#EXT-X-MAP"):
+			lines[i] = rewriteURIAttribute(line, rewrite)
+		case strings.HasPrefix(trimmed, "#"):
+		default:
+			lines[i] = rewrite(trimmed)
+		}
+	}
+
+	return strings.Join(lines, "\n"), uris
+}
+
+// rewriteURIAttribute はタグ行の URI="..." 属性の値を書き換える
+func rewriteURIAttribute(line string, rewrite func(string) string) string {
+	const attr = `URI="`
+	start := strings.Index(line, attr)
+	if start < 0 {
+		return line
+	}
+	start += len(attr)
+	end := strings.Index(line[start:], `"`)
+	if end < 0 {
+		return line
+	}
+	end += start
+	return line[:start] + rewrite(line[start:end]) + line[end:]
+}
+
+// isLocalSegmentURI は出力ディレクトリ直下のファイルを指す URI かどうかを返す
+func isLocalSegmentURI(uri string) bool {
+	return uri != "" && !strings.Contains(uri, "/") && !strings.Contains(uri, "\\") && !strings.Contains(uri, ":")
+}
@@ -0,0 +1,171 @@
+package encoder
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/nzws/flux-encoder/internal/worker/preset"
+	"github.com/nzws/flux-encoder/internal/worker/validator"
+)
+
+func TestセグメントURIにサブディレクトリが付与される(t *testing.T) {
+	content := "#EXTM3U\n#EXT-X-VERSION:7\n#EXT-X-TARGETDURATION:6\n#EXT-X-MAP:URI=\"init.mp4\"\n" +
+		"#EXTINF:6.000000,\nsegment_000.m4s\n#EXTINF:4.000000,\nsegment_001.m4s\n" +
+		"#EXTINF:2.000000,\nhttps://cdn.example.com/ad.ts\n#EXT-X-ENDLIST\n"
+	expected := "#EXTM3U\n#EXT-X-VERSION:7\n#EXT-X-TARGETDURATION:6\n#EXT-X-MAP:URI=\"segments/init.mp4\"\n" +
+		"#EXTINF:6.000000,\nsegments/segment_000.m4s\n#EXTINF:4.000000,\nsegments/segment_001.m4s\n" +
+		"#EXTINF:2.000000,\nhttps://cdn.example.com/ad.ts\n#EXT-X-ENDLIST\n"
+
+	rewritten, uris := rewriteSegmentURIs(content, "segments")
+	if rewritten != expected {
+		t.Errorf("プレイリストが一致しない:\n期待値 %q\n取得値 %q", expected, rewritten)
+	}
+	expectedURIs := []string{"init.mp4", "segment_000.m4s", "segment_001.m4s"}
+	if !reflect.DeepEqual(uris, expectedURIs) {
+		t.Errorf("書き換えた URI が一致しない: 期待値 %v, 取得値 %v", expectedURIs, uris)
+	}
+}
+
+func TestSegmentsレイアウトでセグメントがサブディレクトリに移動される(t *testing.T) {
+	dir := t.TempDir()
+	playlist := "#EXTM3U\n#EXT-X-VERSION:3\n#EXT-X-TARGETDURATION:6\n" +
+		"#EXTINF:6.000000,\nsegment_000.ts\n#EXTINF:3.000000,\nsegment_001.ts\n#EXT-X-ENDLIST\n"
+	files := map[string]string{
+		"playlist.m3u8":  playlist,
+		"segment_000.ts": "segment",
+		"segment_001.ts": "segment",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("ファイルの作成に失敗: %v", err)
+		}
+	}
+
+	if err := applySegmentLayout(dir, "playlist.m3u8", SegmentLayoutSubfolder); err != nil {
+		t.Fatalf("レイアウトの適用に失敗: %v", err)
+	}
+
+	for _, name := range []string{"segment_000.ts", "segment_001.ts"} {
+		if _, err := os.Stat(filepath.Join(dir, segmentsDirName, name)); err != nil {
+			t.Errorf("%s がサブディレクトリに移動されていない: %v", name, err)
+		}
+		if _, err := os.Stat(filepath.Join(dir, name)); !os.IsNotExist(err) {
+			t.Errorf("%s が出力ディレクトリ直下に残っている", name)
+		}
+	}
+
+	// 書き換えたプレイリストが検証を通ることを確認
+	info, err := validator.NewHLSParser().ParseAndValidate(context.Background(), dir, validator.HLSValidationDepthMedium)
+	if err != nil {
+		t.Fatalf("書き換えたプレイリストの検証に失敗: %v", err)
+	}
+	if info.TotalSegments != 2 {
+		t.Errorf("セグメント数が一致しない: 期待値 2, 取得値 %d", info.TotalSegments)
+	}
+}
+
+func TestFlatレイアウトでは何も変更しない(t *testing.T) {
+	dir := t.TempDir()
+	playlist := "#EXTM3U\n#EXTINF:6.000000,\nsegment_000.ts\n"
+	if err := os.WriteFile(filepath.Join(dir, "playlist.m3u8"), []byte(playlist), 0644); err != nil {
+		t.Fatalf("ファイルの作成に失敗: %v", err)
+	}
+
+	for _, layout := range []string{"", SegmentLayoutFlat} {
+		if err := applySegmentLayout(dir, "playlist.m3u8", layout); err != nil {
+			t.Fatalf("レイアウトの適用に失敗 (%q): %v", layout, err)
+		}
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "playlist.m3u8"))
+	if err != nil {
+		t.Fatalf("プレイリストの読み取りに失敗: %v", err)
+	}
+	if string(data) != playlist {
+		t.Errorf("プレイリストが変更された: %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(dir, segmentsDirName)); !os.IsNotExist(err) {
+		t.Error("サブディレクトリが作成された")
+	}
+}
+
+func Testセグメント配置を指定できるプリセットが判定される(t *testing.T) {
+	tests := []struct {
+		preset  string
+		layout  string
+		wantErr bool
+	}{
+		{"hls_720p", SegmentLayoutSubfolder, false},
+		{"hls_720p", SegmentLayoutFlat, false},
+		{"hls_720p", "nested", true},
+		{"hls_720p_abr", SegmentLayoutSubfolder, true},
+		{"720p_h264", SegmentLayoutSubfolder, true},
+		{"720p_h264", "", false},
+	}
+
+	for _, tt := range tests {
+		p, err := preset.Get(tt.preset)
+		if err != nil {
+			t.Fatalf("プリセットの取得に失敗: %v", err)
+		}
+		_, err = applyOptions(p, Options{SegmentLayout: tt.layout})
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s / %q: エラーの有無が一致しない: 期待値 %v, 取得値 %v", tt.preset, tt.layout, tt.wantErr, err)
+		}
+	}
+}
+
+func Test逐次アップロードできる出力ディレクトリが判定される(t *testing.T) {
+	e := New("/work")
+	tests := []struct {
+		preset string
+		layout string
+		wantOK bool
+	}{
+		{"hls_720p", "", true},
+		{"hls_720p_abr", SegmentLayoutFlat, true},
+		{"hls_720p", SegmentLayoutSubfolder, false},
+		{"720p_h264", "", false},
+		{"unknown", "", false},
+	}
+
+	for _, tt := range tests {
+		dir, ok := e.IncrementalUploadDir("job-1", tt.preset, Options{SegmentLayout: tt.layout})
+		if ok != tt.wantOK {
+			t.Errorf("%s / %q: 判定結果が一致しない: 期待値 %v, 取得値 %v", tt.preset, tt.layout, tt.wantOK, ok)
+			continue
+		}
+		if ok && dir != filepath.Join("/work", "job-1", "output") {
+			t.Errorf("%s: 出力ディレクトリが一致しない: 期待値 %s, 取得値 %s", tt.preset, filepath.Join("/work", "job-1", "output"), dir)
+		}
+	}
+}
+
+func Test逐次アップロードの判定にハードウェアエンコード版のプリセットを使う(t *testing.T) {
+	// 単一ファイル出力のプリセットに HLS 出力のハードウェアエンコード版を用意する
+	path := filepath.Join(t.TempDir(), "presets.json")
+	if err := os.WriteFile(path, []byte(`[
+  {"name": "incremental_hw_test", "ffmpeg_args": ["-c:v", "libx264"], "extension": "mp4"},
+  {"name": "incremental_hw_test_nvenc", "ffmpeg_args": ["-c:v", "h264_nvenc", "-f", "hls"], "extension": "m3u8", "output_type": "hls", "hardware_accel": "nvenc"}
+]`), 0644); err != nil {
+		t.Fatalf("プリセットファイルの作成に失敗: %v", err)
+	}
+	if err := preset.LoadFromFile(path); err != nil {
+		t.Fatalf("プリセットの読み込みに失敗: %v", err)
+	}
+
+	e := New("/work")
+	if _, ok := e.IncrementalUploadDir("job-1", "incremental_hw_test", Options{}); ok {
+		t.Error("ハードウェアエンコードが無効な場合に単一ファイル出力のジョブが逐次アップロードの対象になった")
+	}
+
+	if err := e.SetHardwareAccel(preset.HardwareAccelNVENC); err != nil {
+		t.Fatalf("ハードウェアエンコードの設定に失敗: %v", err)
+	}
+	if _, ok := e.IncrementalUploadDir("job-1", "incremental_hw_test", Options{}); !ok {
+		t.Error("HLS 出力のハードウェアエンコード版を使うジョブが逐次アップロードの対象にならない")
+	}
+}
@@ -0,0 +1,82 @@
+package encoder
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/nzws/flux-encoder/internal/shared/execlimit"
+	"github.com/nzws/flux-encoder/internal/shared/logger"
+	"github.com/nzws/flux-encoder/internal/worker/preset"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultSelfTestPreset はセルフテストで使用するデフォルトのプリセット
+	DefaultSelfTestPreset = "480p_h264"
+
+	selfTestJobID = "selftest"
+)
+
+// SelfTest は短いテスト動画を生成し、指定プリセットでエンコード・検証できるか確認する
+// ffmpeg のインストール不備やプリセットの設定ミスを起動時に検出するために使用する
+func (e *Encoder) SelfTest(ctx context.Context, presetName string) error {
+	if !preset.Exists(presetName) {
+		return fmt.Errorf("self-test preset not found: %s", presetName)
+	}
+
+	start := time.Now()
+
+	inputDir := filepath.Join(e.workDir, selfTestJobID+"-input")
+	if err := os.MkdirAll(inputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create self-test input directory: %w", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(inputDir); err != nil {
+			logger.Warn("Failed to remove self-test input directory", zap.Error(err))
+		}
+		if err := e.Cleanup(selfTestJobID); err != nil {
+			logger.Warn("Failed to cleanup self-test job directory", zap.Error(err))
+		}
+	}()
+
+	inputPath := filepath.Join(inputDir, "input.mp4")
+	if err := generateTestClip(ctx, e.ffmpegPath, inputPath); err != nil {
+		return err
+	}
+
+	if _, err := e.Encode(ctx, selfTestJobID, inputPath, presetName, func(float32, string) {}); err != nil {
+		return fmt.Errorf("self-test encode failed: %w", err)
+	}
+
+	logger.Info("Self-test succeeded",
+		zap.String("preset", presetName),
+		zap.Duration("duration", time.Since(start)),
+	)
+
+	return nil
+}
+
+// generateTestClip は ffmpeg の lavfi で1秒間の映像・音声付きテスト動画を生成する
+func generateTestClip(ctx context.Context, ffmpegPath, outputPath string) error {
+	cmd := newFFmpegCommand(ctx, ffmpegPath,
+		"-f", "lavfi", "-i", "testsrc=duration=1:size=320x240:rate=25",
+		"-f", "lavfi", "-i", "sine=frequency=1000:duration=1",
+		"-c:v", "libx264",
+		"-pix_fmt", "yuv420p",
+		"-c:a", "aac",
+		"-shortest",
+		"-y",
+		outputPath,
+	)
+
+	output, err := execlimit.CombinedOutput(cmd)
+	if err != nil {
+		return fmt.Errorf("failed to generate self-test clip: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+
+	return nil
+}
@@ -0,0 +1,72 @@
+package encoder
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSelfTestが存在しないプリセットでエラーを返す(t *testing.T) {
+	encoder := New(t.TempDir())
+
+	err := encoder.SelfTest(context.Background(), "nonexistent_preset")
+	if err == nil {
+		t.Fatal("存在しないプリセットでエラーが返されなかった")
+	}
+	if !strings.Contains(err.Error(), "nonexistent_preset") {
+		t.Errorf("エラーメッセージにプリセット名が含まれていない: %v", err)
+	}
+}
+
+func TestSelfTestがffmpegの失敗を検出して作業ファイルを残さない(t *testing.T) {
+	// 常に失敗する偽の ffmpeg を PATH の先頭に配置する
+	binDir := t.TempDir()
+	fakeFFmpeg := filepath.Join(binDir, "ffmpeg")
+	script := "#!/bin/sh\necho 'Unknown encoder libx264' >&2\nexit 1\n"
+	if err := os.WriteFile(fakeFFmpeg, []byte(script), 0755); err != nil {
+		t.Fatalf("偽の ffmpeg の作成に失敗: %v", err)
+	}
+	t.Setenv("PATH", binDir)
+
+	workDir := t.TempDir()
+	encoder := New(workDir)
+
+	err := encoder.SelfTest(context.Background(), DefaultSelfTestPreset)
+	if err == nil {
+		t.Fatal("ffmpeg が失敗してもエラーが返されなかった")
+	}
+	if !strings.Contains(err.Error(), "Unknown encoder libx264") {
+		t.Errorf("エラーメッセージに ffmpeg の出力が含まれていない: %v", err)
+	}
+
+	entries, err := os.ReadDir(workDir)
+	if err != nil {
+		t.Fatalf("作業ディレクトリの読み取りに失敗: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("セルフテスト後に作業ファイルが残っている: %d 件", len(entries))
+	}
+}
+
+func TestSelfTestが実際のffmpegで成功する(t *testing.T) {
+	if !hasFFmpeg() || !hasFFprobe() {
+		t.Skip("ffmpeg/ffprobe がインストールされていないためスキップ")
+	}
+
+	workDir := t.TempDir()
+	encoder := New(workDir)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	if err := encoder.SelfTest(ctx, DefaultSelfTestPreset); err != nil {
+		t.Fatalf("セルフテストが失敗: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(workDir, selfTestJobID)); !os.IsNotExist(err) {
+		t.Error("セルフテストのジョブディレクトリが削除されていない")
+	}
+}
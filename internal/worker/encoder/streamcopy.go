@@ -0,0 +1,176 @@
+package encoder
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/nzws/flux-encoder/internal/shared/execlimit"
+	"github.com/nzws/flux-encoder/internal/worker/preset"
+	"github.com/nzws/flux-encoder/internal/worker/validator"
+)
+
+const (
+	// StreamCopyVideo は映像をコピーし、音声のみ再エンコードする
+	StreamCopyVideo = "video"
+	// StreamCopyAudio は音声をコピーし、映像のみ再エンコードする
+	StreamCopyAudio = "audio"
+)
+
+// videoEncodeFlags は映像の再エンコードにのみ意味を持つ ffmpeg オプション（いずれも値を1つ取る）
+var videoEncodeFlags = map[string]bool{
+	"-vf": true, "-c:v": true, "-preset": true, "-crf": true, "-b:v": true,
+	"-maxrate": true, "-bufsize": true, "-profile:v": true, "-level": true, "-level:v": true,
+	"-pix_fmt": true, "-tune": true, "-g": true, "-keyint_min": true, "-sc_threshold": true,
+	"-r": true, "-x264-params": true, "-x265-params": true, "-svtav1-params": true,
+}
+
+// audioEncodeFlags は音声の再エンコードにのみ意味を持つ ffmpeg オプション（いずれも値を1つ取る）
+var audioEncodeFlags = map[string]bool{
+	"-af": true, "-c:a": true, "-b:a": true, "-ar": true, "-ac": true, "-q:a": true, "-aq": true,
+}
+
+// copyCompatibleCodecs はコンテナごとにストリームコピーできるコーデック（ffprobe のコーデック名）
+var copyCompatibleCodecs = map[string]map[string]bool{
+	"mp4": {
+		"h264": true, "hevc": true, "av1": true, "vp9": true, "mpeg4": true,
+		"aac": true, "mp3": true, "ac3": true, "eac3": true, "opus": true, "flac": true, "alac": true,
+	},
+	"ts": {
+		"h264": true, "hevc": true, "mpeg2video": true,
+		"aac": true, "mp3": true, "ac3": true, "eac3": true, "mp2": true,
+	},
+	"webm": {
+		"vp8": true, "vp9": true, "av1": true,
+		"opus": true, "vorbis": true,
+	},
+}
+
+// applyStreamCopy は指定したストリームの再エンコード用オプションを取り除き、コピー指定に置き換える
+// -filter_complex/-map を使うプリセット（ABR など）はストリームの対応付けが崩れるため対象外
+func applyStreamCopy(p preset.Preset, args []string, mode string) ([]string, error) {
+	var flags map[string]bool
+	var codecFlag string
+	switch mode {
+	case StreamCopyVideo:
+		flags, codecFlag = videoEncodeFlags, "-c:v"
+		if p.TwoPass {
+			return nil, fmt.Errorf("video stream copy cannot be combined with two-pass encoding")
+		}
+	case StreamCopyAudio:
+		flags, codecFlag = audioEncodeFlags, "-c:a"
+	default:
+		return nil, fmt.Errorf("invalid stream copy mode: %s (must be video or audio)", mode)
+	}
+
+	result := make([]string, 0, len(args)+2)
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "-filter_complex" || arg == "-map" {
+			return nil, fmt.Errorf("stream copy is not supported for presets using %s", arg)
+		}
+		if flags[arg] {
+			i++ // 値もスキップ
+			continue
+		}
+		result = append(result, arg)
+	}
+
+	return append(result, codecFlag, "copy"), nil
+}
+
+// outputContainer はプリセットの出力コンテナ名を返す（copyCompatibleCodecs のキー）
+func outputContainer(p preset.Preset) string {
+	switch p.OutputType {
+	case outputTypeHLS:
+		for i, arg := range p.FFmpegArgs {
+			if arg == "-hls_segment_type" && i+1 < len(p.FFmpegArgs) && p.FFmpegArgs[i+1] == "fmp4" {
+				return "mp4"
+			}
+		}
+		return "ts"
+	case outputTypeDASH:
+		return "mp4"
+	}
+
+	switch p.Extension {
+	case "mp4", "m4v", "mov":
+		return "mp4"
+	case "ts":
+		return "ts"
+	default:
+		return p.Extension
+	}
+}
+
+// checkStreamCopyCompatibility はコピーするストリームのコーデックが出力コンテナに格納できるかチェックする
+// 対応表にないコンテナ（mkv など）はチェックしない
+func checkStreamCopyCompatibility(p preset.Preset, codec string) error {
+	container := outputContainer(p)
+	compatible, known := copyCompatibleCodecs[container]
+	if !known {
+		return nil
+	}
+	if !compatible[codec] {
+		return fmt.Errorf("codec %s cannot be stream copied into %s output", codec, container)
+	}
+	return nil
+}
+
+// prepareStreamCopy はコピーするストリームのコーデックを入力から取得し、
+// 出力コンテナとの互換性を確認して検証の期待値に反映する
+func (e *Encoder) prepareStreamCopy(ctx context.Context, inputURL string, p preset.Preset, mode string, expected *validator.ExpectedMediaInfo) error {
+	streamType := "v"
+	if mode == StreamCopyAudio {
+		streamType = "a"
+	}
+
+	codec, err := probeSourceCodec(ctx, e.ffprobePath, inputURL, streamType)
+	if err != nil {
+		return fmt.Errorf("failed to probe input for stream copy: %w", err)
+	}
+	if err := checkStreamCopyCompatibility(p, codec); err != nil {
+		return err
+	}
+
+	if mode == StreamCopyVideo {
+		expected.VideoCodec = codec
+		// 映像はスケールされないため解像度は検証しない
+		expected.Width = 0
+		expected.Height = 0
+	} else {
+		expected.AudioCodec = codec
+	}
+	return nil
+}
+
+// probeSourceCodec は入力の最初の映像または音声ストリームのコーデック名を取得する
+// streamType は "v"（映像）または "a"（音声）
+func probeSourceCodec(ctx context.Context, ffprobePath, inputURL, streamType string) (string, error) {
+	cmd := exec.CommandContext(ctx, ffprobePath,
+		"-v", "error",
+		"-select_streams", streamType+":0",
+		"-show_entries", "stream=codec_name",
+		"-of", "default=noprint_wrappers=1:nokey=1",
+		inputURL,
+	)
+
+	output, err := execlimit.Output(cmd)
+	if err != nil {
+		return "", err
+	}
+
+	codec := strings.TrimSpace(string(output))
+	if codec == "" {
+		return "", fmt.Errorf("no %s stream found in input", streamTypeName(streamType))
+	}
+	return codec, nil
+}
+
+func streamTypeName(streamType string) string {
+	if streamType == "a" {
+		return "audio"
+	}
+	return "video"
+}
@@ -0,0 +1,188 @@
+package encoder
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/nzws/flux-encoder/internal/worker/preset"
+	"github.com/nzws/flux-encoder/internal/worker/validator"
+)
+
+func Test映像コピー時は映像の再エンコード引数が除かれ音声は再エンコードされる(t *testing.T) {
+	base, err := preset.Get("720p_h264")
+	if err != nil {
+		t.Fatalf("プリセットの取得に失敗: %v", err)
+	}
+
+	applied, err := applyOptions(base, Options{StreamCopy: StreamCopyVideo})
+	if err != nil {
+		t.Fatalf("オプションの適用に失敗: %v", err)
+	}
+
+	expected := []string{
+		"-c:a", "aac",
+		"-b:a", "128k",
+		"-movflags", "+faststart",
+		"-c:v", "copy",
+	}
+	if !reflect.DeepEqual(applied.FFmpegArgs, expected) {
+		t.Errorf("引数が一致しない:\n期待値 %v\n取得値 %v", expected, applied.FFmpegArgs)
+	}
+
+	// 登録済みのプリセットが変更されていないことを確認
+	original, err := preset.Get("720p_h264")
+	if err != nil {
+		t.Fatalf("プリセットの取得に失敗: %v", err)
+	}
+	if !reflect.DeepEqual(original.FFmpegArgs, base.FFmpegArgs) {
+		t.Errorf("登録済みのプリセットが変更された: %v", original.FFmpegArgs)
+	}
+}
+
+func Test音声コピー時は音声の再エンコード引数が除かれ映像は再エンコードされる(t *testing.T) {
+	base, err := preset.Get("hls_720p")
+	if err != nil {
+		t.Fatalf("プリセットの取得に失敗: %v", err)
+	}
+
+	applied, err := applyOptions(base, Options{StreamCopy: StreamCopyAudio, Speed: "veryfast"})
+	if err != nil {
+		t.Fatalf("オプションの適用に失敗: %v", err)
+	}
+
+	expected := []string{
+		"-vf", "scale=-2:720",
+		"-c:v", "libx264",
+		"-b:v", "2500k",
+		"-f", "hls",
+		"-hls_time", "6",
+		"-hls_playlist_type", "vod",
+		"-hls_segment_filename", "segment_%03d.ts",
+		"-preset", "veryfast",
+		"-c:a", "copy",
+	}
+	if !reflect.DeepEqual(applied.FFmpegArgs, expected) {
+		t.Errorf("引数が一致しない:\n期待値 %v\n取得値 %v", expected, applied.FFmpegArgs)
+	}
+}
+
+func Testストリームコピーできない組み合わせでエラーが返る(t *testing.T) {
+	h264, err := preset.Get("720p_h264")
+	if err != nil {
+		t.Fatalf("プリセットの取得に失敗: %v", err)
+	}
+	abr, err := preset.Get("hls_720p_abr")
+	if err != nil {
+		t.Fatalf("プリセットの取得に失敗: %v", err)
+	}
+	twoPass := preset.Preset{Name: "two_pass", FFmpegArgs: []string{"-c:v", "libx264", "-b:v", "5M"}, TwoPass: true}
+
+	tests := []struct {
+		name   string
+		preset preset.Preset
+		opts   Options
+	}{
+		{name: "不正なモード", preset: h264, opts: Options{StreamCopy: "subtitle"}},
+		{name: "filter_complex を使うプリセット", preset: abr, opts: Options{StreamCopy: StreamCopyAudio}},
+		{name: "2パスでの映像コピー", preset: twoPass, opts: Options{StreamCopy: StreamCopyVideo}},
+		{name: "映像コピーと speed の併用", preset: h264, opts: Options{StreamCopy: StreamCopyVideo, Speed: "fast"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := applyOptions(tt.preset, tt.opts); err == nil {
+				t.Error("エラーが返されなかった")
+			}
+		})
+	}
+}
+
+func Testコピーするコーデックと出力コンテナの互換性を判定する(t *testing.T) {
+	mp4 := preset.Preset{Extension: "mp4", OutputType: "single"}
+	hls := preset.Preset{Extension: "m3u8", OutputType: "hls"}
+	hlsFMP4 := preset.Preset{Extension: "m3u8", OutputType: "hls", FFmpegArgs: []string{"-hls_segment_type", "fmp4"}}
+	mkv := preset.Preset{Extension: "mkv", OutputType: "single"}
+
+	tests := []struct {
+		name    string
+		preset  preset.Preset
+		codec   string
+		wantErr bool
+	}{
+		{name: "h264 を mp4 へ", preset: mp4, codec: "h264"},
+		{name: "opus を mp4 へ", preset: mp4, codec: "opus"},
+		{name: "vorbis を mp4 へ", preset: mp4, codec: "vorbis", wantErr: true},
+		{name: "hevc を HLS(ts) へ", preset: hls, codec: "hevc"},
+		{name: "vp9 を HLS(ts) へ", preset: hls, codec: "vp9", wantErr: true},
+		{name: "vp9 を HLS(fMP4) へ", preset: hlsFMP4, codec: "vp9"},
+		{name: "未知のコンテナはチェックしない", preset: mkv, codec: "vp8"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkStreamCopyCompatibility(tt.preset, tt.codec)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("エラーの有無が一致しない: 期待値 %v, 取得値 %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// installFakeFFprobe は指定したコーデック名を出力する偽の ffprobe を PATH に配置する
+func installFakeFFprobe(t *testing.T, codec string) {
+	t.Helper()
+
+	binDir := t.TempDir()
+	script := "#!/bin/sh\necho '" + codec + "'\n"
+	if err := os.WriteFile(filepath.Join(binDir, "ffprobe"), []byte(script), 0755); err != nil {
+		t.Fatalf("偽の ffprobe の作成に失敗: %v", err)
+	}
+	t.Setenv("PATH", binDir)
+}
+
+func Test映像コピー時は入力のコーデックが検証の期待値になる(t *testing.T) {
+	installFakeFFprobe(t, "hevc")
+
+	p, err := preset.Get("720p_h264")
+	if err != nil {
+		t.Fatalf("プリセットの取得に失敗: %v", err)
+	}
+	expected := &validator.ExpectedMediaInfo{VideoCodec: "h264", AudioCodec: "aac", Height: 720}
+
+	encoder := New(t.TempDir())
+	if err := encoder.prepareStreamCopy(context.Background(), "input.mp4", p, StreamCopyVideo, expected); err != nil {
+		t.Fatalf("ストリームコピーの準備に失敗: %v", err)
+	}
+
+	if expected.VideoCodec != "hevc" {
+		t.Errorf("映像コーデックの期待値が一致しない: 期待値 %s, 取得値 %s", "hevc", expected.VideoCodec)
+	}
+	if expected.Height != 0 {
+		t.Errorf("映像コピー時に解像度の期待値が残っている: %d", expected.Height)
+	}
+	if expected.AudioCodec != "aac" {
+		t.Errorf("音声コーデックの期待値が変更された: %s", expected.AudioCodec)
+	}
+}
+
+func Test出力コンテナに格納できないコーデックのコピーはエラーになる(t *testing.T) {
+	installFakeFFprobe(t, "vorbis")
+
+	p, err := preset.Get("hls_720p")
+	if err != nil {
+		t.Fatalf("プリセットの取得に失敗: %v", err)
+	}
+
+	encoder := New(t.TempDir())
+	err = encoder.prepareStreamCopy(context.Background(), "input.webm", p, StreamCopyAudio, &validator.ExpectedMediaInfo{})
+	if err == nil {
+		t.Fatal("互換性のないコーデックでエラーが返されなかった")
+	}
+	if !strings.Contains(err.Error(), "vorbis") {
+		t.Errorf("エラーメッセージにコーデック名が含まれていない: %v", err)
+	}
+}
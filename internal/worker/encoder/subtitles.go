@@ -0,0 +1,158 @@
+package encoder
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/nzws/flux-encoder/internal/shared/logger"
+	"github.com/nzws/flux-encoder/internal/worker/preset"
+	"go.uber.org/zap"
+)
+
+// maxSubtitleBytes はダウンロードする字幕ファイルの上限サイズ
+const maxSubtitleBytes = 10 << 20
+
+// subtitleFileName はダウンロードした字幕ファイルの名前（拡張子を除く、入力ディレクトリからの相対パス）
+const subtitleFileName = "subtitles"
+
+// ValidateSubtitlePath は焼き込む字幕のパスを検証する
+// WebVTT（.vtt）と SRT（.srt）のみ受け付け、http(s)・s3:// の URL またはローカルパスを指定できる
+func ValidateSubtitlePath(subtitlePath string) error {
+	ext, err := subtitleExt(subtitlePath)
+	if err != nil {
+		return err
+	}
+	if ext != ".vtt" && ext != ".srt" {
+		return fmt.Errorf("unsupported subtitle format (only .vtt and .srt are supported): %s", subtitlePath)
+	}
+	return nil
+}
+
+// CheckSubtitleBurnIn はプリセットに字幕を焼き込めるかをチェックする
+// -filter_complex を使うプリセット（ABR など）はバリアントごとにフィルターが分かれるため対象外
+func CheckSubtitleBurnIn(p preset.Preset) error {
+	for _, arg := range p.FFmpegArgs {
+		if arg == "-filter_complex" {
+			return fmt.Errorf("burn-in subtitles are not supported for ABR presets using -filter_complex: %s", p.Name)
+		}
+	}
+	return nil
+}
+
+// subtitleExt は字幕のパス（URL の場合はパス部分）の拡張子を小文字で返す
+func subtitleExt(subtitlePath string) (string, error) {
+	u, err := url.Parse(subtitlePath)
+	if err != nil {
+		return "", fmt.Errorf("invalid subtitle path: %w", err)
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https", "s3":
+		return strings.ToLower(path.Ext(u.Path)), nil
+	case "":
+		return strings.ToLower(filepath.Ext(subtitlePath)), nil
+	default:
+		return "", fmt.Errorf("unsupported subtitle url scheme: %s", u.Scheme)
+	}
+}
+
+// resolveSubtitle は字幕を ffmpeg の subtitles フィルターに渡すローカルパスに解決する
+// http(s)・s3:// の字幕は入力と同じくジョブディレクトリにダウンロードし、ローカルパスはそのまま返す
+func (e *Encoder) resolveSubtitle(ctx context.Context, jobDir, subtitlePath string) (string, error) {
+	if err := ValidateSubtitlePath(subtitlePath); err != nil {
+		return "", err
+	}
+
+	u, err := url.Parse(subtitlePath)
+	if err != nil || u.Scheme == "" {
+		return subtitlePath, nil
+	}
+
+	localPath := subtitleDownloadPath(jobDir, u)
+	if err := os.MkdirAll(filepath.Dir(localPath), 0755); err != nil {
+		return "", fmt.Errorf("failed to create input directory: %w", err)
+	}
+
+	if strings.EqualFold(u.Scheme, "s3") {
+		bucket := u.Host
+		key := strings.TrimPrefix(u.Path, "/")
+		if bucket == "" || key == "" {
+			return "", fmt.Errorf("invalid s3 subtitle url: %s", subtitlePath)
+		}
+		if e.inputDownloader == nil {
+			return "", fmt.Errorf("s3 subtitles are not supported by this worker (STORAGE_TYPE=s3 is required)")
+		}
+		if err := e.inputDownloader.Download(ctx, bucket, key, localPath); err != nil {
+			return "", fmt.Errorf("failed to download subtitles: %w", err)
+		}
+		return localPath, nil
+	}
+
+	if err := downloadSubtitle(ctx, subtitlePath, localPath); err != nil {
+		return "", fmt.Errorf("failed to download subtitles: %w", err)
+	}
+	return localPath, nil
+}
+
+// subtitleDownloadPath は URL で指定された字幕のジョブディレクトリ内のダウンロード先を返す
+func subtitleDownloadPath(jobDir string, u *url.URL) string {
+	return filepath.Join(jobDir, inputDirName, subtitleFileName+strings.ToLower(path.Ext(u.Path)))
+}
+
+// downloadSubtitle は http(s) の字幕ファイルを localPath に保存する（maxSubtitleBytes を超える場合はエラー）
+func downloadSubtitle(ctx context.Context, subtitleURL, localPath string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, subtitleURL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			logger.Warn("Failed to close subtitle response body", zap.Error(err))
+		}
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxSubtitleBytes+1))
+	if err != nil {
+		return err
+	}
+	if len(data) > maxSubtitleBytes {
+		return fmt.Errorf("subtitle file exceeds %d bytes", maxSubtitleBytes)
+	}
+	return os.WriteFile(localPath, data, 0644)
+}
+
+// applySubtitles は字幕を焼き込む subtitles フィルターを -vf に追加する
+// 既存の -vf（scale など）がある場合はその後に連結し、ない場合は -vf を追加する
+func applySubtitles(args []string, subtitlePath string) []string {
+	filter := "subtitles=" + escapeFilterValue(subtitlePath)
+
+	result := make([]string, len(args))
+	copy(result, args)
+	for i, arg := range result {
+		if arg == "-vf" && i+1 < len(result) {
+			result[i+1] += "," + filter
+			return result
+		}
+	}
+	return append(result, "-vf", filter)
+}
+
+// escapeFilterValue はフィルターのオプション値として渡せるようにパスをエスケープする
+// オプション値のエスケープ（\ ' :）の後に、フィルターグラフのエスケープ（\ ' [ ] , ;）を行う
+func escapeFilterValue(value string) string {
+	optionLevel := strings.NewReplacer(`\`, `\\`, `'`, `\'`, `:`, `\:`).Replace(value)
+	return strings.NewReplacer(`\`, `\\`, `'`, `\'`, `[`, `\[`, `]`, `\]`, `,`, `\,`, `;`, `\;`).Replace(optionLevel)
+}
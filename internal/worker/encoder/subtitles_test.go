@@ -0,0 +1,97 @@
+package encoder
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/nzws/flux-encoder/internal/worker/preset"
+)
+
+func Test字幕のsubtitlesフィルターが既存のvfの後に連結される(t *testing.T) {
+	base, err := preset.Get("720p_h264")
+	if err != nil {
+		t.Fatalf("プリセットの取得に失敗: %v", err)
+	}
+
+	args := applySubtitles(base.FFmpegArgs, "/work/job-1/input/subtitles.vtt")
+	var filters []string
+	for i, arg := range args {
+		if arg == "-vf" {
+			filters = append(filters, args[i+1])
+		}
+	}
+	want := []string{"scale=-2:720,subtitles=/work/job-1/input/subtitles.vtt"}
+	if !reflect.DeepEqual(filters, want) {
+		t.Errorf("-vf の値が一致しない: 期待値 %v, 取得値 %v", want, filters)
+	}
+	// 登録済みのプリセットは変更されない
+	if strings.Contains(strings.Join(base.FFmpegArgs, " "), "subtitles") {
+		t.Error("登録済みのプリセットの引数が変更されている")
+	}
+
+	args = applySubtitles([]string{"-c:v", "libx264"}, "/tmp/subs.srt")
+	if want := []string{"-c:v", "libx264", "-vf", "subtitles=/tmp/subs.srt"}; !reflect.DeepEqual(args, want) {
+		t.Errorf("-vf がないプリセットの引数が一致しない: 期待値 %v, 取得値 %v", want, args)
+	}
+}
+
+func Test字幕のパスはフィルターの区切り文字がエスケープされる(t *testing.T) {
+	got := escapeFilterValue(`/tmp/a:b,c's.srt`)
+	want := `/tmp/a\\:b\,c\\\'s.srt`
+	if got != want {
+		t.Errorf("エスケープ結果が一致しない: 期待値 %s, 取得値 %s", want, got)
+	}
+}
+
+func TestABRプリセットに字幕を焼き込むとエラーになる(t *testing.T) {
+	base, err := preset.Get("hls_720p_abr_video_only")
+	if err != nil {
+		t.Fatalf("プリセットの取得に失敗: %v", err)
+	}
+
+	_, err = applyOptions(base, Options{SubtitlePath: "https://example.com/subs.vtt"})
+	if err == nil || !strings.Contains(err.Error(), "ABR") {
+		t.Errorf("ABR プリセットのエラーが返されない: %v", err)
+	}
+}
+
+func Test字幕の形式とURLが検証される(t *testing.T) {
+	for _, valid := range []string{"https://example.com/subs/ja.vtt", "http://example.com/ja.SRT?token=x", "s3://bucket/subs/en.srt", "/data/subs.vtt"} {
+		if err := ValidateSubtitlePath(valid); err != nil {
+			t.Errorf("有効な字幕のパスでエラーが返された (%s): %v", valid, err)
+		}
+	}
+	for _, invalid := range []string{"https://example.com/subs.ass", "ftp://example.com/subs.vtt", "/data/subs.txt"} {
+		if err := ValidateSubtitlePath(invalid); err == nil {
+			t.Errorf("不正な字幕のパスでエラーが返されない: %s", invalid)
+		}
+	}
+}
+
+func TestHTTPの字幕はジョブディレクトリにダウンロードされる(t *testing.T) {
+	content := "WEBVTT\n\n00:00.000 --> 00:01.000\nこんにちは\n"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(content))
+	}))
+	defer server.Close()
+
+	jobDir := t.TempDir()
+	e := New(t.TempDir())
+	localPath, err := e.resolveSubtitle(context.Background(), jobDir, server.URL+"/subs/ja.vtt")
+	if err != nil {
+		t.Fatalf("字幕の解決に失敗: %v", err)
+	}
+	if want := filepath.Join(jobDir, inputDirName, "subtitles.vtt"); localPath != want {
+		t.Errorf("字幕のパスが一致しない: 期待値 %s, 取得値 %s", want, localPath)
+	}
+	data, err := os.ReadFile(localPath)
+	if err != nil || string(data) != content {
+		t.Errorf("ダウンロードした字幕の内容が一致しない: %q (%v)", data, err)
+	}
+}
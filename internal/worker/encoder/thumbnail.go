@@ -0,0 +1,65 @@
+package encoder
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/nzws/flux-encoder/internal/shared/execlimit"
+	"github.com/nzws/flux-encoder/internal/worker/preset"
+)
+
+// ThumbnailFileName はエンコード時に生成するサムネイル画像のファイル名
+const ThumbnailFileName = "thumbnail.jpg"
+
+// GenerateThumbnail は入力の指定時刻のフレームを JPEG として outputPath に書き出す
+// timestamp は ffmpeg の時間表記（"5"、"00:00:05.5" など）
+func (e *Encoder) GenerateThumbnail(ctx context.Context, inputURL, timestamp, outputPath string) error {
+	return e.generateThumbnail(ctx, inputURL, timestamp, 0, outputPath)
+}
+
+// generateThumbnail はサムネイルを生成し、空でないファイルが出力されたか確認する
+// width が 0 より大きい場合はアスペクト比を保って指定幅に縮小する
+func (e *Encoder) generateThumbnail(ctx context.Context, inputURL, timestamp string, width int, outputPath string) error {
+	cmd := newFFmpegCommand(ctx, e.ffmpegPath, buildThumbnailArgs(e.globalArgs, inputURL, timestamp, width, outputPath)...)
+	output, err := execlimit.CombinedOutput(cmd)
+	if err != nil {
+		return withErrorCode(classifyFFmpegError(string(output)), fmt.Errorf("failed to generate thumbnail: %w: %s", err, strings.TrimSpace(string(output))))
+	}
+
+	info, err := os.Stat(outputPath)
+	if err != nil {
+		return fmt.Errorf("thumbnail not found: %w", err)
+	}
+	if info.Size() == 0 {
+		return fmt.Errorf("thumbnail is empty: %s", outputPath)
+	}
+	return nil
+}
+
+// buildThumbnailArgs はサムネイル生成用の ffmpeg 引数を構築する
+// -ss を入力の前に置き、シークしてから1フレームだけデコードする
+func buildThumbnailArgs(globalArgs []string, inputURL, timestamp string, width int, outputPath string) []string {
+	args := withGlobalArgs(globalArgs, []string{
+		"-ss", timestamp,
+		"-i", inputURL,
+		"-frames:v", "1",
+		"-q:v", "2",
+	})
+	if width > 0 {
+		args = append(args, "-vf", "scale="+strconv.Itoa(width)+":-2")
+	}
+	return append(args, "-y", outputPath)
+}
+
+// thumbnailPath はサムネイルの出力先を返す
+// HLS/DASH は出力ディレクトリごとアップロードされるため出力ディレクトリ内、単一ファイル出力は出力ファイルと同じディレクトリ
+func thumbnailPath(p preset.Preset, outputPath string) string {
+	if p.OutputType == outputTypeHLS || p.OutputType == outputTypeDASH {
+		return filepath.Join(outputPath, ThumbnailFileName)
+	}
+	return filepath.Join(filepath.Dir(outputPath), ThumbnailFileName)
+}
@@ -0,0 +1,92 @@
+package encoder
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/nzws/flux-encoder/internal/worker/preset"
+)
+
+// installFakeThumbnailFFmpeg は最後の引数（出力パス）に content を書き込む偽の ffmpeg を PATH に配置する
+func installFakeThumbnailFFmpeg(t *testing.T, content string) {
+	t.Helper()
+
+	binDir := t.TempDir()
+	script := "#!/bin/sh\nfor last; do :; done\nprintf '" + content + "' > \"$last\"\n"
+	if err := os.WriteFile(filepath.Join(binDir, "ffmpeg"), []byte(script), 0755); err != nil {
+		t.Fatalf("偽の ffmpeg の作成に失敗: %v", err)
+	}
+	t.Setenv("PATH", binDir)
+}
+
+func Testサムネイル生成の引数が構築される(t *testing.T) {
+	args := buildThumbnailArgs(nil, "input.mp4", "00:00:05", 0, "thumbnail.jpg")
+	expected := []string{"-ss", "00:00:05", "-i", "input.mp4", "-frames:v", "1", "-q:v", "2", "-y", "thumbnail.jpg"}
+	if !reflect.DeepEqual(args, expected) {
+		t.Errorf("引数が一致しない:\n期待値 %v\n取得値 %v", expected, args)
+	}
+
+	args = buildThumbnailArgs(nil, "input.mp4", "3.5", 320, "thumbnail.jpg")
+	expected = []string{"-ss", "3.5", "-i", "input.mp4", "-frames:v", "1", "-q:v", "2", "-vf", "scale=320:-2", "-y", "thumbnail.jpg"}
+	if !reflect.DeepEqual(args, expected) {
+		t.Errorf("幅指定時の引数が一致しない:\n期待値 %v\n取得値 %v", expected, args)
+	}
+}
+
+func TestGenerateThumbnailがサムネイルを出力する(t *testing.T) {
+	installFakeThumbnailFFmpeg(t, "jpeg")
+
+	outputPath := filepath.Join(t.TempDir(), ThumbnailFileName)
+	if err := New(t.TempDir()).GenerateThumbnail(context.Background(), "input.mp4", "00:00:01", outputPath); err != nil {
+		t.Fatalf("サムネイルの生成に失敗: %v", err)
+	}
+
+	data, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatalf("サムネイルの読み取りに失敗: %v", err)
+	}
+	if string(data) != "jpeg" {
+		t.Errorf("サムネイルの内容が一致しない: %q", data)
+	}
+}
+
+func TestGenerateThumbnailで空のサムネイルはエラーになる(t *testing.T) {
+	installFakeThumbnailFFmpeg(t, "")
+
+	outputPath := filepath.Join(t.TempDir(), ThumbnailFileName)
+	err := New(t.TempDir()).GenerateThumbnail(context.Background(), "input.mp4", "00:00:01", outputPath)
+	if err == nil {
+		t.Fatal("空のサムネイルでエラーが返されなかった")
+	}
+	if !strings.Contains(err.Error(), "empty") {
+		t.Errorf("エラーメッセージが一致しない: %v", err)
+	}
+}
+
+func Testサムネイルの出力先が出力タイプに応じて決まる(t *testing.T) {
+	jobDir := filepath.Join("work", "job-1")
+
+	tests := []struct {
+		name       string
+		outputType string
+		outputPath string
+		expected   string
+	}{
+		{"単一ファイル", "single", filepath.Join(jobDir, "output.mp4"), filepath.Join(jobDir, ThumbnailFileName)},
+		{"HLS", outputTypeHLS, filepath.Join(jobDir, "output"), filepath.Join(jobDir, "output", ThumbnailFileName)},
+		{"DASH", outputTypeDASH, filepath.Join(jobDir, "output"), filepath.Join(jobDir, "output", ThumbnailFileName)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := thumbnailPath(preset.Preset{OutputType: tt.outputType}, tt.outputPath)
+			if got != tt.expected {
+				t.Errorf("出力先が一致しない: 期待値 %s, 取得値 %s", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package encoder
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/nzws/flux-encoder/internal/worker/validator"
+)
+
+// ValidationSettings はエンコード後の出力の検証の設定（Worker の環境変数とジョブごとの指定で使う）
+// ゼロ値の項目は変更しない（Worker のデフォルトの場合は組み込みの値、ジョブの場合は Worker のデフォルトを使う）
+type ValidationSettings struct {
+	// Level は検証のレベル（"minimal"・"standard"・"strict"）
+	Level string
+	// Timeout は検証のタイムアウト
+	Timeout time.Duration
+	// SkipDecodeTest は strict レベルのデコードテストを省略するか（nil の場合は変更しない）
+	SkipDecodeTest *bool
+	// HLSValidationDepth は HLS の検証の深さ（"basic"・"medium"・"full"）
+	HLSValidationDepth string
+}
+
+// ValidationSettingsFromEnv は VALIDATION_LEVEL・VALIDATION_TIMEOUT（秒）・VALIDATION_SKIP_DECODE・HLS_VALIDATION_DEPTH を解析する
+// 不正な値はエラーを返す（Worker の起動時に検出するため）
+func ValidationSettingsFromEnv(getenv func(string) string) (ValidationSettings, error) {
+	settings := ValidationSettings{
+		Level:              getenv("VALIDATION_LEVEL"),
+		HLSValidationDepth: getenv("HLS_VALIDATION_DEPTH"),
+	}
+	if value := getenv("VALIDATION_TIMEOUT"); value != "" {
+		seconds, err := strconv.Atoi(value)
+		if err != nil || seconds <= 0 {
+			return ValidationSettings{}, fmt.Errorf("invalid VALIDATION_TIMEOUT: %q (must be a positive number of seconds)", value)
+		}
+		settings.Timeout = time.Duration(seconds) * time.Second
+	}
+	if value := getenv("VALIDATION_SKIP_DECODE"); value != "" {
+		skip, err := strconv.ParseBool(value)
+		if err != nil {
+			return ValidationSettings{}, fmt.Errorf("invalid VALIDATION_SKIP_DECODE: %q", value)
+		}
+		settings.SkipDecodeTest = &skip
+	}
+	if err := settings.Validate(); err != nil {
+		return ValidationSettings{}, err
+	}
+	return settings, nil
+}
+
+// Validate は設定の値が正しいかをチェックする
+func (s ValidationSettings) Validate() error {
+	_, err := s.apply(validator.DefaultValidationOptions())
+	return err
+}
+
+// apply は base に設定を適用した検証オプションを返す
+func (s ValidationSettings) apply(base validator.ValidationOptions) (validator.ValidationOptions, error) {
+	if s.Level != "" {
+		level, err := validator.ParseValidationLevel(s.Level)
+		if err != nil {
+			return validator.ValidationOptions{}, err
+		}
+		base.Level = level
+	}
+	if s.Timeout < 0 {
+		return validator.ValidationOptions{}, fmt.Errorf("invalid validation timeout: %s", s.Timeout)
+	}
+	if s.Timeout > 0 {
+		base.Timeout = s.Timeout
+	}
+	if s.SkipDecodeTest != nil {
+		base.SkipDecodeTest = *s.SkipDecodeTest
+	}
+	if s.HLSValidationDepth != "" {
+		depth, err := validator.ParseHLSValidationDepth(s.HLSValidationDepth)
+		if err != nil {
+			return validator.ValidationOptions{}, err
+		}
+		base.HLSValidationDepth = depth
+	}
+	return base, nil
+}
+
+// SetValidationDefaults はすべてのジョブに適用する出力の検証のデフォルト設定を設定する
+// ジョブごとの指定（Options.Validation）はこの設定に対して適用する
+func (e *Encoder) SetValidationDefaults(settings ValidationSettings) error {
+	defaults, err := settings.apply(validator.DefaultValidationOptions())
+	if err != nil {
+		return err
+	}
+	e.validationDefaults = defaults
+	return nil
+}
+
+// SetMaxValidationWarnings は出力の検証の警告がこの件数を超えた場合にジョブを失敗とする（0 以下の場合は警告で失敗しない）
+func (e *Encoder) SetMaxValidationWarnings(n int) {
+	if n < 0 {
+		n = 0
+	}
+	e.maxValidationWarnings = n
+}
+
+// ValidateExisting はエンコードせずに既存の出力を検証し、検証結果を返す
+// settings は Worker のデフォルト設定に対して適用する。プリセットがないため、期待するメディア情報は検証しない
+// 警告の件数の上限（SetMaxValidationWarnings）は適用しない
+func (e *Encoder) ValidateExisting(ctx context.Context, outputPath string, settings ValidationSettings) (*validator.ValidationResult, error) {
+	opts, err := settings.apply(e.validationDefaults)
+	if err != nil {
+		return nil, fmt.Errorf("invalid validation settings: %w", err)
+	}
+	return e.validator.Validate(ctx, outputPath, &opts)
+}
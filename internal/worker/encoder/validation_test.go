@@ -0,0 +1,158 @@
+package encoder
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nzws/flux-encoder/internal/worker/preset"
+	"github.com/nzws/flux-encoder/internal/worker/validator"
+)
+
+// envFrom は map を環境変数の取得関数として使う
+func envFrom(env map[string]string) func(string) string {
+	return func(key string) string { return env[key] }
+}
+
+func Test検証の環境変数が未設定の場合は組み込みのデフォルト値になる(t *testing.T) {
+	settings, err := ValidationSettingsFromEnv(envFrom(nil))
+	if err != nil {
+		t.Fatalf("環境変数の解析に失敗: %v", err)
+	}
+
+	e := New(t.TempDir())
+	if err := e.SetValidationDefaults(settings); err != nil {
+		t.Fatalf("デフォルト設定の適用に失敗: %v", err)
+	}
+	if e.validationDefaults != validator.DefaultValidationOptions() {
+		t.Errorf("検証オプションが一致しない: 期待値 %+v, 取得値 %+v", validator.DefaultValidationOptions(), e.validationDefaults)
+	}
+}
+
+func Test検証の環境変数が検証オプションに反映される(t *testing.T) {
+	settings, err := ValidationSettingsFromEnv(envFrom(map[string]string{
+		"VALIDATION_LEVEL":       "strict",
+		"VALIDATION_TIMEOUT":     "120",
+		"VALIDATION_SKIP_DECODE": "true",
+		"HLS_VALIDATION_DEPTH":   "full",
+	}))
+	if err != nil {
+		t.Fatalf("環境変数の解析に失敗: %v", err)
+	}
+
+	e := New(t.TempDir())
+	if err := e.SetValidationDefaults(settings); err != nil {
+		t.Fatalf("デフォルト設定の適用に失敗: %v", err)
+	}
+	got := e.validationDefaults
+	if got.Level != validator.ValidationLevelStrict {
+		t.Errorf("Level が一致しない: 期待値 %v, 取得値 %v", validator.ValidationLevelStrict, got.Level)
+	}
+	if got.Timeout != 120*time.Second {
+		t.Errorf("Timeout が一致しない: 期待値 %v, 取得値 %v", 120*time.Second, got.Timeout)
+	}
+	if !got.SkipDecodeTest {
+		t.Error("SkipDecodeTest が true にならない")
+	}
+	if got.HLSValidationDepth != validator.HLSValidationDepthFull {
+		t.Errorf("HLSValidationDepth が一致しない: 期待値 %v, 取得値 %v", validator.HLSValidationDepthFull, got.HLSValidationDepth)
+	}
+	// 環境変数で指定しない項目は変更しない
+	if got.DASHValidationDepth != validator.DASHValidationDepthMedium {
+		t.Errorf("DASHValidationDepth が変更された: %v", got.DASHValidationDepth)
+	}
+}
+
+func Test不正な検証の環境変数はエラーになる(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+	}{
+		{name: "不明なレベル", env: map[string]string{"VALIDATION_LEVEL": "paranoid"}},
+		{name: "数値ではないタイムアウト", env: map[string]string{"VALIDATION_TIMEOUT": "30s"}},
+		{name: "0のタイムアウト", env: map[string]string{"VALIDATION_TIMEOUT": "0"}},
+		{name: "真偽値ではないデコードテストの省略", env: map[string]string{"VALIDATION_SKIP_DECODE": "sometimes"}},
+		{name: "不明なHLSの検証の深さ", env: map[string]string{"HLS_VALIDATION_DEPTH": "deep"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ValidationSettingsFromEnv(envFrom(tt.env)); err == nil {
+				t.Errorf("エラーが返されない: %v", tt.env)
+			}
+		})
+	}
+}
+
+func Testジョブの検証の設定はWorkerのデフォルト設定を上書きする(t *testing.T) {
+	skip := true
+	e := New(t.TempDir())
+	if err := e.SetValidationDefaults(ValidationSettings{Level: "strict", SkipDecodeTest: &skip, Timeout: time.Minute}); err != nil {
+		t.Fatalf("デフォルト設定の適用に失敗: %v", err)
+	}
+
+	noSkip := false
+	got, err := ValidationSettings{Level: "minimal", SkipDecodeTest: &noSkip}.apply(e.validationDefaults)
+	if err != nil {
+		t.Fatalf("ジョブの設定の適用に失敗: %v", err)
+	}
+	if got.Level != validator.ValidationLevelMinimal {
+		t.Errorf("Level が一致しない: 期待値 %v, 取得値 %v", validator.ValidationLevelMinimal, got.Level)
+	}
+	if got.SkipDecodeTest {
+		t.Error("ジョブで false を指定しても SkipDecodeTest が true のまま")
+	}
+	// ジョブで指定しない項目は Worker のデフォルト設定を使う
+	if got.Timeout != time.Minute {
+		t.Errorf("Timeout が一致しない: 期待値 %v, 取得値 %v", time.Minute, got.Timeout)
+	}
+
+	base, err := preset.Get("720p_h264")
+	if err != nil {
+		t.Fatalf("プリセットの取得に失敗: %v", err)
+	}
+	if _, err := applyOptions(base, Options{Validation: ValidationSettings{HLSValidationDepth: "deep"}}); err == nil {
+		t.Error("不正な検証の設定でエンコードの前にエラーが返されない")
+	}
+}
+
+// stubValidator は固定の検証結果を返す Validator
+type stubValidator struct {
+	result *validator.ValidationResult
+}
+
+func (v stubValidator) Validate(ctx context.Context, outputPath string, options *validator.ValidationOptions) (*validator.ValidationResult, error) {
+	return v.result, nil
+}
+
+func Test検証の警告が上限を超えた場合のみ失敗する(t *testing.T) {
+	result := &validator.ValidationResult{Valid: true}
+	for i := 0; i < 12; i++ {
+		result.Warnings = append(result.Warnings, validator.ValidationWarning{Code: "BITRATE_LOW", Message: "bitrate is lower than expected"})
+	}
+
+	e := New(t.TempDir())
+	e.validator = stubValidator{result: result}
+	opts := validator.DefaultValidationOptions()
+
+	// 上限を設定しない場合は警告があっても成功する
+	if err := e.validateOutput(context.Background(), "warnings-job", "out.mp4", opts); err != nil {
+		t.Errorf("上限なしで失敗した: %v", err)
+	}
+
+	// 警告の件数が上限と同じ場合は成功する
+	e.SetMaxValidationWarnings(12)
+	if err := e.validateOutput(context.Background(), "warnings-job", "out.mp4", opts); err != nil {
+		t.Errorf("警告が上限以下で失敗した: %v", err)
+	}
+
+	e.SetMaxValidationWarnings(10)
+	err := e.validateOutput(context.Background(), "warnings-job", "out.mp4", opts)
+	if err == nil {
+		t.Fatal("警告が上限を超えたのに成功した")
+	}
+	if !strings.Contains(err.Error(), "12 warnings (max 10)") {
+		t.Errorf("エラーメッセージが一致しない: %v", err)
+	}
+}
@@ -0,0 +1,50 @@
+package grpc
+
+import (
+	"context"
+
+	"github.com/nzws/flux-encoder/internal/shared/logger"
+	"github.com/nzws/flux-encoder/internal/worker/encoder"
+	workerv1 "github.com/nzws/flux-encoder/proto/worker/v1"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// DryRun はジョブで実行する ffmpeg のコマンドを実行せずに返す
+// SubmitJob と同じ検証を行い、不正なジョブは InvalidArgument（生の引数を許可していない場合は PermissionDenied）を返す
+func (s *Server) DryRun(ctx context.Context, req *workerv1.JobRequest) (*workerv1.DryRunResponse, error) {
+	if len(req.RawFfmpegArgs) > 0 {
+		if !s.allowRawArgs {
+			return nil, status.Error(codes.PermissionDenied, "raw ffmpeg args are not allowed on this worker (WORKER_ALLOW_RAW_ARGS=true is required)")
+		}
+		if err := encoder.ValidateRawArgs(req.RawFfmpegArgs); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+
+	clip, err := encoder.ParseClip(req.StartTime, req.Duration)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid clip range: %v", err)
+	}
+	validationSettings := validationSettingsFromConfig(req.Validation)
+	if err := validationSettings.Validate(); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid validation settings: %v", err)
+	}
+
+	cmd, err := s.encoder.BuildCommandWithOptions(req.JobId, req.InputUrl, req.Preset, encodeOptions(req, clip, validationSettings))
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	logger.Info("Built ffmpeg command for dry run",
+		zap.String("job_id", req.JobId),
+		zap.String("preset", cmd.Preset),
+	)
+
+	return &workerv1.DryRunResponse{
+		Command:    cmd.Args,
+		WorkingDir: cmd.Dir,
+		Preset:     cmd.Preset,
+	}, nil
+}
@@ -0,0 +1,81 @@
+package grpc
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/nzws/flux-encoder/internal/worker/encoder"
+	workerv1 "github.com/nzws/flux-encoder/proto/worker/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestDryRunがジョブで実行するコマンドを返す(t *testing.T) {
+	workDir := t.TempDir()
+	server := NewServer(encoder.New(workDir), nil, 1, "test-worker", "0.0.0")
+	client := newTestClient(t, server)
+
+	resp, err := client.DryRun(context.Background(), &workerv1.JobRequest{
+		JobId:    "dryrun-job",
+		InputUrl: "https://example.com/input.mp4",
+		Preset:   "720p_h264",
+		Speed:    "veryfast",
+		Output:   &workerv1.OutputConfig{Storage: "local", Path: "out.mp4"},
+	})
+	if err != nil {
+		t.Fatalf("DryRun に失敗: %v", err)
+	}
+
+	if resp.Preset != "720p_h264" || resp.Command[0] != "ffmpeg" {
+		t.Errorf("レスポンスが一致しない: %+v", resp)
+	}
+	if want := filepath.Join(workDir, "dryrun-job", "output.mp4"); resp.Command[len(resp.Command)-1] != want {
+		t.Errorf("出力パスが一致しない: 期待値 %s, 取得値 %v", want, resp.Command)
+	}
+	found := false
+	for i, arg := range resp.Command {
+		if arg == "-preset" && resp.Command[i+1] == "veryfast" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("速度の指定が反映されていない: %v", resp.Command)
+	}
+}
+
+func TestDryRunが不正なジョブを拒否する(t *testing.T) {
+	server := NewServer(encoder.New(t.TempDir()), nil, 1, "test-worker", "0.0.0")
+	client := newTestClient(t, server)
+
+	tests := []struct {
+		name string
+		req  *workerv1.JobRequest
+		code codes.Code
+	}{
+		{
+			name: "存在しないプリセット",
+			req:  &workerv1.JobRequest{JobId: "dryrun-job", InputUrl: "input.mp4", Preset: "nonexistent_preset"},
+			code: codes.InvalidArgument,
+		},
+		{
+			name: "不正な切り出し範囲",
+			req:  &workerv1.JobRequest{JobId: "dryrun-job", InputUrl: "input.mp4", Preset: "720p_h264", StartTime: "-1"},
+			code: codes.InvalidArgument,
+		},
+		{
+			name: "許可していない生の引数",
+			req:  &workerv1.JobRequest{JobId: "dryrun-job", InputUrl: "input.mp4", RawFfmpegArgs: []string{"-c:v", "libx264"}},
+			code: codes.PermissionDenied,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := client.DryRun(context.Background(), tt.req)
+			if status.Code(err) != tt.code {
+				t.Errorf("ステータスコードが一致しない: 期待値 %v, 取得値 %v", tt.code, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package grpc
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/nzws/flux-encoder/internal/shared/logger"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// SetIdleTimeout はジョブがなくなってから Worker を自動停止するまでの待ち時間を設定する（0 以下の場合は自動停止しない）
+// 待っている間にジョブを受け付けた場合は停止を取りやめ、次にジョブがなくなった時点から待ち直す
+func (s *Server) SetIdleTimeout(d time.Duration) {
+	s.idleMutex.Lock()
+	defer s.idleMutex.Unlock()
+	s.idleTimeout = d
+}
+
+// SetAutoShutdown は自動停止を行うかを設定する（デフォルト: true）
+// false の場合は SetIdleTimeout の待ち時間に関わらず、ジョブがなくなっても停止のタイマーを開始しない
+func (s *Server) SetAutoShutdown(enabled bool) {
+	s.idleMutex.Lock()
+	defer s.idleMutex.Unlock()
+	s.autoShutdown = enabled
+}
+
+// SetIdleShutdownFunc は自動停止の際に呼び出す処理を設定する（nil の場合はデフォルトの Stop に戻す）
+// Stop で gRPC サーバーの Serve が戻るため、プロセスの終了は呼び出し元（main）が行う
+func (s *Server) SetIdleShutdownFunc(fn func()) {
+	s.idleMutex.Lock()
+	defer s.idleMutex.Unlock()
+	if fn == nil {
+		fn = s.Stop
+	}
+	s.idleShutdown = fn
+}
+
+// scheduleIdleShutdown は実行中・待機中のジョブがなければ、待ち時間の経過後に自動停止するタイマーを開始する
+// 待ち時間の間もヘルスチェックは SERVING のままで、ジョブを受け付ける
+func (s *Server) scheduleIdleShutdown() {
+	s.idleMutex.Lock()
+	defer s.idleMutex.Unlock()
+
+	if !s.autoShutdown || s.idleTimeout <= 0 || s.shuttingDown || !s.idle() {
+		return
+	}
+	if s.idleTimer != nil {
+		s.idleTimer.Stop()
+	}
+	s.idleGeneration++
+	generation := s.idleGeneration
+	s.idleTimer = time.AfterFunc(s.idleTimeout, func() {
+		s.shutdownIfIdle(generation)
+	})
+	logger.Info("No active jobs, worker will shut down if it stays idle",
+		zap.Duration("idle_timeout", s.idleTimeout),
+	)
+}
+
+// cancelIdleShutdown はジョブを受け付ける際に自動停止のタイマーを止める
+// すでに停止を始めている場合は新しいジョブを受け付けられないため Unavailable を返す
+func (s *Server) cancelIdleShutdown() error {
+	s.idleMutex.Lock()
+	defer s.idleMutex.Unlock()
+
+	if s.shuttingDown {
+		return status.Error(codes.Unavailable, "worker is shutting down")
+	}
+	if s.idleTimer != nil {
+		s.idleTimer.Stop()
+		s.idleTimer = nil
+		logger.Info("Job accepted, cancelled idle shutdown")
+	}
+	// 止める前に発火していたタイマーが停止しないよう世代を進める
+	s.idleGeneration++
+	return nil
+}
+
+// shutdownIfIdle は待ち時間の間ジョブを受け付けなかった場合に Worker を停止する
+func (s *Server) shutdownIfIdle(generation uint64) {
+	s.idleMutex.Lock()
+	if generation != s.idleGeneration || !s.idle() {
+		s.idleMutex.Unlock()
+		return
+	}
+	// 停止を始めた後に届いたジョブは cancelIdleShutdown で拒否する
+	s.shuttingDown = true
+	s.idleTimer = nil
+	shutdown := s.idleShutdown
+	timeout := s.idleTimeout
+	s.idleMutex.Unlock()
+
+	logger.Info("Worker stayed idle, shutting down worker...", zap.Duration("idle_timeout", timeout))
+	shutdown()
+}
+
+// idle は実行中・実行枠を待っているジョブがないかを返す
+func (s *Server) idle() bool {
+	return atomic.LoadInt32(&s.activeJobs) == 0 && s.queuedJobs() == 0
+}
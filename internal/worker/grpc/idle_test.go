@@ -0,0 +1,184 @@
+package grpc
+
+import (
+	"context"
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nzws/flux-encoder/internal/worker/encoder"
+	workerv1 "github.com/nzws/flux-encoder/proto/worker/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// submitFailingJob は ffmpeg を起動せずに失敗するジョブを送信し、ストリームが終了するまで待つ
+func submitFailingJob(t *testing.T, client workerv1.WorkerServiceClient, jobID string) {
+	t.Helper()
+
+	stream, err := client.SubmitJob(context.Background(), &workerv1.JobRequest{
+		JobId:    jobID,
+		InputUrl: "https://example.com/input.mp4",
+		Preset:   "nonexistent_preset",
+		Output:   &workerv1.OutputConfig{Storage: "local", Path: "out.mp4"},
+	})
+	if err != nil {
+		t.Fatalf("ジョブの送信に失敗: %v", err)
+	}
+	for {
+		if _, err := stream.Recv(); err == io.EOF {
+			return
+		} else if err != nil {
+			t.Fatalf("進捗の受信に失敗: %v", err)
+		}
+	}
+}
+
+func Test自動停止の待ち時間中にジョブを受け付けると停止が取りやめられる(t *testing.T) {
+	const idleTimeout = 500 * time.Millisecond
+
+	server := NewServer(encoder.New(t.TempDir()), nil, 1, "test-worker", "0.0.0")
+	server.SetIdleTimeout(idleTimeout)
+	server.SetHealthServer(health.NewServer())
+	var shutdowns int32
+	server.SetIdleShutdownFunc(func() { atomic.AddInt32(&shutdowns, 1) })
+	conn := newTestConn(t, server)
+	client := workerv1.NewWorkerServiceClient(conn)
+	healthClient := healthpb.NewHealthClient(conn)
+
+	// 1つ目のジョブの終了から待ち時間が経過する前に2つ目のジョブを送信する
+	submitFailingJob(t, client, "idle-test-1")
+	time.Sleep(idleTimeout * 3 / 5)
+	submitFailingJob(t, client, "idle-test-2")
+
+	// 1つ目のジョブの終了から待ち時間が経過しても停止しない
+	time.Sleep(idleTimeout * 3 / 5)
+	if got := atomic.LoadInt32(&shutdowns); got != 0 {
+		t.Fatalf("待ち時間中にジョブを受け付けたのに停止した: %d 回", got)
+	}
+	resp, err := healthClient.Check(context.Background(), &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatalf("ヘルスチェックに失敗: %v", err)
+	}
+	if resp.Status != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("待ち時間中のヘルスチェックの状態が一致しない: 期待値 %v, 取得値 %v", healthpb.HealthCheckResponse_SERVING, resp.Status)
+	}
+
+	// 2つ目のジョブの終了から待ち時間が経過すると1回だけ停止する
+	deadline := time.Now().Add(5 * time.Second)
+	for atomic.LoadInt32(&shutdowns) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("待ち時間が経過しても停止しない")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(idleTimeout)
+	if got := atomic.LoadInt32(&shutdowns); got != 1 {
+		t.Errorf("停止の回数が一致しない: 期待値 1, 取得値 %d", got)
+	}
+
+	// 停止を始めた後のジョブは拒否する
+	stream, err := client.SubmitJob(context.Background(), &workerv1.JobRequest{JobId: "idle-test-3", Preset: "nonexistent_preset"})
+	if err == nil {
+		_, err = stream.Recv()
+	}
+	if code := statusCode(err); code != codes.Unavailable {
+		t.Errorf("Unavailable が返されない: %v", err)
+	}
+}
+
+func Test自動停止の待ち時間が0の場合は停止しない(t *testing.T) {
+	server := NewServer(encoder.New(t.TempDir()), nil, 1, "test-worker", "0.0.0")
+	server.SetIdleShutdownFunc(func() { t.Error("待ち時間が0なのに停止した") })
+
+	server.scheduleIdleShutdown()
+	if server.idleTimer != nil {
+		t.Error("待ち時間が0なのにタイマーが開始された")
+	}
+}
+
+func Test自動停止を無効にするとジョブがなくなっても停止のタイマーを開始しない(t *testing.T) {
+	const idleTimeout = 50 * time.Millisecond
+
+	server := NewServer(encoder.New(t.TempDir()), nil, 1, "test-worker", "0.0.0")
+	server.SetIdleTimeout(idleTimeout)
+	server.SetAutoShutdown(false)
+	var shutdowns int32
+	server.SetIdleShutdownFunc(func() { atomic.AddInt32(&shutdowns, 1) })
+	client := workerv1.NewWorkerServiceClient(newTestConn(t, server))
+
+	submitFailingJob(t, client, "auto-shutdown-disabled")
+
+	// ジョブの後処理（defer）が終わるまで待ってからタイマーの有無を確認する
+	deadline := time.Now().Add(5 * time.Second)
+	for !server.idle() {
+		if time.Now().After(deadline) {
+			t.Fatal("ジョブが終了しない")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(idleTimeout * 3)
+
+	server.idleMutex.Lock()
+	timer := server.idleTimer
+	server.idleMutex.Unlock()
+	if timer != nil {
+		t.Error("自動停止が無効なのに停止のタイマーが開始された")
+	}
+	if got := atomic.LoadInt32(&shutdowns); got != 0 {
+		t.Errorf("自動停止が無効なのに停止した: %d 回", got)
+	}
+}
+
+func Testジョブがなくなり待ち時間が経過すると停止の処理が呼ばれる(t *testing.T) {
+	const idleTimeout = 50 * time.Millisecond
+
+	server := NewServer(encoder.New(t.TempDir()), nil, 1, "test-worker", "0.0.0")
+	server.SetIdleTimeout(idleTimeout)
+	shutdowns := make(chan struct{}, 1)
+	server.SetIdleShutdownFunc(func() { shutdowns <- struct{}{} })
+	client := workerv1.NewWorkerServiceClient(newTestConn(t, server))
+
+	start := time.Now()
+	submitFailingJob(t, client, "idle-shutdown")
+
+	select {
+	case <-shutdowns:
+	case <-time.After(5 * time.Second):
+		t.Fatal("待ち時間が経過しても停止の処理が呼ばれない")
+	}
+	if elapsed := time.Since(start); elapsed < idleTimeout {
+		t.Errorf("待ち時間の経過前に停止した: %v", elapsed)
+	}
+	if !server.idle() {
+		t.Error("ジョブが残っているのに停止した")
+	}
+}
+
+func Test停止の処理を設定しない場合はヘルスチェックをNOT_SERVINGにして停止する(t *testing.T) {
+	server := NewServer(encoder.New(t.TempDir()), nil, 1, "test-worker", "0.0.0")
+	healthServer := health.NewServer()
+	server.SetHealthServer(healthServer)
+	server.SetIdleTimeout(10 * time.Millisecond)
+	// nil を設定するとデフォルトの Stop に戻る（gRPC サーバーは未設定のためプロセスは終了しない）
+	server.SetIdleShutdownFunc(nil)
+
+	server.scheduleIdleShutdown()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		resp, err := healthServer.Check(context.Background(), &healthpb.HealthCheckRequest{})
+		if err != nil {
+			t.Fatalf("ヘルスチェックに失敗: %v", err)
+		}
+		if resp.Status == healthpb.HealthCheckResponse_NOT_SERVING {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("待ち時間が経過しても停止しない")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
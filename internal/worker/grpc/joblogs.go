@@ -0,0 +1,81 @@
+package grpc
+
+import (
+	"context"
+	"crypto/subtle"
+	"strings"
+
+	"github.com/nzws/flux-encoder/internal/shared/logger"
+	workerv1 "github.com/nzws/flux-encoder/proto/worker/v1"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// SetAdminToken は GetJobLogs などの管理者用 RPC の認証に使用するトークンを設定する
+// 空の場合は管理者用 RPC をすべて拒否する
+func (s *Server) SetAdminToken(token string) {
+	s.adminToken = token
+}
+
+// authorizeAdmin はメタデータの authorization（"Bearer <token>"）が管理者用のトークンと一致するかを確認する
+func (s *Server) authorizeAdmin(ctx context.Context) error {
+	if s.adminToken == "" {
+		return status.Error(codes.PermissionDenied, "admin RPCs are disabled (WORKER_ADMIN_TOKEN is not set)")
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return status.Error(codes.Unauthenticated, "missing authorization metadata")
+	}
+
+	token, found := strings.CutPrefix(values[0], "Bearer ")
+	if !found || subtle.ConstantTimeCompare([]byte(token), []byte(s.adminToken)) != 1 {
+		return status.Error(codes.Unauthenticated, "invalid admin token")
+	}
+	return nil
+}
+
+// GetJobLogs はエンコード中のジョブの ffmpeg の出力（stderr）をストリームで返す
+// 保持している直近の行を送信した後、エンコードが終了するかストリームが切断されるまで新しい行を送信する
+func (s *Server) GetJobLogs(req *workerv1.JobLogsRequest, stream workerv1.WorkerService_GetJobLogsServer) error {
+	ctx := stream.Context()
+
+	if err := s.authorizeAdmin(ctx); err != nil {
+		logger.Warn("Rejected job log request",
+			zap.String("job_id", req.JobId),
+			zap.Error(err),
+		)
+		return err
+	}
+
+	backlog, lines, unsubscribe, ok := s.encoder.SubscribeJobLogs(req.JobId)
+	if !ok {
+		return status.Errorf(codes.NotFound, "job is not encoding: %s", req.JobId)
+	}
+	defer unsubscribe()
+
+	logger.Info("Streaming job logs", zap.String("job_id", req.JobId))
+
+	for _, line := range backlog {
+		if err := stream.Send(&workerv1.JobLogLine{JobId: req.JobId, Line: line}); err != nil {
+			return err
+		}
+	}
+
+	for {
+		select {
+		case line, open := <-lines:
+			if !open {
+				return nil
+			}
+			if err := stream.Send(&workerv1.JobLogLine{JobId: req.JobId, Line: line}); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package grpc
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nzws/flux-encoder/internal/worker/encoder"
+	workerv1 "github.com/nzws/flux-encoder/proto/worker/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// installFakeEncodeTools は stderr に 2 行出力した後に終了せず待ち続ける ffmpeg と、長さを返す ffprobe を PATH に配置する
+func installFakeEncodeTools(t *testing.T) {
+	t.Helper()
+
+	binDir := t.TempDir()
+	scripts := map[string]string{
+		"ffprobe": "#!/bin/sh\necho 10\n",
+		"ffmpeg":  "#!/bin/sh\necho 'fake ffmpeg started' >&2\necho 'frame=   12 fps=24 time=00:00:00.50' >&2\nexec sleep 30\n",
+	}
+	for name, script := range scripts {
+		if err := os.WriteFile(filepath.Join(binDir, name), []byte(script), 0755); err != nil {
+			t.Fatalf("%s の作成に失敗: %v", name, err)
+		}
+	}
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func withAdminToken(ctx context.Context, token string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+}
+
+func Test実行中のジョブのffmpegの出力を購読できる(t *testing.T) {
+	installFakeEncodeTools(t)
+
+	server := NewServer(encoder.New(t.TempDir()), nil, 1, "test-worker", "0.0.0")
+	server.SetAdminToken("admin-secret")
+	client := newTestClient(t, server)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	submit, err := client.SubmitJob(ctx, &workerv1.JobRequest{
+		JobId:         "log-job",
+		InputUrl:      "https://example.com/input.mp4",
+		Preset:        "720p_h264",
+		Output:        &workerv1.OutputConfig{Storage: "local", Path: "out.mp4"},
+		SkipPreflight: true,
+	})
+	if err != nil {
+		t.Fatalf("ジョブの送信に失敗: %v", err)
+	}
+	defer func() {
+		if _, err := client.CancelJob(context.Background(), &workerv1.CancelRequest{JobId: "log-job"}); err != nil {
+			t.Errorf("ジョブのキャンセルに失敗: %v", err)
+		}
+		// キャンセルによりジョブが終了するまで待つ
+		for {
+			if _, err := submit.Recv(); err != nil {
+				return
+			}
+		}
+	}()
+
+	// ffmpeg が起動するまではエンコード中ではないため NotFound が返る
+	var received []string
+	for len(received) < 2 {
+		logs, err := client.GetJobLogs(withAdminToken(ctx, "admin-secret"), &workerv1.JobLogsRequest{JobId: "log-job"})
+		if err != nil {
+			t.Fatalf("GetJobLogs の呼び出しに失敗: %v", err)
+		}
+		received = nil
+		for len(received) < 2 {
+			line, err := logs.Recv()
+			if status.Code(err) == codes.NotFound {
+				time.Sleep(10 * time.Millisecond)
+				break
+			}
+			if err != nil {
+				t.Fatalf("ログの受信に失敗: %v", err)
+			}
+			if line.JobId != "log-job" {
+				t.Errorf("ジョブ ID が一致しない: %q", line.JobId)
+			}
+			received = append(received, line.Line)
+		}
+	}
+
+	if received[0] != "fake ffmpeg started" || received[1] != "frame=   12 fps=24 time=00:00:00.50" {
+		t.Errorf("受信した行が一致しない: %q", received)
+	}
+}
+
+func Test管理者トークンがない場合GetJobLogsは拒否される(t *testing.T) {
+	server := NewServer(encoder.New(t.TempDir()), nil, 1, "test-worker", "0.0.0")
+	client := newTestClient(t, server)
+
+	recv := func(ctx context.Context) error {
+		logs, err := client.GetJobLogs(ctx, &workerv1.JobLogsRequest{JobId: "log-job"})
+		if err == nil {
+			_, err = logs.Recv()
+		}
+		return err
+	}
+
+	// WORKER_ADMIN_TOKEN が設定されていない Worker では常に拒否する
+	if err := recv(withAdminToken(context.Background(), "")); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("PermissionDenied が返されない: %v", err)
+	}
+
+	server.SetAdminToken("admin-secret")
+	if err := recv(context.Background()); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("トークンなしで Unauthenticated が返されない: %v", err)
+	}
+	if err := recv(withAdminToken(context.Background(), "wrong")); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("誤ったトークンで Unauthenticated が返されない: %v", err)
+	}
+	if err := recv(withAdminToken(context.Background(), "admin-secret")); status.Code(err) != codes.NotFound {
+		t.Errorf("エンコード中でないジョブで NotFound が返されない: %v", err)
+	}
+}
@@ -0,0 +1,152 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/nzws/flux-encoder/internal/shared/metrics"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// PriorityHigh は他のジョブより先に実行枠を渡すジョブの優先度（ライブ配信に近いクリップなど）
+	PriorityHigh = "high"
+	// PriorityNormal はジョブの優先度の既定値
+	PriorityNormal = "normal"
+	// PriorityLow は他のジョブの後に実行枠を渡すジョブの優先度（過去の素材の一括変換など）
+	PriorityLow = "low"
+)
+
+// slotWaiter は実行枠が空くのを待っているジョブ
+type slotWaiter struct {
+	ready      chan struct{}
+	priority   int
+	enqueuedAt time.Time
+}
+
+// parsePriority はジョブの優先度を比較できる値に変換する（大きいほど先に実行枠を渡す、空の場合は normal）
+func parsePriority(priority string) (int, error) {
+	switch priority {
+	case PriorityHigh:
+		return 2, nil
+	case "", PriorityNormal:
+		return 1, nil
+	case PriorityLow:
+		return 0, nil
+	default:
+		return 0, status.Errorf(codes.InvalidArgument, "invalid priority: %q (must be %s, %s or %s)", priority, PriorityHigh, PriorityNormal, PriorityLow)
+	}
+}
+
+// priorityName は parsePriority の値をメトリクスのラベルに使う優先度の名前に戻す
+func priorityName(priority int) string {
+	switch {
+	case priority >= 2:
+		return PriorityHigh
+	case priority == 1:
+		return PriorityNormal
+	default:
+		return PriorityLow
+	}
+}
+
+// SetQueueSize は実行枠が空くのを待てるジョブ数を設定する（0 以下の場合は待たずに拒否する）
+func (s *Server) SetQueueSize(size int) {
+	if size < 0 {
+		size = 0
+	}
+	s.slotMutex.Lock()
+	defer s.slotMutex.Unlock()
+	s.queueSize = size
+}
+
+// queuedJobs は実行枠が空くのを待っているジョブ数を返す
+func (s *Server) queuedJobs() int32 {
+	s.slotMutex.Lock()
+	defer s.slotMutex.Unlock()
+	return int32(len(s.waiters))
+}
+
+// acquireSlot はジョブの実行枠を確保する
+// 空きがない場合、キューに空きがあれば onQueued（待機中の順番を渡す）を呼んでから枠が空くまで待つ
+// キューでは priority（parsePriority の値）が高いジョブから、同じ優先度の中では待ち始めた順に枠を渡す
+// キューも満杯の場合は ResourceExhausted を返し、待機中に ctx がキャンセルされた場合はキャンセルのエラーを返す
+func (s *Server) acquireSlot(ctx context.Context, priority int, onQueued func(position int)) error {
+	s.slotMutex.Lock()
+	current := atomic.LoadInt32(&s.activeJobs)
+	// 先に待っているジョブがある場合は追い越さない
+	if current < s.maxConcurrent && len(s.waiters) == 0 {
+		atomic.AddInt32(&s.activeJobs, 1)
+		s.slotMutex.Unlock()
+		return nil
+	}
+	if len(s.waiters) >= s.queueSize {
+		s.slotMutex.Unlock()
+		return s.capacityExceededError(current)
+	}
+	ready := make(chan struct{})
+	// 優先度が同じか高いジョブの後ろ（優先度の低いジョブの前）に入れる
+	position := len(s.waiters)
+	for position > 0 && s.waiters[position-1].priority < priority {
+		position--
+	}
+	s.waiters = append(s.waiters, nil)
+	copy(s.waiters[position+1:], s.waiters[position:])
+	s.waiters[position] = &slotWaiter{ready: ready, priority: priority, enqueuedAt: time.Now()}
+	metrics.QueuedJobs.WithLabelValues(priorityName(priority), s.workerID).Inc()
+	s.slotMutex.Unlock()
+
+	onQueued(position + 1)
+
+	select {
+	case <-ready:
+		return nil
+	case <-ctx.Done():
+		s.slotMutex.Lock()
+		removed := s.removeWaiter(ready)
+		s.slotMutex.Unlock()
+		if !removed {
+			// キャンセルと同時に枠を譲り受けていた場合は次のジョブに渡す
+			s.releaseSlot()
+		}
+		return status.FromContextError(fmt.Errorf("cancelled while waiting for a job slot: %w", ctx.Err())).Err()
+	}
+}
+
+// releaseSlot はジョブの実行枠を解放する
+// 待っているジョブがある場合は、実行中のジョブ数を変えずに先頭（最も優先度の高い）のジョブに枠を渡す
+func (s *Server) releaseSlot() {
+	s.slotMutex.Lock()
+	defer s.slotMutex.Unlock()
+
+	if len(s.waiters) > 0 {
+		waiter := s.waiters[0]
+		s.waiters = s.waiters[1:]
+		s.recordDequeue(waiter)
+		metrics.QueueWaitDuration.WithLabelValues(priorityName(waiter.priority), s.workerID).Observe(time.Since(waiter.enqueuedAt).Seconds())
+		close(waiter.ready)
+		return
+	}
+	atomic.AddInt32(&s.activeJobs, -1)
+}
+
+// removeWaiter は待機中のジョブをキューから取り除く（すでに枠を渡されていた場合は false）
+// slotMutex を保持して呼び出す
+func (s *Server) removeWaiter(ready chan struct{}) bool {
+	for i, waiter := range s.waiters {
+		if waiter.ready == ready {
+			s.waiters = append(s.waiters[:i], s.waiters[i+1:]...)
+			s.recordDequeue(waiter)
+			return true
+		}
+	}
+	return false
+}
+
+// recordDequeue はキューから取り出したジョブをキュー長のメトリクスから差し引く
+func (s *Server) recordDequeue(waiter *slotWaiter) {
+	metrics.QueuedJobs.WithLabelValues(priorityName(waiter.priority), s.workerID).Dec()
+}
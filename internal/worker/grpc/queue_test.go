@@ -0,0 +1,328 @@
+package grpc
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nzws/flux-encoder/internal/shared/metrics"
+	"github.com/nzws/flux-encoder/internal/worker/encoder"
+	workerv1 "github.com/nzws/flux-encoder/proto/worker/v1"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func Test実行枠が満杯の場合はキューで待ち解放された枠を順番に受け取る(t *testing.T) {
+	server := NewServer(encoder.New(t.TempDir()), nil, 1, "test-worker", "0.0.0")
+	server.SetQueueSize(1)
+
+	if err := server.acquireSlot(context.Background(), mustParsePriority(t, PriorityNormal), func(int) { t.Error("空きがあるのに待機している") }); err != nil {
+		t.Fatalf("実行枠の確保に失敗: %v", err)
+	}
+
+	queued := make(chan int, 1)
+	acquired := make(chan error, 1)
+	go func() {
+		acquired <- server.acquireSlot(context.Background(), mustParsePriority(t, PriorityNormal), func(position int) { queued <- position })
+	}()
+	select {
+	case position := <-queued:
+		if position != 1 {
+			t.Errorf("待機の順番が一致しない: 期待値 1, 取得値 %d", position)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("キューで待機しない")
+	}
+
+	workerStatus, err := server.GetStatus(context.Background(), &workerv1.StatusRequest{})
+	if err != nil {
+		t.Fatalf("状態の取得に失敗: %v", err)
+	}
+	if workerStatus.QueuedJobs != 1 {
+		t.Errorf("待機中のジョブ数が一致しない: 期待値 1, 取得値 %d", workerStatus.QueuedJobs)
+	}
+
+	// キューも満杯の場合は待たずに拒否する
+	err = server.acquireSlot(context.Background(), mustParsePriority(t, PriorityNormal), func(int) { t.Error("キューが満杯なのに待機している") })
+	if code := statusCode(err); code != codes.ResourceExhausted {
+		t.Errorf("ResourceExhausted が返されない: %v", err)
+	}
+
+	server.releaseSlot()
+	select {
+	case err := <-acquired:
+		if err != nil {
+			t.Fatalf("待機していたジョブが実行枠を確保できない: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("解放した枠が待機中のジョブに渡されない")
+	}
+	if got := atomic.LoadInt32(&server.activeJobs); got != 1 {
+		t.Errorf("実行中のジョブ数が一致しない: 期待値 1, 取得値 %d", got)
+	}
+	if got := server.queuedJobs(); got != 0 {
+		t.Errorf("待機中のジョブ数が一致しない: 期待値 0, 取得値 %d", got)
+	}
+}
+
+func Testキューで待機中のジョブはストリームのキャンセルで取り除かれる(t *testing.T) {
+	// 同時実行数 0 で常にキューで待機させる
+	server := NewServer(encoder.New(t.TempDir()), nil, 0, "test-worker", "0.0.0")
+	server.SetQueueSize(1)
+	client := newTestClient(t, server)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream, err := client.SubmitJob(ctx, &workerv1.JobRequest{
+		JobId:    "queued-test",
+		InputUrl: "https://example.com/input.mp4",
+		Preset:   "720p_h264",
+		Output:   &workerv1.OutputConfig{Storage: "local", Path: "out.mp4"},
+	})
+	if err != nil {
+		t.Fatalf("ジョブの送信に失敗: %v", err)
+	}
+
+	progress, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("進捗の受信に失敗: %v", err)
+	}
+	if progress.Status != workerv1.JobStatus_JOB_STATUS_QUEUED || !strings.Contains(progress.Message, "position 1") || progress.QueuePosition != 1 {
+		t.Errorf("待機中の進捗が一致しない: %+v", progress)
+	}
+
+	cancel()
+	deadline := time.Now().Add(5 * time.Second)
+	for server.queuedJobs() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("キャンセルしたジョブがキューから取り除かれない")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := atomic.LoadInt32(&server.activeJobs); got != 0 {
+		t.Errorf("実行中のジョブ数が一致しない: 期待値 0, 取得値 %d", got)
+	}
+}
+
+func Testキューで待機中のジョブはCancelJobでキャンセルしキューから取り除かれる(t *testing.T) {
+	// 同時実行数 0 で常にキューで待機させる
+	server := NewServer(encoder.New(t.TempDir()), nil, 0, "test-worker", "0.0.0")
+	server.SetQueueSize(1)
+	client := newTestClient(t, server)
+
+	stream, err := client.SubmitJob(context.Background(), &workerv1.JobRequest{
+		JobId:    "queued-cancel-test",
+		InputUrl: "https://example.com/input.mp4",
+		Preset:   "720p_h264",
+		Output:   &workerv1.OutputConfig{Storage: "local", Path: "out.mp4"},
+	})
+	if err != nil {
+		t.Fatalf("ジョブの送信に失敗: %v", err)
+	}
+	progress, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("進捗の受信に失敗: %v", err)
+	}
+	if progress.Status != workerv1.JobStatus_JOB_STATUS_QUEUED {
+		t.Fatalf("待機中の進捗が一致しない: %+v", progress)
+	}
+
+	// 待機中のジョブにも再接続できる
+	attachCtx, cancelAttach := context.WithCancel(context.Background())
+	defer cancelAttach()
+	attached, err := client.AttachJob(attachCtx, &workerv1.AttachRequest{JobId: "queued-cancel-test"})
+	if err != nil {
+		t.Fatalf("AttachJob の呼び出しに失敗: %v", err)
+	}
+	progress, err = attached.Recv()
+	if err != nil {
+		t.Fatalf("待機中のジョブに再接続できない: %v", err)
+	}
+	if progress.QueuePosition != 1 {
+		t.Errorf("待機の順番が一致しない: 期待値 1, 取得値 %d", progress.QueuePosition)
+	}
+
+	resp, err := client.CancelJob(context.Background(), &workerv1.CancelRequest{JobId: "queued-cancel-test"})
+	if err != nil {
+		t.Fatalf("CancelJob の呼び出しに失敗: %v", err)
+	}
+	if !resp.Success {
+		t.Fatalf("待機中のジョブをキャンセルできない: %s", resp.Message)
+	}
+
+	if _, err := stream.Recv(); statusCode(err) != codes.Canceled {
+		t.Errorf("キャンセルしたジョブのストリームが Canceled で終了しない: %v", err)
+	}
+	if got := server.queuedJobs(); got != 0 {
+		t.Errorf("待機中のジョブ数が一致しない: 期待値 0, 取得値 %d", got)
+	}
+	if got := atomic.LoadInt32(&server.activeJobs); got != 0 {
+		t.Errorf("実行中のジョブ数が一致しない: 期待値 0, 取得値 %d", got)
+	}
+	workerStatus, err := server.GetStatus(context.Background(), &workerv1.StatusRequest{})
+	if err != nil {
+		t.Fatalf("状態の取得に失敗: %v", err)
+	}
+	if len(workerStatus.ActiveJobIds) != 0 {
+		t.Errorf("キャンセルしたジョブが残っている: %v", workerStatus.ActiveJobIds)
+	}
+}
+
+func Testキューでは優先度の高いジョブから実行枠を受け取る(t *testing.T) {
+	server := NewServer(encoder.New(t.TempDir()), nil, 1, "test-worker", "0.0.0")
+	server.SetQueueSize(5)
+
+	if err := server.acquireSlot(context.Background(), mustParsePriority(t, PriorityNormal), func(int) {}); err != nil {
+		t.Fatalf("実行枠の確保に失敗: %v", err)
+	}
+
+	// 優先度の異なるジョブを順に待機させる
+	jobs := []struct {
+		name     string
+		priority string
+		position int
+	}{
+		{name: "low-1", priority: PriorityLow, position: 1},
+		{name: "normal-1", priority: PriorityNormal, position: 1},
+		{name: "high-1", priority: PriorityHigh, position: 1},
+		{name: "normal-2", priority: "", position: 3},
+		{name: "high-2", priority: PriorityHigh, position: 2},
+	}
+	acquired := make(chan string, len(jobs))
+	for _, job := range jobs {
+		priority := mustParsePriority(t, job.priority)
+		queued := make(chan int, 1)
+		go func() {
+			if err := server.acquireSlot(context.Background(), priority, func(position int) { queued <- position }); err != nil {
+				t.Errorf("%s が実行枠を確保できない: %v", job.name, err)
+				return
+			}
+			acquired <- job.name
+		}()
+		select {
+		case position := <-queued:
+			if position != job.position {
+				t.Errorf("%s の待機の順番が一致しない: 期待値 %d, 取得値 %d", job.name, job.position, position)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%s がキューで待機しない", job.name)
+		}
+	}
+
+	// 枠を解放するたびに、優先度の高い順（同じ優先度の中では待ち始めた順）に受け取る
+	want := []string{"high-1", "high-2", "normal-1", "normal-2", "low-1"}
+	for _, name := range want {
+		server.releaseSlot()
+		select {
+		case got := <-acquired:
+			if got != name {
+				t.Errorf("実行枠を受け取ったジョブが一致しない: 期待値 %s, 取得値 %s", name, got)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%s に実行枠が渡されない", name)
+		}
+	}
+	if got := server.queuedJobs(); got != 0 {
+		t.Errorf("待機中のジョブ数が一致しない: 期待値 0, 取得値 %d", got)
+	}
+}
+
+func Testキューの長さと待ち時間が優先度ごとにメトリクスに記録される(t *testing.T) {
+	server := NewServer(encoder.New(t.TempDir()), nil, 1, "queue-metrics-worker", "0.0.0")
+	server.SetQueueSize(2)
+
+	if err := server.acquireSlot(context.Background(), mustParsePriority(t, PriorityNormal), func(int) {}); err != nil {
+		t.Fatalf("実行枠の確保に失敗: %v", err)
+	}
+
+	high := mustParsePriority(t, PriorityHigh)
+	low := mustParsePriority(t, PriorityLow)
+	highCount, _ := histogramSample(t, metrics.QueueWaitDuration.WithLabelValues(PriorityHigh, "queue-metrics-worker"))
+	lowCount, _ := histogramSample(t, metrics.QueueWaitDuration.WithLabelValues(PriorityLow, "queue-metrics-worker"))
+
+	highAcquired := make(chan error, 1)
+	highQueued := make(chan int, 1)
+	go func() {
+		highAcquired <- server.acquireSlot(context.Background(), high, func(position int) { highQueued <- position })
+	}()
+	lowCtx, cancelLow := context.WithCancel(context.Background())
+	lowAcquired := make(chan error, 1)
+	lowQueued := make(chan int, 1)
+	go func() {
+		lowAcquired <- server.acquireSlot(lowCtx, low, func(position int) { lowQueued <- position })
+	}()
+	for _, queued := range []chan int{highQueued, lowQueued} {
+		select {
+		case <-queued:
+		case <-time.After(5 * time.Second):
+			t.Fatal("キューで待機しない")
+		}
+	}
+
+	for _, priority := range []string{PriorityHigh, PriorityLow} {
+		if got := testutil.ToFloat64(metrics.QueuedJobs.WithLabelValues(priority, "queue-metrics-worker")); got != 1 {
+			t.Errorf("%s のキューの長さが一致しない: 期待値 1, 取得値 %v", priority, got)
+		}
+	}
+
+	// キャンセルされたジョブはキューの長さから差し引き、待ち時間には記録しない
+	cancelLow()
+	if err := <-lowAcquired; statusCode(err) != codes.Canceled {
+		t.Errorf("Canceled が返されない: %v", err)
+	}
+	if got := testutil.ToFloat64(metrics.QueuedJobs.WithLabelValues(PriorityLow, "queue-metrics-worker")); got != 0 {
+		t.Errorf("low のキューの長さが一致しない: 期待値 0, 取得値 %v", got)
+	}
+
+	server.releaseSlot()
+	if err := <-highAcquired; err != nil {
+		t.Fatalf("待機していたジョブが実行枠を確保できない: %v", err)
+	}
+	if got := testutil.ToFloat64(metrics.QueuedJobs.WithLabelValues(PriorityHigh, "queue-metrics-worker")); got != 0 {
+		t.Errorf("high のキューの長さが一致しない: 期待値 0, 取得値 %v", got)
+	}
+	if got, _ := histogramSample(t, metrics.QueueWaitDuration.WithLabelValues(PriorityHigh, "queue-metrics-worker")); got-highCount != 1 {
+		t.Errorf("high の待ち時間の記録数が一致しない: 期待値 1, 取得値 %d", got-highCount)
+	}
+	if got, _ := histogramSample(t, metrics.QueueWaitDuration.WithLabelValues(PriorityLow, "queue-metrics-worker")); got != lowCount {
+		t.Errorf("low の待ち時間が記録されている: 期待値 %d, 取得値 %d", lowCount, got)
+	}
+}
+
+func Test不正な優先度のジョブは拒否される(t *testing.T) {
+	client := newTestClient(t, NewServer(encoder.New(t.TempDir()), nil, 1, "test-worker", "0.0.0"))
+
+	stream, err := client.SubmitJob(context.Background(), &workerv1.JobRequest{
+		JobId:    "priority-test",
+		InputUrl: "https://example.com/input.mp4",
+		Preset:   "720p_h264",
+		Output:   &workerv1.OutputConfig{Storage: "local", Path: "out.mp4"},
+		Priority: "urgent",
+	})
+	if err != nil {
+		t.Fatalf("ジョブの送信に失敗: %v", err)
+	}
+	if _, err := stream.Recv(); statusCode(err) != codes.InvalidArgument {
+		t.Errorf("InvalidArgument が返されない: %v", err)
+	}
+}
+
+// mustParsePriority はジョブの優先度を parsePriority で変換する
+func mustParsePriority(t *testing.T, priority string) int {
+	t.Helper()
+
+	value, err := parsePriority(priority)
+	if err != nil {
+		t.Fatalf("優先度の変換に失敗: %v", err)
+	}
+	return value
+}
+
+// statusCode は gRPC のエラーコードを返す
+func statusCode(err error) codes.Code {
+	st, _ := status.FromError(err)
+	return st.Code()
+}
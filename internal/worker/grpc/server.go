@@ -3,21 +3,39 @@ package grpc
 import (
 	"context"
 	"fmt"
+	"io/fs"
 	"os"
+	"path"
+	"path/filepath"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/nzws/flux-encoder/internal/shared/grpccompress"
 	"github.com/nzws/flux-encoder/internal/shared/logger"
+	"github.com/nzws/flux-encoder/internal/shared/metrics"
+	"github.com/nzws/flux-encoder/internal/shared/retry"
 	"github.com/nzws/flux-encoder/internal/worker/encoder"
+	"github.com/nzws/flux-encoder/internal/worker/sysload"
 	"github.com/nzws/flux-encoder/internal/worker/uploader"
 	workerv1 "github.com/nzws/flux-encoder/proto/worker/v1"
 	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
 )
 
+// DefaultRetryAfter は容量超過でジョブを拒否した際に返す再試行までの待ち時間のデフォルト値
+const DefaultRetryAfter = 30 * time.Second
+
+// partialOutputCleanupTimeout はキャンセルされたジョブの途中までの出力の削除に使用するタイムアウト
+const partialOutputCleanupTimeout = time.Minute
+
 // Server は Worker の gRPC サーバー
 type Server struct {
 	workerv1.UnimplementedWorkerServiceServer
@@ -29,28 +47,79 @@ type Server struct {
 	maxConcurrent   int32
 	activeJobsMutex sync.RWMutex
 	activeJobIDs    map[string]context.CancelFunc
-
-	grpcServer *grpc.Server
-	workerID   string
-	version    string
+	// sessions は実行中のジョブの進捗ストリーム（AttachJob で再接続する際に使用する）
+	sessions      map[string]*jobSession
+	reattachGrace time.Duration
+
+	grpcServer   *grpc.Server
+	healthServer *health.Server
+	workerID     string
+	version      string
+	compression  string
+	retryAfter   time.Duration
+
+	incrementalUpload         bool
+	incrementalUploadInterval time.Duration
+
+	// capabilities は GetStatus で報告する ffmpeg のフィルター・エンコーダー（nil の場合は報告しない）
+	capabilities *workerv1.WorkerCapabilities
+	// loadSampler は GetStatus で報告するホストの負荷の取得元（nil の場合は報告しない）
+	loadSampler *sysload.Sampler
+	// allowRawArgs はジョブが直接指定する ffmpeg 引数（raw_ffmpeg_args）を受け付けるか
+	allowRawArgs bool
+	// metadataLimits はジョブの出力のメタデータ（OutputConfig.metadata）の件数・合計サイズの上限
+	metadataLimits uploader.MetadataLimits
+	// uploadVerifier はアップロードした HLS の出力を取得し直して検証する（nil の場合は検証しない）
+	uploadVerifier *uploader.UploadVerifier
+	// adminToken は GetJobLogs などの管理者用 RPC の認証に使用するトークン（空の場合は管理者用 RPC を拒否する）
+	adminToken string
+
+	// slotMutex は実行枠の確保・解放と待機中のジョブを保護する
+	slotMutex sync.Mutex
+	// waiters は実行枠が空くのを待っているジョブ（優先度の高い順、同じ優先度の中では待ち始めた順に並べ、先頭から順に枠を渡す）
+	waiters []*slotWaiter
+	// queueSize は waiters の上限（0 の場合は待たずに拒否する）
+	queueSize int
+
+	// idleMutex は自動停止の待ち時間とタイマーを保護する（idle.go を参照）
+	idleMutex sync.Mutex
+	// idleTimeout はジョブがなくなってから自動停止するまでの待ち時間（0 の場合は自動停止しない）
+	idleTimeout time.Duration
+	// autoShutdown は自動停止を行うか（false の場合は idleTimeout に関わらず自動停止しない）
+	autoShutdown bool
+	// idleTimer は自動停止までの待ち時間のタイマー（待っていない場合は nil）
+	idleTimer *time.Timer
+	// idleGeneration はタイマーを開始・停止するたびに進め、古いタイマーによる停止を防ぐ
+	idleGeneration uint64
+	// shuttingDown は自動停止を始めたか（以降のジョブは拒否する）
+	shuttingDown bool
+	// idleShutdown は自動停止の処理（デフォルトは Stop。SetIdleShutdownFunc で差し替える）
+	idleShutdown func()
 }
 
 // NewServer は新しい gRPC サーバーを作成する
 func NewServer(
 	encoder *encoder.Encoder,
-	uploader uploader.Uploader,
+	upl uploader.Uploader,
 	maxConcurrent int32,
 	workerID string,
 	version string,
 ) *Server {
-	return &Server{
-		encoder:       encoder,
-		uploader:      uploader,
-		maxConcurrent: maxConcurrent,
-		activeJobIDs:  make(map[string]context.CancelFunc),
-		workerID:      workerID,
-		version:       version,
+	s := &Server{
+		encoder:        encoder,
+		uploader:       upl,
+		maxConcurrent:  maxConcurrent,
+		activeJobIDs:   make(map[string]context.CancelFunc),
+		sessions:       make(map[string]*jobSession),
+		workerID:       workerID,
+		version:        version,
+		retryAfter:     DefaultRetryAfter,
+		reattachGrace:  DefaultReattachGrace,
+		autoShutdown:   true,
+		metadataLimits: uploader.DefaultMetadataLimits(),
 	}
+	s.idleShutdown = s.Stop
+	return s
 }
 
 // SetGRPCServer は gRPC サーバーインスタンスをセットする
@@ -58,37 +127,304 @@ func (s *Server) SetGRPCServer(server *grpc.Server) {
 	s.grpcServer = server
 }
 
+// SetHealthServer は gRPC ヘルスチェックサーバーをセットし、Worker 全体とサービスの状態を SERVING にする
+func (s *Server) SetHealthServer(server *health.Server) {
+	s.healthServer = server
+	server.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+	server.SetServingStatus(workerv1.WorkerService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+}
+
+// Stop はヘルスチェックの状態を NOT_SERVING にしてから gRPC サーバーを停止する
+// 停止を待つ間に Control Plane やオーケストレーターが新しいジョブを振り分けないようにする
+func (s *Server) Stop() {
+	if s.healthServer != nil {
+		s.healthServer.Shutdown()
+	}
+	if s.grpcServer != nil {
+		s.grpcServer.GracefulStop()
+	}
+}
+
+// SetCompression は進捗ストリームの送信に使用する圧縮方式を設定する
+// 空文字・"none" の場合はクライアントのリクエストに合わせる（gRPC のデフォルト動作）
+func (s *Server) SetCompression(name string) error {
+	normalized, err := grpccompress.Normalize(name)
+	if err != nil {
+		return err
+	}
+	s.compression = normalized
+	return nil
+}
+
+// SetRetryAfter は容量超過でジョブを拒否した際にクライアントへ返す再試行までの待ち時間を設定する
+func (s *Server) SetRetryAfter(d time.Duration) {
+	s.retryAfter = d
+}
+
+// SetReattachGrace は進捗ストリームが切断されてから AttachJob による再接続を待つ期間を設定する
+// 期間内に再接続されない場合はジョブをキャンセルする
+func (s *Server) SetReattachGrace(d time.Duration) {
+	s.reattachGrace = d
+}
+
+// SetIncrementalUpload は HLS 出力をエンコード中に逐次アップロードするかを設定する
+// 有効な場合、interval ごとに出力ディレクトリを確認し、完成したセグメントとプレイリストをアップロードする
+// interval が 0 以下の場合は uploader.DefaultIncrementalUploadInterval を使用する
+func (s *Server) SetIncrementalUpload(enabled bool, interval time.Duration) {
+	s.incrementalUpload = enabled
+	s.incrementalUploadInterval = interval
+}
+
+// SetCapabilities は GetStatus で報告する ffmpeg のフィルター・エンコーダーを設定する
+// Control Plane はジョブに必要なフィルター・エンコーダーを持たない Worker にジョブを送信しない
+func (s *Server) SetCapabilities(capabilities encoder.Capabilities) {
+	s.capabilities = &workerv1.WorkerCapabilities{
+		Filters:  capabilities.Filters,
+		Encoders: capabilities.Encoders,
+	}
+}
+
+// SetAllowRawArgs はジョブが直接指定する ffmpeg 引数（raw_ffmpeg_args）を受け付けるかを設定する
+// 生の引数は Worker のファイルを読み書きできるため、ジョブを投入できる利用者を信頼できる場合のみ有効にする
+func (s *Server) SetAllowRawArgs(allow bool) {
+	s.allowRawArgs = allow
+}
+
+// SetMetadataLimits はジョブの出力のメタデータの件数・合計サイズの上限を設定する（0 以下の項目は制限しない）
+func (s *Server) SetMetadataLimits(limits uploader.MetadataLimits) {
+	s.metadataLimits = limits
+}
+
+// SetUploadVerifier はアップロードした HLS の出力を完了前に取得し直して検証する UploadVerifier を設定する
+func (s *Server) SetUploadVerifier(verifier *uploader.UploadVerifier) {
+	s.uploadVerifier = verifier
+}
+
+// SetLoadSampler は GetStatus で報告するホストの負荷の取得元を設定する
+// 負荷の取得は sampler.Run でバックグラウンドで行い、GetStatus は最新の値を返すのみ
+func (s *Server) SetLoadSampler(sampler *sysload.Sampler) {
+	s.loadSampler = sampler
+}
+
+// hostLoad は GetStatus で報告するホストの負荷を返す（取得元がない場合や未取得の場合は nil）
+func (s *Server) hostLoad() *workerv1.HostLoad {
+	if s.loadSampler == nil {
+		return nil
+	}
+	load, ok := s.loadSampler.Latest()
+	if !ok {
+		return nil
+	}
+	return &workerv1.HostLoad{
+		CpuPercent:       load.CPUPercent,
+		MemoryPercent:    load.MemoryPercent,
+		MemoryUsedBytes:  load.MemoryUsedBytes,
+		MemoryTotalBytes: load.MemoryTotalBytes,
+		GpuAvailable:     load.GPUAvailable,
+		GpuPercent:       load.GPUPercent,
+		SampledAtUnixMs:  load.SampledAt.UnixMilli(),
+	}
+}
+
+// capacityExceededError は容量超過を表す ResourceExhausted エラーを返す
+// 再試行までの待ち時間を RetryInfo としてエラー詳細に含める
+func (s *Server) capacityExceededError(current int32) error {
+	st := status.Newf(codes.ResourceExhausted, "worker is at maximum capacity (%d/%d)", current, s.maxConcurrent)
+	if s.retryAfter <= 0 {
+		return st.Err()
+	}
+
+	detailed, err := st.WithDetails(&errdetails.RetryInfo{
+		RetryDelay: durationpb.New(s.retryAfter),
+	})
+	if err != nil {
+		logger.Warn("Failed to attach retry info to capacity error", zap.Error(err))
+		return st.Err()
+	}
+	return detailed.Err()
+}
+
 // SubmitJob はジョブを受け付けて処理する
 func (s *Server) SubmitJob(req *workerv1.JobRequest, stream workerv1.WorkerService_SubmitJobServer) error {
 	ctx := stream.Context()
 
+	// 進捗ストリームの圧縮（クライアントが未対応の場合は非圧縮で送信）
+	if s.compression != "" {
+		if err := grpc.SetSendCompressor(ctx, s.compression); err != nil {
+			logger.Warn("Failed to enable grpc compression, sending uncompressed",
+				zap.String("job_id", req.JobId),
+				zap.String("compression", s.compression),
+				zap.Error(err),
+			)
+		}
+	}
+
 	logger.Info("Received job",
 		zap.String("job_id", req.JobId),
 		zap.String("input_url", req.InputUrl),
 		zap.String("preset", req.Preset),
+		zap.Int("raw_ffmpeg_args", len(req.RawFfmpegArgs)),
 	)
 
-	// 同時実行数チェック
-	current := atomic.LoadInt32(&s.activeJobs)
-	if current >= s.maxConcurrent {
-		return status.Errorf(codes.ResourceExhausted, "worker is at maximum capacity (%d/%d)", current, s.maxConcurrent)
+	// 生の ffmpeg 引数は明示的に有効にした Worker のみ受け付ける（実行枠を確保する前に拒否する）
+	if len(req.RawFfmpegArgs) > 0 {
+		if !s.allowRawArgs {
+			logger.Warn("Rejected job with raw ffmpeg args", zap.String("job_id", req.JobId))
+			return status.Error(codes.PermissionDenied, "raw ffmpeg args are not allowed on this worker (WORKER_ALLOW_RAW_ARGS=true is required)")
+		}
+		if err := encoder.ValidateRawArgs(req.RawFfmpegArgs); err != nil {
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
 	}
 
-	// ジョブ開始
-	atomic.AddInt32(&s.activeJobs, 1)
+	// ジョブごとのリトライ設定（不正な場合はジョブを開始せずに失敗させる）
+	retryConfig, err := retryConfigFromPolicy(req.Retry)
+	if err != nil {
+		return stream.Send(&workerv1.JobProgress{
+			JobId:     req.JobId,
+			Status:    workerv1.JobStatus_JOB_STATUS_FAILED,
+			Message:   "Invalid retry policy",
+			Error:     err.Error(),
+			Timestamp: time.Now().Format(time.RFC3339),
+		})
+	}
+
+	// 切り出し範囲（不正な場合はジョブを開始せずに失敗させる）
+	clip, err := encoder.ParseClip(req.StartTime, req.Duration)
+	if err != nil {
+		return stream.Send(&workerv1.JobProgress{
+			JobId:     req.JobId,
+			Status:    workerv1.JobStatus_JOB_STATUS_FAILED,
+			Message:   "Invalid clip range",
+			Error:     err.Error(),
+			Timestamp: time.Now().Format(time.RFC3339),
+		})
+	}
+
+	// 出力の検証の設定（不正な場合はジョブを開始せずに失敗させる）
+	validationSettings := validationSettingsFromConfig(req.Validation)
+	if err := validationSettings.Validate(); err != nil {
+		return stream.Send(&workerv1.JobProgress{
+			JobId:     req.JobId,
+			Status:    workerv1.JobStatus_JOB_STATUS_FAILED,
+			Message:   "Invalid validation settings",
+			Error:     err.Error(),
+			Timestamp: time.Now().Format(time.RFC3339),
+		})
+	}
+
+	// ジョブごとの SSE-KMS の KMS キー（不正な場合はジョブを開始せずに失敗させる）
+	kmsKeyID := req.GetOutput().GetKmsKeyId()
+	if kmsKeyID != "" {
+		if err := uploader.ValidateKMSKeyID(kmsKeyID); err != nil {
+			return stream.Send(&workerv1.JobProgress{
+				JobId:     req.JobId,
+				Status:    workerv1.JobStatus_JOB_STATUS_FAILED,
+				Message:   "Invalid KMS key id",
+				Error:     err.Error(),
+				Timestamp: time.Now().Format(time.RFC3339),
+			})
+		}
+	}
+
+	// 出力のメタデータ（上限を超える場合はジョブを開始せずに失敗させ、キーは S3 で使える文字に変換する）
+	metadata, err := uploader.SanitizeMetadata(req.GetOutput().GetMetadata(), s.metadataLimits)
+	if err != nil {
+		return stream.Send(&workerv1.JobProgress{
+			JobId:     req.JobId,
+			Status:    workerv1.JobStatus_JOB_STATUS_FAILED,
+			Message:   "Invalid output metadata",
+			Error:     err.Error(),
+			Timestamp: time.Now().Format(time.RFC3339),
+		})
+	}
+	if req.Output != nil {
+		req.Output.Metadata = metadata
+	}
+
+	// キューで実行枠を待つ順番を決める優先度（不正な場合は実行枠を確保する前に拒否する）
+	priority, err := parsePriority(req.Priority)
+	if err != nil {
+		return err
+	}
+
+	// 自動停止の待ち時間中であれば停止を取りやめる
+	if err := s.cancelIdleShutdown(); err != nil {
+		return err
+	}
 
 	// キャンセル可能なコンテキスト作成
-	jobCtx, cancel := context.WithCancel(ctx)
+	// ストリームが一時的に切断されても AttachJob で再接続できるよう、ストリームのキャンセルは引き継がない
+	jobCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancel()
+	if req.Retry != nil {
+		// アップロードのリトライに使用する
+		jobCtx = retry.WithConfig(jobCtx, retryConfig)
+	}
+	if kmsKeyID != "" {
+		// S3 へのアップロードで Worker の既定の KMS キーより優先する
+		jobCtx = uploader.WithSSEKMSKeyID(jobCtx, kmsKeyID)
+	}
+	// キャンセル時に途中までの出力を削除できるよう、アップロードしたパスを記録する
+	tracker := uploader.NewUploadTracker()
+	jobCtx = uploader.WithUploadTracker(jobCtx, tracker)
+
+	// キューで待っている間も CancelJob・AttachJob で扱えるよう、実行枠を確保する前に登録する
+	// 待機中はストリームの切断か CancelJob で待つのをやめ、キューから取り除く
+	waitCtx, cancelWait := context.WithCancel(ctx)
+	defer cancelWait()
+	session := newJobSession(req.JobId, stream)
 	s.activeJobsMutex.Lock()
-	s.activeJobIDs[req.JobId] = cancel
+	s.activeJobIDs[req.JobId] = func() {
+		cancelWait()
+		cancel()
+	}
+	s.sessions[req.JobId] = session
 	s.activeJobsMutex.Unlock()
 
+	// 同時実行数チェック（空きがない場合はキューに空きがあれば枠が空くまで待つ）
+	err = s.acquireSlot(waitCtx, priority, func(position int) {
+		logger.Info("Job queued until a slot frees",
+			zap.String("job_id", req.JobId),
+			zap.String("priority", req.Priority),
+			zap.Int("position", position),
+		)
+		session.send(&workerv1.JobProgress{
+			JobId:         req.JobId,
+			Status:        workerv1.JobStatus_JOB_STATUS_QUEUED,
+			Progress:      0,
+			Message:       fmt.Sprintf("Waiting for a free slot (position %d)", position),
+			Timestamp:     time.Now().Format(time.RFC3339),
+			QueuePosition: int32(position),
+		})
+	})
+	if err != nil {
+		s.activeJobsMutex.Lock()
+		delete(s.activeJobIDs, req.JobId)
+		delete(s.sessions, req.JobId)
+		s.activeJobsMutex.Unlock()
+		// AttachJob で再接続しているストリームも終了させる
+		close(session.done)
+
+		// 枠を確保できなかった場合も、ジョブがなければ自動停止を待ち直す
+		s.scheduleIdleShutdown()
+		return err
+	}
+
+	// ジョブ開始
+	metrics.ActiveJobs.WithLabelValues(s.workerID).Inc()
+
+	// 猶予期間内に再接続されなければジョブをキャンセルする
+	go session.watch(s.reattachGrace, cancel)
+
 	defer func() {
-		// ジョブ終了処理
-		atomic.AddInt32(&s.activeJobs, -1)
+		// ジョブ終了処理（待っているジョブがあれば枠を渡す）
+		s.releaseSlot()
+		metrics.ActiveJobs.WithLabelValues(s.workerID).Dec()
 
 		s.activeJobsMutex.Lock()
 		delete(s.activeJobIDs, req.JobId)
+		delete(s.sessions, req.JobId)
 		s.activeJobsMutex.Unlock()
 
 		// クリーンアップ
@@ -99,90 +435,116 @@ func (s *Server) SubmitJob(req *workerv1.JobRequest, stream workerv1.WorkerServi
 			)
 		}
 
-		// ジョブがなくなったら自動停止（環境変数で無効化可能）
-		newCount := atomic.LoadInt32(&s.activeJobs)
-		if newCount == 0 {
-			disableAutoShutdown := os.Getenv("DISABLE_AUTO_SHUTDOWN")
-			if disableAutoShutdown != "true" && disableAutoShutdown != "1" {
-				go s.gracefulShutdown()
-			} else {
-				logger.Info("Auto shutdown is disabled (DISABLE_AUTO_SHUTDOWN is set)")
-			}
+		// ジョブがなくなったら WORKER_IDLE_TIMEOUT の間ジョブを受け付けなければ自動停止（SetAutoShutdown で無効化可能）
+		if atomic.LoadInt32(&s.activeJobs) == 0 {
+			s.scheduleIdleShutdown()
 		}
 	}()
 
 	// キュー状態を通知
-	if err := stream.Send(&workerv1.JobProgress{
+	session.send(&workerv1.JobProgress{
 		JobId:     req.JobId,
 		Status:    workerv1.JobStatus_JOB_STATUS_QUEUED,
 		Progress:  0,
 		Message:   "Job queued",
 		Timestamp: time.Now().Format(time.RFC3339),
-	}); err != nil {
-		return err
-	}
+	})
 
 	// エンコード開始
-	if err := stream.Send(&workerv1.JobProgress{
+	session.send(&workerv1.JobProgress{
 		JobId:     req.JobId,
 		Status:    workerv1.JobStatus_JOB_STATUS_PROCESSING,
 		Progress:  0,
 		Message:   "Starting encoding",
 		Timestamp: time.Now().Format(time.RFC3339),
-	}); err != nil {
-		return err
+	})
+
+	opts := encodeOptions(req, clip, validationSettings)
+	// 再現や監査のため、実行した ffmpeg のコマンドを認証情報を取り除いて最終の進捗に含める（2パスの場合は2パス目）
+	var ffmpegCommand []string
+	opts.OnCommand = func(command []string) {
+		ffmpegCommand = encoder.RedactCommand(command)
+	}
+	presetLabel := req.Preset
+	if len(req.RawFfmpegArgs) > 0 {
+		presetLabel = "raw"
+	}
+
+	// 逐次アップロード（エンコード完了前に再生を開始できるよう、完成したセグメントから順にアップロードする）
+	var incremental *uploader.IncrementalUploader
+	stopIncremental := func() {}
+	if s.incrementalUpload {
+		if dir, ok := s.encoder.IncrementalUploadDir(req.JobId, req.Preset, opts); ok {
+			incremental = uploader.NewIncrementalUploader(s.uploader, dir, req.Output.Path, s.incrementalUploadInterval)
+			watchCtx, stopWatch := context.WithCancel(jobCtx)
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				incremental.Run(watchCtx)
+			}()
+			stopIncremental = func() {
+				stopWatch()
+				<-done
+			}
+			logger.Info("Incremental upload enabled",
+				zap.String("job_id", req.JobId),
+				zap.String("dir", dir),
+			)
+		}
 	}
 
 	// エンコード実行
-	outputPath, err := s.encoder.Encode(
+	encodeStart := time.Now()
+	outputPath, err := s.encoder.EncodeWithOptions(
 		jobCtx,
 		req.JobId,
 		req.InputUrl,
 		req.Preset,
+		opts,
 		func(progress float32, message string) {
-			// 進捗を通知（送信失敗時はエンコードをキャンセル）
-			if sendErr := stream.Send(&workerv1.JobProgress{
+			// 進捗を通知（切断中は再接続時に最新の進捗を送信する）
+			session.send(&workerv1.JobProgress{
 				JobId:     req.JobId,
 				Status:    workerv1.JobStatus_JOB_STATUS_PROCESSING,
 				Progress:  progress,
 				Message:   message,
 				Timestamp: time.Now().Format(time.RFC3339),
-			}); sendErr != nil {
-				logger.Warn("Failed to send progress, cancelling job",
-					zap.String("job_id", req.JobId),
-					zap.Error(sendErr),
-				)
-				cancel()
-			}
+			})
 		},
 	)
+	stopIncremental()
 
 	if err != nil {
 		logger.Error("Encoding failed",
 			zap.String("job_id", req.JobId),
+			zap.Strings("ffmpeg_command", ffmpegCommand),
 			zap.Error(err),
 		)
-
-		return stream.Send(&workerv1.JobProgress{
-			JobId:     req.JobId,
-			Status:    workerv1.JobStatus_JOB_STATUS_FAILED,
-			Progress:  0,
-			Message:   "Encoding failed",
-			Error:     err.Error(),
-			Timestamp: time.Now().Format(time.RFC3339),
+		// 逐次アップロード済みのセグメントと終了していないプレイリストが再生できる状態で残らないよう削除する
+		s.cleanupPartialOutput(req, tracker)
+
+		return s.finishJob(session, &workerv1.JobProgress{
+			JobId:         req.JobId,
+			Status:        workerv1.JobStatus_JOB_STATUS_FAILED,
+			Progress:      0,
+			Message:       "Encoding failed",
+			Error:         err.Error(),
+			ErrorCode:     string(encoder.ErrorCodeOf(err)),
+			FfmpegCommand: ffmpegCommand,
+			Timestamp:     time.Now().Format(time.RFC3339),
 		})
 	}
 
+	metrics.EncodingDuration.WithLabelValues(presetLabel, s.workerID).Observe(time.Since(encodeStart).Seconds())
+
 	// アップロード開始
-	if err := stream.Send(&workerv1.JobProgress{
+	session.send(&workerv1.JobProgress{
 		JobId:     req.JobId,
 		Status:    workerv1.JobStatus_JOB_STATUS_UPLOADING,
 		Progress:  100,
 		Message:   "Uploading output",
 		Timestamp: time.Now().Format(time.RFC3339),
-	}); err != nil {
-		return err
-	}
+	})
 
 	// アップロード実行（ファイルまたはディレクトリ）
 	var outputURL string
@@ -193,8 +555,9 @@ func (s *Server) SubmitJob(req *workerv1.JobRequest, stream workerv1.WorkerServi
 			zap.String("path", outputPath),
 			zap.Error(err),
 		)
+		s.cleanupPartialOutput(req, tracker)
 
-		return stream.Send(&workerv1.JobProgress{
+		return s.finishJob(session, &workerv1.JobProgress{
 			JobId:     req.JobId,
 			Status:    workerv1.JobStatus_JOB_STATUS_FAILED,
 			Progress:  100,
@@ -204,20 +567,34 @@ func (s *Server) SubmitJob(req *workerv1.JobRequest, stream workerv1.WorkerServi
 		})
 	}
 
-	if fileInfo.IsDir() {
+	uploadStart := time.Now()
+	var uploadedFiles []uploader.UploadedFile
+	if fileInfo.IsDir() && incremental != nil {
+		// 逐次アップロード済みのファイルを除いて残りをアップロード（書き換えられたプレイリストを含む）
+		outputURL, uploadedFiles, err = incremental.Finish(jobCtx)
+	} else if fileInfo.IsDir() {
 		// ディレクトリアップロード
-		outputURL, err = s.uploader.UploadDirectory(jobCtx, outputPath, req.Output.Path)
+		outputURL, uploadedFiles, err = s.uploader.UploadDirectory(jobCtx, outputPath, req.Output.Path)
 	} else {
-		// 単一ファイルアップロード
+		// 単一ファイルアップロード（一覧のパスは出力ファイルのディレクトリからの相対パス）
 		outputURL, err = s.uploader.Upload(jobCtx, outputPath, req.Output.Path)
+		if err == nil {
+			uploadedFiles = []uploader.UploadedFile{{Path: path.Base(req.Output.Path), URL: outputURL}}
+			var thumbnail *uploader.UploadedFile
+			thumbnail, err = s.uploadThumbnail(jobCtx, outputPath, req.Output.Path)
+			if thumbnail != nil {
+				uploadedFiles = append(uploadedFiles, *thumbnail)
+			}
+		}
 	}
 	if err != nil {
 		logger.Error("Upload failed",
 			zap.String("job_id", req.JobId),
 			zap.Error(err),
 		)
+		s.cleanupPartialOutput(req, tracker)
 
-		return stream.Send(&workerv1.JobProgress{
+		return s.finishJob(session, &workerv1.JobProgress{
 			JobId:     req.JobId,
 			Status:    workerv1.JobStatus_JOB_STATUS_FAILED,
 			Progress:  100,
@@ -227,22 +604,197 @@ func (s *Server) SubmitJob(req *workerv1.JobRequest, stream workerv1.WorkerServi
 		})
 	}
 
+	s.observeUpload(req.JobId, outputPath, time.Since(uploadStart))
+
+	// アップロードした HLS が公開された URL から取得できるかを検証する（権限の設定漏れなどを完了前に検出する）
+	if s.uploadVerifier != nil && fileInfo.IsDir() && strings.HasSuffix(outputURL, ".m3u8") {
+		if err := s.uploadVerifier.VerifyHLS(jobCtx, outputURL); err != nil {
+			logger.Error("Upload verification failed",
+				zap.String("job_id", req.JobId),
+				zap.String("output_url", outputURL),
+				zap.Error(err),
+			)
+			s.cleanupPartialOutput(req, tracker)
+
+			return s.finishJob(session, &workerv1.JobProgress{
+				JobId:     req.JobId,
+				Status:    workerv1.JobStatus_JOB_STATUS_FAILED,
+				Progress:  100,
+				Message:   "Upload verification failed",
+				Error:     err.Error(),
+				Timestamp: time.Now().Format(time.RFC3339),
+			})
+		}
+	}
+
 	// 完了通知
 	logger.Info("Job completed",
 		zap.String("job_id", req.JobId),
 		zap.String("output_url", outputURL),
+		zap.Strings("ffmpeg_command", ffmpegCommand),
 	)
 
-	return stream.Send(&workerv1.JobProgress{
-		JobId:     req.JobId,
-		Status:    workerv1.JobStatus_JOB_STATUS_COMPLETED,
-		Progress:  100,
-		Message:   "Job completed",
-		OutputUrl: outputURL,
-		Timestamp: time.Now().Format(time.RFC3339),
+	return s.finishJob(session, &workerv1.JobProgress{
+		JobId:         req.JobId,
+		Status:        workerv1.JobStatus_JOB_STATUS_COMPLETED,
+		Progress:      100,
+		Message:       "Job completed",
+		OutputUrl:     outputURL,
+		OutputFiles:   outputFilesToProto(uploadedFiles),
+		FfmpegCommand: ffmpegCommand,
+		Timestamp:     time.Now().Format(time.RFC3339),
 	})
 }
 
+// outputFilesToProto はアップロードしたファイルの一覧を完了通知に含める形式に変換する
+func outputFilesToProto(files []uploader.UploadedFile) []*workerv1.OutputFile {
+	outputFiles := make([]*workerv1.OutputFile, 0, len(files))
+	for _, file := range files {
+		outputFiles = append(outputFiles, &workerv1.OutputFile{Path: file.Path, Url: file.URL})
+	}
+	return outputFiles
+}
+
+// encodeOptions はジョブのリクエストからエンコードのオプションを作成する
+func encodeOptions(req *workerv1.JobRequest, clip encoder.Clip, validation encoder.ValidationSettings) encoder.Options {
+	return encoder.Options{
+		Speed:         req.Speed,
+		StreamCopy:    req.StreamCopy,
+		Overrides:     req.Overrides,
+		SegmentLayout: req.SegmentLayout,
+		HLSInitPath:   req.HlsInitPath,
+		HLSKeyPath:    req.HlsKeyPath,
+		SubtitlePath:  req.SubtitlePath,
+		RawArgs:       req.RawFfmpegArgs,
+		RawExtension:  path.Ext(req.GetOutput().GetPath()),
+		Clip:          clip,
+		SkipPreflight: req.SkipPreflight,
+		Validation:    validation,
+	}
+}
+
+// finishJob はジョブの最終の進捗を送信してセッションを終了する
+func (s *Server) finishJob(session *jobSession, progress *workerv1.JobProgress) error {
+	switch progress.Status {
+	case workerv1.JobStatus_JOB_STATUS_COMPLETED:
+		metrics.JobsTotal.WithLabelValues("completed").Inc()
+	case workerv1.JobStatus_JOB_STATUS_FAILED:
+		metrics.JobsTotal.WithLabelValues("failed").Inc()
+	}
+	session.finish(progress, s.reattachGrace)
+	return nil
+}
+
+// observeUpload はジョブの出力のアップロード時間とサイズ（ディレクトリの場合は合計）をメトリクスに記録し、スループットをログに出力する
+func (s *Server) observeUpload(jobID, outputPath string, elapsed time.Duration) {
+	storageType := storageTypeOf(s.uploader)
+	metrics.UploadDuration.WithLabelValues(storageType, s.workerID).Observe(elapsed.Seconds())
+
+	size, err := outputSize(outputPath)
+	if err != nil {
+		logger.Warn("Failed to measure output size", zap.String("job_id", jobID), zap.Error(err))
+		return
+	}
+	metrics.UploadSize.WithLabelValues(storageType, s.workerID).Observe(float64(size))
+
+	logger.Info("Output uploaded",
+		zap.String("job_id", jobID),
+		zap.String("storage_type", storageType),
+		zap.Int64("size", size),
+		zap.Duration("elapsed", elapsed),
+		zap.Float64("throughput_mbps", uploader.ThroughputMBps(size, elapsed)),
+	)
+}
+
+// storageTypeOf はメトリクスのラベルに使用するアップロード先のストレージタイプを返す
+func storageTypeOf(u uploader.Uploader) string {
+	switch u.(type) {
+	case *uploader.S3Uploader:
+		return "s3"
+	case *uploader.GCSUploader:
+		return "gcs"
+	case *uploader.LocalUploader:
+		return "local"
+	default:
+		return "unknown"
+	}
+}
+
+// outputSize はアップロードした出力の合計サイズ（ディレクトリの場合は含まれるファイルの合計）を返す
+func outputSize(outputPath string) (int64, error) {
+	var total int64
+	err := filepath.WalkDir(outputPath, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		total += info.Size()
+		return nil
+	})
+	return total, err
+}
+
+// retryConfigFromPolicy はジョブのリトライ設定を retry.Config に変換する（nil の場合は既定値）
+func retryConfigFromPolicy(policy *workerv1.RetryPolicy) (retry.Config, error) {
+	if policy == nil {
+		return retry.DefaultConfig, nil
+	}
+	return retry.DefaultConfig.WithOverrides(int(policy.MaxAttempts), policy.InitialWaitMs, policy.MaxWaitMs)
+}
+
+// validationSettingsFromConfig はジョブごとの検証の設定をエンコーダーの設定に変換する（nil の場合は Worker の既定値を使用する）
+func validationSettingsFromConfig(config *workerv1.ValidationConfig) encoder.ValidationSettings {
+	if config == nil {
+		return encoder.ValidationSettings{}
+	}
+	return encoder.ValidationSettings{
+		Level:              config.Level,
+		Timeout:            time.Duration(config.TimeoutSeconds) * time.Second,
+		SkipDecodeTest:     config.SkipDecodeTest,
+		HLSValidationDepth: config.HlsValidationDepth,
+	}
+}
+
+// AttachJob は実行中のジョブの進捗ストリームに再接続する
+// 最新の進捗を送信した後、ジョブが終了するか再接続したストリームが切断されるまで進捗を送信する
+func (s *Server) AttachJob(req *workerv1.AttachRequest, stream workerv1.WorkerService_AttachJobServer) error {
+	ctx := stream.Context()
+
+	s.activeJobsMutex.RLock()
+	session, exists := s.sessions[req.JobId]
+	s.activeJobsMutex.RUnlock()
+
+	if !exists {
+		return status.Errorf(codes.NotFound, "job not found: %s", req.JobId)
+	}
+
+	if s.compression != "" {
+		if err := grpc.SetSendCompressor(ctx, s.compression); err != nil {
+			logger.Warn("Failed to enable grpc compression, sending uncompressed",
+				zap.String("job_id", req.JobId),
+				zap.String("compression", s.compression),
+				zap.Error(err),
+			)
+		}
+	}
+
+	if err := session.attach(stream); err != nil {
+		return err
+	}
+
+	logger.Info("Reattached to job", zap.String("job_id", req.JobId))
+
+	select {
+	case <-session.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 // GetStatus は Worker の現在の状態を返す
 func (s *Server) GetStatus(ctx context.Context, req *workerv1.StatusRequest) (*workerv1.WorkerStatus, error) {
 	s.activeJobsMutex.RLock()
@@ -258,6 +810,9 @@ func (s *Server) GetStatus(ctx context.Context, req *workerv1.StatusRequest) (*w
 		ActiveJobIds:      jobIDs,
 		WorkerId:          s.workerID,
 		Version:           s.version,
+		Capabilities:      s.capabilities,
+		QueuedJobs:        s.queuedJobs(),
+		HostLoad:          s.hostLoad(),
 	}, nil
 }
 
@@ -286,19 +841,47 @@ func (s *Server) CancelJob(ctx context.Context, req *workerv1.CancelRequest) (*w
 	}, nil
 }
 
-// gracefulShutdown はジョブがなくなったときに自動停止する
-func (s *Server) gracefulShutdown() {
-	// 少し待機（新しいジョブが来る可能性）
-	time.Sleep(1 * time.Second)
+// cleanupPartialOutput はキャンセル・失敗したジョブでアップロード済みのオブジェクトを削除する
+// keep_partial_output が指定された場合は何もしない
+func (s *Server) cleanupPartialOutput(req *workerv1.JobRequest, tracker *uploader.UploadTracker) {
+	if req.KeepPartialOutput || len(tracker.Paths()) == 0 {
+		return
+	}
 
-	// まだジョブがないことを確認
-	if atomic.LoadInt32(&s.activeJobs) == 0 {
-		logger.Info("No active jobs, shutting down worker...")
+	// ジョブのコンテキストはキャンセル済みの場合があるため、別のコンテキストで削除する
+	ctx, cancel := context.WithTimeout(context.Background(), partialOutputCleanupTimeout)
+	defer cancel()
 
-		if s.grpcServer != nil {
-			s.grpcServer.GracefulStop()
+	deleted, err := tracker.DeleteAll(ctx, s.uploader)
+	if err != nil {
+		logger.Error("Failed to delete partial output",
+			zap.String("job_id", req.JobId),
+			zap.Int("deleted", deleted),
+			zap.Error(err),
+		)
+		return
+	}
+	logger.Info("Deleted partial output of failed job",
+		zap.String("job_id", req.JobId),
+		zap.Int("deleted", deleted),
+	)
+}
+
+// uploadThumbnail は単一ファイル出力と一緒に生成されたサムネイルを出力先と同じディレクトリにアップロードする
+// サムネイルが生成されていない場合は何もせず nil を返す
+func (s *Server) uploadThumbnail(ctx context.Context, outputPath, remoteOutputPath string) (*uploader.UploadedFile, error) {
+	localPath := filepath.Join(filepath.Dir(outputPath), encoder.ThumbnailFileName)
+	if _, err := os.Stat(localPath); err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
 		}
+		return nil, fmt.Errorf("failed to stat thumbnail: %w", err)
+	}
 
-		os.Exit(0)
+	remotePath := path.Join(path.Dir(remoteOutputPath), encoder.ThumbnailFileName)
+	url, err := s.uploader.Upload(ctx, localPath, remotePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload thumbnail: %w", err)
 	}
+	return &uploader.UploadedFile{Path: encoder.ThumbnailFileName, URL: url}, nil
 }
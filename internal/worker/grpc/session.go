@@ -0,0 +1,162 @@
+package grpc
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/nzws/flux-encoder/internal/shared/logger"
+	workerv1 "github.com/nzws/flux-encoder/proto/worker/v1"
+	"go.uber.org/zap"
+)
+
+// DefaultReattachGrace は進捗ストリームが切断されてから AttachJob による再接続を待つ期間のデフォルト値
+const DefaultReattachGrace = 30 * time.Second
+
+// progressStream は進捗の送信先のストリーム（SubmitJob / AttachJob のストリーム）
+type progressStream interface {
+	Send(*workerv1.JobProgress) error
+	Context() context.Context
+}
+
+// jobSession は実行中のジョブの進捗の送信先を管理する
+// Control Plane とのストリームが一時的に切断されても、猶予期間内に AttachJob で再接続されればジョブを継続する
+type jobSession struct {
+	jobID string
+
+	mu sync.Mutex
+	// stream は現在の送信先。nil の場合は切断中
+	stream progressStream
+	// last は最後に送信しようとした進捗（再接続時に最初に送信する）
+	last *workerv1.JobProgress
+	// reattached は再接続時に close される（再接続のたびに作り直す）
+	reattached chan struct{}
+	// done はジョブの最終の進捗を送信し終えたときに close される
+	done chan struct{}
+}
+
+// newJobSession は新しい jobSession を作成する
+func newJobSession(jobID string, stream progressStream) *jobSession {
+	return &jobSession{
+		jobID:      jobID,
+		stream:     stream,
+		reattached: make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+}
+
+// send は進捗を現在のストリームに送信し、送信できたかどうかを返す
+// 切断中や送信に失敗した場合も最新の進捗として保持し、再接続時に送信する
+func (s *jobSession) send(progress *workerv1.JobProgress) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.sendLocked(progress)
+}
+
+// sendLocked は send と同じ処理を mu を保持した状態で行う
+func (s *jobSession) sendLocked(progress *workerv1.JobProgress) bool {
+	s.last = progress
+	if s.stream == nil {
+		return false
+	}
+	if err := s.stream.Send(progress); err != nil {
+		logger.Warn("Failed to send progress, waiting for reattach",
+			zap.String("job_id", s.jobID),
+			zap.Error(err),
+		)
+		s.stream = nil
+		return false
+	}
+	return true
+}
+
+// attach は新しいストリームを送信先にし、最新の進捗を送信する
+func (s *jobSession) attach(stream progressStream) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.last != nil {
+		if err := stream.Send(s.last); err != nil {
+			return err
+		}
+	}
+	s.stream = stream
+	close(s.reattached)
+	s.reattached = make(chan struct{})
+	return nil
+}
+
+// watch はストリームの切断を監視し、猶予期間内に再接続されなければ cancel でジョブを中止する
+// ジョブが終了すると戻る
+func (s *jobSession) watch(grace time.Duration, cancel context.CancelFunc) {
+	for {
+		select {
+		case <-s.done:
+			return
+		default:
+		}
+
+		s.mu.Lock()
+		stream := s.stream
+		reattached := s.reattached
+		s.mu.Unlock()
+
+		if stream != nil {
+			select {
+			case <-s.done:
+				return
+			case <-reattached:
+				// 別のストリームに置き換えられた
+				continue
+			case <-stream.Context().Done():
+			}
+
+			s.mu.Lock()
+			if s.stream == stream {
+				s.stream = nil
+			}
+			s.mu.Unlock()
+		}
+
+		logger.Warn("Progress stream disconnected, waiting for reattach",
+			zap.String("job_id", s.jobID),
+			zap.Duration("grace", grace),
+		)
+		if !s.waitReattach(reattached, grace) {
+			logger.Warn("No reattach within grace period, cancelling job", zap.String("job_id", s.jobID))
+			cancel()
+			return
+		}
+	}
+}
+
+// finish は最終の進捗を送信してセッションを終了する
+// 切断中の場合は猶予期間内の再接続を待つ（再接続時に最終の進捗が送信される）
+func (s *jobSession) finish(progress *workerv1.JobProgress, grace time.Duration) {
+	defer close(s.done)
+
+	s.mu.Lock()
+	delivered := s.sendLocked(progress)
+	reattached := s.reattached
+	s.mu.Unlock()
+
+	if !delivered && !s.waitReattach(reattached, grace) {
+		logger.Warn("Final progress was not delivered", zap.String("job_id", s.jobID))
+	}
+}
+
+// waitReattach は reattached が close されるか、ジョブが終了するまで最大 grace の間待つ
+func (s *jobSession) waitReattach(reattached <-chan struct{}, grace time.Duration) bool {
+	timer := time.NewTimer(grace)
+	defer timer.Stop()
+
+	select {
+	case <-reattached:
+		return true
+	case <-s.done:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
@@ -0,0 +1,137 @@
+package grpc
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nzws/flux-encoder/internal/worker/encoder"
+	workerv1 "github.com/nzws/flux-encoder/proto/worker/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fakeProgressStream は送信した進捗を記録する progressStream
+// ctx がキャンセルされた後の送信はエラーになる
+type fakeProgressStream struct {
+	ctx context.Context
+
+	mu   sync.Mutex
+	sent []*workerv1.JobProgress
+}
+
+func newFakeProgressStream(ctx context.Context) *fakeProgressStream {
+	return &fakeProgressStream{ctx: ctx}
+}
+
+func (f *fakeProgressStream) Send(progress *workerv1.JobProgress) error {
+	if err := f.ctx.Err(); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sent = append(f.sent, progress)
+	return nil
+}
+
+func (f *fakeProgressStream) Context() context.Context {
+	return f.ctx
+}
+
+func (f *fakeProgressStream) statuses() []workerv1.JobStatus {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	statuses := make([]workerv1.JobStatus, 0, len(f.sent))
+	for _, p := range f.sent {
+		statuses = append(statuses, p.Status)
+	}
+	return statuses
+}
+
+func progressWithStatus(status workerv1.JobStatus) *workerv1.JobProgress {
+	return &workerv1.JobProgress{JobId: "session-test", Status: status}
+}
+
+func Test切断後に再接続したストリームに最新と最終の進捗が送信される(t *testing.T) {
+	firstCtx, disconnect := context.WithCancel(context.Background())
+	first := newFakeProgressStream(firstCtx)
+	session := newJobSession("session-test", first)
+
+	cancelled := make(chan struct{})
+	watchDone := make(chan struct{})
+	go func() {
+		defer close(watchDone)
+		session.watch(time.Second, func() { close(cancelled) })
+	}()
+
+	if !session.send(progressWithStatus(workerv1.JobStatus_JOB_STATUS_QUEUED)) {
+		t.Fatal("接続中の送信に失敗した")
+	}
+
+	// 切断中の進捗は送信できないが、最新の進捗として保持される
+	disconnect()
+	if session.send(progressWithStatus(workerv1.JobStatus_JOB_STATUS_UPLOADING)) {
+		t.Fatal("切断中の送信が成功扱いになった")
+	}
+
+	second := newFakeProgressStream(context.Background())
+	if err := session.attach(second); err != nil {
+		t.Fatalf("再接続に失敗: %v", err)
+	}
+	session.finish(progressWithStatus(workerv1.JobStatus_JOB_STATUS_COMPLETED), time.Second)
+
+	select {
+	case <-watchDone:
+	case <-time.After(time.Second):
+		t.Fatal("ジョブ終了後も watch が戻らない")
+	}
+	select {
+	case <-cancelled:
+		t.Fatal("再接続したのにジョブがキャンセルされた")
+	default:
+	}
+
+	if got := first.statuses(); len(got) != 1 || got[0] != workerv1.JobStatus_JOB_STATUS_QUEUED {
+		t.Errorf("切断前のストリームの進捗が一致しない: %v", got)
+	}
+	want := []workerv1.JobStatus{workerv1.JobStatus_JOB_STATUS_UPLOADING, workerv1.JobStatus_JOB_STATUS_COMPLETED}
+	got := second.statuses()
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("再接続したストリームの進捗が一致しない: 期待値 %v, 取得値 %v", want, got)
+	}
+}
+
+func Test猶予期間内に再接続されなければジョブがキャンセルされる(t *testing.T) {
+	ctx, disconnect := context.WithCancel(context.Background())
+	session := newJobSession("session-test", newFakeProgressStream(ctx))
+
+	cancelled := make(chan struct{})
+	go session.watch(10*time.Millisecond, func() { close(cancelled) })
+
+	disconnect()
+
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("猶予期間を過ぎてもジョブがキャンセルされない")
+	}
+
+	// 最終の進捗は届かないが、finish は猶予期間の経過後に戻る
+	session.finish(progressWithStatus(workerv1.JobStatus_JOB_STATUS_FAILED), 10*time.Millisecond)
+}
+
+func Test実行中でないジョブへのAttachJobはNotFoundを返す(t *testing.T) {
+	server := NewServer(encoder.New(t.TempDir()), nil, 1, "test-worker", "0.0.0")
+	client := newTestClient(t, server)
+
+	stream, err := client.AttachJob(context.Background(), &workerv1.AttachRequest{JobId: "missing"})
+	if err != nil {
+		t.Fatalf("AttachJob の呼び出しに失敗: %v", err)
+	}
+	_, err = stream.Recv()
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("ステータスコードが一致しない: 期待値 %v, 取得値 %v", codes.NotFound, status.Code(err))
+	}
+}
@@ -0,0 +1,104 @@
+package grpc
+
+import (
+	"context"
+	"strings"
+
+	"github.com/nzws/flux-encoder/internal/shared/logger"
+	"github.com/nzws/flux-encoder/internal/worker/uploader"
+	"github.com/nzws/flux-encoder/internal/worker/validator"
+	workerv1 "github.com/nzws/flux-encoder/proto/worker/v1"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ValidateOutput はストレージにある既存の出力を再エンコードせずに検証する
+// 検証に合格しなかった場合もエラーではなく、valid が false の検証結果を返す
+func (s *Server) ValidateOutput(ctx context.Context, req *workerv1.ValidateOutputRequest) (*workerv1.ValidateOutputResponse, error) {
+	if strings.TrimSpace(req.Path) == "" {
+		return nil, status.Error(codes.InvalidArgument, "path is required")
+	}
+	if strings.Contains(req.Path, "://") {
+		return nil, status.Error(codes.InvalidArgument, "path must be relative to the storage root, not a URL")
+	}
+
+	// 検証は ffprobe などでローカルのファイルを読むため、リモートのストレージの出力は検証できない
+	local, ok := s.uploader.(*uploader.LocalUploader)
+	if !ok {
+		return nil, status.Errorf(codes.FailedPrecondition, "validating existing outputs is not supported with %s storage (local storage is required)", storageTypeOf(s.uploader))
+	}
+
+	validationSettings := validationSettingsFromConfig(req.Validation)
+	if err := validationSettings.Validate(); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid validation settings: %v", err)
+	}
+
+	result, err := s.encoder.ValidateExisting(ctx, local.LocalPath(req.Path), validationSettings)
+	if err != nil {
+		logger.Error("Failed to validate existing output", zap.String("path", req.Path), zap.Error(err))
+		return nil, status.Errorf(codes.Internal, "failed to validate output: %v", err)
+	}
+
+	logger.Info("Validated existing output",
+		zap.String("path", req.Path),
+		zap.Bool("valid", result.Valid),
+		zap.Int("error_count", len(result.Errors)),
+		zap.Int("warning_count", len(result.Warnings)),
+	)
+
+	return validationResultToProto(result), nil
+}
+
+// validationResultToProto は検証結果を ValidateOutputResponse に変換する
+func validationResultToProto(result *validator.ValidationResult) *workerv1.ValidateOutputResponse {
+	resp := &workerv1.ValidateOutputResponse{
+		Valid:      result.Valid,
+		DurationMs: result.ValidationDuration.Milliseconds(),
+	}
+	for _, e := range result.Errors {
+		resp.Errors = append(resp.Errors, &workerv1.ValidationIssue{Code: e.Code, Message: e.Message, Field: e.Field})
+	}
+	for _, w := range result.Warnings {
+		resp.Warnings = append(resp.Warnings, &workerv1.ValidationIssue{Code: w.Code, Message: w.Message, Field: w.Field})
+	}
+
+	info := result.MediaInfo
+	if info == nil {
+		return resp
+	}
+	resp.MediaInfo = &workerv1.ValidatedMediaInfo{
+		Format:   info.Format,
+		Duration: info.Duration,
+		Size:     info.Size,
+		Bitrate:  info.Bitrate,
+	}
+	for _, v := range info.VideoStreams {
+		resp.MediaInfo.VideoStreams = append(resp.MediaInfo.VideoStreams, &workerv1.ValidatedVideoStream{
+			Codec:       v.Codec,
+			Profile:     v.Profile,
+			Level:       v.Level,
+			Width:       int32(v.Width),
+			Height:      int32(v.Height),
+			FrameRate:   v.FrameRate,
+			PixelFormat: v.PixelFormat,
+			Bitrate:     v.Bitrate,
+		})
+	}
+	for _, a := range info.AudioStreams {
+		resp.MediaInfo.AudioStreams = append(resp.MediaInfo.AudioStreams, &workerv1.ValidatedAudioStream{
+			Codec:         a.Codec,
+			SampleRate:    int32(a.SampleRate),
+			Channels:      int32(a.Channels),
+			ChannelLayout: a.ChannelLayout,
+			Bitrate:       a.Bitrate,
+		})
+	}
+	switch {
+	case info.HLSInfo != nil:
+		resp.MediaInfo.TotalSegments = int32(info.HLSInfo.TotalSegments)
+	case info.DASHInfo != nil:
+		resp.MediaInfo.TotalSegments = int32(info.DASHInfo.TotalSegments)
+	}
+	return resp
+}
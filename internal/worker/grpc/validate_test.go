@@ -0,0 +1,138 @@
+package grpc
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nzws/flux-encoder/internal/worker/encoder"
+	"github.com/nzws/flux-encoder/internal/worker/uploader"
+	workerv1 "github.com/nzws/flux-encoder/proto/worker/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// newLocalStorageClient は storageDir をローカルストレージとする Worker のクライアントを返す
+func newLocalStorageClient(t *testing.T, storageDir string) workerv1.WorkerServiceClient {
+	t.Helper()
+
+	t.Setenv("LOCAL_STORAGE_DIR", storageDir)
+	u, err := uploader.NewUploader(context.Background(), "local")
+	if err != nil {
+		t.Fatalf("Uploader の作成に失敗: %v", err)
+	}
+	return newTestClient(t, NewServer(encoder.New(t.TempDir()), u, 1, "test-worker", "0.0.0"))
+}
+
+func TestValidateOutputがローカルストレージの出力の検証結果を返す(t *testing.T) {
+	installFakeCompletingTools(t)
+	storageDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(storageDir, "videos"), 0755); err != nil {
+		t.Fatalf("ディレクトリの作成に失敗: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(storageDir, "videos", "out.mp4"), []byte("video"), 0644); err != nil {
+		t.Fatalf("出力ファイルの作成に失敗: %v", err)
+	}
+	client := newLocalStorageClient(t, storageDir)
+
+	resp, err := client.ValidateOutput(context.Background(), &workerv1.ValidateOutputRequest{
+		Path:       "videos/out.mp4",
+		Validation: &workerv1.ValidationConfig{Level: "standard"},
+	})
+	if err != nil {
+		t.Fatalf("ValidateOutput に失敗: %v", err)
+	}
+
+	if !resp.Valid || len(resp.Errors) != 0 {
+		t.Errorf("正常な出力が検証に合格しない: %+v", resp)
+	}
+	if resp.MediaInfo == nil || len(resp.MediaInfo.VideoStreams) != 1 {
+		t.Fatalf("メディア情報が返されない: %+v", resp)
+	}
+	if video := resp.MediaInfo.VideoStreams[0]; video.Codec != "h264" || video.Width != 1280 || video.Height != 720 {
+		t.Errorf("映像ストリームが一致しない: %+v", video)
+	}
+	if resp.MediaInfo.Size != int64(len("video")) {
+		t.Errorf("ファイルサイズが一致しない: 期待値 %d, 取得値 %d", len("video"), resp.MediaInfo.Size)
+	}
+}
+
+func TestValidateOutputが不正な出力をエラー付きで返す(t *testing.T) {
+	installFakeCompletingTools(t)
+	storageDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(storageDir, "empty.mp4"), nil, 0644); err != nil {
+		t.Fatalf("出力ファイルの作成に失敗: %v", err)
+	}
+	// ストレージの外のファイルは .. を使っても参照できない
+	outside := filepath.Join(filepath.Dir(storageDir), "outside.mp4")
+	if err := os.WriteFile(outside, []byte("video"), 0644); err != nil {
+		t.Fatalf("ストレージ外のファイルの作成に失敗: %v", err)
+	}
+	t.Cleanup(func() { _ = os.Remove(outside) })
+	client := newLocalStorageClient(t, storageDir)
+
+	for _, path := range []string{"empty.mp4", "missing.mp4", "../outside.mp4"} {
+		t.Run(path, func(t *testing.T) {
+			resp, err := client.ValidateOutput(context.Background(), &workerv1.ValidateOutputRequest{Path: path})
+			if err != nil {
+				t.Fatalf("ValidateOutput に失敗: %v", err)
+			}
+			if resp.Valid {
+				t.Fatalf("不正な出力が検証に合格した: %+v", resp)
+			}
+			if len(resp.Errors) != 1 || resp.Errors[0].Code != "FILE_NOT_FOUND" {
+				t.Errorf("検証エラーが一致しない: %+v", resp.Errors)
+			}
+			if resp.MediaInfo != nil {
+				t.Errorf("メディア情報が返された: %+v", resp.MediaInfo)
+			}
+		})
+	}
+}
+
+func TestValidateOutputが検証できないリクエストを拒否する(t *testing.T) {
+	localClient := newLocalStorageClient(t, t.TempDir())
+	remoteClient := newTestClient(t, NewServer(encoder.New(t.TempDir()), nil, 1, "test-worker", "0.0.0"))
+
+	tests := []struct {
+		name   string
+		client workerv1.WorkerServiceClient
+		req    *workerv1.ValidateOutputRequest
+		code   codes.Code
+	}{
+		{
+			name:   "パスが空",
+			client: localClient,
+			req:    &workerv1.ValidateOutputRequest{},
+			code:   codes.InvalidArgument,
+		},
+		{
+			name:   "URL を指定",
+			client: localClient,
+			req:    &workerv1.ValidateOutputRequest{Path: "https://example.com/out.mp4"},
+			code:   codes.InvalidArgument,
+		},
+		{
+			name:   "不正な検証の設定",
+			client: localClient,
+			req:    &workerv1.ValidateOutputRequest{Path: "out.mp4", Validation: &workerv1.ValidationConfig{Level: "paranoid"}},
+			code:   codes.InvalidArgument,
+		},
+		{
+			name:   "ローカル以外のストレージ",
+			client: remoteClient,
+			req:    &workerv1.ValidateOutputRequest{Path: "out.mp4"},
+			code:   codes.FailedPrecondition,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.client.ValidateOutput(context.Background(), tt.req)
+			if status.Code(err) != tt.code {
+				t.Errorf("ステータスコードが一致しない: 期待値 %v, 取得値 %v", tt.code, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package preset
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ハードウェアエンコードの種類（Preset.HardwareAccel と Worker の HW_ACCEL の値）
+const (
+	HardwareAccelNVENC = "nvenc"
+	HardwareAccelQSV   = "qsv"
+	HardwareAccelVAAPI = "vaapi"
+)
+
+// hardwareAccels は対応するハードウェアエンコードの種類
+// ffmpeg のハードウェアエンコーダー名は "h264_nvenc" のように "<コーデック>_<種類>" の形式
+var hardwareAccels = map[string]bool{
+	HardwareAccelNVENC: true,
+	HardwareAccelQSV:   true,
+	HardwareAccelVAAPI: true,
+}
+
+// IsValidHardwareAccel はハードウェアエンコードの種類として有効な値かチェックする
+func IsValidHardwareAccel(hwType string) bool {
+	return hardwareAccels[hwType]
+}
+
+// HardwareAccelOfEncoder は ffmpeg のエンコーダー名からハードウェアエンコードの種類を返す（CPU のエンコーダーは false）
+func HardwareAccelOfEncoder(encoder string) (string, bool) {
+	_, hwType, ok := strings.Cut(encoder, "_")
+	if !ok || !hardwareAccels[hwType] {
+		return "", false
+	}
+	return hwType, true
+}
+
+// HardwareVariantName はプリセットのハードウェアエンコード版のプリセット名を返す（例: 1080p_h264 → 1080p_h264_nvenc）
+func HardwareVariantName(name, hwType string) string {
+	return name + "_" + hwType
+}
+
+// GetForHardware は hwType のハードウェアエンコード版があればそのプリセットを、なければ指定されたプリセットを返す
+// hwType が空の場合や、指定されたプリセットがすでにハードウェアエンコードの場合は Get と同じ
+func GetForHardware(name, hwType string) (Preset, error) {
+	p, err := Get(name)
+	if err != nil || hwType == "" || p.HardwareAccel != "" {
+		return p, err
+	}
+
+	variant, err := Get(HardwareVariantName(name, hwType))
+	if err != nil || variant.HardwareAccel != hwType {
+		return p, nil
+	}
+	return variant, nil
+}
+
+// validateHardwareAccel はハードウェアエンコードのプリセットの設定をチェックする
+// 映像のエンコーダーは指定した種類のハードウェアエンコーダーのみ使用できる
+func validateHardwareAccel(p Preset) error {
+	if p.HardwareAccel == "" {
+		return nil
+	}
+	if !IsValidHardwareAccel(p.HardwareAccel) {
+		return fmt.Errorf("invalid hardware_accel %q (must be nvenc, qsv or vaapi)", p.HardwareAccel)
+	}
+	if p.TwoPass {
+		return fmt.Errorf("two_pass is not supported for hardware_accel presets")
+	}
+	for _, encoder := range p.RequiredEncoders() {
+		hwType, ok := HardwareAccelOfEncoder(encoder)
+		if ok && hwType != p.HardwareAccel {
+			return fmt.Errorf("encoder %s does not match hardware_accel %s", encoder, p.HardwareAccel)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package preset
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGetForHardwareでハードウェアエンコード版のプリセットが返される(t *testing.T) {
+	testCases := []struct {
+		name     string
+		preset   string
+		hwType   string
+		expected string
+	}{
+		{"NVENC版がある", "1080p_h264", HardwareAccelNVENC, "1080p_h264_nvenc"},
+		{"VAAPI版がある", "1080p_h264", HardwareAccelVAAPI, "1080p_h264_vaapi"},
+		{"QSV版がない", "720p_h264", HardwareAccelQSV, "720p_h264"},
+		{"ハードウェアエンコードが無効", "1080p_h264", "", "1080p_h264"},
+		{"すでにハードウェアエンコードのプリセット", "1080p_h264_nvenc", HardwareAccelQSV, "1080p_h264_nvenc"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := GetForHardware(tc.preset, tc.hwType)
+			if err != nil {
+				t.Fatalf("プリセットの取得に失敗: %v", err)
+			}
+			if p.Name != tc.expected {
+				t.Errorf("プリセット名が一致しない: 期待値 %s, 取得値 %s", tc.expected, p.Name)
+			}
+		})
+	}
+}
+
+func TestGetForHardwareで種類が一致しない同名のプリセットは使用されない(t *testing.T) {
+	restorePresets(t)
+	mu.Lock()
+	presets["720p_h264_qsv"] = Preset{Name: "720p_h264_qsv", FFmpegArgs: []string{"-c:v", "libx264"}, Extension: "mp4"}
+	mu.Unlock()
+
+	p, err := GetForHardware("720p_h264", HardwareAccelQSV)
+	if err != nil {
+		t.Fatalf("プリセットの取得に失敗: %v", err)
+	}
+	if p.Name != "720p_h264" {
+		t.Errorf("hardware_accel のないプリセットが使用された: %s", p.Name)
+	}
+}
+
+func TestGetForHardwareで存在しないプリセットはエラーになる(t *testing.T) {
+	if _, err := GetForHardware("nonexistent", HardwareAccelNVENC); err == nil {
+		t.Error("存在しないプリセットでエラーが返されなかった")
+	}
+}
+
+func Testエンコーダー名からハードウェアエンコードの種類が取得される(t *testing.T) {
+	testCases := []struct {
+		encoder  string
+		expected string
+		ok       bool
+	}{
+		{"h264_nvenc", HardwareAccelNVENC, true},
+		{"hevc_qsv", HardwareAccelQSV, true},
+		{"av1_vaapi", HardwareAccelVAAPI, true},
+		{"libx264", "", false},
+		{"pcm_s16le", "", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.encoder, func(t *testing.T) {
+			hwType, ok := HardwareAccelOfEncoder(tc.encoder)
+			if hwType != tc.expected || ok != tc.ok {
+				t.Errorf("種類が一致しない: 期待値 (%q, %v), 取得値 (%q, %v)", tc.expected, tc.ok, hwType, ok)
+			}
+		})
+	}
+}
+
+func TestLoadFromFileで不正なハードウェアエンコードのプリセットはエラーになる(t *testing.T) {
+	testCases := []struct {
+		name    string
+		content string
+		message string
+	}{
+		{
+			name:    "未対応の種類",
+			content: `[{"name": "hw", "ffmpeg_args": ["-c:v", "h264_amf"], "hardware_accel": "amf"}]`,
+			message: "invalid hardware_accel",
+		},
+		{
+			name:    "エンコーダーと種類が一致しない",
+			content: `[{"name": "hw", "ffmpeg_args": ["-c:v", "h264_qsv"], "hardware_accel": "nvenc"}]`,
+			message: "does not match",
+		},
+		{
+			name:    "2パス",
+			content: `[{"name": "hw", "ffmpeg_args": ["-c:v", "h264_nvenc"], "hardware_accel": "nvenc", "two_pass": true}]`,
+			message: "two_pass",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			restorePresets(t)
+
+			err := LoadFromFile(writePresetsFile(t, "presets.json", tc.content))
+			if err == nil {
+				t.Fatal("不正なプリセットでエラーが返されなかった")
+			}
+			if !strings.Contains(err.Error(), tc.message) {
+				t.Errorf("エラーメッセージが一致しない: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package preset
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// validOutputTypes は許可される出力タイプ（空文字は "single" として扱う）
+var validOutputTypes = map[string]bool{
+	"":       true,
+	"single": true,
+	"hls":    true,
+	"dash":   true,
+}
+
+// LoadFromFile は YAML または JSON ファイルからプリセットを読み込み、登録済みのプリセットにマージする
+// 同名のプリセットは上書きされる。1件でも不正なプリセットがあれば何も登録せずにエラーを返す
+func LoadFromFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read presets file: %w", err)
+	}
+
+	var loaded []Preset
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		if err := json.Unmarshal(data, &loaded); err != nil {
+			return fmt.Errorf("failed to parse presets JSON: %w", err)
+		}
+	case ".yaml", ".yml":
+		if err := yaml.Unmarshal(data, &loaded); err != nil {
+			return fmt.Errorf("failed to parse presets YAML: %w", err)
+		}
+	default:
+		return fmt.Errorf("unsupported presets file extension: %s (must be .json, .yaml or .yml)", filepath.Ext(path))
+	}
+
+	if err := validatePresets(loaded); err != nil {
+		return err
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	for _, p := range loaded {
+		p.Custom = true
+		presets[p.Name] = p
+	}
+
+	return nil
+}
+
+// validatePresets は読み込んだプリセットの内容を検証する
+func validatePresets(list []Preset) error {
+	seen := make(map[string]bool, len(list))
+	var invalid []string
+
+	for i, p := range list {
+		if p.Name == "" {
+			invalid = append(invalid, fmt.Sprintf("#%d: name is required", i))
+			continue
+		}
+		if seen[p.Name] {
+			invalid = append(invalid, fmt.Sprintf("%s: duplicate preset name", p.Name))
+		}
+		seen[p.Name] = true
+
+		if !validOutputTypes[p.OutputType] {
+			invalid = append(invalid, fmt.Sprintf("%s: invalid output type %q (must be single, hls or dash)", p.Name, p.OutputType))
+		}
+		if p.TwoPass && (p.OutputType == "hls" || p.OutputType == "dash") {
+			invalid = append(invalid, fmt.Sprintf("%s: two_pass is only supported for single output", p.Name))
+		}
+		if len(p.FFmpegArgs) == 0 {
+			invalid = append(invalid, fmt.Sprintf("%s: ffmpeg_args is required", p.Name))
+		}
+		if p.Height < 0 {
+			invalid = append(invalid, fmt.Sprintf("%s: height must not be negative", p.Name))
+		}
+		if p.Width < 0 {
+			invalid = append(invalid, fmt.Sprintf("%s: width must not be negative", p.Name))
+		}
+		if p.HLSVersion < 0 {
+			invalid = append(invalid, fmt.Sprintf("%s: hls_version must not be negative", p.Name))
+		}
+		if p.HLSVersion > 0 && p.OutputType != "hls" {
+			invalid = append(invalid, fmt.Sprintf("%s: hls_version is only supported for hls output", p.Name))
+		}
+		if p.Loudness != nil && !p.LoudnessNorm {
+			invalid = append(invalid, fmt.Sprintf("%s: loudness requires loudness_norm", p.Name))
+		}
+		if p.LoudnessNorm {
+			if err := ValidateLoudness(p); err != nil {
+				invalid = append(invalid, fmt.Sprintf("%s: %v", p.Name, err))
+			}
+		}
+		if err := validateHardwareAccel(p); err != nil {
+			invalid = append(invalid, fmt.Sprintf("%s: %v", p.Name, err))
+		}
+		if p.Thumbnail != nil {
+			if p.Thumbnail.Timestamp == "" {
+				invalid = append(invalid, fmt.Sprintf("%s: thumbnail timestamp is required", p.Name))
+			}
+			if p.Thumbnail.Width < 0 {
+				invalid = append(invalid, fmt.Sprintf("%s: thumbnail width must not be negative", p.Name))
+			}
+		}
+	}
+
+	if len(invalid) > 0 {
+		return fmt.Errorf("invalid presets: %s", strings.Join(invalid, "; "))
+	}
+	return nil
+}
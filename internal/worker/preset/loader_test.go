@@ -0,0 +1,321 @@
+package preset
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// restorePresets はテスト終了時にプリセットのレジストリを元に戻す
+func restorePresets(t *testing.T) {
+	t.Helper()
+
+	mu.RLock()
+	snapshot := make(map[string]Preset, len(presets))
+	for k, v := range presets {
+		snapshot[k] = v
+	}
+	mu.RUnlock()
+
+	t.Cleanup(func() {
+		mu.Lock()
+		presets = snapshot
+		mu.Unlock()
+	})
+}
+
+func writePresetsFile(t *testing.T, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("プリセットファイルの作成に失敗: %v", err)
+	}
+	return path
+}
+
+func TestLoadFromFileでYAMLのプリセットを読み込める(t *testing.T) {
+	restorePresets(t)
+	before := len(List())
+
+	path := writePresetsFile(t, "presets.yaml", `
+- name: 360p_h264
+  description: 360p H.264
+  ffmpeg_args: ["-c:v", "libx264", "-vf", "scale=-2:360"]
+  extension: mp4
+  output_type: single
+- name: hls_360p
+  description: HLS 360p
+  ffmpeg_args: ["-c:v", "libx264", "-f", "hls"]
+  extension: m3u8
+  output_type: hls
+  output_file_name: playlist.m3u8
+  output_files: ["playlist.m3u8", "segment_*.ts"]
+`)
+
+	if err := LoadFromFile(path); err != nil {
+		t.Fatalf("プリセットの読み込みに失敗: %v", err)
+	}
+
+	if len(List()) != before+2 {
+		t.Errorf("プリセット数が一致しない: 期待値 %d, 取得値 %d", before+2, len(List()))
+	}
+	if got := CustomCount(); got != 2 {
+		t.Errorf("読み込んだプリセット数が一致しない: 期待値 2, 取得値 %d", got)
+	}
+	if !Exists("360p_h264") {
+		t.Error("読み込んだプリセット '360p_h264' が存在しない")
+	}
+
+	p, err := Get("hls_360p")
+	if err != nil {
+		t.Fatalf("読み込んだプリセットの取得に失敗: %v", err)
+	}
+	if p.OutputType != "hls" || p.OutputFileName != "playlist.m3u8" {
+		t.Errorf("プリセットのフィールドが正しくない: %+v", p)
+	}
+	if len(p.OutputFiles) != 2 || len(p.FFmpegArgs) != 4 {
+		t.Errorf("配列フィールドが正しく読み込まれていない: %+v", p)
+	}
+}
+
+func TestLoadFromFileでJSONのプリセットが組み込みプリセットを上書きする(t *testing.T) {
+	restorePresets(t)
+	before := len(List())
+
+	path := writePresetsFile(t, "presets.json", `[
+  {
+    "name": "720p_h264",
+    "description": "custom 720p",
+    "ffmpeg_args": ["-c:v", "libx264", "-crf", "20"],
+    "extension": "mp4",
+    "output_type": "single"
+  }
+]`)
+
+	if err := LoadFromFile(path); err != nil {
+		t.Fatalf("プリセットの読み込みに失敗: %v", err)
+	}
+
+	if len(List()) != before {
+		t.Errorf("上書き時にプリセット数が変わった: 期待値 %d, 取得値 %d", before, len(List()))
+	}
+	if got := CustomCount(); got != 1 {
+		t.Errorf("読み込んだプリセット数が一致しない: 期待値 1, 取得値 %d", got)
+	}
+
+	p, err := Get("720p_h264")
+	if err != nil {
+		t.Fatalf("プリセットの取得に失敗: %v", err)
+	}
+	if p.Description != "custom 720p" {
+		t.Errorf("プリセットが上書きされていない: 期待値 %s, 取得値 %s", "custom 720p", p.Description)
+	}
+}
+
+func TestLoadFromFileで不正なOutputTypeはエラーになり登録されない(t *testing.T) {
+	restorePresets(t)
+
+	path := writePresetsFile(t, "presets.yaml", `
+- name: valid_preset
+  ffmpeg_args: ["-c:v", "libx264"]
+  extension: mp4
+- name: broken_preset
+  ffmpeg_args: ["-c:v", "libx264"]
+  extension: mp4
+  output_type: smooth
+`)
+
+	err := LoadFromFile(path)
+	if err == nil {
+		t.Fatal("不正な OutputType でエラーが返されなかった")
+	}
+	if !strings.Contains(err.Error(), "broken_preset") {
+		t.Errorf("エラーメッセージに不正なプリセット名が含まれていない: %v", err)
+	}
+	if Exists("valid_preset") {
+		t.Error("エラー時に他のプリセットが登録されている")
+	}
+}
+
+func TestLoadFromFileでHLSの2パスプリセットはエラーになる(t *testing.T) {
+	restorePresets(t)
+
+	path := writePresetsFile(t, "presets.json", `[
+  {"name": "hls_two_pass", "ffmpeg_args": ["-f", "hls"], "output_type": "hls", "two_pass": true}
+]`)
+
+	err := LoadFromFile(path)
+	if err == nil {
+		t.Fatal("HLS の2パスプリセットでエラーが返されなかった")
+	}
+	if !strings.Contains(err.Error(), "hls_two_pass") {
+		t.Errorf("エラーメッセージに不正なプリセット名が含まれていない: %v", err)
+	}
+}
+
+func TestLoadFromFileで2パスプリセットを読み込める(t *testing.T) {
+	restorePresets(t)
+
+	path := writePresetsFile(t, "presets.yaml", `
+- name: 1080p_h264_2pass
+  ffmpeg_args: ["-c:v", "libx264", "-b:v", "5M"]
+  extension: mp4
+  two_pass: true
+`)
+
+	if err := LoadFromFile(path); err != nil {
+		t.Fatalf("プリセットの読み込みに失敗: %v", err)
+	}
+
+	p, err := Get("1080p_h264_2pass")
+	if err != nil {
+		t.Fatalf("プリセットの取得に失敗: %v", err)
+	}
+	if !p.TwoPass {
+		t.Error("TwoPass が読み込まれていない")
+	}
+}
+
+func TestLoadFromFileでサムネイル設定を読み込める(t *testing.T) {
+	restorePresets(t)
+
+	path := writePresetsFile(t, "presets.yaml", `
+- name: 720p_with_poster
+  ffmpeg_args: ["-c:v", "libx264"]
+  extension: mp4
+  thumbnail:
+    timestamp: "00:00:05"
+    width: 640
+- name: broken_poster
+  ffmpeg_args: ["-c:v", "libx264"]
+  extension: mp4
+  thumbnail:
+    width: 640
+`)
+
+	err := LoadFromFile(path)
+	if err == nil {
+		t.Fatal("時刻のないサムネイル設定でエラーが返されなかった")
+	}
+	if !strings.Contains(err.Error(), "broken_poster") || strings.Contains(err.Error(), "720p_with_poster") {
+		t.Errorf("エラーメッセージが一致しない: %v", err)
+	}
+
+	path = writePresetsFile(t, "presets.yaml", `
+- name: 720p_with_poster
+  ffmpeg_args: ["-c:v", "libx264"]
+  extension: mp4
+  thumbnail:
+    timestamp: "00:00:05"
+    width: 640
+`)
+	if err := LoadFromFile(path); err != nil {
+		t.Fatalf("プリセットの読み込みに失敗: %v", err)
+	}
+
+	p, err := Get("720p_with_poster")
+	if err != nil {
+		t.Fatalf("プリセットの取得に失敗: %v", err)
+	}
+	if p.Thumbnail == nil || p.Thumbnail.Timestamp != "00:00:05" || p.Thumbnail.Width != 640 {
+		t.Errorf("サムネイル設定が一致しない: %+v", p.Thumbnail)
+	}
+}
+
+func TestLoadFromFileでラウドネス正規化の設定を読み込める(t *testing.T) {
+	restorePresets(t)
+
+	path := writePresetsFile(t, "presets.yaml", `
+- name: 720p_loudnorm
+  ffmpeg_args: ["-c:v", "libx264", "-c:a", "aac"]
+  extension: mp4
+  loudness_norm: true
+  loudness:
+    integrated_lufs: -23
+    two_pass: true
+`)
+	if err := LoadFromFile(path); err != nil {
+		t.Fatalf("プリセットの読み込みに失敗: %v", err)
+	}
+
+	p, err := Get("720p_loudnorm")
+	if err != nil {
+		t.Fatalf("プリセットの取得に失敗: %v", err)
+	}
+	target := LoudnessTarget(p)
+	want := LoudnessSpec{IntegratedLUFS: -23, TruePeak: DefaultLoudnessTruePeak, LRA: DefaultLoudnessLRA, TwoPass: true}
+	if !p.LoudnessNorm || target != want {
+		t.Errorf("ラウドネス正規化の設定が一致しない: 期待値 %+v, 取得値 %+v", want, target)
+	}
+}
+
+func TestLoadFromFileでafとラウドネス正規化の併用と範囲外の目標値はエラーになる(t *testing.T) {
+	restorePresets(t)
+
+	path := writePresetsFile(t, "presets.yaml", `
+- name: loudnorm_with_af
+  ffmpeg_args: ["-c:a", "aac", "-af", "volume=2"]
+  extension: mp4
+  loudness_norm: true
+- name: loudnorm_too_loud
+  ffmpeg_args: ["-c:a", "aac"]
+  extension: mp4
+  loudness_norm: true
+  loudness:
+    integrated_lufs: 3
+- name: loudness_without_norm
+  ffmpeg_args: ["-c:a", "aac"]
+  extension: mp4
+  loudness:
+    lra: 7
+`)
+
+	err := LoadFromFile(path)
+	if err == nil {
+		t.Fatal("不正なラウドネス正規化の設定でエラーが返されなかった")
+	}
+	for _, name := range []string{"loudnorm_with_af", "loudnorm_too_loud", "loudness_without_norm"} {
+		if !strings.Contains(err.Error(), name) {
+			t.Errorf("エラーメッセージに不正なプリセット名 %s が含まれていない: %v", name, err)
+		}
+	}
+	if Exists("loudnorm_with_af") {
+		t.Error("不正なプリセットが登録された")
+	}
+}
+
+func TestLoadFromFileでHLS以外のhls_versionはエラーになる(t *testing.T) {
+	restorePresets(t)
+
+	path := writePresetsFile(t, "presets.yaml", `
+- name: mp4_with_hls_version
+  ffmpeg_args: ["-c:v", "libx264"]
+  extension: mp4
+  hls_version: 7
+`)
+
+	err := LoadFromFile(path)
+	if err == nil {
+		t.Fatal("HLS 以外の hls_version でエラーが返されなかった")
+	}
+	if !strings.Contains(err.Error(), "mp4_with_hls_version") {
+		t.Errorf("エラーメッセージに不正なプリセット名が含まれていない: %v", err)
+	}
+}
+
+func TestLoadFromFileで未対応の拡張子はエラーになる(t *testing.T) {
+	restorePresets(t)
+
+	path := writePresetsFile(t, "presets.toml", "")
+	if err := LoadFromFile(path); err == nil {
+		t.Error("未対応の拡張子でエラーが返されなかった")
+	}
+}
+
+func TestLoadFromFileで存在しないファイルはエラーになる(t *testing.T) {
+	if err := LoadFromFile(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("存在しないファイルでエラーが返されなかった")
+	}
+}
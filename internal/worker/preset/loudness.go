@@ -0,0 +1,61 @@
+package preset
+
+import "fmt"
+
+// ラウドネス正規化のデフォルトの目標値（loudnorm フィルターのデフォルトと同じ）
+const (
+	DefaultLoudnessIntegratedLUFS = -16.0
+	DefaultLoudnessTruePeak       = -1.5
+	DefaultLoudnessLRA            = 11.0
+)
+
+// audioFilterFlags は音声フィルターを指定する ffmpeg の引数（ラウドネス正規化とは併用できない）
+var audioFilterFlags = map[string]bool{
+	"-af":       true,
+	"-filter:a": true,
+}
+
+// LoudnessTarget はプリセットのラウドネス正規化の設定に、未指定の値のデフォルト値を補って返す
+func LoudnessTarget(p Preset) LoudnessSpec {
+	target := LoudnessSpec{
+		IntegratedLUFS: DefaultLoudnessIntegratedLUFS,
+		TruePeak:       DefaultLoudnessTruePeak,
+		LRA:            DefaultLoudnessLRA,
+	}
+	if p.Loudness == nil {
+		return target
+	}
+	if p.Loudness.IntegratedLUFS != 0 {
+		target.IntegratedLUFS = p.Loudness.IntegratedLUFS
+	}
+	if p.Loudness.TruePeak != 0 {
+		target.TruePeak = p.Loudness.TruePeak
+	}
+	if p.Loudness.LRA != 0 {
+		target.LRA = p.Loudness.LRA
+	}
+	target.TwoPass = p.Loudness.TwoPass
+	return target
+}
+
+// ValidateLoudness はラウドネス正規化を行うプリセットの設定をチェックする
+// 目標値が loudnorm フィルターの範囲外の場合と、プリセットがすでに -af を指定している場合はエラー
+func ValidateLoudness(p Preset) error {
+	for _, arg := range p.FFmpegArgs {
+		if audioFilterFlags[arg] {
+			return fmt.Errorf("loudness_norm cannot be combined with %s in ffmpeg_args", arg)
+		}
+	}
+
+	target := LoudnessTarget(p)
+	if target.IntegratedLUFS < -70 || target.IntegratedLUFS > -5 {
+		return fmt.Errorf("loudness integrated_lufs must be between -70 and -5: %g", target.IntegratedLUFS)
+	}
+	if target.TruePeak < -9 || target.TruePeak > 0 {
+		return fmt.Errorf("loudness true_peak must be between -9 and 0: %g", target.TruePeak)
+	}
+	if target.LRA < 1 || target.LRA > 50 {
+		return fmt.Errorf("loudness lra must be between 1 and 50: %g", target.LRA)
+	}
+	return nil
+}
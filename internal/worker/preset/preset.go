@@ -2,20 +2,60 @@ package preset
 
 import (
 	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
 )
 
 // Preset はエンコード設定のプリセット
 type Preset struct {
-	Name           string   // プリセット名
-	Description    string   // 説明
-	FFmpegArgs     []string // ffmpeg引数
-	Extension      string   // 出力ファイル拡張子
-	OutputType     string   // 出力タイプ: "single" (default), "hls", "dash"
-	OutputFileName string   // 出力ファイル名（HLS/DASH用、%vはバリアント番号のプレースホルダー）
-	OutputFiles    []string // 生成されるファイルのパターン（マルチファイル出力用）
+	Name           string         `json:"name" yaml:"name"`                               // プリセット名
+	Description    string         `json:"description" yaml:"description"`                 // 説明
+	FFmpegArgs     []string       `json:"ffmpeg_args" yaml:"ffmpeg_args"`                 // ffmpeg引数
+	Extension      string         `json:"extension" yaml:"extension"`                     // 出力ファイル拡張子
+	OutputType     string         `json:"output_type" yaml:"output_type"`                 // 出力タイプ: "single" (default), "hls", "dash"
+	OutputFileName string         `json:"output_file_name" yaml:"output_file_name"`       // 出力ファイル名（HLS/DASH用、%vはバリアント番号のプレースホルダー）
+	OutputFiles    []string       `json:"output_files" yaml:"output_files"`               // 生成されるファイルのパターン（マルチファイル出力用）
+	TwoPass        bool           `json:"two_pass" yaml:"two_pass"`                       // 2パスエンコードを行うか（単一ファイル出力のみ）
+	Height         int            `json:"height" yaml:"height"`                           // 出力の最大解像度（高さ px、ABR の場合は最大バリアント）。0 は未指定
+	Width          int            `json:"width" yaml:"width"`                             // 出力の幅（px、縦長のプリセット用）。0 は未指定（高さを短辺として扱う）
+	Thumbnail      *ThumbnailSpec `json:"thumbnail,omitempty" yaml:"thumbnail,omitempty"` // サムネイル画像の生成設定。nil の場合は生成しない
+	HLSVersion     int            `json:"hls_version" yaml:"hls_version"`                 // HLS プレイリストの #EXT-X-VERSION（HLS用）。0 は ffmpeg の自動選択
+	LoudnessNorm   bool           `json:"loudness_norm" yaml:"loudness_norm"`             // EBU R128 のラウドネス正規化（loudnorm フィルター）を行うか
+	Loudness       *LoudnessSpec  `json:"loudness,omitempty" yaml:"loudness,omitempty"`   // ラウドネス正規化の目標値と方式。nil の場合はデフォルト値で1パス
+	HardwareAccel  string         `json:"hardware_accel" yaml:"hardware_accel"`           // ハードウェアエンコードの種類: "" (CPU), "nvenc", "qsv", "vaapi"
+	SkipPreflight  bool           `json:"skip_preflight" yaml:"skip_preflight"`           // エンコード前の ffprobe による入力の事前チェックを省略するか
+	Custom         bool           `json:"-" yaml:"-"`                                     // PRESETS_FILE から読み込んだプリセットか（組み込みを上書きした場合を含む）
+}
+
+// ShortEdge は出力の短辺（px、未指定の場合は 0）を返す
+// 横長のプリセットは高さ、縦長のプリセット（幅 < 高さ）は幅が短辺になる
+func (p Preset) ShortEdge() int {
+	if p.Width > 0 && p.Width < p.Height {
+		return p.Width
+	}
+	return p.Height
+}
+
+// LoudnessSpec はラウドネス正規化の設定（0 の値はデフォルト値を使用する）
+type LoudnessSpec struct {
+	IntegratedLUFS float64 `json:"integrated_lufs" yaml:"integrated_lufs"` // 目標の統合ラウドネス（LUFS、-70〜-5）。デフォルト: -16
+	TruePeak       float64 `json:"true_peak" yaml:"true_peak"`             // 最大トゥルーピーク（dBTP、-9〜0）。デフォルト: -1.5
+	LRA            float64 `json:"lra" yaml:"lra"`                         // 目標のラウドネスレンジ（LU、1〜50）。デフォルト: 11
+	TwoPass        bool    `json:"two_pass" yaml:"two_pass"`               // 1回目で入力のラウドネスを測定し、2回目で測定値を使って正規化するか
+}
+
+// ThumbnailSpec はエンコード時に生成するサムネイル画像の設定
+type ThumbnailSpec struct {
+	Timestamp string `json:"timestamp" yaml:"timestamp"` // 切り出す時刻（ffmpeg の時間表記、例: "00:00:05"）
+	Width     int    `json:"width" yaml:"width"`         // 出力幅（px、高さはアスペクト比を保つ）。0 は入力と同じ
 }
 
 var (
+	// mu は presets へのアクセスを保護する
+	mu sync.RWMutex
+
 	// presets は利用可能なプリセットのマップ
 	presets = map[string]Preset{
 		"720p_h264": {
@@ -32,6 +72,7 @@ var (
 			},
 			Extension:  "mp4",
 			OutputType: "single",
+			Height:     720,
 		},
 		"1080p_h264": {
 			Name:        "1080p_h264",
@@ -47,6 +88,80 @@ var (
 			},
 			Extension:  "mp4",
 			OutputType: "single",
+			Height:     1080,
+		},
+		"720p_h264_nvenc": {
+			Name:        "720p_h264_nvenc",
+			Description: "HD 720p with H.264 encoding on NVIDIA GPUs (NVENC)",
+			FFmpegArgs: []string{
+				"-vf", "scale=-2:720",
+				"-c:v", "h264_nvenc",
+				"-preset", "p4", // NVENC の速度と品質のバランス（p1〜p7）
+				"-rc", "vbr",
+				"-cq", "23", // 品質（-b:v 0 と合わせて品質優先の可変ビットレート）
+				"-b:v", "0",
+				"-c:a", "aac",
+				"-b:a", "128k",
+				"-movflags", "+faststart",
+			},
+			Extension:     "mp4",
+			OutputType:    "single",
+			Height:        720,
+			HardwareAccel: HardwareAccelNVENC,
+		},
+		"1080p_h264_nvenc": {
+			Name:        "1080p_h264_nvenc",
+			Description: "Full HD 1080p with H.264 encoding on NVIDIA GPUs (NVENC)",
+			FFmpegArgs: []string{
+				"-vf", "scale=-2:1080",
+				"-c:v", "h264_nvenc",
+				"-preset", "p4",
+				"-rc", "vbr",
+				"-cq", "23",
+				"-b:v", "0",
+				"-c:a", "aac",
+				"-b:a", "192k",
+				"-movflags", "+faststart",
+			},
+			Extension:     "mp4",
+			OutputType:    "single",
+			Height:        1080,
+			HardwareAccel: HardwareAccelNVENC,
+		},
+		"1080p_h264_qsv": {
+			Name:        "1080p_h264_qsv",
+			Description: "Full HD 1080p with H.264 encoding on Intel GPUs (Quick Sync Video)",
+			FFmpegArgs: []string{
+				"-vf", "scale=-2:1080",
+				"-c:v", "h264_qsv",
+				"-preset", "medium",
+				"-global_quality", "23", // 品質（ICQ モード）
+				"-c:a", "aac",
+				"-b:a", "192k",
+				"-movflags", "+faststart",
+			},
+			Extension:     "mp4",
+			OutputType:    "single",
+			Height:        1080,
+			HardwareAccel: HardwareAccelQSV,
+		},
+		"1080p_h264_vaapi": {
+			Name:        "1080p_h264_vaapi",
+			Description: "Full HD 1080p with H.264 encoding via VA-API",
+			FFmpegArgs: []string{
+				"-vaapi_device", "/dev/dri/renderD128",
+				// CPU でスケールしてから GPU のメモリにアップロードする
+				"-vf", "scale=-2:1080,format=nv12,hwupload",
+				"-c:v", "h264_vaapi",
+				"-qp", "23",
+				"-c:a", "aac",
+				"-b:a", "192k",
+				"-movflags", "+faststart",
+			},
+			Extension:     "mp4",
+			OutputType:    "single",
+			Height:        1080,
+			HardwareAccel: HardwareAccelVAAPI,
 		},
 		"480p_h264": {
 			Name:        "480p_h264",
@@ -62,6 +177,58 @@ var (
 			},
 			Extension:  "mp4",
 			OutputType: "single",
+			Height:     480,
+		},
+		"1080p_av1": {
+			Name:        "1080p_av1",
+			Description: "Full HD 1080p with AV1 (SVT-AV1) encoding",
+			FFmpegArgs: []string{
+				"-vf", "scale=-2:1080",
+				"-c:v", "libsvtav1",
+				"-preset", "8",
+				"-crf", "32",
+				"-c:a", "aac",
+				"-b:a", "192k",
+				"-movflags", "+faststart",
+			},
+			Extension:  "mp4",
+			OutputType: "single",
+			Height:     1080,
+		},
+		"vertical_720x1280_h264": {
+			Name:        "vertical_720x1280_h264",
+			Description: "Vertical 720x1280 (9:16) with H.264 encoding for short-form video",
+			FFmpegArgs: []string{
+				// 縦長の 720x1280 に収まるよう縮小し、アスペクト比が異なる入力（横長など）は黒帯で埋める
+				"-vf", "scale=720:1280:force_original_aspect_ratio=decrease,pad=720:1280:(ow-iw)/2:(oh-ih)/2,setsar=1",
+				"-c:v", "libx264",
+				"-preset", "medium",
+				"-crf", "23",
+				"-c:a", "aac",
+				"-b:a", "128k",
+				"-movflags", "+faststart",
+			},
+			Extension:  "mp4",
+			OutputType: "single",
+			Height:     1280,
+			Width:      720,
+		},
+		"vertical_1080x1920_h264": {
+			Name:        "vertical_1080x1920_h264",
+			Description: "Vertical 1080x1920 (9:16) with H.264 encoding for short-form video",
+			FFmpegArgs: []string{
+				"-vf", "scale=1080:1920:force_original_aspect_ratio=decrease,pad=1080:1920:(ow-iw)/2:(oh-ih)/2,setsar=1",
+				"-c:v", "libx264",
+				"-preset", "medium",
+				"-crf", "23",
+				"-c:a", "aac",
+				"-b:a", "192k",
+				"-movflags", "+faststart",
+			},
+			Extension:  "mp4",
+			OutputType: "single",
+			Height:     1920,
+			Width:      1080,
 		},
 		"hls_720p_video_only": {
 			Name:        "hls_720p_video_only",
@@ -77,6 +244,7 @@ var (
 			},
 			Extension:      "m3u8",
 			OutputType:     "hls",
+			Height:         720,
 			OutputFileName: "playlist.m3u8",
 			OutputFiles: []string{
 				"playlist.m3u8",
@@ -99,6 +267,7 @@ var (
 			},
 			Extension:      "m3u8",
 			OutputType:     "hls",
+			Height:         720,
 			OutputFileName: "playlist.m3u8",
 			OutputFiles: []string{
 				"playlist.m3u8",
@@ -144,6 +313,7 @@ var (
 			},
 			Extension:      "m3u8",
 			OutputType:     "hls",
+			Height:         720,
 			OutputFileName: "stream_%v.m3u8",
 			OutputFiles: []string{
 				"master.m3u8",
@@ -197,6 +367,7 @@ var (
 			},
 			Extension:      "m3u8",
 			OutputType:     "hls",
+			Height:         720,
 			OutputFileName: "stream_%v.m3u8",
 			OutputFiles: []string{
 				"master.m3u8",
@@ -204,11 +375,68 @@ var (
 				"segment_*_*.ts",
 			},
 		},
+		"dash_720p_abr": {
+			Name:        "dash_720p_abr",
+			Description: "MPEG-DASH with 3 quality variants (720p, 480p, 360p) - With audio",
+			FFmpegArgs: []string{
+				// 3つの品質バリアント
+				"-filter_complex",
+				"[0:v]split=3[v1][v2][v3];" +
+					"[v1]scale=w=1280:h=720[v1out];" +
+					"[v2]scale=w=854:h=480[v2out];" +
+					"[v3]scale=w=640:h=360[v3out]",
+				// 720p variant
+				"-map", "[v1out]",
+				"-c:v:0", "libx264",
+				"-b:v:0", "2800k",
+				"-maxrate:v:0", "3000k",
+				"-bufsize:v:0", "6000k",
+				// 480p variant
+				"-map", "[v2out]",
+				"-c:v:1", "libx264",
+				"-b:v:1", "1400k",
+				"-maxrate:v:1", "1500k",
+				"-bufsize:v:1", "3000k",
+				// 360p variant
+				"-map", "[v3out]",
+				"-c:v:2", "libx264",
+				"-b:v:2", "800k",
+				"-maxrate:v:2", "900k",
+				"-bufsize:v:2", "1800k",
+				// バリアント間でセグメントの境界を揃えるため、キーフレームをセグメント長ごとに強制する
+				"-force_key_frames", "expr:gte(t,n_forced*6)",
+				// オーディオ（DASH はすべてのバリアントで1つの音声を共有する）
+				"-map", "a:0",
+				"-c:a", "aac",
+				"-b:a", "128k",
+				"-ac", "2",
+				// DASH設定
+				"-f", "dash",
+				"-seg_duration", "6",
+				"-use_template", "1",
+				"-use_timeline", "1",
+				"-init_seg_name", "init_$RepresentationID$.m4s",
+				"-media_seg_name", "chunk_$RepresentationID$_$Number%05d$.m4s",
+				"-adaptation_sets", "id=0,streams=v id=1,streams=a",
+			},
+			Extension:      "mpd",
+			OutputType:     "dash",
+			Height:         720,
+			OutputFileName: "manifest.mpd",
+			OutputFiles: []string{
+				"manifest.mpd",
+				"init_*.m4s",
+				"chunk_*_*.m4s",
+			},
+		},
 	}
 )
 
 // Get は指定されたプリセット名のプリセットを返す
 func Get(name string) (Preset, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+
 	preset, ok := presets[name]
 	if !ok {
 		return Preset{}, fmt.Errorf("preset not found: %s", name)
@@ -216,17 +444,123 @@ func Get(name string) (Preset, error) {
 	return preset, nil
 }
 
-// List は利用可能なすべてのプリセットを返す
+// List は利用可能なすべてのプリセットを名前順で返す
 func List() []Preset {
+	mu.RLock()
+	defer mu.RUnlock()
+
 	result := make([]Preset, 0, len(presets))
 	for _, p := range presets {
 		result = append(result, p)
 	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
 	return result
 }
 
+// CustomCount は PRESETS_FILE から読み込んだプリセットの件数を返す
+func CustomCount() int {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	count := 0
+	for _, p := range presets {
+		if p.Custom {
+			count++
+		}
+	}
+	return count
+}
+
 // Exists は指定されたプリセット名が存在するかチェックする
 func Exists(name string) bool {
+	mu.RLock()
+	defer mu.RUnlock()
+
 	_, ok := presets[name]
 	return ok
 }
+
+// speedTiers は x264/x265 で使用できる -preset の値（速い順）
+var speedTiers = []string{
+	"ultrafast", "superfast", "veryfast", "faster", "fast",
+	"medium", "slow", "slower", "veryslow", "placebo",
+}
+
+// IsValidSpeed は x264/x265 の -preset として有効な値かチェックする
+func IsValidSpeed(speed string) bool {
+	for _, s := range speedTiers {
+		if s == speed {
+			return true
+		}
+	}
+	return false
+}
+
+// bitratePattern はビットレートの上書き値として許可する形式（例: "2500k"）
+var bitratePattern = regexp.MustCompile(`^[0-9]+k$`)
+
+// maxCRF は CRF として許可する最大値（libvpx/SVT-AV1 の上限）
+const maxCRF = 63
+
+// levelPattern はレベルの上書き値として許可する形式（例: "3.1"、"4"、H.264 の "1b"）
+var levelPattern = regexp.MustCompile(`^([1-6](\.[0-9])?|1b)$`)
+
+// validProfiles はプロファイルの上書き値として許可する -profile:v の値（H.264: baseline〜high444、H.265: main・main10）
+var validProfiles = map[string]bool{
+	"baseline": true,
+	"main":     true,
+	"high":     true,
+	"high10":   true,
+	"high422":  true,
+	"high444":  true,
+	"main10":   true,
+}
+
+// overrideValidators はジョブごとに上書きできる ffmpeg オプション（先頭の "-" を除いた名前）と値の検証関数
+// ffmpeg の引数に任意の値を渡さないよう、ここに登録したキーのみ許可する
+var overrideValidators = map[string]func(string) bool{
+	"crf":    isValidCRF,
+	"b:v":    bitratePattern.MatchString,
+	"preset": IsValidSpeed,
+	// 対象デバイスとの互換性のためのプロファイルとレベル（出力検証でプロファイルが一致するかを確認する）
+	"profile:v": isValidProfile,
+	"level":     levelPattern.MatchString,
+}
+
+// ValidateOverrides はジョブごとの上書き指定のキーと値をチェックする
+func ValidateOverrides(overrides map[string]string) error {
+	keys := make([]string, 0, len(overrides))
+	for key := range overrides {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		validate, ok := overrideValidators[key]
+		if !ok {
+			return fmt.Errorf("unknown override key: %s", key)
+		}
+		if !validate(overrides[key]) {
+			return fmt.Errorf("invalid value for override %s: %q", key, overrides[key])
+		}
+	}
+	return nil
+}
+
+// isValidProfile はプロファイルとして有効な値かチェックする
+func isValidProfile(value string) bool {
+	return validProfiles[value]
+}
+
+// isValidCRF は CRF として有効な数値（0〜maxCRF）かチェックする
+func isValidCRF(value string) bool {
+	for _, r := range value {
+		if (r < '0' || r > '9') && r != '.' {
+			return false
+		}
+	}
+	crf, err := strconv.ParseFloat(value, 64)
+	return err == nil && crf >= 0 && crf <= maxCRF
+}
@@ -1,6 +1,7 @@
 package preset
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -55,16 +56,19 @@ func TestListですべてのプリセットが返される(t *testing.T) {
 	list := List()
 
 	// 期待されるプリセット数
-	expectedCount := 7
+	expectedCount := 15
 	if len(list) != expectedCount {
 		t.Errorf("プリセット数が一致しない: 期待値 %d, 取得値 %d", expectedCount, len(list))
 	}
 
 	// すべてのプリセットが含まれているか確認
 	expectedNames := []string{
-		"720p_h264", "1080p_h264", "480p_h264",
+		"720p_h264", "1080p_h264", "480p_h264", "1080p_av1",
+		"720p_h264_nvenc", "1080p_h264_nvenc", "1080p_h264_qsv", "1080p_h264_vaapi",
+		"vertical_720x1280_h264", "vertical_1080x1920_h264",
 		"hls_720p", "hls_720p_video_only",
 		"hls_720p_abr", "hls_720p_abr_video_only",
+		"dash_720p_abr",
 	}
 	foundNames := make(map[string]bool)
 	for _, p := range list {
@@ -143,6 +147,33 @@ func Test1080p_h264プリセットのフィールドが正しい(t *testing.T) {
 	}
 }
 
+func Test1080p_av1プリセットのフィールドが正しい(t *testing.T) {
+	preset, err := Get("1080p_av1")
+	if err != nil {
+		t.Fatalf("プリセットの取得に失敗: %v", err)
+	}
+
+	if preset.Name != "1080p_av1" {
+		t.Errorf("Name が一致しない: %s", preset.Name)
+	}
+	if preset.Extension != expectedMP4Extension {
+		t.Errorf("Extension が一致しない: %s", preset.Extension)
+	}
+	if preset.OutputType != expectedOutputTypeSingle {
+		t.Errorf("OutputType が一致しない: %s", preset.OutputType)
+	}
+
+	hasAV1Encoder := false
+	for i, arg := range preset.FFmpegArgs {
+		if arg == "-c:v" && i+1 < len(preset.FFmpegArgs) && preset.FFmpegArgs[i+1] == "libsvtav1" {
+			hasAV1Encoder = true
+		}
+	}
+	if !hasAV1Encoder {
+		t.Errorf("FFmpegArgs に -c:v libsvtav1 が含まれていない: %v", preset.FFmpegArgs)
+	}
+}
+
 func Test480p_h264プリセットのフィールドが正しい(t *testing.T) {
 	preset, err := Get("480p_h264")
 	if err != nil {
@@ -212,6 +243,63 @@ func TestHLS720pABRプリセットのフィールドが正しい(t *testing.T) {
 	}
 }
 
+func TestDASH720pABRプリセットのフィールドが正しい(t *testing.T) {
+	preset, err := Get("dash_720p_abr")
+	if err != nil {
+		t.Fatalf("プリセットの取得に失敗: %v", err)
+	}
+
+	if preset.Name != "dash_720p_abr" {
+		t.Errorf("Name が一致しない: %s", preset.Name)
+	}
+	if preset.Extension != "mpd" {
+		t.Errorf("Extension が一致しない: %s", preset.Extension)
+	}
+	if preset.OutputType != "dash" {
+		t.Errorf("OutputType が一致しない: %s", preset.OutputType)
+	}
+	if preset.OutputFileName != "manifest.mpd" {
+		t.Errorf("OutputFileName が一致しない: %s", preset.OutputFileName)
+	}
+	if preset.Height != 720 {
+		t.Errorf("Height が一致しない: %d", preset.Height)
+	}
+
+	// DASH は manifest.mpd と .m4s のセグメントを含むはず
+	hasManifest, hasSegments := false, false
+	for _, file := range preset.OutputFiles {
+		if file == "manifest.mpd" {
+			hasManifest = true
+		}
+		if strings.HasSuffix(file, ".m4s") {
+			hasSegments = true
+		}
+	}
+	if !hasManifest {
+		t.Error("dash_720p_abr の OutputFiles に manifest.mpd が含まれていない")
+	}
+	if !hasSegments {
+		t.Error("dash_720p_abr の OutputFiles に .m4s のセグメントが含まれていない")
+	}
+
+	// ABR のバリアントを1つの DASH マニフェストにまとめる
+	args := strings.Join(preset.FFmpegArgs, " ")
+	for _, want := range []string{"-f dash", "-adaptation_sets id=0,streams=v id=1,streams=a", "-c:v:2 libx264"} {
+		if !strings.Contains(args, want) {
+			t.Errorf("ffmpeg 引数に %q が含まれていない: %s", want, args)
+		}
+	}
+}
+
+func TestListが名前順で返される(t *testing.T) {
+	list := List()
+	for i := 1; i < len(list); i++ {
+		if list[i-1].Name >= list[i].Name {
+			t.Errorf("プリセットが名前順でない: %s, %s", list[i-1].Name, list[i].Name)
+		}
+	}
+}
+
 func TestすべてのプリセットがFFmpegArgsを持っている(t *testing.T) {
 	list := List()
 	for _, preset := range list {
@@ -249,6 +337,7 @@ func TestOutputTypeが適切に設定されている(t *testing.T) {
 		{"480p_h264", "single"},
 		{"hls_720p", "hls"},
 		{"hls_720p_abr", "hls"},
+		{"dash_720p_abr", "dash"},
 	}
 
 	for _, tc := range testCases {
@@ -335,3 +424,60 @@ func TestHLS単一バリアントとマルチバリアントのOutputFileNameが
 		t.Errorf("hls_720p_abr の OutputFileName が正しくない: %s", hls720pAbr.OutputFileName)
 	}
 }
+
+func TestIsValidSpeedがx264とx265のプリセット名を判定する(t *testing.T) {
+	for _, speed := range []string{"ultrafast", "veryfast", "medium", "veryslow", "placebo"} {
+		if !IsValidSpeed(speed) {
+			t.Errorf("有効な speed %q が無効と判定された", speed)
+		}
+	}
+	for _, speed := range []string{"", "turbo", "Medium", "8"} {
+		if IsValidSpeed(speed) {
+			t.Errorf("無効な speed %q が有効と判定された", speed)
+		}
+	}
+}
+
+func TestすべてのプリセットがHeightを持っている(t *testing.T) {
+	for _, p := range List() {
+		if p.Height <= 0 {
+			t.Errorf("プリセット '%s' の Height が設定されていない", p.Name)
+		}
+	}
+}
+
+func TestValidateOverridesが許可されたキーと値を判定する(t *testing.T) {
+	valid := []map[string]string{
+		nil,
+		{"crf": "23"},
+		{"crf": "18.5", "b:v": "2500k", "preset": "veryfast"},
+		{"crf": "63"},
+		{"profile:v": "baseline", "level": "3.0"},
+		{"profile:v": "main10", "level": "5.1"},
+		{"level": "1b"},
+	}
+	for _, overrides := range valid {
+		if err := ValidateOverrides(overrides); err != nil {
+			t.Errorf("有効な上書き %v でエラーが返された: %v", overrides, err)
+		}
+	}
+
+	invalid := []map[string]string{
+		{"vf": "scale=1:1"},
+		{"crf": "64"},
+		{"crf": "-1"},
+		{"crf": "23; rm -rf /"},
+		{"b:v": "2500"},
+		{"b:v": "2.5M"},
+		{"preset": "turbo"},
+		{"profile:v": "extended"},
+		{"profile:v": "main -x264opts"},
+		{"level": "7"},
+		{"level": "3.1.1"},
+	}
+	for _, overrides := range invalid {
+		if err := ValidateOverrides(overrides); err == nil {
+			t.Errorf("無効な上書き %v でエラーが返されなかった", overrides)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package preset
+
+import (
+	"sort"
+	"strings"
+)
+
+// filterArgs はフィルターグラフを値に取る ffmpeg オプション
+var filterArgs = map[string]bool{
+	"-vf": true, "-af": true, "-filter_complex": true, "-lavfi": true,
+}
+
+// RequiredFilters はプリセットの ffmpeg 引数で使用するフィルター名を返す（重複を除いてソートする）
+func (p Preset) RequiredFilters() []string {
+	var filters []string
+	for i := 0; i+1 < len(p.FFmpegArgs); i++ {
+		arg := p.FFmpegArgs[i]
+		if filterArgs[arg] || strings.HasPrefix(arg, "-filter:") {
+			filters = append(filters, filterNames(p.FFmpegArgs[i+1])...)
+			i++
+		}
+	}
+	// ラウドネス正規化はエンコード時に -af を追加する
+	if p.LoudnessNorm {
+		filters = append(filters, "loudnorm", "aresample")
+	}
+	return uniqueSorted(filters)
+}
+
+// RequiredEncoders はプリセットの ffmpeg 引数で指定するエンコーダー名を返す（重複を除いてソートする）
+// "copy" はエンコーダーではないため含めない
+func (p Preset) RequiredEncoders() []string {
+	var encoders []string
+	for i := 0; i+1 < len(p.FFmpegArgs); i++ {
+		if !isCodecArg(p.FFmpegArgs[i]) {
+			continue
+		}
+		if name := p.FFmpegArgs[i+1]; name != "copy" {
+			encoders = append(encoders, name)
+		}
+		i++
+	}
+	return uniqueSorted(encoders)
+}
+
+// isCodecArg はエンコーダーを値に取る ffmpeg オプション（-c:v、-c:v:0、-codec:a、-vcodec など）かを返す
+func isCodecArg(arg string) bool {
+	switch arg {
+	case "-c", "-codec", "-vcodec", "-acodec", "-scodec":
+		return true
+	}
+	return strings.HasPrefix(arg, "-c:") || strings.HasPrefix(arg, "-codec:")
+}
+
+// filterNames はフィルターグラフに含まれるフィルター名を返す
+// "[0:v]split=3[v1][v2];[v1]scale=w=1280:h=720[out]" は split と scale になる
+func filterNames(graph string) []string {
+	var names []string
+	for _, filter := range splitFilterGraph(graph) {
+		filter = strings.TrimSpace(filter)
+		// 入力ラベル（[0:v] など）を取り除く
+		for strings.HasPrefix(filter, "[") {
+			end := strings.Index(filter, "]")
+			if end < 0 {
+				break
+			}
+			filter = strings.TrimSpace(filter[end+1:])
+		}
+		// 引数と出力ラベルを取り除く
+		if end := strings.IndexAny(filter, "=[@ "); end >= 0 {
+			filter = filter[:end]
+		}
+		if filter != "" {
+			names = append(names, filter)
+		}
+	}
+	return names
+}
+
+// splitFilterGraph はフィルターグラフを "," と ";" で個々のフィルターに分割する
+// 引用符の中とエスケープされた区切り文字（"\,"）では分割しない
+func splitFilterGraph(graph string) []string {
+	var filters []string
+	var current strings.Builder
+	quoted := false
+	for i := 0; i < len(graph); i++ {
+		c := graph[i]
+		switch {
+		case c == '\\' && i+1 < len(graph):
+			current.WriteByte(c)
+			current.WriteByte(graph[i+1])
+			i++
+		case c == '\'':
+			quoted = !quoted
+			current.WriteByte(c)
+		case (c == ',' || c == ';') && !quoted:
+			filters = append(filters, current.String())
+			current.Reset()
+		default:
+			current.WriteByte(c)
+		}
+	}
+	return append(filters, current.String())
+}
+
+// uniqueSorted は重複を除いてソートした一覧を返す
+func uniqueSorted(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	var unique []string
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			unique = append(unique, v)
+		}
+	}
+	sort.Strings(unique)
+	return unique
+}
@@ -0,0 +1,48 @@
+package preset
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Testプリセットに必要なフィルターとエンコーダーが取り出される(t *testing.T) {
+	p, err := Get("hls_720p_abr")
+	if err != nil {
+		t.Fatalf("プリセットの取得に失敗: %v", err)
+	}
+
+	if got, want := p.RequiredFilters(), []string{"scale", "split"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("フィルターが一致しない: 期待値 %v, 取得値 %v", want, got)
+	}
+	if got, want := p.RequiredEncoders(), []string{"aac", "libx264"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("エンコーダーが一致しない: 期待値 %v, 取得値 %v", want, got)
+	}
+}
+
+func Testフィルターグラフの引数とラベルとエスケープが除かれる(t *testing.T) {
+	p := Preset{FFmpegArgs: []string{
+		"-vf", "zscale=t=linear:npl=100,format=gbrpf32le,tonemap=tonemap=hable:desat=0,select='eq(n\\,0)'",
+		"-af", "loudnorm=I=-16",
+		"-filter:v", "[in]subtitles=subs.srt[out]",
+		"-c:v", "libx265",
+		"-c:a:0", "libfdk_aac",
+		"-c:s", "copy",
+	}}
+
+	wantFilters := []string{"format", "loudnorm", "select", "subtitles", "tonemap", "zscale"}
+	if got := p.RequiredFilters(); !reflect.DeepEqual(got, wantFilters) {
+		t.Errorf("フィルターが一致しない: 期待値 %v, 取得値 %v", wantFilters, got)
+	}
+	wantEncoders := []string{"libfdk_aac", "libx265"}
+	if got := p.RequiredEncoders(); !reflect.DeepEqual(got, wantEncoders) {
+		t.Errorf("エンコーダーが一致しない: 期待値 %v, 取得値 %v", wantEncoders, got)
+	}
+}
+
+func Testラウドネス正規化を行うプリセットはloudnormとaresampleを必要とする(t *testing.T) {
+	p := Preset{FFmpegArgs: []string{"-vf", "scale=-2:720", "-c:a", "aac"}, LoudnessNorm: true}
+
+	if got, want := p.RequiredFilters(), []string{"aresample", "loudnorm", "scale"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("フィルターが一致しない: 期待値 %v, 取得値 %v", want, got)
+	}
+}
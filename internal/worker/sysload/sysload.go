@@ -0,0 +1,196 @@
+package sysload
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nzws/flux-encoder/internal/shared/execlimit"
+	"github.com/nzws/flux-encoder/internal/shared/logger"
+	"github.com/shirou/gopsutil/v4/cpu"
+	"github.com/shirou/gopsutil/v4/mem"
+	"go.uber.org/zap"
+)
+
+// DefaultInterval は負荷を取得する間隔のデフォルト値
+const DefaultInterval = 5 * time.Second
+
+// staleIntervals は最新の負荷を有効とみなす取得間隔の数（取得の失敗が続いた場合は古い値を報告しない）
+const staleIntervals = 3
+
+// gpuQueryTimeout は nvidia-smi の実行のタイムアウト
+const gpuQueryTimeout = 5 * time.Second
+
+// Load はホストの負荷
+type Load struct {
+	// CPUPercent はホスト全体の CPU 使用率（0〜100）
+	CPUPercent float64
+	// MemoryPercent はメモリ使用率（0〜100）
+	MemoryPercent    float64
+	MemoryUsedBytes  uint64
+	MemoryTotalBytes uint64
+	// GPUAvailable は GPU の使用率を取得できたかどうか
+	GPUAvailable bool
+	// GPUPercent は GPU の使用率（0〜100、複数 GPU の場合は平均）
+	GPUPercent float64
+	// SampledAt は負荷を取得した時刻
+	SampledAt time.Time
+}
+
+// Source はホストの負荷の取得元
+type Source interface {
+	Sample(ctx context.Context) (Load, error)
+}
+
+// hostSource は gopsutil と nvidia-smi でホストの負荷を取得する
+type hostSource struct {
+	gpu bool
+}
+
+// NewHostSource はホストの CPU・メモリの負荷を取得する Source を作成する
+// gpu が true の場合は nvidia-smi で NVENC の GPU 使用率も取得する（取得できない場合は GPUAvailable=false）
+func NewHostSource(gpu bool) Source {
+	return &hostSource{gpu: gpu}
+}
+
+// Sample はホストの負荷を取得する
+// CPU 使用率は前回の取得からの平均（初回は起動時からの平均）
+func (h *hostSource) Sample(ctx context.Context) (Load, error) {
+	percents, err := cpu.PercentWithContext(ctx, 0, false)
+	if err != nil {
+		return Load{}, fmt.Errorf("failed to get cpu usage: %w", err)
+	}
+	if len(percents) == 0 {
+		return Load{}, fmt.Errorf("failed to get cpu usage: no data")
+	}
+	memory, err := mem.VirtualMemoryWithContext(ctx)
+	if err != nil {
+		return Load{}, fmt.Errorf("failed to get memory usage: %w", err)
+	}
+
+	load := Load{
+		CPUPercent:       percents[0],
+		MemoryPercent:    memory.UsedPercent,
+		MemoryUsedBytes:  memory.Used,
+		MemoryTotalBytes: memory.Total,
+		SampledAt:        time.Now(),
+	}
+	if h.gpu {
+		gpuPercent, err := queryGPUUtilization(ctx)
+		if err != nil {
+			logger.Debug("Failed to get gpu usage", zap.Error(err))
+		} else {
+			load.GPUAvailable = true
+			load.GPUPercent = gpuPercent
+		}
+	}
+	return load, nil
+}
+
+// queryGPUUtilization は nvidia-smi で GPU の使用率を取得する
+func queryGPUUtilization(ctx context.Context) (float64, error) {
+	ctx, cancel := context.WithTimeout(ctx, gpuQueryTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "nvidia-smi", "--query-gpu=utilization.gpu", "--format=csv,noheader,nounits")
+	output, err := execlimit.Output(cmd)
+	if err != nil {
+		return 0, fmt.Errorf("nvidia-smi failed: %w", err)
+	}
+	return parseGPUUtilization(string(output))
+}
+
+// parseGPUUtilization は nvidia-smi の出力（GPU ごとに1行の使用率）を平均する
+func parseGPUUtilization(output string) (float64, error) {
+	var total float64
+	count := 0
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		value, err := strconv.ParseFloat(line, 64)
+		if err != nil {
+			return 0, fmt.Errorf("unexpected nvidia-smi output: %q", line)
+		}
+		total += value
+		count++
+	}
+	if count == 0 {
+		return 0, fmt.Errorf("no gpu found")
+	}
+	return total / float64(count), nil
+}
+
+// Sampler はバックグラウンドで定期的にホストの負荷を取得し、最新の値を保持する
+// GetStatus ごとに負荷を取得せずに済むよう、呼び出し側は Latest で保持した値を参照する
+type Sampler struct {
+	source   Source
+	interval time.Duration
+	now      func() time.Time
+
+	mutex   sync.RWMutex
+	latest  Load
+	sampled bool
+}
+
+// NewSampler は interval ごとに source から負荷を取得する Sampler を作成する
+// interval が 0 以下の場合は DefaultInterval を使用する
+func NewSampler(source Source, interval time.Duration) *Sampler {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	return &Sampler{
+		source:   source,
+		interval: interval,
+		now:      time.Now,
+	}
+}
+
+// Run は ctx がキャンセルされるまで interval ごとに負荷を取得する（最初の取得はすぐに行う）
+func (s *Sampler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.sample(ctx)
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// sample は負荷を1回取得して保持する（失敗した場合は前回の値を残す）
+func (s *Sampler) sample(ctx context.Context) {
+	load, err := s.source.Sample(ctx)
+	if err != nil {
+		logger.Warn("Failed to sample host load", zap.Error(err))
+		return
+	}
+	if load.SampledAt.IsZero() {
+		load.SampledAt = s.now()
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.latest = load
+	s.sampled = true
+}
+
+// Latest は最新の負荷を返す
+// まだ取得していない場合や、取得の失敗が続いて値が古くなった場合は false を返す
+func (s *Sampler) Latest() (Load, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if !s.sampled || s.now().Sub(s.latest.SampledAt) > staleIntervals*s.interval {
+		return Load{}, false
+	}
+	return s.latest, true
+}
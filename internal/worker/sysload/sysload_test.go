@@ -0,0 +1,138 @@
+package sysload
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeSource は設定した負荷を返すテスト用の Source
+type fakeSource struct {
+	mutex   sync.Mutex
+	loads   []Load
+	err     error
+	samples int
+}
+
+func (f *fakeSource) Sample(ctx context.Context) (Load, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.samples++
+	if f.err != nil {
+		return Load{}, f.err
+	}
+	load := f.loads[0]
+	if len(f.loads) > 1 {
+		f.loads = f.loads[1:]
+	}
+	return load, nil
+}
+
+func (f *fakeSource) setError(err error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.err = err
+}
+
+func (f *fakeSource) sampleCount() int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return f.samples
+}
+
+func Test取得前の負荷は報告されない(t *testing.T) {
+	sampler := NewSampler(&fakeSource{loads: []Load{{CPUPercent: 10}}}, time.Second)
+
+	if _, ok := sampler.Latest(); ok {
+		t.Error("取得前に負荷が報告された")
+	}
+}
+
+func Test最新の負荷が保持される(t *testing.T) {
+	source := &fakeSource{loads: []Load{{CPUPercent: 10, MemoryPercent: 40}, {CPUPercent: 95, MemoryPercent: 42, GPUAvailable: true, GPUPercent: 70}}}
+	sampler := NewSampler(source, time.Second)
+
+	sampler.sample(context.Background())
+	sampler.sample(context.Background())
+
+	load, ok := sampler.Latest()
+	if !ok {
+		t.Fatal("負荷が報告されない")
+	}
+	if load.CPUPercent != 95 || load.MemoryPercent != 42 || !load.GPUAvailable || load.GPUPercent != 70 {
+		t.Errorf("最新の負荷が一致しない: %+v", load)
+	}
+	if load.SampledAt.IsZero() {
+		t.Error("取得時刻が記録されていない")
+	}
+}
+
+func Test取得に失敗し続けると古い負荷は報告されない(t *testing.T) {
+	source := &fakeSource{loads: []Load{{CPUPercent: 30}}}
+	sampler := NewSampler(source, time.Second)
+	now := time.Now()
+	sampler.now = func() time.Time { return now }
+
+	sampler.sample(context.Background())
+	source.setError(errors.New("permission denied"))
+
+	// 1回の失敗では前回の値を報告し続ける
+	now = now.Add(time.Second)
+	sampler.sample(context.Background())
+	if load, ok := sampler.Latest(); !ok || load.CPUPercent != 30 {
+		t.Errorf("前回の負荷が報告されない: %+v, %v", load, ok)
+	}
+
+	now = now.Add(staleIntervals * time.Second)
+	if _, ok := sampler.Latest(); ok {
+		t.Error("古くなった負荷が報告された")
+	}
+}
+
+func TestRunが間隔ごとに負荷を取得する(t *testing.T) {
+	source := &fakeSource{loads: []Load{{CPUPercent: 50}}}
+	sampler := NewSampler(source, 10*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		sampler.Run(ctx)
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for source.sampleCount() < 3 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	if source.sampleCount() < 3 {
+		t.Errorf("負荷が定期的に取得されない: %d 回", source.sampleCount())
+	}
+	if load, ok := sampler.Latest(); !ok || load.CPUPercent != 50 {
+		t.Errorf("負荷が報告されない: %+v, %v", load, ok)
+	}
+}
+
+func TestNvidiaSMIの出力からGPU使用率の平均を求める(t *testing.T) {
+	percent, err := parseGPUUtilization("40\n 80 \n\n")
+	if err != nil {
+		t.Fatalf("パースに失敗: %v", err)
+	}
+	if percent != 60 {
+		t.Errorf("GPU 使用率が一致しない: 期待値 60, 取得値 %v", percent)
+	}
+
+	if _, err := parseGPUUtilization(""); err == nil {
+		t.Error("GPU がない出力でエラーにならない")
+	}
+	if _, err := parseGPUUtilization("[N/A]\n"); err == nil {
+		t.Error("不正な出力でエラーにならない")
+	}
+}
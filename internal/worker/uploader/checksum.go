@@ -0,0 +1,130 @@
+package uploader
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/nzws/flux-encoder/internal/shared/logger"
+	"go.uber.org/zap"
+)
+
+// ErrChecksumMismatch はアップロード先のオブジェクトのチェックサムがローカルのファイルと一致しない場合のエラー
+var ErrChecksumMismatch = errors.New("uploaded object checksum mismatch")
+
+// s3Checksum は S3 にアップロードするファイルの MD5 と、アップロード後に S3 が返すはずの ETag
+type s3Checksum struct {
+	md5  []byte
+	etag string
+}
+
+// computeS3Checksum は r の MD5 と、partSize で分割してアップロードした場合の ETag を計算する
+// partSize 以下のファイルは 1 回の PutObject でアップロードされ、ETag はファイル全体の MD5 になる
+// それより大きいファイルはマルチパートでアップロードされ、ETag は各パートの MD5 を連結した値の MD5 に "-パート数" を付けたものになる
+func computeS3Checksum(r io.Reader, partSize int64) (s3Checksum, error) {
+	whole := md5.New()
+	var partSums []byte
+	parts := 0
+	for {
+		part := md5.New()
+		n, err := io.CopyN(io.MultiWriter(whole, part), r, partSize)
+		if n > 0 {
+			partSums = append(partSums, part.Sum(nil)...)
+			parts++
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return s3Checksum{}, fmt.Errorf("failed to read file for checksum: %w", err)
+		}
+	}
+
+	sum := whole.Sum(nil)
+	checksum := s3Checksum{md5: sum, etag: hex.EncodeToString(sum)}
+	if parts > 1 {
+		multipart := md5.Sum(partSums)
+		checksum.etag = fmt.Sprintf("%s-%d", hex.EncodeToString(multipart[:]), parts)
+	}
+	return checksum, nil
+}
+
+// verifyS3ETag はアップロード後に S3 が返した ETag が期待値と一致するかを確認する
+// SSE-KMS で暗号化されたオブジェクトの ETag は MD5 ではないため確認しない（単一パートは Content-MD5 で S3 が検証する）
+func verifyS3ETag(key, expected string, out *manager.UploadOutput) error {
+	if out.ServerSideEncryption == types.ServerSideEncryptionAwsKms || out.ServerSideEncryption == types.ServerSideEncryptionAwsKmsDsse {
+		return nil
+	}
+	if etag := strings.Trim(aws.ToString(out.ETag), `"`); etag != expected {
+		return fmt.Errorf("%w: s3://%s has ETag %q, expected %q", ErrChecksumMismatch, key, etag, expected)
+	}
+	return nil
+}
+
+// copyFile は srcPath を destPath にストリーミングコピーする
+// verify が true の場合はコピーしたデータの SHA-256 を計算し、コピー先を読み直して一致するかを確認する
+func copyFile(srcPath, destPath string, verify bool) error {
+	srcFile, err := os.Open(srcPath)
+	if err != nil {
+		return fmt.Errorf("failed to open source file: %w", err)
+	}
+	defer func() {
+		if err := srcFile.Close(); err != nil {
+			logger.Warn("Failed to close source file", zap.Error(err))
+		}
+	}()
+
+	dstFile, err := os.Create(destPath)
+	if err != nil {
+		return fmt.Errorf("failed to create destination file: %w", err)
+	}
+
+	var src io.Reader = srcFile
+	var srcHash hash.Hash
+	if verify {
+		srcHash = sha256.New()
+		src = io.TeeReader(srcFile, srcHash)
+	}
+	if _, err := io.Copy(dstFile, src); err != nil {
+		_ = dstFile.Close()
+		return fmt.Errorf("failed to copy file: %w", err)
+	}
+	if err := dstFile.Close(); err != nil {
+		return fmt.Errorf("failed to close destination file: %w", err)
+	}
+
+	if verify {
+		return verifyFileSHA256(destPath, srcHash.Sum(nil))
+	}
+	return nil
+}
+
+// verifyFileSHA256 は path の SHA-256 が expected と一致するかを確認する
+func verifyFileSHA256(path string, expected []byte) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open file for checksum: %w", err)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, file); err != nil {
+		return fmt.Errorf("failed to read file for checksum: %w", err)
+	}
+	if actual := h.Sum(nil); !bytes.Equal(actual, expected) {
+		return fmt.Errorf("%w: %s has sha256 %x, expected %x", ErrChecksumMismatch, path, actual, expected)
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package uploader
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/nzws/flux-encoder/internal/shared/retry"
+)
+
+func TestS3のETagの期待値がパートサイズから計算される(t *testing.T) {
+	data := bytes.Repeat([]byte("flux-encoder"), 10)
+
+	// パートサイズ以下の場合はファイル全体の MD5
+	single, err := computeS3Checksum(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("チェックサムの計算に失敗: %v", err)
+	}
+	whole := md5.Sum(data)
+	if single.etag != hex.EncodeToString(whole[:]) || !bytes.Equal(single.md5, whole[:]) {
+		t.Errorf("単一パートの ETag が一致しない: 取得値 %s", single.etag)
+	}
+
+	// パートサイズを超える場合は各パートの MD5 を連結した値の MD5 に "-パート数" を付ける
+	multipart, err := computeS3Checksum(bytes.NewReader(data), 50)
+	if err != nil {
+		t.Fatalf("チェックサムの計算に失敗: %v", err)
+	}
+	first, second, third := md5.Sum(data[:50]), md5.Sum(data[50:100]), md5.Sum(data[100:])
+	combined := md5.Sum(append(append(first[:], second[:]...), third[:]...))
+	if expected := hex.EncodeToString(combined[:]) + "-3"; multipart.etag != expected {
+		t.Errorf("マルチパートの ETag が一致しない: 期待値 %s, 取得値 %s", expected, multipart.etag)
+	}
+	if !bytes.Equal(multipart.md5, whole[:]) {
+		t.Errorf("ファイル全体の MD5 が一致しない")
+	}
+}
+
+func TestS3Uploaderがチェックサムの一致したアップロードを受け付ける(t *testing.T) {
+	client := &fakeS3Client{}
+	uploader := &S3Uploader{
+		transfer: newS3TransferUploader(client, manager.MinUploadPartSize, 1),
+		bucket:   "test-bucket",
+		region:   "ap-northeast-1",
+	}
+	uploader.SetVerifyChecksum(true)
+
+	localFile := filepath.Join(t.TempDir(), "video.mp4")
+	content := []byte("encoded video")
+	if err := os.WriteFile(localFile, content, 0644); err != nil {
+		t.Fatalf("テストファイルの作成に失敗: %v", err)
+	}
+
+	if _, err := uploader.Upload(context.Background(), localFile, "videos/video.mp4"); err != nil {
+		t.Fatalf("アップロードに失敗: %v", err)
+	}
+	sum := md5.Sum(content)
+	if expected := base64.StdEncoding.EncodeToString(sum[:]); aws.ToString(client.lastPut.ContentMD5) != expected {
+		t.Errorf("Content-MD5 が一致しない: 期待値 %s, 取得値 %s", expected, aws.ToString(client.lastPut.ContentMD5))
+	}
+}
+
+func TestS3UploaderがETagの不一致をエラーにする(t *testing.T) {
+	client := &fakeS3Client{corrupt: true}
+	uploader := &S3Uploader{
+		transfer: newS3TransferUploader(client, manager.MinUploadPartSize, 1),
+		bucket:   "test-bucket",
+		region:   "ap-northeast-1",
+	}
+	uploader.SetVerifyChecksum(true)
+
+	localFile := filepath.Join(t.TempDir(), "video.mp4")
+	writeSizedFile(t, localFile, 1024)
+
+	ctx := retry.WithConfig(context.Background(), retry.Config{MaxAttempts: 2, InitialWait: time.Millisecond, MaxWait: time.Millisecond, Multiplier: 1})
+	_, err := uploader.Upload(ctx, localFile, "videos/video.mp4")
+	if !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("チェックサムの不一致のエラーが返らない: %v", err)
+	}
+	// 転送中に壊れた可能性があるため、アップロードをやり直す
+	if client.puts != 2 {
+		t.Errorf("PutObject の回数が一致しない: 期待値 2, 取得値 %d", client.puts)
+	}
+
+	// 確認を無効にした場合は ETag を照合しない
+	uploader.SetVerifyChecksum(false)
+	if _, err := uploader.Upload(ctx, localFile, "videos/video.mp4"); err != nil {
+		t.Errorf("確認を無効にしたアップロードに失敗: %v", err)
+	}
+}
+
+func TestSSE_KMSのオブジェクトはETagを照合しない(t *testing.T) {
+	out := &manager.UploadOutput{ETag: aws.String(`"not-md5"`), ServerSideEncryption: types.ServerSideEncryptionAwsKms}
+	if err := verifyS3ETag("videos/video.mp4", "d41d8cd98f00b204e9800998ecf8427e", out); err != nil {
+		t.Errorf("SSE-KMS のオブジェクトでエラーが返された: %v", err)
+	}
+}
+
+func TestLocalUploaderがチェックサムを確認してコピーする(t *testing.T) {
+	tempDir := t.TempDir()
+	srcFile := filepath.Join(tempDir, "video.mp4")
+	if err := os.WriteFile(srcFile, []byte("encoded video"), 0644); err != nil {
+		t.Fatalf("テストファイルの作成に失敗: %v", err)
+	}
+
+	uploader := &LocalUploader{baseDir: filepath.Join(tempDir, "storage")}
+	uploader.SetVerifyChecksum(true)
+	if _, err := uploader.Upload(context.Background(), srcFile, "videos/video.mp4"); err != nil {
+		t.Fatalf("アップロードに失敗: %v", err)
+	}
+
+	// コピー先が書き換えられた場合は不一致になる
+	destFile := filepath.Join(tempDir, "storage", "videos", "video.mp4")
+	expected := sha256.Sum256([]byte("encoded video"))
+	if err := verifyFileSHA256(destFile, expected[:]); err != nil {
+		t.Errorf("コピーしたファイルのチェックサムが一致しない: %v", err)
+	}
+	if err := os.WriteFile(destFile, []byte("corrupted"), 0644); err != nil {
+		t.Fatalf("コピー先の書き換えに失敗: %v", err)
+	}
+	if err := verifyFileSHA256(destFile, expected[:]); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("チェックサムの不一致のエラーが返らない: %v", err)
+	}
+}
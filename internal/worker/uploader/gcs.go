@@ -0,0 +1,180 @@
+package uploader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"cloud.google.com/go/storage"
+	"github.com/nzws/flux-encoder/internal/shared/logger"
+	"github.com/nzws/flux-encoder/internal/shared/retry"
+	"go.uber.org/zap"
+	"google.golang.org/api/iterator"
+)
+
+// GCSUploader は Google Cloud Storage にファイルをアップロードする
+type GCSUploader struct {
+	client *storage.Client
+	bucket string
+}
+
+// NewGCSUploader は新しい GCSUploader を作成する
+// 認証情報は Application Default Credentials から取得する
+func NewGCSUploader(ctx context.Context, bucket string) (*GCSUploader, error) {
+	client, err := storage.NewClient(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCS client: %w", err)
+	}
+
+	return &GCSUploader{
+		client: client,
+		bucket: bucket,
+	}, nil
+}
+
+// Upload はファイルを GCS にアップロードする
+func (u *GCSUploader) Upload(ctx context.Context, localPath string, remotePath string) (string, error) {
+	// ファイルを開く
+	file, err := os.Open(localPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to open file: %w", err)
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			logger.Warn("Failed to close source file", zap.Error(err))
+		}
+	}()
+
+	// ファイルサイズ取得
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return "", fmt.Errorf("failed to get file info: %w", err)
+	}
+
+	logger.Info("Uploading to GCS",
+		zap.String("bucket", u.bucket),
+		zap.String("key", remotePath),
+		zap.Int64("size", fileInfo.Size()),
+	)
+
+	// GCSにストリーミングアップロード（リトライあり、ジョブごとの設定を優先）
+	err = retry.Do(ctx, retry.FromContext(ctx, retry.DefaultConfig), func() error {
+		// ファイルポインタを先頭に戻す
+		if _, seekErr := file.Seek(0, 0); seekErr != nil {
+			return fmt.Errorf("failed to seek file: %w", seekErr)
+		}
+
+		writeCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		writer := u.client.Bucket(u.bucket).Object(remotePath).NewWriter(writeCtx)
+		if _, copyErr := io.Copy(writer, file); copyErr != nil {
+			// 途中までのデータでオブジェクトが確定しないよう、Close の前にキャンセルする
+			cancel()
+			if closeErr := writer.Close(); closeErr != nil {
+				logger.Debug("GCS writer closed after aborted upload", zap.Error(closeErr))
+			}
+			return copyErr
+		}
+		// Close でアップロードが確定する
+		return writer.Close()
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to upload to GCS after retries: %w", err)
+	}
+
+	trackUpload(ctx, remotePath)
+
+	// URLを生成
+	url := gcsObjectURL(u.bucket, remotePath)
+
+	logger.Info("Upload completed",
+		zap.String("url", url),
+	)
+
+	return url, nil
+}
+
+// Delete は GCS のオブジェクトを削除する
+func (u *GCSUploader) Delete(ctx context.Context, remotePath string) error {
+	err := u.client.Bucket(u.bucket).Object(remotePath).Delete(ctx)
+	if err != nil && !errors.Is(err, storage.ErrObjectNotExist) {
+		return fmt.Errorf("failed to delete from GCS: %w", err)
+	}
+	return nil
+}
+
+// Exists は GCS のオブジェクトが存在するかとそのサイズを返す
+func (u *GCSUploader) Exists(ctx context.Context, remotePath string) (bool, int64, error) {
+	attrs, err := u.client.Bucket(u.bucket).Object(remotePath).Attrs(ctx)
+	if err != nil {
+		if errors.Is(err, storage.ErrObjectNotExist) {
+			return false, 0, nil
+		}
+		return false, 0, fmt.Errorf("failed to get GCS object attributes: %w", err)
+	}
+	return true, attrs.Size, nil
+}
+
+// DeleteDirectory は remoteDir 以下の GCS のオブジェクトをすべて削除する
+func (u *GCSUploader) DeleteDirectory(ctx context.Context, remoteDir string) error {
+	prefix, err := directoryPrefix(remoteDir)
+	if err != nil {
+		return err
+	}
+
+	bucket := u.client.Bucket(u.bucket)
+	it := bucket.Objects(ctx, &storage.Query{Prefix: prefix})
+	deleted := 0
+	for {
+		attrs, err := it.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to list GCS objects: %w", err)
+		}
+		if err := u.Delete(ctx, attrs.Name); err != nil {
+			return err
+		}
+		deleted++
+	}
+
+	logger.Info("Directory deleted",
+		zap.String("prefix", prefix),
+		zap.Int("objects", deleted),
+	)
+	return nil
+}
+
+// UploadDirectory はディレクトリ全体を再帰的に GCS にアップロードする
+func (u *GCSUploader) UploadDirectory(ctx context.Context, localDir string, remoteDir string) (string, []UploadedFile, error) {
+	uploadedFiles, err := uploadDirectoryFiles(ctx, u, localDir, remoteDir)
+	if err != nil {
+		return "", nil, err
+	}
+
+	// マスタープレイリスト/マニフェストのURLを返す
+	masterFile, err := findMasterFile(uploadedPaths(uploadedFiles))
+	if err != nil {
+		return "", nil, err
+	}
+
+	masterKey := filepath.ToSlash(filepath.Join(remoteDir, masterFile))
+	masterURL := gcsObjectURL(u.bucket, masterKey)
+
+	logger.Info("Directory upload completed",
+		zap.String("url", masterURL),
+		zap.Int("files", len(uploadedFiles)),
+	)
+
+	return masterURL, uploadedFiles, nil
+}
+
+// gcsObjectURL は GCS オブジェクトの公開URLを返す
+func gcsObjectURL(bucket, key string) string {
+	return fmt.Sprintf("https://storage.googleapis.com/%s/%s", bucket, key)
+}
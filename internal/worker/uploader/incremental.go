@@ -0,0 +1,278 @@
+package uploader
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nzws/flux-encoder/internal/shared/logger"
+	"go.uber.org/zap"
+)
+
+// DefaultIncrementalUploadInterval は出力ディレクトリを確認するデフォルトの間隔
+const DefaultIncrementalUploadInterval = 2 * time.Second
+
+// fileState はアップロード済みファイルの状態（変更検出に使用）
+type fileState struct {
+	size    int64
+	modTime time.Time
+}
+
+// IncrementalUploader はエンコード中の HLS 出力ディレクトリを監視し、
+// プレイリストに追加されたセグメントとプレイリストを順次アップロードする
+//
+// ffmpeg はセグメントを書き終えてからプレイリストに追記するため、プレイリストが参照するセグメントは完成している。
+// セグメントを先にアップロードしてからプレイリストをアップロードすることで、
+// アップロード先のプレイリストが未アップロードのセグメントを参照しないようにする
+type IncrementalUploader struct {
+	uploader  Uploader
+	localDir  string
+	remoteDir string
+	interval  time.Duration
+
+	mu       sync.Mutex
+	uploaded map[string]fileState
+	urls     map[string]string
+}
+
+// NewIncrementalUploader は新しい IncrementalUploader を作成する
+func NewIncrementalUploader(uploader Uploader, localDir, remoteDir string, interval time.Duration) *IncrementalUploader {
+	if interval <= 0 {
+		interval = DefaultIncrementalUploadInterval
+	}
+	return &IncrementalUploader{
+		uploader:  uploader,
+		localDir:  localDir,
+		remoteDir: remoteDir,
+		interval:  interval,
+		uploaded:  make(map[string]fileState),
+		urls:      make(map[string]string),
+	}
+}
+
+// Run は ctx がキャンセルされるまで一定間隔で Sync を実行する
+// アップロードの失敗はログに記録して次回に再試行する（最終的な結果は Finish で確定する）
+func (iu *IncrementalUploader) Run(ctx context.Context) {
+	ticker := time.NewTicker(iu.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := iu.Sync(ctx); err != nil && ctx.Err() == nil {
+				logger.Warn("Incremental upload failed, will retry",
+					zap.String("dir", iu.localDir),
+					zap.Error(err),
+				)
+			}
+		}
+	}
+}
+
+// Sync はプレイリストが参照している未アップロードのセグメントをアップロードし、続けて変更されたプレイリストをアップロードする
+// 出力ディレクトリがまだ作成されていない場合は何もしない
+func (iu *IncrementalUploader) Sync(ctx context.Context) error {
+	iu.mu.Lock()
+	defer iu.mu.Unlock()
+
+	playlists, err := filepath.Glob(filepath.Join(iu.localDir, "*.m3u8"))
+	if err != nil {
+		return fmt.Errorf("failed to find playlists: %w", err)
+	}
+
+	// 他のプレイリストを参照するマスタープレイリストは最後にアップロードする
+	var media, masters []string
+	refs := make(map[string][]string, len(playlists))
+	for _, playlist := range playlists {
+		content, err := os.ReadFile(playlist)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return fmt.Errorf("failed to read playlist: %w", err)
+		}
+		name := filepath.Base(playlist)
+		refs[name] = playlistURIs(string(content))
+		if referencesPlaylist(refs[name]) {
+			masters = append(masters, name)
+		} else {
+			media = append(media, name)
+		}
+	}
+
+	for _, name := range append(media, masters...) {
+		ready := true
+		for _, uri := range refs[name] {
+			if strings.HasSuffix(uri, ".m3u8") {
+				if _, ok := iu.uploaded[uri]; !ok {
+					ready = false
+				}
+				continue
+			}
+			uploaded, err := iu.uploadIfChanged(ctx, uri)
+			if err != nil {
+				return err
+			}
+			if !uploaded {
+				ready = false
+			}
+		}
+		// 参照先がそろっていないプレイリストは次回に回す
+		if !ready {
+			continue
+		}
+		if _, err := iu.uploadIfChanged(ctx, name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Finish は出力ディレクトリ内の未アップロードまたは変更されたファイルをすべてアップロードし、
+// マスターファイルの URL と出力ディレクトリ内のファイルの一覧（エンコード中にアップロードしたものを含む）を返す
+// セグメントなどを先に、プレイリスト・マニフェストを最後にアップロードする
+func (iu *IncrementalUploader) Finish(ctx context.Context) (string, []UploadedFile, error) {
+	iu.mu.Lock()
+	defer iu.mu.Unlock()
+
+	var files, playlists []string
+	err := filepath.WalkDir(iu.localDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		relPath, err := filepath.Rel(iu.localDir, path)
+		if err != nil {
+			return err
+		}
+		if isManifestFile(relPath) {
+			playlists = append(playlists, relPath)
+		} else {
+			files = append(files, relPath)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to upload directory: %w", err)
+	}
+
+	// マスタープレイリストを最後にするため、findMasterFile で選ばれるファイルを末尾に移動する
+	masterFile, err := findMasterFile(playlists)
+	if err != nil {
+		return "", nil, err
+	}
+	sort.SliceStable(playlists, func(i, j int) bool {
+		return playlists[j] == masterFile && playlists[i] != masterFile
+	})
+
+	uploadedFiles := make([]UploadedFile, 0, len(files)+len(playlists))
+	for _, relPath := range append(files, playlists...) {
+		exists, err := iu.uploadIfChanged(ctx, relPath)
+		if err != nil {
+			return "", nil, err
+		}
+		if !exists {
+			continue
+		}
+		uploadedFiles = append(uploadedFiles, UploadedFile{Path: filepath.ToSlash(relPath), URL: iu.urls[relPath]})
+	}
+
+	return iu.urls[masterFile], uploadedFiles, nil
+}
+
+// uploadIfChanged はファイルが未アップロードまたは前回から変更されている場合にアップロードする
+// ファイルが存在しない場合は false を返す（書き込み途中のプレイリストの不完全な行など）
+func (iu *IncrementalUploader) uploadIfChanged(ctx context.Context, relPath string) (bool, error) {
+	localPath := filepath.Join(iu.localDir, relPath)
+	info, err := os.Stat(localPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to stat %s: %w", relPath, err)
+	}
+
+	state := fileState{size: info.Size(), modTime: info.ModTime()}
+	if prev, ok := iu.uploaded[relPath]; ok && prev == state {
+		return true, nil
+	}
+
+	key := filepath.ToSlash(filepath.Join(iu.remoteDir, relPath))
+	url, err := iu.uploader.Upload(ctx, localPath, key)
+	if err != nil {
+		return false, fmt.Errorf("failed to upload %s: %w", relPath, err)
+	}
+
+	iu.uploaded[relPath] = state
+	iu.urls[relPath] = url
+	return true, nil
+}
+
+// playlistURIs はプレイリストが参照する出力ディレクトリ内のファイル（#EXT-X-MAP の URI を含む）を返す
+func playlistURIs(content string) []string {
+	var uris []string
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "#EXT-X-MAP"):
+			if uri := uriAttribute(line); uri != "" {
+				uris = append(uris, uri)
+			}
+		case strings.HasPrefix(line, "#"):
+		default:
+			uris = append(uris, line)
+		}
+	}
+
+	// 絶対 URL や親ディレクトリを指すものは対象外
+	local := uris[:0]
+	for _, uri := range uris {
+		if strings.Contains(uri, "://") || strings.HasPrefix(uri, "/") || strings.Contains(uri, "..") {
+			continue
+		}
+		local = append(local, uri)
+	}
+	return local
+}
+
+// uriAttribute はタグ行の URI="..." 属性の値を返す
+func uriAttribute(line string) string {
+	const attr = `URI="`
+	start := strings.Index(line, attr)
+	if start < 0 {
+		return ""
+	}
+	start += len(attr)
+	end := strings.Index(line[start:], `"`)
+	if end < 0 {
+		return ""
+	}
+	return line[start : start+end]
+}
+
+// referencesPlaylist は参照先にプレイリストが含まれるか（マスタープレイリストか）を返す
+func referencesPlaylist(uris []string) bool {
+	for _, uri := range uris {
+		if strings.HasSuffix(uri, ".m3u8") {
+			return true
+		}
+	}
+	return false
+}
+
+// isManifestFile はプレイリストまたはマニフェストかどうかを返す
+func isManifestFile(path string) bool {
+	return strings.HasSuffix(path, ".m3u8") || strings.HasSuffix(path, ".mpd")
+}
@@ -0,0 +1,280 @@
+package uploader
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingUploader はアップロードされたキーを順番に記録するテスト用 Uploader
+type recordingUploader struct {
+	mu   sync.Mutex
+	keys []string
+}
+
+func (u *recordingUploader) Upload(ctx context.Context, localPath, remotePath string) (string, error) {
+	if _, err := os.Stat(localPath); err != nil {
+		return "", err
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.keys = append(u.keys, remotePath)
+	return "mem://" + remotePath, nil
+}
+
+func (u *recordingUploader) UploadDirectory(ctx context.Context, localDir, remoteDir string) (string, []UploadedFile, error) {
+	return "", nil, nil
+}
+
+func (u *recordingUploader) Delete(ctx context.Context, remotePath string) error {
+	return nil
+}
+
+func (u *recordingUploader) DeleteDirectory(ctx context.Context, remoteDir string) error {
+	return nil
+}
+
+func (u *recordingUploader) Exists(ctx context.Context, remotePath string) (bool, int64, error) {
+	return false, 0, nil
+}
+
+func (u *recordingUploader) uploaded() []string {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return append([]string(nil), u.keys...)
+}
+
+// writeTestFile はテスト用のファイルを書き込む
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("ファイルの書き込みに失敗: %v", err)
+	}
+}
+
+// indexOf は keys 内の key の位置を返す（存在しない場合は -1）
+func indexOf(keys []string, key string) int {
+	for i, k := range keys {
+		if k == key {
+			return i
+		}
+	}
+	return -1
+}
+
+const (
+	testPlaylistOneSegment  = "#EXTM3U\n#EXT-X-TARGETDURATION:6\n#EXTINF:6.0,\nsegment_000.ts\n"
+	testPlaylistTwoSegments = testPlaylistOneSegment + "#EXTINF:6.0,\nsegment_001.ts\n"
+)
+
+func TestIncrementalUploaderがプレイリストに追加されたセグメントを先にアップロードする(t *testing.T) {
+	dir := t.TempDir()
+	up := &recordingUploader{}
+	iu := NewIncrementalUploader(up, dir, "videos/job", time.Second)
+	ctx := context.Background()
+
+	// 1つ目のセグメントが完成し、2つ目は書き込み中（プレイリスト未記載）
+	writeTestFile(t, filepath.Join(dir, "segment_000.ts"), "seg0")
+	writeTestFile(t, filepath.Join(dir, "segment_001.ts"), "se")
+	writeTestFile(t, filepath.Join(dir, "playlist.m3u8"), testPlaylistOneSegment)
+
+	if err := iu.Sync(ctx); err != nil {
+		t.Fatalf("Sync に失敗: %v", err)
+	}
+
+	want := []string{"videos/job/segment_000.ts", "videos/job/playlist.m3u8"}
+	got := up.uploaded()
+	if len(got) != len(want) {
+		t.Fatalf("アップロードされたファイルが一致しない: 期待値 %v, 取得値 %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("アップロード順が一致しない: 期待値 %v, 取得値 %v", want, got)
+		}
+	}
+
+	// 2つ目のセグメントが完成してプレイリストに追記される
+	writeTestFile(t, filepath.Join(dir, "segment_001.ts"), "seg1")
+	writeTestFile(t, filepath.Join(dir, "playlist.m3u8"), testPlaylistTwoSegments)
+
+	if err := iu.Sync(ctx); err != nil {
+		t.Fatalf("Sync に失敗: %v", err)
+	}
+
+	want = append(want, "videos/job/segment_001.ts", "videos/job/playlist.m3u8")
+	got = up.uploaded()
+	if len(got) != len(want) {
+		t.Fatalf("アップロードされたファイルが一致しない: 期待値 %v, 取得値 %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("アップロード順が一致しない: 期待値 %v, 取得値 %v", want, got)
+		}
+	}
+}
+
+func TestIncrementalUploaderが変更のないファイルを再アップロードしない(t *testing.T) {
+	dir := t.TempDir()
+	up := &recordingUploader{}
+	iu := NewIncrementalUploader(up, dir, "videos/job", time.Second)
+	ctx := context.Background()
+
+	writeTestFile(t, filepath.Join(dir, "segment_000.ts"), "seg0")
+	writeTestFile(t, filepath.Join(dir, "playlist.m3u8"), testPlaylistOneSegment)
+
+	for i := 0; i < 3; i++ {
+		if err := iu.Sync(ctx); err != nil {
+			t.Fatalf("Sync に失敗: %v", err)
+		}
+	}
+
+	if got := up.uploaded(); len(got) != 2 {
+		t.Errorf("アップロード回数が一致しない: 期待値 2, 取得値 %d (%v)", len(got), got)
+	}
+}
+
+func TestIncrementalUploaderが存在しないセグメントを参照するプレイリストを保留する(t *testing.T) {
+	dir := t.TempDir()
+	up := &recordingUploader{}
+	iu := NewIncrementalUploader(up, dir, "videos/job", time.Second)
+
+	writeTestFile(t, filepath.Join(dir, "segment_000.ts"), "seg0")
+	writeTestFile(t, filepath.Join(dir, "playlist.m3u8"), testPlaylistTwoSegments)
+
+	if err := iu.Sync(context.Background()); err != nil {
+		t.Fatalf("Sync に失敗: %v", err)
+	}
+
+	got := up.uploaded()
+	if indexOf(got, "videos/job/playlist.m3u8") != -1 {
+		t.Errorf("参照先がそろっていないプレイリストがアップロードされた: %v", got)
+	}
+	if indexOf(got, "videos/job/segment_000.ts") == -1 {
+		t.Errorf("完成したセグメントがアップロードされていない: %v", got)
+	}
+}
+
+func TestIncrementalUploaderがマスタープレイリストをメディアプレイリストの後にアップロードする(t *testing.T) {
+	dir := t.TempDir()
+	up := &recordingUploader{}
+	iu := NewIncrementalUploader(up, dir, "videos/job", time.Second)
+
+	writeTestFile(t, filepath.Join(dir, "master.m3u8"), "#EXTM3U\n#EXT-X-STREAM-INF:BANDWIDTH=800000\nstream_0.m3u8\n")
+	writeTestFile(t, filepath.Join(dir, "stream_0.m3u8"), "#EXTM3U\n#EXTINF:6.0,\nsegment_0_000.ts\n")
+	writeTestFile(t, filepath.Join(dir, "segment_0_000.ts"), "seg0")
+
+	if err := iu.Sync(context.Background()); err != nil {
+		t.Fatalf("Sync に失敗: %v", err)
+	}
+
+	got := up.uploaded()
+	segment := indexOf(got, "videos/job/segment_0_000.ts")
+	media := indexOf(got, "videos/job/stream_0.m3u8")
+	master := indexOf(got, "videos/job/master.m3u8")
+	if segment == -1 || media == -1 || master == -1 {
+		t.Fatalf("すべてのファイルがアップロードされていない: %v", got)
+	}
+	if !(segment < media && media < master) {
+		t.Errorf("アップロード順が正しくない: %v", got)
+	}
+}
+
+func TestIncrementalUploaderのFinishが残りのファイルをアップロードしてマスターのURLを返す(t *testing.T) {
+	dir := t.TempDir()
+	up := &recordingUploader{}
+	iu := NewIncrementalUploader(up, dir, "videos/job", time.Second)
+	ctx := context.Background()
+
+	writeTestFile(t, filepath.Join(dir, "segment_000.ts"), "seg0")
+	writeTestFile(t, filepath.Join(dir, "playlist.m3u8"), testPlaylistOneSegment)
+	if err := iu.Sync(ctx); err != nil {
+		t.Fatalf("Sync に失敗: %v", err)
+	}
+
+	// エンコード完了後に追加・書き換えられたファイル
+	writeTestFile(t, filepath.Join(dir, "segment_001.ts"), "seg1")
+	writeTestFile(t, filepath.Join(dir, "thumbnail.jpg"), "jpeg")
+	writeTestFile(t, filepath.Join(dir, "playlist.m3u8"), testPlaylistTwoSegments+"#EXT-X-ENDLIST\n")
+
+	url, uploadedFiles, err := iu.Finish(ctx)
+	if err != nil {
+		t.Fatalf("Finish に失敗: %v", err)
+	}
+	if url != "mem://videos/job/playlist.m3u8" {
+		t.Errorf("URL が一致しない: 期待値 %s, 取得値 %s", "mem://videos/job/playlist.m3u8", url)
+	}
+
+	got := up.uploaded()
+	want := []string{
+		"videos/job/segment_000.ts",
+		"videos/job/playlist.m3u8",
+		"videos/job/segment_001.ts",
+		"videos/job/thumbnail.jpg",
+		"videos/job/playlist.m3u8",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("アップロードされたファイルが一致しない: 期待値 %v, 取得値 %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("アップロード順が一致しない: 期待値 %v, 取得値 %v", want, got)
+		}
+	}
+
+	// エンコード中にアップロードしたファイルも一覧に含まれる（重複なし）
+	wantFiles := []UploadedFile{
+		{Path: "segment_000.ts", URL: "mem://videos/job/segment_000.ts"},
+		{Path: "segment_001.ts", URL: "mem://videos/job/segment_001.ts"},
+		{Path: "thumbnail.jpg", URL: "mem://videos/job/thumbnail.jpg"},
+		{Path: "playlist.m3u8", URL: "mem://videos/job/playlist.m3u8"},
+	}
+	if !reflect.DeepEqual(uploadedFiles, wantFiles) {
+		t.Errorf("アップロードしたファイルの一覧が一致しない: 期待値 %v, 取得値 %v", wantFiles, uploadedFiles)
+	}
+}
+
+func TestIncrementalUploaderがエンコード中の出力を逐次アップロードする(t *testing.T) {
+	dir := t.TempDir()
+	up := &recordingUploader{}
+	iu := NewIncrementalUploader(up, dir, "videos/job", 10*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		iu.Run(ctx)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	// ffmpeg と同様にセグメントを書き終えてからプレイリストに追記する
+	playlist := "#EXTM3U\n#EXT-X-TARGETDURATION:6\n"
+	for i, name := range []string{"segment_000.ts", "segment_001.ts", "segment_002.ts"} {
+		writeTestFile(t, filepath.Join(dir, name), "segment")
+		playlist += "#EXTINF:6.0,\n" + name + "\n"
+		writeTestFile(t, filepath.Join(dir, "playlist.m3u8"), playlist)
+
+		// 完成したセグメントがエンコード完了を待たずにアップロードされるのを待つ
+		key := "videos/job/" + name
+		deadline := time.Now().Add(5 * time.Second)
+		for indexOf(up.uploaded(), key) == -1 {
+			if time.Now().After(deadline) {
+				t.Fatalf("%d 番目のセグメントがアップロードされない: %v", i, up.uploaded())
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+
+	// すべてのセグメントはそれを参照するプレイリストより先にアップロードされている
+	got := up.uploaded()
+	firstPlaylist := indexOf(got, "videos/job/playlist.m3u8")
+	if firstPlaylist == -1 || indexOf(got, "videos/job/segment_000.ts") > firstPlaylist {
+		t.Errorf("セグメントがプレイリストより先にアップロードされていない: %v", got)
+	}
+}
@@ -0,0 +1,81 @@
+package uploader
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	// DefaultMaxMetadataEntries はジョブごとのメタデータの件数の上限のデフォルト値
+	DefaultMaxMetadataEntries = 20
+	// DefaultMaxMetadataBytes はジョブごとのメタデータの合計サイズ（キーと値のバイト数の合計）の上限のデフォルト値
+	// S3 のユーザー定義メタデータの上限（2KB）に合わせる
+	DefaultMaxMetadataBytes = 2048
+)
+
+// MetadataLimits はジョブごとのメタデータ（OutputConfig.metadata）の上限（0 以下の項目は制限しない）
+type MetadataLimits struct {
+	// MaxEntries はメタデータの件数の上限
+	MaxEntries int
+	// MaxBytes はサニタイズ後のキーと値の UTF-8 のバイト数の合計の上限
+	MaxBytes int
+}
+
+// DefaultMetadataLimits はメタデータの上限のデフォルト値を返す
+func DefaultMetadataLimits() MetadataLimits {
+	return MetadataLimits{MaxEntries: DefaultMaxMetadataEntries, MaxBytes: DefaultMaxMetadataBytes}
+}
+
+// SanitizeMetadataKey はメタデータのキーを S3 のユーザー定義メタデータ（x-amz-meta-*）に使える文字に変換する
+// S3 はキーを HTTP ヘッダー名として扱い小文字で保存するため、小文字にして英数字・ハイフン・アンダースコア以外をハイフンに置き換える
+func SanitizeMetadataKey(key string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(key)) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-', r == '_':
+			b.WriteRune(r)
+		default:
+			b.WriteByte('-')
+		}
+	}
+	return b.String()
+}
+
+// SanitizeMetadata はキーを SanitizeMetadataKey で変換したメタデータを返す
+// 上限を超える場合や、空のキー・変換後に重複するキーがある場合はエラーを返す
+func SanitizeMetadata(metadata map[string]string, limits MetadataLimits) (map[string]string, error) {
+	if len(metadata) == 0 {
+		return nil, nil
+	}
+	if limits.MaxEntries > 0 && len(metadata) > limits.MaxEntries {
+		return nil, fmt.Errorf("too many metadata entries: %d (max %d)", len(metadata), limits.MaxEntries)
+	}
+
+	// エラーメッセージが毎回同じになるよう、キーの順に処理する
+	keys := make([]string, 0, len(metadata))
+	for key := range metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	sanitized := make(map[string]string, len(metadata))
+	original := make(map[string]string, len(metadata))
+	size := 0
+	for _, key := range keys {
+		name := SanitizeMetadataKey(key)
+		if strings.Trim(name, "-_") == "" {
+			return nil, fmt.Errorf("invalid metadata key: %q (must contain letters or digits)", key)
+		}
+		if prev, ok := original[name]; ok {
+			return nil, fmt.Errorf("metadata keys %q and %q both become %q after sanitization", prev, key, name)
+		}
+		original[name] = key
+		sanitized[name] = metadata[key]
+		size += len(name) + len(metadata[key])
+	}
+	if limits.MaxBytes > 0 && size > limits.MaxBytes {
+		return nil, fmt.Errorf("metadata is too large: %d bytes of keys and values (max %d)", size, limits.MaxBytes)
+	}
+	return sanitized, nil
+}
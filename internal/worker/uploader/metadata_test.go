@@ -0,0 +1,81 @@
+package uploader
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestメタデータのキーがS3で使える文字に変換される(t *testing.T) {
+	tests := map[string]string{
+		"content-id":     "content-id",
+		"Content_ID":     "content_id",
+		" Title ":        "title",
+		"source url":     "source-url",
+		"x.amz:meta/tag": "x-amz-meta-tag",
+		"タイトル":           "----",
+	}
+	for key, want := range tests {
+		if got := SanitizeMetadataKey(key); got != want {
+			t.Errorf("%q の変換結果が一致しない: 期待値 %q, 取得値 %q", key, want, got)
+		}
+	}
+}
+
+func TestSanitizeMetadataがキーを変換したメタデータを返す(t *testing.T) {
+	metadata, err := SanitizeMetadata(map[string]string{"Content ID": "abc", "owner": "team-a"}, DefaultMetadataLimits())
+	if err != nil {
+		t.Fatalf("SanitizeMetadata に失敗: %v", err)
+	}
+	if len(metadata) != 2 || metadata["content-id"] != "abc" || metadata["owner"] != "team-a" {
+		t.Errorf("メタデータが一致しない: %v", metadata)
+	}
+
+	if metadata, err := SanitizeMetadata(nil, DefaultMetadataLimits()); err != nil || metadata != nil {
+		t.Errorf("空のメタデータが nil にならない: %v, %v", metadata, err)
+	}
+}
+
+func Test上限を超えるメタデータはエラーになる(t *testing.T) {
+	limits := MetadataLimits{MaxEntries: 2, MaxBytes: 32}
+
+	tests := []struct {
+		name     string
+		metadata map[string]string
+		want     string
+	}{
+		{
+			name:     "件数の上限",
+			metadata: map[string]string{"a": "1", "b": "2", "c": "3"},
+			want:     "too many metadata entries: 3 (max 2)",
+		},
+		{
+			name:     "合計サイズの上限",
+			metadata: map[string]string{"description": strings.Repeat("x", 22)},
+			want:     "metadata is too large: 33 bytes of keys and values (max 32)",
+		},
+		{
+			name:     "変換後に重複するキー",
+			metadata: map[string]string{"Owner": "a", "owner": "b"},
+			want:     `metadata keys "Owner" and "owner" both become "owner" after sanitization`,
+		},
+		{
+			name:     "英数字を含まないキー",
+			metadata: map[string]string{"!!!": "a"},
+			want:     `invalid metadata key: "!!!"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := SanitizeMetadata(tt.metadata, limits)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("エラーが一致しない: 期待値 %q, 取得値 %v", tt.want, err)
+			}
+		})
+	}
+
+	// 0 の項目は制限しない
+	if _, err := SanitizeMetadata(map[string]string{"a": "1", "b": "2", "c": strings.Repeat("x", 100)}, MetadataLimits{}); err != nil {
+		t.Errorf("上限なしでエラーになった: %v", err)
+	}
+}
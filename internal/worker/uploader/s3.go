@@ -2,26 +2,48 @@ package uploader
 
 import (
 	"context"
+	"encoding/base64"
+	"errors"
 	"fmt"
 	"io"
 	"io/fs"
+	"net/http"
 	"os"
+	"path"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 	"github.com/nzws/flux-encoder/internal/shared/logger"
 	"github.com/nzws/flux-encoder/internal/shared/retry"
 	"go.uber.org/zap"
 )
 
+const (
+	// DefaultS3UploadPartSizeMB はマルチパートアップロードのパートサイズ（MB）のデフォルト値
+	// これより小さいファイルは 1 回の PutObject でアップロードする
+	DefaultS3UploadPartSizeMB = 64
+	// DefaultS3UploadConcurrency はマルチパートアップロードで同時に送信するパート数のデフォルト値
+	DefaultS3UploadConcurrency = manager.DefaultUploadConcurrency
+)
+
 // S3Uploader はS3にファイルをアップロードする
 type S3Uploader struct {
 	client *s3.Client
-	bucket string
-	region string
+	// transfer はパートサイズを超えるファイルをマルチパートで並行アップロードする
+	transfer *manager.Uploader
+	bucket   string
+	region   string
+	// sseKMSKeyID は SSE-KMS に使用する KMS キーの既定値（空の場合はバケットの既定の暗号化に従う）
+	sseKMSKeyID string
+	// verifyChecksum はアップロードしたオブジェクトの MD5 をローカルのファイルと照合するか
+	verifyChecksum bool
 }
 
 // NewS3Uploader は新しい S3Uploader を作成する
@@ -32,13 +54,55 @@ func NewS3Uploader(ctx context.Context, bucket, region string) (*S3Uploader, err
 		return nil, fmt.Errorf("failed to load AWS config: %w", err)
 	}
 
+	client := s3.NewFromConfig(cfg)
 	return &S3Uploader{
-		client: s3.NewFromConfig(cfg),
-		bucket: bucket,
-		region: region,
+		client:   client,
+		transfer: newS3TransferUploader(client, DefaultS3UploadPartSizeMB<<20, DefaultS3UploadConcurrency),
+		bucket:   bucket,
+		region:   region,
 	}, nil
 }
 
+// SetMultipartUpload はマルチパートアップロードのパートサイズ（MB）と同時に送信するパート数を設定する
+func (u *S3Uploader) SetMultipartUpload(partSizeMB, concurrency int) error {
+	partSize := int64(partSizeMB) << 20
+	if partSize < manager.MinUploadPartSize {
+		return fmt.Errorf("part size must be at least %d MB: %d", manager.MinUploadPartSize>>20, partSizeMB)
+	}
+	if concurrency < 1 {
+		return fmt.Errorf("concurrency must be at least 1: %d", concurrency)
+	}
+
+	u.transfer = newS3TransferUploader(u.client, partSize, concurrency)
+	return nil
+}
+
+// SetSSEKMSKeyID はアップロードするオブジェクトを SSE-KMS で暗号化する KMS キーの既定値を設定する
+// ジョブごとに WithSSEKMSKeyID で指定された場合はそちらを優先する
+func (u *S3Uploader) SetSSEKMSKeyID(keyID string) error {
+	if keyID != "" {
+		if err := ValidateKMSKeyID(keyID); err != nil {
+			return err
+		}
+	}
+	u.sseKMSKeyID = keyID
+	return nil
+}
+
+// SetVerifyChecksum はアップロードしたオブジェクトのチェックサムを確認するかを設定する
+// 有効な場合は単一パートのアップロードに Content-MD5 を付け、S3 が返した ETag をローカルで計算した値と照合する
+func (u *S3Uploader) SetVerifyChecksum(enabled bool) {
+	u.verifyChecksum = enabled
+}
+
+// newS3TransferUploader はパートサイズと並行数を指定した manager.Uploader を作成する
+func newS3TransferUploader(client manager.UploadAPIClient, partSize int64, concurrency int) *manager.Uploader {
+	return manager.NewUploader(client, func(mu *manager.Uploader) {
+		mu.PartSize = partSize
+		mu.Concurrency = concurrency
+	})
+}
+
 // Upload はファイルをS3にアップロードする
 func (u *S3Uploader) Upload(ctx context.Context, localPath string, remotePath string) (string, error) {
 	// ファイルを開く
@@ -58,53 +122,255 @@ func (u *S3Uploader) Upload(ctx context.Context, localPath string, remotePath st
 		return "", fmt.Errorf("failed to get file info: %w", err)
 	}
 
+	input := &s3.PutObjectInput{
+		Bucket: aws.String(u.bucket),
+		Key:    aws.String(remotePath),
+		Body:   file,
+	}
+	// SSE-KMS（ジョブごとの指定を Worker の既定値より優先する）
+	kmsKeyID := sseKMSKeyIDFromContext(ctx, u.sseKMSKeyID)
+	if kmsKeyID != "" {
+		input.ServerSideEncryption = types.ServerSideEncryptionAwsKms
+		input.SSEKMSKeyId = aws.String(kmsKeyID)
+	}
+
+	// チェックサムの確認が有効な場合は、アップロード前にファイルの MD5 と期待する ETag を計算する
+	// パートサイズ以下のファイルは 1 回の PutObject になるため、Content-MD5 を付けて S3 側でも検証させる
+	var checksum s3Checksum
+	if u.verifyChecksum {
+		checksum, err = computeS3Checksum(file, u.transfer.PartSize)
+		if err != nil {
+			return "", err
+		}
+		if fileInfo.Size() <= u.transfer.PartSize {
+			input.ContentMD5 = aws.String(base64.StdEncoding.EncodeToString(checksum.md5))
+		}
+	}
+
 	logger.Info("Uploading to S3",
 		zap.String("bucket", u.bucket),
 		zap.String("key", remotePath),
 		zap.Int64("size", fileInfo.Size()),
+		zap.Bool("sse_kms", kmsKeyID != ""),
+		zap.Bool("verify_checksum", u.verifyChecksum),
 	)
 
-	// S3にアップロード（リトライあり）
-	err = retry.Do(ctx, retry.DefaultConfig, func() error {
+	// S3にアップロード（リトライあり、ジョブごとの設定を優先。4xx エラーはリトライしない）
+	// 経過時間はリトライの待機を含めたアップロード全体の時間
+	// パートサイズを超えるファイルはマルチパートで並行アップロードし、失敗した場合はアップロード全体をやり直す
+	// ETag がチェックサムと一致しない場合も、転送中にデータが壊れた可能性があるためやり直す
+	uploadStart := time.Now()
+	retryConfig := retry.FromContext(ctx, retry.DefaultConfig)
+	retryConfig.IsRetryable = isRetryableS3Error
+	err = retry.Do(ctx, retryConfig, func() error {
 		// ファイルポインタを先頭に戻す
 		if _, seekErr := file.Seek(0, 0); seekErr != nil {
 			return fmt.Errorf("failed to seek file: %w", seekErr)
 		}
 
-		_, putErr := u.client.PutObject(ctx, &s3.PutObjectInput{
-			Bucket: aws.String(u.bucket),
-			Key:    aws.String(remotePath),
-			Body:   file,
-		})
-		return putErr
+		out, putErr := u.transfer.Upload(ctx, input)
+		if putErr != nil || !u.verifyChecksum {
+			return putErr
+		}
+		if verifyErr := verifyS3ETag(remotePath, checksum.etag, out); verifyErr != nil {
+			logger.Warn("Uploaded object checksum mismatch", zap.String("key", remotePath), zap.Error(verifyErr))
+			return verifyErr
+		}
+		return nil
 	})
+	elapsed := time.Since(uploadStart)
 	if err != nil {
+		logger.Warn("Upload to S3 failed",
+			zap.String("key", remotePath),
+			zap.Int64("size", fileInfo.Size()),
+			zap.Duration("elapsed", elapsed),
+		)
 		return "", fmt.Errorf("failed to upload to S3 after retries: %w", err)
 	}
 
+	trackUpload(ctx, remotePath)
+
 	// URLを生成
 	url := fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", u.bucket, u.region, remotePath)
 
 	logger.Info("Upload completed",
 		zap.String("url", url),
+		zap.Int64("size", fileInfo.Size()),
+		zap.Duration("elapsed", elapsed),
+		zap.Float64("throughput_mbps", ThroughputMBps(fileInfo.Size(), elapsed)),
 	)
 
 	return url, nil
 }
 
+// Delete は S3 のオブジェクトを削除する（存在しないキーの削除も成功する）
+func (u *S3Uploader) Delete(ctx context.Context, remotePath string) error {
+	_, err := u.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(u.bucket),
+		Key:    aws.String(remotePath),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete from S3: %w", err)
+	}
+	return nil
+}
+
+// Exists は HeadObject で S3 のオブジェクトが存在するかとそのサイズを返す
+func (u *S3Uploader) Exists(ctx context.Context, remotePath string) (bool, int64, error) {
+	output, err := u.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(u.bucket),
+		Key:    aws.String(remotePath),
+	})
+	if err != nil {
+		if isS3NotFound(err) {
+			return false, 0, nil
+		}
+		return false, 0, fmt.Errorf("failed to head S3 object: %w", err)
+	}
+	return true, aws.ToInt64(output.ContentLength), nil
+}
+
+// isS3NotFound はオブジェクトが存在しないことを表すエラーかを返す
+// HeadObject はレスポンスボディがないため、NotFound 型にならず 404 のみが返る場合がある
+func isS3NotFound(err error) bool {
+	var notFound *types.NotFound
+	if errors.As(err, &notFound) {
+		return true
+	}
+	var noSuchKey *types.NoSuchKey
+	if errors.As(err, &noSuchKey) {
+		return true
+	}
+	var respErr interface{ HTTPStatusCode() int }
+	return errors.As(err, &respErr) && respErr.HTTPStatusCode() == http.StatusNotFound
+}
+
+// DeleteDirectory は remoteDir 以下の S3 のオブジェクトをすべて削除する
+// ListObjectsV2 の1ページ（最大 1000 件）ごとに DeleteObjects でまとめて削除する
+func (u *S3Uploader) DeleteDirectory(ctx context.Context, remoteDir string) error {
+	prefix, err := directoryPrefix(remoteDir)
+	if err != nil {
+		return err
+	}
+
+	paginator := s3.NewListObjectsV2Paginator(u.client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(u.bucket),
+		Prefix: aws.String(prefix),
+	})
+	deleted := 0
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to list S3 objects: %w", err)
+		}
+		if len(page.Contents) == 0 {
+			continue
+		}
+
+		objects := make([]types.ObjectIdentifier, 0, len(page.Contents))
+		for _, object := range page.Contents {
+			objects = append(objects, types.ObjectIdentifier{Key: object.Key})
+		}
+		output, err := u.client.DeleteObjects(ctx, &s3.DeleteObjectsInput{
+			Bucket: aws.String(u.bucket),
+			Delete: &types.Delete{Objects: objects, Quiet: aws.Bool(true)},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to delete from S3: %w", err)
+		}
+		if len(output.Errors) > 0 {
+			first := output.Errors[0]
+			return fmt.Errorf("failed to delete %d objects from S3: %s: %s",
+				len(output.Errors), aws.ToString(first.Key), aws.ToString(first.Message))
+		}
+		deleted += len(objects)
+	}
+
+	logger.Info("Directory deleted",
+		zap.String("prefix", prefix),
+		zap.Int("objects", deleted),
+	)
+	return nil
+}
+
+// Download は S3 のオブジェクトをローカルファイルにダウンロードする
+// アップロード先とは別のバケットも指定できる（認証情報・リージョンはアップロードと共通）
+func (u *S3Uploader) Download(ctx context.Context, bucket, key, localPath string) error {
+	logger.Info("Downloading from S3",
+		zap.String("bucket", bucket),
+		zap.String("key", key),
+	)
+
+	retryConfig := retry.FromContext(ctx, retry.DefaultConfig)
+	retryConfig.IsRetryable = isRetryableS3Error
+	err := retry.Do(ctx, retryConfig, func() error {
+		out, getErr := u.client.GetObject(ctx, &s3.GetObjectInput{
+			Bucket: aws.String(bucket),
+			Key:    aws.String(key),
+		})
+		if getErr != nil {
+			return getErr
+		}
+		defer func() {
+			if err := out.Body.Close(); err != nil {
+				logger.Warn("Failed to close S3 object body", zap.Error(err))
+			}
+		}()
+
+		return writeFile(localPath, out.Body)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to download from S3 after retries: %w", err)
+	}
+	return nil
+}
+
+// writeFile は r の内容を localPath に書き込む（既存のファイルは上書きする）
+func writeFile(localPath string, r io.Reader) error {
+	file, err := os.Create(localPath)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	if _, err := io.Copy(file, r); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to close file: %w", err)
+	}
+	return nil
+}
+
+// isRetryableS3Error は S3 のエラーをリトライするかを判定する
+// 4xx のクライアントエラー（権限不足、バケットが存在しないなど）はリトライしても成功しないため false を返す
+// ただしタイムアウト（408）とスロットリング（429）、5xx、ネットワークエラーはリトライする
+func isRetryableS3Error(err error) bool {
+	var respErr interface{ HTTPStatusCode() int }
+	if !errors.As(err, &respErr) {
+		return true
+	}
+
+	code := respErr.HTTPStatusCode()
+	if code == http.StatusRequestTimeout || code == http.StatusTooManyRequests {
+		return true
+	}
+	return code < 400 || code >= 500
+}
+
 // UploadDirectory はディレクトリ全体を再帰的にS3にアップロードする
-func (u *S3Uploader) UploadDirectory(ctx context.Context, localDir string, remoteDir string) (string, error) {
-	uploadedFiles, err := u.uploadDirectoryFiles(ctx, localDir, remoteDir)
+func (u *S3Uploader) UploadDirectory(ctx context.Context, localDir string, remoteDir string) (string, []UploadedFile, error) {
+	uploadStart := time.Now()
+	uploadedFiles, err := uploadDirectoryFiles(ctx, u, localDir, remoteDir)
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
 
 	// マスタープレイリスト/マニフェストのURLを返す
 	// HLS: master.m3u8 or playlist.m3u8
 	// DASH: manifest.mpd
-	masterFile, err := findMasterFile(uploadedFiles)
+	masterFile, err := findMasterFile(uploadedPaths(uploadedFiles))
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
 
 	// S3のキーをスラッシュ区切りに変換
@@ -115,9 +381,10 @@ func (u *S3Uploader) UploadDirectory(ctx context.Context, localDir string, remot
 	logger.Info("Directory upload completed",
 		zap.String("url", masterURL),
 		zap.Int("files", len(uploadedFiles)),
+		zap.Duration("elapsed", time.Since(uploadStart)),
 	)
 
-	return masterURL, nil
+	return masterURL, uploadedFiles, nil
 }
 
 // NewUploader は環境変数から適切な Uploader を作成する
@@ -132,20 +399,87 @@ func NewUploader(ctx context.Context, storageType string) (Uploader, error) {
 		if region == "" {
 			region = "us-east-1" // デフォルト
 		}
-		return NewS3Uploader(ctx, bucket, region)
+		verifyChecksum, err := envBool("UPLOAD_VERIFY_CHECKSUM", true)
+		if err != nil {
+			return nil, err
+		}
+		partSizeMB, err := envInt("S3_UPLOAD_PART_SIZE_MB", DefaultS3UploadPartSizeMB)
+		if err != nil {
+			return nil, err
+		}
+		concurrency, err := envInt("S3_UPLOAD_CONCURRENCY", DefaultS3UploadConcurrency)
+		if err != nil {
+			return nil, err
+		}
+		uploader, err := NewS3Uploader(ctx, bucket, region)
+		if err != nil {
+			return nil, err
+		}
+		if err := uploader.SetMultipartUpload(partSizeMB, concurrency); err != nil {
+			return nil, fmt.Errorf("invalid S3 multipart upload settings: %w", err)
+		}
+		if err := uploader.SetSSEKMSKeyID(os.Getenv("S3_SSE_KMS_KEY_ID")); err != nil {
+			return nil, fmt.Errorf("invalid S3_SSE_KMS_KEY_ID: %w", err)
+		}
+		uploader.SetVerifyChecksum(verifyChecksum)
+		return uploader, nil
+
+	case "gcs":
+		bucket := os.Getenv("GCS_BUCKET")
+		if bucket == "" {
+			return nil, fmt.Errorf("GCS_BUCKET environment variable is required")
+		}
+		return NewGCSUploader(ctx, bucket)
 
 	case "local":
 		// テスト用: ローカルファイルシステムに保存
-		return &LocalUploader{baseDir: os.Getenv("LOCAL_STORAGE_DIR")}, nil
+		verifyChecksum, err := envBool("UPLOAD_VERIFY_CHECKSUM", true)
+		if err != nil {
+			return nil, err
+		}
+		return &LocalUploader{baseDir: os.Getenv("LOCAL_STORAGE_DIR"), verifyChecksum: verifyChecksum}, nil
 
 	default:
 		return nil, fmt.Errorf("unsupported storage type: %s", storageType)
 	}
 }
 
+// envInt は環境変数を整数として読み込む（未設定の場合は defaultValue）
+func envInt(key string, defaultValue int) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be an integer: %s", key, value)
+	}
+	return n, nil
+}
+
+// envBool は環境変数を真偽値として読み込む（未設定の場合は defaultValue）
+func envBool(key string, defaultValue bool) (bool, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("%s must be a boolean: %s", key, value)
+	}
+	return b, nil
+}
+
 // LocalUploader はローカルファイルシステムにファイルを保存する（テスト用）
 type LocalUploader struct {
 	baseDir string
+	// verifyChecksum はコピー後にコピー元とコピー先の SHA-256 を照合するか
+	verifyChecksum bool
+}
+
+// SetVerifyChecksum はコピーしたファイルのチェックサムを確認するかを設定する
+func (u *LocalUploader) SetVerifyChecksum(enabled bool) {
+	u.verifyChecksum = enabled
 }
 
 // Upload はファイルをローカルにコピーする
@@ -158,53 +492,86 @@ func (u *LocalUploader) Upload(ctx context.Context, localPath string, remotePath
 	}
 
 	// ファイルをストリーミングコピー（メモリ効率的）
-	srcFile, err := os.Open(localPath)
-	if err != nil {
-		return "", fmt.Errorf("failed to open source file: %w", err)
+	if err := copyFile(localPath, destPath, u.verifyChecksum); err != nil {
+		return "", err
 	}
-	defer func() {
-		if err := srcFile.Close(); err != nil {
-			logger.Warn("Failed to close source file", zap.Error(err))
-		}
-	}()
+	trackUpload(ctx, remotePath)
 
-	dstFile, err := os.Create(destPath)
-	if err != nil {
-		return "", fmt.Errorf("failed to create destination file: %w", err)
+	return "file://" + destPath, nil
+}
+
+// LocalPath は remotePath に保存したファイルのローカルのパスを返す
+// remotePath に .. が含まれていても baseDir の外は指さない
+func (u *LocalUploader) LocalPath(remotePath string) string {
+	return filepath.Join(u.baseDir, filepath.FromSlash(path.Clean("/"+filepath.ToSlash(remotePath))))
+}
+
+// Delete はローカルに保存したファイルを削除する（remotePath に .. が含まれていても baseDir の外は削除しない）
+func (u *LocalUploader) Delete(ctx context.Context, remotePath string) error {
+	if err := os.Remove(u.LocalPath(remotePath)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete file: %w", err)
 	}
-	defer func() {
-		if err := dstFile.Close(); err != nil {
-			logger.Warn("Failed to close destination file", zap.Error(err))
-		}
-	}()
+	return nil
+}
 
-	// ストリーミングコピー
-	if _, err := io.Copy(dstFile, srcFile); err != nil {
-		return "", fmt.Errorf("failed to copy file: %w", err)
+// Exists はローカルにファイルが保存されているかとそのサイズを返す（ディレクトリは存在しないものとして扱う）
+func (u *LocalUploader) Exists(ctx context.Context, remotePath string) (bool, int64, error) {
+	info, err := os.Stat(u.LocalPath(remotePath))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, 0, nil
+		}
+		return false, 0, fmt.Errorf("failed to stat file: %w", err)
 	}
+	if info.IsDir() {
+		return false, 0, nil
+	}
+	return true, info.Size(), nil
+}
 
-	return "file://" + destPath, nil
+// DeleteDirectory はローカルに保存したディレクトリを削除する
+func (u *LocalUploader) DeleteDirectory(ctx context.Context, remoteDir string) error {
+	prefix, err := directoryPrefix(remoteDir)
+	if err != nil {
+		return err
+	}
+	if err := os.RemoveAll(filepath.Join(u.baseDir, filepath.FromSlash(prefix))); err != nil {
+		return fmt.Errorf("failed to delete directory: %w", err)
+	}
+	return nil
 }
 
 // UploadDirectory はディレクトリをローカルにコピーする
-func (u *LocalUploader) UploadDirectory(ctx context.Context, localDir string, remoteDir string) (string, error) {
+func (u *LocalUploader) UploadDirectory(ctx context.Context, localDir string, remoteDir string) (string, []UploadedFile, error) {
 	destDir := filepath.Join(u.baseDir, remoteDir)
-	uploadedFiles, err := copyDirectory(localDir, destDir)
+	copiedFiles, err := copyDirectory(localDir, destDir, u.verifyChecksum)
+	for _, relPath := range copiedFiles {
+		trackUpload(ctx, filepath.ToSlash(filepath.Join(remoteDir, relPath)))
+	}
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
 
-	masterFile, err := findMasterFile(uploadedFiles)
+	masterFile, err := findMasterFile(copiedFiles)
 	if err != nil {
-		return "", err
+		return "", nil, err
+	}
+
+	uploadedFiles := make([]UploadedFile, 0, len(copiedFiles))
+	for _, relPath := range copiedFiles {
+		uploadedFiles = append(uploadedFiles, UploadedFile{
+			Path: filepath.ToSlash(relPath),
+			URL:  "file://" + filepath.Join(destDir, relPath),
+		})
 	}
 
 	masterPath := filepath.Join(destDir, masterFile)
-	return "file://" + masterPath, nil
+	return "file://" + masterPath, uploadedFiles, nil
 }
 
-func (u *S3Uploader) uploadDirectoryFiles(ctx context.Context, localDir, remoteDir string) ([]string, error) {
-	var uploadedFiles []string
+// uploadDirectoryFiles はディレクトリ内のファイルを uploader で1つずつアップロードし、アップロードしたファイルの一覧を返す
+func uploadDirectoryFiles(ctx context.Context, uploader Uploader, localDir, remoteDir string) ([]UploadedFile, error) {
+	var uploadedFiles []UploadedFile
 	err := filepath.WalkDir(localDir, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
@@ -219,17 +586,18 @@ func (u *S3Uploader) uploadDirectoryFiles(ctx context.Context, localDir, remoteD
 			return err
 		}
 
-		s3Key := filepath.ToSlash(filepath.Join(remoteDir, relPath))
-		logger.Info("Uploading file to S3",
+		key := filepath.ToSlash(filepath.Join(remoteDir, relPath))
+		logger.Info("Uploading file",
 			zap.String("local", path),
-			zap.String("s3_key", s3Key),
+			zap.String("key", key),
 		)
 
-		if _, err := u.Upload(ctx, path, s3Key); err != nil {
+		url, err := uploader.Upload(ctx, path, key)
+		if err != nil {
 			return fmt.Errorf("failed to upload %s: %w", relPath, err)
 		}
 
-		uploadedFiles = append(uploadedFiles, relPath)
+		uploadedFiles = append(uploadedFiles, UploadedFile{Path: filepath.ToSlash(relPath), URL: url})
 		return nil
 	})
 	if err != nil {
@@ -238,7 +606,9 @@ func (u *S3Uploader) uploadDirectoryFiles(ctx context.Context, localDir, remoteD
 	return uploadedFiles, nil
 }
 
-func copyDirectory(srcDir, destDir string) ([]string, error) {
+// copyDirectory は srcDir を destDir に再帰的にコピーし、コピーしたファイルの相対パスを返す
+// verify が true の場合はファイルごとにコピー元とコピー先のチェックサムを照合する
+func copyDirectory(srcDir, destDir string, verify bool) ([]string, error) {
 	var uploadedFiles []string
 	err := filepath.WalkDir(srcDir, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
@@ -259,37 +629,24 @@ func copyDirectory(srcDir, destDir string) ([]string, error) {
 			return fmt.Errorf("failed to create directory: %w", err)
 		}
 
-		srcFile, err := os.Open(path)
-		if err != nil {
-			return fmt.Errorf("failed to open source file: %w", err)
-		}
-		defer func() {
-			if err := srcFile.Close(); err != nil {
-				logger.Warn("Failed to close source file", zap.Error(err))
-			}
-		}()
-
-		dstFile, err := os.Create(destPath)
-		if err != nil {
-			return fmt.Errorf("failed to create destination file: %w", err)
-		}
-		defer func() {
-			if err := dstFile.Close(); err != nil {
-				logger.Warn("Failed to close destination file", zap.Error(err))
-			}
-		}()
-
-		if _, err := io.Copy(dstFile, srcFile); err != nil {
-			return fmt.Errorf("failed to copy file: %w", err)
+		if err := copyFile(path, destPath, verify); err != nil {
+			return err
 		}
 
 		uploadedFiles = append(uploadedFiles, relPath)
 		return nil
 	})
-	if err != nil {
-		return nil, err
+	// エラーの場合もそれまでにコピーしたファイルを返す（途中までの出力を削除できるようにする）
+	return uploadedFiles, err
+}
+
+// uploadedPaths はアップロードしたファイルの相対パスの一覧を返す
+func uploadedPaths(files []UploadedFile) []string {
+	paths := make([]string, 0, len(files))
+	for _, file := range files {
+		paths = append(paths, file.Path)
 	}
-	return uploadedFiles, nil
+	return paths
 }
 
 func findMasterFile(files []string) (string, error) {
@@ -2,10 +2,22 @@ package uploader
 
 import (
 	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 )
 
 func TestLocalUploaderが単一ファイルをアップロードできる(t *testing.T) {
@@ -75,7 +87,7 @@ func TestLocalUploaderがディレクトリをアップロードできる(t *tes
 	uploader := &LocalUploader{baseDir: baseDir}
 
 	// ディレクトリをアップロード
-	url, err := uploader.UploadDirectory(context.Background(), srcDir, "uploads/hls")
+	url, uploadedFiles, err := uploader.UploadDirectory(context.Background(), srcDir, "uploads/hls")
 	if err != nil {
 		t.Fatalf("ディレクトリのアップロードに失敗: %v", err)
 	}
@@ -92,6 +104,19 @@ func TestLocalUploaderがディレクトリをアップロードできる(t *tes
 			t.Errorf("ファイル '%s' がコピーされていない", name)
 		}
 	}
+
+	// アップロードしたファイルの一覧に相対パスとコピー先の URL が含まれる
+	if len(uploadedFiles) != len(files) {
+		t.Fatalf("アップロードしたファイル数が一致しない: 期待値 %d, 取得値 %d", len(files), len(uploadedFiles))
+	}
+	for _, file := range uploadedFiles {
+		if _, ok := files[file.Path]; !ok {
+			t.Errorf("予期しないファイルが一覧に含まれる: %s", file.Path)
+		}
+		if expected := "file://" + filepath.Join(baseDir, "uploads/hls", file.Path); file.URL != expected {
+			t.Errorf("URL が一致しない: 期待値 %s, 取得値 %s", expected, file.URL)
+		}
+	}
 }
 
 func TestLocalUploaderがmaster_m3u8を優先して検出する(t *testing.T) {
@@ -116,7 +141,7 @@ func TestLocalUploaderがmaster_m3u8を優先して検出する(t *testing.T) {
 	}
 
 	uploader := &LocalUploader{baseDir: baseDir}
-	url, err := uploader.UploadDirectory(context.Background(), srcDir, "uploads/test")
+	url, _, err := uploader.UploadDirectory(context.Background(), srcDir, "uploads/test")
 	if err != nil {
 		t.Fatalf("アップロードに失敗: %v", err)
 	}
@@ -148,7 +173,7 @@ func TestLocalUploaderがplaylist_m3u8をフォールバックとして検出す
 	}
 
 	uploader := &LocalUploader{baseDir: baseDir}
-	url, err := uploader.UploadDirectory(context.Background(), srcDir, "uploads/test")
+	url, _, err := uploader.UploadDirectory(context.Background(), srcDir, "uploads/test")
 	if err != nil {
 		t.Fatalf("アップロードに失敗: %v", err)
 	}
@@ -180,7 +205,7 @@ func TestLocalUploaderがmanifest_mpdを検出する(t *testing.T) {
 	}
 
 	uploader := &LocalUploader{baseDir: baseDir}
-	url, err := uploader.UploadDirectory(context.Background(), srcDir, "uploads/dash")
+	url, _, err := uploader.UploadDirectory(context.Background(), srcDir, "uploads/dash")
 	if err != nil {
 		t.Fatalf("アップロードに失敗: %v", err)
 	}
@@ -212,7 +237,7 @@ func TestLocalUploaderでマスターファイルが見つからない場合は
 	}
 
 	uploader := &LocalUploader{baseDir: baseDir}
-	_, err := uploader.UploadDirectory(context.Background(), srcDir, "uploads/test")
+	_, _, err := uploader.UploadDirectory(context.Background(), srcDir, "uploads/test")
 	if err == nil {
 		t.Error("マスターファイルがないのにエラーが返されなかった")
 	}
@@ -276,6 +301,27 @@ func TestNewUploaderがs3タイプでエラーを返す(t *testing.T) {
 	}
 }
 
+func TestNewUploaderがgcsタイプでバケット未設定の場合エラーを返す(t *testing.T) {
+	mustUnsetenv(t, "GCS_BUCKET")
+
+	_, err := NewUploader(context.Background(), "gcs")
+	if err == nil {
+		t.Fatal("GCS_BUCKET が未設定なのにエラーが返されなかった")
+	}
+
+	if !strings.Contains(err.Error(), "GCS_BUCKET") {
+		t.Errorf("エラーメッセージが GCS_BUCKET を含んでいない: %v", err)
+	}
+}
+
+func TestGCSのオブジェクトURLが正しく生成される(t *testing.T) {
+	url := gcsObjectURL("my-bucket", "output/hls/master.m3u8")
+	expected := "https://storage.googleapis.com/my-bucket/output/hls/master.m3u8"
+	if url != expected {
+		t.Errorf("URL が一致しない: 期待値 %s, 取得値 %s", expected, url)
+	}
+}
+
 func TestNewUploaderがlocalタイプでLocalUploaderを返す(t *testing.T) {
 	tempDir := t.TempDir()
 	mustSetenv(t, "LOCAL_STORAGE_DIR", tempDir)
@@ -366,6 +412,52 @@ func mustUnsetenv(t *testing.T, key string) {
 	}
 }
 
+// statusCodeError は HTTP ステータスコードを持つ S3 のレスポンスエラーの代わり
+type statusCodeError struct {
+	code int
+}
+
+func (e *statusCodeError) Error() string {
+	return fmt.Sprintf("http status %d", e.code)
+}
+
+func (e *statusCodeError) HTTPStatusCode() int {
+	return e.code
+}
+
+func Testアップロードのスループットが計算される(t *testing.T) {
+	if got := ThroughputMBps(10<<20, 2*time.Second); got != 5 {
+		t.Errorf("スループットが一致しない: 期待値 5, 取得値 %v", got)
+	}
+	if got := ThroughputMBps(10<<20, 0); got != 0 {
+		t.Errorf("経過時間が 0 の場合のスループットが一致しない: 期待値 0, 取得値 %v", got)
+	}
+}
+
+func TestS3のエラーのリトライ可否が判定される(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		retryable bool
+	}{
+		{name: "403 Forbidden", err: &statusCodeError{code: 403}, retryable: false},
+		{name: "404 NoSuchBucket", err: fmt.Errorf("operation error S3: PutObject: %w", &statusCodeError{code: 404}), retryable: false},
+		{name: "408 Request Timeout", err: &statusCodeError{code: 408}, retryable: true},
+		{name: "429 Too Many Requests", err: &statusCodeError{code: 429}, retryable: true},
+		{name: "500 Internal Server Error", err: &statusCodeError{code: 500}, retryable: true},
+		{name: "503 Slow Down", err: &statusCodeError{code: 503}, retryable: true},
+		{name: "ネットワークエラー", err: errors.New("dial tcp: connection refused"), retryable: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isRetryableS3Error(tt.err); got != tt.retryable {
+				t.Errorf("リトライ可否が一致しない: 期待値 %v, 取得値 %v", tt.retryable, got)
+			}
+		})
+	}
+}
+
 // Note: S3Uploader のテストは AWS SDK のモックが必要なため、
 // ここでは基本的な初期化のテストのみを含めています。
 // より詳細なテストを書くには、以下のようなモックライブラリを使用できます:
@@ -377,3 +469,346 @@ func mustUnsetenv(t *testing.T, key string) {
 //     // AWS 認証情報のモックが必要
 //     // または実際の AWS 環境が必要
 // }
+
+// fakeS3Client は PutObject とマルチパートアップロードの呼び出しを記録する manager.UploadAPIClient
+type fakeS3Client struct {
+	mu            sync.Mutex
+	puts          int
+	lastPut       *s3.PutObjectInput
+	parts         int
+	partBytes     int64
+	multipartKeys []string
+	completed     int
+	// corrupt が true の場合は受信したデータと異なる ETag を返す（転送中にデータが壊れた状態を再現する）
+	corrupt bool
+}
+
+func (c *fakeS3Client) PutObject(ctx context.Context, in *s3.PutObjectInput, opts ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	h := md5.New()
+	if _, err := io.Copy(h, in.Body); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.puts++
+	c.lastPut = in
+	etag := hex.EncodeToString(h.Sum(nil))
+	if c.corrupt {
+		etag = strings.Repeat("0", len(etag))
+	}
+	return &s3.PutObjectOutput{ETag: aws.String(`"` + etag + `"`)}, nil
+}
+
+func (c *fakeS3Client) UploadPart(ctx context.Context, in *s3.UploadPartInput, opts ...func(*s3.Options)) (*s3.UploadPartOutput, error) {
+	n, err := io.Copy(io.Discard, in.Body)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.parts++
+	c.partBytes += n
+	return &s3.UploadPartOutput{ETag: aws.String(fmt.Sprintf("etag-%d", aws.ToInt32(in.PartNumber)))}, nil
+}
+
+func (c *fakeS3Client) CreateMultipartUpload(ctx context.Context, in *s3.CreateMultipartUploadInput, opts ...func(*s3.Options)) (*s3.CreateMultipartUploadOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.multipartKeys = append(c.multipartKeys, aws.ToString(in.Key))
+	return &s3.CreateMultipartUploadOutput{UploadId: aws.String("upload-1")}, nil
+}
+
+func (c *fakeS3Client) CompleteMultipartUpload(ctx context.Context, in *s3.CompleteMultipartUploadInput, opts ...func(*s3.Options)) (*s3.CompleteMultipartUploadOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.completed++
+	return &s3.CompleteMultipartUploadOutput{}, nil
+}
+
+func (c *fakeS3Client) AbortMultipartUpload(ctx context.Context, in *s3.AbortMultipartUploadInput, opts ...func(*s3.Options)) (*s3.AbortMultipartUploadOutput, error) {
+	return &s3.AbortMultipartUploadOutput{}, nil
+}
+
+// writeSizedFile は指定したサイズのテスト用ファイルを作成する
+func writeSizedFile(t *testing.T, path string, size int64) {
+	t.Helper()
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("テストファイルの作成に失敗: %v", err)
+	}
+	defer func() { _ = file.Close() }()
+	if err := file.Truncate(size); err != nil {
+		t.Fatalf("テストファイルのサイズ変更に失敗: %v", err)
+	}
+}
+
+func TestS3Uploaderがパートサイズを超えるファイルをマルチパートでアップロードする(t *testing.T) {
+	client := &fakeS3Client{}
+	partSize := manager.MinUploadPartSize
+	uploader := &S3Uploader{
+		transfer: newS3TransferUploader(client, partSize, 2),
+		bucket:   "test-bucket",
+		region:   "ap-northeast-1",
+	}
+
+	// パートサイズの 2.5 倍のファイルは 3 パートに分割される
+	largeFile := filepath.Join(t.TempDir(), "large.mp4")
+	size := partSize*2 + partSize/2
+	writeSizedFile(t, largeFile, size)
+
+	url, err := uploader.Upload(context.Background(), largeFile, "videos/large.mp4")
+	if err != nil {
+		t.Fatalf("アップロードに失敗: %v", err)
+	}
+	if expected := "https://test-bucket.s3.ap-northeast-1.amazonaws.com/videos/large.mp4"; url != expected {
+		t.Errorf("URL が一致しない: 期待値 %s, 取得値 %s", expected, url)
+	}
+	if client.puts != 0 {
+		t.Errorf("大きなファイルで PutObject が呼ばれた: %d 回", client.puts)
+	}
+	if len(client.multipartKeys) != 1 || client.multipartKeys[0] != "videos/large.mp4" || client.completed != 1 {
+		t.Errorf("マルチパートアップロードが実行されていない: keys %v, completed %d", client.multipartKeys, client.completed)
+	}
+	if client.parts != 3 || client.partBytes != size {
+		t.Errorf("パートが一致しない: 期待値 3 パート %d バイト, 取得値 %d パート %d バイト", size, client.parts, client.partBytes)
+	}
+
+	// パートサイズ未満のファイルは 1 回の PutObject でアップロードされる
+	smallFile := filepath.Join(t.TempDir(), "small.mp4")
+	writeSizedFile(t, smallFile, 1024)
+	if _, err := uploader.Upload(context.Background(), smallFile, "videos/small.mp4"); err != nil {
+		t.Fatalf("アップロードに失敗: %v", err)
+	}
+	if client.puts != 1 || len(client.multipartKeys) != 1 {
+		t.Errorf("小さなファイルが PutObject でアップロードされていない: puts %d, multipart %d", client.puts, len(client.multipartKeys))
+	}
+}
+
+func TestS3UploaderのSetMultipartUploadが不正な設定でエラーを返す(t *testing.T) {
+	uploader := &S3Uploader{}
+
+	if err := uploader.SetMultipartUpload(4, 2); err == nil {
+		t.Error("5MB 未満のパートサイズでエラーが返されない")
+	}
+	if err := uploader.SetMultipartUpload(8, 0); err == nil {
+		t.Error("並行数 0 でエラーが返されない")
+	}
+	if err := uploader.SetMultipartUpload(8, 4); err != nil {
+		t.Errorf("有効な設定でエラーが返された: %v", err)
+	}
+}
+
+func TestジョブごとのKMSキーがWorkerの既定値より優先してPutObjectに渡される(t *testing.T) {
+	client := &fakeS3Client{}
+	uploader := &S3Uploader{
+		transfer: newS3TransferUploader(client, manager.MinUploadPartSize, 1),
+		bucket:   "test-bucket",
+		region:   "ap-northeast-1",
+	}
+	workerKey := "alias/worker-default"
+	if err := uploader.SetSSEKMSKeyID(workerKey); err != nil {
+		t.Fatalf("既定の KMS キーの設定に失敗: %v", err)
+	}
+
+	localFile := filepath.Join(t.TempDir(), "video.mp4")
+	writeSizedFile(t, localFile, 1024)
+
+	// ジョブごとの指定がない場合は Worker の既定値を使用する
+	if _, err := uploader.Upload(context.Background(), localFile, "videos/default.mp4"); err != nil {
+		t.Fatalf("アップロードに失敗: %v", err)
+	}
+	if client.lastPut.ServerSideEncryption != types.ServerSideEncryptionAwsKms || aws.ToString(client.lastPut.SSEKMSKeyId) != workerKey {
+		t.Errorf("既定の KMS キーが一致しない: 期待値 %s, 取得値 %s (%s)", workerKey, aws.ToString(client.lastPut.SSEKMSKeyId), client.lastPut.ServerSideEncryption)
+	}
+
+	jobKey := "arn:aws:kms:ap-northeast-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab"
+	ctx := WithSSEKMSKeyID(context.Background(), jobKey)
+	if _, err := uploader.Upload(ctx, localFile, "videos/job.mp4"); err != nil {
+		t.Fatalf("アップロードに失敗: %v", err)
+	}
+	if client.lastPut.ServerSideEncryption != types.ServerSideEncryptionAwsKms || aws.ToString(client.lastPut.SSEKMSKeyId) != jobKey {
+		t.Errorf("ジョブごとの KMS キーが一致しない: 期待値 %s, 取得値 %s (%s)", jobKey, aws.ToString(client.lastPut.SSEKMSKeyId), client.lastPut.ServerSideEncryption)
+	}
+
+	// どちらも指定しない場合は暗号化の指定をしない（バケットの既定の暗号化に従う）
+	if err := uploader.SetSSEKMSKeyID(""); err != nil {
+		t.Fatalf("既定の KMS キーの解除に失敗: %v", err)
+	}
+	if _, err := uploader.Upload(context.Background(), localFile, "videos/plain.mp4"); err != nil {
+		t.Fatalf("アップロードに失敗: %v", err)
+	}
+	if client.lastPut.ServerSideEncryption != "" || client.lastPut.SSEKMSKeyId != nil {
+		t.Errorf("暗号化が指定された: %s, %s", client.lastPut.ServerSideEncryption, aws.ToString(client.lastPut.SSEKMSKeyId))
+	}
+}
+
+func TestKMSキーの形式が検証される(t *testing.T) {
+	valid := []string{
+		"1234abcd-12ab-34cd-56ef-1234567890ab",
+		"mrk-1234abcd12ab34cd56ef1234567890ab",
+		"arn:aws:kms:ap-northeast-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab",
+		"arn:aws-us-gov:kms:us-gov-west-1:123456789012:key/mrk-1234abcd12ab34cd56ef1234567890ab",
+		"alias/video-outputs",
+		"arn:aws:kms:ap-northeast-1:123456789012:alias/tenant/a_b-c",
+	}
+	for _, keyID := range valid {
+		if err := ValidateKMSKeyID(keyID); err != nil {
+			t.Errorf("%s でエラーが返された: %v", keyID, err)
+		}
+	}
+
+	invalid := []string{
+		"",
+		"1234abcd",
+		"my-key",
+		"alias/",
+		"alias/bad key",
+		"arn:aws:kms:ap-northeast-1:1234:key/1234abcd-12ab-34cd-56ef-1234567890ab",
+		"arn:aws:s3:::bucket/1234abcd-12ab-34cd-56ef-1234567890ab",
+	}
+	for _, keyID := range invalid {
+		if err := ValidateKMSKeyID(keyID); err == nil {
+			t.Errorf("%q でエラーが返されない", keyID)
+		}
+	}
+}
+
+func TestLocalUploaderがファイルを削除できる(t *testing.T) {
+	tempDir := t.TempDir()
+	baseDir := filepath.Join(tempDir, "storage")
+	srcFile := filepath.Join(tempDir, "video.mp4")
+	if err := os.WriteFile(srcFile, []byte("video"), 0644); err != nil {
+		t.Fatalf("テストファイルの作成に失敗: %v", err)
+	}
+
+	uploader := &LocalUploader{baseDir: baseDir}
+	if _, err := uploader.Upload(context.Background(), srcFile, "out/video.mp4"); err != nil {
+		t.Fatalf("アップロードに失敗: %v", err)
+	}
+
+	if err := uploader.Delete(context.Background(), "out/video.mp4"); err != nil {
+		t.Fatalf("削除に失敗: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(baseDir, "out/video.mp4")); !os.IsNotExist(err) {
+		t.Error("ファイルが削除されていない")
+	}
+
+	// 存在しないファイルの削除は成功として扱う
+	if err := uploader.Delete(context.Background(), "out/video.mp4"); err != nil {
+		t.Errorf("存在しないファイルの削除がエラーになる: %v", err)
+	}
+}
+
+func TestLocalUploaderはストレージの外のファイルを削除も参照もしない(t *testing.T) {
+	tempDir := t.TempDir()
+	baseDir := filepath.Join(tempDir, "storage")
+	mustMkdirAll(t, baseDir)
+	outside := filepath.Join(tempDir, "secret.txt")
+	if err := os.WriteFile(outside, []byte("secret"), 0644); err != nil {
+		t.Fatalf("テストファイルの作成に失敗: %v", err)
+	}
+
+	uploader := &LocalUploader{baseDir: baseDir}
+	for _, remotePath := range []string{"../secret.txt", "out/../../secret.txt", "/../secret.txt"} {
+		exists, _, err := uploader.Exists(context.Background(), remotePath)
+		if err != nil {
+			t.Fatalf("存在確認に失敗 (%s): %v", remotePath, err)
+		}
+		if exists {
+			t.Errorf("ストレージの外のファイルが存在するとみなされる: %s", remotePath)
+		}
+		if err := uploader.Delete(context.Background(), remotePath); err != nil {
+			t.Errorf("削除に失敗 (%s): %v", remotePath, err)
+		}
+	}
+	if _, err := os.Stat(outside); err != nil {
+		t.Errorf("ストレージの外のファイルが削除されている: %v", err)
+	}
+}
+
+func TestLocalUploaderがディレクトリを削除できる(t *testing.T) {
+	tempDir := t.TempDir()
+	baseDir := filepath.Join(tempDir, "storage")
+	srcDir := filepath.Join(tempDir, "src")
+	mustMkdirAll(t, filepath.Join(srcDir, "segments"))
+	for _, name := range []string{"master.m3u8", "segments/segment_000.ts"} {
+		if err := os.WriteFile(filepath.Join(srcDir, name), []byte(name), 0644); err != nil {
+			t.Fatalf("テストファイルの作成に失敗 (%s): %v", name, err)
+		}
+	}
+
+	uploader := &LocalUploader{baseDir: baseDir}
+	if _, _, err := uploader.UploadDirectory(context.Background(), srcDir, "hls/job1"); err != nil {
+		t.Fatalf("ディレクトリのアップロードに失敗: %v", err)
+	}
+	// 同じプレフィックスで始まる別のディレクトリは削除されない
+	if _, _, err := uploader.UploadDirectory(context.Background(), srcDir, "hls/job10"); err != nil {
+		t.Fatalf("ディレクトリのアップロードに失敗: %v", err)
+	}
+
+	if err := uploader.DeleteDirectory(context.Background(), "hls/job1"); err != nil {
+		t.Fatalf("ディレクトリの削除に失敗: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(baseDir, "hls/job1")); !os.IsNotExist(err) {
+		t.Error("ディレクトリが削除されていない")
+	}
+	if _, err := os.Stat(filepath.Join(baseDir, "hls/job10/master.m3u8")); err != nil {
+		t.Errorf("別のディレクトリが削除されている: %v", err)
+	}
+}
+
+func TestDeleteDirectoryはストレージのルートを削除しない(t *testing.T) {
+	baseDir := t.TempDir()
+	uploader := &LocalUploader{baseDir: baseDir}
+
+	for _, remoteDir := range []string{"", "/", ".", "a/.."} {
+		if err := uploader.DeleteDirectory(context.Background(), remoteDir); err == nil {
+			t.Errorf("ルートの削除がエラーにならない: %q", remoteDir)
+		}
+	}
+	if _, err := os.Stat(baseDir); err != nil {
+		t.Errorf("ストレージのルートが削除されている: %v", err)
+	}
+}
+
+func TestLocalUploaderでオブジェクトの存在とサイズを確認できる(t *testing.T) {
+	tempDir := t.TempDir()
+	baseDir := filepath.Join(tempDir, "storage")
+	srcFile := filepath.Join(tempDir, "video.mp4")
+	if err := os.WriteFile(srcFile, []byte("video data"), 0644); err != nil {
+		t.Fatalf("テストファイルの作成に失敗: %v", err)
+	}
+
+	uploader := &LocalUploader{baseDir: baseDir}
+	if _, err := uploader.Upload(context.Background(), srcFile, "out/video.mp4"); err != nil {
+		t.Fatalf("アップロードに失敗: %v", err)
+	}
+
+	exists, size, err := uploader.Exists(context.Background(), "out/video.mp4")
+	if err != nil {
+		t.Fatalf("存在確認に失敗: %v", err)
+	}
+	if !exists || size != int64(len("video data")) {
+		t.Errorf("存在確認の結果が一致しない: 期待値 (true, %d), 取得値 (%v, %d)", len("video data"), exists, size)
+	}
+
+	// 存在しないオブジェクトとディレクトリは false を返す
+	for _, remotePath := range []string{"out/missing.mp4", "out"} {
+		exists, size, err := uploader.Exists(context.Background(), remotePath)
+		if err != nil {
+			t.Fatalf("存在確認に失敗 (%s): %v", remotePath, err)
+		}
+		if exists || size != 0 {
+			t.Errorf("存在しないオブジェクトの結果が一致しない (%s): 取得値 (%v, %d)", remotePath, exists, size)
+		}
+	}
+}
+
+func TestS3のNotFoundエラーを存在しないオブジェクトとして扱う(t *testing.T) {
+	if !isS3NotFound(fmt.Errorf("head: %w", &types.NotFound{})) {
+		t.Error("NotFound が存在しないオブジェクトとして扱われない")
+	}
+	if isS3NotFound(errors.New("connection reset")) {
+		t.Error("ネットワークエラーが存在しないオブジェクトとして扱われる")
+	}
+}
@@ -0,0 +1,42 @@
+package uploader
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+)
+
+// maxKMSKeyIDLength は KMS キーの指定として受け付ける最大長
+const maxKMSKeyIDLength = 2048
+
+// kmsKeyIDPattern は SSE-KMS に指定できる KMS キーの形式
+// キーID（UUID またはマルチリージョンキーの mrk-）、キーARN、エイリアス名、エイリアスARN を受け付ける
+var kmsKeyIDPattern = regexp.MustCompile(`^(?:` +
+	`(?:arn:aws[a-z-]*:kms:[a-z0-9-]+:[0-9]{12}:key/)?(?:[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}|mrk-[0-9a-fA-F]{32})` +
+	`|(?:arn:aws[a-z-]*:kms:[a-z0-9-]+:[0-9]{12}:)?alias/[a-zA-Z0-9/_-]+` +
+	`)$`)
+
+// ValidateKMSKeyID は SSE-KMS に使用する KMS キーの指定が正しい形式かを検証する
+func ValidateKMSKeyID(keyID string) error {
+	if len(keyID) > maxKMSKeyIDLength || !kmsKeyIDPattern.MatchString(keyID) {
+		return fmt.Errorf("invalid kms key id: %q (key id, key ARN, alias or alias ARN is required)", keyID)
+	}
+	return nil
+}
+
+// sseKMSKeyIDContextKey はコンテキストにジョブごとの KMS キーを格納するキー
+type sseKMSKeyIDContextKey struct{}
+
+// WithSSEKMSKeyID はジョブごとの SSE-KMS の KMS キーを付与したコンテキストを返す
+// S3Uploader は Worker の既定値より優先して使用する
+func WithSSEKMSKeyID(ctx context.Context, keyID string) context.Context {
+	return context.WithValue(ctx, sseKMSKeyIDContextKey{}, keyID)
+}
+
+// sseKMSKeyIDFromContext はコンテキストに付与された KMS キーを返す（付与されていない場合は fallback）
+func sseKMSKeyIDFromContext(ctx context.Context, fallback string) string {
+	if keyID, ok := ctx.Value(sseKMSKeyIDContextKey{}).(string); ok && keyID != "" {
+		return keyID
+	}
+	return fallback
+}
@@ -0,0 +1,71 @@
+package uploader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// UploadTracker はジョブでアップロードしたオブジェクトのパスを記録する
+// WithUploadTracker でコンテキストに付与すると、各 Uploader はアップロードに成功したパスを記録する
+type UploadTracker struct {
+	mu    sync.Mutex
+	paths []string
+	seen  map[string]bool
+}
+
+// NewUploadTracker は新しい UploadTracker を作成する
+func NewUploadTracker() *UploadTracker {
+	return &UploadTracker{seen: make(map[string]bool)}
+}
+
+// record はアップロードしたパスを記録する（同じパスは1回のみ）
+func (t *UploadTracker) record(remotePath string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.seen[remotePath] {
+		return
+	}
+	t.seen[remotePath] = true
+	t.paths = append(t.paths, remotePath)
+}
+
+// Paths はアップロードしたパスをアップロード順に返す
+func (t *UploadTracker) Paths() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return append([]string(nil), t.paths...)
+}
+
+// DeleteAll は記録したオブジェクトをすべて uploader で削除し、削除したパスの数を返す
+// 一部の削除に失敗しても残りの削除を続け、失敗をまとめて返す
+func (t *UploadTracker) DeleteAll(ctx context.Context, uploader Uploader) (int, error) {
+	var errs []error
+	deleted := 0
+	for _, remotePath := range t.Paths() {
+		if err := uploader.Delete(ctx, remotePath); err != nil {
+			errs = append(errs, fmt.Errorf("failed to delete %s: %w", remotePath, err))
+			continue
+		}
+		deleted++
+	}
+	return deleted, errors.Join(errs...)
+}
+
+// uploadTrackerContextKey はコンテキストに UploadTracker を格納するキー
+type uploadTrackerContextKey struct{}
+
+// WithUploadTracker はアップロードしたパスを tracker に記録するコンテキストを返す
+func WithUploadTracker(ctx context.Context, tracker *UploadTracker) context.Context {
+	return context.WithValue(ctx, uploadTrackerContextKey{}, tracker)
+}
+
+// trackUpload はコンテキストに UploadTracker が付与されている場合にアップロードしたパスを記録する
+func trackUpload(ctx context.Context, remotePath string) {
+	if tracker, ok := ctx.Value(uploadTrackerContextKey{}).(*UploadTracker); ok {
+		tracker.record(remotePath)
+	}
+}
@@ -0,0 +1,68 @@
+package uploader
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestUploadTrackerがアップロードしたパスを記録して削除できる(t *testing.T) {
+	tempDir := t.TempDir()
+	baseDir := filepath.Join(tempDir, "storage")
+	srcDir := filepath.Join(tempDir, "src")
+	mustMkdirAll(t, srcDir)
+	for _, name := range []string{"master.m3u8", "segment_000.ts"} {
+		if err := os.WriteFile(filepath.Join(srcDir, name), []byte(name), 0644); err != nil {
+			t.Fatalf("テストファイルの作成に失敗 (%s): %v", name, err)
+		}
+	}
+
+	uploader := &LocalUploader{baseDir: baseDir}
+	tracker := NewUploadTracker()
+	ctx := WithUploadTracker(context.Background(), tracker)
+
+	if _, _, err := uploader.UploadDirectory(ctx, srcDir, "hls"); err != nil {
+		t.Fatalf("ディレクトリのアップロードに失敗: %v", err)
+	}
+	// 同じパスへの再アップロードは1回として記録される
+	if _, err := uploader.Upload(ctx, filepath.Join(srcDir, "master.m3u8"), "hls/master.m3u8"); err != nil {
+		t.Fatalf("アップロードに失敗: %v", err)
+	}
+
+	want := []string{"hls/master.m3u8", "hls/segment_000.ts"}
+	if got := tracker.Paths(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("記録したパスが一致しない: 期待値 %v, 取得値 %v", want, got)
+	}
+
+	deleted, err := tracker.DeleteAll(context.Background(), uploader)
+	if err != nil {
+		t.Fatalf("削除に失敗: %v", err)
+	}
+	if deleted != len(want) {
+		t.Errorf("削除したパスの数が一致しない: 期待値 %d, 取得値 %d", len(want), deleted)
+	}
+	for _, remotePath := range want {
+		if _, err := os.Stat(filepath.Join(baseDir, remotePath)); !os.IsNotExist(err) {
+			t.Errorf("アップロード済みのファイルが削除されていない: %s", remotePath)
+		}
+	}
+}
+
+func TestUploadTrackerがないコンテキストではパスを記録しない(t *testing.T) {
+	tempDir := t.TempDir()
+	srcFile := filepath.Join(tempDir, "video.mp4")
+	if err := os.WriteFile(srcFile, []byte("video"), 0644); err != nil {
+		t.Fatalf("テストファイルの作成に失敗: %v", err)
+	}
+
+	tracker := NewUploadTracker()
+	uploader := &LocalUploader{baseDir: filepath.Join(tempDir, "storage")}
+	if _, err := uploader.Upload(context.Background(), srcFile, "out/video.mp4"); err != nil {
+		t.Fatalf("アップロードに失敗: %v", err)
+	}
+	if got := tracker.Paths(); len(got) != 0 {
+		t.Errorf("パスが記録されている: %v", got)
+	}
+}
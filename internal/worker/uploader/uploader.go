@@ -2,13 +2,54 @@ package uploader
 
 import (
 	"context"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
 )
 
+// UploadedFile はディレクトリアップロードでアップロードしたファイル
+type UploadedFile struct {
+	// Path はアップロード元ディレクトリからの相対パス（スラッシュ区切り）
+	Path string
+	// URL はアップロード先の URL
+	URL string
+}
+
 // Uploader はファイルをアップロードするインターフェース
 type Uploader interface {
 	// Upload はファイルをアップロードし、アクセス可能なURLを返す
 	Upload(ctx context.Context, localPath string, remotePath string) (string, error)
 
-	// UploadDirectory はディレクトリを再帰的にアップロードし、マスターファイルのURLを返す
-	UploadDirectory(ctx context.Context, localDir string, remoteDir string) (string, error)
+	// UploadDirectory はディレクトリを再帰的にアップロードし、マスターファイルのURLとアップロードしたファイルの一覧を返す
+	UploadDirectory(ctx context.Context, localDir string, remoteDir string) (string, []UploadedFile, error)
+
+	// Delete は remotePath のオブジェクトを削除する（存在しない場合は成功として扱う）
+	Delete(ctx context.Context, remotePath string) error
+
+	// DeleteDirectory は remoteDir 以下のオブジェクトをすべて削除する（空のディレクトリ指定はエラー）
+	DeleteDirectory(ctx context.Context, remoteDir string) error
+
+	// Exists は remotePath のオブジェクトが存在するかとそのサイズを返す（存在しない場合は false とエラーなし）
+	Exists(ctx context.Context, remotePath string) (bool, int64, error)
+}
+
+// directoryPrefix は remoteDir 以下のオブジェクトのキーに共通するプレフィックスを返す
+// ストレージ全体を削除しないよう、ルートを指す remoteDir はエラーにする
+func directoryPrefix(remoteDir string) (string, error) {
+	dir := strings.Trim(path.Clean("/"+filepath.ToSlash(remoteDir)), "/")
+	if dir == "" {
+		return "", fmt.Errorf("refusing to delete the storage root: %q", remoteDir)
+	}
+	return dir + "/", nil
+}
+
+// ThroughputMBps はアップロードのスループット（MB/s、1MB = 2^20 bytes）を返す
+// 経過時間が 0 の場合は 0 を返す
+func ThroughputMBps(size int64, elapsed time.Duration) float64 {
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(size) / (1 << 20) / elapsed.Seconds()
 }
@@ -0,0 +1,183 @@
+package uploader
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/nzws/flux-encoder/internal/shared/logger"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultVerifyTimeout はアップロードした出力の検証全体のタイムアウトのデフォルト値
+	DefaultVerifyTimeout = 30 * time.Second
+
+	// maxVerifyPlaylistBytes は検証で読み込むプレイリストの最大サイズ
+	maxVerifyPlaylistBytes = 1 << 20
+)
+
+// UploadVerifier はアップロードした HLS の出力を HTTP で取得し直し、公開された URL から読み込めるかを検証する
+// アップロード自体は成功しても、オブジェクトが公開読み取りできない（権限・ACL の設定漏れなど）場合を完了前に検出する
+type UploadVerifier struct {
+	client  *http.Client
+	timeout time.Duration
+}
+
+// NewUploadVerifier は UploadVerifier を作成する
+// client が nil の場合は http.DefaultClient を使用する
+func NewUploadVerifier(client *http.Client) *UploadVerifier {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &UploadVerifier{client: client, timeout: DefaultVerifyTimeout}
+}
+
+// VerifyHLS はマスタープレイリストを取得し、参照するプレイリストとセグメントを取得できるかを検証する
+// マスタープレイリストの場合は最初のバリアントのプレイリストを取得し、そのプレイリストの初期化セグメントと最初のセグメントを HEAD で確認する
+// http/https 以外の URL（ローカルストレージなど）は検証しない
+func (v *UploadVerifier) VerifyHLS(ctx context.Context, masterURL string) error {
+	base, err := url.Parse(masterURL)
+	if err != nil {
+		return fmt.Errorf("invalid uploaded playlist URL: %w", err)
+	}
+	if base.Scheme != "http" && base.Scheme != "https" {
+		logger.Debug("Skipping upload verification for non-HTTP URL", zap.String("url", masterURL))
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, v.timeout)
+	defer cancel()
+
+	playlistURL := base
+	lines, err := v.fetchPlaylist(ctx, playlistURL)
+	if err != nil {
+		return err
+	}
+
+	// マスタープレイリストの場合は最初のバリアントを検証する
+	if variant, ok := firstVariantURI(lines); ok {
+		playlistURL, err = base.Parse(variant)
+		if err != nil {
+			return fmt.Errorf("invalid variant playlist URI %q: %w", variant, err)
+		}
+		lines, err = v.fetchPlaylist(ctx, playlistURL)
+		if err != nil {
+			return err
+		}
+	}
+
+	uris := sampleSegmentURIs(lines)
+	if len(uris) == 0 {
+		return fmt.Errorf("uploaded playlist %s has no segments", playlistURL.Redacted())
+	}
+	for _, uri := range uris {
+		segmentURL, err := playlistURL.Parse(uri)
+		if err != nil {
+			return fmt.Errorf("invalid segment URI %q: %w", uri, err)
+		}
+		if err := v.head(ctx, segmentURL); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fetchPlaylist はプレイリストを取得して行ごとに返す
+func (v *UploadVerifier) fetchPlaylist(ctx context.Context, playlistURL *url.URL) ([]string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, playlistURL.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch uploaded playlist %s: %w", playlistURL.Redacted(), err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			logger.Warn("Failed to close response body", zap.Error(err))
+		}
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("uploaded playlist %s is not reachable: status %d", playlistURL.Redacted(), resp.StatusCode)
+	}
+
+	var lines []string
+	scanner := bufio.NewScanner(io.LimitReader(resp.Body, maxVerifyPlaylistBytes))
+	for scanner.Scan() {
+		lines = append(lines, strings.TrimSpace(scanner.Text()))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read uploaded playlist %s: %w", playlistURL.Redacted(), err)
+	}
+	if len(lines) == 0 || lines[0] != "#EXTM3U" {
+		return nil, fmt.Errorf("uploaded playlist %s is not an HLS playlist", playlistURL.Redacted())
+	}
+	return lines, nil
+}
+
+// head はオブジェクトを HEAD で取得できるかを確認する
+func (v *UploadVerifier) head(ctx context.Context, objectURL *url.URL) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, objectURL.String(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch uploaded segment %s: %w", objectURL.Redacted(), err)
+	}
+	if err := resp.Body.Close(); err != nil {
+		logger.Warn("Failed to close response body", zap.Error(err))
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("uploaded segment %s is not reachable: status %d", objectURL.Redacted(), resp.StatusCode)
+	}
+	return nil
+}
+
+// firstVariantURI はマスタープレイリストの最初のバリアントの URI を返す（メディアプレイリストの場合は false）
+func firstVariantURI(lines []string) (string, bool) {
+	for i, line := range lines {
+		if !strings.HasPrefix(line, "#EXT-X-STREAM-INF") {
+			continue
+		}
+		for _, next := range lines[i+1:] {
+			if next != "" && !strings.HasPrefix(next, "#") {
+				return next, true
+			}
+		}
+	}
+	return "", false
+}
+
+// sampleSegmentURIs はメディアプレイリストから検証するセグメント（初期化セグメントと最初のセグメント）の URI を返す
+func sampleSegmentURIs(lines []string) []string {
+	var uris []string
+	for _, line := range lines {
+		if strings.HasPrefix(line, "#EXT-X-MAP:") {
+			if uri, ok := quotedAttribute(line, "URI"); ok {
+				uris = append(uris, uri)
+			}
+			continue
+		}
+		if line != "" && !strings.HasPrefix(line, "#") {
+			return append(uris, line)
+		}
+	}
+	return uris
+}
+
+// quotedAttribute はタグの属性リストから引用符で囲まれた属性の値を返す
+func quotedAttribute(line, name string) (string, bool) {
+	_, value, ok := strings.Cut(line, name+`="`)
+	if !ok {
+		return "", false
+	}
+	value, _, ok = strings.Cut(value, `"`)
+	return value, ok
+}
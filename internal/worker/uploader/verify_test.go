@@ -0,0 +1,110 @@
+package uploader
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// newHLSOutputServer はアップロード済みの HLS の出力を返す HTTP サーバーを起動する
+// denied に含まれるパスは 403 を返し、リクエストされたメソッドとパスを記録する
+func newHLSOutputServer(t *testing.T, denied ...string) (*httptest.Server, *[]string) {
+	t.Helper()
+
+	files := map[string]string{
+		"/out/master.m3u8":              "#EXTM3U\n#EXT-X-STREAM-INF:BANDWIDTH=800000\nstream_0/playlist.m3u8\n#EXT-X-STREAM-INF:BANDWIDTH=2800000\nstream_1/playlist.m3u8\n",
+		"/out/stream_0/playlist.m3u8":   "#EXTM3U\n#EXT-X-VERSION:7\n#EXT-X-MAP:URI=\"init.mp4\"\n#EXTINF:4.0,\nsegment_000.m4s\n#EXTINF:4.0,\nsegment_001.m4s\n#EXT-X-ENDLIST\n",
+		"/out/stream_0/init.mp4":        "init",
+		"/out/stream_0/segment_000.m4s": "segment",
+	}
+
+	var mutex sync.Mutex
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		mutex.Unlock()
+
+		for _, path := range denied {
+			if r.URL.Path == path {
+				http.Error(w, "AccessDenied", http.StatusForbidden)
+				return
+			}
+		}
+		body, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		if _, err := w.Write([]byte(body)); err != nil {
+			t.Logf("failed to write response: %v", err)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func Test取得できるHLSの出力の検証が成功する(t *testing.T) {
+	server, requests := newHLSOutputServer(t)
+
+	verifier := NewUploadVerifier(server.Client())
+	if err := verifier.VerifyHLS(context.Background(), server.URL+"/out/master.m3u8"); err != nil {
+		t.Fatalf("検証に失敗: %v", err)
+	}
+
+	want := []string{
+		"GET /out/master.m3u8",
+		"GET /out/stream_0/playlist.m3u8",
+		"HEAD /out/stream_0/init.mp4",
+		"HEAD /out/stream_0/segment_000.m4s",
+	}
+	if strings.Join(*requests, "\n") != strings.Join(want, "\n") {
+		t.Errorf("リクエストが一致しない:\n期待値 %v\n取得値 %v", want, *requests)
+	}
+}
+
+func Test取得できないHLSの出力の検証がエラーになる(t *testing.T) {
+	tests := []struct {
+		name   string
+		denied string
+	}{
+		{name: "マスタープレイリスト", denied: "/out/master.m3u8"},
+		{name: "バリアントのプレイリスト", denied: "/out/stream_0/playlist.m3u8"},
+		{name: "初期化セグメント", denied: "/out/stream_0/init.mp4"},
+		{name: "セグメント", denied: "/out/stream_0/segment_000.m4s"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, _ := newHLSOutputServer(t, tt.denied)
+
+			verifier := NewUploadVerifier(server.Client())
+			err := verifier.VerifyHLS(context.Background(), server.URL+"/out/master.m3u8")
+			if err == nil {
+				t.Fatal("取得できない出力でエラーが返されなかった")
+			}
+			if !strings.Contains(err.Error(), tt.denied) || !strings.Contains(err.Error(), "403") {
+				t.Errorf("エラーに取得できないパスとステータスが含まれない: %v", err)
+			}
+		})
+	}
+}
+
+func Test存在しないプレイリストの検証がエラーになる(t *testing.T) {
+	server, _ := newHLSOutputServer(t)
+
+	verifier := NewUploadVerifier(server.Client())
+	if err := verifier.VerifyHLS(context.Background(), server.URL+"/out/stream_1/playlist.m3u8"); err == nil {
+		t.Error("存在しないプレイリストでエラーが返されなかった")
+	}
+}
+
+func TestHTTP以外のURLは検証しない(t *testing.T) {
+	verifier := NewUploadVerifier(nil)
+	if err := verifier.VerifyHLS(context.Background(), "/tmp/outputs/master.m3u8"); err != nil {
+		t.Errorf("ローカルストレージの URL でエラーが返された: %v", err)
+	}
+}
@@ -0,0 +1,360 @@
+package validator
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DASHParser はDASHマニフェスト（MPD）のパーサー
+type DASHParser struct {
+	ffprobe *FFProbe
+}
+
+// NewDASHParser は新しいDASHParserを作成する
+func NewDASHParser() *DASHParser {
+	return &DASHParser{
+		ffprobe: NewFFProbe(DefaultFFprobePath),
+	}
+}
+
+// mpdDocument は MPD の XML 構造（検証に必要な要素のみ）
+type mpdDocument struct {
+	XMLName                   xml.Name    `xml:"MPD"`
+	MediaPresentationDuration string      `xml:"mediaPresentationDuration,attr"`
+	Periods                   []mpdPeriod `xml:"Period"`
+}
+
+type mpdPeriod struct {
+	Duration       string             `xml:"duration,attr"`
+	AdaptationSets []mpdAdaptationSet `xml:"AdaptationSet"`
+}
+
+type mpdAdaptationSet struct {
+	MimeType        string              `xml:"mimeType,attr"`
+	Codecs          string              `xml:"codecs,attr"`
+	SegmentTemplate *mpdSegmentTemplate `xml:"SegmentTemplate"`
+	Representations []mpdRepresentation `xml:"Representation"`
+}
+
+type mpdRepresentation struct {
+	ID              string              `xml:"id,attr"`
+	MimeType        string              `xml:"mimeType,attr"`
+	Codecs          string              `xml:"codecs,attr"`
+	Bandwidth       int64               `xml:"bandwidth,attr"`
+	Width           int                 `xml:"width,attr"`
+	Height          int                 `xml:"height,attr"`
+	BaseURL         string              `xml:"BaseURL"`
+	SegmentTemplate *mpdSegmentTemplate `xml:"SegmentTemplate"`
+	SegmentList     *mpdSegmentList     `xml:"SegmentList"`
+}
+
+type mpdSegmentTemplate struct {
+	Initialization  string              `xml:"initialization,attr"`
+	Media           string              `xml:"media,attr"`
+	StartNumber     *int64              `xml:"startNumber,attr"`
+	Timescale       int64               `xml:"timescale,attr"`
+	Duration        int64               `xml:"duration,attr"`
+	SegmentTimeline *mpdSegmentTimeline `xml:"SegmentTimeline"`
+}
+
+type mpdSegmentTimeline struct {
+	Segments []mpdTimelineSegment `xml:"S"`
+}
+
+type mpdTimelineSegment struct {
+	T *int64 `xml:"t,attr"`
+	D int64  `xml:"d,attr"`
+	R int    `xml:"r,attr"`
+}
+
+type mpdSegmentList struct {
+	Initialization *mpdURL  `xml:"Initialization"`
+	SegmentURLs    []mpdURL `xml:"SegmentURL"`
+}
+
+type mpdURL struct {
+	SourceURL string `xml:"sourceURL,attr"`
+	Media     string `xml:"media,attr"`
+}
+
+// dashSegmentRef はマニフェストから導出したセグメントの参照
+type dashSegmentRef struct {
+	path     string
+	duration float64
+}
+
+// ParseAndValidate はDASHマニフェストをパース・検証する
+func (p *DASHParser) ParseAndValidate(ctx context.Context, baseDir string, depth DASHValidationDepth) (*DASHInfo, error) {
+	manifestPath, err := p.findManifest(baseDir)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read DASH manifest: %w", err)
+	}
+
+	var mpd mpdDocument
+	if err := xml.Unmarshal(data, &mpd); err != nil {
+		return nil, fmt.Errorf("failed to parse DASH manifest: %w", err)
+	}
+
+	dashInfo := &DASHInfo{
+		Manifest: manifestPath,
+	}
+	if mpd.MediaPresentationDuration != "" {
+		duration, err := parseISO8601Duration(mpd.MediaPresentationDuration)
+		if err != nil {
+			return nil, fmt.Errorf("invalid mediaPresentationDuration: %w", err)
+		}
+		dashInfo.Duration = duration
+	}
+
+	for _, period := range mpd.Periods {
+		periodDuration := dashInfo.Duration
+		if period.Duration != "" {
+			if d, err := parseISO8601Duration(period.Duration); err == nil {
+				periodDuration = d
+			}
+		}
+
+		for _, adaptationSet := range period.AdaptationSets {
+			for _, rep := range adaptationSet.Representations {
+				repInfo, err := p.buildRepresentationInfo(ctx, baseDir, adaptationSet, rep, periodDuration, depth)
+				if err != nil {
+					return nil, err
+				}
+				dashInfo.TotalSegments += repInfo.SegmentCount
+				dashInfo.Representations = append(dashInfo.Representations, repInfo)
+			}
+		}
+	}
+
+	if len(dashInfo.Representations) == 0 {
+		return nil, fmt.Errorf("no representation found in DASH manifest: %s", manifestPath)
+	}
+
+	return dashInfo, nil
+}
+
+// findManifest はディレクトリ内のMPDファイルを探す
+func (p *DASHParser) findManifest(baseDir string) (string, error) {
+	manifestPath := filepath.Join(baseDir, "manifest.mpd")
+	if _, err := os.Stat(manifestPath); err == nil {
+		return manifestPath, nil
+	}
+
+	entries, err := os.ReadDir(baseDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to read directory: %w", err)
+	}
+	for _, entry := range entries {
+		if strings.HasSuffix(entry.Name(), ".mpd") {
+			return filepath.Join(baseDir, entry.Name()), nil
+		}
+	}
+
+	return "", fmt.Errorf("no DASH manifest found in directory: %s", baseDir)
+}
+
+// buildRepresentationInfo は Representation の情報を構築し、参照されているセグメントを検証する
+func (p *DASHParser) buildRepresentationInfo(ctx context.Context, baseDir string, adaptationSet mpdAdaptationSet, rep mpdRepresentation, periodDuration float64, depth DASHValidationDepth) (RepresentationInfo, error) {
+	repInfo := RepresentationInfo{
+		ID:        rep.ID,
+		MimeType:  rep.MimeType,
+		Codecs:    rep.Codecs,
+		Bandwidth: rep.Bandwidth,
+		Width:     rep.Width,
+		Height:    rep.Height,
+	}
+	// AdaptationSet の属性を継承する
+	if repInfo.MimeType == "" {
+		repInfo.MimeType = adaptationSet.MimeType
+	}
+	if repInfo.Codecs == "" {
+		repInfo.Codecs = adaptationSet.Codecs
+	}
+
+	if depth < DASHValidationDepthMedium {
+		return repInfo, nil
+	}
+
+	initPath, segments, err := p.resolveSegments(adaptationSet, rep, periodDuration)
+	if err != nil {
+		return RepresentationInfo{}, fmt.Errorf("representation %s: %w", rep.ID, err)
+	}
+
+	if initPath != "" {
+		fullPath := filepath.Join(baseDir, initPath)
+		if _, err := os.Stat(fullPath); err != nil {
+			return RepresentationInfo{}, fmt.Errorf("initialization segment not found: %s", fullPath)
+		}
+		repInfo.Initialization = fullPath
+
+		if depth >= DASHValidationDepthFull {
+			if _, err := p.ffprobe.GetSegmentInfo(ctx, fullPath); err != nil {
+				return RepresentationInfo{}, fmt.Errorf("failed to validate initialization segment %s: %w", initPath, err)
+			}
+		}
+	}
+
+	for _, ref := range segments {
+		fullPath := filepath.Join(baseDir, ref.path)
+		fileInfo, err := os.Stat(fullPath)
+		if err != nil {
+			return RepresentationInfo{}, fmt.Errorf("segment file not found: %s", fullPath)
+		}
+		repInfo.Segments = append(repInfo.Segments, SegmentInfo{
+			Path:     fullPath,
+			Duration: ref.duration,
+			Size:     fileInfo.Size(),
+		})
+	}
+	repInfo.SegmentCount = len(repInfo.Segments)
+
+	return repInfo, nil
+}
+
+// resolveSegments は Representation が参照する初期化セグメントとメディアセグメントを列挙する
+func (p *DASHParser) resolveSegments(adaptationSet mpdAdaptationSet, rep mpdRepresentation, periodDuration float64) (string, []dashSegmentRef, error) {
+	template := rep.SegmentTemplate
+	if template == nil {
+		template = adaptationSet.SegmentTemplate
+	}
+
+	switch {
+	case template != nil:
+		return p.resolveTemplateSegments(template, rep, periodDuration)
+	case rep.SegmentList != nil:
+		var initPath string
+		if rep.SegmentList.Initialization != nil {
+			initPath = rep.SegmentList.Initialization.SourceURL
+		}
+		segments := make([]dashSegmentRef, 0, len(rep.SegmentList.SegmentURLs))
+		for _, url := range rep.SegmentList.SegmentURLs {
+			segments = append(segments, dashSegmentRef{path: url.Media})
+		}
+		return initPath, segments, nil
+	case rep.BaseURL != "":
+		// SegmentBase（単一ファイル）の場合
+		return "", []dashSegmentRef{{path: strings.TrimSpace(rep.BaseURL), duration: periodDuration}}, nil
+	default:
+		return "", nil, fmt.Errorf("no segment information found")
+	}
+}
+
+// resolveTemplateSegments は SegmentTemplate からセグメントのファイル名を展開する
+func (p *DASHParser) resolveTemplateSegments(template *mpdSegmentTemplate, rep mpdRepresentation, periodDuration float64) (string, []dashSegmentRef, error) {
+	if template.Media == "" {
+		return "", nil, fmt.Errorf("segment template has no media attribute")
+	}
+
+	timescale := template.Timescale
+	if timescale <= 0 {
+		timescale = 1
+	}
+	number := int64(1)
+	if template.StartNumber != nil {
+		number = *template.StartNumber
+	}
+
+	var initPath string
+	if template.Initialization != "" {
+		initPath = expandDASHTemplate(template.Initialization, rep, 0, 0)
+	}
+
+	var segments []dashSegmentRef
+	if template.SegmentTimeline != nil {
+		var t int64
+		for _, s := range template.SegmentTimeline.Segments {
+			if s.T != nil {
+				t = *s.T
+			}
+			for i := 0; i <= s.R; i++ {
+				segments = append(segments, dashSegmentRef{
+					path:     expandDASHTemplate(template.Media, rep, number, t),
+					duration: float64(s.D) / float64(timescale),
+				})
+				number++
+				t += s.D
+			}
+		}
+		return initPath, segments, nil
+	}
+
+	if template.Duration <= 0 || periodDuration <= 0 {
+		return "", nil, fmt.Errorf("cannot determine segment count without SegmentTimeline or duration")
+	}
+
+	segmentDuration := float64(template.Duration) / float64(timescale)
+	count := int(math.Ceil(periodDuration/segmentDuration - 1e-9))
+	for i := 0; i < count; i++ {
+		segments = append(segments, dashSegmentRef{
+			path:     expandDASHTemplate(template.Media, rep, number, int64(i)*template.Duration),
+			duration: segmentDuration,
+		})
+		number++
+	}
+	return initPath, segments, nil
+}
+
+// dashTemplateIdentifier は $Number%05d$ のようなテンプレート識別子にマッチする
+var dashTemplateIdentifier = regexp.MustCompile(`\$(RepresentationID|Number|Bandwidth|Time)(%0?\d*d)?\$`)
+
+// expandDASHTemplate はセグメントテンプレートの識別子を展開する
+func expandDASHTemplate(template string, rep mpdRepresentation, number, t int64) string {
+	expanded := dashTemplateIdentifier.ReplaceAllStringFunc(template, func(match string) string {
+		sub := dashTemplateIdentifier.FindStringSubmatch(match)
+		format := sub[2]
+		if format == "" {
+			format = "%d"
+		}
+
+		switch sub[1] {
+		case "RepresentationID":
+			return rep.ID
+		case "Number":
+			return fmt.Sprintf(format, number)
+		case "Bandwidth":
+			return fmt.Sprintf(format, rep.Bandwidth)
+		case "Time":
+			return fmt.Sprintf(format, t)
+		}
+		return match
+	})
+	return strings.ReplaceAll(expanded, "$$", "$")
+}
+
+// iso8601Duration は PT1H2M3.5S 形式の期間にマッチする
+var iso8601Duration = regexp.MustCompile(`^P(?:(\d+(?:\.\d+)?)D)?(?:T(?:(\d+(?:\.\d+)?)H)?(?:(\d+(?:\.\d+)?)M)?(?:(\d+(?:\.\d+)?)S)?)?$`)
+
+// parseISO8601Duration は ISO 8601 形式の期間を秒数に変換する
+func parseISO8601Duration(value string) (float64, error) {
+	matches := iso8601Duration.FindStringSubmatch(strings.TrimSpace(value))
+	if matches == nil || value == "P" || value == "PT" {
+		return 0, fmt.Errorf("invalid ISO 8601 duration: %s", value)
+	}
+
+	units := []time.Duration{24 * time.Hour, time.Hour, time.Minute, time.Second}
+	var seconds float64
+	for i, unit := range units {
+		if matches[i+1] == "" {
+			continue
+		}
+		n, err := strconv.ParseFloat(matches[i+1], 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid ISO 8601 duration: %s", value)
+		}
+		seconds += n * unit.Seconds()
+	}
+	return seconds, nil
+}
@@ -0,0 +1,223 @@
+package validator
+
+import (
+	"context"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// ffmpegDASHManifest は ffmpeg の dash muxer が出力する形式のマニフェスト
+const ffmpegDASHManifest = `<?xml version="1.0" encoding="utf-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-live:2011" type="static" mediaPresentationDuration="PT10.0S" minBufferTime="PT4.0S">
+	<Period id="0" start="PT0.0S">
+		<AdaptationSet id="0" contentType="video" startWithSAP="1" segmentAlignment="true" bitstreamSwitching="true" frameRate="30/1" maxWidth="1280" maxHeight="720" par="16:9">
+			<Representation id="0" mimeType="video/mp4" codecs="avc1.64001f" bandwidth="2500000" width="1280" height="720" sar="1:1">
+				<SegmentTemplate timescale="15360" initialization="init-stream$RepresentationID$.m4s" media="chunk-stream$RepresentationID$-$Number%05d$.m4s" startNumber="1">
+					<SegmentTimeline>
+						<S t="0" d="61440" r="1" />
+						<S d="30720" />
+					</SegmentTimeline>
+				</SegmentTemplate>
+			</Representation>
+		</AdaptationSet>
+		<AdaptationSet id="1" contentType="audio" startWithSAP="1" segmentAlignment="true" bitstreamSwitching="true">
+			<Representation id="1" mimeType="audio/mp4" codecs="mp4a.40.2" bandwidth="128000" audioSamplingRate="48000">
+				<SegmentTemplate timescale="48000" initialization="init-stream$RepresentationID$.m4s" media="chunk-stream$RepresentationID$-$Number%05d$.m4s" startNumber="1">
+					<SegmentTimeline>
+						<S t="0" d="480000" />
+					</SegmentTimeline>
+				</SegmentTemplate>
+			</Representation>
+		</AdaptationSet>
+	</Period>
+</MPD>
+`
+
+func writeDASHFiles(t *testing.T, dir string, manifest string, files ...string) {
+	t.Helper()
+
+	if err := os.WriteFile(filepath.Join(dir, "manifest.mpd"), []byte(manifest), 0644); err != nil {
+		t.Fatalf("Failed to write manifest: %v", err)
+	}
+	for _, name := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("segment"), 0644); err != nil {
+			t.Fatalf("Failed to write segment: %v", err)
+		}
+	}
+}
+
+func TestDASHParser_ParseAndValidate_SegmentTimeline(t *testing.T) {
+	dir := t.TempDir()
+	writeDASHFiles(t, dir, ffmpegDASHManifest,
+		"init-stream0.m4s", "chunk-stream0-00001.m4s", "chunk-stream0-00002.m4s", "chunk-stream0-00003.m4s",
+		"init-stream1.m4s", "chunk-stream1-00001.m4s",
+	)
+
+	info, err := NewDASHParser().ParseAndValidate(context.Background(), dir, DASHValidationDepthMedium)
+	if err != nil {
+		t.Fatalf("ParseAndValidate failed: %v", err)
+	}
+
+	if info.Manifest != filepath.Join(dir, "manifest.mpd") {
+		t.Errorf("Expected manifest path %s, got %s", filepath.Join(dir, "manifest.mpd"), info.Manifest)
+	}
+	if info.Duration != 10 {
+		t.Errorf("Expected duration 10, got %f", info.Duration)
+	}
+	if len(info.Representations) != 2 {
+		t.Fatalf("Expected 2 representations, got %d", len(info.Representations))
+	}
+	if info.TotalSegments != 4 {
+		t.Errorf("Expected 4 segments, got %d", info.TotalSegments)
+	}
+
+	video := info.Representations[0]
+	if video.Width != 1280 || video.Height != 720 || video.Codecs != "avc1.64001f" || video.Bandwidth != 2500000 {
+		t.Errorf("Unexpected video representation: %+v", video)
+	}
+	if video.Initialization != filepath.Join(dir, "init-stream0.m4s") {
+		t.Errorf("Expected initialization init-stream0.m4s, got %s", video.Initialization)
+	}
+	if video.SegmentCount != 3 {
+		t.Fatalf("Expected 3 video segments, got %d", video.SegmentCount)
+	}
+	if filepath.Base(video.Segments[2].Path) != "chunk-stream0-00003.m4s" || video.Segments[2].Duration != 2 {
+		t.Errorf("Unexpected last video segment: %+v", video.Segments[2])
+	}
+}
+
+func TestDASHParser_ParseAndValidate_MissingSegment(t *testing.T) {
+	dir := t.TempDir()
+	writeDASHFiles(t, dir, ffmpegDASHManifest,
+		"init-stream0.m4s", "chunk-stream0-00001.m4s", "chunk-stream0-00003.m4s",
+		"init-stream1.m4s", "chunk-stream1-00001.m4s",
+	)
+
+	_, err := NewDASHParser().ParseAndValidate(context.Background(), dir, DASHValidationDepthMedium)
+	if err == nil {
+		t.Fatal("Expected error for missing segment")
+	}
+	if !strings.Contains(err.Error(), "chunk-stream0-00002.m4s") {
+		t.Errorf("Expected error to mention missing segment, got %v", err)
+	}
+
+	// Basic ではセグメントの存在を確認しない
+	if _, err := NewDASHParser().ParseAndValidate(context.Background(), dir, DASHValidationDepthBasic); err != nil {
+		t.Errorf("Expected basic depth to skip segment check, got %v", err)
+	}
+}
+
+func TestDASHParser_ParseAndValidate_TemplateDuration(t *testing.T) {
+	dir := t.TempDir()
+	manifest := `<MPD mediaPresentationDuration="PT5S">
+	<Period>
+		<AdaptationSet mimeType="video/mp4" codecs="hvc1">
+			<SegmentTemplate timescale="1000" duration="2000" initialization="$RepresentationID$/init.mp4" media="$RepresentationID$/seg-$Number$.m4s" />
+			<Representation id="hd" bandwidth="4000000" width="1920" height="1080" />
+		</AdaptationSet>
+	</Period>
+</MPD>`
+	if err := os.MkdirAll(filepath.Join(dir, "hd"), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	writeDASHFiles(t, dir, manifest, "hd/init.mp4", "hd/seg-1.m4s", "hd/seg-2.m4s", "hd/seg-3.m4s")
+
+	info, err := NewDASHParser().ParseAndValidate(context.Background(), dir, DASHValidationDepthMedium)
+	if err != nil {
+		t.Fatalf("ParseAndValidate failed: %v", err)
+	}
+
+	rep := info.Representations[0]
+	if rep.SegmentCount != 3 {
+		t.Errorf("Expected 3 segments, got %d", rep.SegmentCount)
+	}
+	if rep.MimeType != "video/mp4" || rep.Codecs != "hvc1" {
+		t.Errorf("Expected attributes inherited from AdaptationSet, got %+v", rep)
+	}
+}
+
+func TestDASHParser_ParseAndValidate_InvalidManifest(t *testing.T) {
+	dir := t.TempDir()
+	writeDASHFiles(t, dir, "<MPD><Period>")
+
+	if _, err := NewDASHParser().ParseAndValidate(context.Background(), dir, DASHValidationDepthBasic); err == nil {
+		t.Error("Expected error for invalid manifest")
+	}
+
+	if _, err := NewDASHParser().ParseAndValidate(context.Background(), t.TempDir(), DASHValidationDepthBasic); err == nil {
+		t.Error("Expected error when no manifest exists")
+	}
+}
+
+func TestParseISO8601Duration(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+		wantErr  bool
+	}{
+		{input: "PT10.0S", expected: 10},
+		{input: "PT1H2M3.5S", expected: 3723.5},
+		{input: "P1DT1S", expected: 86401},
+		{input: "PT", wantErr: true},
+		{input: "10s", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := parseISO8601Duration(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected error for %s", tt.input)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if math.Abs(got-tt.expected) > 1e-9 {
+				t.Errorf("Expected %f, got %f", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestDefaultValidator_IsDASHOutput(t *testing.T) {
+	validator := &DefaultValidator{}
+	tmpDir := t.TempDir()
+
+	dashDir := filepath.Join(tmpDir, "dash")
+	if err := os.MkdirAll(dashDir, 0755); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	writeDASHFiles(t, dashDir, ffmpegDASHManifest)
+
+	hlsDir := filepath.Join(tmpDir, "hls")
+	if err := os.MkdirAll(hlsDir, 0755); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(hlsDir, "playlist.m3u8"), []byte("#EXTM3U\n"), 0644); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		expected bool
+	}{
+		{name: "mpd file", path: filepath.Join(dashDir, "manifest.mpd"), expected: true},
+		{name: "directory with mpd", path: dashDir, expected: true},
+		{name: "directory with m3u8", path: hlsDir, expected: false},
+		{name: "mp4 file", path: filepath.Join(tmpDir, "test.mp4"), expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := validator.isDASHOutput(tt.path); result != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
@@ -8,15 +8,22 @@ import (
 	"strings"
 )
 
+// maxDecodeErrorLines はデコードテストで収集する stderr の最大行数
+// 壊れた入力では大量のエラーが出力されるため、メモリを使い果たさないよう先頭のみを保持する
+const maxDecodeErrorLines = 100
+
 // DecodeValidator はデコード検証を行う
 type DecodeValidator struct {
 	ffmpegPath string
 }
 
-// NewDecodeValidator は新しいDecodeValidatorを作成する
-func NewDecodeValidator() *DecodeValidator {
+// NewDecodeValidator は ffmpegPath の ffmpeg でデコードする DecodeValidator を作成する（空の場合は PATH の ffmpeg）
+func NewDecodeValidator(ffmpegPath string) *DecodeValidator {
+	if ffmpegPath == "" {
+		ffmpegPath = DefaultFFmpegPath
+	}
 	return &DecodeValidator{
-		ffmpegPath: "ffmpeg",
+		ffmpegPath: ffmpegPath,
 	}
 }
 
@@ -24,7 +31,9 @@ func NewDecodeValidator() *DecodeValidator {
 func (d *DecodeValidator) TestDecode(ctx context.Context, filePath string) error {
 	// ffmpeg -v error -i input -f null - を実行
 	// エラーがあればstderrに出力される
+	// -nostdin: ffmpeg が Worker の stdin を読んで止まらないようにする
 	cmd := exec.CommandContext(ctx, d.ffmpegPath,
+		"-nostdin",
 		"-v", "error",
 		"-i", filePath,
 		"-f", "null",
@@ -46,8 +55,8 @@ func (d *DecodeValidator) TestDecode(ctx context.Context, filePath string) error
 	scanner := bufio.NewScanner(stderr)
 	for scanner.Scan() {
 		line := scanner.Text()
-		// 空行以外を収集
-		if strings.TrimSpace(line) != "" {
+		// 空行以外を収集（上限を超えた分は読み捨てる）
+		if strings.TrimSpace(line) != "" && len(errorLines) < maxDecodeErrorLines {
 			errorLines = append(errorLines, line)
 		}
 	}
@@ -7,6 +7,15 @@ import (
 	"os/exec"
 	"strconv"
 	"strings"
+
+	"github.com/nzws/flux-encoder/internal/shared/execlimit"
+)
+
+const (
+	// DefaultFFmpegPath は ffmpeg の実行ファイルのデフォルト値（PATH から探す）
+	DefaultFFmpegPath = "ffmpeg"
+	// DefaultFFprobePath は ffprobe の実行ファイルのデフォルト値（PATH から探す）
+	DefaultFFprobePath = "ffprobe"
 )
 
 // FFProbe はffprobeコマンドのラッパー
@@ -14,10 +23,13 @@ type FFProbe struct {
 	execPath string
 }
 
-// NewFFProbe は新しいFFProbeを作成する
-func NewFFProbe() *FFProbe {
+// NewFFProbe は execPath の ffprobe を実行する FFProbe を作成する（空の場合は PATH の ffprobe）
+func NewFFProbe(execPath string) *FFProbe {
+	if execPath == "" {
+		execPath = DefaultFFprobePath
+	}
 	return &FFProbe{
-		execPath: "ffprobe",
+		execPath: execPath,
 	}
 }
 
@@ -41,6 +53,7 @@ type ffprobeStream struct {
 	CodecType     string `json:"codec_type"`
 	CodecLongName string `json:"codec_long_name"`
 	Profile       string `json:"profile"`
+	Level         int    `json:"level"`
 	Width         int    `json:"width"`
 	Height        int    `json:"height"`
 	PixFmt        string `json:"pix_fmt"`
@@ -62,7 +75,7 @@ func (f *FFProbe) GetMediaInfo(ctx context.Context, filePath string) (*MediaInfo
 		filePath,
 	)
 
-	output, err := cmd.Output()
+	output, err := execlimit.Output(cmd)
 	if err != nil {
 		if exitErr, ok := err.(*exec.ExitError); ok {
 			return nil, fmt.Errorf("ffprobe failed: %w, stderr: %s", err, string(exitErr.Stderr))
@@ -119,6 +132,7 @@ func (f *FFProbe) buildVideoStream(stream ffprobeStream) VideoStreamInfo {
 	videoInfo := VideoStreamInfo{
 		Codec:       stream.CodecName,
 		Profile:     stream.Profile,
+		Level:       formatLevel(stream.CodecName, stream.Level),
 		Width:       stream.Width,
 		Height:      stream.Height,
 		PixelFormat: stream.PixFmt,
@@ -134,6 +148,28 @@ func (f *FFProbe) buildVideoStream(stream ffprobeStream) VideoStreamInfo {
 	return videoInfo
 }
 
+// formatLevel は ffprobe の level（H.264 は 10 倍、HEVC は 30 倍した整数）を "3.1" のような表記に変換する
+// ffprobe はレベルが不明な場合に -99 を返すため、0 以下は空文字列とする。H.264・HEVC 以外のコーデックは整数のまま返す
+func formatLevel(codec string, level int) string {
+	if level <= 0 {
+		return ""
+	}
+
+	var scale int
+	switch codec {
+	case "h264":
+		scale = 10
+	case "hevc":
+		scale = 30
+	default:
+		return strconv.Itoa(level)
+	}
+	if level%scale == 0 {
+		return strconv.Itoa(level / scale)
+	}
+	return strconv.FormatFloat(float64(level)/float64(scale), 'f', 1, 64)
+}
+
 func (f *FFProbe) selectFrameRate(stream ffprobeStream) float64 {
 	if stream.RFrameRate != "" {
 		return f.parseFrameRate(stream.RFrameRate)
@@ -194,6 +230,11 @@ func parseInt(value string) (int, bool) {
 
 // parseFrameRate はフレームレート文字列（例: "30000/1001"）をfloat64に変換する
 func (f *FFProbe) parseFrameRate(frameRateStr string) float64 {
+	return ParseFrameRate(frameRateStr)
+}
+
+// ParseFrameRate はフレームレート文字列（"30000/1001" や "29.97"）をfloat64に変換する（不正な値は 0）
+func ParseFrameRate(frameRateStr string) float64 {
 	parts := strings.Split(frameRateStr, "/")
 	if len(parts) != 2 {
 		// "/" がない場合は直接パース
@@ -213,21 +254,26 @@ func (f *FFProbe) parseFrameRate(frameRateStr string) float64 {
 	return numerator / denominator
 }
 
-// ValidatePlaylist はプレイリストファイルの構文をチェックする
+// ValidatePlaylist はプレイリストを ffprobe で解析できるかチェックする
+// フォーマットの判定に必要な分だけ読み込み、全セグメントのデコードは行わない（デコードは DecodeValidator で行う）
 func (f *FFProbe) ValidatePlaylist(ctx context.Context, playlistPath string) error {
 	cmd := exec.CommandContext(ctx, f.execPath,
 		"-v", "error",
-		"-i", playlistPath,
-		"-f", "null",
-		"-",
+		"-show_entries", "format=format_name",
+		"-of", "default=noprint_wrappers=1:nokey=1",
+		playlistPath,
 	)
 
-	if err := cmd.Run(); err != nil {
+	output, err := execlimit.Output(cmd)
+	if err != nil {
 		if exitErr, ok := err.(*exec.ExitError); ok {
-			return fmt.Errorf("playlist validation failed: %s", string(exitErr.Stderr))
+			return fmt.Errorf("playlist validation failed: %s", strings.TrimSpace(string(exitErr.Stderr)))
 		}
 		return fmt.Errorf("playlist validation failed: %w", err)
 	}
+	if !strings.Contains(string(output), "hls") {
+		return fmt.Errorf("playlist validation failed: not recognized as hls (format: %s)", strings.TrimSpace(string(output)))
+	}
 
 	return nil
 }
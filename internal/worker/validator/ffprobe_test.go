@@ -1,11 +1,17 @@
 package validator
 
 import (
+	"context"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
+
+	"github.com/nzws/flux-encoder/internal/shared/execlimit"
 )
 
 func TestFFProbe_ParseFrameRate(t *testing.T) {
-	ffprobe := NewFFProbe()
+	ffprobe := NewFFProbe(DefaultFFprobePath)
 
 	tests := []struct {
 		input    string
@@ -31,8 +37,32 @@ func TestFFProbe_ParseFrameRate(t *testing.T) {
 	}
 }
 
+func TestFFProbe_FormatLevel(t *testing.T) {
+	tests := []struct {
+		codec    string
+		level    int
+		expected string
+	}{
+		{"h264", 31, "3.1"},
+		{"h264", 40, "4"},
+		{"h264", 51, "5.1"},
+		{"hevc", 93, "3.1"},
+		{"hevc", 120, "4"},
+		{"hevc", 153, "5.1"},
+		{"vp9", 41, "41"},
+		{"h264", -99, ""}, // ffprobe がレベルを取得できない場合
+		{"h264", 0, ""},
+	}
+
+	for _, tt := range tests {
+		if got := formatLevel(tt.codec, tt.level); got != tt.expected {
+			t.Errorf("formatLevel(%q, %d) = %q, want %q", tt.codec, tt.level, got, tt.expected)
+		}
+	}
+}
+
 func TestFFProbe_ConvertToMediaInfo_BasicVideoWithAudio(t *testing.T) {
-	ffprobe := NewFFProbe()
+	ffprobe := NewFFProbe(DefaultFFprobePath)
 
 	input := &ffprobeOutput{
 		Format: ffprobeFormat{
@@ -46,6 +76,7 @@ func TestFFProbe_ConvertToMediaInfo_BasicVideoWithAudio(t *testing.T) {
 				CodecType:    "video",
 				CodecName:    "h264",
 				Profile:      "High",
+				Level:        31,
 				Width:        1280,
 				Height:       720,
 				PixFmt:       "yuv420p",
@@ -100,6 +131,9 @@ func TestFFProbe_ConvertToMediaInfo_BasicVideoWithAudio(t *testing.T) {
 	if video.FrameRate != 30.0 {
 		t.Errorf("Expected frame rate 30.0, got %f", video.FrameRate)
 	}
+	if video.Profile != "High" || video.Level != "3.1" {
+		t.Errorf("Expected profile High level 3.1, got %s level %s", video.Profile, video.Level)
+	}
 
 	// Validate audio stream
 	if len(mediaInfo.AudioStreams) != 1 {
@@ -118,7 +152,7 @@ func TestFFProbe_ConvertToMediaInfo_BasicVideoWithAudio(t *testing.T) {
 }
 
 func TestFFProbe_ConvertToMediaInfo(t *testing.T) {
-	ffprobe := NewFFProbe()
+	ffprobe := NewFFProbe(DefaultFFprobePath)
 
 	tests := []struct {
 		name     string
@@ -189,3 +223,72 @@ func TestFFProbe_ConvertToMediaInfo(t *testing.T) {
 		})
 	}
 }
+
+func TestDefaultValidator_Validate_ProbeOutputTooLarge(t *testing.T) {
+	prev := execlimit.MaxOutputBytes()
+	execlimit.SetMaxOutputBytes(1024)
+	t.Cleanup(func() { execlimit.SetMaxOutputBytes(prev) })
+
+	outputFile := filepath.Join(t.TempDir(), "out.mp4")
+	if err := os.WriteFile(outputFile, []byte("test video content"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	// 上限を超える JSON を出力し続ける ffprobe
+	v := &DefaultValidator{
+		ffprobe: &FFProbe{execPath: writeFakeCommand(t, "ffprobe", `printf '{"streams":['; while :; do printf '{"codec_type":"data"},'; done`)},
+	}
+
+	result, err := v.Validate(context.Background(), outputFile, &ValidationOptions{
+		Level:          ValidationLevelStandard,
+		Timeout:        10 * time.Second,
+		SkipDecodeTest: true,
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.Valid || len(result.Errors) != 1 || result.Errors[0].Code != "PROBE_OUTPUT_TOO_LARGE" {
+		t.Errorf("Expected PROBE_OUTPUT_TOO_LARGE error, got %v", result.GetErrorMessages())
+	}
+}
+
+func TestNewWithPaths_UsesConfiguredBinaries(t *testing.T) {
+	// PATH に ffmpeg・ffprobe がなくても、指定した実行ファイルを使用する
+	t.Setenv("PATH", t.TempDir())
+
+	outputFile := filepath.Join(t.TempDir(), "out.mp4")
+	if err := os.WriteFile(outputFile, []byte("test video content"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	probe := `{"format":{"format_name":"mov,mp4,m4a,3gp,3g2,mj2","duration":"10.0"},"streams":[{"codec_type":"video","codec_name":"h264","width":1280,"height":720}]}`
+	ffprobePath := writeFakeCommand(t, "custom-ffprobe", "echo '"+probe+"'\n")
+	ffmpegPath := writeFakeCommand(t, "custom-ffmpeg", "echo 'decoded by custom ffmpeg' >&2\nexit 1\n")
+
+	v := NewWithPaths(ffmpegPath, ffprobePath)
+	result, err := v.Validate(context.Background(), outputFile, &ValidationOptions{
+		Level:   ValidationLevelStrict,
+		Timeout: 10 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.MediaInfo == nil || len(result.MediaInfo.VideoStreams) != 1 || result.MediaInfo.VideoStreams[0].Codec != "h264" {
+		t.Errorf("Expected media info from the configured ffprobe, got %+v (errors %v)", result.MediaInfo, result.GetErrorMessages())
+	}
+	if result.Valid || len(result.Errors) != 1 || result.Errors[0].Code != "DECODE_FAILED" {
+		t.Errorf("Expected DECODE_FAILED from the configured ffmpeg, got %v", result.GetErrorMessages())
+	}
+}
+
+func TestNewFFProbe_DefaultsToPath(t *testing.T) {
+	if got := NewFFProbe("").execPath; got != DefaultFFprobePath {
+		t.Errorf("Expected %q, got %q", DefaultFFprobePath, got)
+	}
+	if got := NewDecodeValidator("").ffmpegPath; got != DefaultFFmpegPath {
+		t.Errorf("Expected %q, got %q", DefaultFFmpegPath, got)
+	}
+	if got := NewFFProbe("/opt/ffmpeg/bin/ffprobe").execPath; got != "/opt/ffmpeg/bin/ffprobe" {
+		t.Errorf("Expected the configured path, got %q", got)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -21,7 +22,7 @@ type HLSParser struct {
 // NewHLSParser は新しいHLSParserを作成する
 func NewHLSParser() *HLSParser {
 	return &HLSParser{
-		ffprobe: NewFFProbe(),
+		ffprobe: NewFFProbe(DefaultFFprobePath),
 	}
 }
 
@@ -124,6 +125,11 @@ func (p *HLSParser) parseMasterPlaylist(ctx context.Context, baseDir, masterPath
 	for scanner.Scan() {
 		line := strings.TrimSpace(scanner.Text())
 
+		if strings.HasPrefix(line, "#EXT-X-VERSION") {
+			hlsInfo.Version = parseHLSVersion(line)
+			continue
+		}
+
 		if strings.HasPrefix(line, "#EXT-X-STREAM-INF") {
 			// STREAM-INF の属性をパース
 			currentStreamInfo = p.parseAttributes(line)
@@ -185,6 +191,10 @@ func (p *HLSParser) buildPlaylistInfo(ctx context.Context, baseDir, line string,
 	}
 	playlistInfo.SegmentCount = segmentInfo.SegmentCount
 	playlistInfo.Segments = segmentInfo.Segments
+	playlistInfo.Version = segmentInfo.Version
+	playlistInfo.RequiredVersion = segmentInfo.RequiredVersion
+	playlistInfo.RequiredBy = segmentInfo.RequiredBy
+	playlistInfo.InitSegments = segmentInfo.InitSegments
 
 	return playlistInfo, segmentInfo, nil
 }
@@ -201,11 +211,16 @@ func (p *HLSParser) parseSingleMediaPlaylist(ctx context.Context, baseDir, playl
 	}
 
 	playlistInfo := PlaylistInfo{
-		Path:         playlistPath,
-		SegmentCount: segmentInfo.SegmentCount,
-		Segments:     segmentInfo.Segments,
+		Path:            playlistPath,
+		SegmentCount:    segmentInfo.SegmentCount,
+		Segments:        segmentInfo.Segments,
+		Version:         segmentInfo.Version,
+		RequiredVersion: segmentInfo.RequiredVersion,
+		RequiredBy:      segmentInfo.RequiredBy,
+		InitSegments:    segmentInfo.InitSegments,
 	}
 
+	hlsInfo.Version = segmentInfo.Version
 	hlsInfo.Playlists = []PlaylistInfo{playlistInfo}
 	hlsInfo.TotalSegments = segmentInfo.SegmentCount
 	hlsInfo.TargetDuration = segmentInfo.TargetDuration
@@ -215,9 +230,21 @@ func (p *HLSParser) parseSingleMediaPlaylist(ctx context.Context, baseDir, playl
 
 // mediaPlaylistInfo は内部的なメディアプレイリスト情報
 type mediaPlaylistInfo struct {
-	SegmentCount   int
-	Segments       []SegmentInfo
-	TargetDuration float64
+	SegmentCount    int
+	Segments        []SegmentInfo
+	TargetDuration  float64
+	Version         int
+	RequiredVersion int
+	RequiredBy      string
+	InitSegments    []string
+}
+
+// require は使用している機能が必要とするバージョンを記録する（最大のものを保持）
+func (info *mediaPlaylistInfo) require(version int, feature string) {
+	if version > info.RequiredVersion {
+		info.RequiredVersion = version
+		info.RequiredBy = feature
+	}
 }
 
 // parseMediaPlaylist はメディアプレイリストをパースする
@@ -236,6 +263,13 @@ func (p *HLSParser) parseMediaPlaylist(ctx context.Context, baseDir, playlistPat
 
 	scanner := bufio.NewScanner(file)
 	var currentDuration float64
+	// currentRange は次のセグメントに適用する #EXT-X-BYTERANGE
+	var currentRange *pendingByteRange
+	// prevRangePath と prevRangeEnd は直前のセグメントが参照した範囲（オフセット省略時の開始位置）
+	var prevRangePath string
+	var prevRangeEnd int64
+	// probed は ffprobe で検証済みのファイル（同じファイルを参照する複数の範囲は1回だけ検証する）
+	probed := make(map[string]bool)
 
 	for scanner.Scan() {
 		line := strings.TrimSpace(scanner.Text())
@@ -243,6 +277,27 @@ func (p *HLSParser) parseMediaPlaylist(ctx context.Context, baseDir, playlistPat
 			continue
 		}
 
+		if strings.HasPrefix(line, "#EXT-X-MAP") {
+			info.require(6, "EXT-X-MAP")
+			initPath, err := p.checkInitSegment(playlistPath, line)
+			if err != nil {
+				return nil, err
+			}
+			if !slices.Contains(info.InitSegments, initPath) {
+				info.InitSegments = append(info.InitSegments, initPath)
+			}
+			continue
+		}
+
+		if strings.HasPrefix(line, "#EXT-X-BYTERANGE") {
+			info.require(4, "EXT-X-BYTERANGE")
+			currentRange, err = parseByteRange(strings.TrimPrefix(line, "#EXT-X-BYTERANGE:"))
+			if err != nil {
+				return nil, fmt.Errorf("invalid EXT-X-BYTERANGE %q: %w", line, err)
+			}
+			continue
+		}
+
 		if strings.HasPrefix(line, "#EXT-X-TARGETDURATION") {
 			p.updateTargetDuration(info, line)
 			continue
@@ -250,21 +305,56 @@ func (p *HLSParser) parseMediaPlaylist(ctx context.Context, baseDir, playlistPat
 
 		if strings.HasPrefix(line, "#EXTINF") {
 			currentDuration = parseSegmentDuration(line, currentDuration)
+			if hasDecimalDuration(line) {
+				info.require(3, "decimal EXTINF durations")
+			}
 			continue
 		}
 
 		if strings.HasPrefix(line, "#") {
+			p.updateVersionInfo(info, line)
 			continue
 		}
 
-		segment, err := p.buildSegmentInfo(ctx, playlistPath, line, currentDuration, depth)
+		// fMP4 セグメントは Apple の HLS オーサリング仕様でバージョン 7 以上が必要
+		if isFMP4Segment(line) {
+			info.require(7, "fMP4 segments")
+		}
+
+		var byteRange *ByteRange
+		if currentRange != nil {
+			// オフセットが省略された場合は、同じファイルを参照する直前のセグメントの続きから始まる
+			segmentPath := filepath.Join(filepath.Dir(playlistPath), line)
+			offset := currentRange.offset
+			if !currentRange.hasOffset {
+				if prevRangePath != segmentPath {
+					return nil, fmt.Errorf("EXT-X-BYTERANGE without offset must follow a sub-range of the same segment file: %s", line)
+				}
+				offset = prevRangeEnd
+			}
+			byteRange = &ByteRange{Length: currentRange.length, Offset: offset}
+			prevRangePath, prevRangeEnd = segmentPath, offset+currentRange.length
+		} else {
+			prevRangePath = ""
+		}
+
+		segment, err := p.buildSegmentInfo(ctx, playlistPath, line, currentDuration, byteRange, depth)
 		if err != nil {
 			return nil, err
 		}
 
+		// 範囲指定のセグメントはファイル全体の長さしか取得できないため、ffprobe ではファイルを1回だけ検証する
+		if byteRange != nil && depth >= HLSValidationDepthFull && !probed[segment.Path] {
+			if _, err := p.ffprobe.GetSegmentInfo(ctx, segment.Path); err != nil {
+				return nil, fmt.Errorf("failed to validate segment %s: %w", line, err)
+			}
+			probed[segment.Path] = true
+		}
+
 		info.Segments = append(info.Segments, segment)
 		info.SegmentCount++
 		currentDuration = 0
+		currentRange = nil
 	}
 
 	if err := scanner.Err(); err != nil {
@@ -284,6 +374,66 @@ func (p *HLSParser) updateTargetDuration(info *mediaPlaylistInfo, line string) {
 	}
 }
 
+// updateVersionInfo は宣言されたバージョンと、バージョン要件のあるタグを記録する
+// 要件は RFC 8216 に従う
+func (p *HLSParser) updateVersionInfo(info *mediaPlaylistInfo, line string) {
+	switch {
+	case strings.HasPrefix(line, "#EXT-X-VERSION"):
+		info.Version = parseHLSVersion(line)
+	case strings.HasPrefix(line, "#EXT-X-KEY"):
+		if _, ok := p.parseAttributes(line)["IV"]; ok {
+			info.require(2, "EXT-X-KEY with IV")
+		}
+	case strings.HasPrefix(line, "#EXT-X-I-FRAMES-ONLY"):
+		info.require(4, "EXT-X-I-FRAMES-ONLY")
+	}
+}
+
+// isFMP4Segment は fMP4 のセグメントかどうかを拡張子で判定する
+func isFMP4Segment(uri string) bool {
+	switch strings.ToLower(filepath.Ext(uri)) {
+	case ".m4s", ".mp4":
+		return true
+	}
+	return false
+}
+
+// parseHLSVersion は #EXT-X-VERSION:<n> からバージョンを取得する（不正な場合は 0）
+func parseHLSVersion(line string) int {
+	parts := strings.SplitN(line, ":", 2)
+	if len(parts) != 2 {
+		return 0
+	}
+	version, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+	if err != nil || version < 0 {
+		return 0
+	}
+	return version
+}
+
+// hasDecimalDuration は #EXTINF の長さが小数で書かれているかを返す
+func hasDecimalDuration(line string) bool {
+	parts := strings.SplitN(line, ":", 2)
+	if len(parts) != 2 {
+		return false
+	}
+	return strings.Contains(strings.Split(parts[1], ",")[0], ".")
+}
+
+// hlsVersionTooLow は宣言されたバージョンが使用している機能の要件を満たさない場合に理由を返す
+// 宣言がない場合はバージョン 1 として扱う
+func hlsVersionTooLow(playlist PlaylistInfo) string {
+	declared := playlist.Version
+	if declared == 0 {
+		declared = 1
+	}
+	if playlist.RequiredVersion <= declared {
+		return ""
+	}
+	return fmt.Sprintf("playlist %s declares EXT-X-VERSION %d but %s requires version %d",
+		filepath.Base(playlist.Path), declared, playlist.RequiredBy, playlist.RequiredVersion)
+}
+
 func parseSegmentDuration(line string, fallback float64) float64 {
 	parts := strings.Split(line, ":")
 	if len(parts) != 2 {
@@ -298,19 +448,26 @@ func parseSegmentDuration(line string, fallback float64) float64 {
 	return duration
 }
 
-func (p *HLSParser) buildSegmentInfo(ctx context.Context, playlistPath, segmentLine string, duration float64, depth HLSValidationDepth) (SegmentInfo, error) {
+func (p *HLSParser) buildSegmentInfo(ctx context.Context, playlistPath, segmentLine string, duration float64, byteRange *ByteRange, depth HLSValidationDepth) (SegmentInfo, error) {
 	segmentPath := filepath.Join(filepath.Dir(playlistPath), segmentLine)
-	if _, err := os.Stat(segmentPath); err != nil {
+	fileInfo, err := os.Stat(segmentPath)
+	if err != nil {
 		return SegmentInfo{}, fmt.Errorf("segment file not found: %s", segmentPath)
 	}
 
 	segment := SegmentInfo{
-		Path:     segmentPath,
-		Duration: duration,
+		Path:      segmentPath,
+		Duration:  duration,
+		Size:      fileInfo.Size(),
+		ByteRange: byteRange,
 	}
 
-	if fileInfo, err := os.Stat(segmentPath); err == nil {
-		segment.Size = fileInfo.Size()
+	if byteRange != nil {
+		if err := checkByteRange(byteRange, fileInfo.Size()); err != nil {
+			return SegmentInfo{}, fmt.Errorf("segment %s: %w", segmentPath, err)
+		}
+		segment.Size = byteRange.Length
+		return segment, nil
 	}
 
 	if depth >= HLSValidationDepthFull {
@@ -324,6 +481,69 @@ func (p *HLSParser) buildSegmentInfo(ctx context.Context, playlistPath, segmentL
 	return segment, nil
 }
 
+// checkInitSegment は #EXT-X-MAP の初期化セグメントが存在するかを確認し、そのパスを返す
+// BYTERANGE 属性がある場合は範囲がファイルに収まるかも確認する
+func (p *HLSParser) checkInitSegment(playlistPath, line string) (string, error) {
+	attributes := p.parseAttributes(line)
+	uri := strings.Trim(attributes["URI"], "\"")
+	if uri == "" {
+		return "", fmt.Errorf("EXT-X-MAP has no URI: %s", line)
+	}
+
+	initPath := filepath.Join(filepath.Dir(playlistPath), uri)
+	fileInfo, err := os.Stat(initPath)
+	if err != nil {
+		return "", fmt.Errorf("init segment file not found: %s", initPath)
+	}
+
+	if value, ok := attributes["BYTERANGE"]; ok {
+		pending, err := parseByteRange(strings.Trim(value, "\""))
+		if err != nil {
+			return "", fmt.Errorf("invalid EXT-X-MAP BYTERANGE %q: %w", value, err)
+		}
+		// EXT-X-MAP の BYTERANGE はオフセットを省略した場合 0 から始まる
+		if err := checkByteRange(&ByteRange{Length: pending.length, Offset: pending.offset}, fileInfo.Size()); err != nil {
+			return "", fmt.Errorf("init segment %s: %w", initPath, err)
+		}
+	}
+
+	return initPath, nil
+}
+
+// pendingByteRange は #EXT-X-BYTERANGE の値（"<n>[@<o>]"）
+type pendingByteRange struct {
+	length    int64
+	offset    int64
+	hasOffset bool
+}
+
+// parseByteRange は "<n>[@<o>]" 形式の範囲をパースする
+func parseByteRange(value string) (*pendingByteRange, error) {
+	lengthStr, offsetStr, hasOffset := strings.Cut(strings.TrimSpace(value), "@")
+	length, err := strconv.ParseInt(lengthStr, 10, 64)
+	if err != nil || length <= 0 {
+		return nil, fmt.Errorf("length must be a positive integer")
+	}
+
+	r := &pendingByteRange{length: length, hasOffset: hasOffset}
+	if hasOffset {
+		offset, err := strconv.ParseInt(offsetStr, 10, 64)
+		if err != nil || offset < 0 {
+			return nil, fmt.Errorf("offset must be a non-negative integer")
+		}
+		r.offset = offset
+	}
+	return r, nil
+}
+
+// checkByteRange は範囲がファイルサイズに収まるかを確認する
+func checkByteRange(r *ByteRange, fileSize int64) error {
+	if r.Offset+r.Length > fileSize {
+		return fmt.Errorf("byte range %d@%d exceeds file size %d", r.Length, r.Offset, fileSize)
+	}
+	return nil
+}
+
 // parseAttributes は属性行（例: #EXT-X-STREAM-INF:BANDWIDTH=2800000,RESOLUTION=1280x720）をパースする
 func (p *HLSParser) parseAttributes(line string) map[string]string {
 	attributes := make(map[string]string)
@@ -0,0 +1,337 @@
+package validator
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeHLSFiles(t *testing.T, dir string, playlists map[string]string, segments ...string) {
+	t.Helper()
+
+	for name, content := range playlists {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write playlist: %v", err)
+		}
+	}
+	for _, name := range segments {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("segment"), 0644); err != nil {
+			t.Fatalf("Failed to write segment: %v", err)
+		}
+	}
+}
+
+func TestParseHLSVersion(t *testing.T) {
+	tests := []struct {
+		line     string
+		expected int
+	}{
+		{"#EXT-X-VERSION:3", 3},
+		{"#EXT-X-VERSION:7", 7},
+		{"#EXT-X-VERSION: 6", 6},
+		{"#EXT-X-VERSION", 0},
+		{"#EXT-X-VERSION:abc", 0},
+		{"#EXT-X-VERSION:-1", 0},
+	}
+
+	for _, tt := range tests {
+		if got := parseHLSVersion(tt.line); got != tt.expected {
+			t.Errorf("parseHLSVersion(%q) = %d, want %d", tt.line, got, tt.expected)
+		}
+	}
+}
+
+func TestHLSParser_ParseAndValidate_Version(t *testing.T) {
+	dir := t.TempDir()
+	writeHLSFiles(t, dir, map[string]string{
+		"master.m3u8": "#EXTM3U\n#EXT-X-VERSION:6\n#EXT-X-STREAM-INF:BANDWIDTH=2800000,RESOLUTION=1280x720\nstream_0.m3u8\n",
+		"stream_0.m3u8": "#EXTM3U\n#EXT-X-VERSION:6\n#EXT-X-TARGETDURATION:6\n#EXT-X-MAP:URI=\"init_0.mp4\"\n" +
+			"#EXTINF:6.000000,\nsegment_0_000.m4s\n#EXT-X-ENDLIST\n",
+	}, "init_0.mp4", "segment_0_000.m4s")
+
+	info, err := NewHLSParser().ParseAndValidate(context.Background(), dir, HLSValidationDepthMedium)
+	if err != nil {
+		t.Fatalf("ParseAndValidate() error = %v", err)
+	}
+
+	if info.Version != 6 {
+		t.Errorf("Version = %d, want 6", info.Version)
+	}
+	if len(info.Playlists) != 1 {
+		t.Fatalf("Playlists count = %d, want 1", len(info.Playlists))
+	}
+	playlist := info.Playlists[0]
+	if playlist.Version != 6 {
+		t.Errorf("Playlist version = %d, want 6", playlist.Version)
+	}
+	if playlist.RequiredVersion != 7 {
+		t.Errorf("RequiredVersion = %d, want 7", playlist.RequiredVersion)
+	}
+	if !strings.Contains(playlist.RequiredBy, "fMP4") {
+		t.Errorf("RequiredBy = %q, want it to mention fMP4", playlist.RequiredBy)
+	}
+}
+
+func TestHLSParser_ParseAndValidate_UnderDeclaredVersion(t *testing.T) {
+	tests := []struct {
+		name              string
+		playlist          string
+		segments          []string
+		wantRequired      int
+		wantFeature       string
+		wantUnderDeclared bool
+	}{
+		{
+			name:              "EXT-X-KEY IV with version 1",
+			playlist:          "#EXTM3U\n#EXT-X-VERSION:1\n#EXT-X-TARGETDURATION:6\n#EXT-X-KEY:METHOD=AES-128,URI=\"key.bin\",IV=0x00000000000000000000000000000001\n#EXTINF:6,\nsegment_000.ts\n",
+			segments:          []string{"segment_000.ts"},
+			wantRequired:      2,
+			wantFeature:       "EXT-X-KEY",
+			wantUnderDeclared: true,
+		},
+		{
+			name:              "EXT-X-MAP with version 5",
+			playlist:          "#EXTM3U\n#EXT-X-VERSION:5\n#EXT-X-TARGETDURATION:6\n#EXT-X-MAP:URI=\"init.ts\"\n#EXTINF:6.000000,\nsegment_000.ts\n",
+			segments:          []string{"init.ts", "segment_000.ts"},
+			wantRequired:      6,
+			wantFeature:       "EXT-X-MAP",
+			wantUnderDeclared: true,
+		},
+		{
+			name:              "fMP4 segments with version 6",
+			playlist:          "#EXTM3U\n#EXT-X-VERSION:6\n#EXT-X-TARGETDURATION:6\n#EXT-X-MAP:URI=\"init.mp4\"\n#EXTINF:6.000000,\nsegment_000.m4s\n",
+			segments:          []string{"init.mp4", "segment_000.m4s"},
+			wantRequired:      7,
+			wantFeature:       "fMP4",
+			wantUnderDeclared: true,
+		},
+		{
+			name:              "decimal durations without version tag",
+			playlist:          "#EXTM3U\n#EXT-X-TARGETDURATION:6\n#EXTINF:6.006000,\nsegment_000.ts\n",
+			segments:          []string{"segment_000.ts"},
+			wantRequired:      3,
+			wantFeature:       "decimal EXTINF",
+			wantUnderDeclared: true,
+		},
+		{
+			name:              "ffmpeg TS output with version 3",
+			playlist:          "#EXTM3U\n#EXT-X-VERSION:3\n#EXT-X-TARGETDURATION:6\n#EXTINF:6.006000,\nsegment_000.ts\n#EXT-X-ENDLIST\n",
+			segments:          []string{"segment_000.ts"},
+			wantRequired:      3,
+			wantFeature:       "decimal EXTINF",
+			wantUnderDeclared: false,
+		},
+		{
+			name:              "ffmpeg fMP4 output with version 7",
+			playlist:          "#EXTM3U\n#EXT-X-VERSION:7\n#EXT-X-TARGETDURATION:6\n#EXT-X-MAP:URI=\"init.mp4\"\n#EXTINF:6.000000,\nsegment_000.m4s\n#EXT-X-ENDLIST\n",
+			segments:          []string{"init.mp4", "segment_000.m4s"},
+			wantRequired:      7,
+			wantFeature:       "fMP4",
+			wantUnderDeclared: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeHLSFiles(t, dir, map[string]string{"playlist.m3u8": tt.playlist}, tt.segments...)
+
+			info, err := NewHLSParser().ParseAndValidate(context.Background(), dir, HLSValidationDepthMedium)
+			if err != nil {
+				t.Fatalf("ParseAndValidate() error = %v", err)
+			}
+
+			playlist := info.Playlists[0]
+			if playlist.RequiredVersion != tt.wantRequired {
+				t.Errorf("RequiredVersion = %d, want %d", playlist.RequiredVersion, tt.wantRequired)
+			}
+			if !strings.Contains(playlist.RequiredBy, tt.wantFeature) {
+				t.Errorf("RequiredBy = %q, want it to mention %q", playlist.RequiredBy, tt.wantFeature)
+			}
+
+			message := hlsVersionTooLow(playlist)
+			if (message != "") != tt.wantUnderDeclared {
+				t.Errorf("hlsVersionTooLow() = %q, wantUnderDeclared %v", message, tt.wantUnderDeclared)
+			}
+		})
+	}
+}
+
+func TestDefaultValidator_ValidateHLS_VersionTooLow(t *testing.T) {
+	dir := t.TempDir()
+	writeHLSFiles(t, dir, map[string]string{
+		"playlist.m3u8": "#EXTM3U\n#EXT-X-VERSION:6\n#EXT-X-TARGETDURATION:6\n#EXT-X-MAP:URI=\"init.mp4\"\n#EXTINF:6.000000,\nsegment_000.m4s\n",
+	}, "init.mp4", "segment_000.m4s")
+
+	v := New().(*DefaultValidator)
+	result := &ValidationResult{Valid: true, MediaInfo: &MediaInfo{}}
+	v.validateHLS(context.Background(), dir, &ValidationOptions{HLSValidationDepth: HLSValidationDepthMedium}, result)
+
+	if result.Valid {
+		t.Fatal("Expected validation to fail for an under-declared playlist version")
+	}
+	found := false
+	for _, e := range result.Errors {
+		if e.Code == "HLS_VERSION_TOO_LOW" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expected HLS_VERSION_TOO_LOW error, got %v", result.GetErrorMessages())
+	}
+}
+
+func TestPlaylistFullDecode(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  ValidationOptions
+		expected bool
+	}{
+		{"standard medium", ValidationOptions{Level: ValidationLevelStandard, HLSValidationDepth: HLSValidationDepthMedium}, false},
+		{"minimal basic", ValidationOptions{Level: ValidationLevelMinimal, HLSValidationDepth: HLSValidationDepthBasic}, false},
+		{"standard full", ValidationOptions{Level: ValidationLevelStandard, HLSValidationDepth: HLSValidationDepthFull}, true},
+		{"strict medium", ValidationOptions{Level: ValidationLevelStrict, HLSValidationDepth: HLSValidationDepthMedium}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := playlistFullDecode(&tt.options); got != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestDefaultValidator_ValidateHLS_PlaylistCheckPath(t *testing.T) {
+	tests := []struct {
+		name        string
+		level       ValidationLevel
+		wantCommand string
+	}{
+		{"standard probes playlist", ValidationLevelStandard, "ffprobe"},
+		{"strict decodes playlist", ValidationLevelStrict, "ffmpeg"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeHLSFiles(t, dir, map[string]string{
+				"playlist.m3u8": "#EXTM3U\n#EXT-X-VERSION:3\n#EXT-X-TARGETDURATION:6\n#EXTINF:6.000000,\nsegment_000.ts\n#EXT-X-ENDLIST\n",
+			}, "segment_000.ts")
+
+			// 実行されたコマンド名をログに記録する偽の ffprobe / ffmpeg
+			logPath := filepath.Join(t.TempDir(), "commands.log")
+			v := &DefaultValidator{
+				hlsParser:       NewHLSParser(),
+				ffprobe:         &FFProbe{execPath: writeFakeCommand(t, "ffprobe", "echo ffprobe >> "+logPath+"\necho hls\n")},
+				decodeValidator: &DecodeValidator{ffmpegPath: writeFakeCommand(t, "ffmpeg", "echo ffmpeg >> "+logPath+"\n")},
+			}
+			result := &ValidationResult{Valid: true, MediaInfo: &MediaInfo{}}
+			v.validateHLS(context.Background(), dir, &ValidationOptions{Level: tt.level, HLSValidationDepth: HLSValidationDepthMedium}, result)
+
+			if !result.Valid {
+				t.Fatalf("Expected valid result, got %v", result.GetErrorMessages())
+			}
+			log, err := os.ReadFile(logPath)
+			if err != nil {
+				t.Fatalf("Failed to read command log: %v", err)
+			}
+			if strings.TrimSpace(string(log)) != tt.wantCommand {
+				t.Errorf("Expected only %s to run, got %q", tt.wantCommand, log)
+			}
+		})
+	}
+}
+
+func TestFFProbe_ValidatePlaylist_NotHLS(t *testing.T) {
+	f := &FFProbe{execPath: writeFakeCommand(t, "ffprobe", "echo mov,mp4,m4a,3gp,3g2,mj2\n")}
+
+	if err := f.ValidatePlaylist(context.Background(), "playlist.m3u8"); err == nil {
+		t.Error("Expected error for a playlist not recognized as hls")
+	}
+}
+
+func TestHLSParser_ParseAndValidate_ByteRange(t *testing.T) {
+	dir := t.TempDir()
+	writeHLSFiles(t, dir, map[string]string{
+		"playlist.m3u8": "#EXTM3U\n#EXT-X-VERSION:7\n#EXT-X-TARGETDURATION:6\n#EXT-X-MAP:URI=\"init.mp4\"\n" +
+			"#EXTINF:6.000000,\n#EXT-X-BYTERANGE:3@0\nmain.mp4\n" +
+			"#EXTINF:6.000000,\n#EXT-X-BYTERANGE:2\nmain.mp4\n" +
+			"#EXTINF:4.000000,\n#EXT-X-BYTERANGE:2@5\nmain.mp4\n#EXT-X-ENDLIST\n",
+	}, "init.mp4")
+	// セグメントは1つのファイル（7バイト）を3つの範囲に分けて参照する
+	if err := os.WriteFile(filepath.Join(dir, "main.mp4"), []byte("0123456"), 0644); err != nil {
+		t.Fatalf("Failed to write segment: %v", err)
+	}
+
+	info, err := NewHLSParser().ParseAndValidate(context.Background(), dir, HLSValidationDepthMedium)
+	if err != nil {
+		t.Fatalf("ParseAndValidate() error = %v", err)
+	}
+
+	if info.TotalSegments != 3 {
+		t.Errorf("TotalSegments = %d, want 3", info.TotalSegments)
+	}
+	playlist := info.Playlists[0]
+	if len(playlist.InitSegments) != 1 || playlist.InitSegments[0] != filepath.Join(dir, "init.mp4") {
+		t.Errorf("InitSegments = %v, want [%s]", playlist.InitSegments, filepath.Join(dir, "init.mp4"))
+	}
+
+	want := []ByteRange{{Length: 3, Offset: 0}, {Length: 2, Offset: 3}, {Length: 2, Offset: 5}}
+	for i, segment := range playlist.Segments {
+		if segment.ByteRange == nil || *segment.ByteRange != want[i] {
+			t.Errorf("Segments[%d].ByteRange = %v, want %v", i, segment.ByteRange, want[i])
+		}
+		if segment.Size != want[i].Length {
+			t.Errorf("Segments[%d].Size = %d, want %d", i, segment.Size, want[i].Length)
+		}
+	}
+}
+
+func TestHLSParser_ParseAndValidate_InvalidFMP4References(t *testing.T) {
+	tests := []struct {
+		name     string
+		playlist string
+		segments []string
+		wantErr  string
+	}{
+		{
+			name:     "missing init segment",
+			playlist: "#EXTM3U\n#EXT-X-VERSION:7\n#EXT-X-TARGETDURATION:6\n#EXT-X-MAP:URI=\"init.mp4\"\n#EXTINF:6.000000,\nsegment_000.m4s\n",
+			segments: []string{"segment_000.m4s"},
+			wantErr:  "init segment file not found",
+		},
+		{
+			name:     "byte range beyond file size",
+			playlist: "#EXTM3U\n#EXT-X-VERSION:7\n#EXT-X-TARGETDURATION:6\n#EXTINF:6.000000,\n#EXT-X-BYTERANGE:100@0\nsegment_000.ts\n",
+			segments: []string{"segment_000.ts"},
+			wantErr:  "exceeds file size",
+		},
+		{
+			name:     "byte range without offset as first sub-range",
+			playlist: "#EXTM3U\n#EXT-X-VERSION:7\n#EXT-X-TARGETDURATION:6\n#EXTINF:6.000000,\n#EXT-X-BYTERANGE:3\nsegment_000.ts\n",
+			segments: []string{"segment_000.ts"},
+			wantErr:  "must follow a sub-range",
+		},
+		{
+			name:     "malformed byte range",
+			playlist: "#EXTM3U\n#EXT-X-VERSION:7\n#EXT-X-TARGETDURATION:6\n#EXTINF:6.000000,\n#EXT-X-BYTERANGE:abc\nsegment_000.ts\n",
+			segments: []string{"segment_000.ts"},
+			wantErr:  "invalid EXT-X-BYTERANGE",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeHLSFiles(t, dir, map[string]string{"playlist.m3u8": tt.playlist}, tt.segments...)
+
+			_, err := NewHLSParser().ParseAndValidate(context.Background(), dir, HLSValidationDepthMedium)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ParseAndValidate() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
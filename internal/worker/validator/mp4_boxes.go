@@ -0,0 +1,127 @@
+package validator
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+
+	"github.com/nzws/flux-encoder/internal/shared/logger"
+	"go.uber.org/zap"
+)
+
+// maxTopLevelBoxes は moov/mdat を探すために読むトップレベルボックスの上限
+const maxTopLevelBoxes = 32
+
+// mp4BoxHeader はMP4ボックスのヘッダー情報
+type mp4BoxHeader struct {
+	Type   string
+	Offset int64
+	Size   int64
+}
+
+// readTopLevelBoxes はMP4ファイルのトップレベルボックスを先頭から順に読み取る
+// moov と mdat の両方が見つかった時点、または maxBoxes 個読んだ時点で終了する
+func readTopLevelBoxes(path string, maxBoxes int) ([]mp4BoxHeader, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			logger.Warn("Failed to close mp4 file", zap.Error(err))
+		}
+	}()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat file: %w", err)
+	}
+	fileSize := info.Size()
+
+	var boxes []mp4BoxHeader
+	var foundMoov, foundMdat bool
+	offset := int64(0)
+	header := make([]byte, 8)
+
+	for len(boxes) < maxBoxes && offset+8 <= fileSize {
+		if _, err := file.ReadAt(header, offset); err != nil {
+			return nil, fmt.Errorf("failed to read box header at %d: %w", offset, err)
+		}
+
+		size := int64(binary.BigEndian.Uint32(header[0:4]))
+		boxType := string(header[4:8])
+
+		switch size {
+		case 0:
+			// サイズ0はファイル末尾まで続くボックス
+			size = fileSize - offset
+		case 1:
+			// サイズ1は64bitの拡張サイズが続く
+			largeSize := make([]byte, 8)
+			if _, err := file.ReadAt(largeSize, offset+8); err != nil {
+				return nil, fmt.Errorf("failed to read extended box size at %d: %w", offset, err)
+			}
+			size = int64(binary.BigEndian.Uint64(largeSize))
+		}
+
+		if size < 8 {
+			return nil, fmt.Errorf("invalid box size %d for %q at offset %d", size, boxType, offset)
+		}
+
+		boxes = append(boxes, mp4BoxHeader{Type: boxType, Offset: offset, Size: size})
+
+		switch boxType {
+		case "moov":
+			foundMoov = true
+		case "mdat":
+			foundMdat = true
+		}
+		if foundMoov && foundMdat {
+			return boxes, nil
+		}
+
+		offset += size
+	}
+
+	if len(boxes) == 0 {
+		return nil, fmt.Errorf("no mp4 boxes found")
+	}
+
+	return boxes, nil
+}
+
+// isMoovBeforeMdat は moov ボックスが mdat ボックスより前にあるかを判定する
+// どちらかが見つからない場合はエラーを返す
+func isMoovBeforeMdat(boxes []mp4BoxHeader) (bool, error) {
+	moovIndex, mdatIndex := -1, -1
+	for i, box := range boxes {
+		switch box.Type {
+		case "moov":
+			if moovIndex < 0 {
+				moovIndex = i
+			}
+		case "mdat":
+			if mdatIndex < 0 {
+				mdatIndex = i
+			}
+		}
+	}
+
+	if moovIndex < 0 {
+		return false, fmt.Errorf("moov box not found")
+	}
+	if mdatIndex < 0 {
+		return false, fmt.Errorf("mdat box not found")
+	}
+
+	return moovIndex < mdatIndex, nil
+}
+
+// checkMP4FastStart は faststart されたMP4の moov が先頭側にあるか検証する
+func checkMP4FastStart(path string) (bool, error) {
+	boxes, err := readTopLevelBoxes(path, maxTopLevelBoxes)
+	if err != nil {
+		return false, err
+	}
+	return isMoovBeforeMdat(boxes)
+}
@@ -0,0 +1,146 @@
+package validator
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeMP4Fixture は指定した順序のトップレベルボックスを持つ最小限のMP4ファイルを作成する
+func writeMP4Fixture(t *testing.T, path string, boxTypes ...string) {
+	t.Helper()
+
+	var data []byte
+	for _, boxType := range boxTypes {
+		payload := []byte("payload-" + boxType)
+		header := make([]byte, 8)
+		binary.BigEndian.PutUint32(header[0:4], uint32(8+len(payload)))
+		copy(header[4:8], boxType)
+		data = append(data, header...)
+		data = append(data, payload...)
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("Failed to write mp4 fixture: %v", err)
+	}
+}
+
+func TestCheckMP4FastStart(t *testing.T) {
+	tests := []struct {
+		name      string
+		boxes     []string
+		expected  bool
+		expectErr bool
+	}{
+		{
+			name:     "faststart (moov before mdat)",
+			boxes:    []string{"ftyp", "moov", "mdat"},
+			expected: true,
+		},
+		{
+			name:     "non-faststart (mdat before moov)",
+			boxes:    []string{"ftyp", "mdat", "moov"},
+			expected: false,
+		},
+		{
+			name:     "free box between ftyp and moov",
+			boxes:    []string{"ftyp", "free", "moov", "mdat"},
+			expected: true,
+		},
+		{
+			name:      "missing moov",
+			boxes:     []string{"ftyp", "mdat"},
+			expectErr: true,
+		},
+	}
+
+	tmpDir := t.TempDir()
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(tmpDir, "fixture_"+string(rune('a'+i))+".mp4")
+			writeMP4Fixture(t, path, tt.boxes...)
+
+			ok, err := checkMP4FastStart(path)
+			if tt.expectErr {
+				if err == nil {
+					t.Errorf("Expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if ok != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, ok)
+			}
+		})
+	}
+}
+
+func TestReadTopLevelBoxes_ExtendedSize(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "largesize.mp4")
+
+	// ftyp（通常サイズ）+ moov（64bit拡張サイズ）+ mdat
+	var data []byte
+	ftyp := make([]byte, 16)
+	binary.BigEndian.PutUint32(ftyp[0:4], 16)
+	copy(ftyp[4:8], "ftyp")
+	data = append(data, ftyp...)
+
+	moov := make([]byte, 24)
+	binary.BigEndian.PutUint32(moov[0:4], 1)
+	copy(moov[4:8], "moov")
+	binary.BigEndian.PutUint64(moov[8:16], 24)
+	data = append(data, moov...)
+
+	mdat := make([]byte, 8)
+	binary.BigEndian.PutUint32(mdat[0:4], 0) // ファイル末尾まで
+	copy(mdat[4:8], "mdat")
+	data = append(data, mdat...)
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("Failed to write fixture: %v", err)
+	}
+
+	boxes, err := readTopLevelBoxes(path, maxTopLevelBoxes)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(boxes) != 3 {
+		t.Fatalf("Expected 3 boxes, got %d", len(boxes))
+	}
+	if boxes[1].Type != "moov" || boxes[1].Size != 24 {
+		t.Errorf("Expected moov box with size 24, got %s (%d)", boxes[1].Type, boxes[1].Size)
+	}
+	if boxes[2].Offset != 40 {
+		t.Errorf("Expected mdat at offset 40, got %d", boxes[2].Offset)
+	}
+}
+
+func TestDefaultValidator_ValidateFastStart(t *testing.T) {
+	validator := &DefaultValidator{}
+	tmpDir := t.TempDir()
+
+	faststart := filepath.Join(tmpDir, "faststart.mp4")
+	writeMP4Fixture(t, faststart, "ftyp", "moov", "mdat")
+
+	result := &ValidationResult{Valid: true}
+	validator.validateFastStart(faststart, result)
+	if len(result.Warnings) != 0 {
+		t.Errorf("Expected no warnings, got %v", result.GetWarningMessages())
+	}
+
+	notFaststart := filepath.Join(tmpDir, "not_faststart.mp4")
+	writeMP4Fixture(t, notFaststart, "ftyp", "mdat", "moov")
+
+	result = &ValidationResult{Valid: true}
+	validator.validateFastStart(notFaststart, result)
+	if len(result.Warnings) != 1 || result.Warnings[0].Code != "MOOV_NOT_AT_FRONT" {
+		t.Errorf("Expected MOOV_NOT_AT_FRONT warning, got %v", result.GetWarningMessages())
+	}
+	if !result.Valid {
+		t.Error("Expected Valid to remain true for faststart warning")
+	}
+}
@@ -0,0 +1,78 @@
+package validator
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/nzws/flux-encoder/internal/shared/execlimit"
+)
+
+// QualityCheckOptions は参照動画と比較する画質検証のオプション
+type QualityCheckOptions struct {
+	ReferencePath string  // 比較対象の元動画（エンコードの入力）
+	MinSSIM       float64 // 許容する SSIM の最小値（0〜1）
+}
+
+// ssimAllPattern は ffmpeg の ssim フィルタが出力する全体スコア（例: "All:0.987654 (18.9)"）
+var ssimAllPattern = regexp.MustCompile(`All:([0-9.]+)`)
+
+// QualityValidator は参照動画と比較して画質を計測する
+type QualityValidator struct {
+	ffmpegPath string
+}
+
+// NewQualityValidator は ffmpegPath の ffmpeg で画質を計測する QualityValidator を作成する（空の場合は PATH の ffmpeg）
+func NewQualityValidator(ffmpegPath string) *QualityValidator {
+	if ffmpegPath == "" {
+		ffmpegPath = DefaultFFmpegPath
+	}
+	return &QualityValidator{
+		ffmpegPath: ffmpegPath,
+	}
+}
+
+// MeasureSSIM は出力と参照動画の SSIM（全プレーンの平均）を計測する
+// 解像度が異なる場合は参照動画を出力の解像度に合わせてから比較する
+func (q *QualityValidator) MeasureSSIM(ctx context.Context, outputPath, referencePath string) (float64, error) {
+	cmd := exec.CommandContext(ctx, q.ffmpegPath,
+		"-nostdin",
+		"-i", outputPath,
+		"-i", referencePath,
+		"-lavfi", "[1:v][0:v]scale2ref[ref][out];[out][ref]ssim",
+		"-f", "null",
+		"-",
+	)
+
+	// ssim フィルタの結果は stderr に出力される
+	output, err := execlimit.CombinedOutput(cmd)
+	if err != nil {
+		return 0, fmt.Errorf("ssim measurement failed: %w: %s", err, lastLine(string(output)))
+	}
+
+	return parseSSIM(string(output))
+}
+
+// parseSSIM は ffmpeg の出力から SSIM の全体スコアを取り出す
+// 進捗行などに複数含まれる場合は最後の値（最終結果）を使う
+func parseSSIM(output string) (float64, error) {
+	matches := ssimAllPattern.FindAllStringSubmatch(output, -1)
+	if len(matches) == 0 {
+		return 0, fmt.Errorf("ssim score not found in ffmpeg output")
+	}
+
+	score, err := strconv.ParseFloat(matches[len(matches)-1][1], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid ssim score: %w", err)
+	}
+	return score, nil
+}
+
+// lastLine は出力の最後の空でない行を返す（エラーメッセージ用）
+func lastLine(output string) string {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
@@ -0,0 +1,147 @@
+package validator
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeFakeCommand は指定したシェルスクリプトを実行ファイルとして書き出し、そのパスを返す
+func writeFakeCommand(t *testing.T, name, script string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0755); err != nil {
+		t.Fatalf("Failed to write fake %s: %v", name, err)
+	}
+	return path
+}
+
+// fakeSSIMScript は ffmpeg の ssim フィルタと同じ形式でスコアを stderr に出力するスクリプト
+const fakeSSIMScript = `echo "frame=  120 fps=0.0 q=-0.0 Lsize=N/A time=00:00:04.00 bitrate=N/A speed=8x" >&2
+echo "[Parsed_ssim_1 @ 0x5581] SSIM Y:0.951234 (13.11) U:0.972345 (15.60) V:0.970123 (15.27) All:0.960123 (13.98)" >&2
+`
+
+func TestParseSSIM(t *testing.T) {
+	tests := []struct {
+		name     string
+		output   string
+		expected float64
+		wantErr  bool
+	}{
+		{
+			name:     "final score",
+			output:   "[Parsed_ssim_1 @ 0x5581] SSIM Y:0.951234 (13.11) U:0.972345 (15.60) V:0.970123 (15.27) All:0.960123 (13.98)",
+			expected: 0.960123,
+		},
+		{
+			name:     "last score wins",
+			output:   "All:0.5 (3.01)\nAll:0.99 (20.00)\n",
+			expected: 0.99,
+		},
+		{
+			name:    "no score",
+			output:  "Output file is empty, nothing was encoded",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score, err := parseSSIM(tt.output)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if !tt.wantErr && score != tt.expected {
+				t.Errorf("Expected SSIM %v, got %v", tt.expected, score)
+			}
+		})
+	}
+}
+
+func TestQualityValidator_MeasureSSIM(t *testing.T) {
+	q := &QualityValidator{ffmpegPath: writeFakeCommand(t, "ffmpeg", fakeSSIMScript)}
+
+	score, err := q.MeasureSSIM(context.Background(), "out.mp4", "ref.mp4")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if score != 0.960123 {
+		t.Errorf("Expected SSIM 0.960123, got %v", score)
+	}
+}
+
+func TestDefaultValidator_ValidateQuality(t *testing.T) {
+	tests := []struct {
+		name         string
+		script       string
+		minSSIM      float64
+		expectedCode string
+	}{
+		{name: "above threshold", script: fakeSSIMScript, minSSIM: 0.95},
+		{name: "below threshold", script: fakeSSIMScript, minSSIM: 0.98, expectedCode: "QUALITY_BELOW_THRESHOLD"},
+		{name: "ffmpeg failure", script: "echo 'ref.mp4: No such file or directory' >&2\nexit 1\n", minSSIM: 0.9, expectedCode: "QUALITY_CHECK_FAILED"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := &DefaultValidator{qualityValidator: &QualityValidator{ffmpegPath: writeFakeCommand(t, "ffmpeg", tt.script)}}
+			result := &ValidationResult{Valid: true}
+
+			v.validateQuality(context.Background(), "out.mp4", &QualityCheckOptions{ReferencePath: "ref.mp4", MinSSIM: tt.minSSIM}, result)
+
+			if tt.expectedCode == "" {
+				if !result.Valid {
+					t.Errorf("Expected valid result, got errors: %v", result.GetErrorMessages())
+				}
+				return
+			}
+			if result.Valid || len(result.Errors) != 1 || result.Errors[0].Code != tt.expectedCode {
+				t.Fatalf("Expected %s error, got %v", tt.expectedCode, result.GetErrorMessages())
+			}
+			if tt.expectedCode == "QUALITY_BELOW_THRESHOLD" {
+				details := result.Errors[0].Details
+				if details["ssim"] != 0.960123 || details["min_ssim"] != tt.minSSIM {
+					t.Errorf("Unexpected details: %v", details)
+				}
+			}
+		})
+	}
+}
+
+func TestDefaultValidator_Validate_QualityCheckOnlyAtStrictLevel(t *testing.T) {
+	outputFile := filepath.Join(t.TempDir(), "out.mp4")
+	if err := os.WriteFile(outputFile, []byte("test video content"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	v := &DefaultValidator{
+		ffprobe:          &FFProbe{execPath: writeFakeCommand(t, "ffprobe", `echo '{"format":{"format_name":"mov,mp4,m4a,3gp,3g2,mj2","duration":"4.0"},"streams":[]}'`)},
+		qualityValidator: &QualityValidator{ffmpegPath: writeFakeCommand(t, "ffmpeg", fakeSSIMScript)},
+	}
+	check := &QualityCheckOptions{ReferencePath: "ref.mp4", MinSSIM: 0.99}
+
+	for _, level := range []ValidationLevel{ValidationLevelStandard, ValidationLevelStrict} {
+		result, err := v.Validate(context.Background(), outputFile, &ValidationOptions{
+			Level:          level,
+			Timeout:        5 * time.Second,
+			SkipDecodeTest: true,
+			QualityCheck:   check,
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		found := false
+		for _, e := range result.Errors {
+			if e.Code == "QUALITY_BELOW_THRESHOLD" {
+				found = true
+			}
+		}
+		if wantCheck := level == ValidationLevelStrict; found != wantCheck {
+			t.Errorf("%s: expected quality check %v, got errors %v", v.levelToString(level), wantCheck, result.GetErrorMessages())
+		}
+	}
+}
@@ -2,12 +2,16 @@ package validator
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/nzws/flux-encoder/internal/shared/execlimit"
 	"github.com/nzws/flux-encoder/internal/shared/logger"
 	"go.uber.org/zap"
 )
@@ -41,27 +45,110 @@ const (
 	HLSValidationDepthFull
 )
 
+// DASHValidationDepth はDASH検証の深さ
+type DASHValidationDepth int
+
+const (
+	// DASHValidationDepthBasic はマニフェストの構文チェックのみ
+	DASHValidationDepthBasic DASHValidationDepth = iota
+	// DASHValidationDepthMedium は全セグメントの存在確認
+	DASHValidationDepthMedium
+	// DASHValidationDepthFull は初期化セグメントの内容検証
+	DASHValidationDepthFull
+)
+
 // ValidationOptions は検証オプション
 type ValidationOptions struct {
-	Level              ValidationLevel
-	Expected           *ExpectedMediaInfo
-	Timeout            time.Duration
-	SkipDecodeTest     bool
-	HLSValidationDepth HLSValidationDepth
+	Level               ValidationLevel
+	Expected            *ExpectedMediaInfo
+	Timeout             time.Duration
+	SkipDecodeTest      bool
+	HLSValidationDepth  HLSValidationDepth
+	DASHValidationDepth DASHValidationDepth
+	// QualityCheck は参照動画との画質比較の設定（ValidationLevelStrict のみ、nil の場合は比較しない）
+	QualityCheck *QualityCheckOptions
+}
+
+// DefaultValidationOptions は検証オプションのデフォルト値を返す（Expected は設定しない）
+func DefaultValidationOptions() ValidationOptions {
+	return ValidationOptions{
+		Level:               ValidationLevelStandard,
+		Timeout:             30 * time.Second,
+		SkipDecodeTest:      false,
+		HLSValidationDepth:  HLSValidationDepthMedium,
+		DASHValidationDepth: DASHValidationDepthMedium,
+	}
+}
+
+// ParseValidationLevel は "minimal"・"standard"・"strict" を ValidationLevel に変換する
+func ParseValidationLevel(value string) (ValidationLevel, error) {
+	switch strings.ToLower(value) {
+	case "minimal":
+		return ValidationLevelMinimal, nil
+	case "standard":
+		return ValidationLevelStandard, nil
+	case "strict":
+		return ValidationLevelStrict, nil
+	default:
+		return 0, fmt.Errorf("invalid validation level: %q (must be minimal, standard or strict)", value)
+	}
+}
+
+// ParseHLSValidationDepth は "basic"・"medium"・"full" を HLSValidationDepth に変換する
+func ParseHLSValidationDepth(value string) (HLSValidationDepth, error) {
+	switch strings.ToLower(value) {
+	case "basic":
+		return HLSValidationDepthBasic, nil
+	case "medium":
+		return HLSValidationDepthMedium, nil
+	case "full":
+		return HLSValidationDepthFull, nil
+	default:
+		return 0, fmt.Errorf("invalid HLS validation depth: %q (must be basic, medium or full)", value)
+	}
 }
 
 // ExpectedMediaInfo は期待されるメディア情報
 type ExpectedMediaInfo struct {
-	VideoCodec  string
-	Width       int
-	Height      int
-	AudioCodec  string
-	MinDuration float64
-	MaxDuration float64
-	MinBitrate  int64
-	MaxBitrate  int64
+	VideoCodec      string
+	Width           int
+	Height          int
+	AudioCodec      string
+	AudioChannels   int // 音声のチャンネル数（0 の場合は検証しない）
+	AudioSampleRate int // 音声のサンプリングレート（Hz、0 の場合は検証しない）
+	MinDuration     float64
+	MaxDuration     float64
+	MinBitrate      int64
+	MaxBitrate      int64
+	FastStart       bool // true の場合、MP4の moov が mdat より前にあることを検証する
+	// FrameRate は映像のフレームレート（0 の場合は検証しない）
+	FrameRate float64
+	// FrameRateTolerance はフレームレートの許容差（0 の場合は DefaultFrameRateTolerance）
+	FrameRateTolerance float64
+	// Profile は映像のプロファイル（ffmpeg の -profile:v の値、例: "main"。空の場合は検証しない）
+	Profile string
+	// HLS は HLS 出力のセグメントの期待値（nil の場合は検証しない）
+	HLS *ExpectedHLSInfo
 }
 
+// ExpectedHLSInfo は HLS 出力のセグメントの期待値（0 の項目は検証しない）
+type ExpectedHLSInfo struct {
+	// MinSegments は各メディアプレイリストに最低限必要なセグメント数
+	// 入力の長さから見て少なすぎる場合（途中で打ち切られたエンコードなど）はエラーにする
+	MinSegments int
+	// TargetDuration は期待する #EXT-X-TARGETDURATION（秒、通常は -hls_time の値）
+	TargetDuration float64
+	// TargetDurationTolerance は TargetDuration の許容差（秒、0 の場合は DefaultTargetDurationTolerance）
+	TargetDurationTolerance float64
+}
+
+// DefaultTargetDurationTolerance は #EXT-X-TARGETDURATION の許容差のデフォルト値（ffmpeg は秒単位に切り上げる）
+const DefaultTargetDurationTolerance = 1.0
+
+// DefaultFrameRateTolerance はフレームレートの検証の許容差のデフォルト値
+// ffprobe の分数表記を小数にした誤差のみを許容し、29.97（30000/1001）と 30 は区別する
+const DefaultFrameRateTolerance = 0.01
+
 // ValidationResult は検証結果
 type ValidationResult struct {
 	Valid              bool
@@ -95,12 +182,15 @@ type MediaInfo struct {
 	VideoStreams []VideoStreamInfo
 	AudioStreams []AudioStreamInfo
 	HLSInfo      *HLSInfo
+	DASHInfo     *DASHInfo
 }
 
 // VideoStreamInfo は映像ストリーム情報
 type VideoStreamInfo struct {
-	Codec       string
-	Profile     string
+	Codec   string
+	Profile string
+	// Level は H.264/HEVC のレベル（例: "3.1"、"4"）。ffprobe で取得できない場合は空
+	Level       string
 	Width       int
 	Height      int
 	FrameRate   float64
@@ -120,6 +210,8 @@ type AudioStreamInfo struct {
 // HLSInfo はHLS固有の情報
 type HLSInfo struct {
 	MasterPlaylist string
+	// Version はメインのプレイリストで宣言された #EXT-X-VERSION（宣言がない場合は 0）
+	Version        int
 	Playlists      []PlaylistInfo
 	TotalSegments  int
 	TargetDuration float64
@@ -133,30 +225,78 @@ type PlaylistInfo struct {
 	Codecs       string
 	SegmentCount int
 	Segments     []SegmentInfo
+	// Version はメディアプレイリストで宣言された #EXT-X-VERSION（宣言がない場合は 0）
+	Version int
+	// RequiredVersion は使用している機能が必要とする最小のバージョン
+	RequiredVersion int
+	// RequiredBy は RequiredVersion を必要とする機能の説明
+	RequiredBy string
+	// InitSegments は #EXT-X-MAP で参照される初期化セグメントのパス
+	InitSegments []string
+}
+
+// DASHInfo はDASH固有の情報
+type DASHInfo struct {
+	Manifest        string
+	Representations []RepresentationInfo
+	TotalSegments   int
+	Duration        float64
+}
+
+// RepresentationInfo はDASHの Representation 情報
+type RepresentationInfo struct {
+	ID             string
+	MimeType       string
+	Codecs         string
+	Bandwidth      int64
+	Width          int
+	Height         int
+	Initialization string
+	SegmentCount   int
+	Segments       []SegmentInfo
 }
 
 // SegmentInfo はセグメント情報
 type SegmentInfo struct {
 	Path     string
 	Duration float64
-	Size     int64
+	// Size はセグメントのバイト数（#EXT-X-BYTERANGE の場合は範囲の長さ）
+	Size int64
+	// ByteRange は #EXT-X-BYTERANGE でファイルの一部を参照している場合の範囲（ファイル全体の場合は nil）
+	ByteRange *ByteRange
+}
+
+// ByteRange はセグメントが参照するファイル内の範囲
+type ByteRange struct {
+	Length int64
+	Offset int64
 }
 
 // DefaultValidator はデフォルトのValidator実装
 type DefaultValidator struct {
-	ffprobe         *FFProbe
-	hlsParser       *HLSParser
-	decodeValidator *DecodeValidator
-	logger          *zap.Logger
+	ffprobe          *FFProbe
+	hlsParser        *HLSParser
+	dashParser       *DASHParser
+	decodeValidator  *DecodeValidator
+	qualityValidator *QualityValidator
+	logger           *zap.Logger
 }
 
-// New は新しいValidatorを作成する
+// New は PATH の ffmpeg・ffprobe を使用する Validator を作成する
 func New() Validator {
+	return NewWithPaths(DefaultFFmpegPath, DefaultFFprobePath)
+}
+
+// NewWithPaths は ffmpegPath の ffmpeg と ffprobePath の ffprobe を使用する Validator を作成する（空の場合は PATH のもの）
+func NewWithPaths(ffmpegPath, ffprobePath string) Validator {
+	ffprobe := NewFFProbe(ffprobePath)
 	return &DefaultValidator{
-		ffprobe:         NewFFProbe(),
-		hlsParser:       NewHLSParser(),
-		decodeValidator: NewDecodeValidator(),
-		logger:          zap.NewNop(), // デフォルトはNopLogger、後でlogger.Logを使用
+		ffprobe:          ffprobe,
+		hlsParser:        &HLSParser{ffprobe: ffprobe},
+		dashParser:       &DASHParser{ffprobe: ffprobe},
+		decodeValidator:  NewDecodeValidator(ffmpegPath),
+		qualityValidator: NewQualityValidator(ffmpegPath),
+		logger:           zap.NewNop(), // デフォルトはNopLogger、後でlogger.Logを使用
 	}
 }
 
@@ -169,12 +309,8 @@ func (v *DefaultValidator) Validate(ctx context.Context, outputPath string, opti
 
 	// デフォルトオプション設定
 	if options == nil {
-		options = &ValidationOptions{
-			Level:              ValidationLevelStandard,
-			Timeout:            30 * time.Second,
-			SkipDecodeTest:     false,
-			HLSValidationDepth: HLSValidationDepthMedium,
-		}
+		defaults := DefaultValidationOptions()
+		options = &defaults
 	}
 
 	// タイムアウト設定
@@ -198,6 +334,11 @@ func (v *DefaultValidator) Validate(ctx context.Context, outputPath string, opti
 
 	// 2. ffprobeでメディア情報取得
 	mediaInfo, err := v.ffprobe.GetMediaInfo(ctx, outputPath)
+	if errors.Is(err, execlimit.ErrOutputTooLarge) {
+		result.addError("PROBE_OUTPUT_TOO_LARGE", err.Error(), "")
+		result.ValidationDuration = time.Since(startTime)
+		return result, nil
+	}
 	if err != nil {
 		result.addError("FFPROBE_FAILED", err.Error(), "")
 		result.ValidationDuration = time.Since(startTime)
@@ -206,7 +347,9 @@ func (v *DefaultValidator) Validate(ctx context.Context, outputPath string, opti
 	result.MediaInfo = mediaInfo
 
 	// 3. フォーマット判定と検証
-	if v.isHLSOutput(outputPath, mediaInfo) {
+	if v.isDASHOutput(outputPath) {
+		v.validateDASH(ctx, outputPath, options, result)
+	} else if v.isHLSOutput(outputPath, mediaInfo) {
 		v.validateHLS(ctx, outputPath, options, result)
 	} else {
 		v.validateSingleFile(ctx, outputPath, options, result)
@@ -224,14 +367,24 @@ func (v *DefaultValidator) Validate(ctx context.Context, outputPath string, opti
 		}
 	}
 
+	// 6. 画質検証（オプション）
+	if options.QualityCheck != nil && options.Level >= ValidationLevelStrict {
+		v.validateQuality(ctx, outputPath, options.QualityCheck, result)
+	}
+
 	result.ValidationDuration = time.Since(startTime)
 
-	logger.Info("Validation completed",
+	fields := []zap.Field{
 		zap.Bool("valid", result.Valid),
 		zap.Int("error_count", len(result.Errors)),
 		zap.Int("warning_count", len(result.Warnings)),
 		zap.Duration("duration", result.ValidationDuration),
-	)
+	}
+	if len(mediaInfo.VideoStreams) > 0 {
+		video := mediaInfo.VideoStreams[0]
+		fields = append(fields, zap.String("video_profile", video.Profile), zap.String("video_level", video.Level))
+	}
+	logger.Info("Validation completed", fields...)
 
 	return result, nil
 }
@@ -291,6 +444,32 @@ func (v *DefaultValidator) isHLSOutput(path string, mediaInfo *MediaInfo) bool {
 	return false
 }
 
+// isDASHOutput はDASH出力かどうかを判定する
+func (v *DefaultValidator) isDASHOutput(path string) bool {
+	// ファイルの拡張子が.mpdならDASH
+	if strings.HasSuffix(path, ".mpd") {
+		return true
+	}
+
+	// ディレクトリならmpdファイルを探す
+	info, err := os.Stat(path)
+	if err != nil || !info.IsDir() {
+		return false
+	}
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		logger.Warn("Failed to read directory for DASH detection", zap.String("path", path), zap.Error(err))
+		return false
+	}
+	for _, entry := range entries {
+		if strings.HasSuffix(entry.Name(), ".mpd") {
+			return true
+		}
+	}
+
+	return false
+}
+
 // validateSingleFile は単一ファイル出力を検証する
 func (v *DefaultValidator) validateSingleFile(ctx context.Context, path string, options *ValidationOptions, result *ValidationResult) {
 	// 基本的なファイルサイズチェック
@@ -312,6 +491,27 @@ func (v *DefaultValidator) validateSingleFile(ctx context.Context, path string,
 				"size")
 		}
 	}
+
+	// faststart 指定時は moov が先頭側に配置されているか確認
+	if options.Expected != nil && options.Expected.FastStart {
+		v.validateFastStart(path, result)
+	}
+}
+
+// validateFastStart は moov ボックスが mdat ボックスより前にあるか検証する
+func (v *DefaultValidator) validateFastStart(path string, result *ValidationResult) {
+	ok, err := checkMP4FastStart(path)
+	if err != nil {
+		result.addWarning("MP4_BOX_PARSE_FAILED",
+			fmt.Sprintf("failed to inspect mp4 box order: %v", err),
+			"moov")
+		return
+	}
+	if !ok {
+		result.addWarning("MOOV_NOT_AT_FRONT",
+			"moov atom is placed after mdat (faststart did not take effect)",
+			"moov")
+	}
 }
 
 // validateHLS はHLS出力を検証する
@@ -325,14 +525,73 @@ func (v *DefaultValidator) validateHLS(ctx context.Context, path string, options
 
 	result.MediaInfo.HLSInfo = hlsInfo
 
-	// プレイリストの構文検証
+	if options.Expected != nil && options.Expected.HLS != nil {
+		v.validateHLSSegments(hlsInfo, options.Expected.HLS, result)
+	}
+
+	// 宣言されたバージョンが使用している機能の要件を満たすか確認
+	for _, playlist := range hlsInfo.Playlists {
+		if message := hlsVersionTooLow(playlist); message != "" {
+			result.addError("HLS_VERSION_TOO_LOW", message, "version")
+		}
+	}
+
+	// プレイリストを ffmpeg で読めるかの検証
 	if hlsInfo.MasterPlaylist != "" {
-		if err := v.ffprobe.ValidatePlaylist(ctx, hlsInfo.MasterPlaylist); err != nil {
+		if err := v.checkPlaylist(ctx, hlsInfo.MasterPlaylist, options); err != nil {
 			result.addError("HLS_PLAYLIST_SYNTAX_ERROR", err.Error(), "playlist")
 		}
 	}
 }
 
+// validateHLSSegments はセグメント数と #EXT-X-TARGETDURATION を期待値と比較する
+// セグメント数が少なすぎる場合は出力が欠けているためエラー、目標時間のずれはキーフレームの間隔でも起こるため警告とする
+func (v *DefaultValidator) validateHLSSegments(hlsInfo *HLSInfo, expected *ExpectedHLSInfo, result *ValidationResult) {
+	if expected.MinSegments > 0 {
+		for _, playlist := range hlsInfo.Playlists {
+			if playlist.SegmentCount < expected.MinSegments {
+				result.addErrorWithDetails("HLS_TOO_FEW_SEGMENTS",
+					fmt.Sprintf("playlist %s has %d segments, expected at least %d", filepath.Base(playlist.Path), playlist.SegmentCount, expected.MinSegments),
+					"segments",
+					map[string]interface{}{
+						"playlist":     playlist.Path,
+						"actual":       playlist.SegmentCount,
+						"min_expected": expected.MinSegments,
+					},
+				)
+			}
+		}
+	}
+
+	if expected.TargetDuration > 0 {
+		tolerance := expected.TargetDurationTolerance
+		if tolerance <= 0 {
+			tolerance = DefaultTargetDurationTolerance
+		}
+		if math.Abs(hlsInfo.TargetDuration-expected.TargetDuration) > tolerance {
+			result.addWarning("HLS_TARGET_DURATION_MISMATCH",
+				fmt.Sprintf("target duration %.0fs differs from expected %.0fs (tolerance %.0fs)", hlsInfo.TargetDuration, expected.TargetDuration, tolerance),
+				"target_duration",
+			)
+		}
+	}
+}
+
+// playlistFullDecode はプレイリスト検証で全セグメントをデコードするかを返す
+// 全セグメントのデコードは出力が大きいと時間がかかるため、strict レベルまたは HLSValidationDepthFull の場合のみ行い、
+// それ以外は ffprobe でプレイリストを解析できるかのみを確認する
+func playlistFullDecode(options *ValidationOptions) bool {
+	return options.Level >= ValidationLevelStrict || options.HLSValidationDepth >= HLSValidationDepthFull
+}
+
+// checkPlaylist は検証オプションに応じてプレイリストを解析またはデコードして検証する
+func (v *DefaultValidator) checkPlaylist(ctx context.Context, playlistPath string, options *ValidationOptions) error {
+	if playlistFullDecode(options) {
+		return v.decodeValidator.TestDecode(ctx, playlistPath)
+	}
+	return v.ffprobe.ValidatePlaylist(ctx, playlistPath)
+}
+
 // validateHLSStructure はHLS構造を検証する
 func (v *DefaultValidator) validateHLSStructure(ctx context.Context, path string, depth HLSValidationDepth) (*HLSInfo, error) {
 	// ディレクトリの場合
@@ -353,6 +612,22 @@ func (v *DefaultValidator) validateHLSStructure(ctx context.Context, path string
 	return v.hlsParser.ParseAndValidate(ctx, baseDir, depth)
 }
 
+// validateDASH はDASH出力を検証する
+func (v *DefaultValidator) validateDASH(ctx context.Context, path string, options *ValidationOptions, result *ValidationResult) {
+	baseDir := path
+	if info, err := os.Stat(path); err == nil && !info.IsDir() {
+		baseDir = filepath.Dir(path)
+	}
+
+	dashInfo, err := v.dashParser.ParseAndValidate(ctx, baseDir, options.DASHValidationDepth)
+	if err != nil {
+		result.addError("DASH_VALIDATION_FAILED", err.Error(), "")
+		return
+	}
+
+	result.MediaInfo.DASHInfo = dashInfo
+}
+
 // validateMediaStreams はメディアストリームを検証する
 func (v *DefaultValidator) validateMediaStreams(mediaInfo *MediaInfo, expected *ExpectedMediaInfo, result *ValidationResult) {
 	if !v.validateVideoStream(mediaInfo, expected, result) {
@@ -375,19 +650,104 @@ func (v *DefaultValidator) validateVideoStream(mediaInfo *MediaInfo, expected *E
 			fmt.Sprintf("expected codec %s, got %s", expected.VideoCodec, video.Codec),
 			"video.codec")
 	}
-	if expected.Width > 0 && video.Width != expected.Width {
+	// ABR の HLS/DASH は ffprobe の最初の映像ストリームが最大のバリアントとは限らないため、バリアントの最大の解像度と比較する
+	width, height := video.Width, video.Height
+	if w, h, ok := largestVariantResolution(mediaInfo); ok {
+		width, height = w, h
+	}
+	if expected.Width > 0 && width != expected.Width {
 		result.addError("RESOLUTION_MISMATCH",
-			fmt.Sprintf("expected width %d, got %d", expected.Width, video.Width),
+			fmt.Sprintf("expected width %d, got %d", expected.Width, width),
 			"video.width")
 	}
-	if expected.Height > 0 && video.Height != expected.Height {
+	if expected.Height > 0 && height != expected.Height {
 		result.addError("RESOLUTION_MISMATCH",
-			fmt.Sprintf("expected height %d, got %d", expected.Height, video.Height),
+			fmt.Sprintf("expected height %d, got %d", expected.Height, height),
 			"video.height")
 	}
+	if expected.FrameRate > 0 {
+		tolerance := expected.FrameRateTolerance
+		if tolerance <= 0 {
+			tolerance = DefaultFrameRateTolerance
+		}
+		if math.Abs(video.FrameRate-expected.FrameRate) > tolerance {
+			result.addError("FRAMERATE_MISMATCH",
+				fmt.Sprintf("expected frame rate %.3f, got %.3f", expected.FrameRate, video.FrameRate),
+				"video.frame_rate")
+		}
+	}
+	if expected.Profile != "" && !ProfileMatches(expected.Profile, video.Profile) {
+		got := video.Profile
+		if video.Level != "" {
+			got = fmt.Sprintf("%s (level %s)", video.Profile, video.Level)
+		}
+		result.addError("PROFILE_MISMATCH",
+			fmt.Sprintf("expected profile %s, got %s", expected.Profile, got),
+			"video.profile")
+	}
 	return true
 }
 
+// largestVariantResolution は HLS のマスタープレイリストの RESOLUTION、または DASH の映像の Representation から
+// 最も大きい（高さが最大、同じ場合は幅が最大）バリアントの解像度を返す。解像度を持つバリアントがない場合は ok が false
+func largestVariantResolution(mediaInfo *MediaInfo) (width, height int, ok bool) {
+	consider := func(w, h int) {
+		if w <= 0 || h <= 0 {
+			return
+		}
+		if !ok || h > height || (h == height && w > width) {
+			width, height, ok = w, h, true
+		}
+	}
+
+	if mediaInfo.HLSInfo != nil {
+		for _, playlist := range mediaInfo.HLSInfo.Playlists {
+			w, h, found := strings.Cut(playlist.Resolution, "x")
+			if !found {
+				continue
+			}
+			pw, errW := strconv.Atoi(w)
+			ph, errH := strconv.Atoi(h)
+			if errW == nil && errH == nil {
+				consider(pw, ph)
+			}
+		}
+	}
+	if mediaInfo.DASHInfo != nil {
+		for _, rep := range mediaInfo.DASHInfo.Representations {
+			consider(rep.Width, rep.Height)
+		}
+	}
+	return width, height, ok
+}
+
+// profileAliases は ffmpeg の -profile:v の値に対して、ffprobe が返す別名のプロファイル（正規化済み）
+// libx264 の baseline は Constrained Baseline として出力され、high444 は High 4:4:4 Predictive と表示される
+var profileAliases = map[string][]string{
+	"baseline": {"constrainedbaseline"},
+	"high444":  {"high444predictive"},
+}
+
+// ProfileMatches は ffprobe のプロファイル名（例: "High 4:2:2"）が ffmpeg の -profile:v の値（例: "high422"）と一致するかを返す
+// 大文字・小文字と空白・コロン・ハイフンの違いは無視する
+func ProfileMatches(expected, actual string) bool {
+	expected, actual = normalizeProfile(expected), normalizeProfile(actual)
+	if expected == actual {
+		return true
+	}
+	for _, alias := range profileAliases[expected] {
+		if actual == alias {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeProfile はプロファイル名を小文字にして空白・コロン・ハイフンを取り除く
+func normalizeProfile(profile string) string {
+	return strings.NewReplacer(" ", "", ":", "", "-", "").Replace(strings.ToLower(profile))
+}
+
 func (v *DefaultValidator) validateDuration(mediaInfo *MediaInfo, expected *ExpectedMediaInfo, result *ValidationResult) {
 	if expected.MinDuration > 0 && mediaInfo.Duration < expected.MinDuration {
 		result.addError("DURATION_TOO_SHORT",
@@ -415,7 +775,7 @@ func (v *DefaultValidator) validateBitrate(mediaInfo *MediaInfo, expected *Expec
 }
 
 func (v *DefaultValidator) validateAudioStream(mediaInfo *MediaInfo, expected *ExpectedMediaInfo, result *ValidationResult) {
-	if expected.AudioCodec == "" {
+	if expected.AudioCodec == "" && expected.AudioChannels == 0 && expected.AudioSampleRate == 0 {
 		return
 	}
 	if len(mediaInfo.AudioStreams) == 0 {
@@ -423,11 +783,45 @@ func (v *DefaultValidator) validateAudioStream(mediaInfo *MediaInfo, expected *E
 		return
 	}
 	audio := mediaInfo.AudioStreams[0]
-	if audio.Codec != expected.AudioCodec {
+	if expected.AudioCodec != "" && audio.Codec != expected.AudioCodec {
 		result.addError("CODEC_MISMATCH",
 			fmt.Sprintf("expected audio codec %s, got %s", expected.AudioCodec, audio.Codec),
 			"audio.codec")
 	}
+	if expected.AudioChannels > 0 && audio.Channels != expected.AudioChannels {
+		result.addError("AUDIO_CHANNELS_MISMATCH",
+			fmt.Sprintf("expected %d audio channels, got %d", expected.AudioChannels, audio.Channels),
+			"audio.channels")
+	}
+	if expected.AudioSampleRate > 0 && audio.SampleRate != expected.AudioSampleRate {
+		result.addError("AUDIO_SAMPLE_RATE_MISMATCH",
+			fmt.Sprintf("expected audio sample rate %d Hz, got %d Hz", expected.AudioSampleRate, audio.SampleRate),
+			"audio.sample_rate")
+	}
+}
+
+// validateQuality は参照動画と比較した SSIM が閾値以上かチェックする
+func (v *DefaultValidator) validateQuality(ctx context.Context, outputPath string, check *QualityCheckOptions, result *ValidationResult) {
+	ssim, err := v.qualityValidator.MeasureSSIM(ctx, outputPath, check.ReferencePath)
+	if err != nil {
+		result.addError("QUALITY_CHECK_FAILED", err.Error(), "quality")
+		return
+	}
+
+	logger.Info("Measured output quality",
+		zap.String("output_path", outputPath),
+		zap.Float64("ssim", ssim),
+	)
+
+	if ssim < check.MinSSIM {
+		result.addErrorWithDetails("QUALITY_BELOW_THRESHOLD",
+			fmt.Sprintf("SSIM %.6f is below threshold %.6f", ssim, check.MinSSIM),
+			"quality.ssim",
+			map[string]interface{}{
+				"ssim":     ssim,
+				"min_ssim": check.MinSSIM,
+			})
+	}
 }
 
 // addError はエラーを追加し、Validフラグをfalseにする
@@ -440,6 +834,12 @@ func (r *ValidationResult) addError(code, message, field string) {
 	})
 }
 
+// addErrorWithDetails は詳細情報付きのエラーを追加し、Validフラグをfalseにする
+func (r *ValidationResult) addErrorWithDetails(code, message, field string, details map[string]interface{}) {
+	r.addError(code, message, field)
+	r.Errors[len(r.Errors)-1].Details = details
+}
+
 // addWarning は警告を追加する
 func (r *ValidationResult) addWarning(code, message, field string) {
 	r.Warnings = append(r.Warnings, ValidationWarning{
@@ -298,6 +298,405 @@ func TestDefaultValidator_ValidateMediaStreams(t *testing.T) {
 	}
 }
 
+func TestDefaultValidator_ValidateAudioStream(t *testing.T) {
+	validator := &DefaultValidator{}
+
+	stereo := &MediaInfo{
+		AudioStreams: []AudioStreamInfo{{Codec: "aac", Channels: 2, SampleRate: 48000}},
+	}
+	mono := &MediaInfo{
+		AudioStreams: []AudioStreamInfo{{Codec: "aac", Channels: 1, SampleRate: 44100}},
+	}
+
+	tests := []struct {
+		name           string
+		mediaInfo      *MediaInfo
+		expected       *ExpectedMediaInfo
+		expectCodes    []string
+		expectWarnings int
+	}{
+		{
+			name:      "stereo matches",
+			mediaInfo: stereo,
+			expected:  &ExpectedMediaInfo{AudioCodec: "aac", AudioChannels: 2, AudioSampleRate: 48000},
+		},
+		{
+			name:        "mono when stereo expected",
+			mediaInfo:   mono,
+			expected:    &ExpectedMediaInfo{AudioCodec: "aac", AudioChannels: 2},
+			expectCodes: []string{"AUDIO_CHANNELS_MISMATCH"},
+		},
+		{
+			name:        "sample rate mismatch",
+			mediaInfo:   mono,
+			expected:    &ExpectedMediaInfo{AudioSampleRate: 48000},
+			expectCodes: []string{"AUDIO_SAMPLE_RATE_MISMATCH"},
+		},
+		{
+			name:        "codec, channels and sample rate mismatch",
+			mediaInfo:   mono,
+			expected:    &ExpectedMediaInfo{AudioCodec: "opus", AudioChannels: 2, AudioSampleRate: 48000},
+			expectCodes: []string{"CODEC_MISMATCH", "AUDIO_CHANNELS_MISMATCH", "AUDIO_SAMPLE_RATE_MISMATCH"},
+		},
+		{
+			name:      "zero means don't check",
+			mediaInfo: mono,
+			expected:  &ExpectedMediaInfo{AudioCodec: "aac"},
+		},
+		{
+			name:           "no audio stream when channels expected",
+			mediaInfo:      &MediaInfo{},
+			expected:       &ExpectedMediaInfo{AudioChannels: 2},
+			expectWarnings: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := &ValidationResult{Valid: true}
+			validator.validateAudioStream(tt.mediaInfo, tt.expected, result)
+
+			if len(result.Errors) != len(tt.expectCodes) {
+				t.Fatalf("Expected %d errors, got %d: %v", len(tt.expectCodes), len(result.Errors), result.GetErrorMessages())
+			}
+			for i, code := range tt.expectCodes {
+				if result.Errors[i].Code != code {
+					t.Errorf("Expected error code %s, got %s", code, result.Errors[i].Code)
+				}
+			}
+			if len(result.Warnings) != tt.expectWarnings {
+				t.Errorf("Expected %d warnings, got %d: %v", tt.expectWarnings, len(result.Warnings), result.GetWarningMessages())
+			}
+		})
+	}
+}
+
+func TestDefaultValidator_ValidateVideoStream_FrameRate(t *testing.T) {
+	validator := &DefaultValidator{}
+	ffprobe := NewFFProbe(DefaultFFprobePath)
+
+	// ffprobe の r_frame_rate を parseFrameRate で変換した値を使用する
+	videoAt := func(rate string) *MediaInfo {
+		return &MediaInfo{
+			VideoStreams: []VideoStreamInfo{{Codec: "h264", FrameRate: ffprobe.parseFrameRate(rate)}},
+		}
+	}
+
+	tests := []struct {
+		name        string
+		mediaInfo   *MediaInfo
+		expected    *ExpectedMediaInfo
+		expectCodes []string
+	}{
+		{
+			name:      "30fps matches",
+			mediaInfo: videoAt("30/1"),
+			expected:  &ExpectedMediaInfo{FrameRate: 30},
+		},
+		{
+			name:        "60fps source not downconverted",
+			mediaInfo:   videoAt("60/1"),
+			expected:    &ExpectedMediaInfo{FrameRate: 30},
+			expectCodes: []string{"FRAMERATE_MISMATCH"},
+		},
+		{
+			name:        "29.97 is not 30 with default tolerance",
+			mediaInfo:   videoAt("30000/1001"),
+			expected:    &ExpectedMediaInfo{FrameRate: 30},
+			expectCodes: []string{"FRAMERATE_MISMATCH"},
+		},
+		{
+			name:      "29.97 matches 30 with wider tolerance",
+			mediaInfo: videoAt("30000/1001"),
+			expected:  &ExpectedMediaInfo{FrameRate: 30, FrameRateTolerance: 0.05},
+		},
+		{
+			name:      "29.97 matches fractional expectation",
+			mediaInfo: videoAt("30000/1001"),
+			expected:  &ExpectedMediaInfo{FrameRate: ParseFrameRate("30000/1001")},
+		},
+		{
+			name:      "decimal notation within default tolerance",
+			mediaInfo: videoAt("2997/100"),
+			expected:  &ExpectedMediaInfo{FrameRate: ParseFrameRate("30000/1001")},
+		},
+		{
+			name:      "zero means don't check",
+			mediaInfo: videoAt("60/1"),
+			expected:  &ExpectedMediaInfo{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := &ValidationResult{Valid: true}
+			validator.validateVideoStream(tt.mediaInfo, tt.expected, result)
+
+			if len(result.Errors) != len(tt.expectCodes) {
+				t.Fatalf("Expected %d errors, got %d: %v", len(tt.expectCodes), len(result.Errors), result.GetErrorMessages())
+			}
+			for i, code := range tt.expectCodes {
+				if result.Errors[i].Code != code {
+					t.Errorf("Expected error code %s, got %s", code, result.Errors[i].Code)
+				}
+			}
+		})
+	}
+}
+
+func TestDefaultValidator_ValidateVideoStream_PortraitResolution(t *testing.T) {
+	validator := &DefaultValidator{}
+
+	tests := []struct {
+		name        string
+		width       int
+		height      int
+		expected    *ExpectedMediaInfo
+		expectCodes []string
+	}{
+		{name: "portrait matches", width: 720, height: 1280, expected: &ExpectedMediaInfo{Width: 720, Height: 1280}},
+		{name: "landscape output for portrait preset", width: 1280, height: 720, expected: &ExpectedMediaInfo{Width: 720, Height: 1280}, expectCodes: []string{"RESOLUTION_MISMATCH", "RESOLUTION_MISMATCH"}},
+		{name: "portrait width only", width: 720, height: 1282, expected: &ExpectedMediaInfo{Width: 720}},
+		{name: "portrait width mismatch", width: 1080, height: 1920, expected: &ExpectedMediaInfo{Width: 720}, expectCodes: []string{"RESOLUTION_MISMATCH"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mediaInfo := &MediaInfo{VideoStreams: []VideoStreamInfo{{Codec: "h264", Width: tt.width, Height: tt.height}}}
+			result := &ValidationResult{Valid: true}
+			validator.validateVideoStream(mediaInfo, tt.expected, result)
+
+			if len(result.Errors) != len(tt.expectCodes) {
+				t.Fatalf("Expected %d errors, got %d: %v", len(tt.expectCodes), len(result.Errors), result.GetErrorMessages())
+			}
+			for i, code := range tt.expectCodes {
+				if result.Errors[i].Code != code {
+					t.Errorf("Expected error code %s, got %s", code, result.Errors[i].Code)
+				}
+			}
+		})
+	}
+}
+
+func TestDefaultValidator_ValidateVideoStream_ABRVariantResolution(t *testing.T) {
+	validator := &DefaultValidator{}
+	expected := &ExpectedMediaInfo{Width: 1280, Height: 720}
+
+	tests := []struct {
+		name        string
+		mediaInfo   *MediaInfo
+		expectCodes []string
+	}{
+		{
+			name: "HLS largest variant matches even if first stream is smaller",
+			mediaInfo: &MediaInfo{
+				VideoStreams: []VideoStreamInfo{{Codec: "h264", Width: 640, Height: 360}},
+				HLSInfo: &HLSInfo{Playlists: []PlaylistInfo{
+					{Path: "stream_2.m3u8", Resolution: "640x360"},
+					{Path: "stream_0.m3u8", Resolution: "1280x720"},
+					{Path: "stream_1.m3u8", Resolution: "854x480"},
+				}},
+			},
+		},
+		{
+			name: "HLS largest variant too small",
+			mediaInfo: &MediaInfo{
+				VideoStreams: []VideoStreamInfo{{Codec: "h264", Width: 1280, Height: 720}},
+				HLSInfo: &HLSInfo{Playlists: []PlaylistInfo{
+					{Path: "stream_0.m3u8", Resolution: "854x480"},
+					{Path: "stream_1.m3u8", Resolution: "640x360"},
+				}},
+			},
+			expectCodes: []string{"RESOLUTION_MISMATCH", "RESOLUTION_MISMATCH"},
+		},
+		{
+			name: "HLS without resolution falls back to video stream",
+			mediaInfo: &MediaInfo{
+				VideoStreams: []VideoStreamInfo{{Codec: "h264", Width: 1280, Height: 720}},
+				HLSInfo:      &HLSInfo{Playlists: []PlaylistInfo{{Path: "playlist.m3u8"}}},
+			},
+		},
+		{
+			name: "DASH representations ignore audio",
+			mediaInfo: &MediaInfo{
+				VideoStreams: []VideoStreamInfo{{Codec: "h264", Width: 640, Height: 360}},
+				DASHInfo: &DASHInfo{Representations: []RepresentationInfo{
+					{ID: "0", MimeType: "video/mp4", Width: 1280, Height: 720},
+					{ID: "1", MimeType: "video/mp4", Width: 854, Height: 480},
+					{ID: "3", MimeType: "audio/mp4"},
+				}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := &ValidationResult{Valid: true}
+			validator.validateVideoStream(tt.mediaInfo, expected, result)
+
+			if len(result.Errors) != len(tt.expectCodes) {
+				t.Fatalf("Expected %d errors, got %d: %v", len(tt.expectCodes), len(result.Errors), result.GetErrorMessages())
+			}
+			for i, code := range tt.expectCodes {
+				if result.Errors[i].Code != code {
+					t.Errorf("Expected error code %s, got %s", code, result.Errors[i].Code)
+				}
+			}
+		})
+	}
+}
+
+func TestDefaultValidator_ValidateHLSSegments(t *testing.T) {
+	validator := &DefaultValidator{}
+
+	tests := []struct {
+		name          string
+		hlsInfo       *HLSInfo
+		expected      *ExpectedHLSInfo
+		expectErrors  []string
+		expectWarning []string
+	}{
+		{
+			name: "plausible segment count and target duration",
+			hlsInfo: &HLSInfo{
+				Playlists:      []PlaylistInfo{{Path: "playlist.m3u8", SegmentCount: 100}},
+				TotalSegments:  100,
+				TargetDuration: 6,
+			},
+			expected: &ExpectedHLSInfo{MinSegments: 50, TargetDuration: 6},
+		},
+		{
+			name: "truncated encode with one segment for a 10-minute source",
+			hlsInfo: &HLSInfo{
+				Playlists:      []PlaylistInfo{{Path: "playlist.m3u8", SegmentCount: 1}},
+				TotalSegments:  1,
+				TargetDuration: 6,
+			},
+			expected:     &ExpectedHLSInfo{MinSegments: 50, TargetDuration: 6},
+			expectErrors: []string{"HLS_TOO_FEW_SEGMENTS"},
+		},
+		{
+			name: "each ABR variant is checked separately",
+			hlsInfo: &HLSInfo{
+				Playlists: []PlaylistInfo{
+					{Path: "stream_0.m3u8", SegmentCount: 100},
+					{Path: "stream_1.m3u8", SegmentCount: 3},
+				},
+				TotalSegments:  103,
+				TargetDuration: 6,
+			},
+			expected:     &ExpectedHLSInfo{MinSegments: 50},
+			expectErrors: []string{"HLS_TOO_FEW_SEGMENTS"},
+		},
+		{
+			name: "target duration within default tolerance",
+			hlsInfo: &HLSInfo{
+				Playlists:      []PlaylistInfo{{Path: "playlist.m3u8", SegmentCount: 10}},
+				TargetDuration: 7,
+			},
+			expected: &ExpectedHLSInfo{TargetDuration: 6},
+		},
+		{
+			name: "target duration outside tolerance",
+			hlsInfo: &HLSInfo{
+				Playlists:      []PlaylistInfo{{Path: "playlist.m3u8", SegmentCount: 10}},
+				TargetDuration: 14,
+			},
+			expected:      &ExpectedHLSInfo{TargetDuration: 6, TargetDurationTolerance: 6},
+			expectWarning: []string{"HLS_TARGET_DURATION_MISMATCH"},
+		},
+		{
+			name: "zero values are not checked",
+			hlsInfo: &HLSInfo{
+				Playlists:      []PlaylistInfo{{Path: "playlist.m3u8", SegmentCount: 1}},
+				TargetDuration: 30,
+			},
+			expected: &ExpectedHLSInfo{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := &ValidationResult{Valid: true}
+			validator.validateHLSSegments(tt.hlsInfo, tt.expected, result)
+
+			if len(result.Errors) != len(tt.expectErrors) {
+				t.Fatalf("Expected %d errors, got %d: %v", len(tt.expectErrors), len(result.Errors), result.GetErrorMessages())
+			}
+			for i, code := range tt.expectErrors {
+				if result.Errors[i].Code != code {
+					t.Errorf("Expected error code %s, got %s", code, result.Errors[i].Code)
+				}
+			}
+			if len(result.Warnings) != len(tt.expectWarning) {
+				t.Fatalf("Expected %d warnings, got %d: %v", len(tt.expectWarning), len(result.Warnings), result.GetWarningMessages())
+			}
+			for i, code := range tt.expectWarning {
+				if result.Warnings[i].Code != code {
+					t.Errorf("Expected warning code %s, got %s", code, result.Warnings[i].Code)
+				}
+			}
+			if result.Valid != (len(tt.expectErrors) == 0) {
+				t.Errorf("Expected valid=%v, got %v", len(tt.expectErrors) == 0, result.Valid)
+			}
+		})
+	}
+}
+
+func TestDefaultValidator_ValidateVideoStream_Profile(t *testing.T) {
+	validator := &DefaultValidator{}
+
+	tests := []struct {
+		name        string
+		profile     string
+		expected    string
+		expectCodes []string
+	}{
+		{name: "main matches", profile: "Main", expected: "main"},
+		{name: "match is case-insensitive", profile: "MAIN", expected: "Main"},
+		{name: "high 10 matches", profile: "High 10", expected: "high10"},
+		{name: "high 4:2:2 matches", profile: "High 4:2:2", expected: "high422"},
+		{name: "baseline matches constrained baseline", profile: "Constrained Baseline", expected: "baseline"},
+		{name: "high444 matches high 4:4:4 predictive", profile: "High 4:4:4 Predictive", expected: "high444"},
+		{name: "hevc main 10 matches", profile: "Main 10", expected: "main10"},
+		{name: "high is not main", profile: "High", expected: "main", expectCodes: []string{"PROFILE_MISMATCH"}},
+		{name: "main is not baseline", profile: "Main", expected: "baseline", expectCodes: []string{"PROFILE_MISMATCH"}},
+		{name: "empty means don't check", profile: "High", expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mediaInfo := &MediaInfo{VideoStreams: []VideoStreamInfo{{Codec: "h264", Profile: tt.profile}}}
+			result := &ValidationResult{Valid: true}
+			validator.validateVideoStream(mediaInfo, &ExpectedMediaInfo{Profile: tt.expected}, result)
+
+			if len(result.Errors) != len(tt.expectCodes) {
+				t.Fatalf("Expected %d errors, got %d: %v", len(tt.expectCodes), len(result.Errors), result.GetErrorMessages())
+			}
+			for i, code := range tt.expectCodes {
+				if result.Errors[i].Code != code {
+					t.Errorf("Expected error code %s, got %s", code, result.Errors[i].Code)
+				}
+			}
+		})
+	}
+}
+
+func TestDefaultValidator_ValidateVideoStream_ProfileMismatchReportsLevel(t *testing.T) {
+	validator := &DefaultValidator{}
+
+	mediaInfo := &MediaInfo{VideoStreams: []VideoStreamInfo{{Codec: "h264", Profile: "High", Level: "4"}}}
+	result := &ValidationResult{Valid: true}
+	validator.validateVideoStream(mediaInfo, &ExpectedMediaInfo{Profile: "main"}, result)
+
+	if len(result.Errors) != 1 || result.Errors[0].Code != "PROFILE_MISMATCH" {
+		t.Fatalf("Expected PROFILE_MISMATCH, got %v", result.GetErrorMessages())
+	}
+	if want := "expected profile main, got High (level 4)"; result.Errors[0].Message != want {
+		t.Errorf("Expected message %q, got %q", want, result.Errors[0].Message)
+	}
+}
+
 func TestDefaultValidator_Validate_MinimalLevel(t *testing.T) {
 	// 最小限の検証レベルのテスト
 	tmpDir := t.TempDir()
@@ -350,3 +749,54 @@ func TestDefaultValidator_LevelToString(t *testing.T) {
 		}
 	}
 }
+
+func TestParseValidationLevel(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    ValidationLevel
+		wantErr bool
+	}{
+		{input: "minimal", want: ValidationLevelMinimal},
+		{input: "standard", want: ValidationLevelStandard},
+		{input: "Strict", want: ValidationLevelStrict},
+		{input: "", wantErr: true},
+		{input: "paranoid", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseValidationLevel(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseValidationLevel(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("ParseValidationLevel(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseHLSValidationDepth(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    HLSValidationDepth
+		wantErr bool
+	}{
+		{input: "basic", want: HLSValidationDepthBasic},
+		{input: "medium", want: HLSValidationDepthMedium},
+		{input: "FULL", want: HLSValidationDepthFull},
+		{input: "deep", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseHLSValidationDepth(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseHLSValidationDepth(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("ParseHLSValidationDepth(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
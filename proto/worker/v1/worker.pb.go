@@ -96,7 +96,49 @@ type JobRequest struct {
 	// output はアップロード先の設定
 	Output *OutputConfig `protobuf:"bytes,4,opt,name=output,proto3" json:"output,omitempty"`
 	// callback_url はジョブ完了時に呼び出すWebhook URL（オプション）
-	CallbackUrl   string `protobuf:"bytes,5,opt,name=callback_url,json=callbackUrl,proto3" json:"callback_url,omitempty"`
+	CallbackUrl string `protobuf:"bytes,5,opt,name=callback_url,json=callbackUrl,proto3" json:"callback_url,omitempty"`
+	// speed はエンコーダーの速度プリセット（x264/x265 の -preset 値、例: "veryfast"）
+	// 空の場合はプリセットの既定値を使用する
+	Speed string `protobuf:"bytes,6,opt,name=speed,proto3" json:"speed,omitempty"`
+	// stream_copy は再エンコードせずにコピーするストリーム（"video" または "audio"）
+	// 空の場合は映像・音声ともにプリセットに従って再エンコードする
+	StreamCopy string `protobuf:"bytes,7,opt,name=stream_copy,json=streamCopy,proto3" json:"stream_copy,omitempty"`
+	// overrides はプリセットの ffmpeg オプションを上書きする値（キーは先頭の "-" を除いたオプション名）
+	// 使用できるキーは "crf"、"b:v"、"preset" のみ
+	Overrides map[string]string `protobuf:"bytes,8,rep,name=overrides,proto3" json:"overrides,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// segment_layout は単一バリアント HLS のセグメントの配置（"flat" または "segments"）
+	// "segments" の場合はセグメントを segments/ サブディレクトリに配置し、プレイリストの URI を書き換える
+	SegmentLayout string `protobuf:"bytes,9,opt,name=segment_layout,json=segmentLayout,proto3" json:"segment_layout,omitempty"`
+	// retry はこのジョブのアップロードのリトライ設定（省略時は Worker の既定値）
+	Retry *RetryPolicy `protobuf:"bytes,10,opt,name=retry,proto3" json:"retry,omitempty"`
+	// keep_partial_output はキャンセル・失敗した場合にアップロード済みの途中までの出力を残すか
+	// false（既定）の場合、キャンセル・失敗時にこのジョブでアップロードしたオブジェクトを削除する
+	KeepPartialOutput bool `protobuf:"varint,11,opt,name=keep_partial_output,json=keepPartialOutput,proto3" json:"keep_partial_output,omitempty"`
+	// subtitle_path は映像に焼き込む字幕（WebVTT/SRT）の URL（http/https/s3）またはローカルパス
+	// -filter_complex を使うプリセット（ABR）とは併用できない
+	SubtitlePath string `protobuf:"bytes,12,opt,name=subtitle_path,json=subtitlePath,proto3" json:"subtitle_path,omitempty"`
+	// raw_ffmpeg_args はプリセットの代わりに使用する ffmpeg 引数（入力 -i と出力パスは Worker が付ける）
+	// Worker で WORKER_ALLOW_RAW_ARGS=true が設定されていない場合は PermissionDenied を返す
+	// 指定した場合は preset と、プリセットを変更するオプション（speed など）は使用しない
+	RawFfmpegArgs []string `protobuf:"bytes,13,rep,name=raw_ffmpeg_args,json=rawFfmpegArgs,proto3" json:"raw_ffmpeg_args,omitempty"`
+	// start_time は入力から切り出す開始位置（秒数 "90" または時刻表記 "00:01:30.5"、空の場合は先頭から）
+	StartTime string `protobuf:"bytes,14,opt,name=start_time,json=startTime,proto3" json:"start_time,omitempty"`
+	// duration は切り出す長さ（start_time と同じ表記、空の場合は入力の最後まで）
+	Duration string `protobuf:"bytes,15,opt,name=duration,proto3" json:"duration,omitempty"`
+	// skip_preflight はエンコード前の ffprobe による入力の事前チェックを省略するか
+	// false（既定）の場合、読み取れない・映像がない・長さが 0 の入力は ffmpeg を起動せずに失敗させる
+	SkipPreflight bool `protobuf:"varint,16,opt,name=skip_preflight,json=skipPreflight,proto3" json:"skip_preflight,omitempty"`
+	// validation はこのジョブの出力の検証の設定（省略時は Worker の既定値）
+	Validation *ValidationConfig `protobuf:"bytes,17,opt,name=validation,proto3" json:"validation,omitempty"`
+	// hls_init_path は fMP4 の HLS の初期化セグメント（#EXT-X-MAP）の配置先（出力からの相対パス、例: "init/init.mp4"）
+	// 指定した場合はエンコード後にファイルを移動し、プレイリストの URI を書き換える
+	HlsInitPath string `protobuf:"bytes,18,opt,name=hls_init_path,json=hlsInitPath,proto3" json:"hls_init_path,omitempty"`
+	// hls_key_path は暗号化した HLS の暗号化キー（#EXT-X-KEY）の配置先（出力からの相対パス、例: "keys/video.key"）
+	// キーの URI が出力ディレクトリ内のファイルを指す場合のみ指定できる
+	HlsKeyPath string `protobuf:"bytes,19,opt,name=hls_key_path,json=hlsKeyPath,proto3" json:"hls_key_path,omitempty"`
+	// priority は Worker のキューで実行枠を待つ順番を決める優先度（"high"、"normal"、"low"、空の場合は "normal"）
+	// 実行枠が空いた際は優先度の高いジョブから、同じ優先度の中では待ち始めた順に実行する
+	Priority      string `protobuf:"bytes,20,opt,name=priority,proto3" json:"priority,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -166,6 +208,248 @@ func (x *JobRequest) GetCallbackUrl() string {
 	return ""
 }
 
+func (x *JobRequest) GetSpeed() string {
+	if x != nil {
+		return x.Speed
+	}
+	return ""
+}
+
+func (x *JobRequest) GetStreamCopy() string {
+	if x != nil {
+		return x.StreamCopy
+	}
+	return ""
+}
+
+func (x *JobRequest) GetOverrides() map[string]string {
+	if x != nil {
+		return x.Overrides
+	}
+	return nil
+}
+
+func (x *JobRequest) GetSegmentLayout() string {
+	if x != nil {
+		return x.SegmentLayout
+	}
+	return ""
+}
+
+func (x *JobRequest) GetRetry() *RetryPolicy {
+	if x != nil {
+		return x.Retry
+	}
+	return nil
+}
+
+func (x *JobRequest) GetKeepPartialOutput() bool {
+	if x != nil {
+		return x.KeepPartialOutput
+	}
+	return false
+}
+
+func (x *JobRequest) GetSubtitlePath() string {
+	if x != nil {
+		return x.SubtitlePath
+	}
+	return ""
+}
+
+func (x *JobRequest) GetRawFfmpegArgs() []string {
+	if x != nil {
+		return x.RawFfmpegArgs
+	}
+	return nil
+}
+
+func (x *JobRequest) GetStartTime() string {
+	if x != nil {
+		return x.StartTime
+	}
+	return ""
+}
+
+func (x *JobRequest) GetDuration() string {
+	if x != nil {
+		return x.Duration
+	}
+	return ""
+}
+
+func (x *JobRequest) GetSkipPreflight() bool {
+	if x != nil {
+		return x.SkipPreflight
+	}
+	return false
+}
+
+func (x *JobRequest) GetValidation() *ValidationConfig {
+	if x != nil {
+		return x.Validation
+	}
+	return nil
+}
+
+func (x *JobRequest) GetHlsInitPath() string {
+	if x != nil {
+		return x.HlsInitPath
+	}
+	return ""
+}
+
+func (x *JobRequest) GetHlsKeyPath() string {
+	if x != nil {
+		return x.HlsKeyPath
+	}
+	return ""
+}
+
+func (x *JobRequest) GetPriority() string {
+	if x != nil {
+		return x.Priority
+	}
+	return ""
+}
+
+// ValidationConfig はジョブごとの出力の検証の設定（空・0 の項目は Worker の既定値を使用する）
+type ValidationConfig struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// level は検証のレベル（"minimal"、"standard"、"strict"）
+	Level string `protobuf:"bytes,1,opt,name=level,proto3" json:"level,omitempty"`
+	// timeout_seconds は検証のタイムアウト（秒）
+	TimeoutSeconds int32 `protobuf:"varint,2,opt,name=timeout_seconds,json=timeoutSeconds,proto3" json:"timeout_seconds,omitempty"`
+	// skip_decode_test は strict レベルのデコードテストを省略するか（未設定の場合は Worker の既定値）
+	SkipDecodeTest *bool `protobuf:"varint,3,opt,name=skip_decode_test,json=skipDecodeTest,proto3,oneof" json:"skip_decode_test,omitempty"`
+	// hls_validation_depth は HLS の検証の深さ（"basic"、"medium"、"full"）
+	HlsValidationDepth string `protobuf:"bytes,4,opt,name=hls_validation_depth,json=hlsValidationDepth,proto3" json:"hls_validation_depth,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *ValidationConfig) Reset() {
+	*x = ValidationConfig{}
+	mi := &file_proto_worker_v1_worker_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ValidationConfig) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ValidationConfig) ProtoMessage() {}
+
+func (x *ValidationConfig) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_worker_v1_worker_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ValidationConfig.ProtoReflect.Descriptor instead.
+func (*ValidationConfig) Descriptor() ([]byte, []int) {
+	return file_proto_worker_v1_worker_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ValidationConfig) GetLevel() string {
+	if x != nil {
+		return x.Level
+	}
+	return ""
+}
+
+func (x *ValidationConfig) GetTimeoutSeconds() int32 {
+	if x != nil {
+		return x.TimeoutSeconds
+	}
+	return 0
+}
+
+func (x *ValidationConfig) GetSkipDecodeTest() bool {
+	if x != nil && x.SkipDecodeTest != nil {
+		return *x.SkipDecodeTest
+	}
+	return false
+}
+
+func (x *ValidationConfig) GetHlsValidationDepth() string {
+	if x != nil {
+		return x.HlsValidationDepth
+	}
+	return ""
+}
+
+// RetryPolicy はジョブごとのリトライ設定（0 の項目は既定値を使用する）
+type RetryPolicy struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// max_attempts は最大試行回数（1〜10）
+	MaxAttempts int32 `protobuf:"varint,1,opt,name=max_attempts,json=maxAttempts,proto3" json:"max_attempts,omitempty"`
+	// initial_wait_ms は初回の待機時間（ミリ秒、最大 300000）
+	InitialWaitMs int64 `protobuf:"varint,2,opt,name=initial_wait_ms,json=initialWaitMs,proto3" json:"initial_wait_ms,omitempty"`
+	// max_wait_ms は待機時間の上限（ミリ秒、最大 300000）
+	MaxWaitMs     int64 `protobuf:"varint,3,opt,name=max_wait_ms,json=maxWaitMs,proto3" json:"max_wait_ms,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RetryPolicy) Reset() {
+	*x = RetryPolicy{}
+	mi := &file_proto_worker_v1_worker_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RetryPolicy) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RetryPolicy) ProtoMessage() {}
+
+func (x *RetryPolicy) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_worker_v1_worker_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RetryPolicy.ProtoReflect.Descriptor instead.
+func (*RetryPolicy) Descriptor() ([]byte, []int) {
+	return file_proto_worker_v1_worker_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *RetryPolicy) GetMaxAttempts() int32 {
+	if x != nil {
+		return x.MaxAttempts
+	}
+	return 0
+}
+
+func (x *RetryPolicy) GetInitialWaitMs() int64 {
+	if x != nil {
+		return x.InitialWaitMs
+	}
+	return 0
+}
+
+func (x *RetryPolicy) GetMaxWaitMs() int64 {
+	if x != nil {
+		return x.MaxWaitMs
+	}
+	return 0
+}
+
 // OutputConfig はアップロード先の設定
 type OutputConfig struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
@@ -176,14 +460,17 @@ type OutputConfig struct {
 	// metadata は任意のメタデータ
 	Metadata map[string]string `protobuf:"bytes,3,rep,name=metadata,proto3" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
 	// type は出力タイプ（"single", "hls", "dash"）デフォルトは "single"
-	Type          string `protobuf:"bytes,4,opt,name=type,proto3" json:"type,omitempty"`
+	Type string `protobuf:"bytes,4,opt,name=type,proto3" json:"type,omitempty"`
+	// kms_key_id は S3 のサーバー側暗号化（SSE-KMS）に使用する KMS キー（キーID、キーARN、エイリアス）
+	// 空の場合は Worker の既定値（S3_SSE_KMS_KEY_ID）を使用する
+	KmsKeyId      string `protobuf:"bytes,5,opt,name=kms_key_id,json=kmsKeyId,proto3" json:"kms_key_id,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *OutputConfig) Reset() {
 	*x = OutputConfig{}
-	mi := &file_proto_worker_v1_worker_proto_msgTypes[1]
+	mi := &file_proto_worker_v1_worker_proto_msgTypes[3]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -195,7 +482,7 @@ func (x *OutputConfig) String() string {
 func (*OutputConfig) ProtoMessage() {}
 
 func (x *OutputConfig) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_worker_v1_worker_proto_msgTypes[1]
+	mi := &file_proto_worker_v1_worker_proto_msgTypes[3]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -208,7 +495,7 @@ func (x *OutputConfig) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use OutputConfig.ProtoReflect.Descriptor instead.
 func (*OutputConfig) Descriptor() ([]byte, []int) {
-	return file_proto_worker_v1_worker_proto_rawDescGZIP(), []int{1}
+	return file_proto_worker_v1_worker_proto_rawDescGZIP(), []int{3}
 }
 
 func (x *OutputConfig) GetStorage() string {
@@ -239,6 +526,13 @@ func (x *OutputConfig) GetType() string {
 	return ""
 }
 
+func (x *OutputConfig) GetKmsKeyId() string {
+	if x != nil {
+		return x.KmsKeyId
+	}
+	return ""
+}
+
 // JobProgress はジョブの進捗情報
 type JobProgress struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
@@ -255,14 +549,26 @@ type JobProgress struct {
 	// output_url は完了時のアップロード先URL
 	OutputUrl string `protobuf:"bytes,6,opt,name=output_url,json=outputUrl,proto3" json:"output_url,omitempty"`
 	// error はエラー発生時のエラーメッセージ
-	Error         string `protobuf:"bytes,7,opt,name=error,proto3" json:"error,omitempty"`
+	Error string `protobuf:"bytes,7,opt,name=error,proto3" json:"error,omitempty"`
+	// output_files は完了時にアップロードしたファイルの一覧（HLS/DASH のセグメントなどを含む）
+	OutputFiles []*OutputFile `protobuf:"bytes,8,rep,name=output_files,json=outputFiles,proto3" json:"output_files,omitempty"`
+	// error_code は失敗の分類（INPUT_UNREACHABLE、INVALID_INPUT、INPUT_TOO_LARGE、UNSUPPORTED_CODEC、DISK_FULL、VALIDATION_FAILED、FFMPEG_CRASH）
+	// エンコード以外（アップロードなど）の失敗や分類できない失敗の場合は空
+	ErrorCode string `protobuf:"bytes,9,opt,name=error_code,json=errorCode,proto3" json:"error_code,omitempty"`
+	// ffmpeg_command はジョブで実行した ffmpeg のコマンド（先頭は実行ファイル名、2パスエンコードの場合は2パス目）
+	// 完了（COMPLETED）と、ffmpeg の実行後にエンコードが失敗した場合（FAILED）の進捗にのみ含まれる
+	// 署名付き URL のクエリの値やユーザー情報、認証情報・暗号化キーのオプションの値は REDACTED に置き換えられる
+	FfmpegCommand []string `protobuf:"bytes,10,rep,name=ffmpeg_command,json=ffmpegCommand,proto3" json:"ffmpeg_command,omitempty"`
+	// queue_position は実行枠が満杯でキュー（JOB_QUEUE_SIZE）で待つ場合の順番（1 始まり）
+	// キューに入ったときの QUEUED の進捗にのみ含まれ、それ以外は 0
+	QueuePosition int32 `protobuf:"varint,11,opt,name=queue_position,json=queuePosition,proto3" json:"queue_position,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *JobProgress) Reset() {
 	*x = JobProgress{}
-	mi := &file_proto_worker_v1_worker_proto_msgTypes[2]
+	mi := &file_proto_worker_v1_worker_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -274,7 +580,7 @@ func (x *JobProgress) String() string {
 func (*JobProgress) ProtoMessage() {}
 
 func (x *JobProgress) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_worker_v1_worker_proto_msgTypes[2]
+	mi := &file_proto_worker_v1_worker_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -287,7 +593,7 @@ func (x *JobProgress) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use JobProgress.ProtoReflect.Descriptor instead.
 func (*JobProgress) Descriptor() ([]byte, []int) {
-	return file_proto_worker_v1_worker_proto_rawDescGZIP(), []int{2}
+	return file_proto_worker_v1_worker_proto_rawDescGZIP(), []int{4}
 }
 
 func (x *JobProgress) GetJobId() string {
@@ -339,6 +645,90 @@ func (x *JobProgress) GetError() string {
 	return ""
 }
 
+func (x *JobProgress) GetOutputFiles() []*OutputFile {
+	if x != nil {
+		return x.OutputFiles
+	}
+	return nil
+}
+
+func (x *JobProgress) GetErrorCode() string {
+	if x != nil {
+		return x.ErrorCode
+	}
+	return ""
+}
+
+func (x *JobProgress) GetFfmpegCommand() []string {
+	if x != nil {
+		return x.FfmpegCommand
+	}
+	return nil
+}
+
+func (x *JobProgress) GetQueuePosition() int32 {
+	if x != nil {
+		return x.QueuePosition
+	}
+	return 0
+}
+
+// OutputFile はジョブがアップロードしたファイル
+type OutputFile struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// path は出力先（OutputConfig.path）からの相対パス（スラッシュ区切り）
+	// 単一ファイル出力の場合は出力ファイルと同じディレクトリからの相対パス（ファイル名とサムネイル）
+	Path string `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	// url はアップロード先URL
+	Url           string `protobuf:"bytes,2,opt,name=url,proto3" json:"url,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OutputFile) Reset() {
+	*x = OutputFile{}
+	mi := &file_proto_worker_v1_worker_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OutputFile) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OutputFile) ProtoMessage() {}
+
+func (x *OutputFile) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_worker_v1_worker_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OutputFile.ProtoReflect.Descriptor instead.
+func (*OutputFile) Descriptor() ([]byte, []int) {
+	return file_proto_worker_v1_worker_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *OutputFile) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *OutputFile) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
 // StatusRequest は Worker 状態取得のリクエスト
 type StatusRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -348,7 +738,7 @@ type StatusRequest struct {
 
 func (x *StatusRequest) Reset() {
 	*x = StatusRequest{}
-	mi := &file_proto_worker_v1_worker_proto_msgTypes[3]
+	mi := &file_proto_worker_v1_worker_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -360,7 +750,7 @@ func (x *StatusRequest) String() string {
 func (*StatusRequest) ProtoMessage() {}
 
 func (x *StatusRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_worker_v1_worker_proto_msgTypes[3]
+	mi := &file_proto_worker_v1_worker_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -373,7 +763,7 @@ func (x *StatusRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use StatusRequest.ProtoReflect.Descriptor instead.
 func (*StatusRequest) Descriptor() ([]byte, []int) {
-	return file_proto_worker_v1_worker_proto_rawDescGZIP(), []int{3}
+	return file_proto_worker_v1_worker_proto_rawDescGZIP(), []int{6}
 }
 
 // WorkerStatus は Worker の現在の状態
@@ -383,19 +773,27 @@ type WorkerStatus struct {
 	CurrentJobs int32 `protobuf:"varint,1,opt,name=current_jobs,json=currentJobs,proto3" json:"current_jobs,omitempty"`
 	// max_concurrent_jobs は最大同時実行数
 	MaxConcurrentJobs int32 `protobuf:"varint,2,opt,name=max_concurrent_jobs,json=maxConcurrentJobs,proto3" json:"max_concurrent_jobs,omitempty"`
-	// active_job_ids は実行中のジョブID一覧
+	// active_job_ids は実行中・キューで待機中のジョブID一覧
 	ActiveJobIds []string `protobuf:"bytes,3,rep,name=active_job_ids,json=activeJobIds,proto3" json:"active_job_ids,omitempty"`
 	// worker_id は Worker の識別子
 	WorkerId string `protobuf:"bytes,4,opt,name=worker_id,json=workerId,proto3" json:"worker_id,omitempty"`
 	// version は Worker のバージョン
-	Version       string `protobuf:"bytes,5,opt,name=version,proto3" json:"version,omitempty"`
+	Version string `protobuf:"bytes,5,opt,name=version,proto3" json:"version,omitempty"`
+	// capabilities は Worker の ffmpeg で利用できるフィルター・エンコーダー
+	// 起動時のプローブが無効・失敗した場合は省略される
+	Capabilities *WorkerCapabilities `protobuf:"bytes,6,opt,name=capabilities,proto3" json:"capabilities,omitempty"`
+	// queued_jobs は実行枠が空くのを待っているジョブ数（JOB_QUEUE_SIZE が 0 の場合は常に 0）
+	QueuedJobs int32 `protobuf:"varint,7,opt,name=queued_jobs,json=queuedJobs,proto3" json:"queued_jobs,omitempty"`
+	// host_load は Worker のホストの負荷（バックグラウンドで定期的に取得した最新値）
+	// 負荷の取得が無効、またはまだ取得していない場合は省略される
+	HostLoad      *HostLoad `protobuf:"bytes,8,opt,name=host_load,json=hostLoad,proto3" json:"host_load,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *WorkerStatus) Reset() {
 	*x = WorkerStatus{}
-	mi := &file_proto_worker_v1_worker_proto_msgTypes[4]
+	mi := &file_proto_worker_v1_worker_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -407,7 +805,7 @@ func (x *WorkerStatus) String() string {
 func (*WorkerStatus) ProtoMessage() {}
 
 func (x *WorkerStatus) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_worker_v1_worker_proto_msgTypes[4]
+	mi := &file_proto_worker_v1_worker_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -420,7 +818,7 @@ func (x *WorkerStatus) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use WorkerStatus.ProtoReflect.Descriptor instead.
 func (*WorkerStatus) Descriptor() ([]byte, []int) {
-	return file_proto_worker_v1_worker_proto_rawDescGZIP(), []int{4}
+	return file_proto_worker_v1_worker_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *WorkerStatus) GetCurrentJobs() int32 {
@@ -458,30 +856,581 @@ func (x *WorkerStatus) GetVersion() string {
 	return ""
 }
 
-// CancelRequest はジョブキャンセルのリクエスト
-type CancelRequest struct {
-	state protoimpl.MessageState `protogen:"open.v1"`
-	// job_id はキャンセルするジョブID
-	JobId         string `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+func (x *WorkerStatus) GetCapabilities() *WorkerCapabilities {
+	if x != nil {
+		return x.Capabilities
+	}
+	return nil
+}
+
+func (x *WorkerStatus) GetQueuedJobs() int32 {
+	if x != nil {
+		return x.QueuedJobs
+	}
+	return 0
+}
+
+func (x *WorkerStatus) GetHostLoad() *HostLoad {
+	if x != nil {
+		return x.HostLoad
+	}
+	return nil
+}
+
+// HostLoad は Worker のホストの CPU・メモリ・GPU の負荷
+type HostLoad struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// cpu_percent はホスト全体の CPU 使用率（0〜100）
+	CpuPercent float64 `protobuf:"fixed64,1,opt,name=cpu_percent,json=cpuPercent,proto3" json:"cpu_percent,omitempty"`
+	// memory_percent はメモリ使用率（0〜100）
+	MemoryPercent float64 `protobuf:"fixed64,2,opt,name=memory_percent,json=memoryPercent,proto3" json:"memory_percent,omitempty"`
+	// memory_used_bytes と memory_total_bytes は使用中のメモリと搭載メモリ（バイト）
+	MemoryUsedBytes  uint64 `protobuf:"varint,3,opt,name=memory_used_bytes,json=memoryUsedBytes,proto3" json:"memory_used_bytes,omitempty"`
+	MemoryTotalBytes uint64 `protobuf:"varint,4,opt,name=memory_total_bytes,json=memoryTotalBytes,proto3" json:"memory_total_bytes,omitempty"`
+	// gpu_available は GPU（NVENC）の使用率を取得できたかどうか
+	GpuAvailable bool `protobuf:"varint,5,opt,name=gpu_available,json=gpuAvailable,proto3" json:"gpu_available,omitempty"`
+	// gpu_percent は GPU の使用率（0〜100、複数 GPU の場合は平均。gpu_available が false の場合は 0）
+	GpuPercent float64 `protobuf:"fixed64,6,opt,name=gpu_percent,json=gpuPercent,proto3" json:"gpu_percent,omitempty"`
+	// sampled_at_unix_ms は負荷を取得した時刻（Unix ミリ秒）
+	SampledAtUnixMs int64 `protobuf:"varint,7,opt,name=sampled_at_unix_ms,json=sampledAtUnixMs,proto3" json:"sampled_at_unix_ms,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *HostLoad) Reset() {
+	*x = HostLoad{}
+	mi := &file_proto_worker_v1_worker_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HostLoad) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HostLoad) ProtoMessage() {}
+
+func (x *HostLoad) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_worker_v1_worker_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HostLoad.ProtoReflect.Descriptor instead.
+func (*HostLoad) Descriptor() ([]byte, []int) {
+	return file_proto_worker_v1_worker_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *HostLoad) GetCpuPercent() float64 {
+	if x != nil {
+		return x.CpuPercent
+	}
+	return 0
+}
+
+func (x *HostLoad) GetMemoryPercent() float64 {
+	if x != nil {
+		return x.MemoryPercent
+	}
+	return 0
+}
+
+func (x *HostLoad) GetMemoryUsedBytes() uint64 {
+	if x != nil {
+		return x.MemoryUsedBytes
+	}
+	return 0
+}
+
+func (x *HostLoad) GetMemoryTotalBytes() uint64 {
+	if x != nil {
+		return x.MemoryTotalBytes
+	}
+	return 0
+}
+
+func (x *HostLoad) GetGpuAvailable() bool {
+	if x != nil {
+		return x.GpuAvailable
+	}
+	return false
+}
+
+func (x *HostLoad) GetGpuPercent() float64 {
+	if x != nil {
+		return x.GpuPercent
+	}
+	return 0
+}
+
+func (x *HostLoad) GetSampledAtUnixMs() int64 {
+	if x != nil {
+		return x.SampledAtUnixMs
+	}
+	return 0
+}
+
+// WorkerCapabilities は ffmpeg で利用できるフィルター・エンコーダーの一覧
+type WorkerCapabilities struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// filters は ffmpeg -filters で報告されたフィルター名（例: "tonemap", "subtitles"）
+	Filters []string `protobuf:"bytes,1,rep,name=filters,proto3" json:"filters,omitempty"`
+	// encoders は ffmpeg -encoders で報告されたエンコーダー名（例: "libx264", "libfdk_aac"）
+	Encoders      []string `protobuf:"bytes,2,rep,name=encoders,proto3" json:"encoders,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WorkerCapabilities) Reset() {
+	*x = WorkerCapabilities{}
+	mi := &file_proto_worker_v1_worker_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WorkerCapabilities) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WorkerCapabilities) ProtoMessage() {}
+
+func (x *WorkerCapabilities) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_worker_v1_worker_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WorkerCapabilities.ProtoReflect.Descriptor instead.
+func (*WorkerCapabilities) Descriptor() ([]byte, []int) {
+	return file_proto_worker_v1_worker_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *WorkerCapabilities) GetFilters() []string {
+	if x != nil {
+		return x.Filters
+	}
+	return nil
+}
+
+func (x *WorkerCapabilities) GetEncoders() []string {
+	if x != nil {
+		return x.Encoders
+	}
+	return nil
+}
+
+// CancelRequest はジョブキャンセルのリクエスト
+type CancelRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// job_id はキャンセルするジョブID
+	JobId         string `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
 func (x *CancelRequest) Reset() {
 	*x = CancelRequest{}
-	mi := &file_proto_worker_v1_worker_proto_msgTypes[5]
+	mi := &file_proto_worker_v1_worker_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CancelRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelRequest) ProtoMessage() {}
+
+func (x *CancelRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_worker_v1_worker_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelRequest.ProtoReflect.Descriptor instead.
+func (*CancelRequest) Descriptor() ([]byte, []int) {
+	return file_proto_worker_v1_worker_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *CancelRequest) GetJobId() string {
+	if x != nil {
+		return x.JobId
+	}
+	return ""
+}
+
+// AttachRequest は進捗ストリームへの再接続のリクエスト
+type AttachRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// job_id は再接続するジョブID
+	JobId         string `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AttachRequest) Reset() {
+	*x = AttachRequest{}
+	mi := &file_proto_worker_v1_worker_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AttachRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AttachRequest) ProtoMessage() {}
+
+func (x *AttachRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_worker_v1_worker_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AttachRequest.ProtoReflect.Descriptor instead.
+func (*AttachRequest) Descriptor() ([]byte, []int) {
+	return file_proto_worker_v1_worker_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *AttachRequest) GetJobId() string {
+	if x != nil {
+		return x.JobId
+	}
+	return ""
+}
+
+// JobLogsRequest は GetJobLogs のリクエスト
+type JobLogsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// job_id はログを取得するジョブID
+	JobId         string `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *JobLogsRequest) Reset() {
+	*x = JobLogsRequest{}
+	mi := &file_proto_worker_v1_worker_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *JobLogsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JobLogsRequest) ProtoMessage() {}
+
+func (x *JobLogsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_worker_v1_worker_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JobLogsRequest.ProtoReflect.Descriptor instead.
+func (*JobLogsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_worker_v1_worker_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *JobLogsRequest) GetJobId() string {
+	if x != nil {
+		return x.JobId
+	}
+	return ""
+}
+
+// JobLogLine は ffmpeg の出力（stderr）の1行
+type JobLogLine struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// job_id はジョブID
+	JobId string `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+	// line は ffmpeg の出力の1行
+	Line          string `protobuf:"bytes,2,opt,name=line,proto3" json:"line,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *JobLogLine) Reset() {
+	*x = JobLogLine{}
+	mi := &file_proto_worker_v1_worker_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *JobLogLine) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JobLogLine) ProtoMessage() {}
+
+func (x *JobLogLine) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_worker_v1_worker_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JobLogLine.ProtoReflect.Descriptor instead.
+func (*JobLogLine) Descriptor() ([]byte, []int) {
+	return file_proto_worker_v1_worker_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *JobLogLine) GetJobId() string {
+	if x != nil {
+		return x.JobId
+	}
+	return ""
+}
+
+func (x *JobLogLine) GetLine() string {
+	if x != nil {
+		return x.Line
+	}
+	return ""
+}
+
+// CancelResponse はジョブキャンセルのレスポンス
+type CancelResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// success はキャンセルが成功したかどうか
+	Success bool `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	// message はレスポンスメッセージ
+	Message       string `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CancelResponse) Reset() {
+	*x = CancelResponse{}
+	mi := &file_proto_worker_v1_worker_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CancelResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelResponse) ProtoMessage() {}
+
+func (x *CancelResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_worker_v1_worker_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelResponse.ProtoReflect.Descriptor instead.
+func (*CancelResponse) Descriptor() ([]byte, []int) {
+	return file_proto_worker_v1_worker_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *CancelResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *CancelResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+// DryRunResponse はジョブで実行する ffmpeg のコマンド
+type DryRunResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// command は ffmpeg 自体を先頭に含むコマンドの引数（argv）
+	Command []string `protobuf:"bytes,1,rep,name=command,proto3" json:"command,omitempty"`
+	// working_dir は ffmpeg を実行するディレクトリ（空の場合は Worker のカレントディレクトリ）
+	WorkingDir string `protobuf:"bytes,2,opt,name=working_dir,json=workingDir,proto3" json:"working_dir,omitempty"`
+	// preset は使用するプリセット（ハードウェアエンコード版に切り替えた場合はその名前）
+	Preset        string `protobuf:"bytes,3,opt,name=preset,proto3" json:"preset,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DryRunResponse) Reset() {
+	*x = DryRunResponse{}
+	mi := &file_proto_worker_v1_worker_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DryRunResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DryRunResponse) ProtoMessage() {}
+
+func (x *DryRunResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_worker_v1_worker_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DryRunResponse.ProtoReflect.Descriptor instead.
+func (*DryRunResponse) Descriptor() ([]byte, []int) {
+	return file_proto_worker_v1_worker_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *DryRunResponse) GetCommand() []string {
+	if x != nil {
+		return x.Command
+	}
+	return nil
+}
+
+func (x *DryRunResponse) GetWorkingDir() string {
+	if x != nil {
+		return x.WorkingDir
+	}
+	return ""
+}
+
+func (x *DryRunResponse) GetPreset() string {
+	if x != nil {
+		return x.Preset
+	}
+	return ""
+}
+
+// ValidateOutputRequest は既存の出力の検証のリクエスト
+type ValidateOutputRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// path は検証する出力のパス（ジョブの OutputConfig.path と同じく、ストレージのルートからの相対パス）
+	// HLS/DASH の場合はプレイリスト・マニフェスト、またはそれを含むディレクトリを指定する
+	Path string `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	// validation は検証の設定（省略時は Worker の既定値）
+	Validation    *ValidationConfig `protobuf:"bytes,2,opt,name=validation,proto3" json:"validation,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ValidateOutputRequest) Reset() {
+	*x = ValidateOutputRequest{}
+	mi := &file_proto_worker_v1_worker_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ValidateOutputRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ValidateOutputRequest) ProtoMessage() {}
+
+func (x *ValidateOutputRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_worker_v1_worker_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ValidateOutputRequest.ProtoReflect.Descriptor instead.
+func (*ValidateOutputRequest) Descriptor() ([]byte, []int) {
+	return file_proto_worker_v1_worker_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *ValidateOutputRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *ValidateOutputRequest) GetValidation() *ValidationConfig {
+	if x != nil {
+		return x.Validation
+	}
+	return nil
+}
+
+// ValidateOutputResponse は既存の出力の検証結果
+type ValidateOutputResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// valid は検証に合格したかどうか（errors が空の場合に true）
+	Valid bool `protobuf:"varint,1,opt,name=valid,proto3" json:"valid,omitempty"`
+	// errors は検証エラー
+	Errors []*ValidationIssue `protobuf:"bytes,2,rep,name=errors,proto3" json:"errors,omitempty"`
+	// warnings は検証の警告
+	Warnings []*ValidationIssue `protobuf:"bytes,3,rep,name=warnings,proto3" json:"warnings,omitempty"`
+	// media_info は ffprobe で取得したメディア情報（ファイルが存在しない場合など、取得前に失敗した場合は未設定）
+	MediaInfo *ValidatedMediaInfo `protobuf:"bytes,4,opt,name=media_info,json=mediaInfo,proto3" json:"media_info,omitempty"`
+	// duration_ms は検証にかかった時間（ミリ秒）
+	DurationMs    int64 `protobuf:"varint,5,opt,name=duration_ms,json=durationMs,proto3" json:"duration_ms,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ValidateOutputResponse) Reset() {
+	*x = ValidateOutputResponse{}
+	mi := &file_proto_worker_v1_worker_proto_msgTypes[17]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *CancelRequest) String() string {
+func (x *ValidateOutputResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*CancelRequest) ProtoMessage() {}
+func (*ValidateOutputResponse) ProtoMessage() {}
 
-func (x *CancelRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_worker_v1_worker_proto_msgTypes[5]
+func (x *ValidateOutputResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_worker_v1_worker_proto_msgTypes[17]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -492,44 +1441,74 @@ func (x *CancelRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use CancelRequest.ProtoReflect.Descriptor instead.
-func (*CancelRequest) Descriptor() ([]byte, []int) {
-	return file_proto_worker_v1_worker_proto_rawDescGZIP(), []int{5}
+// Deprecated: Use ValidateOutputResponse.ProtoReflect.Descriptor instead.
+func (*ValidateOutputResponse) Descriptor() ([]byte, []int) {
+	return file_proto_worker_v1_worker_proto_rawDescGZIP(), []int{17}
 }
 
-func (x *CancelRequest) GetJobId() string {
+func (x *ValidateOutputResponse) GetValid() bool {
 	if x != nil {
-		return x.JobId
+		return x.Valid
 	}
-	return ""
+	return false
 }
 
-// CancelResponse はジョブキャンセルのレスポンス
-type CancelResponse struct {
+func (x *ValidateOutputResponse) GetErrors() []*ValidationIssue {
+	if x != nil {
+		return x.Errors
+	}
+	return nil
+}
+
+func (x *ValidateOutputResponse) GetWarnings() []*ValidationIssue {
+	if x != nil {
+		return x.Warnings
+	}
+	return nil
+}
+
+func (x *ValidateOutputResponse) GetMediaInfo() *ValidatedMediaInfo {
+	if x != nil {
+		return x.MediaInfo
+	}
+	return nil
+}
+
+func (x *ValidateOutputResponse) GetDurationMs() int64 {
+	if x != nil {
+		return x.DurationMs
+	}
+	return 0
+}
+
+// ValidationIssue は検証エラー・警告
+type ValidationIssue struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
-	// success はキャンセルが成功したかどうか
-	Success bool `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
-	// message はレスポンスメッセージ
-	Message       string `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	// code はエラー・警告の種類（例: "FILE_NOT_FOUND"、"BITRATE_LOW"）
+	Code string `protobuf:"bytes,1,opt,name=code,proto3" json:"code,omitempty"`
+	// message は説明
+	Message string `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	// field は対象の項目（例: "width"。特定の項目でない場合は空）
+	Field         string `protobuf:"bytes,3,opt,name=field,proto3" json:"field,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *CancelResponse) Reset() {
-	*x = CancelResponse{}
-	mi := &file_proto_worker_v1_worker_proto_msgTypes[6]
+func (x *ValidationIssue) Reset() {
+	*x = ValidationIssue{}
+	mi := &file_proto_worker_v1_worker_proto_msgTypes[18]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *CancelResponse) String() string {
+func (x *ValidationIssue) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*CancelResponse) ProtoMessage() {}
+func (*ValidationIssue) ProtoMessage() {}
 
-func (x *CancelResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_worker_v1_worker_proto_msgTypes[6]
+func (x *ValidationIssue) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_worker_v1_worker_proto_msgTypes[18]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -540,45 +1519,366 @@ func (x *CancelResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use CancelResponse.ProtoReflect.Descriptor instead.
-func (*CancelResponse) Descriptor() ([]byte, []int) {
-	return file_proto_worker_v1_worker_proto_rawDescGZIP(), []int{6}
+// Deprecated: Use ValidationIssue.ProtoReflect.Descriptor instead.
+func (*ValidationIssue) Descriptor() ([]byte, []int) {
+	return file_proto_worker_v1_worker_proto_rawDescGZIP(), []int{18}
 }
 
-func (x *CancelResponse) GetSuccess() bool {
+func (x *ValidationIssue) GetCode() string {
 	if x != nil {
-		return x.Success
+		return x.Code
 	}
-	return false
+	return ""
 }
 
-func (x *CancelResponse) GetMessage() string {
+func (x *ValidationIssue) GetMessage() string {
 	if x != nil {
 		return x.Message
 	}
 	return ""
 }
 
+func (x *ValidationIssue) GetField() string {
+	if x != nil {
+		return x.Field
+	}
+	return ""
+}
+
+// ValidatedMediaInfo は検証した出力のメディア情報
+type ValidatedMediaInfo struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// format は ffprobe のフォーマット名
+	Format string `protobuf:"bytes,1,opt,name=format,proto3" json:"format,omitempty"`
+	// duration は長さ（秒）
+	Duration float64 `protobuf:"fixed64,2,opt,name=duration,proto3" json:"duration,omitempty"`
+	// size はファイルサイズ（バイト、単一ファイルの場合のみ）
+	Size int64 `protobuf:"varint,3,opt,name=size,proto3" json:"size,omitempty"`
+	// bitrate はビットレート（bps）
+	Bitrate int64 `protobuf:"varint,4,opt,name=bitrate,proto3" json:"bitrate,omitempty"`
+	// video_streams は映像ストリーム
+	VideoStreams []*ValidatedVideoStream `protobuf:"bytes,5,rep,name=video_streams,json=videoStreams,proto3" json:"video_streams,omitempty"`
+	// audio_streams は音声ストリーム
+	AudioStreams []*ValidatedAudioStream `protobuf:"bytes,6,rep,name=audio_streams,json=audioStreams,proto3" json:"audio_streams,omitempty"`
+	// total_segments は HLS/DASH のセグメント数（単一ファイルの場合は 0）
+	TotalSegments int32 `protobuf:"varint,7,opt,name=total_segments,json=totalSegments,proto3" json:"total_segments,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ValidatedMediaInfo) Reset() {
+	*x = ValidatedMediaInfo{}
+	mi := &file_proto_worker_v1_worker_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ValidatedMediaInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ValidatedMediaInfo) ProtoMessage() {}
+
+func (x *ValidatedMediaInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_worker_v1_worker_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ValidatedMediaInfo.ProtoReflect.Descriptor instead.
+func (*ValidatedMediaInfo) Descriptor() ([]byte, []int) {
+	return file_proto_worker_v1_worker_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *ValidatedMediaInfo) GetFormat() string {
+	if x != nil {
+		return x.Format
+	}
+	return ""
+}
+
+func (x *ValidatedMediaInfo) GetDuration() float64 {
+	if x != nil {
+		return x.Duration
+	}
+	return 0
+}
+
+func (x *ValidatedMediaInfo) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *ValidatedMediaInfo) GetBitrate() int64 {
+	if x != nil {
+		return x.Bitrate
+	}
+	return 0
+}
+
+func (x *ValidatedMediaInfo) GetVideoStreams() []*ValidatedVideoStream {
+	if x != nil {
+		return x.VideoStreams
+	}
+	return nil
+}
+
+func (x *ValidatedMediaInfo) GetAudioStreams() []*ValidatedAudioStream {
+	if x != nil {
+		return x.AudioStreams
+	}
+	return nil
+}
+
+func (x *ValidatedMediaInfo) GetTotalSegments() int32 {
+	if x != nil {
+		return x.TotalSegments
+	}
+	return 0
+}
+
+// ValidatedVideoStream は映像ストリームの情報
+type ValidatedVideoStream struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Codec         string                 `protobuf:"bytes,1,opt,name=codec,proto3" json:"codec,omitempty"`
+	Profile       string                 `protobuf:"bytes,2,opt,name=profile,proto3" json:"profile,omitempty"`
+	Level         string                 `protobuf:"bytes,3,opt,name=level,proto3" json:"level,omitempty"`
+	Width         int32                  `protobuf:"varint,4,opt,name=width,proto3" json:"width,omitempty"`
+	Height        int32                  `protobuf:"varint,5,opt,name=height,proto3" json:"height,omitempty"`
+	FrameRate     float64                `protobuf:"fixed64,6,opt,name=frame_rate,json=frameRate,proto3" json:"frame_rate,omitempty"`
+	PixelFormat   string                 `protobuf:"bytes,7,opt,name=pixel_format,json=pixelFormat,proto3" json:"pixel_format,omitempty"`
+	Bitrate       int64                  `protobuf:"varint,8,opt,name=bitrate,proto3" json:"bitrate,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ValidatedVideoStream) Reset() {
+	*x = ValidatedVideoStream{}
+	mi := &file_proto_worker_v1_worker_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ValidatedVideoStream) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ValidatedVideoStream) ProtoMessage() {}
+
+func (x *ValidatedVideoStream) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_worker_v1_worker_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ValidatedVideoStream.ProtoReflect.Descriptor instead.
+func (*ValidatedVideoStream) Descriptor() ([]byte, []int) {
+	return file_proto_worker_v1_worker_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *ValidatedVideoStream) GetCodec() string {
+	if x != nil {
+		return x.Codec
+	}
+	return ""
+}
+
+func (x *ValidatedVideoStream) GetProfile() string {
+	if x != nil {
+		return x.Profile
+	}
+	return ""
+}
+
+func (x *ValidatedVideoStream) GetLevel() string {
+	if x != nil {
+		return x.Level
+	}
+	return ""
+}
+
+func (x *ValidatedVideoStream) GetWidth() int32 {
+	if x != nil {
+		return x.Width
+	}
+	return 0
+}
+
+func (x *ValidatedVideoStream) GetHeight() int32 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *ValidatedVideoStream) GetFrameRate() float64 {
+	if x != nil {
+		return x.FrameRate
+	}
+	return 0
+}
+
+func (x *ValidatedVideoStream) GetPixelFormat() string {
+	if x != nil {
+		return x.PixelFormat
+	}
+	return ""
+}
+
+func (x *ValidatedVideoStream) GetBitrate() int64 {
+	if x != nil {
+		return x.Bitrate
+	}
+	return 0
+}
+
+// ValidatedAudioStream は音声ストリームの情報
+type ValidatedAudioStream struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Codec         string                 `protobuf:"bytes,1,opt,name=codec,proto3" json:"codec,omitempty"`
+	SampleRate    int32                  `protobuf:"varint,2,opt,name=sample_rate,json=sampleRate,proto3" json:"sample_rate,omitempty"`
+	Channels      int32                  `protobuf:"varint,3,opt,name=channels,proto3" json:"channels,omitempty"`
+	ChannelLayout string                 `protobuf:"bytes,4,opt,name=channel_layout,json=channelLayout,proto3" json:"channel_layout,omitempty"`
+	Bitrate       int64                  `protobuf:"varint,5,opt,name=bitrate,proto3" json:"bitrate,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ValidatedAudioStream) Reset() {
+	*x = ValidatedAudioStream{}
+	mi := &file_proto_worker_v1_worker_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ValidatedAudioStream) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ValidatedAudioStream) ProtoMessage() {}
+
+func (x *ValidatedAudioStream) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_worker_v1_worker_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ValidatedAudioStream.ProtoReflect.Descriptor instead.
+func (*ValidatedAudioStream) Descriptor() ([]byte, []int) {
+	return file_proto_worker_v1_worker_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *ValidatedAudioStream) GetCodec() string {
+	if x != nil {
+		return x.Codec
+	}
+	return ""
+}
+
+func (x *ValidatedAudioStream) GetSampleRate() int32 {
+	if x != nil {
+		return x.SampleRate
+	}
+	return 0
+}
+
+func (x *ValidatedAudioStream) GetChannels() int32 {
+	if x != nil {
+		return x.Channels
+	}
+	return 0
+}
+
+func (x *ValidatedAudioStream) GetChannelLayout() string {
+	if x != nil {
+		return x.ChannelLayout
+	}
+	return ""
+}
+
+func (x *ValidatedAudioStream) GetBitrate() int64 {
+	if x != nil {
+		return x.Bitrate
+	}
+	return 0
+}
+
 var File_proto_worker_v1_worker_proto protoreflect.FileDescriptor
 
 const file_proto_worker_v1_worker_proto_rawDesc = "" +
 	"\n" +
-	"\x1cproto/worker/v1/worker.proto\x12\tworker.v1\"\xac\x01\n" +
+	"\x1cproto/worker/v1/worker.proto\x12\tworker.v1\"\xb8\x06\n" +
 	"\n" +
 	"JobRequest\x12\x15\n" +
 	"\x06job_id\x18\x01 \x01(\tR\x05jobId\x12\x1b\n" +
 	"\tinput_url\x18\x02 \x01(\tR\binputUrl\x12\x16\n" +
 	"\x06preset\x18\x03 \x01(\tR\x06preset\x12/\n" +
 	"\x06output\x18\x04 \x01(\v2\x17.worker.v1.OutputConfigR\x06output\x12!\n" +
-	"\fcallback_url\x18\x05 \x01(\tR\vcallbackUrl\"\xd0\x01\n" +
+	"\fcallback_url\x18\x05 \x01(\tR\vcallbackUrl\x12\x14\n" +
+	"\x05speed\x18\x06 \x01(\tR\x05speed\x12\x1f\n" +
+	"\vstream_copy\x18\a \x01(\tR\n" +
+	"streamCopy\x12B\n" +
+	"\toverrides\x18\b \x03(\v2$.worker.v1.JobRequest.OverridesEntryR\toverrides\x12%\n" +
+	"\x0esegment_layout\x18\t \x01(\tR\rsegmentLayout\x12,\n" +
+	"\x05retry\x18\n" +
+	" \x01(\v2\x16.worker.v1.RetryPolicyR\x05retry\x12.\n" +
+	"\x13keep_partial_output\x18\v \x01(\bR\x11keepPartialOutput\x12#\n" +
+	"\rsubtitle_path\x18\f \x01(\tR\fsubtitlePath\x12&\n" +
+	"\x0fraw_ffmpeg_args\x18\r \x03(\tR\rrawFfmpegArgs\x12\x1d\n" +
+	"\n" +
+	"start_time\x18\x0e \x01(\tR\tstartTime\x12\x1a\n" +
+	"\bduration\x18\x0f \x01(\tR\bduration\x12%\n" +
+	"\x0eskip_preflight\x18\x10 \x01(\bR\rskipPreflight\x12;\n" +
+	"\n" +
+	"validation\x18\x11 \x01(\v2\x1b.worker.v1.ValidationConfigR\n" +
+	"validation\x12\"\n" +
+	"\rhls_init_path\x18\x12 \x01(\tR\vhlsInitPath\x12 \n" +
+	"\fhls_key_path\x18\x13 \x01(\tR\n" +
+	"hlsKeyPath\x12\x1a\n" +
+	"\bpriority\x18\x14 \x01(\tR\bpriority\x1a<\n" +
+	"\x0eOverridesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xc7\x01\n" +
+	"\x10ValidationConfig\x12\x14\n" +
+	"\x05level\x18\x01 \x01(\tR\x05level\x12'\n" +
+	"\x0ftimeout_seconds\x18\x02 \x01(\x05R\x0etimeoutSeconds\x12-\n" +
+	"\x10skip_decode_test\x18\x03 \x01(\bH\x00R\x0eskipDecodeTest\x88\x01\x01\x120\n" +
+	"\x14hls_validation_depth\x18\x04 \x01(\tR\x12hlsValidationDepthB\x13\n" +
+	"\x11_skip_decode_test\"x\n" +
+	"\vRetryPolicy\x12!\n" +
+	"\fmax_attempts\x18\x01 \x01(\x05R\vmaxAttempts\x12&\n" +
+	"\x0finitial_wait_ms\x18\x02 \x01(\x03R\rinitialWaitMs\x12\x1e\n" +
+	"\vmax_wait_ms\x18\x03 \x01(\x03R\tmaxWaitMs\"\xee\x01\n" +
 	"\fOutputConfig\x12\x18\n" +
 	"\astorage\x18\x01 \x01(\tR\astorage\x12\x12\n" +
 	"\x04path\x18\x02 \x01(\tR\x04path\x12A\n" +
 	"\bmetadata\x18\x03 \x03(\v2%.worker.v1.OutputConfig.MetadataEntryR\bmetadata\x12\x12\n" +
-	"\x04type\x18\x04 \x01(\tR\x04type\x1a;\n" +
+	"\x04type\x18\x04 \x01(\tR\x04type\x12\x1c\n" +
+	"\n" +
+	"kms_key_id\x18\x05 \x01(\tR\bkmsKeyId\x1a;\n" +
 	"\rMetadataEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xdb\x01\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x82\x03\n" +
 	"\vJobProgress\x12\x15\n" +
 	"\x06job_id\x18\x01 \x01(\tR\x05jobId\x12,\n" +
 	"\x06status\x18\x02 \x01(\x0e2\x14.worker.v1.JobStatusR\x06status\x12\x1a\n" +
@@ -587,19 +1887,101 @@ const file_proto_worker_v1_worker_proto_rawDesc = "" +
 	"\ttimestamp\x18\x05 \x01(\tR\ttimestamp\x12\x1d\n" +
 	"\n" +
 	"output_url\x18\x06 \x01(\tR\toutputUrl\x12\x14\n" +
-	"\x05error\x18\a \x01(\tR\x05error\"\x0f\n" +
-	"\rStatusRequest\"\xbe\x01\n" +
+	"\x05error\x18\a \x01(\tR\x05error\x128\n" +
+	"\foutput_files\x18\b \x03(\v2\x15.worker.v1.OutputFileR\voutputFiles\x12\x1d\n" +
+	"\n" +
+	"error_code\x18\t \x01(\tR\terrorCode\x12%\n" +
+	"\x0effmpeg_command\x18\n" +
+	" \x03(\tR\rffmpegCommand\x12%\n" +
+	"\x0equeue_position\x18\v \x01(\x05R\rqueuePosition\"2\n" +
+	"\n" +
+	"OutputFile\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x10\n" +
+	"\x03url\x18\x02 \x01(\tR\x03url\"\x0f\n" +
+	"\rStatusRequest\"\xd4\x02\n" +
 	"\fWorkerStatus\x12!\n" +
 	"\fcurrent_jobs\x18\x01 \x01(\x05R\vcurrentJobs\x12.\n" +
 	"\x13max_concurrent_jobs\x18\x02 \x01(\x05R\x11maxConcurrentJobs\x12$\n" +
 	"\x0eactive_job_ids\x18\x03 \x03(\tR\factiveJobIds\x12\x1b\n" +
 	"\tworker_id\x18\x04 \x01(\tR\bworkerId\x12\x18\n" +
-	"\aversion\x18\x05 \x01(\tR\aversion\"&\n" +
+	"\aversion\x18\x05 \x01(\tR\aversion\x12A\n" +
+	"\fcapabilities\x18\x06 \x01(\v2\x1d.worker.v1.WorkerCapabilitiesR\fcapabilities\x12\x1f\n" +
+	"\vqueued_jobs\x18\a \x01(\x05R\n" +
+	"queuedJobs\x120\n" +
+	"\thost_load\x18\b \x01(\v2\x13.worker.v1.HostLoadR\bhostLoad\"\x9f\x02\n" +
+	"\bHostLoad\x12\x1f\n" +
+	"\vcpu_percent\x18\x01 \x01(\x01R\n" +
+	"cpuPercent\x12%\n" +
+	"\x0ememory_percent\x18\x02 \x01(\x01R\rmemoryPercent\x12*\n" +
+	"\x11memory_used_bytes\x18\x03 \x01(\x04R\x0fmemoryUsedBytes\x12,\n" +
+	"\x12memory_total_bytes\x18\x04 \x01(\x04R\x10memoryTotalBytes\x12#\n" +
+	"\rgpu_available\x18\x05 \x01(\bR\fgpuAvailable\x12\x1f\n" +
+	"\vgpu_percent\x18\x06 \x01(\x01R\n" +
+	"gpuPercent\x12+\n" +
+	"\x12sampled_at_unix_ms\x18\a \x01(\x03R\x0fsampledAtUnixMs\"J\n" +
+	"\x12WorkerCapabilities\x12\x18\n" +
+	"\afilters\x18\x01 \x03(\tR\afilters\x12\x1a\n" +
+	"\bencoders\x18\x02 \x03(\tR\bencoders\"&\n" +
 	"\rCancelRequest\x12\x15\n" +
-	"\x06job_id\x18\x01 \x01(\tR\x05jobId\"D\n" +
+	"\x06job_id\x18\x01 \x01(\tR\x05jobId\"&\n" +
+	"\rAttachRequest\x12\x15\n" +
+	"\x06job_id\x18\x01 \x01(\tR\x05jobId\"'\n" +
+	"\x0eJobLogsRequest\x12\x15\n" +
+	"\x06job_id\x18\x01 \x01(\tR\x05jobId\"7\n" +
+	"\n" +
+	"JobLogLine\x12\x15\n" +
+	"\x06job_id\x18\x01 \x01(\tR\x05jobId\x12\x12\n" +
+	"\x04line\x18\x02 \x01(\tR\x04line\"D\n" +
 	"\x0eCancelResponse\x12\x18\n" +
 	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
-	"\amessage\x18\x02 \x01(\tR\amessage*\xbe\x01\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\"c\n" +
+	"\x0eDryRunResponse\x12\x18\n" +
+	"\acommand\x18\x01 \x03(\tR\acommand\x12\x1f\n" +
+	"\vworking_dir\x18\x02 \x01(\tR\n" +
+	"workingDir\x12\x16\n" +
+	"\x06preset\x18\x03 \x01(\tR\x06preset\"h\n" +
+	"\x15ValidateOutputRequest\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12;\n" +
+	"\n" +
+	"validation\x18\x02 \x01(\v2\x1b.worker.v1.ValidationConfigR\n" +
+	"validation\"\xf9\x01\n" +
+	"\x16ValidateOutputResponse\x12\x14\n" +
+	"\x05valid\x18\x01 \x01(\bR\x05valid\x122\n" +
+	"\x06errors\x18\x02 \x03(\v2\x1a.worker.v1.ValidationIssueR\x06errors\x126\n" +
+	"\bwarnings\x18\x03 \x03(\v2\x1a.worker.v1.ValidationIssueR\bwarnings\x12<\n" +
+	"\n" +
+	"media_info\x18\x04 \x01(\v2\x1d.worker.v1.ValidatedMediaInfoR\tmediaInfo\x12\x1f\n" +
+	"\vduration_ms\x18\x05 \x01(\x03R\n" +
+	"durationMs\"U\n" +
+	"\x0fValidationIssue\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\tR\x04code\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x12\x14\n" +
+	"\x05field\x18\x03 \x01(\tR\x05field\"\xa9\x02\n" +
+	"\x12ValidatedMediaInfo\x12\x16\n" +
+	"\x06format\x18\x01 \x01(\tR\x06format\x12\x1a\n" +
+	"\bduration\x18\x02 \x01(\x01R\bduration\x12\x12\n" +
+	"\x04size\x18\x03 \x01(\x03R\x04size\x12\x18\n" +
+	"\abitrate\x18\x04 \x01(\x03R\abitrate\x12D\n" +
+	"\rvideo_streams\x18\x05 \x03(\v2\x1f.worker.v1.ValidatedVideoStreamR\fvideoStreams\x12D\n" +
+	"\raudio_streams\x18\x06 \x03(\v2\x1f.worker.v1.ValidatedAudioStreamR\faudioStreams\x12%\n" +
+	"\x0etotal_segments\x18\a \x01(\x05R\rtotalSegments\"\xe6\x01\n" +
+	"\x14ValidatedVideoStream\x12\x14\n" +
+	"\x05codec\x18\x01 \x01(\tR\x05codec\x12\x18\n" +
+	"\aprofile\x18\x02 \x01(\tR\aprofile\x12\x14\n" +
+	"\x05level\x18\x03 \x01(\tR\x05level\x12\x14\n" +
+	"\x05width\x18\x04 \x01(\x05R\x05width\x12\x16\n" +
+	"\x06height\x18\x05 \x01(\x05R\x06height\x12\x1d\n" +
+	"\n" +
+	"frame_rate\x18\x06 \x01(\x01R\tframeRate\x12!\n" +
+	"\fpixel_format\x18\a \x01(\tR\vpixelFormat\x12\x18\n" +
+	"\abitrate\x18\b \x01(\x03R\abitrate\"\xaa\x01\n" +
+	"\x14ValidatedAudioStream\x12\x14\n" +
+	"\x05codec\x18\x01 \x01(\tR\x05codec\x12\x1f\n" +
+	"\vsample_rate\x18\x02 \x01(\x05R\n" +
+	"sampleRate\x12\x1a\n" +
+	"\bchannels\x18\x03 \x01(\x05R\bchannels\x12%\n" +
+	"\x0echannel_layout\x18\x04 \x01(\tR\rchannelLayout\x12\x18\n" +
+	"\abitrate\x18\x05 \x01(\x03R\abitrate*\xbe\x01\n" +
 	"\tJobStatus\x12\x1a\n" +
 	"\x16JOB_STATUS_UNSPECIFIED\x10\x00\x12\x15\n" +
 	"\x11JOB_STATUS_QUEUED\x10\x01\x12\x19\n" +
@@ -607,11 +1989,16 @@ const file_proto_worker_v1_worker_proto_rawDesc = "" +
 	"\x14JOB_STATUS_UPLOADING\x10\x03\x12\x18\n" +
 	"\x14JOB_STATUS_COMPLETED\x10\x04\x12\x15\n" +
 	"\x11JOB_STATUS_FAILED\x10\x05\x12\x18\n" +
-	"\x14JOB_STATUS_CANCELLED\x10\x062\xcf\x01\n" +
+	"\x14JOB_STATUS_CANCELLED\x10\x062\xe5\x03\n" +
 	"\rWorkerService\x12<\n" +
 	"\tSubmitJob\x12\x15.worker.v1.JobRequest\x1a\x16.worker.v1.JobProgress0\x01\x12>\n" +
 	"\tGetStatus\x12\x18.worker.v1.StatusRequest\x1a\x17.worker.v1.WorkerStatus\x12@\n" +
-	"\tCancelJob\x12\x18.worker.v1.CancelRequest\x1a\x19.worker.v1.CancelResponseB6Z4github.com/nzws/flux-encoder/proto/worker/v1;workerv1b\x06proto3"
+	"\tCancelJob\x12\x18.worker.v1.CancelRequest\x1a\x19.worker.v1.CancelResponse\x12?\n" +
+	"\tAttachJob\x12\x18.worker.v1.AttachRequest\x1a\x16.worker.v1.JobProgress0\x01\x12@\n" +
+	"\n" +
+	"GetJobLogs\x12\x19.worker.v1.JobLogsRequest\x1a\x15.worker.v1.JobLogLine0\x01\x12:\n" +
+	"\x06DryRun\x12\x15.worker.v1.JobRequest\x1a\x19.worker.v1.DryRunResponse\x12U\n" +
+	"\x0eValidateOutput\x12 .worker.v1.ValidateOutputRequest\x1a!.worker.v1.ValidateOutputResponseB7Z5github.com/nzws/flux-encoder/proto/worker/v1;workerv1b\x06proto3"
 
 var (
 	file_proto_worker_v1_worker_proto_rawDescOnce sync.Once
@@ -626,33 +2013,69 @@ func file_proto_worker_v1_worker_proto_rawDescGZIP() []byte {
 }
 
 var file_proto_worker_v1_worker_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_proto_worker_v1_worker_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_proto_worker_v1_worker_proto_msgTypes = make([]protoimpl.MessageInfo, 24)
 var file_proto_worker_v1_worker_proto_goTypes = []any{
-	(JobStatus)(0),         // 0: worker.v1.JobStatus
-	(*JobRequest)(nil),     // 1: worker.v1.JobRequest
-	(*OutputConfig)(nil),   // 2: worker.v1.OutputConfig
-	(*JobProgress)(nil),    // 3: worker.v1.JobProgress
-	(*StatusRequest)(nil),  // 4: worker.v1.StatusRequest
-	(*WorkerStatus)(nil),   // 5: worker.v1.WorkerStatus
-	(*CancelRequest)(nil),  // 6: worker.v1.CancelRequest
-	(*CancelResponse)(nil), // 7: worker.v1.CancelResponse
-	nil,                    // 8: worker.v1.OutputConfig.MetadataEntry
+	(JobStatus)(0),                 // 0: worker.v1.JobStatus
+	(*JobRequest)(nil),             // 1: worker.v1.JobRequest
+	(*ValidationConfig)(nil),       // 2: worker.v1.ValidationConfig
+	(*RetryPolicy)(nil),            // 3: worker.v1.RetryPolicy
+	(*OutputConfig)(nil),           // 4: worker.v1.OutputConfig
+	(*JobProgress)(nil),            // 5: worker.v1.JobProgress
+	(*OutputFile)(nil),             // 6: worker.v1.OutputFile
+	(*StatusRequest)(nil),          // 7: worker.v1.StatusRequest
+	(*WorkerStatus)(nil),           // 8: worker.v1.WorkerStatus
+	(*HostLoad)(nil),               // 9: worker.v1.HostLoad
+	(*WorkerCapabilities)(nil),     // 10: worker.v1.WorkerCapabilities
+	(*CancelRequest)(nil),          // 11: worker.v1.CancelRequest
+	(*AttachRequest)(nil),          // 12: worker.v1.AttachRequest
+	(*JobLogsRequest)(nil),         // 13: worker.v1.JobLogsRequest
+	(*JobLogLine)(nil),             // 14: worker.v1.JobLogLine
+	(*CancelResponse)(nil),         // 15: worker.v1.CancelResponse
+	(*DryRunResponse)(nil),         // 16: worker.v1.DryRunResponse
+	(*ValidateOutputRequest)(nil),  // 17: worker.v1.ValidateOutputRequest
+	(*ValidateOutputResponse)(nil), // 18: worker.v1.ValidateOutputResponse
+	(*ValidationIssue)(nil),        // 19: worker.v1.ValidationIssue
+	(*ValidatedMediaInfo)(nil),     // 20: worker.v1.ValidatedMediaInfo
+	(*ValidatedVideoStream)(nil),   // 21: worker.v1.ValidatedVideoStream
+	(*ValidatedAudioStream)(nil),   // 22: worker.v1.ValidatedAudioStream
+	nil,                            // 23: worker.v1.JobRequest.OverridesEntry
+	nil,                            // 24: worker.v1.OutputConfig.MetadataEntry
 }
 var file_proto_worker_v1_worker_proto_depIdxs = []int32{
-	2, // 0: worker.v1.JobRequest.output:type_name -> worker.v1.OutputConfig
-	8, // 1: worker.v1.OutputConfig.metadata:type_name -> worker.v1.OutputConfig.MetadataEntry
-	0, // 2: worker.v1.JobProgress.status:type_name -> worker.v1.JobStatus
-	1, // 3: worker.v1.WorkerService.SubmitJob:input_type -> worker.v1.JobRequest
-	4, // 4: worker.v1.WorkerService.GetStatus:input_type -> worker.v1.StatusRequest
-	6, // 5: worker.v1.WorkerService.CancelJob:input_type -> worker.v1.CancelRequest
-	3, // 6: worker.v1.WorkerService.SubmitJob:output_type -> worker.v1.JobProgress
-	5, // 7: worker.v1.WorkerService.GetStatus:output_type -> worker.v1.WorkerStatus
-	7, // 8: worker.v1.WorkerService.CancelJob:output_type -> worker.v1.CancelResponse
-	6, // [6:9] is the sub-list for method output_type
-	3, // [3:6] is the sub-list for method input_type
-	3, // [3:3] is the sub-list for extension type_name
-	3, // [3:3] is the sub-list for extension extendee
-	0, // [0:3] is the sub-list for field type_name
+	4,  // 0: worker.v1.JobRequest.output:type_name -> worker.v1.OutputConfig
+	23, // 1: worker.v1.JobRequest.overrides:type_name -> worker.v1.JobRequest.OverridesEntry
+	3,  // 2: worker.v1.JobRequest.retry:type_name -> worker.v1.RetryPolicy
+	2,  // 3: worker.v1.JobRequest.validation:type_name -> worker.v1.ValidationConfig
+	24, // 4: worker.v1.OutputConfig.metadata:type_name -> worker.v1.OutputConfig.MetadataEntry
+	0,  // 5: worker.v1.JobProgress.status:type_name -> worker.v1.JobStatus
+	6,  // 6: worker.v1.JobProgress.output_files:type_name -> worker.v1.OutputFile
+	10, // 7: worker.v1.WorkerStatus.capabilities:type_name -> worker.v1.WorkerCapabilities
+	9,  // 8: worker.v1.WorkerStatus.host_load:type_name -> worker.v1.HostLoad
+	2,  // 9: worker.v1.ValidateOutputRequest.validation:type_name -> worker.v1.ValidationConfig
+	19, // 10: worker.v1.ValidateOutputResponse.errors:type_name -> worker.v1.ValidationIssue
+	19, // 11: worker.v1.ValidateOutputResponse.warnings:type_name -> worker.v1.ValidationIssue
+	20, // 12: worker.v1.ValidateOutputResponse.media_info:type_name -> worker.v1.ValidatedMediaInfo
+	21, // 13: worker.v1.ValidatedMediaInfo.video_streams:type_name -> worker.v1.ValidatedVideoStream
+	22, // 14: worker.v1.ValidatedMediaInfo.audio_streams:type_name -> worker.v1.ValidatedAudioStream
+	1,  // 15: worker.v1.WorkerService.SubmitJob:input_type -> worker.v1.JobRequest
+	7,  // 16: worker.v1.WorkerService.GetStatus:input_type -> worker.v1.StatusRequest
+	11, // 17: worker.v1.WorkerService.CancelJob:input_type -> worker.v1.CancelRequest
+	12, // 18: worker.v1.WorkerService.AttachJob:input_type -> worker.v1.AttachRequest
+	13, // 19: worker.v1.WorkerService.GetJobLogs:input_type -> worker.v1.JobLogsRequest
+	1,  // 20: worker.v1.WorkerService.DryRun:input_type -> worker.v1.JobRequest
+	17, // 21: worker.v1.WorkerService.ValidateOutput:input_type -> worker.v1.ValidateOutputRequest
+	5,  // 22: worker.v1.WorkerService.SubmitJob:output_type -> worker.v1.JobProgress
+	8,  // 23: worker.v1.WorkerService.GetStatus:output_type -> worker.v1.WorkerStatus
+	15, // 24: worker.v1.WorkerService.CancelJob:output_type -> worker.v1.CancelResponse
+	5,  // 25: worker.v1.WorkerService.AttachJob:output_type -> worker.v1.JobProgress
+	14, // 26: worker.v1.WorkerService.GetJobLogs:output_type -> worker.v1.JobLogLine
+	16, // 27: worker.v1.WorkerService.DryRun:output_type -> worker.v1.DryRunResponse
+	18, // 28: worker.v1.WorkerService.ValidateOutput:output_type -> worker.v1.ValidateOutputResponse
+	22, // [22:29] is the sub-list for method output_type
+	15, // [15:22] is the sub-list for method input_type
+	15, // [15:15] is the sub-list for extension type_name
+	15, // [15:15] is the sub-list for extension extendee
+	0,  // [0:15] is the sub-list for field type_name
 }
 
 func init() { file_proto_worker_v1_worker_proto_init() }
@@ -660,13 +2083,14 @@ func file_proto_worker_v1_worker_proto_init() {
 	if File_proto_worker_v1_worker_proto != nil {
 		return
 	}
+	file_proto_worker_v1_worker_proto_msgTypes[1].OneofWrappers = []any{}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_worker_v1_worker_proto_rawDesc), len(file_proto_worker_v1_worker_proto_rawDesc)),
 			NumEnums:      1,
-			NumMessages:   8,
+			NumMessages:   24,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -20,9 +20,13 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	WorkerService_SubmitJob_FullMethodName = "/worker.v1.WorkerService/SubmitJob"
-	WorkerService_GetStatus_FullMethodName = "/worker.v1.WorkerService/GetStatus"
-	WorkerService_CancelJob_FullMethodName = "/worker.v1.WorkerService/CancelJob"
+	WorkerService_SubmitJob_FullMethodName      = "/worker.v1.WorkerService/SubmitJob"
+	WorkerService_GetStatus_FullMethodName      = "/worker.v1.WorkerService/GetStatus"
+	WorkerService_CancelJob_FullMethodName      = "/worker.v1.WorkerService/CancelJob"
+	WorkerService_AttachJob_FullMethodName      = "/worker.v1.WorkerService/AttachJob"
+	WorkerService_GetJobLogs_FullMethodName     = "/worker.v1.WorkerService/GetJobLogs"
+	WorkerService_DryRun_FullMethodName         = "/worker.v1.WorkerService/DryRun"
+	WorkerService_ValidateOutput_FullMethodName = "/worker.v1.WorkerService/ValidateOutput"
 )
 
 // WorkerServiceClient is the client API for WorkerService service.
@@ -37,6 +41,20 @@ type WorkerServiceClient interface {
 	GetStatus(ctx context.Context, in *StatusRequest, opts ...grpc.CallOption) (*WorkerStatus, error)
 	// CancelJob は実行中のジョブをキャンセルする
 	CancelJob(ctx context.Context, in *CancelRequest, opts ...grpc.CallOption) (*CancelResponse, error)
+	// AttachJob は実行中のジョブの進捗ストリームに再接続する
+	// SubmitJob のストリームが切断された後、猶予期間内に呼び出すとジョブを継続し、最新の進捗から受信できる
+	AttachJob(ctx context.Context, in *AttachRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[JobProgress], error)
+	// GetJobLogs はエンコード中のジョブの ffmpeg の出力（stderr）をストリームで返す
+	// 保持している直近の行を送信した後、エンコードが終了するかストリームが切断されるまで新しい行を送信する
+	// 管理者用のため、Worker に WORKER_ADMIN_TOKEN が設定されていない場合は PermissionDenied、
+	// メタデータの authorization（"Bearer <token>"）が一致しない場合は Unauthenticated を返す
+	GetJobLogs(ctx context.Context, in *JobLogsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[JobLogLine], error)
+	// DryRun はジョブで実行する ffmpeg のコマンドを実行せずに返す（プリセットの確認用）
+	// 実行枠は使用せず、入力の取得・ffprobe の実行・ファイルの作成も行わない
+	DryRun(ctx context.Context, in *JobRequest, opts ...grpc.CallOption) (*DryRunResponse, error)
+	// ValidateOutput はストレージにある既存の出力を再エンコードせずに検証する（移行したアセットの確認など）
+	// 実行枠は使用しない。リモートのセグメントの検証には対応していないため、ローカルストレージ（STORAGE_TYPE=local）以外の Worker では FailedPrecondition を返す
+	ValidateOutput(ctx context.Context, in *ValidateOutputRequest, opts ...grpc.CallOption) (*ValidateOutputResponse, error)
 }
 
 type workerServiceClient struct {
@@ -86,6 +104,64 @@ func (c *workerServiceClient) CancelJob(ctx context.Context, in *CancelRequest,
 	return out, nil
 }
 
+func (c *workerServiceClient) AttachJob(ctx context.Context, in *AttachRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[JobProgress], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &WorkerService_ServiceDesc.Streams[1], WorkerService_AttachJob_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[AttachRequest, JobProgress]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type WorkerService_AttachJobClient = grpc.ServerStreamingClient[JobProgress]
+
+func (c *workerServiceClient) GetJobLogs(ctx context.Context, in *JobLogsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[JobLogLine], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &WorkerService_ServiceDesc.Streams[2], WorkerService_GetJobLogs_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[JobLogsRequest, JobLogLine]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type WorkerService_GetJobLogsClient = grpc.ServerStreamingClient[JobLogLine]
+
+func (c *workerServiceClient) DryRun(ctx context.Context, in *JobRequest, opts ...grpc.CallOption) (*DryRunResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DryRunResponse)
+	err := c.cc.Invoke(ctx, WorkerService_DryRun_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *workerServiceClient) ValidateOutput(ctx context.Context, in *ValidateOutputRequest, opts ...grpc.CallOption) (*ValidateOutputResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ValidateOutputResponse)
+	err := c.cc.Invoke(ctx, WorkerService_ValidateOutput_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // WorkerServiceServer is the server API for WorkerService service.
 // All implementations must embed UnimplementedWorkerServiceServer
 // for forward compatibility.
@@ -98,6 +174,20 @@ type WorkerServiceServer interface {
 	GetStatus(context.Context, *StatusRequest) (*WorkerStatus, error)
 	// CancelJob は実行中のジョブをキャンセルする
 	CancelJob(context.Context, *CancelRequest) (*CancelResponse, error)
+	// AttachJob は実行中のジョブの進捗ストリームに再接続する
+	// SubmitJob のストリームが切断された後、猶予期間内に呼び出すとジョブを継続し、最新の進捗から受信できる
+	AttachJob(*AttachRequest, grpc.ServerStreamingServer[JobProgress]) error
+	// GetJobLogs はエンコード中のジョブの ffmpeg の出力（stderr）をストリームで返す
+	// 保持している直近の行を送信した後、エンコードが終了するかストリームが切断されるまで新しい行を送信する
+	// 管理者用のため、Worker に WORKER_ADMIN_TOKEN が設定されていない場合は PermissionDenied、
+	// メタデータの authorization（"Bearer <token>"）が一致しない場合は Unauthenticated を返す
+	GetJobLogs(*JobLogsRequest, grpc.ServerStreamingServer[JobLogLine]) error
+	// DryRun はジョブで実行する ffmpeg のコマンドを実行せずに返す（プリセットの確認用）
+	// 実行枠は使用せず、入力の取得・ffprobe の実行・ファイルの作成も行わない
+	DryRun(context.Context, *JobRequest) (*DryRunResponse, error)
+	// ValidateOutput はストレージにある既存の出力を再エンコードせずに検証する（移行したアセットの確認など）
+	// 実行枠は使用しない。リモートのセグメントの検証には対応していないため、ローカルストレージ（STORAGE_TYPE=local）以外の Worker では FailedPrecondition を返す
+	ValidateOutput(context.Context, *ValidateOutputRequest) (*ValidateOutputResponse, error)
 	mustEmbedUnimplementedWorkerServiceServer()
 }
 
@@ -117,6 +207,18 @@ func (UnimplementedWorkerServiceServer) GetStatus(context.Context, *StatusReques
 func (UnimplementedWorkerServiceServer) CancelJob(context.Context, *CancelRequest) (*CancelResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method CancelJob not implemented")
 }
+func (UnimplementedWorkerServiceServer) AttachJob(*AttachRequest, grpc.ServerStreamingServer[JobProgress]) error {
+	return status.Error(codes.Unimplemented, "method AttachJob not implemented")
+}
+func (UnimplementedWorkerServiceServer) GetJobLogs(*JobLogsRequest, grpc.ServerStreamingServer[JobLogLine]) error {
+	return status.Error(codes.Unimplemented, "method GetJobLogs not implemented")
+}
+func (UnimplementedWorkerServiceServer) DryRun(context.Context, *JobRequest) (*DryRunResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method DryRun not implemented")
+}
+func (UnimplementedWorkerServiceServer) ValidateOutput(context.Context, *ValidateOutputRequest) (*ValidateOutputResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ValidateOutput not implemented")
+}
 func (UnimplementedWorkerServiceServer) mustEmbedUnimplementedWorkerServiceServer() {}
 func (UnimplementedWorkerServiceServer) testEmbeddedByValue()                       {}
 
@@ -185,6 +287,64 @@ func _WorkerService_CancelJob_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _WorkerService_AttachJob_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(AttachRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(WorkerServiceServer).AttachJob(m, &grpc.GenericServerStream[AttachRequest, JobProgress]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type WorkerService_AttachJobServer = grpc.ServerStreamingServer[JobProgress]
+
+func _WorkerService_GetJobLogs_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(JobLogsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(WorkerServiceServer).GetJobLogs(m, &grpc.GenericServerStream[JobLogsRequest, JobLogLine]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type WorkerService_GetJobLogsServer = grpc.ServerStreamingServer[JobLogLine]
+
+func _WorkerService_DryRun_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(JobRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WorkerServiceServer).DryRun(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: WorkerService_DryRun_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WorkerServiceServer).DryRun(ctx, req.(*JobRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _WorkerService_ValidateOutput_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ValidateOutputRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WorkerServiceServer).ValidateOutput(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: WorkerService_ValidateOutput_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WorkerServiceServer).ValidateOutput(ctx, req.(*ValidateOutputRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // WorkerService_ServiceDesc is the grpc.ServiceDesc for WorkerService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -200,6 +360,14 @@ var WorkerService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "CancelJob",
 			Handler:    _WorkerService_CancelJob_Handler,
 		},
+		{
+			MethodName: "DryRun",
+			Handler:    _WorkerService_DryRun_Handler,
+		},
+		{
+			MethodName: "ValidateOutput",
+			Handler:    _WorkerService_ValidateOutput_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -207,6 +375,16 @@ var WorkerService_ServiceDesc = grpc.ServiceDesc{
 			Handler:       _WorkerService_SubmitJob_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "AttachJob",
+			Handler:       _WorkerService_AttachJob_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "GetJobLogs",
+			Handler:       _WorkerService_GetJobLogs_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "proto/worker/v1/worker.proto",
 }